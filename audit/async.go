@@ -0,0 +1,245 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/minisource/go-common/shutdown"
+	"gorm.io/gorm"
+)
+
+// ErrBufferFull is returned when the async buffer is full and the overflow
+// policy drops the entry
+var ErrBufferFull = errors.New("audit buffer full")
+
+// ErrClosed is returned when logging to a closed async service
+var ErrClosed = errors.New("audit service closed")
+
+// OverflowPolicy decides what happens when the async buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowDrop drops the new entry and returns ErrBufferFull
+	OverflowDrop OverflowPolicy = iota
+	// OverflowBlock blocks the caller until there is room or ctx is done
+	OverflowBlock
+	// OverflowSync writes the entry synchronously, bypassing the buffer
+	OverflowSync
+)
+
+// AsyncConfig configures asynchronous, batched audit writes
+type AsyncConfig struct {
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+	Overflow      OverflowPolicy
+	// OnError is called when a batch could not be written
+	OnError func(err error, entries []*AuditLog)
+}
+
+// DefaultAsyncConfig returns default async configuration
+func DefaultAsyncConfig() AsyncConfig {
+	return AsyncConfig{
+		BufferSize:    1000,
+		BatchSize:     100,
+		FlushInterval: time.Second,
+		Overflow:      OverflowDrop,
+	}
+}
+
+// asyncWriter buffers entries and writes them in batches from a single
+// background goroutine
+type asyncWriter struct {
+	cfg     AsyncConfig
+	write   func(ctx context.Context, entries []*AuditLog) error
+	entries chan *AuditLog
+	flushCh chan chan struct{}
+	done    chan struct{}
+	closeMu sync.RWMutex
+	closed  bool
+}
+
+// NewAsyncService creates an audit service that buffers entries and writes
+// them in batches in the background. Call Close (or RegisterShutdown) to
+// flush pending entries before the process exits.
+func NewAsyncService(db *gorm.DB, cfg AsyncConfig, opts ...Option) *Service {
+	s := NewService(db, opts...)
+	s.async = newAsyncWriter(cfg, s.writeBatch)
+	return s
+}
+
+func newAsyncWriter(cfg AsyncConfig, write func(ctx context.Context, entries []*AuditLog) error) *asyncWriter {
+	defaults := DefaultAsyncConfig()
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaults.BufferSize
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaults.FlushInterval
+	}
+
+	w := &asyncWriter{
+		cfg:     cfg,
+		write:   write,
+		entries: make(chan *AuditLog, cfg.BufferSize),
+		flushCh: make(chan chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// enqueue adds an entry to the buffer according to the overflow policy
+func (w *asyncWriter) enqueue(ctx context.Context, entry *AuditLog) error {
+	w.closeMu.RLock()
+	defer w.closeMu.RUnlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+
+	select {
+	case w.entries <- entry:
+		return nil
+	default:
+	}
+
+	switch w.cfg.Overflow {
+	case OverflowBlock:
+		select {
+		case w.entries <- entry:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	case OverflowSync:
+		return w.write(ctx, []*AuditLog{entry})
+	default:
+		return ErrBufferFull
+	}
+}
+
+// run collects entries into batches and writes them when the batch is full,
+// the flush interval elapses, a flush is requested or the writer is closed
+func (w *asyncWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*AuditLog, 0, w.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.write(context.Background(), batch); err != nil && w.cfg.OnError != nil {
+			w.cfg.OnError(err, batch)
+		}
+		batch = make([]*AuditLog, 0, w.cfg.BatchSize)
+	}
+
+	for {
+		select {
+		case entry, ok := <-w.entries:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= w.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case ack := <-w.flushCh:
+			// Drain whatever is already buffered before acknowledging
+			closed := false
+			for drained := false; !drained; {
+				select {
+				case entry, ok := <-w.entries:
+					if !ok {
+						closed, drained = true, true
+						break
+					}
+					batch = append(batch, entry)
+					if len(batch) >= w.cfg.BatchSize {
+						flush()
+					}
+				default:
+					drained = true
+				}
+			}
+			flush()
+			close(ack)
+			if closed {
+				return
+			}
+		}
+	}
+}
+
+// Flush writes all buffered entries and waits until they are persisted
+func (w *asyncWriter) Flush(ctx context.Context) error {
+	ack := make(chan struct{})
+	select {
+	case w.flushCh <- ack:
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting entries and flushes the remaining ones
+func (w *asyncWriter) Close(ctx context.Context) error {
+	w.closeMu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.entries)
+	}
+	w.closeMu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Flush writes buffered entries when running in async mode
+func (s *Service) Flush(ctx context.Context) error {
+	if s.async == nil {
+		return nil
+	}
+	return s.async.Flush(ctx)
+}
+
+// Close flushes buffered entries, stops the background writer and closes the
+// sinks. It matches shutdown.Hook so it can be registered directly with a
+// shutdown manager.
+func (s *Service) Close(ctx context.Context) error {
+	if s.async != nil {
+		if err := s.async.Close(ctx); err != nil {
+			return err
+		}
+	}
+	return s.sink.Close()
+}
+
+// RegisterShutdown registers Close with the shutdown manager so pending
+// entries are flushed before databases are closed
+func (s *Service) RegisterShutdown(m *shutdown.Manager) {
+	m.AddHook("audit", s.Close, shutdown.InStage(shutdown.StageConsumers))
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchRecorder records the sizes of written batches
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches []int
+}
+
+func (r *batchRecorder) write(ctx context.Context, entries []*AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, len(entries))
+	return nil
+}
+
+func (r *batchRecorder) sizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.batches...)
+}
+
+func TestAsyncWriterBatchesAndFlushes(t *testing.T) {
+	rec := &batchRecorder{}
+	w := newAsyncWriter(AsyncConfig{BatchSize: 3, FlushInterval: time.Hour}, rec.write)
+	ctx := context.Background()
+
+	for i := 0; i < 7; i++ {
+		require.NoError(t, w.enqueue(ctx, &AuditLog{ID: uuid.New()}))
+	}
+	require.NoError(t, w.Flush(ctx))
+	assert.Equal(t, []int{3, 3, 1}, rec.sizes())
+
+	require.NoError(t, w.enqueue(ctx, &AuditLog{ID: uuid.New()}))
+	require.NoError(t, w.Close(ctx))
+	assert.Equal(t, []int{3, 3, 1, 1}, rec.sizes(), "Close flushes pending entries")
+	assert.ErrorIs(t, w.enqueue(ctx, &AuditLog{}), ErrClosed)
+}
+
+func TestAsyncWriterDropsWhenFull(t *testing.T) {
+	writing := make(chan struct{}, 1)
+	release := make(chan struct{})
+	w := newAsyncWriter(AsyncConfig{BufferSize: 1, BatchSize: 1, Overflow: OverflowDrop}, func(ctx context.Context, entries []*AuditLog) error {
+		writing <- struct{}{}
+		<-release
+		return nil
+	})
+	ctx := context.Background()
+
+	require.NoError(t, w.enqueue(ctx, &AuditLog{}))
+	<-writing // the writer is busy with the first entry
+	require.NoError(t, w.enqueue(ctx, &AuditLog{}), "fills the buffer")
+	assert.ErrorIs(t, w.enqueue(ctx, &AuditLog{}), ErrBufferFull)
+
+	close(release)
+	require.NoError(t, w.Close(ctx))
+}
+
+func TestAsyncWriterFlushRacingClose(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		var mu sync.Mutex
+		var written []*AuditLog
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		w := newAsyncWriter(AsyncConfig{BatchSize: 1, FlushInterval: time.Hour}, func(ctx context.Context, entries []*AuditLog) error {
+			select {
+			case started <- struct{}{}:
+				<-release
+			default:
+			}
+			mu.Lock()
+			written = append(written, entries...)
+			mu.Unlock()
+			return nil
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+
+		require.NoError(t, w.enqueue(ctx, &AuditLog{ID: uuid.New()}))
+		<-started // the writer is busy, so the flush and the close queue up
+		require.NoError(t, w.enqueue(ctx, &AuditLog{ID: uuid.New()}))
+		flushed := make(chan error, 1)
+		closed := make(chan error, 1)
+		go func() { flushed <- w.Flush(ctx) }()
+		go func() { closed <- w.Close(ctx) }()
+		time.Sleep(time.Millisecond)
+		close(release)
+
+		require.NoError(t, <-flushed)
+		require.NoError(t, <-closed)
+		cancel()
+		mu.Lock()
+		assert.Len(t, written, 2)
+		for _, e := range written {
+			assert.NotNil(t, e)
+		}
+		mu.Unlock()
+	}
+}
@@ -2,7 +2,6 @@ package audit
 
 import (
 	"context"
-	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -43,6 +42,9 @@ type AuditLog struct {
 	UserAgent  string                 `json:"user_agent,omitempty" gorm:"type:text"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty" gorm:"type:jsonb"`
 	CreatedAt  time.Time              `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP;index:idx_audit_created"`
+	// PrevHash and Hash are set when hash chaining is enabled
+	PrevHash string `json:"prev_hash,omitempty" gorm:"size:64"`
+	Hash     string `json:"hash,omitempty" gorm:"size:64"`
 }
 
 // TableName overrides the table name
@@ -68,16 +70,47 @@ type Filter struct {
 	EndDate    *time.Time
 	Limit      int
 	Offset     int
+	// Search matches free text anywhere in metadata
+	Search string
+	// SortBy is one of created_at, action, entity_type, user_id (default created_at)
+	SortBy string
+	// SortOrder is asc or desc (default desc)
+	SortOrder string
+	// Cursor continues a keyset-paginated listing (created_at sort only)
+	Cursor string
 }
 
 // Service implements audit logging
 type Service struct {
-	db *gorm.DB
+	db    *gorm.DB
+	sink  Sink
+	async *asyncWriter
+	chain *hashChain
 }
 
-// NewService creates a new audit service
-func NewService(db *gorm.DB) *Service {
-	return &Service{db: db}
+// Option configures the audit service
+type Option func(*Service)
+
+// WithSinks replaces the default database sink. Entries are written to every
+// sink; include NewGormSink(db) to keep writing to the database as well.
+func WithSinks(sinks ...Sink) Option {
+	return func(s *Service) {
+		if len(sinks) == 1 {
+			s.sink = sinks[0]
+			return
+		}
+		s.sink = NewFanoutSink(sinks...)
+	}
+}
+
+// NewService creates a new audit service. Entries are written to the
+// database unless other sinks are configured; Query always reads from db.
+func NewService(db *gorm.DB, opts ...Option) *Service {
+	s := &Service{db: db, sink: NewGormSink(db)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Log writes an audit log entry
@@ -88,7 +121,24 @@ func (s *Service) Log(ctx context.Context, entry *AuditLog) error {
 	if entry.CreatedAt.IsZero() {
 		entry.CreatedAt = time.Now()
 	}
-	return s.db.WithContext(ctx).Create(entry).Error
+	return s.write(ctx, entry)
+}
+
+// write hands the entry to the async buffer or directly to the sink
+func (s *Service) write(ctx context.Context, entry *AuditLog) error {
+	if s.async != nil {
+		return s.async.enqueue(ctx, entry)
+	}
+	return s.writeBatch(ctx, []*AuditLog{entry})
+}
+
+// writeBatch writes entries to the sink, linking them into the hash chain
+// first when chaining is enabled
+func (s *Service) writeBatch(ctx context.Context, entries []*AuditLog) error {
+	if s.chain == nil {
+		return s.sink.Write(ctx, entries)
+	}
+	return s.chain.link(ctx, entries, func() error { return s.sink.Write(ctx, entries) })
 }
 
 // LogAction is a convenience method for logging actions
@@ -106,33 +156,7 @@ func (s *Service) LogAction(ctx context.Context, tenantID, userID uuid.UUID, act
 
 // Query retrieves audit logs based on filter
 func (s *Service) Query(ctx context.Context, filter *Filter) ([]*AuditLog, error) {
-	query := s.db.WithContext(ctx).Model(&AuditLog{})
-
-	query = query.Where("tenant_id = ?", filter.TenantID)
-
-	if filter.UserID != nil {
-		query = query.Where("user_id = ?", filter.UserID)
-	}
-
-	if filter.Action != "" {
-		query = query.Where("action = ?", filter.Action)
-	}
-
-	if filter.EntityType != "" {
-		query = query.Where("entity_type = ?", filter.EntityType)
-	}
-
-	if filter.EntityID != nil {
-		query = query.Where("entity_id = ?", filter.EntityID)
-	}
-
-	if filter.StartDate != nil {
-		query = query.Where("created_at >= ?", filter.StartDate)
-	}
-
-	if filter.EndDate != nil {
-		query = query.Where("created_at <= ?", filter.EndDate)
-	}
+	query := applyFilter(s.db.WithContext(ctx).Model(&AuditLog{}), filter)
 
 	if filter.Limit > 0 {
 		query = query.Limit(filter.Limit)
@@ -145,38 +169,22 @@ func (s *Service) Query(ctx context.Context, filter *Filter) ([]*AuditLog, error
 	}
 
 	var logs []*AuditLog
-	err := query.Order("created_at DESC").Find(&logs).Error
+	err := query.Order(orderClause(filter)).Find(&logs).Error
 	return logs, err
 }
 
-// CompareChanges creates a change map for auditing
-func CompareChanges(old, new interface{}) (map[string]interface{}, error) {
-	changes := make(map[string]interface{})
-
-	oldJSON, err := json.Marshal(old)
-	if err != nil {
-		return nil, err
-	}
-
-	newJSON, err := json.Marshal(new)
+// CompareChanges creates a change map for auditing. Each key is the path of a
+// changed field (e.g. "address.city", "items[2].qty") and each value is a
+// Change holding the old and new values.
+func CompareChanges(old, new interface{}, opts ...DiffOption) (map[string]interface{}, error) {
+	diff, err := Diff(old, new, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	var oldMap, newMap map[string]interface{}
-	if err := json.Unmarshal(oldJSON, &oldMap); err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(newJSON, &newMap); err != nil {
-		return nil, err
+	changes := make(map[string]interface{}, len(diff))
+	for path, change := range diff {
+		changes[path] = change
 	}
-
-	for key, newValue := range newMap {
-		oldValue, exists := oldMap[key]
-		if !exists || oldValue != newValue {
-			changes[key] = newValue
-		}
-	}
-
 	return changes, nil
 }
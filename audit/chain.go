@@ -0,0 +1,268 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// hashChain links the entries of each tenant by storing the hash of the
+// previous entry alongside the hash of the current one. The last hash per
+// tenant is kept in memory, so only one writer per tenant should have
+// chaining enabled.
+type hashChain struct {
+	db      *gorm.DB
+	mu      sync.Mutex
+	tenants map[uuid.UUID]*chainHead
+}
+
+// chainHead is the latest chained entry of a tenant
+type chainHead struct {
+	mu     sync.Mutex
+	loaded bool
+	chainLink
+}
+
+// chainLink is the hash and timestamp a new entry is chained to
+type chainLink struct {
+	hash      string
+	createdAt time.Time
+}
+
+// VerifyResult describes the outcome of a chain verification
+type VerifyResult struct {
+	Valid    bool       `json:"valid"`
+	Checked  int        `json:"checked"`
+	Skipped  int        `json:"skipped"`
+	BrokenAt *uuid.UUID `json:"broken_at,omitempty"`
+	Reason   string     `json:"reason,omitempty"`
+}
+
+// WithHashChain enables tamper-evident hash chaining. Each entry stores the
+// SHA-256 of its payload plus the previous entry's hash for the same tenant.
+func WithHashChain() Option {
+	return func(s *Service) {
+		s.chain = &hashChain{db: s.db, tenants: make(map[uuid.UUID]*chainHead)}
+	}
+}
+
+// head returns the chain head of a tenant, creating it if needed
+func (c *hashChain) head(tenantID uuid.UUID) *chainHead {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.tenants[tenantID]
+	if !ok {
+		h = &chainHead{}
+		c.tenants[tenantID] = h
+	}
+	return h
+}
+
+// link chains entries, in write order, to their tenants' chains and calls
+// write. The heads of the tenants involved stay locked during write and only
+// advance when it succeeds, so entries that are never persisted leave no gap.
+// Async services link each batch right before writing it for the same reason.
+func (c *hashChain) link(ctx context.Context, entries []*AuditLog, write func() error) error {
+	heads := make(map[uuid.UUID]*chainHead)
+	var tenants []uuid.UUID
+	for _, entry := range entries {
+		if _, ok := heads[entry.TenantID]; !ok {
+			heads[entry.TenantID] = c.head(entry.TenantID)
+			tenants = append(tenants, entry.TenantID)
+		}
+	}
+	// Lock in a fixed order so concurrent batches cannot deadlock
+	sort.Slice(tenants, func(i, j int) bool {
+		return bytes.Compare(tenants[i][:], tenants[j][:]) < 0
+	})
+
+	next := make(map[uuid.UUID]chainLink, len(tenants))
+	for _, tenantID := range tenants {
+		h := heads[tenantID]
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if err := c.load(ctx, tenantID, h); err != nil {
+			return err
+		}
+		next[tenantID] = h.chainLink
+	}
+
+	for _, entry := range entries {
+		head := next[entry.TenantID]
+
+		// Postgres stores microseconds; keep timestamps strictly increasing
+		// so the verification order matches the write order
+		entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+		if !entry.CreatedAt.After(head.createdAt) {
+			entry.CreatedAt = head.createdAt.Add(time.Microsecond)
+		}
+
+		entry.PrevHash = head.hash
+		hash, err := computeHash(entry)
+		if err != nil {
+			return err
+		}
+		entry.Hash = hash
+		next[entry.TenantID] = chainLink{hash: entry.Hash, createdAt: entry.CreatedAt}
+	}
+
+	if err := write(); err != nil {
+		return err
+	}
+	for tenantID, link := range next {
+		heads[tenantID].chainLink = link
+	}
+	return nil
+}
+
+// load reads the latest chained entry of a tenant into h once; the caller
+// holds h.mu
+func (c *hashChain) load(ctx context.Context, tenantID uuid.UUID, h *chainHead) error {
+	if h.loaded {
+		return nil
+	}
+	var last AuditLog
+	err := c.db.WithContext(ctx).
+		Where("tenant_id = ? AND hash <> ''", tenantID).
+		Order("created_at DESC, id DESC").
+		Limit(1).
+		Take(&last).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	h.hash = last.Hash
+	h.createdAt = last.CreatedAt
+	h.loaded = true
+	return nil
+}
+
+// computeHash hashes the entry payload together with its previous hash
+func computeHash(entry *AuditLog) (string, error) {
+	payload := struct {
+		ID         uuid.UUID              `json:"id"`
+		TenantID   uuid.UUID              `json:"tenant_id"`
+		UserID     *uuid.UUID             `json:"user_id"`
+		Action     string                 `json:"action"`
+		EntityType string                 `json:"entity_type"`
+		EntityID   *uuid.UUID             `json:"entity_id"`
+		OldValues  map[string]interface{} `json:"old_values"`
+		NewValues  map[string]interface{} `json:"new_values"`
+		IPAddress  string                 `json:"ip_address"`
+		UserAgent  string                 `json:"user_agent"`
+		Metadata   map[string]interface{} `json:"metadata"`
+		CreatedAt  string                 `json:"created_at"`
+		PrevHash   string                 `json:"prev_hash"`
+	}{
+		ID:         entry.ID,
+		TenantID:   entry.TenantID,
+		UserID:     entry.UserID,
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		OldValues:  normalizeJSON(entry.OldValues),
+		NewValues:  normalizeJSON(entry.NewValues),
+		IPAddress:  entry.IPAddress,
+		UserAgent:  entry.UserAgent,
+		Metadata:   normalizeJSON(entry.Metadata),
+		CreatedAt:  entry.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+		PrevHash:   entry.PrevHash,
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// normalizeJSON round-trips a map through JSON so values hash the same
+// before and after being stored as jsonb
+func normalizeJSON(m map[string]interface{}) map[string]interface{} {
+	if len(m) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return m
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return m
+	}
+	return out
+}
+
+// Verify recomputes the hash chain of a tenant between from and to (zero
+// values leave the range open). The first entry of a range that doesn't
+// start at the beginning of the log is trusted as the anchor. Entries
+// written before chaining was enabled are skipped.
+func (s *Service) Verify(ctx context.Context, tenantID uuid.UUID, from, to time.Time) (*VerifyResult, error) {
+	query := s.db.WithContext(ctx).Model(&AuditLog{}).Where("tenant_id = ?", tenantID)
+	if !from.IsZero() {
+		query = query.Where("created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("created_at <= ?", to)
+	}
+
+	result := &VerifyResult{Valid: true}
+	var prev string
+	started := false
+
+	err := findInBatches(query, 500, func(batch []*AuditLog) error {
+		for _, entry := range batch {
+			if !started {
+				if entry.Hash == "" {
+					result.Skipped++
+					continue
+				}
+				started = true
+				if from.IsZero() && entry.PrevHash != "" {
+					result.fail(entry, "first entry references a missing predecessor")
+					return errVerifyStop
+				}
+				prev = entry.PrevHash
+			}
+
+			result.Checked++
+			if entry.PrevHash != prev {
+				result.fail(entry, "previous hash mismatch")
+				return errVerifyStop
+			}
+			hash, err := computeHash(entry)
+			if err != nil {
+				return err
+			}
+			if hash != entry.Hash {
+				result.fail(entry, "hash mismatch")
+				return errVerifyStop
+			}
+			prev = entry.Hash
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errVerifyStop) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// errVerifyStop ends batch iteration once the chain is broken
+var errVerifyStop = errors.New("verification stopped")
+
+func (r *VerifyResult) fail(entry *AuditLog, reason string) {
+	id := entry.ID
+	r.Valid = false
+	r.BrokenAt = &id
+	r.Reason = reason
+}
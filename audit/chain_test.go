@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// columnSink writes entries without the JSON columns, which the test
+// database stores as plain text
+type columnSink struct {
+	db *gorm.DB
+}
+
+func (s columnSink) Name() string { return "test" }
+
+func (s columnSink) Write(ctx context.Context, entries []*AuditLog) error {
+	return s.db.WithContext(ctx).Omit("OldValues", "NewValues", "Metadata").Create(entries).Error
+}
+
+func (s columnSink) Close() error { return nil }
+
+func TestVerifyReadsEveryBatch(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewService(db, WithHashChain(), WithSinks(columnSink{db: db}))
+	tenantID := uuid.New()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var ids []uuid.UUID
+	for i := 0; i < 1200; i++ {
+		entry := &AuditLog{TenantID: tenantID, Action: ActionCreate, EntityType: EntityUser, CreatedAt: start}
+		require.NoError(t, svc.Log(context.Background(), entry))
+		ids = append(ids, entry.ID)
+	}
+
+	result, err := svc.Verify(context.Background(), tenantID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.True(t, result.Valid, result.Reason)
+	assert.Equal(t, 1200, result.Checked)
+
+	// Tampering with an entry past the first batch is detected
+	require.NoError(t, db.Model(&AuditLog{}).Where("id = ?", ids[900]).Update("action", ActionDelete).Error)
+	result, err = svc.Verify(context.Background(), tenantID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, ids[900], *result.BrokenAt)
+}
+
+// failingColumnSink fails the writes whose number is listed in fail
+type failingColumnSink struct {
+	columnSink
+	calls int
+	fail  map[int]bool
+}
+
+func (s *failingColumnSink) Write(ctx context.Context, entries []*AuditLog) error {
+	s.calls++
+	if s.fail[s.calls] {
+		return errors.New("sink unavailable")
+	}
+	return s.columnSink.Write(ctx, entries)
+}
+
+func TestAsyncChainSurvivesFailedBatch(t *testing.T) {
+	db := newTestDB(t)
+	sink := &failingColumnSink{columnSink: columnSink{db: db}, fail: map[int]bool{2: true}}
+	var failed int
+	cfg := AsyncConfig{BatchSize: 10, FlushInterval: time.Hour, OnError: func(err error, entries []*AuditLog) {
+		failed += len(entries)
+	}}
+	svc := NewAsyncService(db, cfg, WithHashChain(), WithSinks(sink))
+	defer svc.Close(context.Background())
+	tenantID := uuid.New()
+
+	for batch := 0; batch < 3; batch++ {
+		for i := 0; i < 2; i++ {
+			require.NoError(t, svc.Log(context.Background(), &AuditLog{TenantID: tenantID, Action: ActionCreate, EntityType: EntityUser}))
+		}
+		require.NoError(t, svc.Flush(context.Background()))
+	}
+
+	assert.Equal(t, 2, failed)
+	result, err := svc.Verify(context.Background(), tenantID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.True(t, result.Valid, result.Reason)
+	assert.Equal(t, 4, result.Checked)
+}
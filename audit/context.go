@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	appctx "github.com/minisource/go-common/context"
+)
+
+// ErrNoTenant is returned by ContextLogger when the context carries no
+// tenant; such entries would be unreachable by tenant queries
+var ErrNoTenant = errors.New("audit: no tenant in context")
+
+type loggerKey struct{}
+
+var defaultLogger atomic.Value // holds Logger
+
+// SetDefault sets the logger used by FromContext when the context carries none
+func SetDefault(logger Logger) {
+	defaultLogger.Store(&logger)
+}
+
+// NewContext returns a context carrying the audit logger
+func NewContext(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// ContextLogger logs audit entries enriched from request context values
+type ContextLogger struct {
+	ctx    context.Context
+	logger Logger
+}
+
+// FromContext returns a logger bound to ctx. Tenant, user, IP, user agent,
+// request ID and trace ID are taken from the context package. The logger
+// comes from NewContext, then SetDefault; without either it is a no-op.
+// Logging fails with ErrNoTenant when ctx has no tenant.
+func FromContext(ctx context.Context) *ContextLogger {
+	logger, _ := ctx.Value(loggerKey{}).(Logger)
+	if logger == nil {
+		if l, ok := defaultLogger.Load().(*Logger); ok {
+			logger = *l
+		}
+	}
+	if logger == nil {
+		logger = NewNoopLogger()
+	}
+	return &ContextLogger{ctx: ctx, logger: logger}
+}
+
+// Log records an action on an entity
+func (l *ContextLogger) Log(action, entityType string, entityID *uuid.UUID, changes map[string]interface{}) error {
+	entry := l.Entry(action, entityType, entityID)
+	entry.NewValues = changes
+	return l.write(entry)
+}
+
+// LogChange records an action with both old and new values
+func (l *ContextLogger) LogChange(action, entityType string, entityID *uuid.UUID, oldValues, newValues map[string]interface{}) error {
+	entry := l.Entry(action, entityType, entityID)
+	entry.OldValues = oldValues
+	entry.NewValues = newValues
+	return l.write(entry)
+}
+
+func (l *ContextLogger) write(entry *AuditLog) error {
+	if entry.TenantID == uuid.Nil {
+		return ErrNoTenant
+	}
+	return l.logger.Log(l.ctx, entry)
+}
+
+// Entry builds an enriched entry without writing it
+func (l *ContextLogger) Entry(action, entityType string, entityID *uuid.UUID) *AuditLog {
+	entry := &AuditLog{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		IPAddress:  appctx.GetClientIP(l.ctx),
+		UserAgent:  appctx.GetUserAgent(l.ctx),
+	}
+
+	if tenantID, ok := appctx.GetTenantID(l.ctx); ok {
+		entry.TenantID = tenantID
+	}
+	if userID, ok := appctx.GetUserID(l.ctx); ok && userID != uuid.Nil {
+		entry.UserID = &userID
+	}
+
+	metadata := make(map[string]interface{})
+	if requestID, ok := appctx.GetRequestID(l.ctx); ok && requestID != "" {
+		metadata["request_id"] = requestID
+	}
+	if traceID, ok := appctx.GetTraceID(l.ctx); ok && traceID != "" {
+		metadata["trace_id"] = traceID
+	}
+	if sessionID, ok := appctx.GetSessionID(l.ctx); ok && sessionID != "" {
+		metadata["session_id"] = sessionID
+	}
+	if len(metadata) > 0 {
+		entry.Metadata = metadata
+	}
+
+	return entry
+}
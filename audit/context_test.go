@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	appctx "github.com/minisource/go-common/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryLogger keeps logged entries in memory
+type memoryLogger struct {
+	NoopLogger
+	entries []*AuditLog
+}
+
+func (l *memoryLogger) Log(ctx context.Context, entry *AuditLog) error {
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+func TestContextLoggerEnrichesEntries(t *testing.T) {
+	logger := &memoryLogger{}
+	tenantID, userID := uuid.New(), uuid.New()
+	ctx := NewContext(context.Background(), logger)
+	ctx = appctx.WithTenantID(ctx, tenantID)
+	ctx = appctx.WithUserID(ctx, userID)
+	ctx = appctx.WithRequestID(ctx, "req-1")
+
+	require.NoError(t, FromContext(ctx).Log(ActionCreate, EntityUser, nil, map[string]interface{}{"name": "ali"}))
+	require.Len(t, logger.entries, 1)
+	entry := logger.entries[0]
+	assert.Equal(t, tenantID, entry.TenantID)
+	assert.Equal(t, userID, *entry.UserID)
+	assert.Equal(t, "req-1", entry.Metadata["request_id"])
+}
+
+func TestContextLoggerRequiresTenant(t *testing.T) {
+	logger := &memoryLogger{}
+	ctx := NewContext(context.Background(), logger)
+
+	assert.ErrorIs(t, FromContext(ctx).Log(ActionCreate, EntityUser, nil, nil), ErrNoTenant)
+	assert.ErrorIs(t, FromContext(ctx).LogChange(ActionUpdate, EntityUser, nil, nil, nil), ErrNoTenant)
+	assert.Empty(t, logger.entries)
+}
@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+)
+
+// DefaultExcludedFields are never included in diffs. Names match ignoring
+// case, "_" and "-", so "refresh_token" also excludes "refreshToken"
+var DefaultExcludedFields = []string{
+	"password", "password_hash", "secret", "client_secret", "token",
+	"access_token", "refresh_token", "api_key", "private_key",
+}
+
+// Change holds the old and new value of a changed field
+type Change struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// DiffOption configures Diff
+type DiffOption func(*diffOptions)
+
+type diffOptions struct {
+	excluded fieldMatcher
+}
+
+// ExcludeFields omits fields from the diff. A name matches either a full path
+// ("profile.ssn") or the last segment of any path ("ssn"), ignoring case,
+// "_" and "-".
+func ExcludeFields(fields ...string) DiffOption {
+	return func(o *diffOptions) {
+		o.excluded.add(fields...)
+	}
+}
+
+// Diff recursively compares two values through their JSON representation and
+// returns the changed leaf paths. Nested objects are walked field by field,
+// slices element by element; added or removed entries have a nil side.
+// Struct fields whose Go name is excluded are skipped whatever their json tag.
+func Diff(old, new interface{}, opts ...DiffOption) (map[string]Change, error) {
+	o := &diffOptions{excluded: newFieldMatcher()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.excluded.addStructFields(old)
+	o.excluded.addStructFields(new)
+
+	oldVal, err := normalize(old)
+	if err != nil {
+		return nil, err
+	}
+	newVal, err := normalize(new)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	o.walk("", "", oldVal, newVal, changes)
+	return changes, nil
+}
+
+// SplitChanges separates a diff into old and new value maps keyed by path,
+// the shape stored in AuditLog.OldValues and AuditLog.NewValues
+func SplitChanges(changes map[string]Change) (oldValues, newValues map[string]interface{}) {
+	oldValues = make(map[string]interface{}, len(changes))
+	newValues = make(map[string]interface{}, len(changes))
+	for path, c := range changes {
+		oldValues[path] = c.Old
+		newValues[path] = c.New
+	}
+	return oldValues, newValues
+}
+
+// walk compares two normalized values and records differences under path
+func (o *diffOptions) walk(path, name string, old, new interface{}, changes map[string]Change) {
+	if path != "" && o.excluded.match(path, name) {
+		return
+	}
+
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := make(map[string]struct{}, len(oldMap)+len(newMap))
+		for k := range oldMap {
+			keys[k] = struct{}{}
+		}
+		for k := range newMap {
+			keys[k] = struct{}{}
+		}
+		for k := range keys {
+			o.walk(joinPath(path, k), k, oldMap[k], newMap[k], changes)
+		}
+		return
+	}
+
+	oldSlice, oldIsSlice := old.([]interface{})
+	newSlice, newIsSlice := new.([]interface{})
+	if oldIsSlice && newIsSlice {
+		n := len(oldSlice)
+		if len(newSlice) > n {
+			n = len(newSlice)
+		}
+		for i := 0; i < n; i++ {
+			var ov, nv interface{}
+			if i < len(oldSlice) {
+				ov = oldSlice[i]
+			}
+			if i < len(newSlice) {
+				nv = newSlice[i]
+			}
+			o.walk(path+"["+strconv.Itoa(i)+"]", name, ov, nv, changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		key := path
+		if key == "" {
+			key = "$"
+		}
+		changes[key] = Change{Old: old, New: new}
+	}
+}
+
+// normalize converts a value to its generic JSON form so that struct fields,
+// maps and numbers of different Go types compare consistently
+func normalize(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type diffAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip"`
+}
+
+type diffUser struct {
+	Name     string            `json:"name"`
+	Age      int               `json:"age"`
+	Password string            `json:"password"`
+	Address  diffAddress       `json:"address"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]string `json:"labels"`
+}
+
+func TestDiffNestedChanges(t *testing.T) {
+	old := diffUser{Name: "Ali", Age: 30, Address: diffAddress{City: "Tehran", Zip: "1"}, Tags: []string{"a"}, Labels: map[string]string{"x": "1"}}
+	new := diffUser{Name: "Ali", Age: 31, Address: diffAddress{City: "Shiraz", Zip: "1"}, Tags: []string{"a", "b"}, Labels: map[string]string{"y": "2"}}
+
+	changes, err := Diff(old, new)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Change{Old: float64(30), New: float64(31)}, changes["age"])
+	assert.Equal(t, Change{Old: "Tehran", New: "Shiraz"}, changes["address.city"])
+	assert.Equal(t, Change{Old: nil, New: "b"}, changes["tags[1]"])
+	assert.Equal(t, Change{Old: "1", New: nil}, changes["labels.x"])
+	assert.Equal(t, Change{Old: nil, New: "2"}, changes["labels.y"])
+	assert.NotContains(t, changes, "name")
+	assert.NotContains(t, changes, "address.zip")
+}
+
+func TestDiffExcludesFields(t *testing.T) {
+	old := diffUser{Name: "Ali", Password: "old"}
+	new := diffUser{Name: "Reza", Password: "new"}
+
+	changes, err := Diff(old, new, ExcludeFields("name"))
+
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+type diffCredentials struct {
+	Email        string `json:"email"`
+	PasswordHash string `json:"passwordHash"`
+	RefreshToken string `json:"refreshToken"`
+	APIKey       string `json:"x-api-key"`
+	Secret       string `json:"s"`
+}
+
+func TestDiffExcludesCamelCaseAndGoNamedSecrets(t *testing.T) {
+	old := diffCredentials{Email: "a@b.c", PasswordHash: "h1", RefreshToken: "r1", APIKey: "k1", Secret: "s1"}
+	new := diffCredentials{Email: "x@b.c", PasswordHash: "h2", RefreshToken: "r2", APIKey: "k2", Secret: "s2"}
+
+	changes, err := Diff(old, new)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Change{"email": {Old: "a@b.c", New: "x@b.c"}}, changes)
+}
+
+func TestDiffExcludeFieldsIgnoresCaseAndSeparators(t *testing.T) {
+	old := map[string]interface{}{"nationalCode": "1", "profile": map[string]interface{}{"home_phone": "1"}}
+	new := map[string]interface{}{"nationalCode": "2", "profile": map[string]interface{}{"home_phone": "2"}}
+
+	changes, err := Diff(old, new, ExcludeFields("national_code", "Profile.HomePhone"))
+
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestCompareChangesDoesNotPanicOnMaps(t *testing.T) {
+	old := map[string]interface{}{"meta": map[string]interface{}{"a": 1}}
+	new := map[string]interface{}{"meta": map[string]interface{}{"a": 2}}
+
+	changes, err := CompareChanges(old, new)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Change{Old: float64(1), New: float64(2)}, changes["meta.a"])
+}
+
+func TestSplitChanges(t *testing.T) {
+	oldValues, newValues := SplitChanges(map[string]Change{"age": {Old: 1, New: 2}})
+
+	assert.Equal(t, 1, oldValues["age"])
+	assert.Equal(t, 2, newValues["age"])
+}
@@ -0,0 +1,297 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	appctx "github.com/minisource/go-common/context"
+	"github.com/minisource/go-common/response"
+	"gorm.io/gorm"
+)
+
+// ExportFormat is the output format of an export
+type ExportFormat string
+
+const (
+	FormatCSV    ExportFormat = "csv"
+	FormatNDJSON ExportFormat = "ndjson"
+)
+
+var (
+	// ErrInvalidRange is returned when the export time range is missing,
+	// inverted or longer than allowed
+	ErrInvalidRange = errors.New("invalid export time range")
+	// ErrUnknownColumn is returned for a column that can't be exported
+	ErrUnknownColumn = errors.New("unknown export column")
+	// ErrUnknownFormat is returned for an unsupported export format
+	ErrUnknownFormat = errors.New("unknown export format")
+)
+
+// exportColumns maps column names to value extractors
+var exportColumns = map[string]func(*AuditLog) interface{}{
+	"id":          func(l *AuditLog) interface{} { return l.ID },
+	"tenant_id":   func(l *AuditLog) interface{} { return l.TenantID },
+	"user_id":     func(l *AuditLog) interface{} { return l.UserID },
+	"action":      func(l *AuditLog) interface{} { return l.Action },
+	"entity_type": func(l *AuditLog) interface{} { return l.EntityType },
+	"entity_id":   func(l *AuditLog) interface{} { return l.EntityID },
+	"old_values":  func(l *AuditLog) interface{} { return l.OldValues },
+	"new_values":  func(l *AuditLog) interface{} { return l.NewValues },
+	"ip_address":  func(l *AuditLog) interface{} { return l.IPAddress },
+	"user_agent":  func(l *AuditLog) interface{} { return l.UserAgent },
+	"metadata":    func(l *AuditLog) interface{} { return l.Metadata },
+	"created_at":  func(l *AuditLog) interface{} { return l.CreatedAt },
+	"prev_hash":   func(l *AuditLog) interface{} { return l.PrevHash },
+	"hash":        func(l *AuditLog) interface{} { return l.Hash },
+}
+
+// DefaultExportColumns are exported when no columns are selected
+var DefaultExportColumns = []string{
+	"id", "created_at", "tenant_id", "user_id", "action", "entity_type",
+	"entity_id", "ip_address", "old_values", "new_values", "metadata",
+}
+
+// ExportConfig configures the exporter
+type ExportConfig struct {
+	// MaxRange is the longest time range per export
+	MaxRange time.Duration
+	// BatchSize is the number of rows read from the database at a time
+	BatchSize int
+}
+
+// DefaultExportConfig returns default export configuration
+func DefaultExportConfig() ExportConfig {
+	return ExportConfig{
+		MaxRange:  92 * 24 * time.Hour,
+		BatchSize: 500,
+	}
+}
+
+// ExportRequest describes one export
+type ExportRequest struct {
+	Format  ExportFormat
+	Columns []string
+	// Filter must set StartDate and EndDate; Limit, Offset and Cursor are ignored
+	Filter Filter
+}
+
+// Exporter streams filtered audit logs as CSV or NDJSON
+type Exporter struct {
+	db     *gorm.DB
+	config ExportConfig
+}
+
+// NewExporter creates an exporter reading from the service's database
+func NewExporter(s *Service, config ExportConfig) *Exporter {
+	defaults := DefaultExportConfig()
+	if config.MaxRange <= 0 {
+		config.MaxRange = defaults.MaxRange
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	return &Exporter{db: s.db, config: config}
+}
+
+// Validate checks the request and fills in default columns
+func (e *Exporter) Validate(req *ExportRequest) error {
+	switch req.Format {
+	case FormatCSV, FormatNDJSON:
+	case "":
+		req.Format = FormatCSV
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, req.Format)
+	}
+
+	f := req.Filter
+	if f.StartDate == nil || f.EndDate == nil || f.EndDate.Before(*f.StartDate) {
+		return ErrInvalidRange
+	}
+	if f.EndDate.Sub(*f.StartDate) > e.config.MaxRange {
+		return fmt.Errorf("%w: exceeds %s", ErrInvalidRange, e.config.MaxRange)
+	}
+
+	if len(req.Columns) == 0 {
+		req.Columns = DefaultExportColumns
+	}
+	for _, col := range req.Columns {
+		if _, ok := exportColumns[col]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownColumn, col)
+		}
+	}
+	return nil
+}
+
+// Export writes the matching logs to w in created_at order and returns the
+// number of rows written
+func (e *Exporter) Export(ctx context.Context, w io.Writer, req ExportRequest) (int, error) {
+	if err := e.Validate(&req); err != nil {
+		return 0, err
+	}
+
+	var write func(*AuditLog) error
+	var flush func() error
+	switch req.Format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(req.Columns); err != nil {
+			return 0, err
+		}
+		write = func(l *AuditLog) error { return cw.Write(csvRow(l, req.Columns)) }
+		flush = func() error { cw.Flush(); return cw.Error() }
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		write = func(l *AuditLog) error { return enc.Encode(jsonRow(l, req.Columns)) }
+		flush = func() error { return nil }
+	}
+
+	filter := req.Filter
+	filter.SortBy, filter.SortOrder = "created_at", "asc"
+
+	count := 0
+	query := applyFilter(e.db.WithContext(ctx).Model(&AuditLog{}), &filter)
+	err := findInBatches(query, e.config.BatchSize, func(batch []*AuditLog) error {
+		for _, l := range batch {
+			if err := write(l); err != nil {
+				return err
+			}
+			count++
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		// Push each batch to the client when streaming
+		if f, ok := w.(interface{ Flush() error }); ok {
+			return f.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, flush()
+}
+
+// Handler returns a Fiber handler streaming an export for the caller's
+// tenant. Query parameters: format (csv|ndjson), columns (comma separated),
+// from and to (RFC3339), action, entity_type, user_id.
+func (e *Exporter) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tenantID, ok := appctx.GetTenantIDFromFiber(c)
+		if !ok {
+			return response.BadRequest(c, "TENANT_REQUIRED", "tenant is required")
+		}
+
+		req, err := parseExportRequest(c, tenantID)
+		if err == nil {
+			err = e.Validate(&req)
+		}
+		if err != nil {
+			return response.BadRequest(c, "INVALID_EXPORT", err.Error())
+		}
+
+		contentType := "text/csv; charset=utf-8"
+		if req.Format == FormatNDJSON {
+			contentType = "application/x-ndjson"
+		}
+		filename := fmt.Sprintf("audit-%s-%s.%s",
+			req.Filter.StartDate.Format("20060102"), req.Filter.EndDate.Format("20060102"), req.Format)
+
+		// The stream runs after the handler returns, so detach from the request
+		ctx := context.WithoutCancel(c.UserContext())
+		return response.StreamAttachment(c, filename, contentType, func(w *bufio.Writer) error {
+			_, err := e.Export(ctx, w, req)
+			return err
+		})
+	}
+}
+
+func parseExportRequest(c *fiber.Ctx, tenantID uuid.UUID) (ExportRequest, error) {
+	req := ExportRequest{
+		Format: ExportFormat(strings.ToLower(c.Query("format"))),
+		Filter: Filter{
+			TenantID:   tenantID,
+			Action:     c.Query("action"),
+			EntityType: c.Query("entity_type"),
+		},
+	}
+
+	if cols := c.Query("columns"); cols != "" {
+		for _, col := range strings.Split(cols, ",") {
+			if col = strings.TrimSpace(col); col != "" {
+				req.Columns = append(req.Columns, col)
+			}
+		}
+	}
+
+	for param, dst := range map[string]**time.Time{"from": &req.Filter.StartDate, "to": &req.Filter.EndDate} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return req, fmt.Errorf("invalid %s: %w", param, err)
+			}
+			*dst = &t
+		}
+	}
+
+	if v := c.Query("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return req, fmt.Errorf("invalid user_id: %w", err)
+		}
+		req.Filter.UserID = &id
+	}
+
+	return req, nil
+}
+
+// csvRow renders the selected columns as strings
+func csvRow(l *AuditLog, columns []string) []string {
+	row := make([]string, len(columns))
+	for i, col := range columns {
+		row[i] = csvValue(exportColumns[col](l))
+	}
+	return row
+}
+
+func csvValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case uuid.UUID:
+		return val.String()
+	case *uuid.UUID:
+		if val == nil {
+			return ""
+		}
+		return val.String()
+	case time.Time:
+		return val.UTC().Format(time.RFC3339Nano)
+	case map[string]interface{}:
+		if len(val) == 0 {
+			return ""
+		}
+		data, _ := json.Marshal(val)
+		return string(data)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// jsonRow builds an object holding only the selected columns
+func jsonRow(l *AuditLog, columns []string) map[string]interface{} {
+	row := make(map[string]interface{}, len(columns))
+	for _, col := range columns {
+		row[col] = exportColumns[col](l)
+	}
+	return row
+}
@@ -0,0 +1,233 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/google/uuid"
+	appctx "github.com/minisource/go-common/context"
+	"gorm.io/gorm"
+)
+
+// Auditable is implemented by models whose changes are recorded automatically
+// by the GORM plugin
+type Auditable interface {
+	AuditEntityType() string
+}
+
+// TenantScoped can be implemented by auditable models to supply the tenant of
+// the entry; otherwise the tenant is taken from the context
+type TenantScoped interface {
+	AuditTenantID() uuid.UUID
+}
+
+const oldValuesKey = "audit:old_values"
+
+// GormPlugin records create, update and delete operations on Auditable
+// models as audit entries. The acting user and tenant are read from the
+// statement context (see the context package).
+type GormPlugin struct {
+	logger Logger
+}
+
+// NewGormPlugin creates the plugin; register it with db.Use(plugin)
+func NewGormPlugin(logger Logger) *GormPlugin {
+	return &GormPlugin{logger: logger}
+}
+
+// Name implements gorm.Plugin
+func (p *GormPlugin) Name() string {
+	return "audit"
+}
+
+// Initialize implements gorm.Plugin by registering the callbacks
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	if err := cb.Create().After("gorm:create").Register("audit:after_create", p.afterCreate); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("audit:before_update", p.captureOld); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("audit:after_update", p.afterUpdate); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("audit:before_delete", p.captureOld); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Register("audit:after_delete", p.afterDelete)
+}
+
+func (p *GormPlugin) afterCreate(db *gorm.DB) {
+	if db.Error != nil || !isAuditable(db) {
+		return
+	}
+	eachModel(db.Statement.ReflectValue, func(model reflect.Value) {
+		p.log(db, ActionCreate, model, nil, Snapshot(model.Interface()))
+	})
+}
+
+// captureOld loads the current row before an update or delete so the old
+// values can be recorded
+func (p *GormPlugin) captureOld(db *gorm.DB) {
+	if db.Error != nil || !isAuditable(db) {
+		return
+	}
+	if old, ok := loadCurrent(db); ok {
+		db.InstanceSet(oldValuesKey, old)
+	}
+}
+
+func (p *GormPlugin) afterUpdate(db *gorm.DB) {
+	if db.Error != nil || db.RowsAffected == 0 || !isAuditable(db) {
+		return
+	}
+	oldVal, ok := db.InstanceGet(oldValuesKey)
+	if !ok {
+		return
+	}
+	current, ok := loadCurrent(db)
+	if !ok {
+		return
+	}
+
+	changes, err := Diff(oldVal, current)
+	if err != nil || len(changes) == 0 {
+		return
+	}
+	oldValues, newValues := SplitChanges(changes)
+	p.log(db, ActionUpdate, reflect.ValueOf(current), oldValues, newValues)
+}
+
+func (p *GormPlugin) afterDelete(db *gorm.DB) {
+	if db.Error != nil || db.RowsAffected == 0 || !isAuditable(db) {
+		return
+	}
+	oldVal, ok := db.InstanceGet(oldValuesKey)
+	if !ok {
+		return
+	}
+	p.log(db, ActionDelete, reflect.ValueOf(oldVal), Snapshot(oldVal), nil)
+}
+
+// log builds and writes the audit entry for a model
+func (p *GormPlugin) log(db *gorm.DB, action string, model reflect.Value, oldValues, newValues map[string]interface{}) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	auditable, ok := model.Interface().(Auditable)
+	if !ok && model.CanAddr() {
+		auditable, ok = model.Addr().Interface().(Auditable)
+	}
+	if !ok {
+		return
+	}
+
+	entry := &AuditLog{
+		Action:     action,
+		EntityType: auditable.AuditEntityType(),
+		OldValues:  oldValues,
+		NewValues:  newValues,
+		IPAddress:  appctx.GetClientIP(ctx),
+	}
+
+	if scoped, ok := auditable.(TenantScoped); ok {
+		entry.TenantID = scoped.AuditTenantID()
+	} else if tenantID, ok := appctx.GetTenantID(ctx); ok {
+		entry.TenantID = tenantID
+	}
+	if userID, ok := appctx.GetUserID(ctx); ok {
+		entry.UserID = &userID
+	}
+	if id, ok := primaryKeyUUID(db, model); ok {
+		entry.EntityID = &id
+	}
+	if requestID, ok := appctx.GetRequestID(ctx); ok {
+		entry.Metadata = map[string]interface{}{"request_id": requestID}
+	}
+
+	// Audit failures must never break the data operation itself
+	_ = p.logger.Log(ctx, entry)
+}
+
+// isAuditable reports whether the statement model implements Auditable
+func isAuditable(db *gorm.DB) bool {
+	if db.Statement.Schema == nil {
+		return false
+	}
+	model := reflect.New(db.Statement.Schema.ModelType).Interface()
+	_, ok := model.(Auditable)
+	return ok
+}
+
+// loadCurrent reads the row targeted by the statement using its primary key
+func loadCurrent(db *gorm.DB) (interface{}, bool) {
+	rv := db.Statement.ReflectValue
+	if rv.Kind() != reflect.Struct {
+		return nil, false
+	}
+	field := db.Statement.Schema.PrioritizedPrimaryField
+	if field == nil {
+		return nil, false
+	}
+	pk, zero := field.ValueOf(db.Statement.Context, rv)
+	if zero {
+		return nil, false
+	}
+
+	current := reflect.New(db.Statement.Schema.ModelType).Interface()
+	err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).
+		Table(db.Statement.Table).
+		Where(field.DBName+" = ?", pk).
+		Take(current).Error
+	if err != nil {
+		return nil, false
+	}
+	return reflect.ValueOf(current).Elem().Interface(), true
+}
+
+// primaryKeyUUID extracts the primary key of a model if it is a UUID
+func primaryKeyUUID(db *gorm.DB, model reflect.Value) (uuid.UUID, bool) {
+	field := db.Statement.Schema.PrioritizedPrimaryField
+	if field == nil || model.Kind() != reflect.Struct {
+		return uuid.Nil, false
+	}
+	pk, zero := field.ValueOf(db.Statement.Context, model)
+	if zero {
+		return uuid.Nil, false
+	}
+	id, ok := pk.(uuid.UUID)
+	return id, ok
+}
+
+// eachModel calls fn for a struct value or every element of a slice
+func eachModel(rv reflect.Value, fn func(reflect.Value)) {
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			elem := reflect.Indirect(rv.Index(i))
+			if elem.Kind() == reflect.Struct {
+				fn(elem)
+			}
+		}
+	case reflect.Struct:
+		fn(rv)
+	}
+}
+
+// toMap converts a model to a JSON-shaped map
+func toMap(v interface{}) map[string]interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,117 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	appctx "github.com/minisource/go-common/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type account struct {
+	ID       uuid.UUID `json:"id" gorm:"primaryKey"`
+	TenantID uuid.UUID `json:"tenant_id"`
+	Name     string    `json:"name"`
+	Password string    `json:"password"`
+}
+
+func (account) AuditEntityType() string { return "ACCOUNT" }
+
+func (a account) AuditTenantID() uuid.UUID { return a.TenantID }
+
+func TestGormPluginRecordsChanges(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&account{}))
+	rec := &memoryLogger{}
+	require.NoError(t, db.Use(NewGormPlugin(rec)))
+
+	userID := uuid.New()
+	ctx := appctx.WithUserID(context.Background(), userID)
+	tx := db.WithContext(ctx)
+	acc := account{ID: uuid.New(), TenantID: uuid.New(), Name: "ali", Password: "secret"}
+
+	require.NoError(t, tx.Create(&acc).Error)
+	require.NoError(t, tx.Model(&acc).Update("name", "sara").Error)
+	require.NoError(t, tx.Delete(&acc).Error)
+
+	require.Len(t, rec.entries, 3)
+	created, updated, deleted := rec.entries[0], rec.entries[1], rec.entries[2]
+
+	assert.Equal(t, ActionCreate, created.Action)
+	assert.Equal(t, "ACCOUNT", created.EntityType)
+	assert.Equal(t, acc.TenantID, created.TenantID)
+	assert.Equal(t, acc.ID, *created.EntityID)
+	assert.Equal(t, userID, *created.UserID)
+	assert.Equal(t, "ali", created.NewValues["name"])
+	assert.NotContains(t, created.NewValues, "password")
+
+	assert.Equal(t, ActionUpdate, updated.Action)
+	assert.Equal(t, map[string]interface{}{"name": "ali"}, updated.OldValues)
+	assert.Equal(t, map[string]interface{}{"name": "sara"}, updated.NewValues)
+
+	assert.Equal(t, ActionDelete, deleted.Action)
+	assert.Equal(t, "sara", deleted.OldValues["name"])
+	assert.Nil(t, deleted.NewValues)
+}
+
+type apiClient struct {
+	ID           uuid.UUID         `json:"id" gorm:"primaryKey"`
+	TenantID     uuid.UUID         `json:"tenantId"`
+	Name         string            `json:"name"`
+	ClientSecret string            `json:"clientSecret"`
+	RefreshToken string            `json:"refreshToken"`
+	Settings     map[string]string `json:"settings" gorm:"serializer:json"`
+}
+
+func (apiClient) AuditEntityType() string { return "API_CLIENT" }
+
+func (c apiClient) AuditTenantID() uuid.UUID { return c.TenantID }
+
+func TestGormPluginRedactsCamelCaseAndNestedSecrets(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&apiClient{}))
+	rec := &memoryLogger{}
+	require.NoError(t, db.Use(NewGormPlugin(rec)))
+
+	client := apiClient{
+		ID:           uuid.New(),
+		TenantID:     uuid.New(),
+		Name:         "billing",
+		ClientSecret: "cs",
+		RefreshToken: "rt",
+		Settings:     map[string]string{"region": "eu", "private_key": "pk", "apiKey": "k"},
+	}
+	require.NoError(t, db.Create(&client).Error)
+	require.NoError(t, db.Delete(&client).Error)
+
+	require.Len(t, rec.entries, 2)
+	for _, values := range []map[string]interface{}{rec.entries[0].NewValues, rec.entries[1].OldValues} {
+		assert.Equal(t, "billing", values["name"])
+		assert.NotContains(t, values, "clientSecret")
+		assert.NotContains(t, values, "refreshToken")
+		assert.Equal(t, map[string]interface{}{"region": "eu"}, values["settings"])
+	}
+}
+
+func TestRedactNestedValues(t *testing.T) {
+	values := map[string]interface{}{
+		"user":  map[string]interface{}{"name": "ali", "Password": "p"},
+		"items": []interface{}{map[string]interface{}{"access-token": "t", "id": 1}},
+		"pin":   "1234",
+	}
+
+	redacted := Redact(values, "pin")
+
+	assert.Equal(t, map[string]interface{}{
+		"user":  map[string]interface{}{"name": "ali"},
+		"items": []interface{}{map[string]interface{}{"id": 1}},
+	}, redacted)
+	assert.Contains(t, values, "pin")
+}
@@ -0,0 +1,215 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/metrics"
+	"gorm.io/gorm"
+)
+
+// RetentionPolicy defines how long audit logs are kept
+type RetentionPolicy struct {
+	// Default applies to every tenant without an override (0 = keep forever)
+	Default time.Duration
+	// PerTenant overrides the retention of specific tenants
+	PerTenant map[uuid.UUID]time.Duration
+}
+
+// PrunerConfig configures the retention job
+type PrunerConfig struct {
+	Policy RetentionPolicy
+	// Interval between runs when started with Start (default: 1h)
+	Interval time.Duration
+	// BatchSize is the number of rows removed per statement (default: 1000)
+	BatchSize int
+	// MaxBatches bounds the work done per scope and run (default: 100)
+	MaxBatches int
+	// Archive, if set, receives entries before they are deleted
+	Archive Sink
+	Logger  logging.Logger
+}
+
+// PruneStats summarizes a retention run
+type PruneStats struct {
+	Deleted  int64
+	Archived int64
+	Duration time.Duration
+}
+
+// Pruner deletes or archives audit logs older than their retention
+type Pruner struct {
+	db      *gorm.DB
+	cfg     PrunerConfig
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	started atomic.Bool
+}
+
+// NewPruner creates a retention job
+func NewPruner(db *gorm.DB, cfg PrunerConfig) *Pruner {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1000
+	}
+	if cfg.MaxBatches <= 0 {
+		cfg.MaxBatches = 100
+	}
+	return &Pruner{
+		db:   db,
+		cfg:  cfg,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Start runs the job on the configured interval until Stop is called
+func (p *Pruner) Start() {
+	if !p.started.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(p.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				_, _ = p.RunOnce(context.Background())
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduled job and waits for a running pass to finish. It
+// matches shutdown.Hook.
+func (p *Pruner) Stop(ctx context.Context) error {
+	p.once.Do(func() { close(p.stop) })
+	if !p.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunOnce applies the retention policy once
+func (p *Pruner) RunOnce(ctx context.Context) (PruneStats, error) {
+	start := time.Now()
+	var stats PruneStats
+
+	now := time.Now()
+	overridden := make([]uuid.UUID, 0, len(p.cfg.Policy.PerTenant))
+
+	for tenantID, retention := range p.cfg.Policy.PerTenant {
+		overridden = append(overridden, tenantID)
+		if retention <= 0 {
+			continue
+		}
+		scope := p.db.Session(&gorm.Session{NewDB: true}).Where("tenant_id = ?", tenantID)
+		if err := p.pruneScope(ctx, scope, now.Add(-retention), &stats); err != nil {
+			return p.finish(stats, start, fmt.Errorf("tenant %s: %w", tenantID, err))
+		}
+	}
+
+	if p.cfg.Policy.Default > 0 {
+		scope := p.db.Session(&gorm.Session{NewDB: true})
+		if len(overridden) > 0 {
+			scope = scope.Where("tenant_id NOT IN ?", overridden)
+		}
+		if err := p.pruneScope(ctx, scope, now.Add(-p.cfg.Policy.Default), &stats); err != nil {
+			return p.finish(stats, start, err)
+		}
+	}
+
+	return p.finish(stats, start, nil)
+}
+
+// pruneScope removes logs of a scope older than cutoff in bounded batches
+func (p *Pruner) pruneScope(ctx context.Context, scope *gorm.DB, cutoff time.Time, stats *PruneStats) error {
+	for batch := 0; batch < p.cfg.MaxBatches; batch++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var entries []*AuditLog
+		err := p.db.WithContext(ctx).
+			Where(scope).
+			Where("created_at < ?", cutoff).
+			Order("created_at ASC").
+			Limit(p.cfg.BatchSize).
+			Find(&entries).Error
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		if p.cfg.Archive != nil {
+			if err := p.cfg.Archive.Write(ctx, entries); err != nil {
+				return fmt.Errorf("archive: %w", err)
+			}
+			stats.Archived += int64(len(entries))
+			metrics.AuditLogsPrunedTotal.WithLabelValues("archived").Add(float64(len(entries)))
+		}
+
+		ids := make([]uuid.UUID, len(entries))
+		for i, e := range entries {
+			ids[i] = e.ID
+		}
+		res := p.db.WithContext(ctx).Where("id IN ?", ids).Delete(&AuditLog{})
+		if res.Error != nil {
+			return res.Error
+		}
+		stats.Deleted += res.RowsAffected
+		metrics.AuditLogsPrunedTotal.WithLabelValues("deleted").Add(float64(res.RowsAffected))
+
+		if len(entries) < p.cfg.BatchSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+// finish records the outcome of a run
+func (p *Pruner) finish(stats PruneStats, start time.Time, err error) (PruneStats, error) {
+	stats.Duration = time.Since(start)
+
+	if err != nil {
+		metrics.AuditPruneErrorsTotal.Inc()
+	}
+
+	if p.cfg.Logger != nil {
+		extra := map[logging.ExtraKey]interface{}{
+			"deleted":  stats.Deleted,
+			"archived": stats.Archived,
+			"duration": stats.Duration.String(),
+		}
+		if err != nil {
+			extra["error"] = err.Error()
+			p.cfg.Logger.Error(logging.Postgres, logging.Delete, "Audit retention run failed", extra)
+		} else {
+			p.cfg.Logger.Info(logging.Postgres, logging.Delete, "Audit retention run completed", extra)
+		}
+	}
+
+	return stats, err
+}
@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func countLogs(t *testing.T, db *gorm.DB, tenantID uuid.UUID) int64 {
+	t.Helper()
+	var n int64
+	require.NoError(t, db.Model(&AuditLog{}).Where("tenant_id = ?", tenantID).Count(&n).Error)
+	return n
+}
+
+func TestPrunerAppliesRetentionPerTenant(t *testing.T) {
+	db := newTestDB(t)
+	short, long, forever := uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+	for _, tenantID := range []uuid.UUID{short, long, forever} {
+		insertLogs(t, db, tenantID, now.Add(-48*time.Hour), 3)
+		insertLogs(t, db, tenantID, now.Add(-2*time.Hour), 3)
+	}
+
+	archive := &flakySink{name: "archive"}
+	pruner := NewPruner(db, PrunerConfig{
+		Policy: RetentionPolicy{
+			Default:   24 * time.Hour,
+			PerTenant: map[uuid.UUID]time.Duration{short: time.Hour, forever: 0},
+		},
+		BatchSize: 2,
+		Archive:   archive,
+	})
+
+	stats, err := pruner.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), stats.Deleted)
+	assert.Equal(t, int64(9), stats.Archived)
+	assert.Equal(t, 9, archive.written)
+
+	assert.Equal(t, int64(0), countLogs(t, db, short))
+	assert.Equal(t, int64(3), countLogs(t, db, long))
+	assert.Equal(t, int64(6), countLogs(t, db, forever))
+}
+
+func TestPrunerKeepsLogsWhenArchiveFails(t *testing.T) {
+	db := newTestDB(t)
+	tenantID := uuid.New()
+	insertLogs(t, db, tenantID, time.Now().Add(-48*time.Hour), 3)
+
+	pruner := NewPruner(db, PrunerConfig{
+		Policy:  RetentionPolicy{Default: time.Hour},
+		Archive: &flakySink{name: "archive", failures: 1},
+	})
+	stats, err := pruner.RunOnce(context.Background())
+	assert.ErrorContains(t, err, "archive: unavailable")
+	assert.Equal(t, int64(0), stats.Deleted)
+	assert.Equal(t, int64(3), countLogs(t, db, tenantID))
+}
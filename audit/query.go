@@ -0,0 +1,256 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minisource/go-common/pagination"
+	"gorm.io/gorm"
+)
+
+// sortableColumns whitelists the columns accepted in Filter.SortBy
+var sortableColumns = map[string]bool{
+	"created_at":  true,
+	"action":      true,
+	"entity_type": true,
+	"user_id":     true,
+}
+
+// Dimension is a grouping key for aggregations
+type Dimension string
+
+const (
+	ByAction     Dimension = "action"
+	ByUser       Dimension = "user"
+	ByEntityType Dimension = "entity_type"
+	ByDay        Dimension = "day"
+)
+
+// AggregateBucket is one group of an aggregation
+type AggregateBucket struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+// QueryPage retrieves a page of audit logs together with pagination info.
+// With a cursor (or Filter.Cursor set to "start") keyset pagination on
+// created_at is used; otherwise Limit/Offset are applied.
+func (s *Service) QueryPage(ctx context.Context, filter *Filter) ([]*AuditLog, *pagination.Result, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = pagination.DefaultPageSize
+	}
+	if limit > pagination.MaxPageSize {
+		limit = pagination.MaxPageSize
+	}
+
+	base := applyFilter(s.db.WithContext(ctx).Model(&AuditLog{}), filter)
+
+	var total int64
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if filter.Cursor == "" || sortColumn(filter) != "created_at" {
+		var logs []*AuditLog
+		err := base.Order(orderClause(filter)).Limit(limit).Offset(filter.Offset).Find(&logs).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		return logs, pagination.NewResult(filter.Offset/limit+1, limit, total), nil
+	}
+
+	return s.queryCursor(base, filter, limit, total)
+}
+
+// prevCursorMarker marks cursors that page backwards from their row
+const prevCursorMarker = "prev"
+
+// queryCursor applies keyset pagination on (created_at, id). Next cursors
+// continue after the last row of the page and previous cursors before the
+// first one; a previous page is read in reverse order and flipped back
+func (s *Service) queryCursor(base *gorm.DB, filter *Filter, limit int, total int64) ([]*AuditLog, *pagination.Result, error) {
+	desc := sortDirection(filter) == "DESC"
+	backward := false
+
+	query := base
+	if filter.Cursor != "start" {
+		cursor, err := pagination.DecodeCursor(filter.Cursor)
+		if err != nil || cursor == nil {
+			return nil, nil, fmt.Errorf("invalid cursor")
+		}
+		id, err := uuid.Parse(cursor.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid cursor")
+		}
+		backward = cursor.Value == prevCursorMarker
+		op := ">"
+		if desc != backward {
+			op = "<"
+		}
+		createdAt := time.Unix(0, cursor.CreatedAt)
+		query = query.Where("(created_at, id) "+op+" (?, ?)", createdAt, id)
+	}
+
+	dir := "ASC"
+	if desc != backward {
+		dir = "DESC"
+	}
+	var logs []*AuditLog
+	err := query.Order("created_at " + dir + ", id " + dir).Limit(limit + 1).Find(&logs).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hasMore := len(logs) > limit
+	if hasMore {
+		logs = logs[:limit]
+	}
+	if backward {
+		for i, j := 0, len(logs)-1; i < j; i, j = i+1, j-1 {
+			logs[i], logs[j] = logs[j], logs[i]
+		}
+	}
+
+	hasNext, hasPrev := hasMore, filter.Cursor != "start"
+	if backward {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	var next, prev string
+	if hasNext && len(logs) > 0 {
+		next = logCursor(logs[len(logs)-1], "")
+	}
+	if hasPrev && len(logs) > 0 {
+		prev = logCursor(logs[0], prevCursorMarker)
+	}
+	return logs, pagination.NewCursorResult(total, next != "", next, prev), nil
+}
+
+// logCursor encodes the keyset position of a log
+func logCursor(log *AuditLog, marker string) string {
+	return pagination.EncodeCursor(pagination.CursorData{
+		ID:        log.ID.String(),
+		CreatedAt: log.CreatedAt.UnixNano(),
+		Value:     marker,
+	})
+}
+
+// findInBatches calls fn with the logs matching query in (created_at, id)
+// order, size at a time. It pages by keyset rather than with GORM's
+// FindInBatches, which continues from the last primary key and so skips
+// rows when ids are random UUIDs
+func findInBatches(query *gorm.DB, size int, fn func(batch []*AuditLog) error) error {
+	base := query.Session(&gorm.Session{})
+	var last *AuditLog
+	for {
+		page := base
+		if last != nil {
+			page = page.Where("(created_at, id) > (?, ?)", last.CreatedAt, last.ID)
+		}
+		var batch []*AuditLog
+		if err := page.Order("created_at ASC, id ASC").Limit(size).Find(&batch).Error; err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < size {
+			return nil
+		}
+		last = batch[len(batch)-1]
+	}
+}
+
+// Aggregate counts audit logs matching the filter grouped by a dimension
+func (s *Service) Aggregate(ctx context.Context, filter *Filter, dim Dimension) ([]AggregateBucket, error) {
+	var keyExpr string
+	switch dim {
+	case ByAction:
+		keyExpr = "action"
+	case ByEntityType:
+		keyExpr = "entity_type"
+	case ByUser:
+		keyExpr = "COALESCE(CAST(user_id AS TEXT), '')"
+	case ByDay:
+		keyExpr = "TO_CHAR(DATE_TRUNC('day', created_at), 'YYYY-MM-DD')"
+	default:
+		return nil, fmt.Errorf("unsupported aggregation dimension: %s", dim)
+	}
+
+	var buckets []AggregateBucket
+	err := applyFilter(s.db.WithContext(ctx).Model(&AuditLog{}), filter).
+		Select(keyExpr + " AS key, COUNT(*) AS count").
+		Group(keyExpr).
+		Order("count DESC").
+		Scan(&buckets).Error
+	return buckets, err
+}
+
+// applyFilter adds the filter conditions to a query
+func applyFilter(query *gorm.DB, filter *Filter) *gorm.DB {
+	query = query.Where("tenant_id = ?", filter.TenantID)
+
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+
+	if filter.EntityID != nil {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+
+	if filter.StartDate != nil {
+		query = query.Where("created_at >= ?", filter.StartDate)
+	}
+
+	if filter.EndDate != nil {
+		query = query.Where("created_at <= ?", filter.EndDate)
+	}
+
+	if filter.Search != "" {
+		query = query.Where("LOWER(CAST(metadata AS TEXT)) LIKE ? ESCAPE '!'", "%"+escapeLike(filter.Search)+"%")
+	}
+
+	return query
+}
+
+// orderClause builds a safe ORDER BY from the whitelisted sort column
+func orderClause(filter *Filter) string {
+	return sortColumn(filter) + " " + sortDirection(filter)
+}
+
+func sortColumn(filter *Filter) string {
+	if sortableColumns[filter.SortBy] {
+		return filter.SortBy
+	}
+	return "created_at"
+}
+
+func sortDirection(filter *Filter) string {
+	if strings.EqualFold(filter.SortOrder, "asc") {
+		return "ASC"
+	}
+	return "DESC"
+}
+
+// escapeLike lowercases user input for a case-insensitive LIKE; wildcards
+// in it match literally. It escapes with "!", which unlike a backslash is
+// not special in MySQL string literals
+func escapeLike(s string) string {
+	s = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+	return strings.ToLower(s)
+}
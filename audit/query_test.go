@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minisource/go-common/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryPageCursorVisitsEveryLog(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewService(db)
+	tenantID := uuid.New()
+	// Cursors carry created_at in the local zone; sqlite compares the
+	// stored text, so the fixtures use the same zone
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+	insertLogs(t, db, tenantID, start, 10)
+	insertLogs(t, db, uuid.New(), start, 4)
+
+	for _, order := range []string{"asc", "desc"} {
+		t.Run(order, func(t *testing.T) {
+			seen := map[uuid.UUID]bool{}
+			var prev *AuditLog
+			filter := &Filter{TenantID: tenantID, Limit: 4, SortOrder: order, Cursor: "start"}
+			for pages := 0; ; pages++ {
+				require.Less(t, pages, 4, "cursor doesn't advance")
+				logs, page, err := svc.QueryPage(context.Background(), filter)
+				require.NoError(t, err)
+				assert.Equal(t, int64(10), page.Total)
+				for _, l := range logs {
+					assert.False(t, seen[l.ID], "duplicate log %s", l.ID)
+					seen[l.ID] = true
+					if prev != nil && order == "asc" {
+						assert.False(t, l.CreatedAt.Before(prev.CreatedAt), "logs out of order")
+					}
+					if prev != nil && order == "desc" {
+						assert.False(t, l.CreatedAt.After(prev.CreatedAt), "logs out of order")
+					}
+					prev = l
+				}
+				if !page.HasNext {
+					break
+				}
+				filter.Cursor = page.NextCursor
+			}
+			assert.Len(t, seen, 10)
+		})
+	}
+}
+
+func TestQueryPageCursorPagesBackwards(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewService(db)
+	tenantID := uuid.New()
+	insertLogs(t, db, tenantID, time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local), 10)
+
+	for _, order := range []string{"asc", "desc"} {
+		t.Run(order, func(t *testing.T) {
+			ids := func(logs []*AuditLog) []uuid.UUID {
+				out := make([]uuid.UUID, len(logs))
+				for i, l := range logs {
+					out[i] = l.ID
+				}
+				return out
+			}
+
+			filter := &Filter{TenantID: tenantID, Limit: 4, SortOrder: order, Cursor: "start"}
+			var forward [][]uuid.UUID
+			var page *pagination.Result
+			for {
+				logs, p, err := svc.QueryPage(context.Background(), filter)
+				require.NoError(t, err)
+				forward = append(forward, ids(logs))
+				page = p
+				if !p.HasNext {
+					break
+				}
+				filter.Cursor = p.NextCursor
+			}
+			require.Len(t, forward, 3)
+			assert.True(t, page.HasPrev)
+
+			for i := len(forward) - 2; i >= 0; i-- {
+				filter.Cursor = page.PrevCursor
+				logs, p, err := svc.QueryPage(context.Background(), filter)
+				require.NoError(t, err)
+				assert.Equal(t, forward[i], ids(logs), "page %d", i)
+				assert.True(t, p.HasNext)
+				assert.Equal(t, i > 0, p.HasPrev)
+				page = p
+			}
+			assert.Empty(t, page.PrevCursor)
+
+			// Following next from a previous page continues forward again
+			filter.Cursor = page.NextCursor
+			logs, _, err := svc.QueryPage(context.Background(), filter)
+			require.NoError(t, err)
+			assert.Equal(t, forward[1], ids(logs))
+		})
+	}
+}
+
+func TestQueryPageOffset(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewService(db)
+	tenantID := uuid.New()
+	insertLogs(t, db, tenantID, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 10)
+
+	logs, page, err := svc.QueryPage(context.Background(), &Filter{TenantID: tenantID, Limit: 4, Offset: 8})
+	require.NoError(t, err)
+	assert.Len(t, logs, 2)
+	assert.Equal(t, 3, page.Page)
+	assert.Equal(t, 3, page.TotalPages)
+	assert.False(t, page.HasNext)
+
+	_, _, err = svc.QueryPage(context.Background(), &Filter{TenantID: tenantID, Cursor: "not-a-cursor"})
+	assert.ErrorContains(t, err, "invalid cursor")
+}
+
+func TestOrderClauseIgnoresUnknownColumns(t *testing.T) {
+	assert.Equal(t, "action ASC", orderClause(&Filter{SortBy: "action", SortOrder: "ASC"}))
+	assert.Equal(t, "created_at DESC", orderClause(&Filter{SortBy: "id; DROP TABLE audit_logs"}))
+}
+
+func TestSearchMatchesWildcardsLiterally(t *testing.T) {
+	db := newTestDB(t)
+	tenantID := uuid.New()
+	notes := map[string]string{}
+	for _, note := range []string{"50%_OFF", "50xyoff", "500 off", `a\b`, "x!y"} {
+		id := uuid.New()
+		notes[note] = id.String()
+		require.NoError(t, db.Exec("INSERT INTO audit_logs (id, tenant_id, action, entity_type, metadata, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+			id, tenantID, ActionUpdate, EntityUser, `{"note":"`+note+`"}`, time.Now()).Error)
+	}
+
+	search := func(term string) []string {
+		var ids []string
+		filter := &Filter{TenantID: tenantID, Search: term}
+		require.NoError(t, applyFilter(db.Model(&AuditLog{}), filter).Pluck("id", &ids).Error)
+		return ids
+	}
+	assert.Equal(t, []string{notes["50%_OFF"]}, search("50%_off"), "case-insensitive, wildcards literal")
+	assert.Len(t, search("50"), 3)
+	assert.Equal(t, []string{notes[`a\b`]}, search(`a\b`))
+	assert.Equal(t, []string{notes["x!y"]}, search("x!y"))
+	assert.Empty(t, search("%x"))
+}
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `50!%!_a!!b`, escapeLike(`50%_A!b`))
+}
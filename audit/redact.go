@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// IsExcludedField reports whether name is one of DefaultExcludedFields or
+// extra, ignoring case, "_" and "-"
+func IsExcludedField(name string, extra ...string) bool {
+	return newFieldMatcher(extra...).match(name, name)
+}
+
+// Redact returns a copy of values without the excluded fields, removed at any
+// depth including inside nested objects and arrays. Fields are matched like in
+// Diff: DefaultExcludedFields plus extra, ignoring case, "_" and "-"
+func Redact(values map[string]interface{}, extra ...string) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	return newFieldMatcher(extra...).redact("", values, false).(map[string]interface{})
+}
+
+// RedactedValue replaces the values of excluded fields kept by Mask
+const RedactedValue = "[REDACTED]"
+
+// Mask is like Redact but keeps excluded fields, replacing their values with
+// RedactedValue so it stays visible that they were sent
+func Mask(values map[string]interface{}, extra ...string) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	return newFieldMatcher(extra...).redact("", values, true).(map[string]interface{})
+}
+
+// Snapshot converts v to its JSON object form and redacts it like Redact.
+// Struct fields whose Go name is excluded are removed whatever their json tag
+func Snapshot(v interface{}, extra ...string) map[string]interface{} {
+	values := toMap(v)
+	if values == nil {
+		return nil
+	}
+	m := newFieldMatcher(extra...)
+	m.addStructFields(v)
+	return m.redact("", values, false).(map[string]interface{})
+}
+
+// fieldMatcher matches field names and paths against an exclusion list.
+// Names are compared ignoring case, "_" and "-", so "refresh_token",
+// "refreshToken" and "Refresh-Token" all name the same field
+type fieldMatcher map[string]bool
+
+// newFieldMatcher creates a matcher for DefaultExcludedFields plus extra
+func newFieldMatcher(extra ...string) fieldMatcher {
+	m := make(fieldMatcher, len(DefaultExcludedFields)+len(extra))
+	m.add(DefaultExcludedFields...)
+	m.add(extra...)
+	return m
+}
+
+func (m fieldMatcher) add(fields ...string) {
+	for _, f := range fields {
+		m[normalizeFieldName(f)] = true
+	}
+}
+
+// match reports whether the full path or the last segment name is excluded
+func (m fieldMatcher) match(path, name string) bool {
+	return m[normalizeFieldName(path)] || m[normalizeFieldName(name)]
+}
+
+// redact copies a JSON-shaped value, dropping excluded object keys or, when
+// mask is set, replacing their values with RedactedValue
+func (m fieldMatcher) redact(path string, v interface{}, mask bool) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			p := joinPath(path, k)
+			if m.match(p, k) {
+				if mask {
+					out[k] = RedactedValue
+				}
+				continue
+			}
+			out[k] = m.redact(p, item, mask)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = m.redact(path+"["+strconv.Itoa(i)+"]", item, mask)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// addStructFields excludes the json keys of struct fields whose Go name is
+// excluded, so `Secret string json:"s"` is hidden as well
+func (m fieldMatcher) addStructFields(v interface{}) {
+	if v == nil {
+		return
+	}
+	m.addType(reflect.TypeOf(v), make(map[reflect.Type]bool))
+}
+
+func (m fieldMatcher) addType(t reflect.Type, seen map[reflect.Type]bool) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return
+	}
+	seen[t] = true
+
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name != "-" && name != "" && m[normalizeFieldName(f.Name)] {
+			keys = append(keys, name)
+		}
+		m.addType(f.Type, seen)
+	}
+	m.add(keys...)
+}
+
+// normalizeFieldName lowercases name and strips "_" and "-"
+func normalizeFieldName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
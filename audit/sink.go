@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Sink is a destination for audit log entries
+type Sink interface {
+	// Name identifies the sink in errors and logs
+	Name() string
+	// Write persists a batch of entries
+	Write(ctx context.Context, entries []*AuditLog) error
+	// Close releases resources held by the sink
+	Close() error
+}
+
+// ============================================
+// GORM Sink
+// ============================================
+
+// GormSink writes entries to the audit_logs table
+type GormSink struct {
+	db *gorm.DB
+}
+
+// NewGormSink creates a sink backed by the primary database
+func NewGormSink(db *gorm.DB) *GormSink {
+	return &GormSink{db: db}
+}
+
+// Name returns the sink name
+func (s *GormSink) Name() string {
+	return "gorm"
+}
+
+// Write inserts the entries in a single batch
+func (s *GormSink) Write(ctx context.Context, entries []*AuditLog) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).CreateInBatches(entries, len(entries)).Error
+}
+
+// Close does nothing; the database is owned by the caller
+func (s *GormSink) Close() error {
+	return nil
+}
+
+// ============================================
+// Fan-out Sink
+// ============================================
+
+// FanoutSink writes every batch to all of its sinks concurrently
+type FanoutSink struct {
+	sinks []Sink
+}
+
+// NewFanoutSink creates a sink that writes to all given sinks
+func NewFanoutSink(sinks ...Sink) *FanoutSink {
+	return &FanoutSink{sinks: sinks}
+}
+
+// Name returns the sink name
+func (s *FanoutSink) Name() string {
+	return "fanout"
+}
+
+// Write writes to all sinks and joins the errors of those that failed
+func (s *FanoutSink) Write(ctx context.Context, entries []*AuditLog) error {
+	errs := make([]error, len(s.sinks))
+
+	var wg sync.WaitGroup
+	for i, sink := range s.sinks {
+		wg.Add(1)
+		go func(idx int, sink Sink) {
+			defer wg.Done()
+			if err := sink.Write(ctx, entries); err != nil {
+				errs[idx] = fmt.Errorf("%s: %w", sink.Name(), err)
+			}
+		}(i, sink)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Close closes all sinks
+func (s *FanoutSink) Close() error {
+	var errs []error
+	for _, sink := range s.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ============================================
+// Retry Sink
+// ============================================
+
+// RetryConfig configures per-sink retries
+type RetryConfig struct {
+	MaxRetries    int
+	InitialDelay  time.Duration
+	MaxDelay      time.Duration
+	BackoffFactor float64
+}
+
+// DefaultRetryConfig returns default retry configuration
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries:    3,
+		InitialDelay:  200 * time.Millisecond,
+		MaxDelay:      5 * time.Second,
+		BackoffFactor: 2.0,
+	}
+}
+
+// RetrySink retries failed writes of the wrapped sink with exponential backoff
+type RetrySink struct {
+	sink Sink
+	cfg  RetryConfig
+}
+
+// WithRetry wraps a sink with retry logic
+func WithRetry(sink Sink, cfg RetryConfig) *RetrySink {
+	if cfg.BackoffFactor <= 0 {
+		cfg.BackoffFactor = DefaultRetryConfig().BackoffFactor
+	}
+	return &RetrySink{sink: sink, cfg: cfg}
+}
+
+// Name returns the wrapped sink name
+func (s *RetrySink) Name() string {
+	return s.sink.Name()
+}
+
+// Write writes to the wrapped sink, retrying on failure
+func (s *RetrySink) Write(ctx context.Context, entries []*AuditLog) error {
+	delay := s.cfg.InitialDelay
+
+	var lastErr error
+	for attempt := 0; attempt <= s.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			delay = time.Duration(float64(delay) * s.cfg.BackoffFactor)
+			if s.cfg.MaxDelay > 0 && delay > s.cfg.MaxDelay {
+				delay = s.cfg.MaxDelay
+			}
+		}
+
+		if lastErr = s.sink.Write(ctx, entries); lastErr == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("after %d retries: %w", s.cfg.MaxRetries, lastErr)
+}
+
+// Close closes the wrapped sink
+func (s *RetrySink) Close() error {
+	return s.sink.Close()
+}
@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// FileSinkConfig configures the rotating JSONL file sink
+type FileSinkConfig struct {
+	Path       string
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool
+}
+
+// FileSink appends entries as JSON lines to a size-rotated file
+type FileSink struct {
+	mu     sync.Mutex
+	writer *lumberjack.Logger
+}
+
+// NewFileSink creates a file sink
+func NewFileSink(cfg FileSinkConfig) *FileSink {
+	if cfg.MaxSizeMB == 0 {
+		cfg.MaxSizeMB = 100
+	}
+	return &FileSink{
+		writer: &lumberjack.Logger{
+			Filename:   cfg.Path,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAgeDays,
+			Compress:   cfg.Compress,
+		},
+	}
+}
+
+// Name returns the sink name
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+// Write appends one JSON document per line
+func (s *FileSink) Write(ctx context.Context, entries []*AuditLog) error {
+	buf := make([]byte, 0, 512*len(entries))
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.writer.Write(buf)
+	return err
+}
+
+// Close closes the current log file
+func (s *FileSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaSinkConfig configures the Kafka sink
+type KafkaSinkConfig struct {
+	Brokers []string
+	Topic   string
+}
+
+// KafkaSink publishes entries as JSON messages keyed by tenant ID
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSink creates a Kafka sink
+func NewKafkaSink(cfg KafkaSinkConfig) *KafkaSink {
+	return &KafkaSink{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Topic:        cfg.Topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+		},
+	}
+}
+
+// Name returns the sink name
+func (s *KafkaSink) Name() string {
+	return "kafka"
+}
+
+// Write publishes the entries; messages of a tenant land on the same partition
+func (s *KafkaSink) Write(ctx context.Context, entries []*AuditLog) error {
+	messages := make([]kafka.Message, 0, len(entries))
+	for _, entry := range entries {
+		value, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, kafka.Message{
+			Key:   []byte(entry.TenantID.String()),
+			Value: value,
+		})
+	}
+	return s.writer.WriteMessages(ctx, messages...)
+}
+
+// Close flushes and closes the Kafka writer
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenSearchSinkConfig configures the OpenSearch sink
+type OpenSearchSinkConfig struct {
+	URL      string // e.g. "https://opensearch:9200"
+	Index    string // index name, may contain a time layout in braces: "audit-{2006.01}"
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+// OpenSearchSink indexes entries with the bulk API
+type OpenSearchSink struct {
+	cfg    OpenSearchSinkConfig
+	client *http.Client
+}
+
+// NewOpenSearchSink creates an OpenSearch sink
+func NewOpenSearchSink(cfg OpenSearchSinkConfig) *OpenSearchSink {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Index == "" {
+		cfg.Index = "audit-logs"
+	}
+	return &OpenSearchSink{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Name returns the sink name
+func (s *OpenSearchSink) Name() string {
+	return "opensearch"
+}
+
+// Write sends the entries in one bulk request, using the entry ID as
+// document ID so retries don't create duplicates
+func (s *OpenSearchSink) Write(ctx context.Context, entries []*AuditLog) error {
+	var body bytes.Buffer
+	for _, entry := range entries {
+		action := map[string]interface{}{
+			"index": map[string]interface{}{
+				"_index": s.indexName(entry.CreatedAt),
+				"_id":    entry.ID.String(),
+			},
+		}
+		if err := json.NewEncoder(&body).Encode(action); err != nil {
+			return err
+		}
+		if err := json.NewEncoder(&body).Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(s.cfg.URL, "/")+"/_bulk", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.cfg.Username != "" {
+		req.SetBasicAuth(s.cfg.Username, s.cfg.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Errors bool `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil && resp.StatusCode < 300 {
+		return fmt.Errorf("failed to decode bulk response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("bulk request returned status %d", resp.StatusCode)
+	}
+	if result.Errors {
+		return fmt.Errorf("bulk request had item errors")
+	}
+	return nil
+}
+
+// Close does nothing
+func (s *OpenSearchSink) Close() error {
+	return nil
+}
+
+// indexName expands an optional time layout in braces, e.g. "audit-{2006.01}"
+func (s *OpenSearchSink) indexName(t time.Time) string {
+	start := strings.Index(s.cfg.Index, "{")
+	end := strings.Index(s.cfg.Index, "}")
+	if start == -1 || end < start {
+		return s.cfg.Index
+	}
+	return s.cfg.Index[:start] + t.UTC().Format(s.cfg.Index[start+1:end]) + s.cfg.Index[end+1:]
+}
@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakySink fails the given number of writes before succeeding
+type flakySink struct {
+	name     string
+	failures int
+	calls    int
+	written  int
+}
+
+func (s *flakySink) Name() string { return s.name }
+
+func (s *flakySink) Write(ctx context.Context, entries []*AuditLog) error {
+	s.calls++
+	if s.calls <= s.failures {
+		return errors.New("unavailable")
+	}
+	s.written += len(entries)
+	return nil
+}
+
+func (s *flakySink) Close() error { return nil }
+
+func TestFanoutSinkReportsFailedSinks(t *testing.T) {
+	ok := &flakySink{name: "ok"}
+	down := &flakySink{name: "down", failures: 10}
+	sink := NewFanoutSink(ok, down)
+
+	err := sink.Write(context.Background(), []*AuditLog{{ID: uuid.New()}})
+	assert.ErrorContains(t, err, "down: unavailable")
+	assert.NotContains(t, err.Error(), "ok:")
+	assert.Equal(t, 1, ok.written, "a failing sink doesn't hold back the others")
+}
+
+func TestRetrySink(t *testing.T) {
+	flaky := &flakySink{name: "flaky", failures: 2}
+	sink := WithRetry(flaky, RetryConfig{MaxRetries: 3})
+	require.NoError(t, sink.Write(context.Background(), []*AuditLog{{}}))
+	assert.Equal(t, 3, flaky.calls)
+
+	down := &flakySink{name: "down", failures: 10}
+	err := WithRetry(down, RetryConfig{MaxRetries: 2}).Write(context.Background(), []*AuditLog{{}})
+	assert.ErrorContains(t, err, "after 2 retries: unavailable")
+	assert.Equal(t, 3, down.calls)
+}
+
+func TestFileSinkWritesJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink := NewFileSink(FileSinkConfig{Path: path})
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	require.NoError(t, sink.Write(context.Background(), []*AuditLog{{ID: ids[0]}, {ID: ids[1]}}))
+	require.NoError(t, sink.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var got []uuid.UUID
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry AuditLog
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		got = append(got, entry.ID)
+	}
+	assert.Equal(t, ids, got)
+}
@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WebhookSinkConfig configures the webhook sink
+type WebhookSinkConfig struct {
+	URL     string
+	Headers map[string]string
+	Timeout time.Duration
+}
+
+// WebhookSink posts batches of entries as a JSON array to an HTTP endpoint
+type WebhookSink struct {
+	cfg    WebhookSinkConfig
+	client *http.Client
+}
+
+// NewWebhookSink creates a webhook sink
+func NewWebhookSink(cfg WebhookSinkConfig) *WebhookSink {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &WebhookSink{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Name returns the sink name
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Write posts the entries; any non-2xx response is an error
+func (s *WebhookSink) Write(ctx context.Context, entries []*AuditLog) error {
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close does nothing
+func (s *WebhookSink) Close() error {
+	return nil
+}
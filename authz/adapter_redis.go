@@ -0,0 +1,118 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisAdapter keeps policies and role links as JSON members of two Redis
+// sets, shared by all replicas
+type RedisAdapter struct {
+	client      redis.UniversalClient
+	policiesKey string
+	rolesKey    string
+}
+
+// NewRedisAdapter creates a Redis backed adapter using the keys
+// "authz:policies" and "authz:roles"
+func NewRedisAdapter(client redis.UniversalClient) *RedisAdapter {
+	return &RedisAdapter{
+		client:      client,
+		policiesKey: "authz:policies",
+		rolesKey:    "authz:roles",
+	}
+}
+
+// Load implements Adapter
+func (a *RedisAdapter) Load(ctx context.Context) ([]Policy, []RoleLink, error) {
+	members, err := a.client.SMembers(ctx, a.policiesKey).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	policies := make([]Policy, 0, len(members))
+	for _, m := range members {
+		var p Policy
+		if err := json.Unmarshal([]byte(m), &p); err != nil {
+			return nil, nil, err
+		}
+		policies = append(policies, p)
+	}
+
+	members, err = a.client.SMembers(ctx, a.rolesKey).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	links := make([]RoleLink, 0, len(members))
+	for _, m := range members {
+		var l RoleLink
+		if err := json.Unmarshal([]byte(m), &l); err != nil {
+			return nil, nil, err
+		}
+		links = append(links, l)
+	}
+	return policies, links, nil
+}
+
+// AddPolicies implements Adapter
+func (a *RedisAdapter) AddPolicies(ctx context.Context, policies ...Policy) error {
+	members, err := policyMembers(policies)
+	if err != nil || len(members) == 0 {
+		return err
+	}
+	return a.client.SAdd(ctx, a.policiesKey, members...).Err()
+}
+
+// RemovePolicies implements Adapter
+func (a *RedisAdapter) RemovePolicies(ctx context.Context, policies ...Policy) error {
+	members, err := policyMembers(policies)
+	if err != nil || len(members) == 0 {
+		return err
+	}
+	return a.client.SRem(ctx, a.policiesKey, members...).Err()
+}
+
+// AddRoles implements Adapter
+func (a *RedisAdapter) AddRoles(ctx context.Context, links ...RoleLink) error {
+	members, err := roleMembers(links)
+	if err != nil || len(members) == 0 {
+		return err
+	}
+	return a.client.SAdd(ctx, a.rolesKey, members...).Err()
+}
+
+// RemoveRoles implements Adapter
+func (a *RedisAdapter) RemoveRoles(ctx context.Context, links ...RoleLink) error {
+	members, err := roleMembers(links)
+	if err != nil || len(members) == 0 {
+		return err
+	}
+	return a.client.SRem(ctx, a.rolesKey, members...).Err()
+}
+
+// policyMembers encodes policies with their effect set, so the same policy
+// always has the same member
+func policyMembers(policies []Policy) ([]interface{}, error) {
+	members := make([]interface{}, len(policies))
+	for i, p := range policies {
+		data, err := json.Marshal(normalize(p))
+		if err != nil {
+			return nil, err
+		}
+		members[i] = string(data)
+	}
+	return members, nil
+}
+
+func roleMembers(links []RoleLink) ([]interface{}, error) {
+	members := make([]interface{}, len(links))
+	for i, l := range links {
+		data, err := json.Marshal(l)
+		if err != nil {
+			return nil, err
+		}
+		members[i] = string(data)
+	}
+	return members, nil
+}
@@ -0,0 +1,356 @@
+package authz
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minisource/go-common/logging"
+)
+
+// Effect of a matching policy
+type Effect string
+
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+)
+
+// Policy grants (or with Deny, revokes) an action on an object to a
+// subject, like a casbin "p" line. Object and Action are patterns where "*"
+// matches any run of characters and "|" separates alternatives, e.g.
+// "/api/v1/orders/*" and "GET|HEAD"
+type Policy struct {
+	// Subject is a role or user ID; "*" matches everyone
+	Subject string `json:"sub"`
+	Object  string `json:"obj"`
+	Action  string `json:"act"`
+	// Effect defaults to Allow; a matching Deny wins over any Allow
+	Effect Effect `json:"eft,omitempty"`
+}
+
+// RoleLink gives Subject, a user ID or role, the policies of Role, like a
+// casbin "g" line; links are followed transitively
+type RoleLink struct {
+	Subject string `json:"sub"`
+	Role    string `json:"role"`
+}
+
+// Request is an authorization question
+type Request struct {
+	// Subject is the user or client ID
+	Subject string
+	// Roles of the subject taken from its token, in addition to those
+	// linked by RoleLinks
+	Roles  []string
+	Object string
+	Action string
+}
+
+// PolicyEnforcer decides authorization requests
+type PolicyEnforcer interface {
+	Enforce(ctx context.Context, req Request) (bool, error)
+}
+
+// Adapter persists policies and role links
+type Adapter interface {
+	Load(ctx context.Context) ([]Policy, []RoleLink, error)
+	AddPolicies(ctx context.Context, policies ...Policy) error
+	RemovePolicies(ctx context.Context, policies ...Policy) error
+	AddRoles(ctx context.Context, links ...RoleLink) error
+	RemoveRoles(ctx context.Context, links ...RoleLink) error
+}
+
+// ============================================
+// Enforcer
+// ============================================
+
+// Config configures an Enforcer
+type Config struct {
+	// Adapter persists policies; without one they live in memory only
+	Adapter Adapter
+	// ReloadInterval between reloads from Adapter when started with Start,
+	// so changes made by other replicas apply (default: 1m)
+	ReloadInterval time.Duration
+	Logger         logging.Logger
+}
+
+// Enforcer evaluates policies in memory
+type Enforcer struct {
+	cfg Config
+
+	mu       sync.RWMutex
+	policies map[Policy]struct{}
+	roles    map[string]map[string]struct{}
+
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	started atomic.Bool
+}
+
+// NewEnforcer creates an enforcer; call Load to read policies from the
+// adapter
+func NewEnforcer(cfg Config) *Enforcer {
+	if cfg.ReloadInterval <= 0 {
+		cfg.ReloadInterval = time.Minute
+	}
+	return &Enforcer{
+		cfg:      cfg,
+		policies: make(map[Policy]struct{}),
+		roles:    make(map[string]map[string]struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Load replaces the policies in memory with those of the adapter
+func (e *Enforcer) Load(ctx context.Context) error {
+	if e.cfg.Adapter == nil {
+		return nil
+	}
+	policies, links, err := e.cfg.Adapter.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.policies = make(map[Policy]struct{}, len(policies))
+	e.roles = make(map[string]map[string]struct{})
+	e.addPolicies(policies)
+	e.addRoles(links)
+	return nil
+}
+
+// Start reloads policies on the configured interval until Stop is called
+func (e *Enforcer) Start() {
+	if !e.started.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.cfg.ReloadInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), e.cfg.ReloadInterval)
+				if err := e.Load(ctx); err != nil && e.cfg.Logger != nil {
+					e.cfg.Logger.Error(logging.General, logging.Reload, "Policy reload failed", map[logging.ExtraKey]interface{}{
+						logging.ErrorMessage: err.Error(),
+					})
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Stop stops reloading. It matches shutdown.Hook.
+func (e *Enforcer) Stop(ctx context.Context) error {
+	e.once.Do(func() { close(e.stop) })
+	if !e.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// AddPolicies adds policies and saves them with the adapter
+func (e *Enforcer) AddPolicies(ctx context.Context, policies ...Policy) error {
+	if e.cfg.Adapter != nil {
+		if err := e.cfg.Adapter.AddPolicies(ctx, policies...); err != nil {
+			return err
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.addPolicies(policies)
+	return nil
+}
+
+// RemovePolicies removes policies and deletes them from the adapter
+func (e *Enforcer) RemovePolicies(ctx context.Context, policies ...Policy) error {
+	if e.cfg.Adapter != nil {
+		if err := e.cfg.Adapter.RemovePolicies(ctx, policies...); err != nil {
+			return err
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, p := range policies {
+		delete(e.policies, normalize(p))
+	}
+	return nil
+}
+
+// AddRoles links subjects to roles and saves the links with the adapter
+func (e *Enforcer) AddRoles(ctx context.Context, links ...RoleLink) error {
+	if e.cfg.Adapter != nil {
+		if err := e.cfg.Adapter.AddRoles(ctx, links...); err != nil {
+			return err
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.addRoles(links)
+	return nil
+}
+
+// RemoveRoles unlinks subjects from roles and deletes the links from the
+// adapter
+func (e *Enforcer) RemoveRoles(ctx context.Context, links ...RoleLink) error {
+	if e.cfg.Adapter != nil {
+		if err := e.cfg.Adapter.RemoveRoles(ctx, links...); err != nil {
+			return err
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, l := range links {
+		delete(e.roles[l.Subject], l.Role)
+	}
+	return nil
+}
+
+// Policies returns all policies
+func (e *Enforcer) Policies() []Policy {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	policies := make([]Policy, 0, len(e.policies))
+	for p := range e.policies {
+		policies = append(policies, p)
+	}
+	return policies
+}
+
+// Roles returns the direct and inherited roles of subject
+func (e *Enforcer) Roles(subject string) []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	subjects := e.expand([]string{subject})
+	delete(subjects, subject)
+	roles := make([]string, 0, len(subjects))
+	for role := range subjects {
+		roles = append(roles, role)
+	}
+	return roles
+}
+
+// Enforce implements PolicyEnforcer
+func (e *Enforcer) Enforce(ctx context.Context, req Request) (bool, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	subjects := e.expand(append([]string{req.Subject}, req.Roles...))
+	allowed := false
+	for p := range e.policies {
+		if p.Subject != "*" {
+			if _, ok := subjects[p.Subject]; !ok {
+				continue
+			}
+		}
+		if !matchPattern(p.Object, req.Object) || !matchPattern(p.Action, req.Action) {
+			continue
+		}
+		if p.Effect == Deny {
+			return false, nil
+		}
+		allowed = true
+	}
+	return allowed, nil
+}
+
+// expand returns subjects with all roles they inherit
+func (e *Enforcer) expand(subjects []string) map[string]struct{} {
+	seen := make(map[string]struct{}, len(subjects))
+	queue := make([]string, 0, len(subjects))
+	for _, s := range subjects {
+		if _, ok := seen[s]; !ok && s != "" {
+			seen[s] = struct{}{}
+			queue = append(queue, s)
+		}
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for role := range e.roles[s] {
+			if _, ok := seen[role]; !ok {
+				seen[role] = struct{}{}
+				queue = append(queue, role)
+			}
+		}
+	}
+	return seen
+}
+
+func (e *Enforcer) addPolicies(policies []Policy) {
+	for _, p := range policies {
+		e.policies[normalize(p)] = struct{}{}
+	}
+}
+
+func (e *Enforcer) addRoles(links []RoleLink) {
+	for _, l := range links {
+		if e.roles[l.Subject] == nil {
+			e.roles[l.Subject] = make(map[string]struct{})
+		}
+		e.roles[l.Subject][l.Role] = struct{}{}
+	}
+}
+
+func normalize(p Policy) Policy {
+	if p.Effect == "" {
+		p.Effect = Allow
+	}
+	return p
+}
+
+// matchPattern reports whether value matches one of the "|" separated
+// alternatives of pattern, where "*" matches any run of characters
+func matchPattern(pattern, value string) bool {
+	for _, alt := range strings.Split(pattern, "|") {
+		if glob(alt, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func glob(pattern, value string) bool {
+	if pattern == "*" || pattern == value {
+		return true
+	}
+	if !strings.Contains(pattern, "*") {
+		return false
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(value, part)
+		if i < 0 {
+			return false
+		}
+		value = value[i+len(part):]
+	}
+	return strings.HasSuffix(value, last)
+}
@@ -0,0 +1,161 @@
+package authz
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	appctx "github.com/minisource/go-common/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestEnforcer(t *testing.T) *Enforcer {
+	e := NewEnforcer(Config{})
+	require.NoError(t, e.AddPolicies(context.Background(),
+		Policy{Subject: "viewer", Object: "/api/v1/orders*", Action: "GET|HEAD"},
+		Policy{Subject: "editor", Object: "/api/v1/orders*", Action: "POST|PUT"},
+		Policy{Subject: "admin", Object: "*", Action: "*"},
+		Policy{Subject: "suspended", Object: "*", Action: "*", Effect: Deny},
+	))
+	require.NoError(t, e.AddRoles(context.Background(),
+		RoleLink{Subject: "editor", Role: "viewer"},
+		RoleLink{Subject: "user-1", Role: "editor"},
+	))
+	return e
+}
+
+func TestEnforce(t *testing.T) {
+	e := newTestEnforcer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		req     Request
+		allowed bool
+	}{
+		{"role from token", Request{Subject: "u", Roles: []string{"viewer"}, Object: "/api/v1/orders/1", Action: "GET"}, true},
+		{"action not granted", Request{Subject: "u", Roles: []string{"viewer"}, Object: "/api/v1/orders", Action: "POST"}, false},
+		{"inherited role", Request{Subject: "u", Roles: []string{"editor"}, Object: "/api/v1/orders", Action: "GET"}, true},
+		{"linked user", Request{Subject: "user-1", Object: "/api/v1/orders", Action: "PUT"}, true},
+		{"other object", Request{Subject: "user-1", Object: "/api/v1/users", Action: "GET"}, false},
+		{"admin", Request{Subject: "u", Roles: []string{"admin"}, Object: "/anything", Action: "DELETE"}, true},
+		{"deny wins", Request{Subject: "u", Roles: []string{"admin", "suspended"}, Object: "/anything", Action: "GET"}, false},
+		{"no roles", Request{Subject: "u", Object: "/api/v1/orders", Action: "GET"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, err := e.Enforce(ctx, tt.req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.allowed, allowed)
+		})
+	}
+}
+
+func TestRolesAndRemoval(t *testing.T) {
+	e := newTestEnforcer(t)
+	ctx := context.Background()
+
+	assert.ElementsMatch(t, []string{"editor", "viewer"}, e.Roles("user-1"))
+
+	require.NoError(t, e.RemoveRoles(ctx, RoleLink{Subject: "user-1", Role: "editor"}))
+	assert.Empty(t, e.Roles("user-1"))
+
+	require.NoError(t, e.RemovePolicies(ctx, Policy{Subject: "admin", Object: "*", Action: "*"}))
+	allowed, _ := e.Enforce(ctx, Request{Subject: "u", Roles: []string{"admin"}, Object: "/x", Action: "GET"})
+	assert.False(t, allowed)
+	assert.Len(t, e.Policies(), 3)
+}
+
+func TestRoleCycle(t *testing.T) {
+	e := NewEnforcer(Config{})
+	ctx := context.Background()
+	require.NoError(t, e.AddRoles(ctx, RoleLink{Subject: "a", Role: "b"}, RoleLink{Subject: "b", Role: "a"}))
+	require.NoError(t, e.AddPolicies(ctx, Policy{Subject: "b", Object: "reports", Action: "read"}))
+
+	allowed, err := e.Enforce(ctx, Request{Subject: "a", Object: "reports", Action: "read"})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestMatchPattern(t *testing.T) {
+	assert.True(t, matchPattern("*", "anything"))
+	assert.True(t, matchPattern("orders:*", "orders:read"))
+	assert.True(t, matchPattern("/api/*/orders/*", "/api/v1/orders/:id"))
+	assert.True(t, matchPattern("Get*|List*", "ListOrders"))
+	assert.False(t, matchPattern("Get*|List*", "CreateOrder"))
+	assert.False(t, matchPattern("/api/*/orders", "/api/v1/orders/1"))
+	assert.False(t, matchPattern("a*a", "a"))
+}
+
+func TestFiberMiddleware(t *testing.T) {
+	e := newTestEnforcer(t)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userId", "u")
+		c.Locals("roles", []string{c.Get("X-Role")})
+		return c.Next()
+	})
+	app.Use(Middleware(FiberConfig{Enforcer: e, SkipPaths: []string{"/health"}}))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/api/v1/orders/:id", ok)
+	app.Delete("/api/v1/orders/:id", ok)
+	app.Get("/health", ok)
+
+	call := func(method, path, role string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-Role", role)
+		res, err := app.Test(req)
+		require.NoError(t, err)
+		return res.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, call("GET", "/api/v1/orders/1", "viewer"))
+	assert.Equal(t, fiber.StatusForbidden, call("DELETE", "/api/v1/orders/1", "viewer"))
+	assert.Equal(t, fiber.StatusOK, call("DELETE", "/api/v1/orders/1", "admin"))
+	assert.Equal(t, fiber.StatusOK, call("GET", "/health", ""))
+}
+
+func TestRequire(t *testing.T) {
+	e := NewEnforcer(Config{})
+	require.NoError(t, e.AddPolicies(context.Background(), Policy{Subject: "billing", Object: "invoices", Action: "refund"}))
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("clientId", c.Get("X-Client"))
+		return c.Next()
+	})
+	app.Post("/refund", Require(e, "invoices", "refund"), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for client, want := range map[string]int{"billing": fiber.StatusOK, "shipping": fiber.StatusForbidden} {
+		req := httptest.NewRequest("POST", "/refund", nil)
+		req.Header.Set("X-Client", client)
+		res, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, want, res.StatusCode, client)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	e := NewEnforcer(Config{})
+	require.NoError(t, e.AddPolicies(context.Background(),
+		Policy{Subject: "support", Object: "orders.v1.OrderService", Action: "Get*|List*"},
+	))
+	interceptor := UnaryServerInterceptor(GRPCConfig{Enforcer: e})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	ctx := appctx.WithRoles(context.Background(), []string{"support"})
+	res, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/orders.v1.OrderService/GetOrder"}, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", res)
+
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/orders.v1.OrderService/CancelOrder"}, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
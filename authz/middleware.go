@@ -0,0 +1,185 @@
+package authz
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	appctx "github.com/minisource/go-common/context"
+	grpcauth "github.com/minisource/go-common/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ============================================
+// Fiber
+// ============================================
+
+// FiberConfig configures the Fiber middleware
+type FiberConfig struct {
+	Enforcer PolicyEnforcer
+	// Subject returns the subject and roles of a request (default: the
+	// userId or clientId and roles locals set by the auth middlewares)
+	Subject func(c *fiber.Ctx) (string, []string)
+	// Object returns the object of a request (default: the request path,
+	// matched by policies such as /api/v1/orders/*)
+	Object func(c *fiber.Ctx) string
+	// Action returns the action of a request (default: the HTTP method)
+	Action func(c *fiber.Ctx) string
+	// SkipPaths are paths that are not authorized
+	SkipPaths []string
+}
+
+// Middleware authorizes every request against the enforcer, so policies
+// rather than route code decide who may call a route
+func Middleware(config FiberConfig) fiber.Handler {
+	if config.Subject == nil {
+		config.Subject = fiberSubject
+	}
+	if config.Object == nil {
+		config.Object = func(c *fiber.Ctx) string { return c.Path() }
+	}
+	if config.Action == nil {
+		config.Action = func(c *fiber.Ctx) string { return c.Method() }
+	}
+
+	return func(c *fiber.Ctx) error {
+		path := c.Path()
+		for _, skipPath := range config.SkipPaths {
+			if strings.HasPrefix(path, skipPath) {
+				return c.Next()
+			}
+		}
+
+		subject, roles := config.Subject(c)
+		return authorize(c, config.Enforcer, Request{
+			Subject: subject,
+			Roles:   roles,
+			Object:  config.Object(c),
+			Action:  config.Action(c),
+		})
+	}
+}
+
+// Require authorizes a single route for a fixed object and action, e.g.
+// Require(enforcer, "orders", "delete")
+func Require(enforcer PolicyEnforcer, object, action string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		subject, roles := fiberSubject(c)
+		return authorize(c, enforcer, Request{
+			Subject: subject,
+			Roles:   roles,
+			Object:  object,
+			Action:  action,
+		})
+	}
+}
+
+func authorize(c *fiber.Ctx, enforcer PolicyEnforcer, req Request) error {
+	allowed, err := enforcer.Enforce(c.UserContext(), req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Authorization failed",
+		})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+	return c.Next()
+}
+
+func fiberSubject(c *fiber.Ctx) (string, []string) {
+	subject, _ := c.Locals("userId").(string)
+	if subject == "" {
+		subject, _ = c.Locals("clientId").(string)
+	}
+	roles, _ := c.Locals("roles").([]string)
+	return subject, roles
+}
+
+// ============================================
+// gRPC
+// ============================================
+
+// GRPCConfig configures the gRPC interceptors
+type GRPCConfig struct {
+	Enforcer PolicyEnforcer
+	// Subject returns the subject and roles of a call (default: the user or
+	// client ID set by the auth interceptors and the roles of the context)
+	Subject func(ctx context.Context) (string, []string)
+	// SkipMethods are full method names that are not authorized
+	SkipMethods []string
+}
+
+// UnaryServerInterceptor authorizes calls with the service as object and
+// the method as action, e.g. "orders.v1.OrderService" and "CreateOrder"
+func UnaryServerInterceptor(config GRPCConfig) grpc.UnaryServerInterceptor {
+	check := grpcAuthorizer(config)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor authorizes streams like UnaryServerInterceptor
+func StreamServerInterceptor(config GRPCConfig) grpc.StreamServerInterceptor {
+	check := grpcAuthorizer(config)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := check(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func grpcAuthorizer(config GRPCConfig) func(ctx context.Context, fullMethod string) error {
+	if config.Subject == nil {
+		config.Subject = grpcSubject
+	}
+
+	return func(ctx context.Context, fullMethod string) error {
+		for _, method := range config.SkipMethods {
+			if fullMethod == method {
+				return nil
+			}
+		}
+
+		service, method := splitMethod(fullMethod)
+		subject, roles := config.Subject(ctx)
+		allowed, err := config.Enforcer.Enforce(ctx, Request{
+			Subject: subject,
+			Roles:   roles,
+			Object:  service,
+			Action:  method,
+		})
+		if err != nil {
+			return status.Error(codes.Internal, "authorization failed")
+		}
+		if !allowed {
+			return status.Error(codes.PermissionDenied, "access denied")
+		}
+		return nil
+	}
+}
+
+func grpcSubject(ctx context.Context) (string, []string) {
+	subject, _ := ctx.Value(grpcauth.UserIDKey).(string)
+	if subject == "" {
+		subject, _ = ctx.Value(grpcauth.ServiceClientIDKey).(string)
+	}
+	return subject, appctx.GetRoles(ctx)
+}
+
+// splitMethod splits "/pkg.Service/Method" into service and method
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return fullMethod, ""
+}
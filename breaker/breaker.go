@@ -0,0 +1,409 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/metrics"
+)
+
+// ErrOpen is returned by Allow while the breaker rejects calls
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the state of a circuit breaker
+type State int
+
+const (
+	// StateClosed lets every call through and counts failures
+	StateClosed State = iota
+	// StateHalfOpen lets a few probe calls through to test the service
+	StateHalfOpen
+	// StateOpen rejects every call until OpenTimeout elapses
+	StateOpen
+)
+
+// String returns the state name
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// Config holds circuit breaker configuration
+type Config struct {
+	// FailureThreshold is the number of consecutive failures that opens
+	// the breaker (default 5 unless FailureRatio is set)
+	FailureThreshold int
+	// FailureRatio, if set, also opens the breaker once this share (0-1)
+	// of the calls made in Window failed, after at least MinRequests calls
+	FailureRatio float64
+	// MinRequests is the number of calls in Window needed before
+	// FailureRatio applies (default 10)
+	MinRequests int
+	// Window is the rolling period FailureRatio is computed over
+	// (default 1 minute)
+	Window time.Duration
+	// OpenTimeout is how long the breaker stays open before probe calls
+	// are let through
+	OpenTimeout time.Duration
+	// HalfOpenMaxCalls is the number of probe calls let through at once
+	// while half-open (default 1)
+	HalfOpenMaxCalls int
+	// SuccessThreshold is the number of successful probes that close the
+	// breaker again; any failed probe reopens it
+	SuccessThreshold int
+	// OnStateChange, if set, is called after every state change
+	OnStateChange func(name, key string, from, to State)
+	// Logger, if set, logs state changes
+	Logger logging.Logger
+}
+
+// DefaultConfig returns default circuit breaker configuration
+func DefaultConfig() Config {
+	return Config{
+		FailureThreshold: 5,
+		MinRequests:      10,
+		Window:           time.Minute,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+	}
+}
+
+// windowBuckets is the number of buckets of the rolling failure window
+const windowBuckets = 10
+
+// bucket counts the calls of one slice of the rolling window
+type bucket struct {
+	id       int64
+	total    int
+	failures int
+}
+
+type transition struct {
+	from, to State
+}
+
+// Breaker stops calls to a failing dependency so it isn't hammered while
+// it is down. It opens after FailureThreshold consecutive failures or when
+// the failure ratio exceeds FailureRatio, lets probe calls through after
+// OpenTimeout, and closes once SuccessThreshold probes succeed
+type Breaker struct {
+	name string
+	key  string
+	cfg  Config
+	now  func() time.Time
+
+	mu         sync.Mutex
+	state      State
+	generation uint64
+	failures   int
+	successes  int
+	probes     int
+	openedAt   time.Time
+	buckets    [windowBuckets]bucket
+	changed    chan struct{}
+	pending    []transition
+}
+
+// New creates a circuit breaker; name labels its logs and metrics
+func New(name string, cfg Config) *Breaker {
+	return newBreaker(name, "", cfg)
+}
+
+func newBreaker(name, key string, cfg Config) *Breaker {
+	defaults := DefaultConfig()
+	if cfg.FailureThreshold <= 0 && cfg.FailureRatio <= 0 {
+		cfg.FailureThreshold = defaults.FailureThreshold
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = defaults.MinRequests
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaults.Window
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = defaults.OpenTimeout
+	}
+	if cfg.HalfOpenMaxCalls <= 0 {
+		cfg.HalfOpenMaxCalls = defaults.HalfOpenMaxCalls
+	}
+	if cfg.SuccessThreshold <= 0 {
+		cfg.SuccessThreshold = defaults.SuccessThreshold
+	}
+	b := &Breaker{name: name, key: key, cfg: cfg, now: time.Now, changed: make(chan struct{})}
+	metrics.CircuitBreakerState.WithLabelValues(name, key).Set(float64(StateClosed))
+	return b
+}
+
+// State returns the current state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.unlock()
+	b.expireOpen()
+	return b.state
+}
+
+// RetryAfter returns the time until an open breaker lets probes through;
+// zero in other states
+func (b *Breaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.unlock()
+	b.expireOpen()
+	if b.state != StateOpen {
+		return 0
+	}
+	return b.cfg.OpenTimeout - b.now().Sub(b.openedAt)
+}
+
+// Allow reports whether a call may proceed, returning ErrOpen if not.
+// When it may, the caller must report the outcome by calling done exactly
+// once; success false counts the call as a failure of the dependency
+func (b *Breaker) Allow() (done func(success bool), err error) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	b.expireOpen()
+	switch {
+	case b.state == StateOpen, b.state == StateHalfOpen && b.probes >= b.cfg.HalfOpenMaxCalls:
+		metrics.CircuitBreakerRejectedTotal.WithLabelValues(b.name, b.key).Inc()
+		return nil, ErrOpen
+	case b.state == StateHalfOpen:
+		b.probes++
+	}
+
+	generation := b.generation
+	var once sync.Once
+	return func(success bool) {
+		once.Do(func() { b.record(generation, success) })
+	}, nil
+}
+
+// Wait is like Allow but blocks while the breaker rejects calls, until a
+// call is allowed or ctx is done. Consumers use it to pause instead of
+// failing every message while a dependency is down
+func (b *Breaker) Wait(ctx context.Context) (done func(success bool), err error) {
+	for {
+		if done, err = b.Allow(); err == nil {
+			return done, nil
+		}
+
+		b.mu.Lock()
+		changed := b.changed
+		b.expireOpen()
+		var timer *time.Timer
+		var retry <-chan time.Time
+		if b.state == StateOpen {
+			timer = time.NewTimer(b.cfg.OpenTimeout - b.now().Sub(b.openedAt))
+			retry = timer.C
+		}
+		b.unlock()
+
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-retry:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Execute runs fn if the breaker allows it, counting any error as a failure
+func (b *Breaker) Execute(fn func() error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	done(err == nil)
+	return err
+}
+
+// record applies the outcome of a call made in the given generation;
+// outcomes of calls started before the last state change are ignored
+func (b *Breaker) record(generation uint64, success bool) {
+	b.mu.Lock()
+	defer b.unlock()
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateClosed:
+		total, failures := b.count(success)
+		if success {
+			b.failures = 0
+		} else {
+			b.failures++
+		}
+		if b.cfg.FailureThreshold > 0 && b.failures >= b.cfg.FailureThreshold {
+			b.setState(StateOpen)
+		} else if b.cfg.FailureRatio > 0 && total >= b.cfg.MinRequests &&
+			float64(failures)/float64(total) >= b.cfg.FailureRatio {
+			b.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		b.probes--
+		if !success {
+			b.setState(StateOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.SuccessThreshold {
+			b.setState(StateClosed)
+			return
+		}
+		// Wake waiters for the freed probe slot
+		b.broadcast()
+	}
+}
+
+// count adds a call to the rolling window and returns the window totals
+func (b *Breaker) count(success bool) (total, failures int) {
+	size := int64(b.cfg.Window / windowBuckets)
+	if size <= 0 {
+		size = 1
+	}
+	id := b.now().UnixNano() / size
+	current := &b.buckets[id%windowBuckets]
+	if current.id != id {
+		*current = bucket{id: id}
+	}
+	current.total++
+	if !success {
+		current.failures++
+	}
+
+	for _, bk := range b.buckets {
+		if bk.id > id-windowBuckets {
+			total += bk.total
+			failures += bk.failures
+		}
+	}
+	return total, failures
+}
+
+// expireOpen moves an open breaker to half-open once OpenTimeout elapsed
+func (b *Breaker) expireOpen() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.setState(StateHalfOpen)
+	}
+}
+
+func (b *Breaker) setState(state State) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.successes = 0
+	b.probes = 0
+	b.buckets = [windowBuckets]bucket{}
+	if state == StateOpen {
+		b.openedAt = b.now()
+	}
+	b.broadcast()
+	b.pending = append(b.pending, transition{from: from, to: state})
+
+	metrics.CircuitBreakerState.WithLabelValues(b.name, b.key).Set(float64(state))
+	metrics.CircuitBreakerTransitionsTotal.WithLabelValues(b.name, b.key, state.String()).Inc()
+	if b.cfg.Logger == nil {
+		return
+	}
+	extra := map[logging.ExtraKey]interface{}{
+		"breaker": b.name,
+		"key":     b.key,
+		"from":    from.String(),
+		"to":      state.String(),
+	}
+	if state == StateOpen {
+		b.cfg.Logger.Warn(logging.General, logging.ExternalService, "Circuit breaker opened", extra)
+	} else {
+		b.cfg.Logger.Info(logging.General, logging.ExternalService, "Circuit breaker state changed", extra)
+	}
+}
+
+// broadcast wakes every Wait call
+func (b *Breaker) broadcast() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// unlock releases the lock, then runs OnStateChange for the transitions
+// made while it was held, so callbacks may use the breaker
+func (b *Breaker) unlock() {
+	pending := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+	if b.cfg.OnStateChange == nil {
+		return
+	}
+	for _, t := range pending {
+		b.cfg.OnStateChange(b.name, b.key, t.from, t.to)
+	}
+}
+
+// ============================================
+// Per-key breakers
+// ============================================
+
+// Group holds one breaker per key, e.g. per endpoint of a service, so a
+// single failing endpoint doesn't cut off the others. Keys should come from
+// a bounded set: breakers are never removed
+type Group struct {
+	name string
+	cfg  Config
+
+	mu       sync.RWMutex
+	breakers map[string]*Breaker
+}
+
+// NewGroup creates a group of breakers sharing cfg; name labels their logs
+// and metrics
+func NewGroup(name string, cfg Config) *Group {
+	return &Group{name: name, cfg: cfg, breakers: make(map[string]*Breaker)}
+}
+
+// Get returns the breaker for key, creating it on first use
+func (g *Group) Get(key string) *Breaker {
+	g.mu.RLock()
+	b, ok := g.breakers[key]
+	g.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if b, ok := g.breakers[key]; ok {
+		return b
+	}
+	b = newBreaker(g.name, key, g.cfg)
+	g.breakers[key] = b
+	return b
+}
+
+// States returns the state of every breaker by key
+func (g *Group) States() map[string]State {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	states := make(map[string]State, len(g.breakers))
+	for key, b := range g.breakers {
+		states[key] = b.State()
+	}
+	return states
+}
@@ -0,0 +1,226 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced clock for state timeout tests
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker(cfg Config) (*Breaker, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := New("test", cfg)
+	b.now = clock.now
+	return b, clock
+}
+
+func call(t *testing.T, b *Breaker, success bool) {
+	t.Helper()
+	done, err := b.Allow()
+	require.NoError(t, err)
+	done(success)
+}
+
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	b, _ := newTestBreaker(Config{FailureThreshold: 3, OpenTimeout: time.Minute})
+
+	call(t, b, false)
+	call(t, b, false)
+	call(t, b, true) // resets the count
+	call(t, b, false)
+	call(t, b, false)
+	assert.Equal(t, StateClosed, b.State())
+
+	call(t, b, false)
+	assert.Equal(t, StateOpen, b.State())
+	_, err := b.Allow()
+	assert.ErrorIs(t, err, ErrOpen)
+}
+
+func TestBreaker_HalfOpenProbe(t *testing.T) {
+	b, clock := newTestBreaker(Config{FailureThreshold: 1, OpenTimeout: time.Minute})
+	call(t, b, false)
+	require.Equal(t, StateOpen, b.State())
+
+	clock.advance(time.Minute)
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	// Only one probe at a time
+	done, err := b.Allow()
+	require.NoError(t, err)
+	_, err = b.Allow()
+	assert.ErrorIs(t, err, ErrOpen)
+
+	// A failed probe reopens the breaker for another timeout
+	done(false)
+	assert.Equal(t, StateOpen, b.State())
+	clock.advance(30 * time.Second)
+	assert.Equal(t, StateOpen, b.State())
+	clock.advance(30 * time.Second)
+
+	call(t, b, true)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_SuccessThreshold(t *testing.T) {
+	b, clock := newTestBreaker(Config{FailureThreshold: 1, OpenTimeout: time.Second, SuccessThreshold: 2})
+	call(t, b, false)
+	clock.advance(time.Second)
+
+	call(t, b, true)
+	assert.Equal(t, StateHalfOpen, b.State())
+	call(t, b, true)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_IgnoresStaleOutcomes(t *testing.T) {
+	b, clock := newTestBreaker(Config{FailureThreshold: 1, OpenTimeout: time.Second})
+
+	slow, err := b.Allow()
+	require.NoError(t, err)
+	call(t, b, false)
+	clock.advance(time.Second)
+	require.Equal(t, StateHalfOpen, b.State())
+
+	// A success of a call started while closed doesn't close the breaker
+	slow(true)
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	// Reporting twice has no effect
+	done, err := b.Allow()
+	require.NoError(t, err)
+	done(true)
+	done(false)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_Execute(t *testing.T) {
+	b, _ := newTestBreaker(Config{FailureThreshold: 1})
+	boom := errors.New("boom")
+
+	assert.ErrorIs(t, b.Execute(func() error { return boom }), boom)
+	called := false
+	err := b.Execute(func() error { called = true; return nil })
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.False(t, called)
+}
+
+func TestGroup_IndependentKeys(t *testing.T) {
+	g := NewGroup("test", Config{FailureThreshold: 1, OpenTimeout: time.Minute})
+
+	done, err := g.Get("/a").Allow()
+	require.NoError(t, err)
+	done(false)
+
+	assert.Same(t, g.Get("/a"), g.Get("/a"))
+	assert.Equal(t, StateOpen, g.Get("/a").State())
+	assert.Equal(t, StateClosed, g.Get("/b").State())
+	assert.Equal(t, map[string]State{"/a": StateOpen, "/b": StateClosed}, g.States())
+}
+
+func TestBreaker_FailureRatio(t *testing.T) {
+	b, clock := newTestBreaker(Config{FailureRatio: 0.6, MinRequests: 4, Window: 10 * time.Second})
+
+	// Alternating failures never reach a consecutive threshold
+	call(t, b, false)
+	call(t, b, true)
+	call(t, b, false)
+	assert.Equal(t, StateClosed, b.State(), "below MinRequests")
+	call(t, b, true)
+	assert.Equal(t, StateClosed, b.State(), "2 of 4 failed")
+	call(t, b, false)
+	assert.Equal(t, StateOpen, b.State(), "3 of 5 failed")
+
+	// Calls older than the window don't count
+	b, clock = newTestBreaker(Config{FailureRatio: 0.6, MinRequests: 4, Window: 10 * time.Second})
+	call(t, b, false)
+	call(t, b, false)
+	call(t, b, false)
+	clock.advance(11 * time.Second)
+	call(t, b, true)
+	call(t, b, false)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_HalfOpenMaxCalls(t *testing.T) {
+	b, clock := newTestBreaker(Config{FailureThreshold: 1, OpenTimeout: time.Second, HalfOpenMaxCalls: 2, SuccessThreshold: 2})
+	call(t, b, false)
+	clock.advance(time.Second)
+
+	first, err := b.Allow()
+	require.NoError(t, err)
+	second, err := b.Allow()
+	require.NoError(t, err)
+	_, err = b.Allow()
+	assert.ErrorIs(t, err, ErrOpen)
+
+	first(true)
+	assert.Equal(t, StateHalfOpen, b.State())
+	second(true)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_OnStateChangeAndRetryAfter(t *testing.T) {
+	var changes []string
+	var b *Breaker
+	b, clock := newTestBreaker(Config{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Minute,
+		OnStateChange: func(name, key string, from, to State) {
+			// The breaker is usable from the callback
+			_ = b.State()
+			changes = append(changes, name+":"+from.String()+"->"+to.String())
+		},
+	})
+
+	call(t, b, false)
+	clock.advance(20 * time.Second)
+	assert.Equal(t, 40*time.Second, b.RetryAfter())
+	clock.advance(40 * time.Second)
+	assert.Zero(t, b.RetryAfter())
+	call(t, b, true)
+
+	assert.Equal(t, []string{"test:closed->open", "test:open->half-open", "test:half-open->closed"}, changes)
+}
+
+func TestBreaker_Wait(t *testing.T) {
+	b := New("test", Config{FailureThreshold: 1, OpenTimeout: 50 * time.Millisecond})
+	require.NoError(t, b.Execute(func() error { return nil }))
+	_ = b.Execute(func() error { return errors.New("boom") })
+	require.Equal(t, StateOpen, b.State())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := b.Wait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Unblocks once the open timeout elapses
+	start := time.Now()
+	done, err := b.Wait(context.Background())
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// A second waiter is woken when the probe closes the breaker
+	woken := make(chan error, 1)
+	go func() {
+		_, err := b.Wait(context.Background())
+		woken <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	done(true)
+	select {
+	case err := <-woken:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("waiter not woken")
+	}
+}
@@ -0,0 +1,102 @@
+package common
+
+import "strings"
+
+// ibanLengths holds the IBAN length for the countries we operate in
+var ibanLengths = map[string]int{
+	"IR": 26, "AE": 23, "TR": 26, "IQ": 23, "AZ": 28, "QA": 29,
+	"DE": 22, "GB": 22, "FR": 27, "NL": 18, "OM": 23,
+}
+
+// NormalizeIBAN removes spaces and dashes, converts Persian digits and uppercases
+func NormalizeIBAN(iban string) string {
+	return strings.ToUpper(stripSeparators(ToEnglishDigits(iban)))
+}
+
+// stripSeparators removes whitespace, dashes and zero-width non-joiners
+func stripSeparators(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '\t' || r == '\u200c' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+}
+
+// ValidateIBAN checks the country length and the ISO 13616 mod-97 checksum
+func ValidateIBAN(iban string) bool {
+	iban = NormalizeIBAN(iban)
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	for i := 0; i < len(iban); i++ {
+		c := iban[i]
+		if i < 2 && (c < 'A' || c > 'Z') || i >= 2 && i < 4 && (c < '0' || c > '9') {
+			return false
+		}
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	if n, ok := ibanLengths[iban[:2]]; ok && len(iban) != n {
+		return false
+	}
+
+	// Move the first four characters to the end and compute mod 97
+	// incrementally, letters count as 10..35
+	rearranged := iban[4:] + iban[:4]
+	rem := 0
+	for i := 0; i < len(rearranged); i++ {
+		c := rearranged[i]
+		if c >= 'A' {
+			rem = (rem*100 + int(c-'A') + 10) % 97
+		} else {
+			rem = (rem*10 + int(c-'0')) % 97
+		}
+	}
+	return rem == 1
+}
+
+// ValidateSheba checks an Iranian IBAN (شبا). The "IR" prefix is optional
+//
+//	"IR06 2960 0000 0010 0324 2000 01" → true
+//	"062960000000100324200001"         → true
+func ValidateSheba(sheba string) bool {
+	sheba = NormalizeIBAN(sheba)
+	if !strings.HasPrefix(sheba, "IR") {
+		sheba = "IR" + sheba
+	}
+	return len(sheba) == 26 && isDigits(sheba[2:]) && ValidateIBAN(sheba)
+}
+
+// ValidateLuhn checks the Luhn (mod 10) checksum of a digit string
+func ValidateLuhn(number string) bool {
+	if !isDigits(number) {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// ValidateBankCard checks a payment card number (12-19 digits) with Luhn.
+// Spaces, dashes and Persian digits are accepted
+func ValidateBankCard(card string) bool {
+	card = stripSeparators(ToEnglishDigits(card))
+	if len(card) < 12 || len(card) > 19 {
+		return false
+	}
+	return ValidateLuhn(card)
+}
@@ -0,0 +1,54 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateIranianNationalCode(t *testing.T) {
+	tests := []struct {
+		code  string
+		valid bool
+	}{
+		{"0499370899", true},
+		{"0013542419", true},
+		{"۰۰۸۴۵۷۵۹۴۸", true},
+		{"008-457594-8", true},
+		{"84575948", true},
+		{"1234567890", false},
+		{"1111111111", false},
+		{"0499370898", false},
+		{"04993708a9", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.valid, ValidateIranianNationalCode(tt.code), tt.code)
+	}
+}
+
+func TestValidateIBAN(t *testing.T) {
+	assert.True(t, ValidateIBAN("DE89370400440532013000"))
+	assert.True(t, ValidateIBAN("gb82 west 1234 5698 7654 32"))
+	assert.False(t, ValidateIBAN("DE89370400440532013001"))
+	assert.False(t, ValidateIBAN("DE8937040044053201300"))
+	assert.False(t, ValidateIBAN("D189370400440532013000"))
+}
+
+func TestValidateSheba(t *testing.T) {
+	assert.True(t, ValidateSheba("IR062960000000100324200001"))
+	assert.True(t, ValidateSheba("IR82 0540 1026 8002 0817 9090 02"))
+	assert.True(t, ValidateSheba("062960000000100324200001"))
+	assert.False(t, ValidateSheba("IR062960000000100324200002"))
+	assert.False(t, ValidateSheba("DE89370400440532013000"))
+}
+
+func TestValidateBankCard(t *testing.T) {
+	assert.True(t, ValidateBankCard("6037997599999993"))
+	assert.True(t, ValidateBankCard("6037-9975-9999-9993"))
+	assert.True(t, ValidateBankCard("4111 1111 1111 1111"))
+	assert.False(t, ValidateBankCard("6037997599999990"))
+	assert.False(t, ValidateBankCard("6037a97599999993"))
+	assert.False(t, ValidateBankCard("12345"))
+}
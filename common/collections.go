@@ -0,0 +1,235 @@
+package common
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Map applies fn to every element
+func Map[T, R any](items []T, fn func(T) R) []R {
+	if items == nil {
+		return nil
+	}
+	out := make([]R, len(items))
+	for i, item := range items {
+		out[i] = fn(item)
+	}
+	return out
+}
+
+// MapErr applies fn to every element, stopping at the first error
+func MapErr[T, R any](items []T, fn func(T) (R, error)) ([]R, error) {
+	if items == nil {
+		return nil, nil
+	}
+	out := make([]R, len(items))
+	for i, item := range items {
+		r, err := fn(item)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = r
+	}
+	return out, nil
+}
+
+// Filter returns the elements for which keep returns true
+func Filter[T any](items []T, keep func(T) bool) []T {
+	var out []T
+	for _, item := range items {
+		if keep(item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Reduce folds items into a single value
+func Reduce[T, A any](items []T, initial A, fn func(A, T) A) A {
+	acc := initial
+	for _, item := range items {
+		acc = fn(acc, item)
+	}
+	return acc
+}
+
+// Find returns the first element matching fn
+func Find[T any](items []T, fn func(T) bool) (T, bool) {
+	for _, item := range items {
+		if fn(item) {
+			return item, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Partition splits items into those matching fn and the rest
+func Partition[T any](items []T, fn func(T) bool) (matched, rest []T) {
+	for _, item := range items {
+		if fn(item) {
+			matched = append(matched, item)
+		} else {
+			rest = append(rest, item)
+		}
+	}
+	return matched, rest
+}
+
+// Unique removes duplicates, keeping the first occurrence and the order
+func Unique[T comparable](items []T) []T {
+	return UniqueBy(items, func(t T) T { return t })
+}
+
+// UniqueBy removes elements whose key was already seen
+func UniqueBy[T any, K comparable](items []T, key func(T) K) []T {
+	if items == nil {
+		return nil
+	}
+	seen := make(map[K]struct{}, len(items))
+	out := make([]T, 0, len(items))
+	for _, item := range items {
+		k := key(item)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		out = append(out, item)
+	}
+	return out
+}
+
+// Chunk splits items into slices of at most size elements. The chunks share
+// the backing array of items
+func Chunk[T any](items []T, size int) [][]T {
+	if size <= 0 {
+		panic("common.Chunk: size must be positive")
+	}
+	var out [][]T
+	for size < len(items) {
+		items, out = items[size:], append(out, items[:size:size])
+	}
+	if len(items) > 0 {
+		out = append(out, items)
+	}
+	return out
+}
+
+// ChunkBy splits items into consecutive chunks whose total weight stays
+// within limit, e.g. to keep a batch INSERT under the driver's parameter
+// limit (weight = columns per row, limit = 65535). An element heavier than
+// limit gets a chunk of its own
+func ChunkBy[T any](items []T, limit int, weight func(T) int) [][]T {
+	var out [][]T
+	start, total := 0, 0
+	for i, item := range items {
+		w := weight(item)
+		if i > start && total+w > limit {
+			out = append(out, items[start:i:i])
+			start, total = i, 0
+		}
+		total += w
+	}
+	if start < len(items) {
+		out = append(out, items[start:])
+	}
+	return out
+}
+
+// GroupBy groups items by key, preserving order within each group
+func GroupBy[T any, K comparable](items []T, key func(T) K) map[K][]T {
+	out := make(map[K][]T)
+	for _, item := range items {
+		k := key(item)
+		out[k] = append(out[k], item)
+	}
+	return out
+}
+
+// KeyBy indexes items by key, later elements win on duplicates
+func KeyBy[T any, K comparable](items []T, key func(T) K) map[K]T {
+	out := make(map[K]T, len(items))
+	for _, item := range items {
+		out[key(item)] = item
+	}
+	return out
+}
+
+// Keys returns the keys of m in unspecified order
+func Keys[K comparable, V any](m map[K]V) []K {
+	out := make([]K, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+// SortedKeys returns the keys of m in ascending order
+func SortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	out := Keys(m)
+	slices.Sort(out)
+	return out
+}
+
+// Values returns the values of m in unspecified order
+func Values[K comparable, V any](m map[K]V) []V {
+	out := make([]V, 0, len(m))
+	for _, v := range m {
+		out = append(out, v)
+	}
+	return out
+}
+
+// Contains reports whether item is in items
+func Contains[T comparable](items []T, item T) bool {
+	return slices.Contains(items, item)
+}
+
+// Difference returns the elements of a that are not in b
+func Difference[T comparable](a, b []T) []T {
+	return DifferenceBy(a, b, func(t T) T { return t })
+}
+
+// DifferenceBy returns the elements of a whose key is not in b, e.g. the
+// records a sync job must delete because the source no longer has them
+func DifferenceBy[T any, K comparable](a, b []T, key func(T) K) []T {
+	exclude := make(map[K]struct{}, len(b))
+	for _, item := range b {
+		exclude[key(item)] = struct{}{}
+	}
+	var out []T
+	for _, item := range a {
+		if _, ok := exclude[key(item)]; !ok {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Intersect returns the elements of a that are also in b
+func Intersect[T comparable](a, b []T) []T {
+	include := make(map[T]struct{}, len(b))
+	for _, item := range b {
+		include[item] = struct{}{}
+	}
+	var out []T
+	for _, item := range a {
+		if _, ok := include[item]; ok {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Flatten concatenates nested slices
+func Flatten[T any](items [][]T) []T {
+	n := 0
+	for _, s := range items {
+		n += len(s)
+	}
+	out := make([]T, 0, n)
+	for _, s := range items {
+		out = append(out, s...)
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package common
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapFilterReduce(t *testing.T) {
+	nums := []int{1, 2, 3, 4}
+
+	assert.Equal(t, []string{"1", "2", "3", "4"}, Map(nums, strconv.Itoa))
+	assert.Nil(t, Map[int, string](nil, strconv.Itoa))
+	assert.Equal(t, []int{2, 4}, Filter(nums, func(n int) bool { return n%2 == 0 }))
+	assert.Equal(t, 10, Reduce(nums, 0, func(acc, n int) int { return acc + n }))
+
+	out, err := MapErr([]string{"1", "2"}, strconv.Atoi)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, out)
+	_, err = MapErr([]string{"1", "x"}, strconv.Atoi)
+	assert.Error(t, err)
+
+	n, ok := Find(nums, func(n int) bool { return n > 2 })
+	assert.True(t, ok)
+	assert.Equal(t, 3, n)
+	_, ok = Find(nums, func(n int) bool { return n > 9 })
+	assert.False(t, ok)
+
+	even, odd := Partition(nums, func(n int) bool { return n%2 == 0 })
+	assert.Equal(t, []int{2, 4}, even)
+	assert.Equal(t, []int{1, 3}, odd)
+}
+
+func TestUnique(t *testing.T) {
+	assert.Equal(t, []int{3, 1, 2}, Unique([]int{3, 1, 3, 2, 1}))
+
+	type user struct{ ID, Name string }
+	users := []user{{"1", "a"}, {"2", "b"}, {"1", "c"}}
+	assert.Equal(t, []user{{"1", "a"}, {"2", "b"}}, UniqueBy(users, func(u user) string { return u.ID }))
+}
+
+func TestChunk(t *testing.T) {
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, Chunk([]int{1, 2, 3, 4, 5}, 2))
+	assert.Equal(t, [][]int{{1, 2}}, Chunk([]int{1, 2}, 5))
+	assert.Nil(t, Chunk([]int{}, 3))
+	assert.Panics(t, func() { Chunk([]int{1}, 0) })
+
+	// Appending to a chunk must not overwrite the next one
+	chunks := Chunk([]int{1, 2, 3, 4}, 2)
+	_ = append(chunks[0], 99)
+	assert.Equal(t, []int{3, 4}, chunks[1])
+}
+
+func TestChunkBy(t *testing.T) {
+	words := []string{"aa", "bbb", "c", "dddd", "eeeeeeee", "f"}
+	chunks := ChunkBy(words, 5, func(s string) int { return len(s) })
+
+	assert.Equal(t, [][]string{{"aa", "bbb"}, {"c", "dddd"}, {"eeeeeeee"}, {"f"}}, chunks)
+	assert.Nil(t, ChunkBy([]string{}, 5, func(s string) int { return len(s) }))
+}
+
+func TestGroupAndKeyBy(t *testing.T) {
+	words := []string{"apple", "avocado", "banana"}
+	first := func(s string) byte { return s[0] }
+
+	assert.Equal(t, map[byte][]string{'a': {"apple", "avocado"}, 'b': {"banana"}}, GroupBy(words, first))
+	assert.Equal(t, map[byte]string{'a': "avocado", 'b': "banana"}, KeyBy(words, first))
+}
+
+func TestMapHelpers(t *testing.T) {
+	m := map[string]int{"b": 2, "a": 1, "c": 3}
+
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, Keys(m))
+	assert.Equal(t, []string{"a", "b", "c"}, SortedKeys(m))
+	assert.ElementsMatch(t, []int{1, 2, 3}, Values(m))
+}
+
+func TestSetOperations(t *testing.T) {
+	assert.True(t, Contains([]string{"a", "b"}, "b"))
+	assert.Equal(t, []int{1, 3}, Difference([]int{1, 2, 3}, []int{2, 4}))
+	assert.Equal(t, []int{2}, Intersect([]int{1, 2, 3}, []int{2, 4}))
+	assert.Equal(t, []int{1, 2, 3}, Flatten([][]int{{1}, {2, 3}, nil}))
+
+	type record struct {
+		ID      int
+		Version int
+	}
+	local := []record{{1, 1}, {2, 1}, {3, 1}}
+	remote := []record{{2, 5}, {3, 1}}
+	stale := DifferenceBy(local, remote, func(r record) int { return r.ID })
+	assert.Equal(t, []record{{1, 1}}, stale)
+}
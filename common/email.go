@@ -0,0 +1,195 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidEmail  = errors.New("invalid email address")
+	ErrEmailNoMX     = errors.New("email domain does not accept mail")
+	ErrEmailNoDomain = errors.New("email domain does not exist")
+)
+
+const (
+	maxEmailLength      = 254
+	maxEmailLocalLength = 64
+	maxDomainLabel      = 63
+)
+
+// gmailDomains are folded to a single canonical domain
+var gmailDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+}
+
+type emailOptions struct {
+	foldGmail bool
+}
+
+// EmailOption configures NormalizeEmail
+type EmailOption func(*emailOptions)
+
+// WithGmailFolding removes dots and "+tag" suffixes from Gmail local parts
+// and maps googlemail.com to gmail.com, so aliases of one inbox compare equal
+func WithGmailFolding() EmailOption {
+	return func(o *emailOptions) {
+		o.foldGmail = true
+	}
+}
+
+// NormalizeEmail trims the address and lowercases the domain. The local part
+// keeps its case (RFC 5321) unless the domain is Gmail, which is case-insensitive
+//
+//	"  John.Doe@Example.COM " → "John.Doe@example.com"
+//	"J.Doe+news@GoogleMail.com" with WithGmailFolding() → "jdoe@gmail.com"
+//
+// Returns the trimmed input unchanged if it has no "@"
+func NormalizeEmail(email string, opts ...EmailOption) string {
+	var o emailOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 {
+		return email
+	}
+
+	local := email[:at]
+	domain := strings.TrimSuffix(strings.ToLower(email[at+1:]), ".")
+
+	if gmailDomains[domain] {
+		local = strings.ToLower(local)
+		if o.foldGmail {
+			if i := strings.IndexByte(local, '+'); i >= 0 {
+				local = local[:i]
+			}
+			local = strings.ReplaceAll(local, ".", "")
+			domain = "gmail.com"
+		}
+	}
+
+	return local + "@" + domain
+}
+
+// ValidateEmail checks the syntax of a bare address (no display name)
+func ValidateEmail(email string) bool {
+	return CheckEmail(email) == nil
+}
+
+// CheckEmail checks the syntax of a bare address and returns ErrInvalidEmail
+// if it is malformed
+func CheckEmail(email string) error {
+	if email == "" || len(email) > maxEmailLength {
+		return ErrInvalidEmail
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return ErrInvalidEmail
+	}
+
+	at := strings.LastIndex(email, "@")
+	local, domain := email[:at], email[at+1:]
+	if len(local) > maxEmailLocalLength || !validEmailDomain(domain) {
+		return ErrInvalidEmail
+	}
+	return nil
+}
+
+// EmailDomain returns the lowercased domain of an address
+func EmailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
+
+// EmailResolver is the subset of net.Resolver used for MX validation
+type EmailResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// EmailMXConfig configures CheckEmailMX
+type EmailMXConfig struct {
+	Resolver EmailResolver
+	Timeout  time.Duration
+}
+
+// DefaultEmailMXConfig returns the default MX validation configuration
+func DefaultEmailMXConfig() EmailMXConfig {
+	return EmailMXConfig{
+		Resolver: net.DefaultResolver,
+		Timeout:  3 * time.Second,
+	}
+}
+
+// CheckEmailMX validates the syntax and verifies the domain can receive mail.
+// Domains without MX records fall back to their A/AAAA records (RFC 5321),
+// and a null MX (".") is rejected (RFC 7505)
+func CheckEmailMX(ctx context.Context, email string, cfg ...EmailMXConfig) error {
+	if err := CheckEmail(email); err != nil {
+		return err
+	}
+
+	c := DefaultEmailMXConfig()
+	if len(cfg) > 0 {
+		if cfg[0].Resolver != nil {
+			c.Resolver = cfg[0].Resolver
+		}
+		if cfg[0].Timeout > 0 {
+			c.Timeout = cfg[0].Timeout
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	domain := EmailDomain(email)
+	mxs, err := c.Resolver.LookupMX(ctx, domain)
+	if err == nil && len(mxs) > 0 {
+		if len(mxs) == 1 && (mxs[0].Host == "." || mxs[0].Host == "") {
+			return ErrEmailNoMX
+		}
+		return nil
+	}
+
+	var dnsErr *net.DNSError
+	if err != nil && errors.As(err, &dnsErr) && !dnsErr.IsNotFound {
+		// Temporary resolver failures are not proof the domain is bad
+		return err
+	}
+
+	if hosts, err := c.Resolver.LookupHost(ctx, domain); err == nil && len(hosts) > 0 {
+		return nil
+	}
+	return ErrEmailNoDomain
+}
+
+func validEmailDomain(domain string) bool {
+	if domain == "" || !strings.Contains(domain, ".") {
+		return false
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > maxDomainLabel {
+			return false
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+				return false
+			}
+		}
+	}
+	return true
+}
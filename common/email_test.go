@@ -0,0 +1,68 @@
+package common
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []EmailOption
+		expected string
+	}{
+		{"Trim and lowercase domain", "  John.Doe@Example.COM ", nil, "John.Doe@example.com"},
+		{"Gmail lowercased without folding", "J.Doe+news@Gmail.com", nil, "j.doe+news@gmail.com"},
+		{"Gmail folding", "J.Doe+news@GoogleMail.com", []EmailOption{WithGmailFolding()}, "jdoe@gmail.com"},
+		{"Folding ignores other domains", "j.doe+news@example.com", []EmailOption{WithGmailFolding()}, "j.doe+news@example.com"},
+		{"No at sign", " invalid ", nil, "invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NormalizeEmail(tt.input, tt.opts...))
+		})
+	}
+}
+
+func TestValidateEmail(t *testing.T) {
+	valid := []string{"user@example.com", "first.last+tag@sub.example.co.uk", "u_1@ex-ample.ir"}
+	invalid := []string{"", "user", "user@", "@example.com", "user@localhost", "John <user@example.com>",
+		"user@-example.com", "user@example..com", "us er@example.com"}
+
+	for _, e := range valid {
+		assert.True(t, ValidateEmail(e), e)
+	}
+	for _, e := range invalid {
+		assert.False(t, ValidateEmail(e), e)
+	}
+}
+
+type fakeResolver struct {
+	mx    []*net.MX
+	mxErr error
+	hosts []string
+}
+
+func (r fakeResolver) LookupMX(context.Context, string) ([]*net.MX, error) { return r.mx, r.mxErr }
+func (r fakeResolver) LookupHost(context.Context, string) ([]string, error) {
+	if len(r.hosts) == 0 {
+		return nil, &net.DNSError{IsNotFound: true}
+	}
+	return r.hosts, nil
+}
+
+func TestCheckEmailMX(t *testing.T) {
+	ctx := context.Background()
+	notFound := &net.DNSError{IsNotFound: true}
+
+	assert.NoError(t, CheckEmailMX(ctx, "a@example.com", EmailMXConfig{Resolver: fakeResolver{mx: []*net.MX{{Host: "mx.example.com."}}}}))
+	assert.NoError(t, CheckEmailMX(ctx, "a@example.com", EmailMXConfig{Resolver: fakeResolver{mxErr: notFound, hosts: []string{"1.2.3.4"}}}))
+	assert.ErrorIs(t, CheckEmailMX(ctx, "a@example.com", EmailMXConfig{Resolver: fakeResolver{mx: []*net.MX{{Host: "."}}}}), ErrEmailNoMX)
+	assert.ErrorIs(t, CheckEmailMX(ctx, "a@example.com", EmailMXConfig{Resolver: fakeResolver{mxErr: notFound}}), ErrEmailNoDomain)
+	assert.ErrorIs(t, CheckEmailMX(ctx, "bad", EmailMXConfig{Resolver: fakeResolver{}}), ErrInvalidEmail)
+}
@@ -0,0 +1,490 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JalaliMonth is a month of the Jalali (Solar Hijri) calendar
+type JalaliMonth int
+
+const (
+	Farvardin JalaliMonth = 1 + iota
+	Ordibehesht
+	Khordad
+	Tir
+	Mordad
+	Shahrivar
+	Mehr
+	Aban
+	Azar
+	Dey
+	Bahman
+	Esfand
+)
+
+var jalaliMonthNames = [...]string{
+	"فروردین", "اردیبهشت", "خرداد", "تیر", "مرداد", "شهریور",
+	"مهر", "آبان", "آذر", "دی", "بهمن", "اسفند",
+}
+
+var persianWeekdayNames = [...]string{
+	"یکشنبه", "دوشنبه", "سه‌شنبه", "چهارشنبه", "پنجشنبه", "جمعه", "شنبه",
+}
+
+// String returns the Persian month name
+func (m JalaliMonth) String() string {
+	if m < Farvardin || m > Esfand {
+		return "%!JalaliMonth(" + strconv.Itoa(int(m)) + ")"
+	}
+	return jalaliMonthNames[m-1]
+}
+
+// PersianWeekday returns the Persian name of a weekday
+func PersianWeekday(d time.Weekday) string {
+	return persianWeekdayNames[d]
+}
+
+var ErrInvalidJalaliDate = errors.New("invalid jalali date")
+
+// JalaliTime is an instant viewed in the Jalali calendar. Conversions are
+// exact for years 1 to 3177
+type JalaliTime struct {
+	t     time.Time
+	year  int
+	month JalaliMonth
+	day   int
+}
+
+// ToJalali converts a Gregorian time to Jalali in the time's location
+func ToJalali(t time.Time) JalaliTime {
+	gy := t.Year()
+	jy := gy - 621
+
+	// Days since 1 Farvardin of jy, which always falls in March of gy
+	start := time.Date(gy, time.March, jalaliNowruz(jy), 0, 0, 0, 0, time.UTC)
+	k := civilDays(time.Date(gy, t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)) - civilDays(start)
+
+	var jm, jd int
+	switch {
+	case k >= 186:
+		k -= 186
+		jm, jd = 7+k/30, k%30+1
+	case k >= 0:
+		jm, jd = 1+k/31, k%31+1
+	default:
+		// Before Nowruz: the last months of the previous Jalali year
+		jy--
+		k += 179
+		if IsJalaliLeapYear(jy) {
+			k++
+		}
+		jm, jd = 7+k/30, k%30+1
+	}
+
+	return JalaliTime{t: t, year: jy, month: JalaliMonth(jm), day: jd}
+}
+
+// JalaliDate returns the time for the given Jalali date in loc. Like
+// time.Date, out-of-range days, hours, etc. are normalized, so day 31 of
+// Mehr becomes 1 Aban
+func JalaliDate(year int, month JalaliMonth, day, hour, minute, sec, nsec int, loc *time.Location) JalaliTime {
+	// Normalize the month into [1, 12] first
+	m := int(month) - 1
+	year += m / 12
+	m %= 12
+	if m < 0 {
+		m += 12
+		year--
+	}
+	month = JalaliMonth(m + 1)
+
+	gy := year + 621
+	offset := jalaliDaysBeforeMonth(month) + day - 1
+	t := time.Date(gy, time.March, jalaliNowruz(year)+offset, hour, minute, sec, nsec, loc)
+	return ToJalali(t)
+}
+
+// NowJalali returns the current time in the Jalali calendar
+func NowJalali(loc *time.Location) JalaliTime {
+	return ToJalali(time.Now().In(loc))
+}
+
+// Time returns the Gregorian time
+func (j JalaliTime) Time() time.Time { return j.t }
+
+// Year returns the Jalali year
+func (j JalaliTime) Year() int { return j.year }
+
+// Month returns the Jalali month
+func (j JalaliTime) Month() JalaliMonth { return j.month }
+
+// Day returns the day of the Jalali month
+func (j JalaliTime) Day() int { return j.day }
+
+// Date returns the Jalali year, month and day
+func (j JalaliTime) Date() (int, JalaliMonth, int) { return j.year, j.month, j.day }
+
+// Weekday returns the day of the week
+func (j JalaliTime) Weekday() time.Weekday { return j.t.Weekday() }
+
+// YearDay returns the day of the Jalali year, in [1, 366]
+func (j JalaliTime) YearDay() int {
+	return jalaliDaysBeforeMonth(j.month) + j.day
+}
+
+// AddDate adds years, months and days in the Jalali calendar. The day is
+// clamped to the end of the target month, so 31 Shahrivar + 1 month is 30 Mehr
+func (j JalaliTime) AddDate(years, months, days int) JalaliTime {
+	m := int(j.month) - 1 + months
+	y := j.year + years + m/12
+	m %= 12
+	if m < 0 {
+		m += 12
+		y--
+	}
+	d := j.day
+	if last := JalaliMonthDays(y, JalaliMonth(m+1)); d > last {
+		d = last
+	}
+	h, minute, s := j.t.Clock()
+	return JalaliDate(y, JalaliMonth(m+1), d+days, h, minute, s, j.t.Nanosecond(), j.t.Location())
+}
+
+// StartOfMonth returns 00:00 on the first day of the Jalali month
+func (j JalaliTime) StartOfMonth() JalaliTime {
+	return JalaliDate(j.year, j.month, 1, 0, 0, 0, 0, j.t.Location())
+}
+
+// EndOfMonth returns the last nanosecond of the Jalali month
+func (j JalaliTime) EndOfMonth() JalaliTime {
+	next := JalaliDate(j.year, j.month+1, 1, 0, 0, 0, 0, j.t.Location())
+	return ToJalali(next.t.Add(-time.Nanosecond))
+}
+
+// StartOfYear returns 00:00 on 1 Farvardin
+func (j JalaliTime) StartOfYear() JalaliTime {
+	return JalaliDate(j.year, Farvardin, 1, 0, 0, 0, 0, j.t.Location())
+}
+
+// EndOfYear returns the last nanosecond of Esfand
+func (j JalaliTime) EndOfYear() JalaliTime {
+	next := JalaliDate(j.year+1, Farvardin, 1, 0, 0, 0, 0, j.t.Location())
+	return ToJalali(next.t.Add(-time.Nanosecond))
+}
+
+// String formats the time as "1403/02/11 15:04:05"
+func (j JalaliTime) String() string {
+	return j.Format(JalaliDateTimeLayout)
+}
+
+// IsJalaliLeapYear reports whether year has 366 days
+func IsJalaliLeapYear(year int) bool {
+	leap, _ := jalaliCal(year)
+	return leap == 0
+}
+
+// JalaliMonthDays returns the number of days in a Jalali month
+func JalaliMonthDays(year int, month JalaliMonth) int {
+	switch {
+	case month <= Shahrivar:
+		return 31
+	case month < Esfand:
+		return 30
+	case IsJalaliLeapYear(year):
+		return 30
+	default:
+		return 29
+	}
+}
+
+// =====================
+// Formatting and parsing
+// =====================
+
+const (
+	JalaliDateLayout     = "2006/01/02"
+	JalaliDateTimeLayout = "2006/01/02 15:04:05"
+)
+
+// Layout tokens follow the time package reference layout:
+//
+//	2006 06      year
+//	01 1         month number
+//	January      Persian month name (فروردین)
+//	02 2         day
+//	Monday       Persian weekday name (دوشنبه)
+//	15 03 3      hour (24h, 12h padded, 12h)
+//	04 4 05 5    minute and second
+//	PM           ق.ظ / ب.ظ
+var jalaliTokens = []string{"January", "Monday", "2006", "PM", "06", "01", "02", "15", "03", "04", "05", "1", "2", "3", "4", "5"}
+
+func nextJalaliToken(layout string) (prefix, token, rest string) {
+	for i := 0; i < len(layout); i++ {
+		for _, tok := range jalaliTokens {
+			if strings.HasPrefix(layout[i:], tok) {
+				return layout[:i], tok, layout[i+len(tok):]
+			}
+		}
+	}
+	return layout, "", ""
+}
+
+// Format renders the time using a layout with the tokens described above,
+// digits are ASCII; wrap with ToPersianDigits for Persian digits
+func (j JalaliTime) Format(layout string) string {
+	var b strings.Builder
+	hour, minute, sec := j.t.Clock()
+	for layout != "" {
+		prefix, tok, rest := nextJalaliToken(layout)
+		b.WriteString(prefix)
+		layout = rest
+
+		switch tok {
+		case "2006":
+			b.WriteString(fmt.Sprintf("%04d", j.year))
+		case "06":
+			b.WriteString(fmt.Sprintf("%02d", j.year%100))
+		case "January":
+			b.WriteString(j.month.String())
+		case "01":
+			b.WriteString(fmt.Sprintf("%02d", int(j.month)))
+		case "1":
+			b.WriteString(strconv.Itoa(int(j.month)))
+		case "Monday":
+			b.WriteString(PersianWeekday(j.Weekday()))
+		case "02":
+			b.WriteString(fmt.Sprintf("%02d", j.day))
+		case "2":
+			b.WriteString(strconv.Itoa(j.day))
+		case "15":
+			b.WriteString(fmt.Sprintf("%02d", hour))
+		case "03":
+			b.WriteString(fmt.Sprintf("%02d", hour12(hour)))
+		case "3":
+			b.WriteString(strconv.Itoa(hour12(hour)))
+		case "04":
+			b.WriteString(fmt.Sprintf("%02d", minute))
+		case "4":
+			b.WriteString(strconv.Itoa(minute))
+		case "05":
+			b.WriteString(fmt.Sprintf("%02d", sec))
+		case "5":
+			b.WriteString(strconv.Itoa(sec))
+		case "PM":
+			if hour >= 12 {
+				b.WriteString("ب.ظ")
+			} else {
+				b.WriteString("ق.ظ")
+			}
+		}
+	}
+	return b.String()
+}
+
+// ParseJalali parses a Jalali date using a layout with the same tokens as
+// Format. Persian and Arabic-Indic digits are accepted
+//
+//	ParseJalali(JalaliDateLayout, "۱۴۰۳/۰۲/۱۱", loc)
+func ParseJalali(layout, value string, loc *time.Location) (JalaliTime, error) {
+	orig := value
+	value = ToEnglishDigits(value)
+	fail := func(reason string) (JalaliTime, error) {
+		return JalaliTime{}, fmt.Errorf("%w: parsing %q as %q: %s", ErrInvalidJalaliDate, orig, layout, reason)
+	}
+
+	year, month, day := 0, 1, 1
+	hour, minute, sec := 0, 0, 0
+	pm, hasPM := false, false
+
+	for layout != "" {
+		prefix, tok, rest := nextJalaliToken(layout)
+		if !strings.HasPrefix(value, prefix) {
+			return fail("expected " + strconv.Quote(prefix))
+		}
+		value = value[len(prefix):]
+		layout = rest
+
+		var err error
+		switch tok {
+		case "":
+		case "2006":
+			year, value, err = parseJalaliNumber(value, 4, 4)
+		case "06":
+			year, value, err = parseJalaliNumber(value, 2, 2)
+			if year < 50 {
+				year += 1400
+			} else {
+				year += 1300
+			}
+		case "01":
+			month, value, err = parseJalaliNumber(value, 2, 2)
+		case "1":
+			month, value, err = parseJalaliNumber(value, 1, 2)
+		case "January":
+			month = 0
+			for i, name := range jalaliMonthNames {
+				if strings.HasPrefix(value, name) {
+					month, value = i+1, value[len(name):]
+					break
+				}
+			}
+			if month == 0 {
+				return fail("unknown month name")
+			}
+		case "Monday":
+			found := false
+			for _, name := range persianWeekdayNames {
+				if strings.HasPrefix(value, name) {
+					value, found = value[len(name):], true
+					break
+				}
+			}
+			if !found {
+				return fail("unknown weekday name")
+			}
+		case "02":
+			day, value, err = parseJalaliNumber(value, 2, 2)
+		case "2":
+			day, value, err = parseJalaliNumber(value, 1, 2)
+		case "15", "03":
+			hour, value, err = parseJalaliNumber(value, 2, 2)
+		case "3":
+			hour, value, err = parseJalaliNumber(value, 1, 2)
+		case "04":
+			minute, value, err = parseJalaliNumber(value, 2, 2)
+		case "4":
+			minute, value, err = parseJalaliNumber(value, 1, 2)
+		case "05":
+			sec, value, err = parseJalaliNumber(value, 2, 2)
+		case "5":
+			sec, value, err = parseJalaliNumber(value, 1, 2)
+		case "PM":
+			hasPM = true
+			switch {
+			case strings.HasPrefix(value, "ب.ظ"):
+				pm, value = true, value[len("ب.ظ"):]
+			case strings.HasPrefix(value, "ق.ظ"):
+				value = value[len("ق.ظ"):]
+			case strings.HasPrefix(value, "PM"):
+				pm, value = true, value[2:]
+			case strings.HasPrefix(value, "AM"):
+				value = value[2:]
+			default:
+				return fail("expected AM/PM")
+			}
+		}
+		if err != nil {
+			return fail(err.Error())
+		}
+	}
+	if value != "" {
+		return fail("extra text " + strconv.Quote(value))
+	}
+
+	if hasPM {
+		if hour < 1 || hour > 12 {
+			return fail("hour out of range")
+		}
+		if pm && hour < 12 {
+			hour += 12
+		} else if !pm && hour == 12 {
+			hour = 0
+		}
+	}
+
+	if month < 1 || month > 12 {
+		return fail("month out of range")
+	}
+	if day < 1 || day > JalaliMonthDays(year, JalaliMonth(month)) {
+		return fail("day out of range")
+	}
+	if hour > 23 || minute > 59 || sec > 59 {
+		return fail("time out of range")
+	}
+	return JalaliDate(year, JalaliMonth(month), day, hour, minute, sec, 0, loc), nil
+}
+
+func parseJalaliNumber(value string, minDigits, maxDigits int) (int, string, error) {
+	n := 0
+	for n < maxDigits && n < len(value) && value[n] >= '0' && value[n] <= '9' {
+		n++
+	}
+	if n < minDigits {
+		return 0, value, errors.New("expected number")
+	}
+	v, _ := strconv.Atoi(value[:n])
+	return v, value[n:], nil
+}
+
+func hour12(h int) int {
+	h %= 12
+	if h == 0 {
+		return 12
+	}
+	return h
+}
+
+// =====================
+// Calendar arithmetic
+// =====================
+
+// jalaliBreaks are the years the 33-year leap cycle shifts (Borkowski)
+var jalaliBreaks = [...]int{
+	-61, 9, 38, 199, 426, 686, 756, 818, 1111, 1181, 1210,
+	1635, 2060, 2097, 2192, 2262, 2324, 2394, 2456, 3178,
+}
+
+// jalaliCal returns the number of years since the last leap year (0 means
+// jy is a leap year) and the day in March of the Gregorian year jy+621 on
+// which 1 Farvardin falls
+func jalaliCal(jy int) (leap, march int) {
+	gy := jy + 621
+	leapJ := -14
+	jp := jalaliBreaks[0]
+	jump := 0
+	for _, jm := range jalaliBreaks[1:] {
+		jump = jm - jp
+		if jy < jm {
+			break
+		}
+		leapJ += jump/33*8 + (jump%33)/4
+		jp = jm
+	}
+	n := jy - jp
+
+	leapJ += n/33*8 + (n%33+3)/4
+	if jump%33 == 4 && jump-n == 4 {
+		leapJ++
+	}
+	leapG := gy/4 - (gy/100+1)*3/4 - 150
+	march = 20 + leapJ - leapG
+
+	if jump-n < 6 {
+		n = n - jump + (jump+4)/33*33
+	}
+	leap = ((n+1)%33 - 1) % 4
+	if leap == -1 {
+		leap = 4
+	}
+	return leap, march
+}
+
+func jalaliNowruz(jy int) int {
+	_, march := jalaliCal(jy)
+	return march
+}
+
+func jalaliDaysBeforeMonth(m JalaliMonth) int {
+	if m <= Mehr {
+		return (int(m) - 1) * 31
+	}
+	return 186 + (int(m)-7)*30
+}
+
+func civilDays(t time.Time) int {
+	return int(t.Unix() / 86400)
+}
@@ -0,0 +1,114 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToJalali(t *testing.T) {
+	tests := []struct {
+		gregorian time.Time
+		year      int
+		month     JalaliMonth
+		day       int
+	}{
+		{time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC), 1403, Farvardin, 1},
+		{time.Date(2024, 3, 19, 12, 0, 0, 0, time.UTC), 1402, Esfand, 29},
+		{time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC), 1403, Esfand, 30},
+		{time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC), 1404, Farvardin, 1},
+		{time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), 1403, Ordibehesht, 12},
+		{time.Date(2024, 9, 22, 0, 0, 0, 0, time.UTC), 1403, Mehr, 1},
+		{time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), 1378, Dey, 11},
+		{time.Date(1979, 2, 11, 0, 0, 0, 0, time.UTC), 1357, Bahman, 22},
+	}
+
+	for _, tt := range tests {
+		j := ToJalali(tt.gregorian)
+		y, m, d := j.Date()
+		assert.Equal(t, tt.year, y, tt.gregorian.String())
+		assert.Equal(t, tt.month, m, tt.gregorian.String())
+		assert.Equal(t, tt.day, d, tt.gregorian.String())
+		assert.True(t, tt.gregorian.Equal(JalaliDate(y, m, d, tt.gregorian.Hour(), 0, 0, 0, time.UTC).Time()))
+	}
+}
+
+func TestJalaliRoundTrip(t *testing.T) {
+	start := time.Date(1950, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 365*120; i += 7 {
+		g := start.AddDate(0, 0, i)
+		j := ToJalali(g)
+		require.True(t, g.Equal(JalaliDate(j.Year(), j.Month(), j.Day(), 0, 0, 0, 0, time.UTC).Time()), g.String())
+		require.LessOrEqual(t, j.Day(), JalaliMonthDays(j.Year(), j.Month()))
+	}
+}
+
+func TestIsJalaliLeapYear(t *testing.T) {
+	for _, y := range []int{1399, 1403, 1408} {
+		assert.True(t, IsJalaliLeapYear(y), y)
+	}
+	for _, y := range []int{1400, 1401, 1402, 1404} {
+		assert.False(t, IsJalaliLeapYear(y), y)
+	}
+	assert.Equal(t, 30, JalaliMonthDays(1403, Esfand))
+	assert.Equal(t, 29, JalaliMonthDays(1402, Esfand))
+}
+
+func TestJalaliMonthBoundaries(t *testing.T) {
+	tehran := time.FixedZone("IRST", 3*3600+1800)
+	j := JalaliDate(1403, Esfand, 15, 10, 30, 0, 0, tehran)
+
+	start := j.StartOfMonth()
+	assert.Equal(t, "1403/12/01 00:00:00", start.String())
+
+	end := j.EndOfMonth()
+	assert.Equal(t, "1403/12/30 23:59:59", end.String())
+	assert.Equal(t, 999999999, end.Time().Nanosecond())
+	assert.Equal(t, "1404/01/01", ToJalali(end.Time().Add(time.Nanosecond)).Format(JalaliDateLayout))
+
+	assert.Equal(t, "1403/01/01 00:00:00", j.StartOfYear().String())
+	assert.Equal(t, "1403/12/30 23:59:59", j.EndOfYear().String())
+	assert.Equal(t, 366, j.EndOfYear().YearDay())
+}
+
+func TestJalaliAddDate(t *testing.T) {
+	j := JalaliDate(1403, Shahrivar, 31, 9, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "1403/07/30", j.AddDate(0, 1, 0).Format(JalaliDateLayout))
+	assert.Equal(t, "1404/06/31", j.AddDate(1, 0, 0).Format(JalaliDateLayout))
+	assert.Equal(t, "1402/12/29", JalaliDate(1403, Esfand, 30, 0, 0, 0, 0, time.UTC).AddDate(-1, 0, 0).Format(JalaliDateLayout))
+	assert.Equal(t, "1403/07/01", j.AddDate(0, 0, 1).Format(JalaliDateLayout))
+	assert.Equal(t, "1402/12/29", JalaliDate(1403, Farvardin, 31, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0).Format(JalaliDateLayout))
+	assert.Equal(t, 9, j.AddDate(0, 1, 0).Time().Hour())
+}
+
+func TestJalaliFormat(t *testing.T) {
+	j := JalaliDate(1403, Ordibehesht, 2, 15, 4, 5, 0, time.UTC)
+
+	assert.Equal(t, "1403/02/02 15:04:05", j.String())
+	assert.Equal(t, "2 اردیبهشت 1403", j.Format("2 January 2006"))
+	assert.Equal(t, "یکشنبه 03/2/2", j.Format("Monday 06/1/2"))
+	assert.Equal(t, "03:04 ب.ظ", j.Format("03:04 PM"))
+	assert.Equal(t, "۱۴۰۳/۰۲/۰۲", ToPersianDigits(j.Format(JalaliDateLayout)))
+}
+
+func TestParseJalali(t *testing.T) {
+	j, err := ParseJalali(JalaliDateTimeLayout, "1403/12/30 23:59:59", time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 3, 20, 23, 59, 59, 0, time.UTC), j.Time())
+
+	j, err = ParseJalali(JalaliDateLayout, "۱۴۰۳/۰۲/۱۲", time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), j.Time())
+
+	j, err = ParseJalali("2 January 2006 3:04 PM", "12 اردیبهشت 1403 3:30 ب.ظ", time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 15, 30, 0, 0, time.UTC), j.Time())
+
+	for _, value := range []string{"1402/12/30", "1403/13/01", "1403/1/01", "1403/01/01 extra", "abcd/01/01"} {
+		_, err := ParseJalali(JalaliDateLayout, value, time.UTC)
+		assert.ErrorIs(t, err, ErrInvalidJalaliDate, value)
+	}
+}
@@ -1,16 +1,742 @@
 package common
 
-import "encoding/json"
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
 
+// ConvertHook transforms a value before it is converted from one type to
+// another. Return data unchanged to let the default conversion run
+type ConvertHook func(from, to reflect.Type, data any) (any, error)
+
+// FieldHook transforms the value of a single destination field
+type FieldHook func(data any) (any, error)
+
+// ConversionError describes a value that could not be converted
+type ConversionError struct {
+	Path string
+	From reflect.Type
+	To   reflect.Type
+	Err  error
+}
+
+func (e *ConversionError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "value"
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("convert %s from %v to %v: %v", path, e.From, e.To, e.Err)
+	}
+	return fmt.Sprintf("convert %s: cannot convert %v to %v", path, e.From, e.To)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// Mapper converts values between structs, maps and slices using reflection.
+// Struct fields are matched by tag name (json by default), falling back to a
+// case-insensitive match, like encoding/json. Types with their own JSON or
+// text encoding, such as json.RawMessage, time.Time and uuid.UUID, convert
+// the way a JSON round trip would, and so do structs with json ",string"
+// fields, which skip hooks and WithoutOmitEmpty
+type Mapper struct {
+	tagName    string
+	hooks      []ConvertHook
+	fieldHooks map[string]FieldHook
+	omitEmpty  bool
+
+	fields sync.Map // reflect.Type → []fieldInfo
+}
+
+// MapperOption configures a Mapper
+type MapperOption func(*Mapper)
+
+// WithTagName sets the struct tag used for field names, defaults to "json"
+func WithTagName(tag string) MapperOption {
+	return func(m *Mapper) {
+		m.tagName = tag
+	}
+}
+
+// WithConvertHook adds a hook that runs before every conversion
+func WithConvertHook(hook ConvertHook) MapperOption {
+	return func(m *Mapper) {
+		m.hooks = append(m.hooks, hook)
+	}
+}
+
+// WithFieldHook adds a hook for a destination field, addressed by its tag
+// name path, e.g. "address.city"
+func WithFieldHook(path string, hook FieldHook) MapperOption {
+	return func(m *Mapper) {
+		m.fieldHooks[path] = hook
+	}
+}
+
+// WithoutOmitEmpty keeps empty fields tagged omitempty when converting
+// structs to maps
+func WithoutOmitEmpty() MapperOption {
+	return func(m *Mapper) {
+		m.omitEmpty = false
+	}
+}
+
+// NewMapper creates a Mapper
+func NewMapper(opts ...MapperOption) *Mapper {
+	m := &Mapper{
+		tagName:    "json",
+		fieldHooks: make(map[string]FieldHook),
+		omitEmpty:  true,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+var defaultMapper = NewMapper()
+
+// TypeConverter converts data into T: struct↔struct, struct↔map (by json
+// tags), and slices of either
 func TypeConverter[T any](data any) (T, error) {
+	return Convert[T](defaultMapper, data)
+}
+
+// Convert converts data into T using m
+func Convert[T any](m *Mapper, data any) (T, error) {
 	var result T
-	dataJson, err := json.Marshal(&data)
-	if err != nil {
-		return result, err
+	err := m.Map(data, &result)
+	return result, err
+}
+
+// StructToMap converts a struct into a map keyed by json tag names
+func StructToMap(data any) (map[string]any, error) {
+	return TypeConverter[map[string]any](data)
+}
+
+// MapToStruct converts a map keyed by json tag names into T
+func MapToStruct[T any](data map[string]any) (T, error) {
+	return TypeConverter[T](data)
+}
+
+// Map converts src into the value dst points to
+func (m *Mapper) Map(src, dst any) error {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() {
+		return &ConversionError{From: reflect.TypeOf(src), To: reflect.TypeOf(dst), Err: fmt.Errorf("destination must be a non-nil pointer")}
+	}
+	return m.convert("", reflect.ValueOf(src), dv.Elem())
+}
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+func (m *Mapper) convert(path string, src, dst reflect.Value) error {
+	// Unwrap interfaces and pointers on the source side
+	for src.IsValid() && (src.Kind() == reflect.Interface || src.Kind() == reflect.Pointer) {
+		if src.IsNil() {
+			src = reflect.Value{}
+			break
+		}
+		src = src.Elem()
+	}
+
+	if hook, ok := m.fieldHooks[path]; ok && path != "" {
+		out, err := hook(valueInterface(src))
+		if err != nil {
+			return &ConversionError{Path: path, From: typeOf(src), To: dst.Type(), Err: err}
+		}
+		src = reflect.ValueOf(out)
+	}
+
+	for _, hook := range m.hooks {
+		if !src.IsValid() {
+			break
+		}
+		out, err := hook(src.Type(), dst.Type(), src.Interface())
+		if err != nil {
+			return &ConversionError{Path: path, From: src.Type(), To: dst.Type(), Err: err}
+		}
+		src = reflect.ValueOf(out)
+	}
+
+	if !src.IsValid() {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	st, dt := src.Type(), dst.Type()
+
+	switch {
+	case dt.Kind() == reflect.Pointer:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dt.Elem()))
+		}
+		return m.convert(path, src, dst.Elem())
+
+	case dt.Kind() == reflect.Interface && dt.NumMethod() == 0:
+		v, err := m.toInterface(path, src)
+		if err != nil {
+			return err
+		}
+		if v.IsValid() {
+			dst.Set(v)
+		} else {
+			dst.Set(reflect.Zero(dt))
+		}
+		return nil
+
+	case st == dt && st.Kind() != reflect.Slice && st.Kind() != reflect.Map && st.Kind() != reflect.Struct:
+		dst.Set(src)
+		return nil
+
+	case st == dt && st.Kind() == reflect.Struct && !hasExportedFields(st):
+		// Opaque value types such as time.Time
+		dst.Set(src)
+		return nil
+
+	case dt.Kind() == reflect.Interface:
+		if st.Implements(dt) {
+			dst.Set(src)
+			return nil
+		}
+
+	case reflect.PointerTo(dt).Implements(textUnmarshalerType) && st.Kind() == reflect.String:
+		if err := dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(src.String())); err != nil {
+			return &ConversionError{Path: path, From: st, To: dt, Err: err}
+		}
+		return nil
+
+	case dt.Kind() == reflect.String && st.Kind() != reflect.String && textMarshaler(src) != nil:
+		text, err := textMarshaler(src).MarshalText()
+		if err != nil {
+			return &ConversionError{Path: path, From: st, To: dt, Err: err}
+		}
+		dst.SetString(string(text))
+		return nil
+
+	case jsonMarshaler(src) != nil || reflect.PointerTo(dt).Implements(jsonUnmarshalerType):
+		return convertJSON(path, src, dst)
+
+	case m.quotesFields(st) || m.quotesFields(dt):
+		return convertJSON(path, src, dst)
+	}
+
+	switch dt.Kind() {
+	case reflect.Struct:
+		return m.toStruct(path, src, dst)
+	case reflect.Map:
+		return m.toMap(path, src, dst)
+	case reflect.Slice, reflect.Array:
+		return m.toSlice(path, src, dst)
+	case reflect.String:
+		if st.Kind() == reflect.String {
+			dst.SetString(src.String())
+			return nil
+		}
+	case reflect.Bool:
+		if st.Kind() == reflect.Bool {
+			dst.SetBool(src.Bool())
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return convertNumber(path, src, dst)
+	}
+
+	return &ConversionError{Path: path, From: st, To: dt}
+}
+
+// toInterface produces a generic value (map[string]any, []any or a scalar)
+// for an empty interface destination
+func (m *Mapper) toInterface(path string, src reflect.Value) (reflect.Value, error) {
+	if jsonMarshaler(src) != nil {
+		var out any
+		if err := convertJSON(path, src, reflect.ValueOf(&out).Elem()); err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(out), nil
+	}
+	if text := textMarshaler(src); text != nil {
+		data, err := text.MarshalText()
+		if err != nil {
+			return reflect.Value{}, &ConversionError{Path: path, From: src.Type(), To: reflect.TypeOf(""), Err: err}
+		}
+		return reflect.ValueOf(string(data)), nil
+	}
+
+	st := src.Type()
+	switch st.Kind() {
+	case reflect.Struct:
+		if !hasExportedFields(st) {
+			return src, nil
+		}
+		if m.quotesFields(st) {
+			var out any
+			if err := convertJSON(path, src, reflect.ValueOf(&out).Elem()); err != nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(out), nil
+		}
+		out := reflect.New(reflect.TypeOf(map[string]any{})).Elem()
+		if err := m.toMap(path, src, out); err != nil {
+			return reflect.Value{}, err
+		}
+		return out, nil
+	case reflect.Map:
+		if st.Key().Kind() != reflect.String {
+			return src, nil
+		}
+		if src.IsNil() {
+			// null, as in a JSON round trip
+			return reflect.Value{}, nil
+		}
+		out := reflect.New(reflect.TypeOf(map[string]any{})).Elem()
+		if err := m.toMap(path, src, out); err != nil {
+			return reflect.Value{}, err
+		}
+		return out, nil
+	case reflect.Slice, reflect.Array:
+		if st.Kind() == reflect.Slice && src.IsNil() {
+			return reflect.Value{}, nil
+		}
+		if st.Elem().Kind() == reflect.Uint8 {
+			return src, nil
+		}
+		out := reflect.New(reflect.TypeOf([]any{})).Elem()
+		if err := m.toSlice(path, src, out); err != nil {
+			return reflect.Value{}, err
+		}
+		return out, nil
+	default:
+		return src, nil
+	}
+}
+
+func (m *Mapper) toStruct(path string, src, dst reflect.Value) error {
+	dt := dst.Type()
+	switch src.Kind() {
+	case reflect.Struct:
+		values := make(map[string]reflect.Value)
+		for _, f := range m.structFields(src.Type()) {
+			v, ok := fieldByIndex(src, f.index)
+			if ok {
+				values[f.name] = v
+			}
+		}
+		return m.fillStruct(path, dst, func(name string) (reflect.Value, bool) {
+			return lookupFold(values, name)
+		})
+
+	case reflect.Map:
+		if src.Type().Key().Kind() != reflect.String {
+			break
+		}
+		values := make(map[string]reflect.Value, src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			values[iter.Key().String()] = iter.Value()
+		}
+		return m.fillStruct(path, dst, func(name string) (reflect.Value, bool) {
+			return lookupFold(values, name)
+		})
+	}
+	return &ConversionError{Path: path, From: src.Type(), To: dt}
+}
+
+func (m *Mapper) fillStruct(path string, dst reflect.Value, lookup func(string) (reflect.Value, bool)) error {
+	for _, f := range m.structFields(dst.Type()) {
+		fieldPath := joinPath(path, f.name)
+		v, ok := lookup(f.name)
+		if !ok {
+			if _, hooked := m.fieldHooks[fieldPath]; !hooked {
+				continue
+			}
+		}
+		field, ok := fieldByIndexAlloc(dst, f.index)
+		if !ok {
+			continue
+		}
+		if err := m.convert(fieldPath, v, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Mapper) toMap(path string, src, dst reflect.Value) error {
+	dt := dst.Type()
+	if dt.Key().Kind() != reflect.String {
+		return &ConversionError{Path: path, From: src.Type(), To: dt}
+	}
+
+	out := reflect.MakeMap(dt)
+	set := func(key string, v reflect.Value) error {
+		elem := reflect.New(dt.Elem()).Elem()
+		if err := m.convert(joinPath(path, key), v, elem); err != nil {
+			return err
+		}
+		out.SetMapIndex(reflect.ValueOf(key).Convert(dt.Key()), elem)
+		return nil
+	}
+
+	switch src.Kind() {
+	case reflect.Struct:
+		for _, f := range m.structFields(src.Type()) {
+			v, ok := fieldByIndex(src, f.index)
+			if !ok || (m.omitEmpty && f.omitEmpty && v.IsZero()) {
+				continue
+			}
+			if err := set(f.name, v); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if src.Type().Key().Kind() != reflect.String {
+			return &ConversionError{Path: path, From: src.Type(), To: dt}
+		}
+		if src.IsNil() {
+			dst.Set(reflect.Zero(dt))
+			return nil
+		}
+		iter := src.MapRange()
+		for iter.Next() {
+			if err := set(iter.Key().String(), iter.Value()); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return &ConversionError{Path: path, From: src.Type(), To: dt}
+	}
+
+	dst.Set(out)
+	return nil
+}
+
+func (m *Mapper) toSlice(path string, src, dst reflect.Value) error {
+	dt := dst.Type()
+	if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+		return &ConversionError{Path: path, From: src.Type(), To: dt}
+	}
+	if src.Kind() == reflect.Slice && src.IsNil() {
+		dst.Set(reflect.Zero(dt))
+		return nil
+	}
+
+	n := src.Len()
+	out := dst
+	if dt.Kind() == reflect.Slice {
+		out = reflect.MakeSlice(dt, n, n)
+	} else if n > dt.Len() {
+		return &ConversionError{Path: path, From: src.Type(), To: dt, Err: fmt.Errorf("%d elements do not fit", n)}
+	}
+
+	for i := 0; i < n; i++ {
+		if err := m.convert(fmt.Sprintf("%s[%d]", path, i), src.Index(i), out.Index(i)); err != nil {
+			return err
+		}
+	}
+	if dt.Kind() == reflect.Slice {
+		dst.Set(out)
+	}
+	return nil
+}
+
+func convertNumber(path string, src, dst reflect.Value) error {
+	st, dt := src.Type(), dst.Type()
+	fail := &ConversionError{Path: path, From: st, To: dt}
+
+	switch st.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := src.Int()
+		switch dt.Kind() {
+		case reflect.Float32, reflect.Float64:
+			dst.SetFloat(float64(n))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if n < 0 || dst.OverflowUint(uint64(n)) {
+				fail.Err = fmt.Errorf("value %d overflows", n)
+				return fail
+			}
+			dst.SetUint(uint64(n))
+		default:
+			if dst.OverflowInt(n) {
+				fail.Err = fmt.Errorf("value %d overflows", n)
+				return fail
+			}
+			dst.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := src.Uint()
+		switch dt.Kind() {
+		case reflect.Float32, reflect.Float64:
+			dst.SetFloat(float64(n))
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if n > 1<<63-1 || dst.OverflowInt(int64(n)) {
+				fail.Err = fmt.Errorf("value %d overflows", n)
+				return fail
+			}
+			dst.SetInt(int64(n))
+		default:
+			if dst.OverflowUint(n) {
+				fail.Err = fmt.Errorf("value %d overflows", n)
+				return fail
+			}
+			dst.SetUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		f := src.Float()
+		switch dt.Kind() {
+		case reflect.Float32, reflect.Float64:
+			dst.SetFloat(f)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			// Accept whole floats, e.g. numbers decoded from JSON
+			if f != float64(int64(f)) || dst.OverflowInt(int64(f)) {
+				fail.Err = fmt.Errorf("value %v is not a valid %v", f, dt)
+				return fail
+			}
+			dst.SetInt(int64(f))
+		default:
+			if f < 0 || f != float64(uint64(f)) || dst.OverflowUint(uint64(f)) {
+				fail.Err = fmt.Errorf("value %v is not a valid %v", f, dt)
+				return fail
+			}
+			dst.SetUint(uint64(f))
+		}
+	default:
+		return fail
+	}
+	return nil
+}
+
+// =====================
+// Struct field metadata
+// =====================
+
+type fieldInfo struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	// quoted marks json ",string" fields, encoded as JSON strings
+	quoted bool
+}
+
+// quotesFields reports whether t is a struct with json ",string" fields,
+// which convert through encoding/json
+func (m *Mapper) quotesFields(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || m.tagName != "json" {
+		return false
+	}
+	for _, f := range m.structFields(t) {
+		if f.quoted {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Mapper) structFields(t reflect.Type) []fieldInfo {
+	if cached, ok := m.fields.Load(t); ok {
+		return cached.([]fieldInfo)
+	}
+
+	var fields []fieldInfo
+	seen := make(map[string]bool)
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		var embedded [][]int
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			idx := append(append([]int(nil), index...), i)
+
+			tag := sf.Tag.Get(m.tagName)
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				// Flatten embedded structs after the outer fields, which win
+				embedded = append(embedded, idx)
+				continue
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			fields = append(fields, fieldInfo{
+				name:      name,
+				index:     idx,
+				omitEmpty: hasTagOption(opts, "omitempty"),
+				quoted:    hasTagOption(opts, "string") && quotable(ft.Kind()),
+			})
+		}
+		for _, idx := range embedded {
+			ft := t.Field(idx[len(idx)-1]).Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			walk(ft, idx)
+		}
+	}
+	walk(t, nil)
+
+	m.fields.Store(t, fields)
+	return fields
+}
+
+// fieldByIndex reads a (possibly embedded) field, reporting false if it
+// sits behind a nil embedded pointer
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// fieldByIndexAlloc returns a settable field, allocating nil embedded pointers
+func fieldByIndexAlloc(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, v.CanSet()
+}
+
+func textMarshaler(v reflect.Value) encoding.TextMarshaler {
+	if v.Type().Implements(textMarshalerType) {
+		return v.Interface().(encoding.TextMarshaler)
+	}
+	if v.CanAddr() && reflect.PointerTo(v.Type()).Implements(textMarshalerType) {
+		return v.Addr().Interface().(encoding.TextMarshaler)
+	}
+	return nil
+}
+
+func jsonMarshaler(v reflect.Value) json.Marshaler {
+	if v.Type().Implements(jsonMarshalerType) {
+		return v.Interface().(json.Marshaler)
+	}
+	if v.CanAddr() && reflect.PointerTo(v.Type()).Implements(jsonMarshalerType) {
+		return v.Addr().Interface().(json.Marshaler)
+	}
+	return nil
+}
+
+// convertJSON converts through encoding/json, for types that define their
+// own JSON encoding
+func convertJSON(path string, src, dst reflect.Value) error {
+	var v any = src.Interface()
+	if marshaler := jsonMarshaler(src); marshaler != nil {
+		v = marshaler
+	}
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = json.Unmarshal(data, dst.Addr().Interface())
 	}
-	err = json.Unmarshal(dataJson, &result)
 	if err != nil {
-		return result, err
+		return &ConversionError{Path: path, From: src.Type(), To: dst.Type(), Err: err}
+	}
+	return nil
+}
+
+func hasTagOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// quotable reports whether encoding/json honors ",string" for a kind
+func quotable(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func hasExportedFields(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupFold(values map[string]reflect.Value, name string) (reflect.Value, bool) {
+	if v, ok := values[name]; ok {
+		return v, true
+	}
+	for k, v := range values {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func typeOf(v reflect.Value) reflect.Type {
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Type()
+}
+
+func valueInterface(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
 	}
-	return result, nil
+	return v.Interface()
 }
@@ -0,0 +1,394 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapperBase struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type mapperAddress struct {
+	City   string `json:"city"`
+	Street string `json:"street,omitempty"`
+}
+
+type mapperUser struct {
+	mapperBase
+	Name     string            `json:"name"`
+	Email    *string           `json:"email,omitempty"`
+	Age      int               `json:"age"`
+	Score    float64           `json:"score"`
+	Tags     []string          `json:"tags"`
+	Address  *mapperAddress    `json:"address,omitempty"`
+	Meta     map[string]string `json:"meta,omitempty"`
+	Password string            `json:"-"`
+	internal string
+}
+
+type mapperUserDTO struct {
+	ID        string         `json:"id"`
+	CreatedAt time.Time      `json:"createdAt"`
+	Name      string         `json:"name"`
+	Email     string         `json:"email"`
+	Age       int64          `json:"age"`
+	Score     float32        `json:"score"`
+	Tags      []string       `json:"tags"`
+	Address   mapperAddress  `json:"address"`
+	Password  string         `json:"password"`
+	Extra     map[string]any `json:"extra"`
+}
+
+func newMapperUser() mapperUser {
+	email := "ali@example.com"
+	return mapperUser{
+		mapperBase: mapperBase{
+			ID:        uuid.MustParse("6f1c1b8e-2a51-4d8e-9a0e-1b2c3d4e5f60"),
+			CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		},
+		Name:     "Ali",
+		Email:    &email,
+		Age:      30,
+		Score:    4.5,
+		Tags:     []string{"a", "b"},
+		Address:  &mapperAddress{City: "Tehran"},
+		Password: "secret",
+		internal: "hidden",
+	}
+}
+
+func TestTypeConverter_StructToStruct(t *testing.T) {
+	user := newMapperUser()
+
+	dto, err := TypeConverter[mapperUserDTO](user)
+	require.NoError(t, err)
+
+	assert.Equal(t, "6f1c1b8e-2a51-4d8e-9a0e-1b2c3d4e5f60", dto.ID)
+	assert.True(t, user.CreatedAt.Equal(dto.CreatedAt))
+	assert.Equal(t, "Ali", dto.Name)
+	assert.Equal(t, "ali@example.com", dto.Email)
+	assert.Equal(t, int64(30), dto.Age)
+	assert.Equal(t, float32(4.5), dto.Score)
+	assert.Equal(t, []string{"a", "b"}, dto.Tags)
+	assert.Equal(t, "Tehran", dto.Address.City)
+	assert.Empty(t, dto.Password, "fields tagged json:\"-\" are skipped")
+
+	// The result must not share memory with the source
+	dto.Tags[0] = "changed"
+	assert.Equal(t, "a", user.Tags[0])
+}
+
+func TestTypeConverter_StructToStructRoundTrip(t *testing.T) {
+	user := newMapperUser()
+
+	dto, err := TypeConverter[mapperUserDTO](&user)
+	require.NoError(t, err)
+
+	back, err := TypeConverter[mapperUser](dto)
+	require.NoError(t, err)
+
+	assert.Equal(t, user.ID, back.ID)
+	assert.Equal(t, user.Name, back.Name)
+	require.NotNil(t, back.Email)
+	assert.Equal(t, *user.Email, *back.Email)
+	assert.Equal(t, user.Address, back.Address)
+	assert.Empty(t, back.Password)
+	assert.Empty(t, back.internal)
+}
+
+func TestTypeConverter_StructToMap(t *testing.T) {
+	user := newMapperUser()
+	user.Email = nil
+
+	m, err := StructToMap(user)
+	require.NoError(t, err)
+
+	assert.Equal(t, user.ID.String(), m["id"], "embedded fields are flattened")
+	assert.Equal(t, "2024-05-01T10:00:00Z", m["createdAt"], "values are encoded like encoding/json does")
+	assert.Equal(t, "Ali", m["name"])
+	assert.Equal(t, 30, m["age"], "numbers keep their type")
+	assert.Equal(t, []any{"a", "b"}, m["tags"])
+	assert.Equal(t, map[string]any{"city": "Tehran"}, m["address"], "nested structs become maps, omitempty respected")
+	assert.NotContains(t, m, "email", "nil omitempty pointers are omitted")
+	assert.NotContains(t, m, "meta")
+	assert.NotContains(t, m, "Password")
+	assert.NotContains(t, m, "internal")
+}
+
+func TestTypeConverter_MapToStruct(t *testing.T) {
+	m := map[string]any{
+		"id":        "6f1c1b8e-2a51-4d8e-9a0e-1b2c3d4e5f60",
+		"createdAt": "2024-05-01T10:00:00Z",
+		"NAME":      "Sara",
+		"age":       float64(25),
+		"tags":      []any{"x"},
+		"address":   map[string]any{"city": "Shiraz"},
+		"meta":      map[string]any{"k": "v"},
+		"unknown":   true,
+	}
+
+	user, err := MapToStruct[mapperUser](m)
+	require.NoError(t, err)
+
+	assert.Equal(t, uuid.MustParse("6f1c1b8e-2a51-4d8e-9a0e-1b2c3d4e5f60"), user.ID)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), user.CreatedAt)
+	assert.Equal(t, "Sara", user.Name, "keys match case-insensitively")
+	assert.Equal(t, 25, user.Age, "whole floats convert to ints")
+	assert.Equal(t, []string{"x"}, user.Tags)
+	require.NotNil(t, user.Address)
+	assert.Equal(t, "Shiraz", user.Address.City)
+	assert.Equal(t, map[string]string{"k": "v"}, user.Meta)
+}
+
+func TestTypeConverter_Slices(t *testing.T) {
+	users := []mapperUser{newMapperUser(), newMapperUser()}
+	users[1].Name = "Reza"
+
+	dtos, err := TypeConverter[[]mapperUserDTO](&users)
+	require.NoError(t, err)
+	require.Len(t, dtos, 2)
+	assert.Equal(t, "Reza", dtos[1].Name)
+
+	ptrs, err := TypeConverter[[]*mapperUserDTO](users)
+	require.NoError(t, err)
+	require.Len(t, ptrs, 2)
+	assert.Equal(t, "Ali", ptrs[0].Name)
+
+	var nilUsers []mapperUser
+	empty, err := TypeConverter[[]mapperUserDTO](nilUsers)
+	require.NoError(t, err)
+	assert.Nil(t, empty)
+
+	arr, err := TypeConverter[[2]int]([]int64{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, [2]int{1, 2}, arr)
+
+	_, err = TypeConverter[[1]int]([]int{1, 2})
+	assert.Error(t, err)
+}
+
+func TestTypeConverter_Nil(t *testing.T) {
+	dto, err := TypeConverter[*mapperUserDTO](nil)
+	require.NoError(t, err)
+	assert.Nil(t, dto)
+
+	var user *mapperUser
+	out, err := TypeConverter[mapperUserDTO](user)
+	require.NoError(t, err)
+	assert.Equal(t, mapperUserDTO{}, out)
+}
+
+func TestTypeConverter_Errors(t *testing.T) {
+	_, err := TypeConverter[mapperUserDTO](map[string]any{"age": "thirty"})
+	var convErr *ConversionError
+	require.ErrorAs(t, err, &convErr)
+	assert.Equal(t, "age", convErr.Path)
+
+	_, err = TypeConverter[mapperUser](map[string]any{"id": "not-a-uuid"})
+	require.ErrorAs(t, err, &convErr)
+	assert.Equal(t, "id", convErr.Path)
+
+	_, err = TypeConverter[int8](300)
+	assert.Error(t, err, "overflow is an error")
+
+	_, err = TypeConverter[uint](-1)
+	assert.Error(t, err)
+
+	_, err = TypeConverter[int](1.5)
+	assert.Error(t, err, "fractions are not truncated")
+
+	_, err = TypeConverter[[]string](map[string]any{"tags": []any{1}})
+	assert.Error(t, err)
+
+	_, err = TypeConverter[mapperUser](map[string]any{"tags": []any{"ok", 2}})
+	require.ErrorAs(t, err, &convErr)
+	assert.Equal(t, "tags[1]", convErr.Path)
+
+	err = NewMapper().Map(map[string]any{}, mapperUser{})
+	assert.Error(t, err, "destination must be a pointer")
+}
+
+func TestMapper_Hooks(t *testing.T) {
+	upper := func(from, to reflect.Type, data any) (any, error) {
+		if s, ok := data.(string); ok && to.Kind() == reflect.String {
+			return strings.ToUpper(s), nil
+		}
+		return data, nil
+	}
+	unix := func(from, to reflect.Type, data any) (any, error) {
+		if ts, ok := data.(int64); ok && to == reflect.TypeOf(time.Time{}) {
+			return time.Unix(ts, 0).UTC(), nil
+		}
+		return data, nil
+	}
+	m := NewMapper(
+		WithConvertHook(upper),
+		WithConvertHook(unix),
+		WithFieldHook("address.city", func(data any) (any, error) {
+			if data == nil {
+				return "Unknown", nil
+			}
+			return data, nil
+		}),
+	)
+
+	dto, err := Convert[mapperUserDTO](m, map[string]any{
+		"name":      "ali",
+		"createdAt": int64(1714557600),
+		"address":   map[string]any{"street": "valiasr"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ALI", dto.Name)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), dto.CreatedAt)
+	assert.Equal(t, "UNKNOWN", dto.Address.City, "field hook fills missing values")
+	assert.Equal(t, "VALIASR", dto.Address.Street)
+
+	failing := NewMapper(WithFieldHook("name", func(any) (any, error) {
+		return nil, errors.New("boom")
+	}))
+	_, err = Convert[mapperUserDTO](failing, map[string]any{"name": "x"})
+	assert.ErrorContains(t, err, "boom")
+}
+
+func TestMapper_Options(t *testing.T) {
+	type row struct {
+		UserName string `db:"user_name"`
+		Note     string `json:"note,omitempty"`
+	}
+
+	m := NewMapper(WithTagName("db"))
+	out, err := Convert[map[string]any](m, row{UserName: "ali"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"user_name": "ali", "Note": ""}, out)
+
+	m = NewMapper(WithoutOmitEmpty())
+	out, err = Convert[map[string]any](m, mapperAddress{City: "Tabriz"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"city": "Tabriz", "street": ""}, out)
+}
+
+// mapperMoney encodes as a decimal string in JSON
+type mapperMoney struct {
+	cents int64
+}
+
+func (m mapperMoney) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatFloat(float64(m.cents)/100, 'f', 2, 64))
+}
+
+func (m *mapperMoney) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	m.cents = int64(f * 100)
+	return err
+}
+
+type mapperOrder struct {
+	Total mapperMoney     `json:"total"`
+	Raw   json.RawMessage `json:"raw,omitempty"`
+}
+
+func TestTypeConverter_JSONTypes(t *testing.T) {
+	m, err := TypeConverter[map[string]any](json.RawMessage(`{"name":"Ali","tags":["a"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "Ali", "tags": []any{"a"}}, m, "raw JSON converts to a map")
+
+	out, err := StructToMap(mapperOrder{Total: mapperMoney{cents: 1250}, Raw: json.RawMessage(`{"a":1}`)})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"total": "12.50", "raw": map[string]any{"a": float64(1)}}, out)
+
+	order, err := MapToStruct[mapperOrder](map[string]any{"total": "3.40", "raw": map[string]any{"b": true}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(340), order.Total.cents, "json.Unmarshaler fields are honored")
+	assert.JSONEq(t, `{"b":true}`, string(order.Raw))
+
+	_, err = MapToStruct[mapperOrder](map[string]any{"total": 3})
+	assert.Error(t, err)
+}
+
+// jsonConvert is the JSON round trip the Mapper replaces
+func jsonConvert[T any](data any) (T, error) {
+	var out T
+	b, err := json.Marshal(data)
+	if err == nil {
+		err = json.Unmarshal(b, &out)
+	}
+	return out, err
+}
+
+type mapperTagged struct {
+	Name  string            `json:"name"`
+	Tags  []string          `json:"tags"`
+	Attrs map[string]string `json:"attrs"`
+}
+
+type mapperQuoted struct {
+	ID      int64   `json:"id,string"`
+	Enabled bool    `json:"enabled,string"`
+	Ratio   float64 `json:"ratio,string,omitempty"`
+	Tags    []int   `json:"tags,string"`
+}
+
+type mapperUnquoted struct {
+	ID int64 `json:"id"`
+}
+
+func TestTypeConverter_MatchesJSONRoundTrip(t *testing.T) {
+	compare := func(t *testing.T, got, want any, gotErr, wantErr error) {
+		t.Helper()
+		if wantErr != nil {
+			assert.Error(t, gotErr)
+			return
+		}
+		require.NoError(t, gotErr)
+		assert.Equal(t, want, got)
+	}
+
+	t.Run("nil slices and maps are null", func(t *testing.T) {
+		for _, in := range []mapperTagged{{Name: "a"}, {Name: "b", Tags: []string{}, Attrs: map[string]string{}}} {
+			got, gotErr := StructToMap(in)
+			want, wantErr := jsonConvert[map[string]any](in)
+			compare(t, got, want, gotErr, wantErr)
+		}
+		got, gotErr := TypeConverter[[]any]([]*mapperTagged{{Name: "a"}})
+		want, wantErr := jsonConvert[[]any]([]*mapperTagged{{Name: "a"}})
+		compare(t, got, want, gotErr, wantErr)
+	})
+
+	t.Run("string option", func(t *testing.T) {
+		in := mapperQuoted{ID: 42, Enabled: true, Tags: []int{1}}
+		got, gotErr := StructToMap(in)
+		want, wantErr := jsonConvert[map[string]any](in)
+		compare(t, got, want, gotErr, wantErr)
+		assert.Equal(t, "42", got["id"])
+
+		src := map[string]any{"id": "7", "enabled": "false", "ratio": "0.5"}
+		back, gotErr := MapToStruct[mapperQuoted](src)
+		wantBack, wantErr := jsonConvert[mapperQuoted](src)
+		compare(t, back, wantBack, gotErr, wantErr)
+		assert.Equal(t, int64(7), back.ID)
+
+		plain, gotErr := TypeConverter[mapperUnquoted](in)
+		wantPlain, wantErr := jsonConvert[mapperUnquoted](in)
+		compare(t, plain, wantPlain, gotErr, wantErr)
+
+		_, gotErr = MapToStruct[mapperQuoted](map[string]any{"id": 7})
+		_, wantErr = jsonConvert[mapperQuoted](map[string]any{"id": 7})
+		require.Error(t, wantErr)
+		compare(t, nil, nil, gotErr, wantErr)
+	})
+}
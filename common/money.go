@@ -0,0 +1,402 @@
+package common
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("money: currency mismatch")
+	ErrUnknownCurrency  = errors.New("money: unknown currency")
+	ErrMoneyOverflow    = errors.New("money: amount overflows int64")
+	ErrInvalidAmount    = errors.New("money: invalid amount")
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+const (
+	IRR Currency = "IRR"
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+	AED Currency = "AED"
+	TRY Currency = "TRY"
+	IQD Currency = "IQD"
+	OMR Currency = "OMR"
+	QAR Currency = "QAR"
+	AFN Currency = "AFN"
+	AMD Currency = "AMD"
+	AZN Currency = "AZN"
+)
+
+type currencyInfo struct {
+	digits int
+	symbol string
+	fa     string
+}
+
+var currencies = map[Currency]currencyInfo{
+	IRR: {0, "IRR", "ریال"},
+	USD: {2, "$", "دلار"},
+	EUR: {2, "€", "یورو"},
+	GBP: {2, "£", "پوند"},
+	AED: {2, "AED", "درهم"},
+	TRY: {2, "₺", "لیر"},
+	IQD: {3, "IQD", "دینار عراق"},
+	OMR: {3, "OMR", "ریال عمان"},
+	QAR: {2, "QAR", "ریال قطر"},
+	AFN: {2, "؋", "افغانی"},
+	AMD: {2, "֏", "درام"},
+	AZN: {2, "₼", "منات"},
+}
+
+// RegisterCurrency adds or replaces a currency with its minor unit digits
+// and display symbol. Call it during initialization only
+func RegisterCurrency(code Currency, digits int, symbol string) {
+	currencies[code] = currencyInfo{digits: digits, symbol: symbol, fa: symbol}
+}
+
+// Digits returns the number of minor unit digits, e.g. 2 for USD
+func (c Currency) Digits() int {
+	return currencies[c].digits
+}
+
+// Valid reports whether the currency is known
+func (c Currency) Valid() bool {
+	_, ok := currencies[c]
+	return ok
+}
+
+// Money is an amount in minor units (cents, rials) of a currency. All
+// arithmetic is integer based and checked for overflow
+type Money struct {
+	Amount   int64    `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// NewMoney creates Money from minor units
+func NewMoney(amount int64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney parses a decimal amount in major units, e.g. "1234.5" USD →
+// 123450 cents. More fraction digits than the currency allows is an error
+func ParseMoney(amount string, currency Currency) (Money, error) {
+	currency = Currency(strings.ToUpper(string(currency)))
+	info, ok := currencies[currency]
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+
+	s := strings.ReplaceAll(strings.TrimSpace(ToEnglishDigits(amount)), ",", "")
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if !isDigits(whole+frac) || len(frac) > info.digits {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	frac += strings.Repeat("0", info.digits-len(frac))
+
+	n, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrMoneyOverflow, amount)
+	}
+	if neg {
+		n = -n
+	}
+	return Money{Amount: n, Currency: currency}, nil
+}
+
+// Add returns m + o
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	sum := m.Amount + o.Amount
+	if (sum > m.Amount) != (o.Amount > 0) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Sub returns m - o
+func (m Money) Sub(o Money) (Money, error) {
+	neg, err := o.Neg()
+	if err != nil {
+		return Money{}, err
+	}
+	return m.Add(neg)
+}
+
+// Mul returns m * n
+func (m Money) Mul(n int64) (Money, error) {
+	if m.Amount == 0 || n == 0 {
+		return Money{Currency: m.Currency}, nil
+	}
+	p := m.Amount * n
+	if p/n != m.Amount || (m.Amount == -1 && n == math.MinInt64) || (n == -1 && m.Amount == math.MinInt64) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Amount: p, Currency: m.Currency}, nil
+}
+
+// Allocate splits m by ratios without losing minor units: the remainder is
+// handed out one unit at a time from the first share, e.g. 100 by [1, 1, 1]
+// gives [34, 33, 33]
+func (m Money) Allocate(ratios ...int) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, errors.New("money: no ratios")
+	}
+	var total uint64
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, errors.New("money: negative ratio")
+		}
+		total += uint64(r)
+	}
+	if total == 0 {
+		return nil, errors.New("money: ratios sum to zero")
+	}
+
+	neg := m.Amount < 0
+	amount := absUint(m.Amount)
+
+	shares := make([]Money, len(ratios))
+	var allocated uint64
+	for i, r := range ratios {
+		// amount * r / total without intermediate overflow
+		hi, lo := bits.Mul64(amount, uint64(r))
+		share, _ := bits.Div64(hi, lo, total)
+		allocated += share
+		shares[i] = Money{Amount: int64(share), Currency: m.Currency}
+	}
+	for i := 0; allocated < amount; i = (i + 1) % len(shares) {
+		if ratios[i] == 0 {
+			continue
+		}
+		shares[i].Amount++
+		allocated++
+	}
+	if neg {
+		for i := range shares {
+			shares[i].Amount = -shares[i].Amount
+		}
+	}
+	return shares, nil
+}
+
+// Split divides m into n equal parts, see Allocate
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, errors.New("money: split into non-positive parts")
+	}
+	ratios := make([]int, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
+
+// Neg returns -m; the smallest amount has no positive counterpart and
+// overflows
+func (m Money) Neg() (Money, error) {
+	if m.Amount == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Amount: -m.Amount, Currency: m.Currency}, nil
+}
+
+// Abs returns |m|, overflowing like Neg
+func (m Money) Abs() (Money, error) {
+	if m.Amount < 0 {
+		return m.Neg()
+	}
+	return m, nil
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool { return m.Amount == 0 }
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+// Cmp returns -1, 0 or 1 comparing m to o
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Equal reports whether both amount and currency match
+func (m Money) Equal(o Money) bool {
+	return m == o
+}
+
+// Decimal returns the amount in major units, e.g. "-12.50"
+func (m Money) Decimal() string {
+	digits := m.Currency.Digits()
+	neg := m.Amount < 0
+	s := strconv.FormatUint(absUint(m.Amount), 10)
+	if digits > 0 {
+		if len(s) <= digits {
+			s = strings.Repeat("0", digits-len(s)+1) + s
+		}
+		s = s[:len(s)-digits] + "." + s[len(s)-digits:]
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// String returns the amount and currency code, e.g. "12.50 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + string(m.Currency)
+}
+
+// Format renders the amount for a locale: "en" ($1,234.50), "fa"
+// (۱٬۲۳۴٫۵۰ دلار) or "de" (1.234,50 €). Unknown locales fall back to "en"
+func (m Money) Format(locale string) string {
+	info := currencies[m.Currency]
+	whole, frac, _ := strings.Cut(strings.TrimPrefix(m.Decimal(), "-"), ".")
+	sign := ""
+	if m.Amount < 0 {
+		sign = "-"
+	}
+
+	switch strings.ToLower(strings.SplitN(locale, "-", 2)[0]) {
+	case "fa":
+		s := groupDigits(whole, "٬")
+		if frac != "" {
+			s += "٫" + frac
+		}
+		name := info.fa
+		if name == "" {
+			name = string(m.Currency)
+		}
+		return ToPersianDigits(sign+s) + " " + name
+	case "de", "tr":
+		s := groupDigits(whole, ".")
+		if frac != "" {
+			s += "," + frac
+		}
+		return sign + s + " " + symbolOr(info, m.Currency)
+	default:
+		s := groupDigits(whole, ",")
+		if frac != "" {
+			s += "." + frac
+		}
+		sym := symbolOr(info, m.Currency)
+		if len([]rune(sym)) > 1 {
+			return sign + sym + " " + s
+		}
+		return sign + sym + s
+	}
+}
+
+// UnmarshalJSON decodes {"amount":1250,"currency":"USD"} and validates the currency
+func (m *Money) UnmarshalJSON(data []byte) error {
+	type plain Money
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	p.Currency = Currency(strings.ToUpper(string(p.Currency)))
+	if !p.Currency.Valid() {
+		return fmt.Errorf("%w: %q", ErrUnknownCurrency, p.Currency)
+	}
+	*m = Money(p)
+	return nil
+}
+
+// Value implements driver.Valuer, storing m as "1250 USD" (minor units)
+func (m Money) Value() (driver.Value, error) {
+	return strconv.FormatInt(m.Amount, 10) + " " + string(m.Currency), nil
+}
+
+// Scan implements sql.Scanner for values written by Value
+func (m *Money) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*m = Money{}
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+
+	amount, currency, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	n, err := strconv.ParseInt(amount, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	*m = Money{Amount: n, Currency: Currency(currency)}
+	return nil
+}
+
+// GormDataType tells GORM to store Money in a string column
+func (Money) GormDataType() string {
+	return "string"
+}
+
+func (m Money) sameCurrency(o Money) error {
+	if m.Currency != o.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return nil
+}
+
+func absUint(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
+
+func groupDigits(s, sep string) string {
+	if len(s) <= 3 {
+		return s
+	}
+	var b strings.Builder
+	head := len(s) % 3
+	if head > 0 {
+		b.WriteString(s[:head])
+	}
+	for i := head; i < len(s); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(s[i : i+3])
+	}
+	return b.String()
+}
+
+func symbolOr(info currencyInfo, c Currency) string {
+	if info.symbol != "" {
+		return info.symbol
+	}
+	return string(c)
+}
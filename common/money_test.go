@@ -0,0 +1,142 @@
+package common
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	m, err := ParseMoney("1,234.5", "usd")
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(123450, USD), m)
+
+	m, err = ParseMoney("-0.05", USD)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-5), m.Amount)
+
+	m, err = ParseMoney("۲۵۰۰۰۰", IRR)
+	require.NoError(t, err)
+	assert.Equal(t, int64(250000), m.Amount)
+
+	_, err = ParseMoney("1.234", USD)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+	_, err = ParseMoney("abc", USD)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+	_, err = ParseMoney("", USD)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+	_, err = ParseMoney("1", "XXX")
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+	_, err = ParseMoney("99999999999999999999", IRR)
+	assert.ErrorIs(t, err, ErrMoneyOverflow)
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	a, b := NewMoney(1050, USD), NewMoney(250, USD)
+
+	sum, err := a.Add(b)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1300), sum.Amount)
+
+	diff, err := b.Sub(a)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-800), diff.Amount)
+	assert.True(t, diff.IsNegative())
+	abs, err := diff.Abs()
+	require.NoError(t, err)
+	assert.Equal(t, int64(800), abs.Amount)
+
+	prod, err := a.Mul(3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3150), prod.Amount)
+
+	cmp, err := a.Cmp(b)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cmp)
+
+	_, err = a.Add(NewMoney(1, EUR))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = a.Cmp(NewMoney(1, EUR))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = NewMoney(math.MaxInt64, USD).Add(NewMoney(1, USD))
+	assert.ErrorIs(t, err, ErrMoneyOverflow)
+	_, err = NewMoney(math.MinInt64, USD).Sub(NewMoney(1, USD))
+	assert.ErrorIs(t, err, ErrMoneyOverflow)
+	_, err = NewMoney(math.MaxInt64/2+1, USD).Mul(2)
+	assert.ErrorIs(t, err, ErrMoneyOverflow)
+	_, err = NewMoney(math.MinInt64, USD).Abs()
+	assert.ErrorIs(t, err, ErrMoneyOverflow)
+	_, err = NewMoney(math.MinInt64, USD).Neg()
+	assert.ErrorIs(t, err, ErrMoneyOverflow)
+	_, err = NewMoney(0, USD).Sub(NewMoney(math.MinInt64, USD))
+	assert.ErrorIs(t, err, ErrMoneyOverflow)
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	shares, err := NewMoney(100, USD).Split(3)
+	require.NoError(t, err)
+	assert.Equal(t, []Money{NewMoney(34, USD), NewMoney(33, USD), NewMoney(33, USD)}, shares)
+
+	shares, err = NewMoney(-100, USD).Allocate(70, 30)
+	require.NoError(t, err)
+	assert.Equal(t, []Money{NewMoney(-70, USD), NewMoney(-30, USD)}, shares)
+
+	shares, err = NewMoney(5, IRR).Allocate(0, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []Money{NewMoney(0, IRR), NewMoney(3, IRR), NewMoney(2, IRR)}, shares)
+
+	shares, err = NewMoney(math.MaxInt64, IRR).Allocate(math.MaxInt32, math.MaxInt32)
+	require.NoError(t, err)
+	total, _ := shares[0].Add(shares[1])
+	assert.Equal(t, int64(math.MaxInt64), total.Amount)
+
+	_, err = NewMoney(1, USD).Allocate()
+	assert.Error(t, err)
+	_, err = NewMoney(1, USD).Allocate(0, 0)
+	assert.Error(t, err)
+}
+
+func TestMoneyFormat(t *testing.T) {
+	m := NewMoney(123450, USD)
+
+	assert.Equal(t, "1234.50", m.Decimal())
+	assert.Equal(t, "1234.50 USD", m.String())
+	assert.Equal(t, "$1,234.50", m.Format("en-US"))
+	assert.Equal(t, "1.234,50 $", m.Format("de"))
+	assert.Equal(t, "۱٬۲۳۴٫۵۰ دلار", m.Format("fa-IR"))
+	assert.Equal(t, "-0.05", NewMoney(-5, USD).Decimal())
+	assert.Equal(t, "IRR 1,250,000", NewMoney(1250000, IRR).Format("en"))
+	assert.Equal(t, "۱٬۲۵۰٬۰۰۰ ریال", NewMoney(1250000, IRR).Format("fa"))
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(NewMoney(1250, USD))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":1250,"currency":"USD"}`, string(data))
+
+	var m Money
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":99,"currency":"eur"}`), &m))
+	assert.Equal(t, NewMoney(99, EUR), m)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"amount":1,"currency":"XXX"}`), &m), ErrUnknownCurrency)
+}
+
+func TestMoneyValueScan(t *testing.T) {
+	v, err := NewMoney(-1250, USD).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "-1250 USD", v)
+
+	var m Money
+	require.NoError(t, m.Scan([]byte("-1250 USD")))
+	assert.Equal(t, NewMoney(-1250, USD), m)
+
+	require.NoError(t, m.Scan(nil))
+	assert.Equal(t, Money{}, m)
+
+	assert.Error(t, m.Scan("garbage"))
+	assert.Error(t, m.Scan(42))
+}
@@ -0,0 +1,48 @@
+package common
+
+import "strings"
+
+// ValidateIranianNationalCode checks the check digit of an Iranian national
+// code (کد ملی). Persian digits, dashes and 8-9 digit codes that lost their
+// leading zeros are accepted
+func ValidateIranianNationalCode(code string) bool {
+	code = NormalizeIranianNationalCode(code)
+	if len(code) != 10 || !isDigits(code) {
+		return false
+	}
+
+	// Codes made of a single repeated digit pass the checksum but are not issued
+	if strings.Count(code, code[:1]) == len(code) {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(code[i]-'0') * (10 - i)
+	}
+	check := int(code[9] - '0')
+	r := sum % 11
+	if r < 2 {
+		return check == r
+	}
+	return check == 11-r
+}
+
+// NormalizeIranianNationalCode strips separators, converts Persian digits and
+// restores leading zeros, e.g. "۰۰۱-۲۳۴۵۶۷-۸" → "0012345678"
+func NormalizeIranianNationalCode(code string) string {
+	code = stripSeparators(ToEnglishDigits(code))
+	if (len(code) == 8 || len(code) == 9) && isDigits(code) {
+		code = strings.Repeat("0", 10-len(code)) + code
+	}
+	return code
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,28 @@
+package common
+
+import "strings"
+
+// MatchPermission reports whether a granted permission or scope covers
+// required: "*" covers everything and "<resource>:*" covers every action of
+// a two part "<resource>:<action>", so "orders:*" covers "orders:read" but
+// not "orders:items:read"
+func MatchPermission(granted, required string) bool {
+	if granted == "*" || granted == required {
+		return true
+	}
+	resource, action, ok := strings.Cut(required, ":")
+	if !ok || strings.Contains(action, ":") {
+		return false
+	}
+	return granted == resource+":*"
+}
+
+// HasPermission reports whether any granted permission covers required
+func HasPermission(granted []string, required string) bool {
+	for _, p := range granted {
+		if MatchPermission(p, required) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPermission(t *testing.T) {
+	tests := []struct {
+		granted, required string
+		want              bool
+	}{
+		{"orders:read", "orders:read", true},
+		{"orders:read", "orders:write", false},
+		{"orders:*", "orders:read", true},
+		{"orders:*", "orders:items:read", false},
+		{"orders:*", "orders", false},
+		{"orders:*", "invoices:read", false},
+		{"*:read", "orders:read", false},
+		{"orders:*:read", "orders:items:read", false},
+		{"orders:items:*", "orders:items:read", false},
+		{"*", "orders:items:read", true},
+		{"orders", "orders:read", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, MatchPermission(tt.granted, tt.required), "%s covers %s", tt.granted, tt.required)
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	granted := []string{"orders:read", "invoices:*"}
+	assert.True(t, HasPermission(granted, "orders:read"))
+	assert.True(t, HasPermission(granted, "invoices:send"))
+	assert.False(t, HasPermission(granted, "orders:write"))
+	assert.False(t, HasPermission(nil, "orders:read"))
+}
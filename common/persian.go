@@ -3,6 +3,8 @@ package common
 import (
 	"log"
 	"regexp"
+	"strings"
+	"unicode"
 )
 
 const iranianMobileNumberPattern string = `^09(1[0-9]|2[0-2]|3[0-9]|9[0-9])[0-9]{7}$`
@@ -14,3 +16,117 @@ func IranianMobileNumberValidate(mobileNumber string) bool {
 	}
 	return res
 }
+
+// =====================
+// Text normalization
+// =====================
+
+const (
+	zwnj    = '\u200c' // zero-width non-joiner (نیم‌فاصله)
+	tatweel = '\u0640' // kashida
+)
+
+var persianDigits = []rune("۰۱۲۳۴۵۶۷۸۹")
+
+// ToEnglishDigits replaces Persian (۰-۹) and Arabic-Indic (٠-٩) digits with ASCII digits
+func ToEnglishDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '۰' && r <= '۹':
+			return '0' + (r - '۰')
+		case r >= '٠' && r <= '٩':
+			return '0' + (r - '٠')
+		}
+		return r
+	}, s)
+}
+
+// ToPersianDigits replaces ASCII and Arabic-Indic digits with Persian digits
+func ToPersianDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9':
+			return persianDigits[r-'0']
+		case r >= '٠' && r <= '٩':
+			return persianDigits[r-'٠']
+		}
+		return r
+	}, s)
+}
+
+// NormalizeArabicLetters maps Arabic letter variants to their Persian forms
+// (ي ى → ی, ك → ک) and removes tatweel
+func NormalizeArabicLetters(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case 'ي', 'ى':
+			return 'ی'
+		case 'ك':
+			return 'ک'
+		case tatweel:
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// StripZeroWidth removes zero-width and bidi control characters. The
+// zero-width non-joiner is kept since it is part of Persian spelling,
+// use NormalizePersianForSearch to drop it too
+func StripZeroWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		if isInvisible(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// NormalizeWhitespace collapses runs of whitespace (including non-breaking
+// spaces) into a single space and trims both ends
+func NormalizeWhitespace(s string) string {
+	return strings.Join(strings.FieldsFunc(s, isSpace), " ")
+}
+
+func isSpace(r rune) bool {
+	return unicode.IsSpace(r) || r == '\u00a0' || r == '\u202f'
+}
+
+// NormalizePersian prepares fa-locale user input for storage: ASCII digits,
+// Persian ی/ک, no invisible characters, single spaces, and no stray
+// zero-width non-joiners around spaces
+//
+//	"  علي  ۱۲۳ " → "علی 123"
+func NormalizePersian(s string) string {
+	s = StripZeroWidth(NormalizeArabicLetters(ToEnglishDigits(s)))
+
+	// Collapse repeated ZWNJs and drop those next to spaces or at the edges
+	var b strings.Builder
+	b.Grow(len(s))
+	runes := []rune(s)
+	for i, r := range runes {
+		if r == zwnj {
+			prevOK := i > 0 && !isSpace(runes[i-1]) && runes[i-1] != zwnj
+			nextOK := i < len(runes)-1 && !isSpace(runes[i+1])
+			if !prevOK || !nextOK {
+				continue
+			}
+		}
+		b.WriteRune(r)
+	}
+	return NormalizeWhitespace(b.String())
+}
+
+// NormalizePersianForSearch is NormalizePersian with zero-width non-joiners
+// removed, so "می‌خواهم", "میخواهم" and "می‌‌خواهم" produce the same key
+func NormalizePersianForSearch(s string) string {
+	return strings.ReplaceAll(NormalizePersian(s), string(zwnj), "")
+}
+
+func isInvisible(r rune) bool {
+	switch r {
+	case '\u200b', '\u200d', '\u200e', '\u200f', '\u2060', '\ufeff', '\u00ad':
+		return true
+	}
+	return r >= '\u202a' && r <= '\u202e' || r >= '\u2066' && r <= '\u2069'
+}
@@ -0,0 +1,59 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToEnglishDigits(t *testing.T) {
+	assert.Equal(t, "09123456789", ToEnglishDigits("۰۹۱۲۳۴۵۶۷۸۹"))
+	assert.Equal(t, "0123456789", ToEnglishDigits("٠١٢٣٤٥٦٧٨٩"))
+	assert.Equal(t, "abc 12", ToEnglishDigits("abc ۱2"))
+}
+
+func TestToPersianDigits(t *testing.T) {
+	assert.Equal(t, "۰۹۱۲", ToPersianDigits("0912"))
+	assert.Equal(t, "۳۴", ToPersianDigits("٣٤"))
+}
+
+func TestNormalizeArabicLetters(t *testing.T) {
+	assert.Equal(t, "علی کریمی", NormalizeArabicLetters("علي كريمى"))
+	assert.Equal(t, "سلام", NormalizeArabicLetters("ســلام"))
+}
+
+func TestStripZeroWidth(t *testing.T) {
+	assert.Equal(t, "abc", StripZeroWidth("\ufeffa\u200bb\u200ec"))
+	assert.Equal(t, "می\u200cخواهم", StripZeroWidth("می\u200cخواهم"), "ZWNJ is kept")
+}
+
+func TestNormalizeWhitespace(t *testing.T) {
+	assert.Equal(t, "a b c", NormalizeWhitespace("  a\u00a0\u00a0b\t\nc  "))
+	assert.Equal(t, "", NormalizeWhitespace(" \u202f "))
+}
+
+func TestNormalizePersian(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Digits and letters", "  علي  ۱۲۳ ", "علی 123"},
+		{"Invisible characters", "\u200fكتاب\u200b", "کتاب"},
+		{"ZWNJ kept inside words", "می\u200cخواهم", "می\u200cخواهم"},
+		{"Repeated ZWNJ collapsed", "می\u200c\u200cخواهم", "می\u200cخواهم"},
+		{"ZWNJ next to spaces dropped", "کتاب\u200c ها \u200c", "کتاب ها"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NormalizePersian(tt.input))
+		})
+	}
+}
+
+func TestNormalizePersianForSearch(t *testing.T) {
+	key := NormalizePersianForSearch("میخواهم")
+	assert.Equal(t, key, NormalizePersianForSearch("می\u200cخواهم"))
+	assert.Equal(t, key, NormalizePersianForSearch(" مي\u200c\u200cخواهم "))
+}
@@ -30,6 +30,9 @@ var (
 
 // NormalizePhoneNumber cleans and normalizes a phone number according to config
 //
+// It is a lightweight fast path for a single known country, use ParsePhone
+// for metadata-driven validation of numbers from other markets
+//
 // Examples with DefaultCountryCode="98" (Iran):
 //
 //	Input: "+989011793041"  → Output: "+989011793041" (E164)
@@ -50,11 +53,17 @@ func NormalizePhoneNumber(phone string, config PhoneNumberConfig) string {
 	// Extract only digits
 	digits := digitRegex.ReplaceAllString(phone, "")
 
+	// A "+" or "00" prefix means the country code is present, a single
+	// leading zero means national format and the country code is missing
+	international := strings.HasPrefix(strings.TrimSpace(phone), "+") || strings.HasPrefix(digits, "00")
+	national := !international && strings.HasPrefix(digits, "0")
+
 	// Remove leading zeros
 	digits = strings.TrimLeft(digits, "0")
 
 	// If no country code, add default
-	if config.DefaultCountryCode != "" && !strings.HasPrefix(digits, config.DefaultCountryCode) {
+	if config.DefaultCountryCode != "" && !international &&
+		(national || !strings.HasPrefix(digits, config.DefaultCountryCode)) {
 		digits = config.DefaultCountryCode + digits
 	}
 
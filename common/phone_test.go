@@ -183,3 +183,10 @@ func TestNormalizePhoneNumber_OtherCountries(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizePhoneNumber_NationalStartingWithCountryCode(t *testing.T) {
+	config := PhoneNumberConfig{DefaultCountryCode: "49", Format: FormatE164}
+
+	assert.Equal(t, "+494912345678", NormalizePhoneNumber("04912345678", config))
+	assert.Equal(t, "+4912345678", NormalizePhoneNumber("+49 12345678", config))
+}
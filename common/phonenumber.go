@@ -0,0 +1,214 @@
+package common
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// PhoneType classifies a parsed phone number
+type PhoneType string
+
+const (
+	PhoneTypeMobile    PhoneType = "mobile"
+	PhoneTypeFixedLine PhoneType = "fixed_line"
+	// PhoneTypeFixedOrMobile is used where metadata can't tell the two apart (e.g. US)
+	PhoneTypeFixedOrMobile PhoneType = "fixed_or_mobile"
+	PhoneTypeTollFree      PhoneType = "toll_free"
+	PhoneTypeVoIP          PhoneType = "voip"
+	PhoneTypeOther         PhoneType = "other"
+	PhoneTypeUnknown       PhoneType = "unknown"
+)
+
+var (
+	ErrInvalidPhone        = errors.New("invalid phone number")
+	ErrUnsupportedRegion   = errors.New("phone number region is not supported")
+	ErrPhoneTypeNotAllowed = errors.New("phone number type is not allowed")
+)
+
+// SupportedPhoneRegions lists the ISO 3166-1 regions we operate in
+var SupportedPhoneRegions = []string{"IR", "AE", "TR", "IQ", "AF", "AM", "AZ", "OM", "QA", "DE", "GB"}
+
+// PhoneNumber is a parsed and classified phone number
+type PhoneNumber struct {
+	E164           string    `json:"e164"`
+	CountryCode    int       `json:"countryCode"`
+	Region         string    `json:"region"`
+	NationalNumber string    `json:"nationalNumber"`
+	Type           PhoneType `json:"type"`
+}
+
+// PhoneParseOptions controls parsing and validation
+type PhoneParseOptions struct {
+	// DefaultRegion is used for numbers written in national format, e.g. "IR"
+	DefaultRegion string
+	// Regions restricts accepted regions, defaults to SupportedPhoneRegions
+	Regions []string
+	// Types restricts accepted number types, empty allows all
+	Types []PhoneType
+}
+
+var iranMobileFastPath = regexp.MustCompile(`^9\d{9}$`)
+
+// ParsePhone parses a phone number in any supported format, detects its
+// country and classifies it as mobile, landline, etc.
+//
+// Iranian mobile numbers take a fast path that skips the metadata lookup
+func ParsePhone(raw string, opts PhoneParseOptions) (*PhoneNumber, error) {
+	raw = strings.TrimSpace(ToEnglishDigits(raw))
+	if raw == "" {
+		return nil, ErrInvalidPhone
+	}
+
+	region := strings.ToUpper(opts.DefaultRegion)
+	if p, ok := parseIranMobile(raw, region); ok {
+		return checkPhone(p, opts)
+	}
+
+	// Treat the "00" international call prefix as "+" regardless of region
+	if digits := digitRegex.ReplaceAllString(raw, ""); strings.HasPrefix(digits, "00") {
+		raw = "+" + digits[2:]
+	}
+
+	num, err := phonenumbers.Parse(raw, region)
+	if err != nil || !phonenumbers.IsValidNumber(num) {
+		return nil, ErrInvalidPhone
+	}
+
+	p := &PhoneNumber{
+		E164:           phonenumbers.Format(num, phonenumbers.E164),
+		CountryCode:    int(num.GetCountryCode()),
+		Region:         phonenumbers.GetRegionCodeForNumber(num),
+		NationalNumber: phonenumbers.GetNationalSignificantNumber(num),
+		Type:           phoneType(phonenumbers.GetNumberType(num)),
+	}
+	return checkPhone(p, opts)
+}
+
+// ValidatePhone reports whether raw is a valid number for the given options
+func ValidatePhone(raw string, opts PhoneParseOptions) bool {
+	_, err := ParsePhone(raw, opts)
+	return err == nil
+}
+
+// ValidateMobile reports whether raw is a valid mobile number in a supported region
+func ValidateMobile(raw, defaultRegion string) bool {
+	return ValidatePhone(raw, PhoneParseOptions{
+		DefaultRegion: defaultRegion,
+		Types:         []PhoneType{PhoneTypeMobile},
+	})
+}
+
+// Format renders the number in the requested format
+//
+//	FormatE164          → +989123456789
+//	FormatInternational → 989123456789
+//	FormatLocal         → 09123456789 (national dialing format, digits only)
+func (p *PhoneNumber) Format(format PhoneNumberFormat) string {
+	switch format {
+	case FormatInternational:
+		return strings.TrimPrefix(p.E164, "+")
+	case FormatLocal:
+		num, err := phonenumbers.Parse(p.E164, "")
+		if err != nil {
+			return p.E164
+		}
+		return digitRegex.ReplaceAllString(phonenumbers.Format(num, phonenumbers.NATIONAL), "")
+	default:
+		return p.E164
+	}
+}
+
+// Pretty renders the number for display, e.g. "+98 912 345 6789"
+func (p *PhoneNumber) Pretty() string {
+	num, err := phonenumbers.Parse(p.E164, "")
+	if err != nil {
+		return p.E164
+	}
+	return phonenumbers.Format(num, phonenumbers.INTERNATIONAL)
+}
+
+// IsMobile reports whether the number can be a mobile number
+func (p *PhoneNumber) IsMobile() bool {
+	return p.Type == PhoneTypeMobile || p.Type == PhoneTypeFixedOrMobile
+}
+
+// parseIranMobile handles Iranian mobile numbers without metadata
+func parseIranMobile(raw, region string) (*PhoneNumber, bool) {
+	digits := digitRegex.ReplaceAllString(raw, "")
+	switch {
+	case strings.HasPrefix(raw, "+98"), strings.HasPrefix(digits, "0098"):
+		digits = strings.TrimPrefix(strings.TrimPrefix(digits, "00"), "98")
+	case strings.HasPrefix(raw, "+"), strings.HasPrefix(digits, "00"):
+		return nil, false
+	case region == "IR" && strings.HasPrefix(digits, "0"):
+		digits = digits[1:]
+	case region == "IR" && len(digits) == 10:
+	default:
+		return nil, false
+	}
+
+	if !iranMobileFastPath.MatchString(digits) || !IranianMobileNumberValidate("0"+digits) {
+		return nil, false
+	}
+	return &PhoneNumber{
+		E164:           "+98" + digits,
+		CountryCode:    98,
+		Region:         "IR",
+		NationalNumber: digits,
+		Type:           PhoneTypeMobile,
+	}, true
+}
+
+func checkPhone(p *PhoneNumber, opts PhoneParseOptions) (*PhoneNumber, error) {
+	regions := opts.Regions
+	if len(regions) == 0 {
+		regions = SupportedPhoneRegions
+	}
+	if !containsFold(regions, p.Region) {
+		return nil, ErrUnsupportedRegion
+	}
+	if len(opts.Types) > 0 {
+		allowed := false
+		for _, t := range opts.Types {
+			if t == p.Type || (p.Type == PhoneTypeFixedOrMobile && (t == PhoneTypeMobile || t == PhoneTypeFixedLine)) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, ErrPhoneTypeNotAllowed
+		}
+	}
+	return p, nil
+}
+
+func phoneType(t phonenumbers.PhoneNumberType) PhoneType {
+	switch t {
+	case phonenumbers.MOBILE:
+		return PhoneTypeMobile
+	case phonenumbers.FIXED_LINE:
+		return PhoneTypeFixedLine
+	case phonenumbers.FIXED_LINE_OR_MOBILE:
+		return PhoneTypeFixedOrMobile
+	case phonenumbers.TOLL_FREE:
+		return PhoneTypeTollFree
+	case phonenumbers.VOIP:
+		return PhoneTypeVoIP
+	case phonenumbers.UNKNOWN:
+		return PhoneTypeUnknown
+	default:
+		return PhoneTypeOther
+	}
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
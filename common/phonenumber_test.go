@@ -0,0 +1,64 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePhone(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		region  string
+		e164    string
+		country string
+		typ     PhoneType
+	}{
+		{"Iran mobile local", "09123456789", "IR", "+989123456789", "IR", PhoneTypeMobile},
+		{"Iran mobile persian digits", "۰۹۱۲۳۴۵۶۷۸۹", "IR", "+989123456789", "IR", PhoneTypeMobile},
+		{"Iran mobile international", "+98 912 345 6789", "", "+989123456789", "IR", PhoneTypeMobile},
+		{"Iran landline", "021 8888 1234", "IR", "+982188881234", "IR", PhoneTypeFixedLine},
+		{"UAE mobile", "050 123 4567", "AE", "+971501234567", "AE", PhoneTypeMobile},
+		{"Turkey mobile with 00", "0090 532 123 4567", "", "+905321234567", "TR", PhoneTypeMobile},
+		{"Germany national starting with country digits", "0491 1234567", "DE", "+494911234567", "DE", PhoneTypeFixedLine},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePhone(tt.input, PhoneParseOptions{DefaultRegion: tt.region})
+			require.NoError(t, err)
+			assert.Equal(t, tt.e164, p.E164)
+			assert.Equal(t, tt.country, p.Region)
+			assert.Equal(t, tt.typ, p.Type)
+		})
+	}
+}
+
+func TestParsePhone_Errors(t *testing.T) {
+	_, err := ParsePhone("12345", PhoneParseOptions{DefaultRegion: "IR"})
+	assert.ErrorIs(t, err, ErrInvalidPhone)
+
+	_, err = ParsePhone("+1 650 253 0000", PhoneParseOptions{})
+	assert.ErrorIs(t, err, ErrUnsupportedRegion)
+
+	_, err = ParsePhone("02188881234", PhoneParseOptions{DefaultRegion: "IR", Types: []PhoneType{PhoneTypeMobile}})
+	assert.ErrorIs(t, err, ErrPhoneTypeNotAllowed)
+}
+
+func TestPhoneNumber_Format(t *testing.T) {
+	p, err := ParsePhone("+989123456789", PhoneParseOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "+989123456789", p.Format(FormatE164))
+	assert.Equal(t, "989123456789", p.Format(FormatInternational))
+	assert.Equal(t, "09123456789", p.Format(FormatLocal))
+	assert.Equal(t, "+98 912 345 6789", p.Pretty())
+}
+
+func TestValidateMobile(t *testing.T) {
+	assert.True(t, ValidateMobile("09123456789", "IR"))
+	assert.True(t, ValidateMobile("+971501234567", ""))
+	assert.False(t, ValidateMobile("02188881234", "IR"))
+}
@@ -1,14 +1,10 @@
 package common
 
 import (
-	"regexp"
 	"strings"
 	"unicode"
 )
 
-var matchFirstCap = regexp.MustCompile("(.)([A-Z][a-z]+)")
-var matchAllCap = regexp.MustCompile("([a-z0-9])([A-Z])")
-
 func HasUpper(s string) bool {
 	for _, r := range s {
 		if unicode.IsUpper(r) && unicode.IsLetter(r) {
@@ -45,9 +41,91 @@ func HasDigits(s string) bool {
 	return false
 }
 
-// To snake case : CountryId -> country_id
+// SplitWords splits an identifier into words at separators (_ - . space),
+// lower→upper transitions and acronym ends. Digits stay with the preceding
+// word: "HTTPServer2Config" → [HTTP Server2 Config], "user_id" → [user id]
+func SplitWords(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := -1
+	flush := func(end int) {
+		if start >= 0 && end > start {
+			words = append(words, string(runes[start:end]))
+		}
+		start = -1
+	}
+
+	for i, r := range runes {
+		if isWordSeparator(r) {
+			flush(i)
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		prev := runes[i-1]
+		if unicode.IsUpper(r) {
+			lowerToUpper := unicode.IsLower(prev) || unicode.IsDigit(prev)
+			acronymEnd := unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				flush(i)
+				start = i
+			}
+		}
+	}
+	flush(len(runes))
+	return words
+}
+
+// ToSnakeCase converts an identifier to snake_case: CountryId → country_id,
+// HTTPServer → http_server, userID → user_id
 func ToSnakeCase(str string) string {
-	snake := matchFirstCap.ReplaceAllString(str, "${1}_${2}")
-	snake = matchAllCap.ReplaceAllString(snake, "${1}_${2}")
-	return strings.ToLower(snake)
+	return joinWords(SplitWords(str), "_", strings.ToLower)
+}
+
+// ToScreamingSnakeCase converts an identifier to SCREAMING_SNAKE_CASE, as
+// used for environment variables: DBHost → DB_HOST
+func ToScreamingSnakeCase(str string) string {
+	return joinWords(SplitWords(str), "_", strings.ToUpper)
+}
+
+// ToKebabCase converts an identifier to kebab-case: UserProfile → user-profile
+func ToKebabCase(str string) string {
+	return joinWords(SplitWords(str), "-", strings.ToLower)
+}
+
+// ToPascalCase converts an identifier to PascalCase. Acronyms are treated as
+// words: user_id → UserId, HTTP_SERVER → HttpServer
+func ToPascalCase(str string) string {
+	return joinWords(SplitWords(str), "", capitalize)
+}
+
+// ToCamelCase converts an identifier to camelCase: user_id → userId,
+// HTTPServer → httpServer
+func ToCamelCase(str string) string {
+	words := SplitWords(str)
+	if len(words) == 0 {
+		return ""
+	}
+	return strings.ToLower(words[0]) + joinWords(words[1:], "", capitalize)
+}
+
+func joinWords(words []string, sep string, fn func(string) string) string {
+	for i, w := range words {
+		words[i] = fn(w)
+	}
+	return strings.Join(words, sep)
+}
+
+func capitalize(word string) string {
+	runes := []rune(strings.ToLower(word))
+	if len(runes) > 0 {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+	return string(runes)
+}
+
+func isWordSeparator(r rune) bool {
+	return r == '_' || r == '-' || r == '.' || r == '/' || unicode.IsSpace(r)
 }
@@ -0,0 +1,90 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"CountryId", []string{"Country", "Id"}},
+		{"HTTPServer2Config", []string{"HTTP", "Server2", "Config"}},
+		{"userID", []string{"user", "ID"}},
+		{"user_id", []string{"user", "id"}},
+		{"  kebab-case.with spaces ", []string{"kebab", "case", "with", "spaces"}},
+		{"", nil},
+		{"___", nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, SplitWords(tt.input), tt.input)
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"CountryId", "country_id"},
+		{"countryId", "country_id"},
+		{"UserID", "user_id"},
+		{"HTTPServer", "http_server"},
+		{"APIKey", "api_key"},
+		{"JSONToXML", "json_to_xml"},
+		{"Base64Encode", "base64_encode"},
+		{"OAuth2Token", "o_auth2_token"},
+		{"Version2", "version2"},
+		{"already_snake", "already_snake"},
+		{"Kebab-Case", "kebab_case"},
+		{"ÉcoleName", "école_name"},
+		{"ΑβγΔέλτα", "αβγ_δέλτα"},
+		{"نام_کاربر", "نام_کاربر"},
+		{"A", "a"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ToSnakeCase(tt.input), tt.input)
+	}
+}
+
+func TestToScreamingSnakeCase(t *testing.T) {
+	assert.Equal(t, "DB_HOST", ToScreamingSnakeCase("DBHost"))
+	assert.Equal(t, "JWT_SECRET", ToScreamingSnakeCase("JWTSecret"))
+	assert.Equal(t, "MAX_IDLE_CONNS", ToScreamingSnakeCase("MaxIdleConns"))
+	assert.Equal(t, "REDIS", ToScreamingSnakeCase("Redis"))
+}
+
+func TestToKebabCase(t *testing.T) {
+	assert.Equal(t, "user-profile", ToKebabCase("UserProfile"))
+	assert.Equal(t, "http-server", ToKebabCase("HTTP_SERVER"))
+}
+
+func TestToPascalCase(t *testing.T) {
+	assert.Equal(t, "UserId", ToPascalCase("user_id"))
+	assert.Equal(t, "HttpServer", ToPascalCase("HTTP_SERVER"))
+	assert.Equal(t, "HttpServer", ToPascalCase("HTTPServer"))
+	assert.Equal(t, "Base64Encode", ToPascalCase("base64-encode"))
+	assert.Equal(t, "École", ToPascalCase("école"))
+	assert.Equal(t, "", ToPascalCase(""))
+}
+
+func TestToCamelCase(t *testing.T) {
+	assert.Equal(t, "userId", ToCamelCase("user_id"))
+	assert.Equal(t, "userId", ToCamelCase("UserID"))
+	assert.Equal(t, "httpServer", ToCamelCase("HTTPServer"))
+	assert.Equal(t, "createdAt", ToCamelCase("created at"))
+	assert.Equal(t, "", ToCamelCase("__"))
+}
+
+func TestCaseRoundTrip(t *testing.T) {
+	for _, s := range []string{"user_id", "http_server_config", "base64_encode"} {
+		assert.Equal(t, s, ToSnakeCase(ToPascalCase(s)))
+		assert.Equal(t, s, ToSnakeCase(ToCamelCase(s)))
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"reflect"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/minisource/go-common/common"
 )
 
 // Loader handles configuration loading from various sources
@@ -78,10 +80,12 @@ func unmarshalEnv(cfg interface{}, prefix string) error {
 		return fmt.Errorf("config must be a pointer to struct")
 	}
 
-	return parseStruct(v, prefix)
+	return parseStruct(v, prefix, prefix)
 }
 
-func parseStruct(v reflect.Value, prefix string) error {
+// parseStruct sets the fields of v from the environment. legacyPrefix is
+// prefix as built before acronyms were kept together (D_B_HOST for DBHost)
+func parseStruct(v reflect.Value, prefix, legacyPrefix string) error {
 	t := v.Type()
 
 	for i := 0; i < v.NumField(); i++ {
@@ -94,15 +98,14 @@ func parseStruct(v reflect.Value, prefix string) error {
 
 		// Handle nested structs
 		if field.Kind() == reflect.Struct && fieldType.Type != reflect.TypeOf(time.Time{}) {
-			nestedPrefix := prefix
+			nestedPrefix, legacyNested := prefix, legacyPrefix
 			if tag := fieldType.Tag.Get("env_prefix"); tag != "" {
-				nestedPrefix = tag
-			} else if prefix != "" {
-				nestedPrefix = prefix + "_" + toSnakeCase(fieldType.Name)
+				nestedPrefix, legacyNested = tag, tag
 			} else {
-				nestedPrefix = toSnakeCase(fieldType.Name)
+				nestedPrefix = joinEnvKey(prefix, common.ToScreamingSnakeCase(fieldType.Name))
+				legacyNested = joinEnvKey(legacyPrefix, legacySnakeCase(fieldType.Name))
 			}
-			if err := parseStruct(field, nestedPrefix); err != nil {
+			if err := parseStruct(field, nestedPrefix, legacyNested); err != nil {
 				return err
 			}
 			continue
@@ -110,17 +113,21 @@ func parseStruct(v reflect.Value, prefix string) error {
 
 		// Get env tag
 		envKey := fieldType.Tag.Get("env")
+		legacyKey := envKey
 		if envKey == "" {
-			envKey = toSnakeCase(fieldType.Name)
+			envKey = common.ToScreamingSnakeCase(fieldType.Name)
+			legacyKey = legacySnakeCase(fieldType.Name)
 		}
-
-		if prefix != "" {
-			envKey = prefix + "_" + envKey
-		}
-		envKey = strings.ToUpper(envKey)
+		envKey = strings.ToUpper(joinEnvKey(prefix, envKey))
+		legacyKey = strings.ToUpper(joinEnvKey(legacyPrefix, legacyKey))
 
 		// Get value from environment
 		envValue := os.Getenv(envKey)
+		if envValue == "" && legacyKey != envKey {
+			if envValue = os.Getenv(legacyKey); envValue != "" {
+				log.Printf("config: %s is deprecated, use %s", legacyKey, envKey)
+			}
+		}
 		if envValue == "" {
 			// Check for default tag
 			if defaultVal := fieldType.Tag.Get("default"); defaultVal != "" {
@@ -203,7 +210,16 @@ func setField(field reflect.Value, value string) error {
 	return nil
 }
 
-func toSnakeCase(s string) string {
+func joinEnvKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
+
+// legacySnakeCase is the env key naming used before common.ToScreamingSnakeCase,
+// splitting before every capital letter
+func legacySnakeCase(s string) string {
 	var result strings.Builder
 	for i, r := range s {
 		if i > 0 && r >= 'A' && r <= 'Z' {
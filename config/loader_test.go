@@ -0,0 +1,51 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type dbConfig struct {
+	DBHost  string
+	MaxConn int
+	SSLMode string `env:"SSL_MODE"`
+}
+
+type appConfig struct {
+	APIKey string
+	DB     dbConfig
+}
+
+func TestUnmarshalEnvAcronymFields(t *testing.T) {
+	t.Setenv("APP_API_KEY", "new-key")
+	t.Setenv("APP_DB_DB_HOST", "db.internal")
+	t.Setenv("APP_DB_MAX_CONN", "20")
+	t.Setenv("APP_DB_SSL_MODE", "require")
+
+	var cfg appConfig
+	require.NoError(t, unmarshalEnv(&cfg, "APP"))
+	assert.Equal(t, appConfig{
+		APIKey: "new-key",
+		DB:     dbConfig{DBHost: "db.internal", MaxConn: 20, SSLMode: "require"},
+	}, cfg)
+}
+
+func TestUnmarshalEnvLegacyKeys(t *testing.T) {
+	// Keys from before acronyms were kept together still work
+	t.Setenv("APP_A_P_I_KEY", "legacy-key")
+	t.Setenv("APP_D_B_D_B_HOST", "legacy.internal")
+	t.Setenv("APP_DB_MAX_CONN", "5")
+
+	var cfg appConfig
+	require.NoError(t, unmarshalEnv(&cfg, "APP"))
+	assert.Equal(t, "legacy-key", cfg.APIKey)
+	assert.Equal(t, "legacy.internal", cfg.DB.DBHost)
+	assert.Equal(t, 5, cfg.DB.MaxConn)
+
+	// The new key wins when both are set
+	t.Setenv("APP_API_KEY", "new-key")
+	require.NoError(t, unmarshalEnv(&cfg, "APP"))
+	assert.Equal(t, "new-key", cfg.APIKey)
+}
@@ -181,6 +181,17 @@ func GetClientIP(ctx context.Context) string {
 	return ip
 }
 
+// WithUserAgent adds user agent to context
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return context.WithValue(ctx, keyUserAgent, userAgent)
+}
+
+// GetUserAgent retrieves user agent from context
+func GetUserAgent(ctx context.Context) string {
+	ua, _ := ctx.Value(keyUserAgent).(string)
+	return ua
+}
+
 // WithRequestContext adds all request context values
 func WithRequestContext(ctx context.Context, rc *RequestContext) context.Context {
 	if rc.UserID != uuid.Nil {
@@ -210,6 +221,9 @@ func WithRequestContext(ctx context.Context, rc *RequestContext) context.Context
 	if rc.ClientIP != "" {
 		ctx = WithClientIP(ctx, rc.ClientIP)
 	}
+	if rc.UserAgent != "" {
+		ctx = WithUserAgent(ctx, rc.UserAgent)
+	}
 	return ctx
 }
 
@@ -225,6 +239,7 @@ func GetRequestContext(ctx context.Context) *RequestContext {
 	rc.Permissions = GetPermissions(ctx)
 	rc.Language = GetLanguage(ctx)
 	rc.ClientIP = GetClientIP(ctx)
+	rc.UserAgent = GetUserAgent(ctx)
 	return rc
 }
 
@@ -251,6 +266,11 @@ func FromFiber(c *fiber.Ctx) context.Context {
 	// Add client IP
 	ctx = WithClientIP(ctx, c.IP())
 
+	// Add user agent
+	if ua := c.Get(fiber.HeaderUserAgent); ua != "" {
+		ctx = WithUserAgent(ctx, ua)
+	}
+
 	// Add language
 	lang := c.Get("Accept-Language")
 	if lang == "" {
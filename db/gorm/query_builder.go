@@ -5,8 +5,11 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/minisource/go-common/audit"
 	"github.com/minisource/go-common/common"
+	"github.com/minisource/go-common/dto"
 	filter "github.com/minisource/go-common/filter"
+	"github.com/minisource/go-common/repository"
 	"gorm.io/gorm"
 )
 
@@ -14,7 +17,63 @@ type PreloadEntity struct {
 	Entity string
 }
 
-// GenerateDynamicQuery
+// DynamicFilterScope is the parameterized form of GenerateDynamicQuery and
+// GenerateDynamicSort. Filters and sorts name exported fields of T by their
+// Go name and map to snake_case columns; fields tagged json:"-" and secret
+// fields (see audit.IsExcludedField) are not filterable. Values are bound as
+// query arguments, and unknown fields or operators return an error wrapping
+// dto.ErrInvalidFilter
+//
+//	scope, err := gormdb.DynamicFilterScope[User](req)
+//	if err != nil {
+//		return err
+//	}
+//	db.Scopes(scope).Find(&users)
+func DynamicFilterScope[T any](f *filter.DynamicFilter) (func(*gorm.DB) *gorm.DB, error) {
+	df := dto.DynamicFilter{}
+	if f.Sort != nil {
+		sorts := make([]dto.Sort, len(*f.Sort))
+		for i, s := range *f.Sort {
+			sorts[i] = dto.Sort{ColId: s.ColId, Sort: s.Sort}
+		}
+		df.Sort = &sorts
+	}
+	if f.Filter != nil {
+		df.Filter = make(map[string]dto.Filter, len(f.Filter))
+		for name, fl := range f.Filter {
+			df.Filter[name] = dto.Filter{Type: fl.Type, From: fl.From, To: fl.To, FilterType: fl.FilterType}
+		}
+	}
+
+	fq, err := df.Build(structFilterFields(reflect.TypeOf(*new(T))))
+	if err != nil {
+		return nil, err
+	}
+	return repository.FilterScope(fq), nil
+}
+
+// structFilterFields whitelists the exported fields of t under their Go names
+func structFilterFields(t reflect.Type) dto.FilterFields {
+	fields := make(dto.FilterFields)
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if jsonName == "-" || audit.IsExcludedField(f.Name) || audit.IsExcludedField(jsonName) {
+			continue
+		}
+		fields[f.Name] = dto.FilterField{Column: common.ToSnakeCase(f.Name)}
+	}
+	return fields
+}
+
+// GenerateDynamicQuery builds a WHERE clause by interpolating filter values
+// into the SQL.
+//
+// Deprecated: the values are not escaped, so the result is open to SQL
+// injection. Use DynamicFilterScope, or dto.DynamicFilter.Build with
+// repository.FilterScope.
 func GenerateDynamicQuery[T any](filter *filter.DynamicFilter) string {
 	t := new(T)
 	typeT := reflect.TypeOf(*t)
@@ -30,6 +89,12 @@ func GenerateDynamicQuery[T any](filter *filter.DynamicFilter) string {
 	return strings.Join(query, " AND ")
 }
 
+// GenerateDynamicFilter builds the condition of a single filter by
+// interpolating its values into the SQL.
+//
+// Deprecated: the values are not escaped, so the result is open to SQL
+// injection. Use DynamicFilterScope, or dto.DynamicFilter.Build with
+// repository.FilterScope.
 func GenerateDynamicFilter(fld reflect.StructField, filter filter.Filter) string {
 	conditionQuery := ""
 	fld.Name = common.ToSnakeCase(fld.Name)
@@ -66,7 +131,10 @@ func GenerateDynamicFilter(fld reflect.StructField, filter filter.Filter) string
 	return conditionQuery
 }
 
-// generateDynamicSort
+// GenerateDynamicSort builds an ORDER BY list from the sort of filter.
+//
+// Deprecated: use DynamicFilterScope, or dto.DynamicFilter.Build with
+// repository.FilterScope, which also validate the filters.
 func GenerateDynamicSort[T any](filter *filter.DynamicFilter) string {
 	t := new(T)
 	typeT := reflect.TypeOf(*t)
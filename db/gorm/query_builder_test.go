@@ -0,0 +1,67 @@
+package gormdb
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/minisource/go-common/dto"
+	filter "github.com/minisource/go-common/filter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type filterUser struct {
+	ID           int
+	FirstName    string
+	PasswordHash string
+	Internal     string `json:"-"`
+}
+
+func TestDynamicFilterScopeBindsValues(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard, DryRun: true})
+	require.NoError(t, err)
+
+	scope, err := DynamicFilterScope[filterUser](&filter.DynamicFilter{
+		Filter: map[string]filter.Filter{"FirstName": {Type: "equals", From: "x' OR '1'='1", FilterType: "text"}},
+		Sort:   &[]filter.Sort{{ColId: "ID", Sort: "desc"}},
+	})
+	require.NoError(t, err)
+
+	stmt := db.Scopes(scope).Find(&[]filterUser{}).Statement
+	assert.NotContains(t, stmt.SQL.String(), "OR '1'='1")
+	assert.Contains(t, stmt.SQL.String(), "ORDER BY")
+	assert.Equal(t, []interface{}{"x' OR '1'='1"}, stmt.Vars)
+}
+
+func TestDynamicFilterScopeRejectsHiddenFields(t *testing.T) {
+	for _, name := range []string{"PasswordHash", "Internal", "Unknown"} {
+		_, err := DynamicFilterScope[filterUser](&filter.DynamicFilter{
+			Filter: map[string]filter.Filter{name: {Type: "equals", From: "x", FilterType: "text"}},
+		})
+		assert.ErrorIs(t, err, dto.ErrInvalidFilter, name)
+	}
+}
+
+func TestDynamicFilterScopeMatchesWildcardsLiterally(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&filterUser{}))
+	require.NoError(t, db.Create(&[]filterUser{{FirstName: "50% off"}, {FirstName: "500 off"}, {FirstName: `a\b!c`}}).Error)
+
+	for search, want := range map[string]string{"50%": "50% off", `\b!`: `a\b!c`} {
+		scope, err := DynamicFilterScope[filterUser](&filter.DynamicFilter{
+			Filter: map[string]filter.Filter{"FirstName": {Type: "contains", From: search, FilterType: "text"}},
+		})
+		require.NoError(t, err)
+
+		stmt := db.Session(&gorm.Session{DryRun: true}).Scopes(scope).Find(&[]filterUser{}).Statement
+		assert.Contains(t, stmt.SQL.String(), "LIKE ? ESCAPE '!'")
+
+		var users []filterUser
+		require.NoError(t, db.Scopes(scope).Find(&users).Error)
+		require.Len(t, users, 1, search)
+		assert.Equal(t, want, users[0].FirstName)
+	}
+}
@@ -1,16 +1,21 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"flag"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
-	_ "github.com/lib/pq"
+	"github.com/minisource/go-common/db/migrations"
+	_ "github.com/minisource/go-common/db/migrations/cockroachdb"
+	_ "github.com/minisource/go-common/db/migrations/mysql"
+	_ "github.com/minisource/go-common/db/migrations/sqlite"
 )
 
 /*
@@ -27,20 +32,31 @@ Usage:
   go run cmd/migrate/main.go -command=version
   go run cmd/migrate/main.go -command=force -version=1
   go run cmd/migrate/main.go -command=create -name=add_users_table
+  go run cmd/migrate/main.go -command=seed -seeds=./seeds
+  go run cmd/migrate/main.go -command=up -driver=mysql -database="user:pass@tcp(localhost:3306)/app"
+
+The database driver (postgres, mysql, sqlite, cockroachdb) is detected from
+the URL scheme unless -driver is set.
 
 Environment Variables:
-  DATABASE_URL - PostgreSQL connection string
+  DATABASE_URL - Database connection URL
   MIGRATIONS_PATH - Path to migrations folder (default: ./migrations)
+  SEEDS_PATH - Path to seeds folder (default: ./seeds)
+
+Seeds are NNN_name.seed.sql files applied once each, in version order, and
+recorded in the schema_seeds table.
 */
 
 func main() {
 	// Parse flags
-	command := flag.String("command", "", "Migration command: up, down, version, force, create, status")
+	command := flag.String("command", "", "Migration command: up, down, version, force, create, status, seed")
 	steps := flag.Int("steps", 0, "Number of steps for step-based migration")
 	version := flag.Int("version", 0, "Version number for force command")
 	name := flag.String("name", "", "Migration name for create command")
 	migrationsPath := flag.String("path", "", "Path to migrations folder")
+	seedsPath := flag.String("seeds", "", "Path to seeds folder")
 	databaseURL := flag.String("database", "", "Database URL (overrides DATABASE_URL env)")
+	driver := flag.String("driver", "", "Database driver: postgres, mysql, sqlite, cockroachdb (default: from URL scheme)")
 	flag.Parse()
 
 	if *command == "" {
@@ -63,6 +79,15 @@ func main() {
 		migPath = "./migrations"
 	}
 
+	// Get seeds path
+	seedPath := *seedsPath
+	if seedPath == "" {
+		seedPath = os.Getenv("SEEDS_PATH")
+	}
+	if seedPath == "" {
+		seedPath = "./seeds"
+	}
+
 	switch *command {
 	case "create":
 		if *name == "" {
@@ -74,40 +99,32 @@ func main() {
 		if dbURL == "" {
 			log.Fatal("Error: DATABASE_URL environment variable or -database flag is required")
 		}
-		runMigrationCommand(*command, dbURL, migPath, *steps, *version)
+		runMigrationCommand(*command, *driver, dbURL, migPath, *steps, *version)
+
+	case "seed":
+		if dbURL == "" {
+			log.Fatal("Error: DATABASE_URL environment variable or -database flag is required")
+		}
+		runSeeds(*driver, dbURL, seedPath)
 
 	default:
 		log.Fatalf("Unknown command: %s", *command)
 	}
 }
 
-func runMigrationCommand(command, dbURL, migPath string, steps, forceVersion int) {
-	// Connect to database
-	db, err := sql.Open("postgres", dbURL)
+func runMigrationCommand(command, driver, dbURL, migPath string, steps, forceVersion int) {
+	// Select the database driver from the flag or the URL scheme
+	dbURL, err := migrations.DatabaseURL(driver, dbURL)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-	}
-	defer db.Close()
-
-	if err := db.Ping(); err != nil {
-		log.Fatalf("Failed to ping database: %v", err)
-	}
-
-	// Create postgres driver
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		log.Fatalf("Failed to create database driver: %v", err)
+		log.Fatalf("Invalid database: %v", err)
 	}
 
 	// Create migrate instance
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", migPath),
-		"postgres",
-		driver,
-	)
+	m, err := migrate.New(fmt.Sprintf("file://%s", migPath), dbURL)
 	if err != nil {
 		log.Fatalf("Failed to create migrator: %v", err)
 	}
+	defer m.Close()
 
 	switch command {
 	case "up":
@@ -151,6 +168,70 @@ func runMigrationCommand(command, dbURL, migPath string, steps, forceVersion int
 	}
 }
 
+func runSeeds(driver, dbURL, seedPath string) {
+	dbURL, err := migrations.DatabaseURL(driver, dbURL)
+	if err != nil {
+		log.Fatalf("Invalid database: %v", err)
+	}
+	driver, _ = migrations.DriverFromURL(dbURL)
+
+	db, err := openDatabase(driver, dbURL)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	seeder, err := migrations.NewSeederFromFS(db, driver, os.DirFS(seedPath), "")
+	if err != nil {
+		log.Fatalf("Failed to load seeds: %v", err)
+	}
+
+	applied, err := seeder.Run(context.Background())
+	for _, seed := range applied {
+		log.Printf("✓ Applied seed %d_%s", seed.Version, seed.Name)
+	}
+	if err != nil {
+		log.Fatalf("Seeding failed: %v", err)
+	}
+	if len(applied) == 0 {
+		log.Println("No pending seeds")
+		return
+	}
+	log.Println("✓ Seeds applied successfully")
+}
+
+// openDatabase opens a migrate URL with the database/sql driver its
+// golang-migrate driver registers, dropping the x- migrate parameters
+func openDatabase(driver, dbURL string) (*sql.DB, error) {
+	_, dsn, _ := strings.Cut(dbURL, "://")
+	if base, query, ok := strings.Cut(dsn, "?"); ok {
+		params, err := url.ParseQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		for key := range params {
+			if strings.HasPrefix(key, "x-") {
+				params.Del(key)
+			}
+		}
+		dsn = base
+		if encoded := params.Encode(); encoded != "" {
+			dsn += "?" + encoded
+		}
+	}
+
+	switch driver {
+	case migrations.DriverPostgres, migrations.DriverCockroachDB:
+		return sql.Open("postgres", "postgres://"+dsn)
+	case migrations.DriverMySQL:
+		return sql.Open("mysql", dsn)
+	case migrations.DriverSQLite:
+		return sql.Open("sqlite", dsn)
+	default:
+		return nil, fmt.Errorf("seeding is not supported for driver %q", driver)
+	}
+}
+
 func printVersion(m *migrate.Migrate) {
 	version, dirty, err := m.Version()
 	if err != nil {
@@ -272,6 +353,7 @@ func printUsage() {
 ║    status    Show detailed migration status                          ║
 ║    force     Force set version (fix dirty state)                     ║
 ║    create    Create new migration files                              ║
+║    seed      Apply pending seeds (NNN_name.seed.sql)                 ║
 ║                                                                      ║
 ║  FLAGS:                                                              ║
 ║    -command   Migration command (required)                           ║
@@ -279,7 +361,9 @@ func printUsage() {
 ║    -version   Version number for force command                       ║
 ║    -name      Migration name for create command                      ║
 ║    -path      Path to migrations folder (default: ./migrations)      ║
+║    -seeds     Path to seeds folder (default: ./seeds)                ║
 ║    -database  Database URL (overrides DATABASE_URL env)              ║
+║    -driver    postgres, mysql, sqlite, cockroachdb (default: scheme) ║
 ║                                                                      ║
 ║  EXAMPLES:                                                           ║
 ║    migrate -command=create -name=add_users_table                     ║
@@ -287,10 +371,12 @@ func printUsage() {
 ║    migrate -command=down -steps=1                                    ║
 ║    migrate -command=status                                           ║
 ║    migrate -command=force -version=5                                 ║
+║    migrate -command=seed                                             ║
 ║                                                                      ║
 ║  ENVIRONMENT:                                                        ║
-║    DATABASE_URL      PostgreSQL connection string                    ║
+║    DATABASE_URL      Database connection URL                         ║
 ║    MIGRATIONS_PATH   Path to migrations folder                       ║
+║    SEEDS_PATH        Path to seeds folder                            ║
 ║                                                                      ║
 ╚══════════════════════════════════════════════════════════════════════╝`)
 }
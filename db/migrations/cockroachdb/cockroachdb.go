@@ -0,0 +1,18 @@
+// Package cockroachdb registers the CockroachDB migration driver:
+//
+//	import _ "github.com/minisource/go-common/db/migrations/cockroachdb"
+package cockroachdb
+
+import (
+	"database/sql"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	migratecockroachdb "github.com/golang-migrate/migrate/v4/database/cockroachdb"
+	"github.com/minisource/go-common/db/migrations"
+)
+
+func init() {
+	migrations.RegisterDriver(migrations.DriverCockroachDB, func(db *sql.DB, databaseName string) (database.Driver, error) {
+		return migratecockroachdb.WithInstance(db, &migratecockroachdb.Config{DatabaseName: databaseName})
+	}, "cockroach", "crdb-postgres")
+}
@@ -5,9 +5,10 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"io/fs"
 
 	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
@@ -23,48 +24,100 @@ type Config struct {
 // Migrator handles database migrations
 type Migrator struct {
 	migrate *migrate.Migrate
+	source  source.Driver
 }
 
 // NewMigrator creates a new migrator instance
 func NewMigrator(db *sql.DB, databaseName string) (*Migrator, error) {
-	// Create source driver from embedded files
-	sourceDriver, err := iofs.New(migrationFiles, "sql")
+	return NewMigratorFromFS(db, databaseName, migrationFiles, "sql")
+}
+
+// NewMigratorFromURL creates a migrator from database URL
+func NewMigratorFromURL(databaseURL, databaseName string) (*Migrator, error) {
+	return NewMigratorFromFSURL(databaseURL, migrationFiles, "sql")
+}
+
+// NewMigratorFromFS creates a migrator reading the migrations in dir of
+// fsys, so services can embed their migrations in the binary:
+//
+//	//go:embed migrations/*.sql
+//	var migrationsFS embed.FS
+//
+//	m, err := migrations.NewMigratorFromFS(db, "orders", migrationsFS, "migrations")
+//
+// An empty dir reads the root of fsys
+func NewMigratorFromFS(db *sql.DB, databaseName string, fsys fs.FS, dir string) (*Migrator, error) {
+	sourceDriver, err := newFSSource(fsys, dir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create source driver: %w", err)
+		return nil, err
 	}
+	return NewMigratorWithSource(db, databaseName, "iofs", sourceDriver)
+}
 
-	// Create database driver
-	dbDriver, err := postgres.WithInstance(db, &postgres.Config{
-		DatabaseName: databaseName,
-	})
+// NewMigratorFromFSURL creates a migrator from database URL reading the
+// migrations in dir of fsys; the URL scheme selects the driver
+func NewMigratorFromFSURL(databaseURL string, fsys fs.FS, dir string) (*Migrator, error) {
+	sourceDriver, err := newFSSource(fsys, dir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create database driver: %w", err)
+		return nil, err
 	}
 
-	// Create migrate instance
-	m, err := migrate.NewWithInstance("iofs", sourceDriver, databaseName, dbDriver)
+	m, err := migrate.NewWithSourceInstance("iofs", sourceDriver, databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create migrator: %w", err)
 	}
 
-	return &Migrator{migrate: m}, nil
+	return &Migrator{migrate: m, source: sourceDriver}, nil
 }
 
-// NewMigratorFromURL creates a migrator from database URL
-func NewMigratorFromURL(databaseURL, databaseName string) (*Migrator, error) {
-	// Create source driver from embedded files
-	sourceDriver, err := iofs.New(migrationFiles, "sql")
+// NewMigratorForDriver creates a migrator for a connection of the given
+// driver (see Drivers) reading the migrations in dir of fsys
+func NewMigratorForDriver(db *sql.DB, driver, databaseName string, fsys fs.FS, dir string) (*Migrator, error) {
+	sourceDriver, err := newFSSource(fsys, dir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create source driver: %w", err)
+		return nil, err
+	}
+	return NewMigratorWithDriver(db, driver, databaseName, "iofs", sourceDriver)
+}
+
+// NewMigratorWithSource creates a Postgres migrator from any golang-migrate
+// source driver, e.g. one reading migrations from S3 or GitHub
+func NewMigratorWithSource(db *sql.DB, databaseName, sourceName string, sourceDriver source.Driver) (*Migrator, error) {
+	return NewMigratorWithDriver(db, DriverPostgres, databaseName, sourceName, sourceDriver)
+}
+
+// NewMigratorWithDriver creates a migrator from a registered database
+// driver and any golang-migrate source driver
+func NewMigratorWithDriver(db *sql.DB, driver, databaseName, sourceName string, sourceDriver source.Driver) (*Migrator, error) {
+	entry, err := lookupDriver(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create database driver
+	dbDriver, err := entry.open(db, databaseName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database driver: %w", err)
 	}
 
 	// Create migrate instance
-	m, err := migrate.NewWithSourceInstance("iofs", sourceDriver, databaseURL)
+	m, err := migrate.NewWithInstance(sourceName, sourceDriver, databaseName, dbDriver)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create migrator: %w", err)
 	}
 
-	return &Migrator{migrate: m}, nil
+	return &Migrator{migrate: m, source: sourceDriver}, nil
+}
+
+func newFSSource(fsys fs.FS, dir string) (source.Driver, error) {
+	if dir == "" {
+		dir = "."
+	}
+	sourceDriver, err := iofs.New(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create source driver: %w", err)
+	}
+	return sourceDriver, nil
 }
 
 // Up runs all pending migrations
@@ -0,0 +1,98 @@
+package migrations
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"migrations/1_create_users.up.sql":    {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);")},
+		"migrations/1_create_users.down.sql":  {Data: []byte("DROP TABLE users;")},
+		"migrations/2_add_email.up.sql":       {Data: []byte("ALTER TABLE users ADD COLUMN email TEXT;")},
+		"migrations/2_add_email.down.sql":     {Data: []byte("ALTER TABLE users DROP COLUMN email;")},
+		"migrations/3_create_orders.up.sql":   {Data: []byte("CREATE TABLE orders (id INTEGER PRIMARY KEY);")},
+		"migrations/3_create_orders.down.sql": {Data: []byte("DROP TABLE orders;")},
+	}
+}
+
+func openSQLite(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	var n int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n))
+	return n == 1
+}
+
+func TestMigratorFromFS(t *testing.T) {
+	db := openSQLite(t)
+	m, err := NewMigratorForDriver(db, "sqlite3", "main", testMigrations(), "migrations")
+	require.NoError(t, err)
+
+	status, err := m.Status()
+	require.NoError(t, err)
+	assert.Equal(t, &MigrationInfo{}, status, "no version before the first migration")
+
+	require.NoError(t, m.Steps(2))
+	status, err = m.Status()
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), status.Version)
+	assert.False(t, tableExists(t, db, "orders"))
+
+	require.NoError(t, m.Up())
+	require.NoError(t, m.Up(), "no change is not an error")
+	assert.True(t, tableExists(t, db, "orders"))
+
+	require.NoError(t, m.Down())
+	assert.False(t, tableExists(t, db, "users"))
+}
+
+func TestMigratorFromFSURL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.db")
+	m, err := NewMigratorFromFSURL("sqlite://"+path, testMigrations(), "migrations")
+	require.NoError(t, err)
+	require.NoError(t, m.Up())
+	version, dirty, err := m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), version)
+	assert.False(t, dirty)
+	require.NoError(t, m.Close())
+}
+
+func TestMigratorFromFSRoot(t *testing.T) {
+	fsys := fstest.MapFS{
+		"1_init.up.sql":   {Data: []byte("CREATE TABLE things (id INTEGER);")},
+		"1_init.down.sql": {Data: []byte("DROP TABLE things;")},
+	}
+	db := openSQLite(t)
+	m, err := NewMigratorForDriver(db, DriverSQLite, "main", fsys, "")
+	require.NoError(t, err)
+	require.NoError(t, m.Up())
+	assert.True(t, tableExists(t, db, "things"))
+}
+
+func TestMigratorFromFSErrors(t *testing.T) {
+	db := openSQLite(t)
+
+	_, err := NewMigratorFromFS(db, "main", testMigrations(), "missing")
+	assert.ErrorContains(t, err, "failed to create source driver")
+
+	// NewMigratorFromFS migrates Postgres, so the migrations load but the
+	// SQLite connection is rejected
+	_, err = NewMigratorFromFS(db, "main", testMigrations(), "migrations")
+	assert.ErrorContains(t, err, "failed to create database driver")
+
+	_, err = NewMigratorForDriver(db, "oracle", "main", testMigrations(), "migrations")
+	assert.ErrorContains(t, err, "unknown database driver")
+}
@@ -0,0 +1,18 @@
+// Package mysql registers the MySQL migration driver:
+//
+//	import _ "github.com/minisource/go-common/db/migrations/mysql"
+package mysql
+
+import (
+	"database/sql"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	migratemysql "github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/minisource/go-common/db/migrations"
+)
+
+func init() {
+	migrations.RegisterDriver(migrations.DriverMySQL, func(db *sql.DB, databaseName string) (database.Driver, error) {
+		return migratemysql.WithInstance(db, &migratemysql.Config{DatabaseName: databaseName})
+	})
+}
@@ -0,0 +1,203 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/minisource/go-common/logging"
+)
+
+// Migration directions
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+)
+
+// ErrDirty is returned when a failed migration left the schema dirty; it
+// has to be fixed by hand and cleared with Force
+var ErrDirty = errors.New("database schema is dirty")
+
+// Step describes one migration run by a Runner
+type Step struct {
+	// Version is the migration being applied or rolled back
+	Version uint
+	// Identifier is the migration name from its file name
+	Identifier string
+	Direction  string
+	// Duration and Err are set for AfterEach
+	Duration time.Duration
+	Err      error
+}
+
+// Runner runs migrations one at a time, so services can migrate on startup
+// and observe every migration:
+//
+//	r := migrations.NewRunner(m, logger)
+//	r.AfterEach = func(ctx context.Context, s migrations.Step) {
+//		durations.WithLabelValues(s.Direction).Observe(s.Duration.Seconds())
+//	}
+//	r.OnDirty = func(ctx context.Context, version uint, err error) {
+//		alert.Send(ctx, "schema dirty at version %d: %v", version, err)
+//	}
+//	err := r.Up(ctx)
+type Runner struct {
+	Migrator *Migrator
+	// Logger logs every migration; nil disables logging
+	Logger logging.Logger
+	// BeforeEach runs before a migration; an error stops the run before it
+	BeforeEach func(ctx context.Context, step Step) error
+	// AfterEach runs after a migration, failed or not
+	AfterEach func(ctx context.Context, step Step)
+	// OnDirty runs when the schema is found or left dirty
+	OnDirty func(ctx context.Context, version uint, err error)
+}
+
+// NewRunner creates a runner for m
+func NewRunner(m *Migrator, logger logging.Logger) *Runner {
+	return &Runner{Migrator: m, Logger: logger}
+}
+
+// Up applies all pending migrations
+func (r *Runner) Up(ctx context.Context) error {
+	return r.run(ctx, DirectionUp, -1)
+}
+
+// Down rolls back all migrations
+func (r *Runner) Down(ctx context.Context) error {
+	return r.run(ctx, DirectionDown, -1)
+}
+
+// Steps runs n migrations (positive = up, negative = down); it stops
+// early without error when there are fewer
+func (r *Runner) Steps(ctx context.Context, n int) error {
+	if n < 0 {
+		return r.run(ctx, DirectionDown, -n)
+	}
+	return r.run(ctx, DirectionUp, n)
+}
+
+// run migrates in direction until no migration is left, limit migrations
+// ran (negative = no limit) or ctx is done
+func (r *Runner) run(ctx context.Context, direction string, limit int) error {
+	for ran := 0; limit < 0 || ran < limit; ran++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		version, ok, err := r.next(ctx, direction)
+		if err != nil || !ok {
+			return err
+		}
+
+		step := Step{Version: version, Identifier: r.identifier(version, direction), Direction: direction}
+		if r.BeforeEach != nil {
+			if err := r.BeforeEach(ctx, step); err != nil {
+				return fmt.Errorf("migration %d aborted: %w", version, err)
+			}
+		}
+
+		n := 1
+		if direction == DirectionDown {
+			n = -1
+		}
+		start := time.Now()
+		step.Err = r.Migrator.migrate.Steps(n)
+		step.Duration = time.Since(start)
+
+		r.log(step)
+		if r.AfterEach != nil {
+			r.AfterEach(ctx, step)
+		}
+
+		if step.Err != nil {
+			if current, dirty, err := r.Migrator.migrate.Version(); err == nil && dirty {
+				r.dirty(ctx, current, step.Err)
+			}
+			return fmt.Errorf("failed to run migration %d %s: %w", version, direction, step.Err)
+		}
+	}
+	return nil
+}
+
+// next returns the migration to apply or roll back next
+func (r *Runner) next(ctx context.Context, direction string) (uint, bool, error) {
+	current, dirty, err := r.Migrator.migrate.Version()
+	noVersion := errors.Is(err, migrate.ErrNilVersion)
+	if err != nil && !noVersion {
+		return 0, false, fmt.Errorf("failed to get migration version: %w", err)
+	}
+	if dirty {
+		r.dirty(ctx, current, ErrDirty)
+		return 0, false, fmt.Errorf("%w at version %d", ErrDirty, current)
+	}
+
+	if direction == DirectionDown {
+		return current, !noVersion, nil
+	}
+
+	var next uint
+	if noVersion {
+		next, err = r.Migrator.source.First()
+	} else {
+		next, err = r.Migrator.source.Next(current)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	return next, true, nil
+}
+
+func (r *Runner) identifier(version uint, direction string) string {
+	var (
+		body       io.ReadCloser
+		identifier string
+		err        error
+	)
+	if direction == DirectionDown {
+		body, identifier, err = r.Migrator.source.ReadDown(version)
+	} else {
+		body, identifier, err = r.Migrator.source.ReadUp(version)
+	}
+	if err != nil {
+		return ""
+	}
+	body.Close()
+	return identifier
+}
+
+func (r *Runner) dirty(ctx context.Context, version uint, err error) {
+	if r.Logger != nil {
+		r.Logger.Error(logging.Postgres, logging.Migration, "Database schema is dirty", map[logging.ExtraKey]interface{}{
+			logging.Version:      version,
+			logging.ErrorMessage: err.Error(),
+		})
+	}
+	if r.OnDirty != nil {
+		r.OnDirty(ctx, version, err)
+	}
+}
+
+func (r *Runner) log(step Step) {
+	if r.Logger == nil {
+		return
+	}
+	extra := map[logging.ExtraKey]interface{}{
+		logging.Version: step.Version,
+		logging.Name:    step.Identifier,
+		logging.Latency: step.Duration.String(),
+	}
+	if step.Err != nil {
+		extra[logging.ErrorMessage] = step.Err.Error()
+		r.Logger.Error(logging.Postgres, logging.Migration, "Migration "+step.Direction+" failed", extra)
+		return
+	}
+	r.Logger.Info(logging.Postgres, logging.Migration, "Migration "+step.Direction+" applied", extra)
+}
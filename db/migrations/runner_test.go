@@ -0,0 +1,123 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRunner(t *testing.T, fsys fstest.MapFS) *Runner {
+	m, err := NewMigratorForDriver(openSQLite(t), DriverSQLite, "main", fsys, "migrations")
+	require.NoError(t, err)
+	return NewRunner(m, nil)
+}
+
+func TestRunnerHooks(t *testing.T) {
+	r := newTestRunner(t, testMigrations())
+	var before, after []Step
+	r.BeforeEach = func(ctx context.Context, s Step) error {
+		before = append(before, s)
+		return nil
+	}
+	r.AfterEach = func(ctx context.Context, s Step) {
+		after = append(after, s)
+	}
+	ctx := context.Background()
+
+	require.NoError(t, r.Up(ctx))
+	require.Len(t, before, 3)
+	require.Len(t, after, 3)
+	assert.Equal(t, Step{Version: 1, Identifier: "create_users", Direction: DirectionUp}, before[0])
+	assert.Equal(t, uint(3), after[2].Version)
+	assert.Equal(t, "create_orders", after[2].Identifier)
+	assert.Positive(t, after[2].Duration)
+	assert.NoError(t, after[2].Err)
+
+	before, after = nil, nil
+	require.NoError(t, r.Steps(ctx, -2))
+	require.Len(t, after, 2)
+	assert.Equal(t, uint(3), after[0].Version)
+	assert.Equal(t, uint(2), after[1].Version)
+	assert.Equal(t, DirectionDown, after[1].Direction)
+
+	require.NoError(t, r.Steps(ctx, 5), "stops early when fewer migrations are pending")
+	assert.Len(t, after, 4)
+	status, err := r.Migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), status.Version)
+}
+
+func TestRunnerBeforeEachAborts(t *testing.T) {
+	r := newTestRunner(t, testMigrations())
+	stop := errors.New("maintenance window closed")
+	r.BeforeEach = func(ctx context.Context, s Step) error {
+		if s.Version == 2 {
+			return stop
+		}
+		return nil
+	}
+
+	err := r.Up(context.Background())
+	assert.ErrorIs(t, err, stop)
+	status, err := r.Migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), status.Version)
+}
+
+func TestRunnerOnDirty(t *testing.T) {
+	fsys := testMigrations()
+	fsys["migrations/2_add_email.up.sql"] = &fstest.MapFile{Data: []byte("ALTER TABLE missing ADD COLUMN email TEXT;")}
+	r := newTestRunner(t, fsys)
+	type dirtyCall struct {
+		version uint
+		err     error
+	}
+	var calls []dirtyCall
+	r.OnDirty = func(ctx context.Context, version uint, err error) {
+		calls = append(calls, dirtyCall{version, err})
+	}
+	var failed Step
+	r.AfterEach = func(ctx context.Context, s Step) {
+		if s.Err != nil {
+			failed = s
+		}
+	}
+	ctx := context.Background()
+
+	err := r.Up(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to run migration 2 up")
+	assert.Equal(t, uint(2), failed.Version)
+	require.Len(t, calls, 1)
+	assert.Equal(t, uint(2), calls[0].version)
+	assert.Equal(t, failed.Err, calls[0].err)
+
+	// A later run refuses to touch the dirty schema
+	err = r.Up(ctx)
+	assert.ErrorIs(t, err, ErrDirty)
+	require.Len(t, calls, 2)
+	assert.ErrorIs(t, calls[1].err, ErrDirty)
+
+	// Once fixed and forced back the run resumes
+	fsys["migrations/2_add_email.up.sql"] = testMigrations()["migrations/2_add_email.up.sql"]
+	require.NoError(t, r.Migrator.Force(1))
+	require.NoError(t, r.Up(ctx))
+	status, err := r.Migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, &MigrationInfo{Version: 3}, status)
+}
+
+func TestRunnerStopsOnCanceledContext(t *testing.T) {
+	r := newTestRunner(t, testMigrations())
+	ctx, cancel := context.WithCancel(context.Background())
+	r.AfterEach = func(ctx context.Context, s Step) { cancel() }
+
+	assert.ErrorIs(t, r.Up(ctx), context.Canceled)
+	status, err := r.Migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), status.Version)
+}
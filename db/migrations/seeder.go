@@ -0,0 +1,224 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SeedsTable records the applied seeds
+const SeedsTable = "schema_seeds"
+
+// seedFilePattern matches seed files such as 001_default_roles.seed.sql
+var seedFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.seed\.sql$`)
+
+// SeedFunc inserts seed data inside the transaction of its seed
+type SeedFunc func(ctx context.Context, tx *sql.Tx) error
+
+// Seed is one unit of bootstrap data, applied once per database
+type Seed struct {
+	Version uint
+	Name    string
+	// SQL is the content of a seed file; Func is used when it is empty
+	SQL  string
+	Func SeedFunc
+}
+
+// Seeder applies seeds in version order and records them in the
+// schema_seeds table, so bootstrap data is inserted once even though
+// services seed on every start. Each seed runs in its own transaction
+// together with its record
+type Seeder struct {
+	db     *sql.DB
+	driver string
+	seeds  []Seed
+}
+
+// NewSeeder creates a seeder for a connection of the given driver (see
+// Drivers)
+func NewSeeder(db *sql.DB, driver string) *Seeder {
+	if entry, err := lookupDriver(driver); err == nil {
+		driver = entry.name
+	}
+	return &Seeder{db: db, driver: driver}
+}
+
+// NewSeederFromFS creates a seeder with the seed files in dir of fsys
+func NewSeederFromFS(db *sql.DB, driver string, fsys fs.FS, dir string) (*Seeder, error) {
+	s := NewSeeder(db, driver)
+	if err := s.Load(fsys, dir); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Load adds the NNN_name.seed.sql files in dir of fsys; other files are
+// ignored. An empty dir reads the root of fsys
+func (s *Seeder) Load(fsys fs.FS, dir string) error {
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("failed to read seeds: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := seedFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid seed version in %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read seed %s: %w", entry.Name(), err)
+		}
+		s.seeds = append(s.seeds, Seed{Version: uint(version), Name: match[2], SQL: string(content)})
+	}
+	return nil
+}
+
+// Register adds a Go seed
+func (s *Seeder) Register(version uint, name string, fn SeedFunc) {
+	s.seeds = append(s.seeds, Seed{Version: version, Name: name, Func: fn})
+}
+
+// Seeds returns the seeds in version order
+func (s *Seeder) Seeds() ([]Seed, error) {
+	seeds := append([]Seed(nil), s.seeds...)
+	sort.Slice(seeds, func(i, j int) bool { return seeds[i].Version < seeds[j].Version })
+	for i := 1; i < len(seeds); i++ {
+		if seeds[i].Version == seeds[i-1].Version {
+			return nil, fmt.Errorf("duplicate seed version %d: %s and %s", seeds[i].Version, seeds[i-1].Name, seeds[i].Name)
+		}
+	}
+	return seeds, nil
+}
+
+// Pending returns the seeds not applied yet
+func (s *Seeder) Pending(ctx context.Context) ([]Seed, error) {
+	seeds, err := s.Seeds()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := s.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]Seed, 0, len(seeds))
+	for _, seed := range seeds {
+		if !applied[seed.Version] {
+			pending = append(pending, seed)
+		}
+	}
+	return pending, nil
+}
+
+// Run applies the pending seeds and returns them. It stops at the first
+// failing seed, whose data is rolled back
+func (s *Seeder) Run(ctx context.Context) ([]Seed, error) {
+	pending, err := s.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, seed := range pending {
+		if err := s.apply(ctx, seed); err != nil {
+			return pending[:i], fmt.Errorf("failed to apply seed %d_%s: %w", seed.Version, seed.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+func (s *Seeder) apply(ctx context.Context, seed Seed) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Recording the seed first makes a concurrent seeder block on the
+	// primary key and fail instead of inserting the data twice
+	_, err = tx.ExecContext(ctx,
+		s.bind("INSERT INTO "+SeedsTable+" (version, name, applied_at) VALUES (?, ?, ?)"),
+		int64(seed.Version), seed.Name, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case strings.TrimSpace(seed.SQL) != "":
+		_, err = tx.ExecContext(ctx, seed.SQL)
+	case seed.Func != nil:
+		err = seed.Func(ctx, tx)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Seeder) ensureTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+SeedsTable+` (
+	version BIGINT NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied_at TIMESTAMP NOT NULL
+)`)
+	if err != nil {
+		return fmt.Errorf("failed to create %s table: %w", SeedsTable, err)
+	}
+	return nil
+}
+
+func (s *Seeder) applied(ctx context.Context) (map[uint]bool, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT version FROM "+SeedsTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied seeds: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[uint]bool)
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[uint(version)] = true
+	}
+	return applied, rows.Err()
+}
+
+// bind rewrites ? placeholders to $n for Postgres and CockroachDB
+func (s *Seeder) bind(query string) string {
+	if s.driver != DriverPostgres && s.driver != DriverCockroachDB {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
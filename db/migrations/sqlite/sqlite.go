@@ -0,0 +1,19 @@
+// Package sqlite registers the pure Go SQLite migration driver
+// (modernc.org/sqlite, database/sql name "sqlite"):
+//
+//	import _ "github.com/minisource/go-common/db/migrations/sqlite"
+package sqlite
+
+import (
+	"database/sql"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/minisource/go-common/db/migrations"
+)
+
+func init() {
+	migrations.RegisterDriver(migrations.DriverSQLite, func(db *sql.DB, databaseName string) (database.Driver, error) {
+		return migratesqlite.WithInstance(db, &migratesqlite.Config{DatabaseName: databaseName})
+	}, "sqlite3")
+}
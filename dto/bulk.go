@@ -0,0 +1,9 @@
+package dto
+
+import "github.com/google/uuid"
+
+// BulkUpdateItem is one entry of a bulk update payload
+type BulkUpdateItem[T any] struct {
+	ID   uuid.UUID `json:"id"`
+	Data T         `json:"data"`
+}
@@ -6,10 +6,12 @@ type Sort struct {
 }
 
 type Filter struct {
-	// contains notContains equals notEqual startsWith lessThan lessThanOrEqual greaterThan greaterThanOrEqual inRange endsWith
+	// contains notContains equals notEqual startsWith lessThan lessThanOrEqual greaterThan greaterThanOrEqual inRange endsWith in isNull
 	Type string `json:"type"`
 	From string `json:"from"`
 	To   string `json:"to"`
+	// Values for the in operator
+	Values []string `json:"values,omitempty"`
 	// text number
 	FilterType string `json:"filterType"`
 }
@@ -0,0 +1,220 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ============================================
+// Filter Builder
+// ============================================
+
+// Filter operators
+const (
+	OpContains           = "contains"
+	OpNotContains        = "notContains"
+	OpStartsWith         = "startsWith"
+	OpEndsWith           = "endsWith"
+	OpEquals             = "equals"
+	OpNotEqual           = "notEqual"
+	OpLessThan           = "lessThan"
+	OpLessThanOrEqual    = "lessThanOrEqual"
+	OpGreaterThan        = "greaterThan"
+	OpGreaterThanOrEqual = "greaterThanOrEqual"
+	OpInRange            = "inRange"
+	OpIn                 = "in"
+	OpIsNull             = "isNull"
+)
+
+// MaxFilterValues caps the values of an "in" filter
+const MaxFilterValues = 1000
+
+// ErrInvalidFilter is wrapped by every error Build returns, so callers can
+// map them to a 400 response
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// FilterField whitelists a field for filtering and sorting
+type FilterField struct {
+	// Column is the SQL column the field maps to. It is written into the
+	// query as is and must never come from user input
+	Column string
+	// Operators allowed on the field; empty allows all
+	Operators []string
+	// NoSort excludes the field from sorting
+	NoSort bool
+}
+
+// FilterFields maps the field names clients use to their columns
+type FilterFields map[string]FilterField
+
+// AllowFields whitelists columns under their own names with all operators
+func AllowFields(columns ...string) FilterFields {
+	fields := make(FilterFields, len(columns))
+	for _, column := range columns {
+		fields[column] = FilterField{Column: column}
+	}
+	return fields
+}
+
+// Condition is a parameterized SQL condition
+type Condition struct {
+	SQL  string
+	Args []interface{}
+}
+
+// FilterQuery is the parameterized form of a DynamicFilter
+type FilterQuery struct {
+	Conditions []Condition
+	// Order holds "column asc" or "column desc" terms
+	Order []string
+}
+
+// Where joins the conditions with AND for raw SQL; it returns an empty
+// string when there are none
+func (q *FilterQuery) Where() (string, []interface{}) {
+	parts := make([]string, 0, len(q.Conditions))
+	var args []interface{}
+	for _, c := range q.Conditions {
+		parts = append(parts, "("+c.SQL+")")
+		args = append(args, c.Args...)
+	}
+	return strings.Join(parts, " AND "), args
+}
+
+// OrderBy joins the order terms for raw SQL
+func (q *FilterQuery) OrderBy() string {
+	return strings.Join(q.Order, ", ")
+}
+
+// Build turns the filter into parameterized conditions. Only whitelisted
+// fields and operators are accepted; values are always passed as arguments
+//
+//	fq, err := req.DynamicFilter.Build(dto.FilterFields{
+//		"name":      {Column: "name", Operators: []string{dto.OpContains}},
+//		"createdAt": {Column: "created_at"},
+//	})
+func (f *DynamicFilter) Build(fields FilterFields) (*FilterQuery, error) {
+	q := &FilterQuery{}
+
+	// Map iteration order is random; sort so the SQL is stable
+	names := make([]string, 0, len(f.Filter))
+	for name := range f.Filter {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		filter := f.Filter[name]
+		field, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidFilter, name)
+		}
+		if !field.allows(filter.Type) {
+			return nil, fmt.Errorf("%w: operator %q not allowed on %q", ErrInvalidFilter, filter.Type, name)
+		}
+		cond, err := condition(field.Column, filter)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidFilter, name, err)
+		}
+		q.Conditions = append(q.Conditions, cond)
+	}
+
+	if f.Sort != nil {
+		for _, s := range *f.Sort {
+			field, ok := fields[s.ColId]
+			if !ok || field.NoSort {
+				return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidFilter, s.ColId)
+			}
+			dir := strings.ToLower(s.Sort)
+			if dir == "" {
+				dir = "asc"
+			}
+			if dir != "asc" && dir != "desc" {
+				return nil, fmt.Errorf("%w: sort direction %q", ErrInvalidFilter, s.Sort)
+			}
+			q.Order = append(q.Order, field.Column+" "+dir)
+		}
+	}
+	return q, nil
+}
+
+func (f FilterField) allows(op string) bool {
+	if len(f.Operators) == 0 {
+		return true
+	}
+	for _, allowed := range f.Operators {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
+
+func condition(col string, f Filter) (Condition, error) {
+	switch f.Type {
+	case OpContains:
+		return Condition{"LOWER(" + col + ") LIKE ? ESCAPE '!'", []interface{}{"%" + likeEscape(f.From) + "%"}}, nil
+	case OpNotContains:
+		return Condition{"LOWER(" + col + ") NOT LIKE ? ESCAPE '!'", []interface{}{"%" + likeEscape(f.From) + "%"}}, nil
+	case OpStartsWith:
+		return Condition{"LOWER(" + col + ") LIKE ? ESCAPE '!'", []interface{}{likeEscape(f.From) + "%"}}, nil
+	case OpEndsWith:
+		return Condition{"LOWER(" + col + ") LIKE ? ESCAPE '!'", []interface{}{"%" + likeEscape(f.From)}}, nil
+	case OpEquals:
+		return Condition{col + " = ?", []interface{}{f.From}}, nil
+	case OpNotEqual:
+		return Condition{col + " <> ?", []interface{}{f.From}}, nil
+	case OpLessThan:
+		return Condition{col + " < ?", []interface{}{f.From}}, nil
+	case OpLessThanOrEqual:
+		return Condition{col + " <= ?", []interface{}{f.From}}, nil
+	case OpGreaterThan:
+		return Condition{col + " > ?", []interface{}{f.From}}, nil
+	case OpGreaterThanOrEqual:
+		return Condition{col + " >= ?", []interface{}{f.From}}, nil
+	case OpInRange:
+		// Either bound may be left empty for an open range
+		switch {
+		case f.From != "" && f.To != "":
+			return Condition{col + " >= ? AND " + col + " <= ?", []interface{}{f.From, f.To}}, nil
+		case f.From != "":
+			return Condition{col + " >= ?", []interface{}{f.From}}, nil
+		case f.To != "":
+			return Condition{col + " <= ?", []interface{}{f.To}}, nil
+		}
+		return Condition{}, errors.New("inRange needs from or to")
+	case OpIn:
+		values := f.Values
+		if len(values) == 0 && f.From != "" {
+			values = strings.Split(f.From, ",")
+		}
+		if len(values) == 0 {
+			return Condition{}, errors.New("in needs values")
+		}
+		if len(values) > MaxFilterValues {
+			return Condition{}, fmt.Errorf("in accepts at most %d values", MaxFilterValues)
+		}
+		args := make([]interface{}, len(values))
+		for i, v := range values {
+			args[i] = strings.TrimSpace(v)
+		}
+		return Condition{col + " IN (" + strings.TrimSuffix(strings.Repeat("?,", len(args)), ",") + ")", args}, nil
+	case OpIsNull:
+		// from "false" inverts the check
+		if strings.EqualFold(f.From, "false") {
+			return Condition{SQL: col + " IS NOT NULL"}, nil
+		}
+		return Condition{SQL: col + " IS NULL"}, nil
+	}
+	return Condition{}, fmt.Errorf("unsupported operator %q", f.Type)
+}
+
+// likeEscape lowercases s for a case-insensitive LIKE; wildcards in s match
+// literally. The escape character is "!" since a backslash is itself an
+// escape in MySQL string literals
+func likeEscape(s string) string {
+	s = strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(s)
+	return strings.ToLower(s)
+}
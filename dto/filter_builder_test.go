@@ -0,0 +1,86 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildConditions(t *testing.T) {
+	fields := AllowFields("name", "price", "deleted_at")
+
+	tests := []struct {
+		name   string
+		filter Filter
+		sql    string
+		args   []interface{}
+	}{
+		{"contains", Filter{Type: OpContains, From: "Ab"}, "LOWER(name) LIKE ? ESCAPE '!'", []interface{}{"%ab%"}},
+		{"contains escapes wildcards", Filter{Type: OpContains, From: "50%_!"}, "LOWER(name) LIKE ? ESCAPE '!'", []interface{}{"%50!%!_!!%"}},
+		{"equals", Filter{Type: OpEquals, From: "x"}, "name = ?", []interface{}{"x"}},
+		{"inRange", Filter{Type: OpInRange, From: "1", To: "5"}, "name >= ? AND name <= ?", []interface{}{"1", "5"}},
+		{"inRange open", Filter{Type: OpInRange, To: "5"}, "name <= ?", []interface{}{"5"}},
+		{"in", Filter{Type: OpIn, Values: []string{"a", "b"}}, "name IN (?,?)", []interface{}{"a", "b"}},
+		{"in from list", Filter{Type: OpIn, From: "a, b,c"}, "name IN (?,?,?)", []interface{}{"a", "b", "c"}},
+		{"isNull", Filter{Type: OpIsNull}, "name IS NULL", nil},
+		{"isNull false", Filter{Type: OpIsNull, From: "false"}, "name IS NOT NULL", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := DynamicFilter{Filter: map[string]Filter{"name": tt.filter}}
+			q, err := f.Build(fields)
+			require.NoError(t, err)
+			require.Len(t, q.Conditions, 1)
+			assert.Equal(t, tt.sql, q.Conditions[0].SQL)
+			assert.Equal(t, tt.args, q.Conditions[0].Args)
+		})
+	}
+}
+
+func TestBuildRejectsInvalidInput(t *testing.T) {
+	fields := FilterFields{
+		"name":  {Column: "name", Operators: []string{OpContains}},
+		"email": {Column: "email", NoSort: true},
+	}
+
+	tests := []struct {
+		name   string
+		filter DynamicFilter
+	}{
+		{"unknown field", DynamicFilter{Filter: map[string]Filter{"name = 1 OR 1": {Type: OpEquals}}}},
+		{"operator not allowed", DynamicFilter{Filter: map[string]Filter{"name": {Type: OpEquals}}}},
+		{"unknown operator", DynamicFilter{Filter: map[string]Filter{"email": {Type: "regex"}}}},
+		{"empty range", DynamicFilter{Filter: map[string]Filter{"email": {Type: OpInRange}}}},
+		{"empty in", DynamicFilter{Filter: map[string]Filter{"email": {Type: OpIn}}}},
+		{"unknown sort", DynamicFilter{Sort: &[]Sort{{ColId: "id; DROP TABLE users", Sort: "asc"}}}},
+		{"sort not allowed", DynamicFilter{Sort: &[]Sort{{ColId: "email", Sort: "asc"}}}},
+		{"bad direction", DynamicFilter{Sort: &[]Sort{{ColId: "name", Sort: "asc; --"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.filter.Build(fields)
+			assert.ErrorIs(t, err, ErrInvalidFilter)
+		})
+	}
+}
+
+func TestBuildWhereAndOrder(t *testing.T) {
+	f := DynamicFilter{
+		Filter: map[string]Filter{
+			"price": {Type: OpGreaterThan, From: "10"},
+			"name":  {Type: OpStartsWith, From: "a"},
+		},
+		Sort: &[]Sort{{ColId: "price", Sort: "DESC"}, {ColId: "name"}},
+	}
+
+	q, err := f.Build(AllowFields("name", "price"))
+	require.NoError(t, err)
+
+	where, args := q.Where()
+	assert.Equal(t, "(LOWER(name) LIKE ? ESCAPE '!') AND (price > ?)", where)
+	assert.Equal(t, []interface{}{"a%", "10"}, args)
+	assert.Equal(t, "price desc, name asc", q.OrderBy())
+}
@@ -0,0 +1,122 @@
+package election
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PostgresBackend holds leases as session level advisory locks, each on a
+// dedicated connection. A lease lasts as long as its connection, so the ttl
+// is unused: when a leader dies its connection closes and the lock is freed
+type PostgresBackend struct {
+	db *gorm.DB
+
+	mu   sync.Mutex
+	held map[string]*heldLock
+}
+
+type heldLock struct {
+	owner string
+	conn  *sql.Conn
+}
+
+// NewPostgresBackend creates an advisory lock backend
+func NewPostgresBackend(db *gorm.DB) *PostgresBackend {
+	return &PostgresBackend{db: db, held: make(map[string]*heldLock)}
+}
+
+// Acquire implements Backend
+func (b *PostgresBackend) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	h, ok := b.held[key]
+	b.mu.Unlock()
+	if ok {
+		return h.owner == owner, nil
+	}
+
+	sqlDB, err := b.db.DB()
+	if err != nil {
+		return false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockID(key)).Scan(&acquired); err != nil {
+		discard(conn)
+		return false, err
+	}
+	if !acquired {
+		conn.Close()
+		return false, nil
+	}
+
+	b.mu.Lock()
+	b.held[key] = &heldLock{owner: owner, conn: conn}
+	b.mu.Unlock()
+	return true, nil
+}
+
+// Renew implements Backend by checking that the lock's connection is alive
+func (b *PostgresBackend) Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	h := b.take(key, owner, false)
+	if h == nil {
+		return false, nil
+	}
+	if _, err := h.conn.ExecContext(ctx, "SELECT 1"); err != nil {
+		// The session, and with it the lock, may be gone
+		b.take(key, owner, true)
+		discard(h.conn)
+		return false, nil
+	}
+	return true, nil
+}
+
+// Release implements Backend
+func (b *PostgresBackend) Release(ctx context.Context, key, owner string) error {
+	h := b.take(key, owner, true)
+	if h == nil {
+		return nil
+	}
+	if _, err := h.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockID(key)); err != nil {
+		// Closing the session frees the lock instead
+		discard(h.conn)
+		return err
+	}
+	return h.conn.Close()
+}
+
+// take returns the lock of key held by owner, removing it if remove is set
+func (b *PostgresBackend) take(key, owner string, remove bool) *heldLock {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h, ok := b.held[key]
+	if !ok || h.owner != owner {
+		return nil
+	}
+	if remove {
+		delete(b.held, key)
+	}
+	return h
+}
+
+// discard closes the connection instead of returning it to the pool, where
+// it would keep holding its session locks
+func discard(conn *sql.Conn) {
+	_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	_ = conn.Close()
+}
+
+// lockID maps a key to an advisory lock id
+func lockID(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("election:" + key))
+	return int64(h.Sum64())
+}
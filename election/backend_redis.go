@@ -0,0 +1,65 @@
+package election
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireScript takes a free key or extends the caller's own lease
+var acquireScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// renewScript extends a lease only while the caller still holds it
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes a key only while the caller still holds it
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisBackend keeps leases as expiring Redis keys holding the owner
+type RedisBackend struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisBackend creates a Redis backed election backend; keys are
+// prefixed with "election:"
+func NewRedisBackend(client redis.UniversalClient) *RedisBackend {
+	return &RedisBackend{client: client, prefix: "election:"}
+}
+
+// Acquire implements Backend
+func (b *RedisBackend) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	n, err := acquireScript.Run(ctx, b.client, []string{b.prefix + key}, owner, ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+// Renew implements Backend
+func (b *RedisBackend) Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	n, err := renewScript.Run(ctx, b.client, []string{b.prefix + key}, owner, ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+// Release implements Backend
+func (b *RedisBackend) Release(ctx context.Context, key, owner string) error {
+	return releaseScript.Run(ctx, b.client, []string{b.prefix + key}, owner).Err()
+}
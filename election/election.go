@@ -0,0 +1,331 @@
+package election
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/metrics"
+)
+
+// ErrInvalidTTL is returned by Campaign for a non-positive lease duration
+var ErrInvalidTTL = errors.New("election: ttl must be positive")
+
+// Backend grants a key to one owner at a time as a lease that expires
+// unless it is renewed
+type Backend interface {
+	// Acquire reports whether key was free, or already held by owner, and
+	// is now held by owner for ttl
+	Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// Renew extends the lease of owner on key by ttl; false means the lease
+	// was lost
+	Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// Release frees key if owner holds it
+	Release(ctx context.Context, key, owner string) error
+}
+
+// Config configures an elector
+type Config struct {
+	Backend Backend
+	// Identity names this replica in logs and the backend
+	// (default: hostname-pid)
+	Identity string
+	// RetryInterval is how often a follower tries to take the lead
+	// (default: a third of the ttl)
+	RetryInterval time.Duration
+	Logger        logging.Logger
+}
+
+// Elector elects one leader per key among the replicas sharing a backend,
+// e.g. to run an outbox relay or a pruner on a single replica without
+// access to the Kubernetes lease API
+type Elector struct {
+	cfg Config
+}
+
+// New creates an elector
+func New(cfg Config) *Elector {
+	if cfg.Identity == "" {
+		host, _ := os.Hostname()
+		cfg.Identity = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	return &Elector{cfg: cfg}
+}
+
+// Campaign blocks until this replica leads key or ctx is done. The lease
+// lasts ttl and is renewed in the background every ttl/3; leadership ends
+// when a renewal is refused, when renewals keep failing for two thirds of
+// the ttl, on Resign, or when ctx is done
+func (e *Elector) Campaign(ctx context.Context, key string, ttl time.Duration) (*Leadership, error) {
+	if ttl <= 0 {
+		return nil, ErrInvalidTTL
+	}
+	retry := e.cfg.RetryInterval
+	if retry <= 0 {
+		retry = ttl / 3
+	}
+	owner := e.cfg.Identity + "/" + randomID()
+
+	for {
+		start := time.Now()
+		acquired, err := e.cfg.Backend.Acquire(ctx, key, owner, ttl)
+		if err != nil && ctx.Err() == nil {
+			e.log(key, "Leader election failed", err)
+		}
+		if acquired {
+			return e.lead(ctx, key, owner, ttl, start), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retry):
+		}
+	}
+}
+
+// Run campaigns for key and calls fn while leading. The context of fn is
+// cancelled when leadership ends, after which Run campaigns again; when fn
+// returns on its own the key is released and the election starts over.
+// Run returns once ctx is done
+func (e *Elector) Run(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) error) error {
+	for {
+		leadership, err := e.Campaign(ctx, key, ttl)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(leadership.Context()); err != nil && leadership.Context().Err() == nil {
+			e.log(key, "Leader task failed", err)
+		}
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_ = leadership.Resign(releaseCtx)
+		cancel()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+func (e *Elector) lead(ctx context.Context, key, owner string, ttl time.Duration, acquiredAt time.Time) *Leadership {
+	leadCtx, cancel := context.WithCancel(ctx)
+	l := &Leadership{
+		elector: e,
+		key:     key,
+		owner:   owner,
+		ttl:     ttl,
+		ctx:     leadCtx,
+		cancel:  cancel,
+		resign:  make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	metrics.LeaderElectionIsLeader.WithLabelValues(key).Set(1)
+	metrics.LeaderElectionChangesTotal.WithLabelValues(key, "acquired").Inc()
+	e.info(key, "Became leader", owner)
+
+	go l.renew(acquiredAt)
+	return l
+}
+
+func (e *Elector) log(key, msg string, err error) {
+	if e.cfg.Logger == nil {
+		return
+	}
+	e.cfg.Logger.Error(logging.Internal, logging.LeaderElection, msg, map[logging.ExtraKey]interface{}{
+		logging.Name:         key,
+		logging.ErrorMessage: err.Error(),
+	})
+}
+
+func (e *Elector) info(key, msg, owner string) {
+	if e.cfg.Logger == nil {
+		return
+	}
+	e.cfg.Logger.Info(logging.Internal, logging.LeaderElection, msg, map[logging.ExtraKey]interface{}{
+		logging.Name: key,
+		"owner":      owner,
+	})
+}
+
+// ============================================
+// Leadership
+// ============================================
+
+// Leadership is a won election; it is kept until lost or resigned
+type Leadership struct {
+	elector *Elector
+	key     string
+	owner   string
+	ttl     time.Duration
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	once   sync.Once
+	resign chan struct{}
+	done   chan struct{}
+	err    error
+}
+
+// Key returns the election key
+func (l *Leadership) Key() string {
+	return l.key
+}
+
+// Context returns a context that is cancelled when leadership ends; run
+// the singleton work with it
+func (l *Leadership) Context() context.Context {
+	return l.ctx
+}
+
+// Lost returns a channel that is closed when leadership ends
+func (l *Leadership) Lost() <-chan struct{} {
+	return l.ctx.Done()
+}
+
+// Resign stops renewing and releases the key so another replica can take
+// the lead right away
+func (l *Leadership) Resign(ctx context.Context) error {
+	l.once.Do(func() { close(l.resign) })
+	select {
+	case <-l.done:
+		return l.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// renew extends the lease until leadership ends, then releases the key
+func (l *Leadership) renew(lastRenewal time.Time) {
+	defer close(l.done)
+	e := l.elector
+	interval := l.ttl / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	event := "resigned"
+loop:
+	for {
+		select {
+		case <-l.resign:
+			break loop
+		case <-l.ctx.Done():
+			break loop
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		renewCtx, cancel := context.WithTimeout(l.ctx, interval)
+		renewed, err := e.cfg.Backend.Renew(renewCtx, l.key, l.owner, l.ttl)
+		cancel()
+		switch {
+		case err == nil && renewed:
+			lastRenewal = start
+			continue
+		case err == nil:
+			event = "lost"
+			break loop
+		case l.ctx.Err() != nil:
+			break loop
+		}
+
+		e.log(l.key, "Leader lease renewal failed", err)
+		// Step down before the lease can expire and another replica lead
+		if time.Since(lastRenewal) >= l.ttl-interval {
+			event = "lost"
+			break loop
+		}
+	}
+
+	l.cancel()
+	metrics.LeaderElectionIsLeader.WithLabelValues(l.key).Set(0)
+	metrics.LeaderElectionChangesTotal.WithLabelValues(l.key, event).Inc()
+	if event == "lost" {
+		e.info(l.key, "Lost leadership", l.owner)
+	} else {
+		e.info(l.key, "Resigned leadership", l.owner)
+	}
+
+	releaseCtx, cancel := context.WithTimeout(context.Background(), interval)
+	defer cancel()
+	if err := e.cfg.Backend.Release(releaseCtx, l.key, l.owner); err != nil {
+		l.err = err
+		e.log(l.key, "Leader lease release failed", err)
+	}
+}
+
+// randomID returns a short random hex string
+func randomID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ============================================
+// Memory backend
+// ============================================
+
+// MemoryBackend is a Backend for a single process, e.g. in tests
+type MemoryBackend struct {
+	mu     sync.Mutex
+	leases map[string]lease
+}
+
+type lease struct {
+	owner   string
+	expires time.Time
+}
+
+// NewMemoryBackend creates an in-memory backend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{leases: make(map[string]lease)}
+}
+
+// Acquire implements Backend
+func (b *MemoryBackend) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	if l, ok := b.leases[key]; ok && l.owner != owner && now.Before(l.expires) {
+		return false, nil
+	}
+	b.leases[key] = lease{owner: owner, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// Renew implements Backend
+func (b *MemoryBackend) Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	l, ok := b.leases[key]
+	if !ok || l.owner != owner || !now.Before(l.expires) {
+		return false, nil
+	}
+	b.leases[key] = lease{owner: owner, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// Release implements Backend
+func (b *MemoryBackend) Release(ctx context.Context, key, owner string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if l, ok := b.leases[key]; ok && l.owner == owner {
+		delete(b.leases, key)
+	}
+	return nil
+}
+
+// Expire drops the lease of key as if it had timed out, e.g. to simulate
+// a network partition in tests
+func (b *MemoryBackend) Expire(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.leases, key)
+}
@@ -0,0 +1,118 @@
+package election
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTTL = 90 * time.Millisecond
+
+func TestCampaignElectsOneLeader(t *testing.T) {
+	backend := NewMemoryBackend()
+	a := New(Config{Backend: backend, Identity: "a"})
+	b := New(Config{Backend: backend, Identity: "b"})
+	ctx := context.Background()
+
+	leader, err := a.Campaign(ctx, "relay", testTTL)
+	require.NoError(t, err)
+
+	// b can't win while a renews its lease
+	short, cancel := context.WithTimeout(ctx, 3*testTTL)
+	defer cancel()
+	_, err = b.Campaign(short, "relay", testTTL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Resigning hands the key over
+	require.NoError(t, leader.Resign(ctx))
+	select {
+	case <-leader.Lost():
+	default:
+		t.Fatal("resigned leadership not reported as lost")
+	}
+	next, err := b.Campaign(ctx, "relay", testTTL)
+	require.NoError(t, err)
+	assert.Equal(t, "relay", next.Key())
+	require.NoError(t, next.Resign(ctx))
+}
+
+func TestLeadershipLostWhenLeaseTaken(t *testing.T) {
+	backend := NewMemoryBackend()
+	a := New(Config{Backend: backend, Identity: "a"})
+	b := New(Config{Backend: backend, Identity: "b"})
+	ctx := context.Background()
+
+	leader, err := a.Campaign(ctx, "pruner", testTTL)
+	require.NoError(t, err)
+
+	// Simulate the lease expiring during a partition
+	backend.Expire("pruner")
+	next, err := b.Campaign(ctx, "pruner", testTTL)
+	require.NoError(t, err)
+	defer next.Resign(ctx)
+
+	select {
+	case <-leader.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("leadership not lost")
+	}
+	assert.Error(t, leader.Context().Err())
+	// Releasing after the loss leaves the new leader's lease alone
+	require.NoError(t, leader.Resign(ctx))
+	ok, err := backend.Renew(ctx, "pruner", next.owner, testTTL)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestLeadershipEndsWithContext(t *testing.T) {
+	backend := NewMemoryBackend()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	leader, err := New(Config{Backend: backend}).Campaign(ctx, "job", testTTL)
+	require.NoError(t, err)
+	cancel()
+	<-leader.Lost()
+	require.NoError(t, leader.Resign(context.Background()))
+
+	// The key was released
+	ok, err := backend.Acquire(context.Background(), "job", "other", testTTL)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestRunCampaignsAgainAfterLoss(t *testing.T) {
+	backend := NewMemoryBackend()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var terms atomic.Int32
+	done := make(chan error, 1)
+	go func() {
+		done <- New(Config{Backend: backend}).Run(ctx, "relay", testTTL, func(ctx context.Context) error {
+			terms.Add(1)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+
+	require.Eventually(t, func() bool { return terms.Load() == 1 }, time.Second, 5*time.Millisecond)
+	// Another replica takes over, then steps down
+	backend.Expire("relay")
+	ok, err := backend.Acquire(ctx, "relay", "intruder", testTTL)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, backend.Release(ctx, "relay", "intruder"))
+
+	require.Eventually(t, func() bool { return terms.Load() == 2 }, time.Second, 5*time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestCampaignRejectsInvalidTTL(t *testing.T) {
+	_, err := New(Config{Backend: NewMemoryBackend()}).Campaign(context.Background(), "x", 0)
+	assert.ErrorIs(t, err, ErrInvalidTTL)
+}
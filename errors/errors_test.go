@@ -117,3 +117,24 @@ func TestRepositoryErrorMessageWithoutEntity(t *testing.T) {
 	assert.Contains(t, msg, "connect")
 	assert.NotContains(t, msg, "  ") // No double spaces from empty entity
 }
+
+func TestMultiError(t *testing.T) {
+	var multi MultiError
+	assert.Nil(t, multi.ErrorOrNil())
+
+	multi.Add(2, NewNotFoundError("user", "update"))
+	multi.Add(0, ErrValidation)
+	multi.Add(1, nil)
+
+	err := multi.ErrorOrNil()
+	assert.Error(t, err)
+	assert.Equal(t, 2, multi.Len())
+	assert.True(t, IsNotFound(err))
+	assert.True(t, IsValidation(err))
+	assert.Nil(t, multi.For(1))
+	assert.Equal(t, ErrValidation, multi.For(0))
+	assert.Contains(t, err.Error(), "2 items failed: item 0: validation error; item 2:")
+
+	var repoErr *RepositoryError
+	assert.True(t, errors.As(err, &repoErr))
+}
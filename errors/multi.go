@@ -0,0 +1,78 @@
+package errors
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ItemError is the error of one item of a batch operation
+type ItemError struct {
+	Index int   // Position of the item in the batch
+	Err   error // Underlying error
+}
+
+func (e ItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e ItemError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError collects the per item errors of a batch operation in which
+// other items may have succeeded
+type MultiError struct {
+	Errors []ItemError
+}
+
+// Add records err for the item at index; nil errors are ignored
+func (e *MultiError) Add(index int, err error) {
+	if err != nil {
+		e.Errors = append(e.Errors, ItemError{Index: index, Err: err})
+	}
+}
+
+// For returns the error of the item at index, or nil
+func (e *MultiError) For(index int) error {
+	for _, item := range e.Errors {
+		if item.Index == index {
+			return item.Err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of failed items
+func (e *MultiError) Len() int {
+	return len(e.Errors)
+}
+
+// ErrorOrNil returns e if any item failed, else nil
+func (e *MultiError) ErrorOrNil() error {
+	if e == nil || len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *MultiError) Error() string {
+	items := make([]ItemError, len(e.Errors))
+	copy(items, e.Errors)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Index < items[j].Index })
+
+	msgs := make([]string, len(items))
+	for i, item := range items {
+		msgs[i] = item.Error()
+	}
+	return fmt.Sprintf("%d items failed: %s", len(items), strings.Join(msgs, "; "))
+}
+
+// Unwrap lets errors.Is and errors.As match any item error
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, item := range e.Errors {
+		errs[i] = item
+	}
+	return errs
+}
@@ -0,0 +1,208 @@
+package exports
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minisource/go-common/i18n"
+	"github.com/xuri/excelize/v2"
+)
+
+var (
+	// ErrTooManyRows is returned when an export or import exceeds its
+	// MaxRows limit
+	ErrTooManyRows = errors.New("exports: too many rows")
+	// ErrTooLarge is returned when an imported file exceeds MaxBytes
+	ErrTooLarge = errors.New("exports: file too large")
+	// ErrUnknownFormat is returned for formats other than CSV and XLSX
+	ErrUnknownFormat = errors.New("exports: unknown format")
+)
+
+// Format is a spreadsheet file format
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Extension returns the file extension of the format, with its dot
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+func (f Format) valid() bool {
+	return f == CSV || f == XLSX
+}
+
+// ============================================
+// Columns
+// ============================================
+
+// Column maps a field of T to a spreadsheet column
+type Column[T any] struct {
+	// Header is the column title; i18n keys such as "exports.email" are
+	// translated to the export or import language
+	Header string
+	// Key names the column in import errors (default: Header)
+	Key string
+	// Value returns the cell of a row when exporting: a string, number,
+	// bool, time.Time or nil
+	Value func(row T) interface{}
+	// Parse sets the field from a cell when importing; columns without it
+	// are ignored on import
+	Parse func(row *T, cell string) error
+	// Layout formats and parses time values (default time.RFC3339)
+	Layout string
+	// Optional columns may be missing from imported files
+	Optional bool
+}
+
+func (c Column[T]) key() string {
+	if c.Key != "" {
+		return c.Key
+	}
+	return c.Header
+}
+
+// title returns the header in lang, or as is when it isn't an i18n key
+func (c Column[T]) title(lang string) string {
+	if lang == "" {
+		return c.Header
+	}
+	return i18n.TLang(lang, c.Header)
+}
+
+// withSetter drops the Parse of col when set is nil
+func withSetter[T, V any](set func(*T, V), col Column[T]) Column[T] {
+	if set == nil {
+		col.Parse = nil
+	}
+	return col
+}
+
+// StringColumn maps a string field. In this and the other typed
+// constructors, set may be nil for columns that are only exported
+func StringColumn[T any](header string, get func(T) string, set func(*T, string)) Column[T] {
+	return withSetter(set, Column[T]{
+		Header: header,
+		Value:  func(row T) interface{} { return get(row) },
+		Parse: func(row *T, cell string) error {
+			set(row, cell)
+			return nil
+		},
+	})
+}
+
+// IntColumn maps an integer field; empty cells leave it zero
+func IntColumn[T any](header string, get func(T) int64, set func(*T, int64)) Column[T] {
+	return withSetter(set, Column[T]{
+		Header: header,
+		Value:  func(row T) interface{} { return get(row) },
+		Parse: func(row *T, cell string) error {
+			if cell = strings.TrimSpace(cell); cell == "" {
+				return nil
+			}
+			n, err := strconv.ParseInt(cell, 10, 64)
+			if err != nil {
+				// Spreadsheets may store whole numbers as "12.0"
+				f, ferr := strconv.ParseFloat(cell, 64)
+				if ferr != nil || f != float64(int64(f)) {
+					return err
+				}
+				n = int64(f)
+			}
+			set(row, n)
+			return nil
+		},
+	})
+}
+
+// FloatColumn maps a decimal field; empty cells leave it zero
+func FloatColumn[T any](header string, get func(T) float64, set func(*T, float64)) Column[T] {
+	return withSetter(set, Column[T]{
+		Header: header,
+		Value:  func(row T) interface{} { return get(row) },
+		Parse: func(row *T, cell string) error {
+			if cell = strings.TrimSpace(cell); cell == "" {
+				return nil
+			}
+			f, err := strconv.ParseFloat(cell, 64)
+			if err != nil {
+				return err
+			}
+			set(row, f)
+			return nil
+		},
+	})
+}
+
+// BoolColumn maps a boolean field, accepting true/false, 1/0 and yes/no;
+// empty cells leave it false
+func BoolColumn[T any](header string, get func(T) bool, set func(*T, bool)) Column[T] {
+	return withSetter(set, Column[T]{
+		Header: header,
+		Value:  func(row T) interface{} { return get(row) },
+		Parse: func(row *T, cell string) error {
+			switch strings.ToLower(strings.TrimSpace(cell)) {
+			case "":
+			case "true", "1", "yes", "y":
+				set(row, true)
+			case "false", "0", "no", "n":
+				set(row, false)
+			default:
+				return fmt.Errorf("invalid boolean %q", cell)
+			}
+			return nil
+		},
+	})
+}
+
+// TimeColumn maps a time field formatted with layout (default
+// time.RFC3339); XLSX files store dates natively. Empty cells leave it zero
+func TimeColumn[T any](header, layout string, get func(T) time.Time, set func(*T, time.Time)) Column[T] {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return withSetter(set, Column[T]{
+		Header: header,
+		Layout: layout,
+		Value: func(row T) interface{} {
+			if t := get(row); !t.IsZero() {
+				return t
+			}
+			return nil
+		},
+		Parse: func(row *T, cell string) error {
+			if cell = strings.TrimSpace(cell); cell == "" {
+				return nil
+			}
+			t, err := time.Parse(layout, cell)
+			if err != nil {
+				// Raw XLSX date cells are serial numbers
+				serial, ferr := strconv.ParseFloat(cell, 64)
+				if ferr != nil {
+					return err
+				}
+				if t, err = excelize.ExcelDateToTime(serial, false); err != nil {
+					return err
+				}
+			}
+			set(row, t)
+			return nil
+		},
+	})
+}
@@ -0,0 +1,167 @@
+package exports
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/minisource/go-common/repository"
+	"github.com/minisource/go-common/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type user struct {
+	Name    string `validate:"required"`
+	Email   string `validate:"required,email"`
+	Age     int64
+	Balance float64
+	Active  bool
+	Joined  time.Time
+}
+
+func userColumns() []Column[user] {
+	return []Column[user]{
+		StringColumn("Name", func(u user) string { return u.Name }, func(u *user, v string) { u.Name = v }),
+		StringColumn("Email", func(u user) string { return u.Email }, func(u *user, v string) { u.Email = v }),
+		IntColumn("Age", func(u user) int64 { return u.Age }, func(u *user, v int64) { u.Age = v }),
+		FloatColumn("Balance", func(u user) float64 { return u.Balance }, func(u *user, v float64) { u.Balance = v }),
+		BoolColumn("Active", func(u user) bool { return u.Active }, func(u *user, v bool) { u.Active = v }),
+		TimeColumn("Joined", "2006-01-02", func(u user) time.Time { return u.Joined }, func(u *user, v time.Time) { u.Joined = v }),
+	}
+}
+
+var users = []user{
+	{Name: "Ali", Email: "ali@example.com", Age: 31, Balance: 12.5, Active: true, Joined: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+	{Name: "=SUM(A1)", Email: "sara@example.com", Age: 27, Balance: -3},
+}
+
+func TestCSVExport(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, userColumns(), Options{})
+	require.NoError(t, err)
+	require.NoError(t, w.Write(users...))
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "Name,Email,Age,Balance,Active,Joined\n"+
+		"Ali,ali@example.com,31,12.5,true,2024-03-01\n"+
+		"'=SUM(A1),sara@example.com,27,-3,false,\n", buf.String())
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{CSV, XLSX} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, userColumns(), Options{Format: format, BOM: true})
+			require.NoError(t, err)
+			require.NoError(t, w.Write(users...))
+			require.NoError(t, w.Close())
+
+			got, err := Import(&buf, userColumns(), ImportOptions{Format: format})
+			require.NoError(t, err)
+			assert.Equal(t, users, got)
+		})
+	}
+}
+
+func TestExportMaxRows(t *testing.T) {
+	w, err := NewWriter(&bytes.Buffer{}, userColumns(), Options{MaxRows: 1})
+	require.NoError(t, err)
+	assert.ErrorIs(t, w.Write(users...), ErrTooManyRows)
+	assert.Equal(t, 1, w.Rows())
+}
+
+func TestHeadersTranslated(t *testing.T) {
+	columns := []Column[user]{
+		StringColumn("common.search", func(u user) string { return u.Name }, func(u *user, v string) { u.Name = v }),
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, columns, Options{Lang: "fa"})
+	require.NoError(t, err)
+	require.NoError(t, w.Write(users[0]))
+	require.NoError(t, w.Close())
+	assert.Equal(t, "جستجو\nAli\n", buf.String())
+
+	got, err := Import(&buf, columns, ImportOptions{Lang: "fa"})
+	require.NoError(t, err)
+	assert.Equal(t, "Ali", got[0].Name)
+}
+
+func TestImportErrors(t *testing.T) {
+	validate := validator.New()
+	csv := "email,NAME,Age,Extra\n" +
+		"ali@example.com,Ali,31,x\n" +
+		",,,\n" +
+		"not-an-email,Sara,27\n" +
+		"reza@example.com,,old\n"
+
+	columns := userColumns()
+	columns[4].Optional = true
+	columns[5].Optional = true
+	columns[3].Optional = true
+
+	_, err := Import(strings.NewReader(csv), columns, ImportOptions{Lang: "en", Validate: validate.Struct})
+	var importErr *ImportError
+	require.True(t, errors.As(err, &importErr))
+	assert.Equal(t, []response.ValidationError{
+		{Field: "rows[4].Email", Message: "Invalid email address", Code: "email"},
+		{Field: "rows[5].Age", Message: "Invalid format", Code: "invalid_format"},
+	}, importErr.Errors)
+
+	// Missing columns are reported before any row is read
+	_, err = Import(strings.NewReader("Name\nAli\n"), userColumns(), ImportOptions{Lang: "en"})
+	require.True(t, errors.As(err, &importErr))
+	assert.Len(t, importErr.Errors, 5)
+	assert.Equal(t, response.ValidationError{Field: "Email", Message: "Required column is missing", Code: "missing_column"}, importErr.Errors[0])
+}
+
+func TestImportLimits(t *testing.T) {
+	csv := "Name,Email,Age,Balance,Active,Joined\n" + strings.Repeat("Ali,ali@example.com,1,1,true,\n", 3)
+
+	_, err := Import(strings.NewReader(csv), userColumns(), ImportOptions{MaxRows: 2})
+	assert.ErrorIs(t, err, ErrTooManyRows)
+
+	_, err = Import(strings.NewReader(csv), userColumns(), ImportOptions{MaxBytes: 40})
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = Import(strings.NewReader(csv), userColumns(), ImportOptions{Format: "json"})
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+type product struct {
+	ID    uuid.UUID `gorm:"primaryKey"`
+	Name  string
+	Price int64
+}
+
+func TestWriteQueryKeepsOrder(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&product{}))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, db.Create(&product{ID: uuid.New(), Name: fmt.Sprintf("p%d", i), Price: int64(i)}).Error)
+	}
+
+	columns := []Column[product]{
+		IntColumn("Price", func(p product) int64 { return p.Price }, nil),
+	}
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, columns, Options{})
+	require.NoError(t, err)
+	q := repository.NewGormRepository[product](db).Query().Order("price DESC")
+	require.NoError(t, WriteQuery(w, q, 3))
+	require.NoError(t, w.Close())
+	assert.Equal(t, "Price\n9\n8\n7\n6\n5\n4\n3\n2\n1\n0\n", buf.String())
+
+	err = WriteQuery(w, repository.NewGormRepository[product](db).Query().Preload("Orders"), 3)
+	assert.ErrorIs(t, err, repository.ErrPreloadInBatches)
+}
@@ -0,0 +1,320 @@
+package exports
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/minisource/go-common/i18n"
+	"github.com/minisource/go-common/response"
+	"github.com/xuri/excelize/v2"
+)
+
+// ImportOptions configures an import
+type ImportOptions struct {
+	// Format is CSV or XLSX (default CSV)
+	Format Format
+	// Lang matches headers that are i18n keys and translates error
+	// messages; headers are matched as written too
+	Lang string
+	// Sheet is the XLSX worksheet to read (default: the first one)
+	Sheet string
+	// MaxRows fails the import with ErrTooManyRows past this many data
+	// rows (default 10000)
+	MaxRows int
+	// MaxBytes fails the import with ErrTooLarge past this file size
+	// (default 10MB); XLSX files may unzip to 20 times as much
+	MaxBytes int64
+	// MaxErrors stops reading once this many errors were found (default 100)
+	MaxErrors int
+	// Validate checks each parsed row, e.g. validator.New().Struct; field
+	// errors from go-playground/validator are reported per field
+	Validate func(row interface{}) error
+}
+
+// DefaultImportOptions returns the default import options
+func DefaultImportOptions() ImportOptions {
+	return ImportOptions{
+		Format:    CSV,
+		MaxRows:   10000,
+		MaxBytes:  10 << 20,
+		MaxErrors: 100,
+	}
+}
+
+// ImportError lists the problems found in an imported file. Fields are
+// named "rows[<line>].<column key>", with the line as numbered by
+// spreadsheets, so the header is line 1. Send them with
+// response.UnprocessableEntity:
+//
+//	var importErr *exports.ImportError
+//	if errors.As(err, &importErr) {
+//	    return response.UnprocessableEntity(c, importErr.Errors)
+//	}
+type ImportError struct {
+	Errors []response.ValidationError
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("exports: %d import errors", len(e.Errors))
+}
+
+func (e *ImportError) add(field, message, code string) {
+	e.Errors = append(e.Errors, response.ValidationError{Field: field, Message: message, Code: code})
+}
+
+// Import reads every row of a CSV or XLSX file into T. Columns are matched
+// by header, in any order; unknown columns are ignored. Nothing is returned
+// unless every row parses and validates, otherwise the error is an
+// *ImportError listing the problems
+func Import[T any](r io.Reader, columns []Column[T], opts ImportOptions) ([]T, error) {
+	defaults := DefaultImportOptions()
+	if opts.Format == "" {
+		opts.Format = defaults.Format
+	}
+	if opts.MaxRows <= 0 {
+		opts.MaxRows = defaults.MaxRows
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = defaults.MaxBytes
+	}
+	if opts.MaxErrors <= 0 {
+		opts.MaxErrors = defaults.MaxErrors
+	}
+
+	src, err := openRows(&limitReader{r: r, remaining: opts.MaxBytes}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	header, err := src.Next()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	index, importErr := matchColumns(header, columns, opts.Lang)
+	if importErr != nil {
+		return nil, importErr
+	}
+
+	importErr = &ImportError{}
+	var rows []T
+	for line := 2; ; line++ {
+		cells, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if blank(cells) {
+			continue
+		}
+		if len(rows) >= opts.MaxRows {
+			return nil, ErrTooManyRows
+		}
+
+		var row T
+		valid := true
+		for i, col := range columns {
+			if index[i] < 0 {
+				continue
+			}
+			var cell string
+			if index[i] < len(cells) {
+				cell = cells[index[i]]
+			}
+			if err := col.Parse(&row, cell); err != nil {
+				importErr.add(rowField(line, col.key()), i18n.TLang(opts.Lang, "validation.invalid_format"), "invalid_format")
+				valid = false
+			}
+		}
+		if valid && opts.Validate != nil {
+			if err := opts.Validate(&row); err != nil {
+				addValidationErrors(importErr, line, err, opts.Lang)
+			}
+		}
+		if len(importErr.Errors) >= opts.MaxErrors {
+			importErr.Errors = importErr.Errors[:opts.MaxErrors]
+			return nil, importErr
+		}
+		rows = append(rows, row)
+	}
+
+	if len(importErr.Errors) > 0 {
+		return nil, importErr
+	}
+	return rows, nil
+}
+
+// matchColumns returns the cell index of each column, -1 for columns not
+// imported, or an error listing the missing ones
+func matchColumns[T any](header []string, columns []Column[T], lang string) ([]int, *ImportError) {
+	positions := make(map[string]int, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if _, dup := positions[h]; !dup {
+			positions[h] = i
+		}
+	}
+
+	index := make([]int, len(columns))
+	var importErr *ImportError
+	for i, col := range columns {
+		index[i] = -1
+		if col.Parse == nil {
+			continue
+		}
+		for _, name := range []string{col.title(lang), col.Header, col.key()} {
+			if pos, ok := positions[strings.ToLower(strings.TrimSpace(name))]; ok {
+				index[i] = pos
+				break
+			}
+		}
+		if index[i] < 0 && !col.Optional {
+			if importErr == nil {
+				importErr = &ImportError{}
+			}
+			importErr.add(col.key(), i18n.TLang(lang, "validation.missing_column"), "missing_column")
+		}
+	}
+	return index, importErr
+}
+
+// addValidationErrors reports the errors of a Validate call
+func addValidationErrors(importErr *ImportError, line int, err error, lang string) {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		importErr.add(fmt.Sprintf("rows[%d]", line), err.Error(), "invalid")
+		return
+	}
+	for _, fe := range fieldErrs {
+		key := "validation.invalid_format"
+		switch fe.Tag() {
+		case "required":
+			key = "validation.required"
+		case "email":
+			key = "validation.invalid_email"
+		case "uuid", "uuid4":
+			key = "validation.invalid_uuid"
+		}
+		importErr.add(rowField(line, fe.Field()), i18n.TLang(lang, key), fe.Tag())
+	}
+}
+
+func rowField(line int, key string) string {
+	return fmt.Sprintf("rows[%d].%s", line, key)
+}
+
+func blank(cells []string) bool {
+	for _, c := range cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================
+// Row sources
+// ============================================
+
+// rowSource yields the rows of a file, returning io.EOF after the last one
+type rowSource interface {
+	Next() ([]string, error)
+	Close() error
+}
+
+func openRows(r io.Reader, opts ImportOptions) (rowSource, error) {
+	switch opts.Format {
+	case CSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.ReuseRecord = true
+		return &csvRows{reader: cr}, nil
+	case XLSX:
+		file, err := excelize.OpenReader(r, excelize.Options{UnzipSizeLimit: opts.MaxBytes * 20})
+		if err != nil {
+			return nil, err
+		}
+		sheet := opts.Sheet
+		if sheet == "" {
+			sheet = file.GetSheetName(0)
+		}
+		rows, err := file.Rows(sheet)
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		return &xlsxRows{file: file, rows: rows}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+type csvRows struct {
+	reader *csv.Reader
+	line   int
+}
+
+func (s *csvRows) Next() ([]string, error) {
+	record, err := s.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	if s.line == 0 && len(record) > 0 {
+		record[0] = strings.TrimPrefix(record[0], "\ufeff")
+	}
+	s.line++
+	// Undo the formula escaping applied on export
+	for i, cell := range record {
+		if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(cell[1])) {
+			record[i] = cell[1:]
+		}
+	}
+	return record, nil
+}
+
+func (s *csvRows) Close() error {
+	return nil
+}
+
+type xlsxRows struct {
+	file *excelize.File
+	rows *excelize.Rows
+}
+
+func (s *xlsxRows) Next() ([]string, error) {
+	if !s.rows.Next() {
+		if err := s.rows.Error(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	// Raw values keep numbers and dates parsable regardless of cell format
+	return s.rows.Columns(excelize.Options{RawCellValue: true})
+}
+
+func (s *xlsxRows) Close() error {
+	_ = s.rows.Close()
+	return s.file.Close()
+}
+
+// limitReader fails with ErrTooLarge instead of truncating like
+// io.LimitReader
+type limitReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,275 @@
+package exports
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/i18n"
+	"github.com/minisource/go-common/repository"
+	"github.com/minisource/go-common/response"
+	"github.com/xuri/excelize/v2"
+)
+
+// Options configures an export
+type Options struct {
+	// Format is CSV or XLSX (default CSV)
+	Format Format
+	// Lang translates headers that are i18n keys; Send defaults it to the
+	// request language
+	Lang string
+	// MaxRows fails the export with ErrTooManyRows past this many rows
+	// (default: no limit)
+	MaxRows int
+	// Sheet names the XLSX worksheet (default "Sheet1")
+	Sheet string
+	// BOM prefixes CSV output with a UTF-8 byte order mark, which Excel
+	// needs to display non-ASCII text such as Persian headers
+	BOM bool
+}
+
+// Writer streams rows of T to a CSV or XLSX file. CSV rows are written as
+// they come; XLSX rows are buffered by excelize until Close
+type Writer[T any] struct {
+	columns []Column[T]
+	opts    Options
+	out     io.Writer
+	rows    int
+
+	csv    *csv.Writer
+	record []string
+
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	cells  []interface{}
+}
+
+// NewWriter creates a writer and writes the header row
+func NewWriter[T any](out io.Writer, columns []Column[T], opts Options) (*Writer[T], error) {
+	if opts.Format == "" {
+		opts.Format = CSV
+	}
+	if !opts.Format.valid() {
+		return nil, ErrUnknownFormat
+	}
+	if opts.Sheet == "" {
+		opts.Sheet = "Sheet1"
+	}
+	w := &Writer[T]{columns: columns, opts: opts, out: out}
+
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		headers[i] = col.title(opts.Lang)
+	}
+
+	switch opts.Format {
+	case CSV:
+		if opts.BOM {
+			if _, err := io.WriteString(out, "\ufeff"); err != nil {
+				return nil, err
+			}
+		}
+		w.csv = csv.NewWriter(out)
+		w.record = make([]string, len(columns))
+		if err := w.csv.Write(headers); err != nil {
+			return nil, err
+		}
+	case XLSX:
+		w.file = excelize.NewFile()
+		if opts.Sheet != "Sheet1" {
+			if err := w.file.SetSheetName("Sheet1", opts.Sheet); err != nil {
+				return nil, err
+			}
+		}
+		stream, err := w.file.NewStreamWriter(opts.Sheet)
+		if err != nil {
+			return nil, err
+		}
+		w.stream = stream
+		w.cells = make([]interface{}, len(columns))
+		for i, h := range headers {
+			w.cells[i] = h
+		}
+		if err := stream.SetRow("A1", w.cells); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Write appends rows
+func (w *Writer[T]) Write(rows ...T) error {
+	for _, row := range rows {
+		if w.opts.MaxRows > 0 && w.rows >= w.opts.MaxRows {
+			return ErrTooManyRows
+		}
+		w.rows++
+
+		if w.csv != nil {
+			for i, col := range w.columns {
+				w.record[i] = formatCell(col.Value(row), col.Layout)
+			}
+			if err := w.csv.Write(w.record); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for i, col := range w.columns {
+			w.cells[i] = col.Value(row)
+		}
+		// Row 1 is the header
+		cell, err := excelize.CoordinatesToCellName(1, w.rows+1)
+		if err != nil {
+			return err
+		}
+		if err := w.stream.SetRow(cell, w.cells); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rows returns the number of rows written, excluding the header
+func (w *Writer[T]) Rows() int {
+	return w.rows
+}
+
+// Close flushes the file to the output; the output itself is not closed
+func (w *Writer[T]) Close() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	defer w.file.Close()
+	if err := w.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := w.file.WriteTo(w.out)
+	return err
+}
+
+// formatCell renders a value as CSV text. Text starting with a formula
+// character is prefixed with a quote so spreadsheets don't evaluate it
+func formatCell(v interface{}, layout string) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case time.Time:
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return v.Format(layout)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case []byte:
+		return formatCell(string(v), layout)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ============================================
+// Sources
+// ============================================
+
+// WriteQuery writes every row matched by q in its order, loading batchSize
+// rows at a time (default 500); see repository.Query.FindInBatches
+func WriteQuery[T any](w *Writer[T], q *repository.Query[T], batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	return q.FindInBatches(batchSize, func(batch []T) error {
+		return w.Write(batch...)
+	})
+}
+
+// WriteSQLRows exports raw SQL rows as is, with the column names as
+// headers; rows is closed when done
+func WriteSQLRows(out io.Writer, rows *sql.Rows, opts Options) error {
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	columns := make([]Column[[]interface{}], len(names))
+	for i, name := range names {
+		i := i
+		columns[i] = Column[[]interface{}]{
+			Header: name,
+			Value: func(row []interface{}) interface{} {
+				if b, ok := row[i].([]byte); ok {
+					return string(b)
+				}
+				return row[i]
+			},
+		}
+	}
+
+	w, err := NewWriter(out, columns, opts)
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(names))
+	dest := make([]interface{}, len(names))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		if err := w.Write(values); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// Send streams an export as a file download named filename plus the format
+// extension. Headers are translated to the request language unless
+// opts.Lang is set. fn runs after the handler returns, so it must not use
+// c or its context; errors it returns end the download early
+//
+//	ctx := context.WithoutCancel(c.UserContext())
+//	return exports.Send(c, "users", columns, exports.Options{Format: exports.XLSX},
+//	    func(w *exports.Writer[User]) error {
+//	        return exports.WriteQuery(w, repo.Query().WithContext(ctx), 0)
+//	    })
+func Send[T any](c *fiber.Ctx, filename string, columns []Column[T], opts Options, fn func(w *Writer[T]) error) error {
+	if opts.Format == "" {
+		opts.Format = CSV
+	}
+	if !opts.Format.valid() {
+		return response.BadRequest(c, "INVALID_EXPORT", ErrUnknownFormat.Error())
+	}
+	if opts.Lang == "" {
+		opts.Lang = i18n.GetTranslator().GetLangFromContext(c)
+	}
+	return response.StreamAttachment(c, filename+opts.Format.Extension(), opts.Format.ContentType(), func(out *bufio.Writer) error {
+		w, err := NewWriter(out, columns, opts)
+		if err != nil {
+			return err
+		}
+		if err := fn(w); err != nil {
+			return err
+		}
+		return w.Close()
+	})
+}
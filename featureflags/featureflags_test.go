@@ -0,0 +1,183 @@
+package featureflags
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	appctx "github.com/minisource/go-common/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlagEvaluate(t *testing.T) {
+	tests := []struct {
+		name   string
+		flag   Flag
+		target Target
+		want   bool
+	}{
+		{"disabled", Flag{Key: "f"}, Target{UserID: "u1"}, false},
+		{"boolean", Flag{Key: "f", Enabled: true}, Target{}, true},
+		{"listed user", Flag{Key: "f", Enabled: true, Users: []string{"u1"}}, Target{UserID: "u1"}, true},
+		{"other user", Flag{Key: "f", Enabled: true, Users: []string{"u1"}}, Target{UserID: "u2"}, false},
+		{"listed tenant", Flag{Key: "f", Enabled: true, Tenants: []string{"t1"}}, Target{UserID: "u2", TenantID: "t1"}, true},
+		{"disabled listed user", Flag{Key: "f", Users: []string{"u1"}}, Target{UserID: "u1"}, false},
+		{"zero percent", Flag{Key: "f", Enabled: true, Percentage: Percent(0)}, Target{UserID: "u1"}, false},
+		{"full percent", Flag{Key: "f", Enabled: true, Percentage: Percent(100)}, Target{UserID: "u1"}, true},
+		{"percent without target", Flag{Key: "f", Enabled: true, Percentage: Percent(50)}, Target{}, false},
+		{"listed user beats percent", Flag{Key: "f", Enabled: true, Users: []string{"u1"}, Percentage: Percent(0)}, Target{UserID: "u1"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.flag.Evaluate(tt.target))
+		})
+	}
+}
+
+func TestPercentageRollout(t *testing.T) {
+	flag := Flag{Key: "new-checkout", Enabled: true, Percentage: Percent(30)}
+
+	enabled := 0
+	for i := 0; i < 10000; i++ {
+		target := Target{UserID: uuid.NewString()}
+		on := flag.Evaluate(target)
+		assert.Equal(t, on, flag.Evaluate(target), "evaluation must be stable")
+		if on {
+			enabled++
+		}
+	}
+	assert.InDelta(t, 3000, enabled, 300)
+}
+
+func TestIsEnabledReadsContext(t *testing.T) {
+	userID := uuid.New()
+	flags := New(Config{Provider: StaticProvider{
+		{Key: "beta", Enabled: true, Users: []string{userID.String()}},
+	}})
+	require.NoError(t, flags.Load(context.Background()))
+
+	ctx := appctx.WithUserID(context.Background(), userID)
+	assert.True(t, flags.IsEnabled(ctx, "beta"))
+	assert.False(t, flags.IsEnabled(context.Background(), "beta"))
+	assert.False(t, flags.IsEnabled(ctx, "unknown"))
+
+	ctx = WithTarget(context.Background(), Target{UserID: userID.String()})
+	assert.True(t, flags.IsEnabled(ctx, "beta"))
+}
+
+func TestEvaluatorIsCached(t *testing.T) {
+	calls := 0
+	flags := New(Config{Evaluator: EvaluatorFunc(func(ctx context.Context, key string, target Target) (bool, error) {
+		calls++
+		return target.TenantID == "t1", nil
+	})})
+
+	ctx := WithTarget(context.Background(), Target{TenantID: "t1"})
+	assert.True(t, flags.IsEnabled(ctx, "remote"))
+	assert.True(t, flags.IsEnabled(ctx, "remote"))
+	assert.Equal(t, 1, calls)
+
+	assert.False(t, flags.IsEnabled(WithTarget(context.Background(), Target{TenantID: "t2"}), "remote"))
+	assert.Equal(t, 2, calls)
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "flags.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`
+- key: new-checkout
+  enabled: true
+  percentage: 25
+- key: beta
+  enabled: true
+  tenants: [t1, t2]
+`), 0o600))
+	jsonPath := filepath.Join(dir, "flags.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`[{"key":"dark-mode","enabled":false}]`), 0o600))
+
+	flags, err := NewFileProvider(yamlPath).Flags(context.Background())
+	require.NoError(t, err)
+	require.Len(t, flags, 2)
+	assert.Equal(t, 25, *flags[0].Percentage)
+	assert.Equal(t, []string{"t1", "t2"}, flags[1].Tenants)
+
+	flags, err = NewFileProvider(jsonPath).Flags(context.Background())
+	require.NoError(t, err)
+	require.Len(t, flags, 1)
+	assert.False(t, flags[0].Enabled)
+
+	_, err = NewFileProvider(filepath.Join(dir, "flags.txt")).Flags(context.Background())
+	assert.Error(t, err)
+}
+
+func TestUnleashProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/client/features", r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		_, _ = io.WriteString(w, `{"version":1,"features":[
+			{"name":"on","enabled":true,"strategies":[{"name":"default"}]},
+			{"name":"users","enabled":true,"strategies":[{"name":"userWithId","parameters":{"userIds":"u1, u2"}}]},
+			{"name":"rollout","enabled":true,"strategies":[{"name":"flexibleRollout","parameters":{"rollout":"40","stickiness":"default"}}]},
+			{"name":"custom","enabled":true,"strategies":[{"name":"remoteAddress","parameters":{"IPs":"10.0.0.1"}}]}
+		]}`)
+	}))
+	defer server.Close()
+
+	provider := NewUnleashProvider(UnleashConfig{URL: server.URL + "/api", Token: "secret", AppName: "orders"})
+	flags, err := provider.Flags(context.Background())
+	require.NoError(t, err)
+	require.Len(t, flags, 4)
+
+	byKey := map[string]Flag{}
+	for _, f := range flags {
+		byKey[f.Key] = f
+	}
+	assert.True(t, byKey["on"].Evaluate(Target{}))
+	assert.Equal(t, []string{"u1", "u2"}, byKey["users"].Users)
+	assert.Equal(t, 40, *byKey["rollout"].Percentage)
+	assert.False(t, byKey["custom"].Enabled)
+}
+
+func TestMiddleware(t *testing.T) {
+	flags := New(Config{Provider: StaticProvider{
+		{Key: "beta", Enabled: true, Tenants: []string{"t1"}},
+		{Key: "on", Enabled: true},
+	}})
+	require.NoError(t, flags.Load(context.Background()))
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("tenantId", c.Get("X-Tenant-ID"))
+		return c.Next()
+	})
+	app.Use(Middleware(flags, MiddlewareConfig{Header: "X-Feature-Flags"}))
+	app.Get("/flags", Handler())
+	app.Get("/checkout", func(c *fiber.Ctx) error {
+		if flags.IsEnabled(c.UserContext(), "beta") && Enabled(c, "beta") {
+			return c.SendString("beta")
+		}
+		return c.SendString("stable")
+	})
+
+	req := httptest.NewRequest("GET", "/checkout", nil)
+	req.Header.Set("X-Tenant-ID", "t1")
+	res, err := app.Test(req)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(res.Body)
+	assert.Equal(t, "beta", string(body))
+	assert.Equal(t, "beta,on", res.Header.Get("X-Feature-Flags"))
+
+	req = httptest.NewRequest("GET", "/flags", nil)
+	req.Header.Set("X-Tenant-ID", "t2")
+	res, err = app.Test(req)
+	require.NoError(t, err)
+	body, _ = io.ReadAll(res.Body)
+	assert.JSONEq(t, `{"flags":{"beta":false,"on":true}}`, string(body))
+}
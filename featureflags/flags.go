@@ -0,0 +1,340 @@
+package featureflags
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	appctx "github.com/minisource/go-common/context"
+	"github.com/minisource/go-common/logging"
+)
+
+// Flag defines a feature flag and who it is enabled for. A disabled flag is
+// off for everyone. Otherwise it is on for listed users and tenants, for
+// Percentage of the remaining ones, and for everyone when it has no
+// targeting at all
+type Flag struct {
+	Key         string `json:"key" yaml:"key" gorm:"size:255;primaryKey"`
+	Description string `json:"description,omitempty" yaml:"description" gorm:"type:text"`
+	Enabled     bool   `json:"enabled" yaml:"enabled" gorm:"not null;default:false"`
+	// Users and Tenants the flag is enabled for
+	Users   []string `json:"users,omitempty" yaml:"users" gorm:"serializer:json"`
+	Tenants []string `json:"tenants,omitempty" yaml:"tenants" gorm:"serializer:json"`
+	// Percentage (0-100) rolls the flag out by a stable hash of the user
+	// ID, or of the tenant ID for requests without a user
+	Percentage *int      `json:"percentage,omitempty" yaml:"percentage"`
+	UpdatedAt  time.Time `json:"updated_at" yaml:"-"`
+}
+
+// TableName overrides the table name
+func (Flag) TableName() string {
+	return "feature_flags"
+}
+
+// Evaluate reports whether the flag is enabled for target
+func (f Flag) Evaluate(target Target) bool {
+	if !f.Enabled {
+		return false
+	}
+	if target.UserID != "" && contains(f.Users, target.UserID) {
+		return true
+	}
+	if target.TenantID != "" && contains(f.Tenants, target.TenantID) {
+		return true
+	}
+	if f.Percentage != nil {
+		id := target.UserID
+		if id == "" {
+			id = target.TenantID
+		}
+		if id == "" {
+			return *f.Percentage >= 100
+		}
+		return bucket(f.Key, id) < *f.Percentage
+	}
+	return len(f.Users) == 0 && len(f.Tenants) == 0
+}
+
+// bucket maps id to 0-99, independently per flag
+func bucket(key, id string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key + ":" + id))
+	return int(h.Sum32() % 100)
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================
+// Target
+// ============================================
+
+// Target is who a flag is evaluated for
+type Target struct {
+	UserID   string
+	TenantID string
+}
+
+type targetKey struct{}
+
+// WithTarget sets the target of flag evaluations with ctx
+func WithTarget(ctx context.Context, target Target) context.Context {
+	return context.WithValue(ctx, targetKey{}, target)
+}
+
+// TargetFromContext returns the target set with WithTarget, or the user and
+// tenant IDs of the request context
+func TargetFromContext(ctx context.Context) Target {
+	if target, ok := ctx.Value(targetKey{}).(Target); ok {
+		return target
+	}
+
+	var target Target
+	if id, ok := appctx.GetUserID(ctx); ok {
+		target.UserID = id.String()
+	}
+	if id, ok := appctx.GetTenantID(ctx); ok {
+		target.TenantID = id.String()
+	}
+	return target
+}
+
+// ============================================
+// Backends
+// ============================================
+
+// Provider supplies flag definitions that are evaluated locally, e.g. from
+// a file, a table or Unleash
+type Provider interface {
+	Flags(ctx context.Context) ([]Flag, error)
+}
+
+// Evaluator decides flags remotely, e.g. with the LaunchDarkly SDK
+type Evaluator interface {
+	IsEnabled(ctx context.Context, key string, target Target) (bool, error)
+}
+
+// EvaluatorFunc adapts a function to Evaluator
+type EvaluatorFunc func(ctx context.Context, key string, target Target) (bool, error)
+
+// IsEnabled implements Evaluator
+func (f EvaluatorFunc) IsEnabled(ctx context.Context, key string, target Target) (bool, error) {
+	return f(ctx, key, target)
+}
+
+// ============================================
+// Flags
+// ============================================
+
+// Config configures Flags
+type Config struct {
+	// Provider supplies the flag definitions
+	Provider Provider
+	// Evaluator, if set, decides flags instead of the definitions; its
+	// results are cached per flag and target for CacheTTL
+	Evaluator Evaluator
+	// RefreshInterval between reloads of the definitions when started with
+	// Start (default: 30s)
+	RefreshInterval time.Duration
+	// CacheTTL of Evaluator results (default: 30s)
+	CacheTTL time.Duration
+	Logger   logging.Logger
+}
+
+// Flags evaluates feature flags against cached definitions
+type Flags struct {
+	cfg Config
+
+	mu    sync.RWMutex
+	flags map[string]*Flag
+
+	cache sync.Map
+
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	started atomic.Bool
+}
+
+type cachedResult struct {
+	enabled   bool
+	expiresAt time.Time
+}
+
+// New creates a flag set; call Load to read the definitions
+func New(cfg Config) *Flags {
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = 30 * time.Second
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = 30 * time.Second
+	}
+	return &Flags{
+		cfg:   cfg,
+		flags: make(map[string]*Flag),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// Load replaces the cached definitions with those of the provider
+func (f *Flags) Load(ctx context.Context) error {
+	if f.cfg.Provider == nil {
+		return nil
+	}
+	defs, err := f.cfg.Provider.Flags(ctx)
+	if err != nil {
+		return err
+	}
+
+	flags := make(map[string]*Flag, len(defs))
+	for i := range defs {
+		flags[defs[i].Key] = &defs[i]
+	}
+	f.mu.Lock()
+	f.flags = flags
+	f.mu.Unlock()
+	return nil
+}
+
+// Start reloads the definitions and clears evaluation results on the
+// configured interval until Stop is called
+func (f *Flags) Start() {
+	if !f.started.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer close(f.done)
+		ticker := time.NewTicker(f.cfg.RefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-f.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), f.cfg.RefreshInterval)
+				if err := f.Load(ctx); err != nil {
+					f.log(logging.Reload, "Feature flag reload failed", err)
+				}
+				cancel()
+				f.purge()
+			}
+		}
+	}()
+}
+
+// Stop stops reloading. It matches shutdown.Hook.
+func (f *Flags) Stop(ctx context.Context) error {
+	f.once.Do(func() { close(f.stop) })
+	if !f.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-f.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsEnabled reports whether the flag key is enabled for the target of ctx.
+// Unknown flags and evaluation errors count as disabled
+func (f *Flags) IsEnabled(ctx context.Context, key string) bool {
+	enabled, err := f.Evaluate(ctx, key, TargetFromContext(ctx))
+	if err != nil {
+		f.log(logging.ExternalService, "Feature flag evaluation failed", err)
+		return false
+	}
+	return enabled
+}
+
+// Evaluate reports whether the flag key is enabled for target
+func (f *Flags) Evaluate(ctx context.Context, key string, target Target) (bool, error) {
+	if f.cfg.Evaluator != nil {
+		return f.evaluateRemote(ctx, key, target)
+	}
+
+	f.mu.RLock()
+	flag := f.flags[key]
+	f.mu.RUnlock()
+	if flag == nil {
+		return false, nil
+	}
+	return flag.Evaluate(target), nil
+}
+
+// All evaluates the given flags, or all defined ones, for the target of ctx
+func (f *Flags) All(ctx context.Context, keys ...string) map[string]bool {
+	if len(keys) == 0 {
+		keys = f.Keys()
+	}
+	result := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		result[key] = f.IsEnabled(ctx, key)
+	}
+	return result
+}
+
+// Keys returns the keys of the defined flags
+func (f *Flags) Keys() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	keys := make([]string, 0, len(f.flags))
+	for key := range f.flags {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (f *Flags) evaluateRemote(ctx context.Context, key string, target Target) (bool, error) {
+	cacheKey := key + "\x00" + target.UserID + "\x00" + target.TenantID
+	if v, ok := f.cache.Load(cacheKey); ok {
+		if cached := v.(cachedResult); time.Now().Before(cached.expiresAt) {
+			return cached.enabled, nil
+		}
+	}
+
+	enabled, err := f.cfg.Evaluator.IsEnabled(ctx, key, target)
+	if err != nil {
+		return false, err
+	}
+	f.cache.Store(cacheKey, cachedResult{enabled: enabled, expiresAt: time.Now().Add(f.cfg.CacheTTL)})
+	return enabled, nil
+}
+
+// purge drops expired evaluation results
+func (f *Flags) purge() {
+	now := time.Now()
+	f.cache.Range(func(k, v interface{}) bool {
+		if !now.Before(v.(cachedResult).expiresAt) {
+			f.cache.Delete(k)
+		}
+		return true
+	})
+}
+
+func (f *Flags) log(sub logging.SubCategory, msg string, err error) {
+	if f.cfg.Logger == nil {
+		return
+	}
+	f.cfg.Logger.Error(logging.General, sub, msg, map[logging.ExtraKey]interface{}{
+		logging.ErrorMessage: err.Error(),
+	})
+}
+
+// Percent returns a pointer to p for Flag.Percentage
+func Percent(p int) *int {
+	return &p
+}
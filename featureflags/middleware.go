@@ -0,0 +1,73 @@
+package featureflags
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LocalsKey is the Fiber locals key of the evaluated flags
+const LocalsKey = "featureFlags"
+
+// MiddlewareConfig configures the Fiber middleware
+type MiddlewareConfig struct {
+	// Keys are the flags evaluated per request (default: all defined)
+	Keys []string
+	// Header, if set, lists the enabled flags in this response header,
+	// e.g. X-Feature-Flags
+	Header string
+}
+
+// Middleware evaluates flags for the userId and tenantId locals set by the
+// auth and tenant middlewares and stores them under LocalsKey. It also
+// sets the target on the user context, so flags.IsEnabled(ctx, key) works
+// in handlers and services
+func Middleware(flags *Flags, config MiddlewareConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		target := TargetFromContext(ctx)
+		if userID, ok := c.Locals("userId").(string); ok && userID != "" {
+			target.UserID = userID
+		}
+		if tenantID, ok := c.Locals("tenantId").(string); ok && tenantID != "" {
+			target.TenantID = tenantID
+		}
+		ctx = WithTarget(ctx, target)
+		c.SetUserContext(ctx)
+
+		evaluated := flags.All(ctx, config.Keys...)
+		c.Locals(LocalsKey, evaluated)
+
+		if config.Header != "" {
+			enabled := make([]string, 0, len(evaluated))
+			for key, on := range evaluated {
+				if on {
+					enabled = append(enabled, key)
+				}
+			}
+			sort.Strings(enabled)
+			c.Set(config.Header, strings.Join(enabled, ","))
+		}
+
+		return c.Next()
+	}
+}
+
+// Handler responds with the flags evaluated by Middleware, e.g. for
+// frontends: {"flags": {"new-checkout": true}}
+func Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		evaluated, _ := c.Locals(LocalsKey).(map[string]bool)
+		if evaluated == nil {
+			evaluated = map[string]bool{}
+		}
+		return c.JSON(fiber.Map{"flags": evaluated})
+	}
+}
+
+// Enabled reports whether Middleware evaluated key as enabled
+func Enabled(c *fiber.Ctx, key string) bool {
+	evaluated, _ := c.Locals(LocalsKey).(map[string]bool)
+	return evaluated[key]
+}
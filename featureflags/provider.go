@@ -0,0 +1,54 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StaticProvider serves fixed definitions, e.g. defaults or tests
+type StaticProvider []Flag
+
+// Flags implements Provider
+func (p StaticProvider) Flags(ctx context.Context) ([]Flag, error) {
+	flags := make([]Flag, len(p))
+	copy(flags, p)
+	return flags, nil
+}
+
+// FileProvider reads definitions from a .json, .yml or .yaml file holding a
+// list of flags, re-reading it on every load so edits apply on refresh
+type FileProvider struct {
+	path string
+}
+
+// NewFileProvider creates a provider for the file at path
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+// Flags implements Provider
+func (p *FileProvider) Flags(ctx context.Context) ([]Flag, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var flags []Flag
+	switch filepath.Ext(p.path) {
+	case ".json":
+		err = json.Unmarshal(data, &flags)
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(data, &flags)
+	default:
+		return nil, fmt.Errorf("feature flags: unsupported file type %s", p.path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("feature flags: %s: %w", p.path, err)
+	}
+	return flags, nil
+}
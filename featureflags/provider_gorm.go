@@ -0,0 +1,42 @@
+package featureflags
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormProvider reads definitions from the feature_flags table
+type GormProvider struct {
+	db *gorm.DB
+}
+
+// NewGormProvider creates a database backed provider
+func NewGormProvider(db *gorm.DB) *GormProvider {
+	return &GormProvider{db: db}
+}
+
+// AutoMigrate creates or updates the feature_flags table
+func (p *GormProvider) AutoMigrate() error {
+	return p.db.AutoMigrate(&Flag{})
+}
+
+// Flags implements Provider
+func (p *GormProvider) Flags(ctx context.Context) ([]Flag, error) {
+	var flags []Flag
+	if err := p.db.WithContext(ctx).Find(&flags).Error; err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// Save creates or replaces a flag definition
+func (p *GormProvider) Save(ctx context.Context, flag *Flag) error {
+	return p.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(flag).Error
+}
+
+// Delete removes a flag definition
+func (p *GormProvider) Delete(ctx context.Context, key string) error {
+	return p.db.WithContext(ctx).Delete(&Flag{Key: key}).Error
+}
@@ -0,0 +1,132 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnleashConfig configures the Unleash provider
+type UnleashConfig struct {
+	// URL of the Unleash API, e.g. https://unleash.example.com/api
+	URL string
+	// Token is a client API token
+	Token   string
+	AppName string
+	// HTTPTimeout is the timeout for fetching flags (default: 5s)
+	HTTPTimeout time.Duration
+}
+
+// UnleashProvider reads flags from the Unleash client API. Strategies map
+// to definitions: "default" enables everyone, "userWithId" lists users,
+// a custom "tenantWithId" strategy with a tenantIds parameter lists
+// tenants, and "flexibleRollout" or "gradualRolloutUserId" set the
+// percentage. Other strategies are ignored. Rollouts are bucketed locally,
+// so they pick the same share of users as Unleash, but not the same users
+type UnleashProvider struct {
+	cfg    UnleashConfig
+	client *http.Client
+}
+
+// NewUnleashProvider creates an Unleash provider
+func NewUnleashProvider(cfg UnleashConfig) *UnleashProvider {
+	if cfg.HTTPTimeout <= 0 {
+		cfg.HTTPTimeout = 5 * time.Second
+	}
+	return &UnleashProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.HTTPTimeout},
+	}
+}
+
+type unleashFeatures struct {
+	Features []struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Enabled     bool   `json:"enabled"`
+		Strategies  []struct {
+			Name       string            `json:"name"`
+			Parameters map[string]string `json:"parameters"`
+		} `json:"strategies"`
+	} `json:"features"`
+}
+
+// Flags implements Provider
+func (p *UnleashProvider) Flags(ctx context.Context) ([]Flag, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.cfg.URL, "/")+"/client/features", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", p.cfg.Token)
+	req.Header.Set("UNLEASH-APPNAME", p.cfg.AppName)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Unleash features: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch Unleash features: status %d", resp.StatusCode)
+	}
+
+	var body unleashFeatures
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse Unleash features: %w", err)
+	}
+
+	flags := make([]Flag, 0, len(body.Features))
+	for _, feature := range body.Features {
+		flag := Flag{
+			Key:         feature.Name,
+			Description: feature.Description,
+			Enabled:     feature.Enabled,
+		}
+
+		everyone := len(feature.Strategies) == 0
+		percentage := -1
+		for _, s := range feature.Strategies {
+			switch s.Name {
+			case "default":
+				everyone = true
+			case "userWithId":
+				flag.Users = append(flag.Users, splitList(s.Parameters["userIds"])...)
+			case "tenantWithId":
+				flag.Tenants = append(flag.Tenants, splitList(s.Parameters["tenantIds"])...)
+			case "flexibleRollout", "gradualRolloutUserId":
+				param := s.Parameters["rollout"]
+				if param == "" {
+					param = s.Parameters["percentage"]
+				}
+				if n, err := strconv.Atoi(param); err == nil && n > percentage {
+					percentage = n
+				}
+			}
+		}
+
+		switch {
+		case everyone:
+			flag.Users, flag.Tenants = nil, nil
+		case percentage >= 0:
+			flag.Percentage = Percent(min(percentage, 100))
+		case len(flag.Users) == 0 && len(flag.Tenants) == 0:
+			// Only unsupported strategies: keep the flag off
+			flag.Enabled = false
+		}
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/minisource/go-common/common"
 	"github.com/minisource/go-common/logging"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -203,26 +204,10 @@ func addServiceInfoToContext(ctx context.Context, validation *TokenValidationRes
 	return ctx
 }
 
-// HasScope checks if the given scopes contain the required scope
+// HasScope checks if the given scopes contain the required scope, matching
+// wildcards as in common.MatchPermission
 func HasScope(scopes []string, required string) bool {
-	for _, scope := range scopes {
-		// Check for wildcard scope
-		if scope == "*" {
-			return true
-		}
-		// Check exact match
-		if scope == required {
-			return true
-		}
-		// Check resource wildcard (e.g., "notifications:*" matches "notifications:send")
-		parts := strings.Split(required, ":")
-		if len(parts) == 2 {
-			if scope == parts[0]+":*" {
-				return true
-			}
-		}
-	}
-	return false
+	return common.HasPermission(scopes, required)
 }
 
 // wrappedServerStream wraps a grpc.ServerStream with a custom context
@@ -0,0 +1,27 @@
+package grpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		scopes   []string
+		required string
+		want     bool
+	}{
+		{[]string{"notifications:send"}, "notifications:send", true},
+		{[]string{"notifications:read"}, "notifications:send", false},
+		{[]string{"notifications:*"}, "notifications:send", true},
+		{[]string{"notifications:*"}, "notifications:templates:delete", false},
+		{[]string{"notifications:*"}, "sms:send", false},
+		{[]string{"*:send"}, "notifications:send", false},
+		{[]string{"*"}, "notifications:templates:delete", true},
+		{nil, "notifications:send", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, HasScope(tt.scopes, tt.required), "%v covers %s", tt.scopes, tt.required)
+	}
+}
@@ -0,0 +1,36 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/minisource/go-common/limiter"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryConcurrencyInterceptor sheds calls with ResourceExhausted when the
+// gate (a limiter.ConcurrencyLimiter or limiter.AdaptiveLimiter) is saturated
+func UnaryConcurrencyInterceptor(gate limiter.Gate) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		release, err := gate.Acquire(ctx)
+		if err != nil {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+}
+
+// StreamConcurrencyInterceptor is UnaryConcurrencyInterceptor for streams;
+// a slot is held for the lifetime of the stream
+func StreamConcurrencyInterceptor(gate limiter.Gate) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		release, err := gate.Acquire(ss.Context())
+		if err != nil {
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
+		defer release()
+		return handler(srv, ss)
+	}
+}
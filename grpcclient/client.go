@@ -2,12 +2,16 @@ package grpcclient
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"time"
 
+	"github.com/minisource/go-common/breaker"
 	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/metrics"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
@@ -30,6 +34,30 @@ type Config struct {
 	Logger             logging.Logger
 	Interceptors       []grpc.UnaryClientInterceptor
 	StreamInterceptors []grpc.StreamClientInterceptor
+	// TLS verifies the server certificate; MTLS also presents a client
+	// certificate and takes precedence. Without either the connection is
+	// plaintext
+	TLS  *TLSConfig
+	MTLS *MTLSConfig
+	// CircuitBreaker, if set, rejects calls without contacting the service
+	// after repeated failures, so retries don't pile onto a service that
+	// is down. Unavailable, DeadlineExceeded, Internal, Unknown and
+	// DataLoss count as failures
+	CircuitBreaker *breaker.Config
+	// BreakerKey picks the breaker of a call (default: one breaker per
+	// full method name)
+	BreakerKey func(method string) string
+	// TokenProvider, if set, authenticates every unary and stream call
+	// with a bearer token, e.g. a ClientCredentialsProvider
+	TokenProvider TokenProvider
+	// Tracing records an OpenTelemetry client span for every call attempt
+	// and propagates the trace context to the server
+	Tracing bool
+	// Metrics, if set, receives the grpc_client_* collectors for unary
+	// calls: calls, duration, retries and circuit breaker state. Use
+	// metrics.DefaultRegistry() or metrics.NewRegistry with your own
+	// prometheus.Registerer
+	Metrics *metrics.Registry
 }
 
 // RetryConfig holds retry configuration
@@ -63,25 +91,9 @@ func NewClient(ctx context.Context, cfg Config) (*Client, error) {
 		cfg.RetryConfig = DefaultRetryConfig()
 	}
 
-	// Add logging interceptor first
-	interceptors := []grpc.UnaryClientInterceptor{
-		createLoggingInterceptor(cfg.Logger, cfg.ServiceName),
-	}
-	interceptors = append(interceptors, cfg.Interceptors...)
-
-	// Add retry interceptor last
-	interceptors = append(interceptors, createRetryInterceptor(cfg.Logger, cfg.ServiceName, cfg.RetryConfig))
-
-	// Add logging stream interceptor
-	streamInterceptors := []grpc.StreamClientInterceptor{
-		createStreamLoggingInterceptor(cfg.Logger, cfg.ServiceName),
-	}
-	streamInterceptors = append(streamInterceptors, cfg.StreamInterceptors...)
-
-	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithChainUnaryInterceptor(interceptors...),
-		grpc.WithChainStreamInterceptor(streamInterceptors...),
+	opts, err := dialOptions(cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	conn, err := grpc.DialContext(ctx, cfg.Target, opts...)
@@ -103,6 +115,88 @@ func NewClient(ctx context.Context, cfg Config) (*Client, error) {
 	}, nil
 }
 
+// dialOptions builds the credentials and interceptor chains of cfg
+func dialOptions(cfg Config) ([]grpc.DialOption, error) {
+	// Add logging interceptor first
+	interceptors := []grpc.UnaryClientInterceptor{
+		createLoggingInterceptor(cfg.Logger, cfg.ServiceName),
+	}
+	interceptors = append(interceptors, cfg.Interceptors...)
+
+	// Add retry interceptor last, checking the circuit breaker per attempt
+	var breakers *breaker.Group
+	if cfg.CircuitBreaker != nil {
+		breakerConfig := *cfg.CircuitBreaker
+		if breakerConfig.Logger == nil {
+			breakerConfig.Logger = cfg.Logger
+		}
+		breakers = breaker.NewGroup(cfg.ServiceName, breakerConfig)
+	}
+	var clientMetrics *metrics.GRPCClientMetrics
+	if cfg.Metrics != nil {
+		clientMetrics = metrics.NewGRPCClientMetrics(cfg.Metrics)
+	}
+	interceptors = append(interceptors, createRetryInterceptor(cfg.Logger, cfg.ServiceName, cfg.RetryConfig, breakers, cfg.BreakerKey, clientMetrics))
+
+	// Add tracing and token auth after retry so every attempt gets its own
+	// span and a current token
+	if cfg.Tracing {
+		interceptors = append(interceptors, TracingInterceptor(cfg.ServiceName))
+	}
+	if cfg.TokenProvider != nil {
+		interceptors = append(interceptors, TokenAuthInterceptor(cfg.TokenProvider))
+	}
+
+	// Add logging stream interceptor
+	streamInterceptors := []grpc.StreamClientInterceptor{
+		createStreamLoggingInterceptor(cfg.Logger, cfg.ServiceName),
+	}
+	streamInterceptors = append(streamInterceptors, cfg.StreamInterceptors...)
+	if cfg.Tracing {
+		streamInterceptors = append(streamInterceptors, TracingStreamInterceptor(cfg.ServiceName))
+	}
+	if cfg.TokenProvider != nil {
+		streamInterceptors = append(streamInterceptors, TokenAuthStreamInterceptor(cfg.TokenProvider))
+	}
+
+	creds, err := transportCredentials(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure TLS for %s: %w", cfg.Target, err)
+	}
+
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(interceptors...),
+		grpc.WithChainStreamInterceptor(streamInterceptors...),
+	}, nil
+}
+
+// transportCredentials returns TLS credentials when configured, and
+// insecure ones otherwise
+func transportCredentials(cfg Config) (credentials.TransportCredentials, error) {
+	var tlsConfig *tls.Config
+	var err error
+	switch {
+	case cfg.MTLS != nil:
+		tlsConfig, err = cfg.MTLS.build()
+	case cfg.TLS != nil:
+		tlsConfig, err = cfg.TLS.build()
+	default:
+		return insecure.NewCredentials(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig.InsecureSkipVerify {
+		cfg.Logger.Warn(logging.General, logging.ExternalService, "gRPC server certificate verification is disabled", map[logging.ExtraKey]interface{}{
+			"service": cfg.ServiceName,
+			"target":  cfg.Target,
+		})
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
 // Conn returns the underlying gRPC connection
 func (c *Client) Conn() *grpc.ClientConn {
 	return c.conn
@@ -174,9 +268,29 @@ func createStreamLoggingInterceptor(logger logging.Logger, serviceName string) g
 }
 
 // createRetryInterceptor creates a unary interceptor for retry logic
-func createRetryInterceptor(logger logging.Logger, serviceName string, cfg RetryConfig) grpc.UnaryClientInterceptor {
+func createRetryInterceptor(logger logging.Logger, serviceName string, cfg RetryConfig, breakers *breaker.Group, breakerKey func(method string) string, m *metrics.GRPCClientMetrics) grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 		var lastErr error
+		startTime := time.Now()
+		observe := func(code string, retries int) {
+			if m != nil {
+				m.Observe(serviceName, method, code, retries, time.Since(startTime))
+			}
+		}
+
+		var cb *breaker.Breaker
+		recordState := func() {}
+		if breakers != nil {
+			key := method
+			if breakerKey != nil {
+				key = breakerKey(method)
+			}
+			cb = breakers.Get(key)
+			if m != nil {
+				state := m.BreakerState.WithLabelValues(serviceName, key)
+				recordState = func() { state.Set(float64(cb.State())) }
+			}
+		}
 
 		for attempt := 0; attempt <= cfg.MaxRetries; attempt++ {
 			if attempt > 0 {
@@ -190,12 +304,31 @@ func createRetryInterceptor(logger logging.Logger, serviceName string, cfg Retry
 
 				select {
 				case <-ctx.Done():
+					observe(status.FromContextError(ctx.Err()).Code().String(), attempt-1)
 					return ctx.Err()
 				case <-time.After(delay):
 				}
 			}
 
+			done := func(bool) {}
+			if cb != nil {
+				var err error
+				done, err = cb.Allow()
+				recordState()
+				if err != nil {
+					logger.Warn(logging.General, logging.ExternalService, "gRPC request rejected by circuit breaker", map[logging.ExtraKey]interface{}{
+						"service": serviceName,
+						"method":  method,
+						"attempt": attempt + 1,
+					})
+					observe("rejected", attempt)
+					return NewServiceUnavailableError(serviceName, err)
+				}
+			}
+
 			err := invoker(ctx, method, req, reply, cc, opts...)
+			done(!isBreakerFailure(err))
+			recordState()
 			if err == nil {
 				if attempt > 0 {
 					logger.Info(logging.General, logging.ExternalService, "gRPC request succeeded after retry", map[logging.ExtraKey]interface{}{
@@ -204,6 +337,7 @@ func createRetryInterceptor(logger logging.Logger, serviceName string, cfg Retry
 						"attempt": attempt + 1,
 					})
 				}
+				observe(codes.OK.String(), attempt)
 				return nil
 			}
 
@@ -216,6 +350,7 @@ func createRetryInterceptor(logger logging.Logger, serviceName string, cfg Retry
 					"method":  method,
 					"code":    st.Code().String(),
 				})
+				observe(st.Code().String(), attempt)
 				return err
 			}
 
@@ -233,11 +368,25 @@ func createRetryInterceptor(logger logging.Logger, serviceName string, cfg Retry
 			"method":   method,
 			"attempts": cfg.MaxRetries + 1,
 		})
+		observe(status.Code(lastErr).String(), cfg.MaxRetries)
 
 		return NewServiceUnavailableError(serviceName, lastErr)
 	}
 }
 
+// isBreakerFailure reports whether an error shows the service unhealthy;
+// client errors and caller cancellations don't count against it
+func isBreakerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
 func calculateBackoff(cfg RetryConfig, attempt int) time.Duration {
 	delay := float64(cfg.InitialDelay) * pow(cfg.BackoffFactor, float64(attempt-1))
 	if delay > float64(cfg.MaxDelay) {
@@ -0,0 +1,107 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minisource/go-common/breaker"
+	"github.com/minisource/go-common/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const checkMethod = "/grpc.health.v1.Health/Check"
+
+// failFirst answers the first failures calls with Unavailable
+func failFirst(failures int64, calls *atomic.Int64) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if calls.Add(1) <= failures {
+			return nil, status.Error(codes.Unavailable, "overloaded")
+		}
+		return handler(ctx, req)
+	}
+}
+
+func newMeteredClient(t *testing.T, failures int64, cb *breaker.Config) (healthpb.HealthClient, *metrics.GRPCClientMetrics, *atomic.Int64) {
+	var calls atomic.Int64
+	target, _ := startServer(t, grpc.UnaryInterceptor(failFirst(failures, &calls)))
+	reg := metrics.NewRegistry(metrics.RegistryConfig{})
+	client, err := NewClient(context.Background(), Config{
+		Target:      target,
+		ServiceName: "health",
+		Logger:      nopLogger{},
+		RetryConfig: RetryConfig{
+			MaxRetries:     2,
+			InitialDelay:   time.Millisecond,
+			MaxDelay:       time.Millisecond,
+			BackoffFactor:  1,
+			RetryableCodes: []codes.Code{codes.Unavailable},
+		},
+		CircuitBreaker: cb,
+		BreakerKey:     func(string) string { return "health" },
+		Metrics:        reg,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return healthpb.NewHealthClient(client.Conn()), metrics.NewGRPCClientMetrics(reg), &calls
+}
+
+func TestClientMetricsRecordRetries(t *testing.T) {
+	client, m, calls := newMeteredClient(t, 1, nil)
+	ctx := context.Background()
+
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err), "not retried")
+	assert.Equal(t, int64(3), calls.Load())
+
+	err = testutil.CollectAndCompare(m.Requests, strings.NewReader(`
+# HELP grpc_client_requests_total Total number of gRPC client calls
+# TYPE grpc_client_requests_total counter
+grpc_client_requests_total{code="NotFound",method="/grpc.health.v1.Health/Check",service="health"} 1
+grpc_client_requests_total{code="OK",method="/grpc.health.v1.Health/Check",service="health"} 1
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Retries.WithLabelValues("health", checkMethod)))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.Duration))
+}
+
+func TestClientMetricsRecordFailure(t *testing.T) {
+	client, m, calls := newMeteredClient(t, 100, nil)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	var unavailable *ServiceUnavailableError
+	require.True(t, errors.As(err, &unavailable))
+	assert.Equal(t, int64(3), calls.Load())
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Requests.WithLabelValues("health", checkMethod, "Unavailable")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.Retries.WithLabelValues("health", checkMethod)))
+}
+
+func TestClientMetricsRecordBreakerState(t *testing.T) {
+	cb := breaker.DefaultConfig()
+	cb.FailureThreshold = 3
+	cb.OpenTimeout = time.Minute
+	client, m, calls := newMeteredClient(t, 100, &cb)
+	state := m.BreakerState.WithLabelValues("health", "health")
+	ctx := context.Background()
+
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.Error(t, err)
+	assert.Equal(t, float64(breaker.StateOpen), testutil.ToFloat64(state), "three failed attempts open the breaker")
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.Error(t, err)
+	assert.Equal(t, int64(3), calls.Load(), "an open breaker rejects without calling the service")
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Requests.WithLabelValues("health", checkMethod, "rejected")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Requests.WithLabelValues("health", checkMethod, "Unavailable")))
+}
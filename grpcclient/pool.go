@@ -0,0 +1,322 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minisource/go-common/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	_ "google.golang.org/grpc/health" // client side health checking
+)
+
+// ErrPoolClosed is returned by Acquire after Close
+var ErrPoolClosed = errors.New("grpcclient: pool is closed")
+
+// PoolConfig configures a connection pool; the embedded Config applies to
+// every connection, which share one breaker group and token provider
+type PoolConfig struct {
+	Config
+	// Size is the number of connections to the target (default 4)
+	Size int
+	// CheckInterval is how often connection states are checked
+	// (default 10s)
+	CheckInterval time.Duration
+	// RecycleAfter is how long a connection may stay in transient failure
+	// before it is closed and dialed again (default 30s). Connections in
+	// use are closed once their calls finish, or after another RecycleAfter
+	RecycleAfter time.Duration
+	// HealthCheck enables client side health checking with the server's
+	// grpc.health.v1 service: a server that reports NOT_SERVING puts its
+	// connection in transient failure, so it is skipped and recycled
+	HealthCheck bool
+	// HealthService is the service name sent with health checks
+	// (default: "", the whole server)
+	HealthService string
+}
+
+// pooledConn is a pool connection with its number of calls in flight
+type pooledConn struct {
+	conn      *grpc.ClientConn
+	inFlight  atomic.Int64
+	badSince  time.Time
+	retiredAt time.Time
+}
+
+// Pool spreads calls to one target over several connections, so a busy
+// service isn't limited by the concurrent streams of a single HTTP/2
+// connection and, behind a layer 4 load balancer, reaches several backends.
+// It implements grpc.ClientConnInterface, so generated clients can use it
+// directly
+type Pool struct {
+	cfg  PoolConfig
+	opts []grpc.DialOption
+
+	mu      sync.RWMutex
+	conns   []*pooledConn
+	byConn  map[*grpc.ClientConn]*pooledConn
+	retired []*pooledConn
+	next    atomic.Uint32
+	closed  bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+var _ grpc.ClientConnInterface = (*Pool)(nil)
+
+// NewPool dials Size connections to the target
+func NewPool(ctx context.Context, cfg PoolConfig) (*Pool, error) {
+	if cfg.RetryConfig.MaxRetries == 0 {
+		cfg.RetryConfig = DefaultRetryConfig()
+	}
+	if cfg.Size <= 0 {
+		cfg.Size = 4
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = 10 * time.Second
+	}
+	if cfg.RecycleAfter <= 0 {
+		cfg.RecycleAfter = 30 * time.Second
+	}
+
+	opts, err := dialOptions(cfg.Config)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.HealthCheck {
+		opts = append(opts, grpc.WithDefaultServiceConfig(fmt.Sprintf(
+			`{"loadBalancingConfig":[{"round_robin":{}}],"healthCheckConfig":{"serviceName":%q}}`,
+			cfg.HealthService,
+		)))
+	}
+
+	p := &Pool{
+		cfg:    cfg,
+		opts:   opts,
+		byConn: make(map[*grpc.ClientConn]*pooledConn),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	for i := 0; i < cfg.Size; i++ {
+		pc, err := p.dial(ctx)
+		if err != nil {
+			for _, c := range p.conns {
+				c.conn.Close()
+			}
+			return nil, err
+		}
+		p.conns = append(p.conns, pc)
+		p.byConn[pc.conn] = pc
+	}
+
+	cfg.Logger.Info(logging.General, logging.ExternalService, "gRPC connection pool established", map[logging.ExtraKey]interface{}{
+		"service": cfg.ServiceName,
+		"target":  cfg.Target,
+		"size":    cfg.Size,
+	})
+
+	go p.check()
+	return p, nil
+}
+
+func (p *Pool) dial(ctx context.Context) (*pooledConn, error) {
+	conn, err := grpc.DialContext(ctx, p.cfg.Target, p.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", p.cfg.Target, err)
+	}
+	return &pooledConn{conn: conn}, nil
+}
+
+// Acquire returns the ready connection with the fewest calls in flight,
+// or the best connection available when none is ready. Call Release when
+// the call is done
+func (p *Pool) Acquire() (*grpc.ClientConn, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+
+	var best *pooledConn
+	bestRank := 0
+	start := int(p.next.Add(1))
+	for i := range p.conns {
+		pc := p.conns[(start+i)%len(p.conns)]
+		rank := stateRank(pc.conn.GetState())
+		if best == nil || rank < bestRank || rank == bestRank && pc.inFlight.Load() < best.inFlight.Load() {
+			best, bestRank = pc, rank
+		}
+	}
+	best.inFlight.Add(1)
+	return best.conn, nil
+}
+
+// Release returns a connection obtained from Acquire
+func (p *Pool) Release(conn *grpc.ClientConn) {
+	p.mu.RLock()
+	pc, ok := p.byConn[conn]
+	p.mu.RUnlock()
+	if ok {
+		pc.inFlight.Add(-1)
+	}
+}
+
+// stateRank orders connection states by preference
+func stateRank(state connectivity.State) int {
+	switch state {
+	case connectivity.Ready:
+		return 0
+	case connectivity.Idle, connectivity.Connecting:
+		return 1
+	case connectivity.TransientFailure:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Invoke implements grpc.ClientConnInterface on an acquired connection
+func (p *Pool) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	conn, err := p.Acquire()
+	if err != nil {
+		return err
+	}
+	defer p.Release(conn)
+	return conn.Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream implements grpc.ClientConnInterface on an acquired connection,
+// which is released when the stream ends
+func (p *Pool) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	conn, err := p.Acquire()
+	if err != nil {
+		return nil, err
+	}
+	stream, err := conn.NewStream(ctx, desc, method, opts...)
+	if err != nil {
+		p.Release(conn)
+		return nil, err
+	}
+	go func() {
+		<-stream.Context().Done()
+		p.Release(conn)
+	}()
+	return stream, nil
+}
+
+// Close closes every connection of the pool
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	close(p.stop)
+	<-p.done
+
+	p.cfg.Logger.Info(logging.General, logging.ExternalService, "Closing gRPC connection pool", map[logging.ExtraKey]interface{}{
+		"service": p.cfg.ServiceName,
+	})
+	var errs []error
+	for _, pc := range append(p.conns, p.retired...) {
+		if err := pc.conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// check wakes idle connections and recycles broken ones until Close
+func (p *Pool) check() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		p.mu.RLock()
+		conns := append([]*pooledConn(nil), p.conns...)
+		p.mu.RUnlock()
+
+		now := time.Now()
+		for i, pc := range conns {
+			switch pc.conn.GetState() {
+			case connectivity.Idle:
+				pc.conn.Connect()
+				pc.badSince = time.Time{}
+			case connectivity.TransientFailure, connectivity.Shutdown:
+				if pc.badSince.IsZero() {
+					pc.badSince = now
+				}
+				if now.Sub(pc.badSince) >= p.cfg.RecycleAfter {
+					p.recycle(i, pc)
+				}
+			default:
+				pc.badSince = time.Time{}
+			}
+		}
+		p.closeRetired(now)
+	}
+}
+
+// recycle replaces the connection at index i with a new one
+func (p *Pool) recycle(i int, old *pooledConn) {
+	state := old.conn.GetState()
+	pc, err := p.dial(context.Background())
+	if err != nil {
+		p.cfg.Logger.Error(logging.General, logging.ExternalService, "Failed to recycle gRPC connection", map[logging.ExtraKey]interface{}{
+			"service": p.cfg.ServiceName,
+			"target":  p.cfg.Target,
+			"error":   err.Error(),
+		})
+		return
+	}
+	pc.conn.Connect()
+
+	p.mu.Lock()
+	p.conns[i] = pc
+	p.byConn[pc.conn] = pc
+	old.retiredAt = time.Now()
+	p.retired = append(p.retired, old)
+	p.mu.Unlock()
+
+	p.cfg.Logger.Warn(logging.General, logging.ExternalService, "Recycled gRPC connection", map[logging.ExtraKey]interface{}{
+		"service": p.cfg.ServiceName,
+		"target":  p.cfg.Target,
+		"state":   state.String(),
+	})
+}
+
+// closeRetired closes replaced connections once their calls have finished
+func (p *Pool) closeRetired(now time.Time) {
+	p.mu.Lock()
+	var closing []*pooledConn
+	kept := p.retired[:0]
+	for _, pc := range p.retired {
+		if pc.inFlight.Load() <= 0 || now.Sub(pc.retiredAt) >= p.cfg.RecycleAfter {
+			closing = append(closing, pc)
+			delete(p.byConn, pc.conn)
+		} else {
+			kept = append(kept, pc)
+		}
+	}
+	p.retired = kept
+	p.mu.Unlock()
+
+	for _, pc := range closing {
+		pc.conn.Close()
+	}
+}
@@ -0,0 +1,199 @@
+package grpcclient
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/minisource/go-common/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// nopLogger discards everything
+type nopLogger struct{}
+
+func (nopLogger) Init() {}
+func (nopLogger) Debug(logging.Category, logging.SubCategory, string, map[logging.ExtraKey]interface{}) {
+}
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Info(logging.Category, logging.SubCategory, string, map[logging.ExtraKey]interface{}) {
+}
+func (nopLogger) Infof(string, ...interface{}) {}
+func (nopLogger) Warn(logging.Category, logging.SubCategory, string, map[logging.ExtraKey]interface{}) {
+}
+func (nopLogger) Warnf(string, ...interface{}) {}
+func (nopLogger) Error(logging.Category, logging.SubCategory, string, map[logging.ExtraKey]interface{}) {
+}
+func (nopLogger) Errorf(string, ...interface{}) {}
+func (nopLogger) Fatal(logging.Category, logging.SubCategory, string, map[logging.ExtraKey]interface{}) {
+}
+func (nopLogger) Fatalf(string, ...interface{}) {}
+
+// startServer serves the health service and any extra services on a
+// loopback port, since Config has no dialer to plug a bufconn listener into
+func startServer(t *testing.T, opts ...grpc.ServerOption) (string, *health.Server) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer(opts...)
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String(), healthServer
+}
+
+func newTestPool(t *testing.T, cfg PoolConfig) *Pool {
+	cfg.ServiceName = "health"
+	cfg.Logger = nopLogger{}
+	p, err := NewPool(context.Background(), cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func (p *Pool) inFlight(conn *grpc.ClientConn) int64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.byConn[conn].inFlight.Load()
+}
+
+func TestPoolAcquireSpreadsCalls(t *testing.T) {
+	target, _ := startServer(t)
+	p := newTestPool(t, PoolConfig{Config: Config{Target: target}, Size: 3})
+
+	seen := make(map[*grpc.ClientConn]bool)
+	for i := 0; i < 3; i++ {
+		conn, err := p.Acquire()
+		require.NoError(t, err)
+		seen[conn] = true
+	}
+	assert.Len(t, seen, 3, "each acquire takes the connection with the fewest calls")
+	for conn := range seen {
+		assert.Equal(t, int64(1), p.inFlight(conn))
+		p.Release(conn)
+		assert.Equal(t, int64(0), p.inFlight(conn))
+	}
+	p.Release(&grpc.ClientConn{}) // unknown connections are ignored
+}
+
+func TestPoolAcquirePrefersReadyConnections(t *testing.T) {
+	target, _ := startServer(t)
+	p := newTestPool(t, PoolConfig{Config: Config{Target: target}, Size: 2})
+	ready, broken := p.conns[0].conn, p.conns[1].conn
+	assert.Eventually(t, func() bool {
+		return ready.GetState() == connectivity.Ready
+	}, 5*time.Second, 5*time.Millisecond)
+	broken.Close()
+
+	// Busier but ready beats idle or broken
+	for i := 0; i < 3; i++ {
+		conn, err := p.Acquire()
+		require.NoError(t, err)
+		assert.True(t, conn == ready, "acquired a %s connection", conn.GetState())
+	}
+	assert.Equal(t, int64(3), p.inFlight(ready))
+	assert.Zero(t, p.inFlight(broken))
+}
+
+func TestPoolInvoke(t *testing.T) {
+	target, healthServer := startServer(t)
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_NOT_SERVING)
+	p := newTestPool(t, PoolConfig{Config: Config{Target: target}, Size: 2})
+
+	resp, err := healthpb.NewHealthClient(p).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "orders"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, pc := range p.conns {
+		assert.Zero(t, pc.inFlight.Load(), "calls release their connection")
+	}
+}
+
+func TestPoolRecycleClosesRetiredWhenIdle(t *testing.T) {
+	target, _ := startServer(t)
+	p := newTestPool(t, PoolConfig{Config: Config{Target: target}, Size: 1, RecycleAfter: time.Minute})
+
+	old, err := p.Acquire()
+	require.NoError(t, err)
+	p.recycle(0, p.conns[0])
+
+	fresh, err := p.Acquire()
+	require.NoError(t, err)
+	assert.False(t, old == fresh, "the recycled connection is replaced")
+	p.Release(fresh)
+
+	// The retired connection stays open for its call in flight
+	p.closeRetired(time.Now())
+	assert.NotEqual(t, connectivity.Shutdown, old.GetState())
+
+	p.Release(old)
+	p.closeRetired(time.Now())
+	assert.Equal(t, connectivity.Shutdown, old.GetState())
+	assert.Empty(t, p.retired)
+	p.Release(old) // released after close: no longer tracked
+}
+
+func TestPoolRecycleClosesRetiredAfterTimeout(t *testing.T) {
+	target, _ := startServer(t)
+	p := newTestPool(t, PoolConfig{Config: Config{Target: target}, Size: 1, RecycleAfter: time.Minute})
+
+	old, err := p.Acquire()
+	require.NoError(t, err)
+	p.recycle(0, p.conns[0])
+
+	p.closeRetired(time.Now().Add(time.Minute))
+	assert.Equal(t, connectivity.Shutdown, old.GetState(), "stuck calls don't keep a retired connection open")
+}
+
+func TestPoolCheckRecyclesBrokenConnections(t *testing.T) {
+	// Nothing listens on the target, so connections fail
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	target := lis.Addr().String()
+	lis.Close()
+
+	p := newTestPool(t, PoolConfig{
+		Config:        Config{Target: target},
+		Size:          1,
+		CheckInterval: 5 * time.Millisecond,
+		RecycleAfter:  20 * time.Millisecond,
+	})
+	p.mu.RLock()
+	first := p.conns[0].conn
+	p.mu.RUnlock()
+
+	assert.Eventually(t, func() bool {
+		return first.GetState() == connectivity.Shutdown
+	}, 5*time.Second, 5*time.Millisecond)
+	conn, err := p.Acquire()
+	require.NoError(t, err)
+	assert.False(t, first == conn, "the broken connection is replaced")
+}
+
+func TestPoolClose(t *testing.T) {
+	target, _ := startServer(t)
+	p := newTestPool(t, PoolConfig{Config: Config{Target: target}, Size: 2})
+	conn, err := p.Acquire()
+	require.NoError(t, err)
+	p.recycle(0, p.conns[0])
+
+	require.NoError(t, p.Close())
+	assert.NoError(t, p.Close(), "closing twice is a no-op")
+	assert.Equal(t, connectivity.Shutdown, conn.GetState(), "retired connections are closed too")
+	for _, pc := range p.conns {
+		assert.Equal(t, connectivity.Shutdown, pc.conn.GetState())
+	}
+
+	_, err = p.Acquire()
+	assert.ErrorIs(t, err, ErrPoolClosed)
+	_, err = healthpb.NewHealthClient(p).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.ErrorIs(t, err, ErrPoolClosed)
+}
@@ -0,0 +1,128 @@
+package grpcclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSConfig enables TLS with verification of the server certificate
+type TLSConfig struct {
+	// CAFile is a PEM bundle of the CAs trusted for the server certificate
+	// (default: system roots)
+	CAFile string
+	// CAPool is used instead of CAFile, e.g. when CAs come from a secret store
+	CAPool *x509.CertPool
+	// ServerName overrides the name verified against the server
+	// certificate, e.g. when dialing an IP or through a proxy
+	ServerName string
+	// InsecureSkipVerify accepts any server certificate. For development
+	// only: it allows man-in-the-middle attacks
+	InsecureSkipVerify bool
+	// MinVersion is the minimum TLS version (default: TLS 1.2)
+	MinVersion uint16
+}
+
+// MTLSConfig enables mutual TLS: TLS plus a client certificate
+type MTLSConfig struct {
+	TLSConfig
+	// CertFile and KeyFile are the PEM client certificate and key. They
+	// are re-read when they change, so rotated certificates apply to new
+	// connections without a restart
+	CertFile string
+	KeyFile  string
+	// Certificate is used instead of CertFile and KeyFile
+	Certificate *tls.Certificate
+}
+
+// build creates the crypto/tls configuration
+func (c *TLSConfig) build() (*tls.Config, error) {
+	cfg := &tls.Config{
+		RootCAs:            c.CAPool,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		MinVersion:         c.MinVersion,
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	if cfg.RootCAs == nil && c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", c.CAFile)
+		}
+	}
+	return cfg, nil
+}
+
+// build creates the crypto/tls configuration with the client certificate
+func (c *MTLSConfig) build() (*tls.Config, error) {
+	cfg, err := c.TLSConfig.build()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c.Certificate != nil:
+		cfg.Certificates = []tls.Certificate{*c.Certificate}
+	case c.CertFile != "" && c.KeyFile != "":
+		reloader := &certReloader{certFile: c.CertFile, keyFile: c.KeyFile}
+		if _, err := reloader.load(); err != nil {
+			return nil, err
+		}
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return reloader.load()
+		}
+	default:
+		return nil, errors.New("mTLS requires a client certificate")
+	}
+	return cfg, nil
+}
+
+// certReloader serves a key pair from disk, reloading it when the files'
+// modification times change
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	certTime time.Time
+	keyTime  time.Time
+}
+
+func (r *certReloader) load() (*tls.Certificate, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client key: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cert != nil && certInfo.ModTime().Equal(r.certTime) && keyInfo.ModTime().Equal(r.keyTime) {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		// Keep serving the previous pair while a rotation is half written
+		if r.cert != nil {
+			return r.cert, nil
+		}
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	r.cert, r.certTime, r.keyTime = &cert, certInfo.ModTime(), keyInfo.ModTime()
+	return r.cert, nil
+}
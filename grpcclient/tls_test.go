@@ -0,0 +1,199 @@
+package grpcclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+// testCA issues certificates for TLS tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM certificate and key for commonName
+func (ca *testCA) issue(t *testing.T, commonName string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeKeyPair writes a key pair and moves its modification time forward,
+// since a rotation within the file system's timestamp resolution would
+// otherwise go unnoticed
+func writeKeyPair(t *testing.T, certFile, keyFile string, certPEM, keyPEM []byte, modTime time.Time) {
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+func leafCommonName(t *testing.T, cert *tls.Certificate) string {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloaderRotation(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	now := time.Now()
+
+	certPEM, keyPEM := ca.issue(t, "orders-v1", x509.ExtKeyUsageClientAuth)
+	writeKeyPair(t, certFile, keyFile, certPEM, keyPEM, now)
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	first, err := r.load()
+	require.NoError(t, err)
+	assert.Equal(t, "orders-v1", leafCommonName(t, first))
+
+	again, err := r.load()
+	require.NoError(t, err)
+	assert.Same(t, first, again, "unchanged files are not re-read")
+
+	certPEM, keyPEM = ca.issue(t, "orders-v2", x509.ExtKeyUsageClientAuth)
+	writeKeyPair(t, certFile, keyFile, certPEM, keyPEM, now.Add(time.Minute))
+	rotated, err := r.load()
+	require.NoError(t, err)
+	assert.Equal(t, "orders-v2", leafCommonName(t, rotated))
+
+	// Half written: the new certificate doesn't match the old key yet
+	newCert, _ := ca.issue(t, "orders-v3", x509.ExtKeyUsageClientAuth)
+	writeKeyPair(t, certFile, keyFile, newCert, keyPEM, now.Add(2*time.Minute))
+	current, err := r.load()
+	require.NoError(t, err)
+	assert.Same(t, rotated, current, "the previous pair is served during a rotation")
+
+	fresh := &certReloader{certFile: certFile, keyFile: keyFile}
+	_, err = fresh.load()
+	assert.ErrorContains(t, err, "failed to load client certificate")
+
+	_, err = (&certReloader{certFile: filepath.Join(dir, "missing.crt"), keyFile: keyFile}).load()
+	assert.ErrorContains(t, err, "failed to read client certificate")
+}
+
+func TestMTLSConfigBuild(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0o600))
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	certPEM, keyPEM := ca.issue(t, "orders", x509.ExtKeyUsageClientAuth)
+	writeKeyPair(t, certFile, keyFile, certPEM, keyPEM, time.Now())
+
+	cfg, err := (&MTLSConfig{TLSConfig: TLSConfig{CAFile: caFile}, CertFile: certFile, KeyFile: keyFile}).build()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+	assert.NotNil(t, cfg.RootCAs)
+	cert, err := cfg.GetClientCertificate(&tls.CertificateRequestInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, "orders", leafCommonName(t, cert))
+
+	_, err = (&MTLSConfig{}).build()
+	assert.ErrorContains(t, err, "mTLS requires a client certificate")
+
+	notPEM := filepath.Join(dir, "ca.txt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+	_, err = (&TLSConfig{CAFile: notPEM}).build()
+	assert.ErrorContains(t, err, "no certificates found")
+}
+
+func TestClientMTLSHandshake(t *testing.T) {
+	ca := newTestCA(t)
+	serverCert, serverKey := ca.issue(t, "localhost", x509.ExtKeyUsageServerAuth)
+	serverPair, err := tls.X509KeyPair(serverCert, serverKey)
+	require.NoError(t, err)
+
+	var clientName string
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverPair},
+		ClientCAs:    ca.pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	target, _ := startServer(t, grpc.Creds(creds), grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		p, _ := peer.FromContext(ctx)
+		clientName = p.AuthInfo.(credentials.TLSInfo).State.PeerCertificates[0].Subject.CommonName
+		return handler(ctx, req)
+	}))
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	certPEM, keyPEM := ca.issue(t, "orders", x509.ExtKeyUsageClientAuth)
+	writeKeyPair(t, certFile, keyFile, certPEM, keyPEM, time.Now())
+
+	client, err := NewClient(context.Background(), Config{
+		Target:      target,
+		ServiceName: "health",
+		Logger:      nopLogger{},
+		MTLS: &MTLSConfig{
+			TLSConfig: TLSConfig{CAPool: ca.pool, ServerName: "localhost"},
+			CertFile:  certFile,
+			KeyFile:   keyFile,
+		},
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = healthpb.NewHealthClient(client.Conn()).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "orders", clientName)
+}
@@ -0,0 +1,246 @@
+package grpcclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TokenProvider returns the bearer token sent with calls. Implementations
+// cache the token and refresh it before it expires
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenProviderFunc adapts a function to TokenProvider
+type TokenProviderFunc func(ctx context.Context) (string, error)
+
+// Token implements TokenProvider
+func (f TokenProviderFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// TokenInvalidator is implemented by providers that can drop a token the
+// server rejected, so the next Token call fetches a new one
+type TokenInvalidator interface {
+	Invalidate(token string)
+}
+
+// ============================================
+// Client credentials
+// ============================================
+
+// ClientCredentialsConfig configures the OAuth2 client credentials flow
+// against the auth service
+type ClientCredentialsConfig struct {
+	// TokenURL is the token endpoint of the auth service
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// RefreshBefore renews the token this long before it expires
+	// (default: 1 minute)
+	RefreshBefore time.Duration
+	// HTTPTimeout is the timeout for token requests (default: 10s)
+	HTTPTimeout time.Duration
+}
+
+// ClientCredentialsProvider fetches service tokens with the client
+// credentials grant and caches them until shortly before they expire
+type ClientCredentialsProvider struct {
+	cfg    ClientCredentialsConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewClientCredentialsProvider creates a client credentials token provider
+func NewClientCredentialsProvider(cfg ClientCredentialsConfig) *ClientCredentialsProvider {
+	if cfg.RefreshBefore <= 0 {
+		cfg.RefreshBefore = time.Minute
+	}
+	if cfg.HTTPTimeout <= 0 {
+		cfg.HTTPTimeout = 10 * time.Second
+	}
+	return &ClientCredentialsProvider{cfg: cfg, client: &http.Client{Timeout: cfg.HTTPTimeout}}
+}
+
+// Token implements TokenProvider. When a refresh fails the cached token is
+// returned as long as it hasn't expired
+func (p *ClientCredentialsProvider) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if p.token != "" && now.Before(p.expiresAt.Add(-p.cfg.RefreshBefore)) {
+		return p.token, nil
+	}
+
+	token, expiresIn, err := p.fetch(ctx)
+	if err != nil {
+		if p.token != "" && now.Before(p.expiresAt) {
+			return p.token, nil
+		}
+		return "", err
+	}
+	p.token = token
+	p.expiresAt = now.Add(expiresIn)
+	return p.token, nil
+}
+
+// Invalidate implements TokenInvalidator
+func (p *ClientCredentialsProvider) Invalidate(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token == token {
+		p.token = ""
+	}
+}
+
+// fetch requests a new token from the token endpoint
+func (p *ClientCredentialsProvider) fetch(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {p.cfg.ClientID},
+		"client_secret": {p.cfg.ClientSecret},
+	}
+	if len(p.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("token request failed: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", 0, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", 0, errors.New("token response has no access_token")
+	}
+	expiresIn := time.Duration(token.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		// Without expires_in, refresh hourly
+		expiresIn = time.Hour
+	}
+	return token.AccessToken, expiresIn, nil
+}
+
+// ============================================
+// Interceptors
+// ============================================
+
+// TokenAuthInterceptor adds a bearer token from provider to every unary
+// call. When the server answers Unauthenticated, the token is invalidated
+// and the call is retried once with a new one
+func TokenAuthInterceptor(provider TokenProvider) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		token, err := providerToken(ctx, provider)
+		if err != nil {
+			return err
+		}
+		err = invoker(withBearer(ctx, token), method, req, reply, cc, opts...)
+		if !invalidate(provider, token, err) {
+			return err
+		}
+
+		if token, err = providerToken(ctx, provider); err != nil {
+			return err
+		}
+		return invoker(withBearer(ctx, token), method, req, reply, cc, opts...)
+	}
+}
+
+// TokenAuthStreamInterceptor adds a bearer token from provider to every
+// stream. A stream can't be replayed, so when it fails with Unauthenticated
+// the token is only invalidated and the caller's next stream gets a new one
+func TokenAuthStreamInterceptor(provider TokenProvider) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		token, err := providerToken(ctx, provider)
+		if err != nil {
+			return nil, err
+		}
+		stream, err := streamer(withBearer(ctx, token), desc, cc, method, opts...)
+		if err != nil {
+			invalidate(provider, token, err)
+			return nil, err
+		}
+		return &tokenStream{ClientStream: stream, provider: provider, token: token}, nil
+	}
+}
+
+// tokenStream invalidates its token when the server rejects it, which
+// grpc reports on receive rather than when the stream is opened
+type tokenStream struct {
+	grpc.ClientStream
+	provider TokenProvider
+	token    string
+}
+
+func (s *tokenStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		invalidate(s.provider, s.token, err)
+	}
+	return err
+}
+
+// providerToken gets a token, reporting failures as Unauthenticated so they
+// are neither retried nor counted against the called service
+func providerToken(ctx context.Context, provider TokenProvider) (string, error) {
+	token, err := provider.Token(ctx)
+	if err != nil {
+		return "", status.Errorf(codes.Unauthenticated, "failed to obtain token: %v", err)
+	}
+	return token, nil
+}
+
+// invalidate drops a token the server rejected; it reports whether the
+// call should be retried with a new token
+func invalidate(provider TokenProvider, token string, err error) bool {
+	invalidator, ok := provider.(TokenInvalidator)
+	if !ok || status.Code(err) != codes.Unauthenticated {
+		return false
+	}
+	invalidator.Invalidate(token)
+	return true
+}
+
+func withBearer(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
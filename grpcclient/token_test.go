@@ -0,0 +1,228 @@
+package grpcclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	commontesting "github.com/minisource/go-common/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tokenServer issues token-1, token-2, ... from a client credentials
+// endpoint, or fails with status when it is set
+type tokenServer struct {
+	*httptest.Server
+	mu        sync.Mutex
+	issued    int
+	status    int
+	expiresIn int64
+	forms     []map[string]string
+}
+
+func newTokenServer(t *testing.T) *tokenServer {
+	s := &tokenServer{expiresIn: 3600}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.forms = append(s.forms, map[string]string{
+			"grant_type":    r.PostForm.Get("grant_type"),
+			"client_id":     r.PostForm.Get("client_id"),
+			"client_secret": r.PostForm.Get("client_secret"),
+			"scope":         r.PostForm.Get("scope"),
+		})
+		if s.status != 0 {
+			http.Error(w, "invalid_client", s.status)
+			return
+		}
+		s.issued++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", s.issued),
+			"expires_in":   s.expiresIn,
+		})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *tokenServer) fail(status int) {
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+}
+
+func (s *tokenServer) provider() *ClientCredentialsProvider {
+	return NewClientCredentialsProvider(ClientCredentialsConfig{
+		TokenURL:     s.URL,
+		ClientID:     "orders",
+		ClientSecret: "secret",
+		Scopes:       []string{"users:read", "users:write"},
+	})
+}
+
+func TestClientCredentialsProviderCachesToken(t *testing.T) {
+	server := newTokenServer(t)
+	p := server.provider()
+	ctx := context.Background()
+
+	token, err := p.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+	token, err = p.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	require.Len(t, server.forms, 1)
+	assert.Equal(t, map[string]string{
+		"grant_type":    "client_credentials",
+		"client_id":     "orders",
+		"client_secret": "secret",
+		"scope":         "users:read users:write",
+	}, server.forms[0])
+
+	p.Invalidate("token-0") // another token: ignored
+	token, _ = p.Token(ctx)
+	assert.Equal(t, "token-1", token)
+	p.Invalidate("token-1")
+	token, _ = p.Token(ctx)
+	assert.Equal(t, "token-2", token)
+}
+
+func TestClientCredentialsProviderRefresh(t *testing.T) {
+	server := newTokenServer(t)
+	server.expiresIn = 30 // within RefreshBefore, so every call refreshes
+	p := server.provider()
+	ctx := context.Background()
+
+	token, err := p.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+	token, err = p.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+
+	// A failed refresh keeps serving the unexpired token
+	server.fail(http.StatusServiceUnavailable)
+	token, err = p.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+
+	p.mu.Lock()
+	p.expiresAt = time.Now().Add(-time.Second)
+	p.mu.Unlock()
+	_, err = p.Token(ctx)
+	assert.ErrorContains(t, err, "HTTP 503")
+}
+
+func TestClientCredentialsProviderErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClientCredentialsProvider(ClientCredentialsConfig{TokenURL: server.URL}).Token(context.Background())
+	assert.ErrorContains(t, err, "no access_token")
+}
+
+// tokenCheck accepts only the current token
+func tokenCheck(current *string, mu *sync.Mutex) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		mu.Lock()
+		want := "Bearer " + *current
+		mu.Unlock()
+		if got := md.Get("authorization"); len(got) != 1 || got[0] != want {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+func TestTokenAuthInterceptorRetriesWithNewToken(t *testing.T) {
+	server := newTokenServer(t)
+	provider := server.provider()
+	recorder := commontesting.NewMetadataRecorder()
+	var mu sync.Mutex
+	current := "token-1"
+
+	conn := commontesting.NewGRPCServerWithOptions(t, func(s *grpc.Server) {
+		healthpb.RegisterHealthServer(s, health.NewServer())
+	}, []grpc.ServerOption{grpc.ChainUnaryInterceptor(recorder.Interceptor(), tokenCheck(&current, &mu))},
+		grpc.WithChainUnaryInterceptor(TokenAuthInterceptor(provider)))
+	client := healthpb.NewHealthClient(conn)
+	ctx := context.Background()
+
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	commontesting.AssertMetadata(t, recorder.Last(), "authorization", "Bearer token-1")
+
+	// The server rotates its keys: the cached token is rejected once
+	mu.Lock()
+	current = "token-2"
+	mu.Unlock()
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	calls := recorder.For("/grpc.health.v1.Health/Check")
+	require.Len(t, calls, 3)
+	commontesting.AssertMetadata(t, calls[1], "authorization", "Bearer token-1")
+	commontesting.AssertMetadata(t, calls[2], "authorization", "Bearer token-2")
+
+	// Rejected again: no second retry
+	mu.Lock()
+	current = "token-9"
+	mu.Unlock()
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Len(t, recorder.For("/grpc.health.v1.Health/Check"), 5)
+}
+
+func TestTokenAuthInterceptorProviderFailure(t *testing.T) {
+	provider := TokenProviderFunc(func(ctx context.Context) (string, error) {
+		return "", errors.New("auth service down")
+	})
+	called := false
+	conn := commontesting.NewGRPCServerWithOptions(t, func(s *grpc.Server) {
+		healthpb.RegisterHealthServer(s, health.NewServer())
+	}, []grpc.ServerOption{grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		called = true
+		return handler(ctx, req)
+	})}, grpc.WithChainUnaryInterceptor(TokenAuthInterceptor(provider)))
+
+	_, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Contains(t, err.Error(), "auth service down")
+	assert.False(t, called, "calls without a token never reach the server")
+}
+
+func TestTokenAuthStreamInterceptorInvalidatesRejectedToken(t *testing.T) {
+	server := newTokenServer(t)
+	provider := server.provider()
+	conn := commontesting.NewGRPCServerWithOptions(t, func(s *grpc.Server) {
+		healthpb.RegisterHealthServer(s, health.NewServer())
+	}, []grpc.ServerOption{grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return status.Error(codes.Unauthenticated, "invalid token")
+	})}, grpc.WithChainStreamInterceptor(TokenAuthStreamInterceptor(provider)))
+
+	stream, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	token, err := provider.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token, "the next stream gets a new token")
+}
@@ -0,0 +1,151 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/minisource/go-common/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tracerName is the tracer of client spans
+const tracerName = "grpcclient"
+
+// TracingInterceptor records a client span for every unary call and sends
+// the trace context, with the tenant, user and request IDs as baggage, in
+// the call metadata. It uses the global tracer provider and propagator set
+// up by tracing.Init
+func TracingInterceptor(serviceName string) grpc.UnaryClientInterceptor {
+	tracer := otel.Tracer(tracerName)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := startSpan(ctx, tracer, serviceName, method, cc)
+		defer span.End()
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		endSpan(span, err)
+		return err
+	}
+}
+
+// TracingStreamInterceptor is TracingInterceptor for streams; the span ends
+// when the stream does
+func TracingStreamInterceptor(serviceName string) grpc.StreamClientInterceptor {
+	tracer := otel.Tracer(tracerName)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, span := startSpan(ctx, tracer, serviceName, method, cc)
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			endSpan(span, err)
+			span.End()
+			return nil, err
+		}
+
+		ts := &tracedStream{ClientStream: stream, span: span, serverStreams: desc.ServerStreams}
+		go func() {
+			// Ends the span of a stream the caller stopped reading
+			<-stream.Context().Done()
+			ts.finish(stream.Context().Err())
+		}()
+		return ts, nil
+	}
+}
+
+// tracedStream ends its span on the first receive error, or after the
+// single response of a client streaming call
+type tracedStream struct {
+	grpc.ClientStream
+	span          trace.Span
+	serverStreams bool
+	once          sync.Once
+}
+
+func (s *tracedStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case errors.Is(err, io.EOF):
+		s.finish(nil)
+	case err != nil:
+		s.finish(err)
+	case !s.serverStreams:
+		s.finish(nil)
+	}
+	return err
+}
+
+func (s *tracedStream) finish(err error) {
+	s.once.Do(func() {
+		endSpan(s.span, err)
+		s.span.End()
+	})
+}
+
+// startSpan starts a client span named after the full method and injects
+// its context into the outgoing metadata
+func startSpan(ctx context.Context, tracer trace.Tracer, serviceName, method string, cc *grpc.ClientConn) (context.Context, trace.Span) {
+	name := strings.TrimPrefix(method, "/")
+	attrs := []attribute.KeyValue{semconv.RPCSystemGRPC, semconv.PeerService(serviceName)}
+	if service, rpc, ok := strings.Cut(name, "/"); ok {
+		attrs = append(attrs, semconv.RPCService(service), semconv.RPCMethod(rpc))
+	}
+	if cc != nil {
+		attrs = append(attrs, semconv.NetPeerName(cc.Target()))
+	}
+	ctx, span := tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	otel.GetTextMapPropagator().Inject(tracing.WithIdentityBaggage(ctx), metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md), span
+}
+
+// endSpan records the status code of a finished call; every code but OK
+// is an error for the caller
+func endSpan(span trace.Span, err error) {
+	st, ok := status.FromError(err)
+	if !ok {
+		st = status.FromContextError(err)
+	}
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(st.Code())))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, st.Message())
+		return
+	}
+	span.SetStatus(otelcodes.Ok, "")
+}
+
+// metadataCarrier adapts gRPC metadata for OpenTelemetry propagation
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
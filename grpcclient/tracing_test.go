@@ -0,0 +1,113 @@
+package grpcclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	appctx "github.com/minisource/go-common/context"
+	commontesting "github.com/minisource/go-common/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func newTestTracer(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return exporter
+}
+
+func newTracedHealthClient(t *testing.T, recorder *commontesting.MetadataRecorder) (healthpb.HealthClient, *health.Server) {
+	healthServer := health.NewServer()
+	conn := commontesting.NewGRPCServerWithOptions(t, func(s *grpc.Server) {
+		healthpb.RegisterHealthServer(s, healthServer)
+	}, []grpc.ServerOption{grpc.UnaryInterceptor(recorder.Interceptor())},
+		grpc.WithChainUnaryInterceptor(TracingInterceptor("health")),
+		grpc.WithChainStreamInterceptor(TracingStreamInterceptor("health")),
+	)
+	return healthpb.NewHealthClient(conn), healthServer
+}
+
+func TestTracingInterceptorRecordsSpan(t *testing.T) {
+	exporter := newTestTracer(t)
+	recorder := commontesting.NewMetadataRecorder()
+	client, _ := newTracedHealthClient(t, recorder)
+	tenantID := uuid.New()
+	ctx := appctx.WithTenantID(context.Background(), tenantID)
+
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "grpc.health.v1.Health/Check", span.Name)
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind)
+	assert.Equal(t, otelcodes.Ok, span.Status.Code)
+	assert.Contains(t, span.Attributes, attribute.String("rpc.service", "grpc.health.v1.Health"))
+	assert.Contains(t, span.Attributes, attribute.String("rpc.method", "Check"))
+	assert.Contains(t, span.Attributes, attribute.String("peer.service", "health"))
+	assert.Contains(t, span.Attributes, attribute.Int("rpc.grpc.status_code", int(codes.OK)))
+
+	md := recorder.Last()
+	require.Len(t, md.Get("traceparent"), 1)
+	assert.Contains(t, md.Get("traceparent")[0], span.SpanContext.TraceID().String(), "the server joins the client trace")
+	require.Len(t, md.Get("baggage"), 1)
+	assert.Contains(t, md.Get("baggage")[0], tenantID.String())
+}
+
+func TestTracingInterceptorRecordsError(t *testing.T) {
+	exporter := newTestTracer(t)
+	client, _ := newTracedHealthClient(t, commontesting.NewMetadataRecorder())
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, otelcodes.Error, spans[0].Status.Code)
+	assert.Equal(t, "unknown service", spans[0].Status.Description)
+	assert.Contains(t, spans[0].Attributes, attribute.Int("rpc.grpc.status_code", int(codes.NotFound)))
+	require.NotEmpty(t, spans[0].Events)
+	assert.Equal(t, "exception", spans[0].Events[0].Name)
+}
+
+func TestTracingStreamInterceptorEndsSpanWithStream(t *testing.T) {
+	exporter := newTestTracer(t)
+	client, healthServer := newTracedHealthClient(t, commontesting.NewMetadataRecorder())
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "orders"})
+	require.NoError(t, err)
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+	assert.Empty(t, exporter.GetSpans(), "a server stream is open until it ends")
+
+	cancel()
+	_, err = stream.Recv()
+	require.Equal(t, codes.Canceled, status.Code(err))
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "grpc.health.v1.Health/Watch", spans[0].Name)
+	assert.Contains(t, spans[0].Attributes, attribute.Int("rpc.grpc.status_code", int(codes.Canceled)))
+}
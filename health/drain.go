@@ -0,0 +1,67 @@
+package health
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// StatusDraining is reported by readiness while the instance is being drained
+const StatusDraining Status = "draining"
+
+// DefaultDrainKey is the cache key used for the shared drain flag
+const DefaultDrainKey = "health:draining"
+
+// draining is the process-wide drain flag
+var draining atomic.Bool
+
+// SetDraining marks the process as draining. While draining, readiness
+// reports 503 but liveness stays healthy, so the pod is taken out of
+// rotation without being restarted.
+func SetDraining(enabled bool) {
+	draining.Store(enabled)
+}
+
+// IsDraining reports whether the process-wide drain flag is set
+func IsDraining() bool {
+	return draining.Load()
+}
+
+// SetSharedDraining sets or clears the cache-backed drain flag, draining
+// every instance that shares the configured cache and key
+func (h *HealthService) SetSharedDraining(ctx context.Context, enabled bool) error {
+	if h.drainCache == nil {
+		SetDraining(enabled)
+		return nil
+	}
+	if !enabled {
+		return h.drainCache.Delete(ctx, h.drainKey)
+	}
+	return h.drainCache.Set(ctx, h.drainKey, []byte("1"), 0)
+}
+
+// isDraining checks the local flag and then the optional cache-backed flag.
+// Cache errors are ignored so an unavailable cache never drains the pod.
+func (h *HealthService) isDraining(ctx context.Context) bool {
+	if IsDraining() {
+		return true
+	}
+	if h.drainCache == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	exists, err := h.drainCache.Exists(ctx, h.drainKey)
+	return err == nil && exists
+}
+
+// drainingResult builds the readiness payload returned while draining
+func drainingResult() map[string]interface{} {
+	return map[string]interface{}{
+		"status":    StatusDraining,
+		"timestamp": time.Now(),
+		"checks":    []CheckResult{},
+	}
+}
@@ -46,10 +46,22 @@ func (h *FiberHandler) Readiness(c *fiber.Ctx) error {
 	return c.JSON(result)
 }
 
+// Details handles detailed status requests
+// @Summary Detailed health status
+// @Description Recent results of every health checker
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /health/details [get]
+func (h *FiberHandler) Details(c *fiber.Ctx) error {
+	return c.JSON(h.healthService.Details())
+}
+
 // RegisterRoutes registers health check routes
 func (h *FiberHandler) RegisterRoutes(app *fiber.App) {
 	app.Get("/health", h.Liveness)
 	app.Get("/ready", h.Readiness)
+	app.Get("/health/details", h.Details)
 	app.Get("/healthz", h.Liveness) // Kubernetes standard
 	app.Get("/readyz", h.Readiness) // Kubernetes standard
 }
@@ -3,8 +3,12 @@ package health
 import (
 	"context"
 	"fmt"
+	"runtime"
 	"sync"
 	"time"
+
+	"github.com/minisource/go-common/cache"
+	"github.com/minisource/go-common/metrics"
 )
 
 // Status represents the health status of a component
@@ -33,20 +37,69 @@ type Checker interface {
 
 // HealthService manages health checks
 type HealthService struct {
-	checkers []Checker
-	mu       sync.RWMutex
-	timeout  time.Duration
+	checkers       []registeredChecker
+	mu             sync.RWMutex
+	timeout        time.Duration
+	maxConcurrency int
+
+	statsMu     sync.Mutex
+	failures    map[string]int
+	history     map[string]*resultHistory
+	historySize int
+
+	listenersMu sync.Mutex
+	listeners   []func(old, new Status)
+	lastStatus  Status
+
+	drainCache cache.Cache
+	drainKey   string
+}
+
+// registeredChecker holds a checker together with its registration options
+type registeredChecker struct {
+	checker          Checker
+	timeout          time.Duration
+	failureThreshold int
+}
+
+// CheckerOption configures a registered checker
+type CheckerOption func(*registeredChecker)
+
+// WithCheckerTimeout sets a per-checker timeout (bounded by the service timeout)
+func WithCheckerTimeout(timeout time.Duration) CheckerOption {
+	return func(rc *registeredChecker) {
+		rc.timeout = timeout
+	}
+}
+
+// WithFailureThreshold marks a checker as critical only after it has failed
+// threshold times in a row. Failures below the threshold are reported as
+// degraded and do not flip readiness.
+func WithFailureThreshold(threshold int) CheckerOption {
+	return func(rc *registeredChecker) {
+		rc.failureThreshold = threshold
+	}
 }
 
 // Config for health service
 type Config struct {
 	Timeout time.Duration
+	// MaxConcurrency limits how many checkers run at once (0 = unlimited)
+	MaxConcurrency int
+	// DrainCache optionally backs the drain flag so it can be toggled for all
+	// instances at once (e.g. by setting the key in Redis)
+	DrainCache cache.Cache
+	// DrainKey is the cache key checked for the shared drain flag
+	DrainKey string
+	// HistorySize is the number of recent results kept per checker
+	HistorySize int
 }
 
 // DefaultConfig returns default health config
 func DefaultConfig() Config {
 	return Config{
-		Timeout: 5 * time.Second,
+		Timeout:     5 * time.Second,
+		HistorySize: 20,
 	}
 }
 
@@ -55,17 +108,60 @@ func NewHealthService(cfg Config) *HealthService {
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 5 * time.Second
 	}
+	if cfg.HistorySize <= 0 {
+		cfg.HistorySize = 20
+	}
+	if cfg.DrainKey == "" {
+		cfg.DrainKey = DefaultDrainKey
+	}
 	return &HealthService{
-		checkers: make([]Checker, 0),
-		timeout:  cfg.Timeout,
+		checkers:       make([]registeredChecker, 0),
+		timeout:        cfg.Timeout,
+		maxConcurrency: cfg.MaxConcurrency,
+		failures:       make(map[string]int),
+		history:        make(map[string]*resultHistory),
+		historySize:    cfg.HistorySize,
+		lastStatus:     StatusHealthy,
+		drainCache:     cfg.DrainCache,
+		drainKey:       cfg.DrainKey,
 	}
 }
 
 // RegisterChecker adds a checker to the health service
-func (h *HealthService) RegisterChecker(checker Checker) {
+func (h *HealthService) RegisterChecker(checker Checker, opts ...CheckerOption) {
+	rc := registeredChecker{checker: checker}
+	for _, opt := range opts {
+		opt(&rc)
+	}
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	h.checkers = append(h.checkers, checker)
+	h.checkers = append(h.checkers, rc)
+}
+
+// OnStatusChange registers a callback invoked whenever the overall readiness
+// status changes between two consecutive CheckReadiness calls
+func (h *HealthService) OnStatusChange(fn func(old, new Status)) {
+	h.listenersMu.Lock()
+	defer h.listenersMu.Unlock()
+	h.listeners = append(h.listeners, fn)
+}
+
+// notifyStatus records the latest overall status and notifies listeners on change
+func (h *HealthService) notifyStatus(status Status) {
+	h.listenersMu.Lock()
+	old := h.lastStatus
+	h.lastStatus = status
+	listeners := make([]func(old, new Status), len(h.listeners))
+	copy(listeners, h.listeners)
+	h.listenersMu.Unlock()
+
+	if old == status {
+		return
+	}
+	for _, fn := range listeners {
+		fn(old, status)
+	}
 }
 
 // CheckLiveness performs a basic liveness check
@@ -79,11 +175,17 @@ func (h *HealthService) CheckLiveness() map[string]interface{} {
 // CheckReadiness performs all registered health checks
 func (h *HealthService) CheckReadiness(ctx context.Context) (map[string]interface{}, bool) {
 	h.mu.RLock()
-	checkers := make([]Checker, len(h.checkers))
+	checkers := make([]registeredChecker, len(h.checkers))
 	copy(checkers, h.checkers)
 	h.mu.RUnlock()
 
+	if h.isDraining(ctx) {
+		h.notifyStatus(StatusDraining)
+		return drainingResult(), false
+	}
+
 	if len(checkers) == 0 {
+		h.notifyStatus(StatusHealthy)
 		return map[string]interface{}{
 			"status":    StatusHealthy,
 			"timestamp": time.Now(),
@@ -96,30 +198,40 @@ func (h *HealthService) CheckReadiness(ctx context.Context) (map[string]interfac
 
 	results := make([]CheckResult, len(checkers))
 	var wg sync.WaitGroup
-	allHealthy := true
+
+	var sem chan struct{}
+	if h.maxConcurrency > 0 {
+		sem = make(chan struct{}, h.maxConcurrency)
+	}
 
 	for i, checker := range checkers {
 		wg.Add(1)
-		go func(idx int, c Checker) {
+		go func(idx int, rc registeredChecker) {
 			defer wg.Done()
 
-			start := time.Now()
-			err := c.Check(ctx)
-			duration := time.Since(start)
-
-			result := CheckResult{
-				Name:      c.Name(),
-				Duration:  duration,
-				Timestamp: time.Now(),
+			var result CheckResult
+			if sem != nil {
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+					result = runChecker(ctx, rc)
+				case <-ctx.Done():
+					result = CheckResult{
+						Name:      rc.checker.Name(),
+						Status:    StatusUnhealthy,
+						Message:   "check not started: " + ctx.Err().Error(),
+						Timestamp: time.Now(),
+					}
+				}
+			} else {
+				result = runChecker(ctx, rc)
 			}
 
-			if err != nil {
-				result.Status = StatusUnhealthy
-				result.Message = err.Error()
-				allHealthy = false
-			} else {
-				result.Status = StatusHealthy
+			failures := h.countFailure(result)
+			if result.Status == StatusUnhealthy && failures < rc.failureThreshold {
+				result.Status = StatusDegraded
 			}
+			h.recordResult(result, failures)
 
 			results[idx] = result
 		}(i, checker)
@@ -127,10 +239,21 @@ func (h *HealthService) CheckReadiness(ctx context.Context) (map[string]interfac
 
 	wg.Wait()
 
+	// Aggregate only after all goroutines are done so no shared state is
+	// written concurrently
 	status := StatusHealthy
-	if !allHealthy {
-		status = StatusUnhealthy
+	for _, result := range results {
+		if result.Status == StatusUnhealthy {
+			status = StatusUnhealthy
+			break
+		}
+		if result.Status == StatusDegraded {
+			status = StatusDegraded
+		}
 	}
+	allHealthy := status != StatusUnhealthy
+
+	h.notifyStatus(status)
 
 	return map[string]interface{}{
 		"status":    status,
@@ -139,6 +262,99 @@ func (h *HealthService) CheckReadiness(ctx context.Context) (map[string]interfac
 	}, allHealthy
 }
 
+// countFailure updates and returns the number of consecutive failures of the
+// checker that produced result
+func (h *HealthService) countFailure(result CheckResult) int {
+	h.statsMu.Lock()
+	defer h.statsMu.Unlock()
+	if result.Status == StatusUnhealthy {
+		h.failures[result.Name]++
+	} else {
+		h.failures[result.Name] = 0
+	}
+	return h.failures[result.Name]
+}
+
+// recordResult stores the final result (after the failure threshold has been
+// applied) in the checker history and publishes it to Prometheus
+func (h *HealthService) recordResult(result CheckResult, failures int) {
+	h.statsMu.Lock()
+	hist, ok := h.history[result.Name]
+	if !ok {
+		hist = newResultHistory(h.historySize)
+		h.history[result.Name] = hist
+	}
+	hist.add(result)
+	h.statsMu.Unlock()
+
+	metrics.HealthCheckStatus.WithLabelValues(result.Name).Set(statusValue(result.Status))
+	metrics.HealthCheckDuration.WithLabelValues(result.Name).Observe(result.Duration.Seconds())
+	metrics.HealthCheckConsecutiveFailures.WithLabelValues(result.Name).Set(float64(failures))
+}
+
+// ConsecutiveFailures returns how many times in a row the named checker has failed
+func (h *HealthService) ConsecutiveFailures(name string) int {
+	h.statsMu.Lock()
+	defer h.statsMu.Unlock()
+	return h.failures[name]
+}
+
+// statusValue maps a status to its gauge value
+func statusValue(status Status) float64 {
+	switch status {
+	case StatusHealthy:
+		return 1
+	case StatusDegraded:
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// runChecker executes a single checker with its own timeout and panic isolation.
+// A checker that ignores context cancellation is abandoned once its timeout
+// elapses so it cannot hold up the rest of the probe.
+func runChecker(ctx context.Context, rc registeredChecker) CheckResult {
+	if rc.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rc.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errCh <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		errCh <- rc.checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out: %w", ctx.Err())
+	}
+
+	result := CheckResult{
+		Name:      rc.checker.Name(),
+		Status:    StatusHealthy,
+		Duration:  time.Since(start),
+		Timestamp: time.Now(),
+	}
+
+	if err != nil {
+		result.Status = StatusUnhealthy
+		result.Message = err.Error()
+	}
+
+	return result
+}
+
 // PostgresChecker checks PostgreSQL connectivity
 type PostgresChecker struct {
 	name   string
@@ -223,3 +439,49 @@ func (c *CustomChecker) Check(ctx context.Context) error {
 	}
 	return c.checkFunc(ctx)
 }
+
+// GoroutineChecker detects goroutine leaks. It fails when the goroutine
+// count exceeds a limit, or grows too far above the lowest count it has
+// observed; combine with WithFailureThreshold to ignore bursts
+type GoroutineChecker struct {
+	name      string
+	max       int
+	maxGrowth int
+
+	mu       sync.Mutex
+	baseline int
+}
+
+// NewGoroutineChecker creates a goroutine leak checker; a zero limit or
+// growth disables that bound
+func NewGoroutineChecker(name string, limit, growth int) *GoroutineChecker {
+	return &GoroutineChecker{
+		name:      name,
+		max:       limit,
+		maxGrowth: growth,
+	}
+}
+
+func (c *GoroutineChecker) Name() string {
+	return c.name
+}
+
+func (c *GoroutineChecker) Check(ctx context.Context) error {
+	return c.check(runtime.NumGoroutine())
+}
+
+func (c *GoroutineChecker) check(count int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.baseline == 0 || count < c.baseline {
+		c.baseline = count
+	}
+	if c.max > 0 && count > c.max {
+		return fmt.Errorf("%d goroutines exceed the limit of %d", count, c.max)
+	}
+	if c.maxGrowth > 0 && count-c.baseline > c.maxGrowth {
+		return fmt.Errorf("%d goroutines grew by %d over the baseline of %d", count, count-c.baseline, c.baseline)
+	}
+	return nil
+}
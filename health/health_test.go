@@ -0,0 +1,213 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minisource/go-common/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckReadinessAllHealthy(t *testing.T) {
+	hs := NewHealthService(DefaultConfig())
+	hs.RegisterChecker(NewCustomChecker("a", func(ctx context.Context) error { return nil }))
+	hs.RegisterChecker(NewCustomChecker("b", func(ctx context.Context) error { return nil }))
+
+	result, healthy := hs.CheckReadiness(context.Background())
+
+	assert.True(t, healthy)
+	assert.Equal(t, StatusHealthy, result["status"])
+}
+
+func TestCheckReadinessConcurrentFailures(t *testing.T) {
+	hs := NewHealthService(DefaultConfig())
+	for i := 0; i < 20; i++ {
+		hs.RegisterChecker(NewCustomChecker("failing", func(ctx context.Context) error {
+			return errors.New("down")
+		}))
+	}
+
+	result, healthy := hs.CheckReadiness(context.Background())
+
+	assert.False(t, healthy)
+	assert.Equal(t, StatusUnhealthy, result["status"])
+}
+
+func TestCheckReadinessRecoversPanics(t *testing.T) {
+	hs := NewHealthService(DefaultConfig())
+	hs.RegisterChecker(NewCustomChecker("panicky", func(ctx context.Context) error {
+		panic("boom")
+	}))
+
+	result, healthy := hs.CheckReadiness(context.Background())
+
+	assert.False(t, healthy)
+	checks := result["checks"].([]CheckResult)
+	assert.Contains(t, checks[0].Message, "panic: boom")
+}
+
+func TestCheckReadinessPerCheckerTimeout(t *testing.T) {
+	hs := NewHealthService(DefaultConfig())
+	hs.RegisterChecker(NewCustomChecker("hung", func(ctx context.Context) error {
+		select {}
+	}), WithCheckerTimeout(50*time.Millisecond))
+
+	start := time.Now()
+	_, healthy := hs.CheckReadiness(context.Background())
+
+	assert.False(t, healthy)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestCheckReadinessMaxConcurrency(t *testing.T) {
+	hs := NewHealthService(Config{Timeout: 5 * time.Second, MaxConcurrency: 2})
+
+	var running, peak int32
+	for i := 0; i < 6; i++ {
+		hs.RegisterChecker(NewCustomChecker("slow", func(ctx context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		}))
+	}
+
+	_, healthy := hs.CheckReadiness(context.Background())
+
+	assert.True(t, healthy)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&peak))
+}
+
+func TestCheckReadinessRecordsMetrics(t *testing.T) {
+	hs := NewHealthService(DefaultConfig())
+	hs.RegisterChecker(NewCustomChecker("metrics_up", func(ctx context.Context) error { return nil }))
+	hs.RegisterChecker(NewCustomChecker("metrics_down", func(ctx context.Context) error {
+		return errors.New("down")
+	}))
+
+	// The duration histogram is global, so count the samples this test adds
+	before := gatherHealthMetrics(t)["health_check_duration_seconds/metrics_up"]
+	hs.CheckReadiness(context.Background())
+	hs.CheckReadiness(context.Background())
+
+	values := gatherHealthMetrics(t)
+	assert.Equal(t, 1.0, values["health_check_status/metrics_up"])
+	assert.Equal(t, 0.0, values["health_check_status/metrics_down"])
+	assert.Equal(t, 0.0, values["health_check_consecutive_failures/metrics_up"])
+	assert.Equal(t, 2.0, values["health_check_consecutive_failures/metrics_down"])
+	assert.Equal(t, 2.0, values["health_check_duration_seconds/metrics_up"]-before)
+	assert.Equal(t, 2, hs.ConsecutiveFailures("metrics_down"))
+}
+
+func TestDegradedStatusMetric(t *testing.T) {
+	hs := NewHealthService(DefaultConfig())
+	hs.RegisterChecker(NewCustomChecker("metrics_flaky", func(ctx context.Context) error {
+		return errors.New("down")
+	}), WithFailureThreshold(2))
+
+	result, _ := hs.CheckReadiness(context.Background())
+	assert.Equal(t, StatusDegraded, result["status"])
+	assert.Equal(t, 0.5, gatherHealthMetrics(t)["health_check_status/metrics_flaky"])
+
+	result, _ = hs.CheckReadiness(context.Background())
+	assert.Equal(t, StatusUnhealthy, result["status"])
+	assert.Equal(t, 0.0, gatherHealthMetrics(t)["health_check_status/metrics_flaky"])
+}
+
+// gatherHealthMetrics returns the health metrics keyed by "name/checker";
+// histograms report their sample count
+func gatherHealthMetrics(t *testing.T) map[string]float64 {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(metrics.HealthCheckStatus, metrics.HealthCheckDuration, metrics.HealthCheckConsecutiveFailures)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	values := make(map[string]float64)
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() != "checker" {
+					continue
+				}
+				key := mf.GetName() + "/" + label.GetValue()
+				if h := m.GetHistogram(); h != nil {
+					values[key] = float64(h.GetSampleCount())
+				} else {
+					values[key] = m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return values
+}
+
+func TestFailureThresholdAndStatusChange(t *testing.T) {
+	hs := NewHealthService(DefaultConfig())
+	hs.RegisterChecker(NewCustomChecker("flaky", func(ctx context.Context) error {
+		return errors.New("down")
+	}), WithFailureThreshold(2))
+
+	var changes []Status
+	hs.OnStatusChange(func(old, new Status) {
+		changes = append(changes, new)
+	})
+
+	_, healthy := hs.CheckReadiness(context.Background())
+	assert.True(t, healthy)
+
+	_, healthy = hs.CheckReadiness(context.Background())
+	assert.False(t, healthy)
+
+	assert.Equal(t, []Status{StatusDegraded, StatusUnhealthy}, changes)
+	assert.Equal(t, 2, hs.ConsecutiveFailures("flaky"))
+}
+
+func TestDraining(t *testing.T) {
+	hs := NewHealthService(DefaultConfig())
+
+	SetDraining(true)
+	defer SetDraining(false)
+
+	result, ready := hs.CheckReadiness(context.Background())
+	assert.False(t, ready)
+	assert.Equal(t, StatusDraining, result["status"])
+	assert.Equal(t, StatusHealthy, hs.CheckLiveness()["status"])
+}
+
+func TestHistoryRingBuffer(t *testing.T) {
+	hs := NewHealthService(Config{HistorySize: 3})
+	hs.RegisterChecker(NewCustomChecker("a", func(ctx context.Context) error { return nil }))
+
+	for i := 0; i < 5; i++ {
+		hs.CheckReadiness(context.Background())
+	}
+
+	history := hs.History("a")
+	assert.Len(t, history, 3)
+	assert.False(t, history[0].Timestamp.After(history[2].Timestamp))
+}
+
+func TestGoroutineChecker(t *testing.T) {
+	c := NewGoroutineChecker("goroutines", 100, 20)
+
+	assert.NoError(t, c.check(30))
+	assert.NoError(t, c.check(25))
+	assert.NoError(t, c.check(45))
+	assert.ErrorContains(t, c.check(46), "grew by 21")
+	assert.ErrorContains(t, c.check(101), "exceed the limit")
+
+	assert.NoError(t, NewGoroutineChecker("unbounded", 0, 0).Check(context.Background()))
+}
@@ -0,0 +1,88 @@
+package health
+
+import (
+	"sort"
+	"time"
+)
+
+// resultHistory is a fixed-size ring buffer of check results
+type resultHistory struct {
+	items []CheckResult
+	next  int
+	full  bool
+}
+
+func newResultHistory(size int) *resultHistory {
+	return &resultHistory{items: make([]CheckResult, size)}
+}
+
+// add stores a result, overwriting the oldest one when full
+func (r *resultHistory) add(result CheckResult) {
+	r.items[r.next] = result
+	r.next = (r.next + 1) % len(r.items)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns the stored results from oldest to newest
+func (r *resultHistory) list() []CheckResult {
+	if !r.full {
+		out := make([]CheckResult, r.next)
+		copy(out, r.items[:r.next])
+		return out
+	}
+	out := make([]CheckResult, 0, len(r.items))
+	out = append(out, r.items[r.next:]...)
+	out = append(out, r.items[:r.next]...)
+	return out
+}
+
+// CheckerDetails summarizes the recent behaviour of a single checker
+type CheckerDetails struct {
+	Name                string        `json:"name"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	History             []CheckResult `json:"history"`
+}
+
+// History returns the recent results of the named checker, oldest first
+func (h *HealthService) History(name string) []CheckResult {
+	h.statsMu.Lock()
+	defer h.statsMu.Unlock()
+
+	hist, ok := h.history[name]
+	if !ok {
+		return []CheckResult{}
+	}
+	return hist.list()
+}
+
+// Details returns the recent results of every checker that has run,
+// useful for debugging flapping checks
+func (h *HealthService) Details() map[string]interface{} {
+	h.statsMu.Lock()
+	checkers := make([]CheckerDetails, 0, len(h.history))
+	for name, hist := range h.history {
+		checkers = append(checkers, CheckerDetails{
+			Name:                name,
+			ConsecutiveFailures: h.failures[name],
+			History:             hist.list(),
+		})
+	}
+	h.statsMu.Unlock()
+
+	sort.Slice(checkers, func(i, j int) bool {
+		return checkers[i].Name < checkers[j].Name
+	})
+
+	h.listenersMu.Lock()
+	status := h.lastStatus
+	h.listenersMu.Unlock()
+
+	return map[string]interface{}{
+		"status":    status,
+		"draining":  IsDraining(),
+		"timestamp": time.Now(),
+		"checkers":  checkers,
+	}
+}
@@ -0,0 +1,123 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/minisource/go-common/dto"
+	apperrors "github.com/minisource/go-common/errors"
+)
+
+// ============================================
+// Bulk Operations
+// ============================================
+
+// MaxBulkItems caps the items of one bulk request
+var MaxBulkItems = 1000
+
+// BulkItemResult is the outcome of one item of a bulk request
+type BulkItemResult struct {
+	Index   int    `json:"index"`
+	Success bool   `json:"success"`
+	Status  int    `json:"status"`
+	Result  any    `json:"result,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkResult aggregates the outcomes of a bulk request
+type BulkResult struct {
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Items     []BulkItemResult `json:"items"`
+}
+
+// BulkCreate handles batch creation from a JSON array body. caller reports
+// failed items through an *errors.MultiError; the response is 201 if all
+// items succeeded and 207 Multi-Status with per item results otherwise
+func BulkCreate[Ti any, To any](c *fiber.Ctx, caller func(ctx context.Context, reqs []*Ti) ([]*To, error)) error {
+	var reqs []*Ti
+	if err := parseBulkBody(c, &reqs); err != nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(GenerateBaseResponseWithError(nil, false, ValidationError, err))
+	}
+
+	results, err := caller(c.Context(), reqs)
+	return bulkResponse(c, fiber.StatusCreated, len(reqs), results, err)
+}
+
+// BulkUpdate handles batch updates from a JSON array of {"id", "data"}
+// objects; see BulkCreate for the response
+func BulkUpdate[Ti any, To any](c *fiber.Ctx, caller func(ctx context.Context, items []dto.BulkUpdateItem[Ti]) ([]*To, error)) error {
+	var items []dto.BulkUpdateItem[Ti]
+	if err := parseBulkBody(c, &items); err != nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(GenerateBaseResponseWithError(nil, false, ValidationError, err))
+	}
+
+	results, err := caller(c.Context(), items)
+	return bulkResponse(c, fiber.StatusOK, len(items), results, err)
+}
+
+// BulkDelete handles batch deletes from a JSON array of ids; see BulkCreate
+// for the response
+func BulkDelete(c *fiber.Ctx, caller func(ctx context.Context, ids []uuid.UUID) error) error {
+	var ids []uuid.UUID
+	if err := parseBulkBody(c, &ids); err != nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(GenerateBaseResponseWithError(nil, false, ValidationError, err))
+	}
+
+	err := caller(c.Context(), ids)
+	return bulkResponse[any](c, fiber.StatusOK, len(ids), nil, err)
+}
+
+func parseBulkBody[T any](c *fiber.Ctx, dst *[]T) error {
+	if err := c.BodyParser(dst); err != nil {
+		return err
+	}
+	if len(*dst) == 0 {
+		return errors.New("bulk request has no items")
+	}
+	if len(*dst) > MaxBulkItems {
+		return fmt.Errorf("bulk request has %d items, the limit is %d", len(*dst), MaxBulkItems)
+	}
+	return nil
+}
+
+func bulkResponse[To any](c *fiber.Ctx, okStatus, total int, results []*To, err error) error {
+	var multi *apperrors.MultiError
+	if err != nil && !errors.As(err, &multi) {
+		return c.Status(TranslateErrorToStatusCode(err)).
+			JSON(GenerateBaseResponseWithError(nil, false, InternalError, err))
+	}
+
+	res := &BulkResult{Total: total, Items: make([]BulkItemResult, total)}
+	for i := range res.Items {
+		item := BulkItemResult{Index: i, Success: true, Status: okStatus}
+		if multi != nil {
+			if itemErr := multi.For(i); itemErr != nil {
+				item = BulkItemResult{Index: i, Status: TranslateErrorToStatusCode(itemErr), Error: itemErr.Error()}
+			}
+		}
+		if item.Success && i < len(results) && results[i] != nil {
+			item.Result = results[i]
+		}
+		if item.Success {
+			res.Succeeded++
+		} else {
+			res.Failed++
+		}
+		res.Items[i] = item
+	}
+
+	if res.Failed > 0 {
+		return c.Status(fiber.StatusMultiStatus).
+			JSON(GenerateBaseResponse(res, false, PartialSuccess))
+	}
+	return c.Status(okStatus).
+		JSON(GenerateBaseResponse(res, true, 0))
+}
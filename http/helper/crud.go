@@ -0,0 +1,196 @@
+package helper
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/minisource/go-common/dto"
+	"github.com/minisource/go-common/http/openapi"
+)
+
+// ============================================
+// CRUD Route Registration
+// ============================================
+
+// CRUDService is the service RegisterCRUD exposes; services.BaseService
+// implements it
+type CRUDService[Tc any, Tu any, Tr any] interface {
+	Create(ctx context.Context, req *Tc) (*Tr, error)
+	Update(ctx context.Context, id uuid.UUID, req *Tu) (*Tr, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Tr, error)
+	GetByFilter(ctx context.Context, req *dto.PaginationInputWithFilter) (*dto.PagedList[Tr], error)
+}
+
+// BulkCRUDService is a CRUDService with batch operations; RegisterCRUD
+// adds the bulk routes for services implementing it
+type BulkCRUDService[Tc any, Tu any, Tr any] interface {
+	CreateBatch(ctx context.Context, reqs []*Tc) ([]*Tr, error)
+	UpdateBatch(ctx context.Context, items []dto.BulkUpdateItem[Tu]) ([]*Tr, error)
+	DeleteBatch(ctx context.Context, ids []uuid.UUID) error
+}
+
+// PatchCRUDService is a CRUDService with field-mask partial updates;
+// RegisterCRUD adds the PATCH route only for services implementing it
+type PatchCRUDService interface {
+	Patch(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error
+}
+
+// CRUDRoute identifies a route registered by RegisterCRUD
+type CRUDRoute string
+
+const (
+	RouteCreate CRUDRoute = "create" // POST   {base}
+	RouteList   CRUDRoute = "list"   // GET    {base} and POST {base}/filter
+	RouteGet    CRUDRoute = "get"    // GET    {base}/:id
+	RouteUpdate CRUDRoute = "update" // PUT    {base}/:id
+	RoutePatch  CRUDRoute = "patch"  // PATCH  {base}/:id
+	RouteDelete CRUDRoute = "delete" // DELETE {base}/:id
+	RouteBulk   CRUDRoute = "bulk"   // POST, PUT and DELETE {base}/bulk
+)
+
+type crudConfig struct {
+	middleware      []fiber.Handler
+	routeMiddleware map[CRUDRoute][]fiber.Handler
+	disabled        map[CRUDRoute]bool
+	spec            *openapi.Spec
+	tag             string
+}
+
+// CRUDOption configures RegisterCRUD
+type CRUDOption func(*crudConfig)
+
+// WithCRUDMiddleware runs handlers before every route
+func WithCRUDMiddleware(handlers ...fiber.Handler) CRUDOption {
+	return func(c *crudConfig) {
+		c.middleware = append(c.middleware, handlers...)
+	}
+}
+
+// WithRouteMiddleware runs handlers before one route, after the
+// WithCRUDMiddleware handlers
+func WithRouteMiddleware(route CRUDRoute, handlers ...fiber.Handler) CRUDOption {
+	return func(c *crudConfig) {
+		c.routeMiddleware[route] = append(c.routeMiddleware[route], handlers...)
+	}
+}
+
+// WithoutRoutes skips registering routes
+func WithoutRoutes(routes ...CRUDRoute) CRUDOption {
+	return func(c *crudConfig) {
+		for _, route := range routes {
+			c.disabled[route] = true
+		}
+	}
+}
+
+// WithOpenAPI documents the registered routes in spec under tag
+func WithOpenAPI(spec *openapi.Spec, tag string) CRUDOption {
+	return func(c *crudConfig) {
+		c.spec = spec
+		c.tag = tag
+	}
+}
+
+// RegisterCRUD wires the create, list, get, update and delete routes of
+// service onto router under basePath using the generic handlers, plus the
+// bulk routes if service implements BulkCRUDService and the PATCH route,
+// handled by Patch with Tu as the body type, if it implements PatchCRUDService
+//
+//	helper.RegisterCRUD(api, "/products", productService,
+//		helper.WithCRUDMiddleware(middleware.AuthMiddleware(authCfg)),
+//		helper.WithRouteMiddleware(helper.RouteCreate,
+//			middleware.RequirePermissions("products:write"),
+//			middleware.ValidateMiddleware[dto.CreateProduct](validator)),
+//		helper.WithoutRoutes(helper.RouteDelete),
+//		helper.WithOpenAPI(spec, "products"),
+//	)
+//	spec.Mount(app) // GET /openapi.json
+func RegisterCRUD[Tc any, Tu any, Tr any](router fiber.Router, basePath string, service CRUDService[Tc, Tu, Tr], opts ...CRUDOption) {
+	cfg := &crudConfig{
+		routeMiddleware: make(map[CRUDRoute][]fiber.Handler),
+		disabled:        make(map[CRUDRoute]bool),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	group := router.Group(basePath)
+	prefix := basePath
+	if g, ok := group.(*fiber.Group); ok {
+		prefix = g.Prefix
+	}
+	idParam := map[string]*openapi.Schema{"id": {Type: "string", Format: "uuid"}}
+
+	add := func(route CRUDRoute, method, path string, handler fiber.Handler, doc openapi.Route) {
+		if cfg.disabled[route] {
+			return
+		}
+		handlers := make([]fiber.Handler, 0, len(cfg.middleware)+len(cfg.routeMiddleware[route])+1)
+		handlers = append(handlers, cfg.middleware...)
+		handlers = append(handlers, cfg.routeMiddleware[route]...)
+		handlers = append(handlers, handler)
+		group.Add(method, path, handlers...)
+
+		if cfg.spec != nil {
+			doc.Method = method
+			doc.Path = strings.TrimSuffix(prefix+path, "/")
+			if doc.Path == "" {
+				doc.Path = "/"
+			}
+			if cfg.tag != "" {
+				doc.Tags = []string{cfg.tag}
+			}
+			if strings.Contains(path, ":id") {
+				doc.PathParams = idParam
+			}
+			cfg.spec.Add(doc)
+		}
+	}
+
+	add(RouteCreate, fiber.MethodPost, "/", func(c *fiber.Ctx) error {
+		return Create(c, service.Create)
+	}, openapi.Route{Summary: "Create " + cfg.tag, Request: new(Tc), Response: new(Tr), Status: fiber.StatusCreated})
+	add(RouteList, fiber.MethodGet, "/", func(c *fiber.Ctx) error {
+		return GetByFilterQuery(c, service.GetByFilter)
+	}, openapi.Route{Summary: "List " + cfg.tag, Parameters: filterQueryParameters, Response: new(dto.PagedList[Tr])})
+	add(RouteList, fiber.MethodPost, "/filter", func(c *fiber.Ctx) error {
+		return GetByFilter(c, service.GetByFilter)
+	}, openapi.Route{Summary: "Filter " + cfg.tag, Request: new(dto.PaginationInputWithFilter), Response: new(dto.PagedList[Tr])})
+	// Registered before /:id, which would otherwise match "bulk"
+	if bulk, ok := service.(BulkCRUDService[Tc, Tu, Tr]); ok {
+		add(RouteBulk, fiber.MethodPost, "/bulk", func(c *fiber.Ctx) error {
+			return BulkCreate(c, bulk.CreateBatch)
+		}, openapi.Route{Summary: "Create " + cfg.tag + " in bulk", Request: []*Tc{}, Response: new(BulkResult), Status: fiber.StatusCreated})
+		add(RouteBulk, fiber.MethodPut, "/bulk", func(c *fiber.Ctx) error {
+			return BulkUpdate(c, bulk.UpdateBatch)
+		}, openapi.Route{Summary: "Update " + cfg.tag + " in bulk", Request: []dto.BulkUpdateItem[Tu]{}, Response: new(BulkResult)})
+		add(RouteBulk, fiber.MethodDelete, "/bulk", func(c *fiber.Ctx) error {
+			return BulkDelete(c, bulk.DeleteBatch)
+		}, openapi.Route{Summary: "Delete " + cfg.tag + " in bulk", Request: []uuid.UUID{}, Response: new(BulkResult)})
+	}
+	add(RouteGet, fiber.MethodGet, "/:id", func(c *fiber.Ctx) error {
+		return GetByID(c, service.GetByID)
+	}, openapi.Route{Summary: "Get " + cfg.tag, Response: new(Tr)})
+	add(RouteUpdate, fiber.MethodPut, "/:id", func(c *fiber.Ctx) error {
+		return Update(c, service.Update)
+	}, openapi.Route{Summary: "Update " + cfg.tag, Request: new(Tu), Response: new(Tr)})
+	if patcher, ok := service.(PatchCRUDService); ok {
+		add(RoutePatch, fiber.MethodPatch, "/:id", func(c *fiber.Ctx) error {
+			return Patch[Tu](c, patcher.Patch)
+		}, openapi.Route{Summary: "Partially update " + cfg.tag, Request: new(Tu)})
+	}
+	add(RouteDelete, fiber.MethodDelete, "/:id", func(c *fiber.Ctx) error {
+		return Delete(c, service.Delete)
+	}, openapi.Route{Summary: "Delete " + cfg.tag})
+}
+
+// filterQueryParameters documents the ParseFilterQuery parameters
+var filterQueryParameters = []openapi.Parameter{
+	{Name: "page", In: "query", Schema: &openapi.Schema{Type: "integer", Format: "int32"}},
+	{Name: "pageSize", In: "query", Schema: &openapi.Schema{Type: "integer", Format: "int32"}},
+	{Name: "sort", In: "query", Description: "Comma separated fields, - prefix for descending", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "filter", In: "query", Description: "filter[field][operator]=value", Schema: &openapi.Schema{Type: "object"}},
+}
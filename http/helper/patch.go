@@ -0,0 +1,183 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	validation "github.com/minisource/go-common/validations"
+)
+
+// ============================================
+// PATCH Partial Updates
+// ============================================
+
+// ErrEmptyPatch is returned for a PATCH body without fields
+var ErrEmptyPatch = errors.New("patch body has no fields")
+
+// PatchFields is the field mask of a PATCH body
+type PatchFields struct {
+	// Mask lists the json names present in the body, sorted
+	Mask []string
+	// Values maps the column of each present field to its value, nil for
+	// an explicit null. Keys are the gorm column tag or the Go field name,
+	// both of which GORM resolves through the model schema
+	Values map[string]interface{}
+}
+
+// Has reports whether the json field name was present in the body
+func (p *PatchFields) Has(name string) bool {
+	i := sort.SearchStrings(p.Mask, name)
+	return i < len(p.Mask) && p.Mask[i] == name
+}
+
+var (
+	patchValidator     *validator.Validate
+	patchValidatorOnce sync.Once
+)
+
+// PatchValidator returns the validator ParsePatch uses, so callers can
+// register their own validations on it
+func PatchValidator() *validator.Validate {
+	patchValidatorOnce.Do(func() {
+		v := validator.New()
+		v.RegisterTagNameFunc(func(fld reflect.StructField) string {
+			name, _, _ := strings.Cut(fld.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+		validation.RegisterAll(v)
+		patchValidator = v
+	})
+	return patchValidator
+}
+
+// ParsePatch decodes a partial JSON body into T and returns the fields it
+// contains. Only the present fields are validated, so `validate` tags on
+// T apply to what the client sends; a present null is validated as the zero
+// value. Keys that are not json fields of T are rejected
+func ParsePatch[T any](body []byte) (*T, *PatchFields, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid patch body: %w", err)
+	}
+	if len(raw) == 0 {
+		return nil, nil, ErrEmptyPatch
+	}
+
+	req := new(T)
+	rv := reflect.ValueOf(req).Elem()
+	if rv.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("patch type %s is not a struct", rv.Type())
+	}
+	fields := patchFieldIndex(rv.Type())
+
+	patch := &PatchFields{Values: make(map[string]interface{}, len(raw))}
+	goNames := make([]string, 0, len(raw))
+	for name := range raw {
+		f, ok := fields[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown field %q", name)
+		}
+		patch.Mask = append(patch.Mask, name)
+		goNames = append(goNames, f.Name)
+	}
+	sort.Strings(patch.Mask)
+
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, nil, fmt.Errorf("invalid patch body: %w", err)
+	}
+	if err := PatchValidator().StructPartial(req, goNames...); err != nil {
+		return nil, nil, err
+	}
+
+	for name, value := range raw {
+		f := fields[name]
+		if bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+			patch.Values[patchColumn(f)] = nil
+			continue
+		}
+		fv := rv.FieldByIndex(f.Index)
+		for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		patch.Values[patchColumn(f)] = fv.Interface()
+	}
+	return req, patch, nil
+}
+
+// Patch handles PATCH /:id endpoints with a partial body decoded as T,
+// passing the present fields to caller, which is typically
+// GormRepository.UpdateFields. Absent fields are left unchanged and null
+// fields are set to NULL
+//
+//	app.Patch("/users/:id", func(c *fiber.Ctx) error {
+//		return helper.Patch[dto.UpdateUser](c, userRepo.UpdateFields)
+//	})
+func Patch[T any](c *fiber.Ctx, caller func(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(GenerateBaseResponse(nil, false, ValidationError))
+	}
+
+	_, patch, err := ParsePatch[T](c.Body())
+	if err != nil {
+		var ve validator.ValidationErrors
+		if errors.As(err, &ve) {
+			return c.Status(fiber.StatusBadRequest).
+				JSON(GenerateBaseResponseWithValidationError(nil, false, ValidationError, err))
+		}
+		return c.Status(fiber.StatusBadRequest).
+			JSON(GenerateBaseResponseWithError(nil, false, ValidationError, err))
+	}
+
+	if err := caller(c.Context(), id, patch.Values); err != nil {
+		return c.Status(TranslateErrorToStatusCode(err)).
+			JSON(GenerateBaseResponseWithError(nil, false, InternalError, err))
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(GenerateBaseResponse(fiber.Map{"updated": patch.Mask}, true, 0))
+}
+
+// patchFieldIndex maps the json names of t's exported fields to the fields
+func patchFieldIndex(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if _, exists := fields[name]; !exists {
+			fields[name] = f
+		}
+	}
+	return fields
+}
+
+func patchColumn(f reflect.StructField) string {
+	for _, part := range strings.Split(f.Tag.Get("gorm"), ";") {
+		if column, ok := strings.CutPrefix(part, "column:"); ok {
+			return column
+		}
+	}
+	return f.Name
+}
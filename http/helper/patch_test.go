@@ -0,0 +1,52 @@
+package helper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type updateUser struct {
+	Name     *string `json:"name" validate:"omitempty,min=2"`
+	Email    *string `json:"email" validate:"required,email"`
+	Bio      *string `json:"bio" gorm:"column:biography"`
+	Nickname string  `json:"nickname"`
+}
+
+func TestParsePatchNullAndAbsentFields(t *testing.T) {
+	req, patch, err := ParsePatch[updateUser]([]byte(`{"name":"Ali","bio":null}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"bio", "name"}, patch.Mask)
+	assert.True(t, patch.Has("bio"))
+	assert.False(t, patch.Has("email"))
+	assert.Equal(t, map[string]interface{}{"Name": "Ali", "biography": nil}, patch.Values)
+	assert.Equal(t, "Ali", *req.Name)
+	assert.Nil(t, req.Bio)
+}
+
+func TestParsePatchRejectsUnknownKeys(t *testing.T) {
+	_, _, err := ParsePatch[updateUser]([]byte(`{"name":"Ali","role":"admin"}`))
+	assert.ErrorContains(t, err, `unknown field "role"`)
+
+	_, _, err = ParsePatch[updateUser]([]byte(`{}`))
+	assert.ErrorIs(t, err, ErrEmptyPatch)
+}
+
+func TestParsePatchValidatesPresentFieldsOnly(t *testing.T) {
+	// email is required but absent, so it isn't validated
+	_, _, err := ParsePatch[updateUser]([]byte(`{"nickname":"ali"}`))
+	assert.NoError(t, err)
+
+	_, _, err = ParsePatch[updateUser]([]byte(`{"name":"A"}`))
+	var ve validator.ValidationErrors
+	require.True(t, errors.As(err, &ve))
+	assert.Equal(t, "name", ve[0].Field())
+
+	_, _, err = ParsePatch[updateUser]([]byte(`{"email":"not-an-email"}`))
+	require.True(t, errors.As(err, &ve))
+	assert.Equal(t, "email", ve[0].Field())
+}
@@ -0,0 +1,140 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/dto"
+	"github.com/minisource/go-common/pagination"
+)
+
+// ============================================
+// Query Parameter Filters
+// ============================================
+
+// ParseFilterQuery builds the pagination and filter input from query
+// parameters, as sent by the admin UI grids:
+//
+//	?page=2&pageSize=50
+//	&filter[name][contains]=ali
+//	&filter[price][inRange]=10,100     either bound may be empty: ,100
+//	&filter[status][in]=active,blocked
+//	&filter[deleted_at][isNull]=true
+//	&sort=-created_at,name             "-" sorts descending
+//
+// Malformed parameters are errors rather than being ignored; parameters
+// other than page, pageSize, sort and filter[...] are left alone
+func ParseFilterQuery(c *fiber.Ctx) (*dto.PaginationInputWithFilter, error) {
+	req := &dto.PaginationInputWithFilter{}
+	var err error
+
+	c.Context().QueryArgs().VisitAll(func(k, v []byte) {
+		if err != nil {
+			return
+		}
+		key, value := string(k), string(v)
+		switch {
+		case key == "page":
+			req.PageNumber, err = parseQueryInt(key, value, 1, 0)
+		case key == "pageSize":
+			req.PageSize, err = parseQueryInt(key, value, 1, pagination.MaxPageSize)
+		case key == "sort":
+			err = parseQuerySort(req, value)
+		case strings.HasPrefix(key, "filter["):
+			err = parseQueryFilter(req, key, value)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// GetByFilterQuery handles list endpoints filtered by query parameters;
+// see ParseFilterQuery
+func GetByFilterQuery[To any](c *fiber.Ctx, caller func(ctx context.Context, req *dto.PaginationInputWithFilter) (*To, error)) error {
+	req, err := ParseFilterQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(GenerateBaseResponseWithError(nil, false, ValidationError, err))
+	}
+
+	res, err := caller(c.Context(), req)
+	if err != nil {
+		return c.Status(TranslateErrorToStatusCode(err)).
+			JSON(GenerateBaseResponseWithError(nil, false, InternalError, err))
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(GenerateBaseResponse(res, true, 0))
+}
+
+func parseQueryInt(key, value string, lo, hi int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < lo || (hi > 0 && n > hi) {
+		if hi > 0 {
+			return 0, fmt.Errorf("%s must be an integer between %d and %d", key, lo, hi)
+		}
+		return 0, fmt.Errorf("%s must be an integer of at least %d", key, lo)
+	}
+	return n, nil
+}
+
+func parseQuerySort(req *dto.PaginationInputWithFilter, value string) error {
+	var sorts []dto.Sort
+	if req.Sort != nil {
+		sorts = *req.Sort
+	}
+	for _, field := range strings.Split(value, ",") {
+		dir := "asc"
+		if strings.HasPrefix(field, "-") {
+			field, dir = field[1:], "desc"
+		}
+		if field == "" {
+			return fmt.Errorf("sort %q has an empty field", value)
+		}
+		sorts = append(sorts, dto.Sort{ColId: field, Sort: dir})
+	}
+	req.Sort = &sorts
+	return nil
+}
+
+// parseQueryFilter parses filter[field][operator]=value
+func parseQueryFilter(req *dto.PaginationInputWithFilter, key, value string) error {
+	field, rest, ok := strings.Cut(strings.TrimPrefix(key, "filter["), "][")
+	op, closed := strings.CutSuffix(rest, "]")
+	if !ok || !closed || field == "" || op == "" || strings.ContainsAny(field+op, "[]") {
+		return fmt.Errorf("malformed filter parameter %q, want filter[field][operator]", key)
+	}
+	if _, exists := req.Filter[field]; exists {
+		return fmt.Errorf("more than one filter on %q", field)
+	}
+
+	f := dto.Filter{Type: op}
+	switch op {
+	case dto.OpInRange:
+		from, to, found := strings.Cut(value, ",")
+		if !found {
+			return fmt.Errorf("filter[%s][inRange] must be from,to", field)
+		}
+		f.From, f.To = from, to
+	case dto.OpIn:
+		f.Values = strings.Split(value, ",")
+	case dto.OpIsNull:
+		if value != "" && value != "true" && value != "false" {
+			return fmt.Errorf("filter[%s][isNull] must be true or false", field)
+		}
+		f.From = value
+	default:
+		f.From = value
+	}
+
+	if req.Filter == nil {
+		req.Filter = make(map[string]dto.Filter)
+	}
+	req.Filter[field] = f
+	return nil
+}
@@ -4,6 +4,7 @@ type ResultCode int
 
 const (
 	Success         ResultCode = 0
+	PartialSuccess  ResultCode = 20701
 	ValidationError ResultCode = 40001
 	AuthError       ResultCode = 40101
 	ForbiddenError       ResultCode = 40301
@@ -1,29 +1,90 @@
 package helper
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
+	"github.com/minisource/go-common/dto"
+	apperrors "github.com/minisource/go-common/errors"
+	"github.com/minisource/go-common/repository"
+	"github.com/minisource/go-common/response"
 	"github.com/minisource/go-common/service_errors"
 )
 
-var StatusCodeMapping = map[string]int{
+// StatusCodeMapping is response.ErrorCodeToStatus, the same map, kept for
+// callers registering codes through helper.
+//
+// Deprecated: add codes to response.ErrorCodeToStatus
+var StatusCodeMapping = response.ErrorCodeToStatus
 
-	// OTP
-	service_errors.OptExists:   409,
-	service_errors.OtpUsed:     409,
-	service_errors.OtpNotValid: 400,
-
-	// User
-	service_errors.EmailExists:      409,
-	service_errors.UsernameExists:   409,
-	service_errors.RecordNotFound:   404,
-	service_errors.PermissionDenied: 403,
+// sentinelStatuses maps the sentinel errors of the errors and repository
+// packages, matched with errors.Is in order
+var sentinelStatuses = []struct {
+	err    error
+	status int
+}{
+	{apperrors.ErrNotFound, http.StatusNotFound},
+	{repository.ErrNotFound, http.StatusNotFound},
+	{apperrors.ErrDuplicate, http.StatusConflict},
+	{repository.ErrAlreadyExists, http.StatusConflict},
+	{apperrors.ErrConflict, http.StatusConflict},
+	{apperrors.ErrInvalidInput, http.StatusBadRequest},
+	{repository.ErrInvalidID, http.StatusBadRequest},
+	{apperrors.ErrValidation, http.StatusBadRequest},
+	{dto.ErrInvalidFilter, http.StatusBadRequest},
+	{apperrors.ErrUnauthorized, http.StatusUnauthorized},
+	{apperrors.ErrForbidden, http.StatusForbidden},
+	{apperrors.ErrTimeout, http.StatusGatewayTimeout},
+	{context.DeadlineExceeded, http.StatusGatewayTimeout},
+	{apperrors.ErrConnectionFailed, http.StatusServiceUnavailable},
+	{apperrors.ErrInternal, http.StatusInternalServerError},
 }
 
+// TranslateErrorToStatusCode returns the HTTP status for err, looking
+// through wrapped errors:
+//
+//  1. the StatusCode of an errors.ServiceError
+//  2. the Code, then EndUserMessage, of a service_errors.ServiceError in
+//     response.ErrorCodeToStatus
+//  3. the sentinel errors of the errors and repository packages, which
+//     RepositoryError wraps
+//  4. the error message in response.ErrorCodeToStatus
+//
+// and 500 otherwise. Services extend it through response.ErrorCodeToStatus
 func TranslateErrorToStatusCode(err error) int {
-	value, ok := StatusCodeMapping[err.Error()]
-	if !ok {
-		return http.StatusInternalServerError
+	if err == nil {
+		return http.StatusOK
+	}
+
+	var appErr *apperrors.ServiceError
+	if errors.As(err, &appErr) {
+		if appErr.StatusCode != 0 {
+			return appErr.StatusCode
+		}
+		if status, ok := response.ErrorCodeToStatus[appErr.Code]; ok {
+			return status
+		}
+	}
+
+	var svcErr *service_errors.ServiceError
+	if errors.As(err, &svcErr) {
+		if status, ok := response.ErrorCodeToStatus[svcErr.Code]; ok {
+			return status
+		}
+		if status, ok := response.ErrorCodeToStatus[svcErr.EndUserMessage]; ok {
+			return status
+		}
+	}
+
+	for _, s := range sentinelStatuses {
+		if errors.Is(err, s.err) {
+			return s.status
+		}
+	}
+
+	if status, ok := response.ErrorCodeToStatus[err.Error()]; ok {
+		return status
 	}
-	return value
+	return http.StatusInternalServerError
 }
@@ -0,0 +1,77 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/minisource/go-common/dto"
+	apperrors "github.com/minisource/go-common/errors"
+	"github.com/minisource/go-common/repository"
+	"github.com/minisource/go-common/response"
+	"github.com/minisource/go-common/service_errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateErrorToStatusCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, http.StatusOK},
+		{"unknown", errors.New("boom"), http.StatusInternalServerError},
+
+		// errors.ServiceError
+		{"service error status", apperrors.NotFoundServiceError("user"), http.StatusNotFound},
+		{"service error 422", apperrors.ValidationServiceError("bad"), http.StatusUnprocessableEntity},
+		{"service error code only", &apperrors.ServiceError{Code: response.ErrCodeRateLimited}, http.StatusTooManyRequests},
+		{"wrapped service error", fmt.Errorf("handler: %w", apperrors.ConflictServiceError("taken")), http.StatusConflict},
+
+		// service_errors.ServiceError
+		{"legacy end user message", &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}, http.StatusNotFound},
+		{"legacy code", service_errors.NewServiceError(service_errors.EmailExists, "Email already used", ""), http.StatusConflict},
+		{"legacy response code", service_errors.NewServiceError(response.ErrCodeTokenExpired, "expired", ""), http.StatusUnauthorized},
+		{"legacy validation", service_errors.NewValidationError("name is required"), http.StatusBadRequest},
+		{"legacy unknown code", service_errors.NewServiceError("SOMETHING", "something", ""), http.StatusInternalServerError},
+
+		// Sentinels and RepositoryError
+		{"repository error not found", apperrors.NewNotFoundError("user", "find"), http.StatusNotFound},
+		{"repository error duplicate", apperrors.NewDuplicateError("user", "create"), http.StatusConflict},
+		{"repository error validation", apperrors.NewValidationError("create", "bad email"), http.StatusBadRequest},
+		{"repository error internal", apperrors.NewInternalError("create", errors.New("db down")), http.StatusInternalServerError},
+		{"repository package not found", repository.ErrNotFound, http.StatusNotFound},
+		{"repository package exists", repository.ErrAlreadyExists, http.StatusConflict},
+		{"unauthorized", apperrors.ErrUnauthorized, http.StatusUnauthorized},
+		{"forbidden", apperrors.ErrForbidden, http.StatusForbidden},
+		{"timeout", apperrors.ErrTimeout, http.StatusGatewayTimeout},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{"connection", apperrors.ErrConnectionFailed, http.StatusServiceUnavailable},
+		{"invalid filter", fmt.Errorf("%w: unknown field", dto.ErrInvalidFilter), http.StatusBadRequest},
+		{"multi error", (&apperrors.MultiError{Errors: []apperrors.ItemError{{Index: 0, Err: apperrors.ErrForbidden}}}), http.StatusForbidden},
+
+		// Message keys
+		{"message key", errors.New(service_errors.PermissionDenied), http.StatusForbidden},
+		{"response code message", errors.New(response.ErrCodeServiceUnavailable), http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, TranslateErrorToStatusCode(tt.err))
+		})
+	}
+}
+
+func TestTranslateErrorToStatusCodeCustomCode(t *testing.T) {
+	const code = "ORDER_ALREADY_SHIPPED"
+	response.ErrorCodeToStatus[code] = http.StatusConflict
+	defer delete(response.ErrorCodeToStatus, code)
+
+	assert.Equal(t, http.StatusConflict, TranslateErrorToStatusCode(service_errors.NewServiceError(code, "shipped", "")))
+	assert.Equal(t, http.StatusConflict, TranslateErrorToStatusCode(&apperrors.ServiceError{Code: code}))
+
+	// StatusCodeMapping is the same table
+	assert.Equal(t, http.StatusConflict, StatusCodeMapping[code])
+}
@@ -0,0 +1,249 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/cache"
+	"github.com/minisource/go-common/common"
+	"github.com/minisource/go-common/crypto"
+	"github.com/minisource/go-common/logging"
+)
+
+// ErrInvalidAPIKey is returned by validators for unknown or revoked keys
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// APIKeyInfo describes the client an API key belongs to
+type APIKeyInfo struct {
+	ClientID    string    `json:"clientId"`
+	ServiceName string    `json:"serviceName,omitempty"`
+	TenantID    string    `json:"tenantId,omitempty"`
+	Scopes      []string  `json:"scopes,omitempty"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// Expired reports whether the key has an expiry in the past
+func (i *APIKeyInfo) Expired() bool {
+	return !i.ExpiresAt.IsZero() && time.Now().After(i.ExpiresAt)
+}
+
+// APIKeyValidator looks up API keys. It receives the hash of the key, so
+// stores never hold plain keys
+type APIKeyValidator interface {
+	ValidateAPIKey(ctx context.Context, keyHash string) (*APIKeyInfo, error)
+}
+
+// APIKeyValidatorFunc adapts a function to APIKeyValidator
+type APIKeyValidatorFunc func(ctx context.Context, keyHash string) (*APIKeyInfo, error)
+
+// ValidateAPIKey implements APIKeyValidator
+func (f APIKeyValidatorFunc) ValidateAPIKey(ctx context.Context, keyHash string) (*APIKeyInfo, error) {
+	return f(ctx, keyHash)
+}
+
+// StaticAPIKeyValidator validates keys from a fixed set keyed by
+// HashAPIKey(key), e.g. loaded from config
+type StaticAPIKeyValidator map[string]APIKeyInfo
+
+// ValidateAPIKey implements APIKeyValidator
+func (v StaticAPIKeyValidator) ValidateAPIKey(ctx context.Context, keyHash string) (*APIKeyInfo, error) {
+	info, ok := v[keyHash]
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	return &info, nil
+}
+
+// HashAPIKey returns the SHA-256 hex digest of key, the default hash passed
+// to validators. Store this when issuing keys from crypto.GenerateAPIKey
+func HashAPIKey(key string) string {
+	return crypto.SHA256Hash(key)
+}
+
+// APIKeyConfig holds configuration for API key middleware
+type APIKeyConfig struct {
+	// Enabled determines if an API key is required
+	Enabled bool
+	// Validator looks up keys by hash
+	Validator APIKeyValidator
+	// KeyLookup lists comma separated sources of the key, tried in order
+	// Format: "header:X-API-Key", "query:api_key" or "cookie:api_key"
+	// (default: header:X-API-Key). Query keys end up in access logs, so
+	// prefer headers
+	KeyLookup string
+	// AuthScheme is stripped from header values, e.g. "ApiKey" for
+	// "Authorization: ApiKey <key>" (default: none)
+	AuthScheme string
+	// Hasher hashes keys before validation (default: HashAPIKey). Use
+	// crypto.HMACSign with a server-side secret to pepper stored hashes
+	Hasher func(key string) string
+	// Cache, if set, caches validated keys to spare the validator
+	Cache cache.Cache
+	// CacheTTL bounds how long a revoked key keeps working (default: 5m)
+	CacheTTL time.Duration
+	// CachePrefix prefixes the cache keys (default: apikey:)
+	CachePrefix string
+	// SkipPaths are paths that don't require a key
+	SkipPaths []string
+	// RequiredScopes must all be granted to the key
+	RequiredScopes []string
+	// ContextKey is the key to store the APIKeyInfo in context
+	// (default: apiKey)
+	ContextKey string
+	// ErrorHandler handles authentication errors
+	ErrorHandler fiber.ErrorHandler
+	Logger       logging.Logger
+}
+
+// DefaultAPIKeyConfig returns default API key configuration
+func DefaultAPIKeyConfig(validator APIKeyValidator) APIKeyConfig {
+	return APIKeyConfig{
+		Enabled:     true,
+		Validator:   validator,
+		KeyLookup:   "header:X-API-Key",
+		Hasher:      HashAPIKey,
+		CacheTTL:    5 * time.Minute,
+		CachePrefix: "apikey:",
+		ContextKey:  "apiKey",
+		SkipPaths:   []string{"/health", "/ready"},
+	}
+}
+
+// APIKeyMiddleware authenticates requests by API key. It sets the same
+// locals as ServiceAuthMiddleware (clientId, serviceName, tenantId and
+// scopes), so RequireScopes and TenantMiddleware work unchanged
+func APIKeyMiddleware(config APIKeyConfig) fiber.Handler {
+	// Set defaults
+	if config.KeyLookup == "" {
+		config.KeyLookup = "header:X-API-Key"
+	}
+	if config.Hasher == nil {
+		config.Hasher = HashAPIKey
+	}
+	if config.CacheTTL == 0 {
+		config.CacheTTL = 5 * time.Minute
+	}
+	if config.CachePrefix == "" {
+		config.CachePrefix = "apikey:"
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = "apiKey"
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = func(c *fiber.Ctx, err error) error {
+			status := fiber.StatusUnauthorized
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+			return c.Status(status).JSON(fiber.Map{
+				"error": "Unauthorized",
+			})
+		}
+	}
+	lookups := strings.Split(config.KeyLookup, ",")
+
+	return func(c *fiber.Ctx) error {
+		// Check if auth is disabled
+		if !config.Enabled {
+			return c.Next()
+		}
+
+		// Check if path should be skipped
+		path := c.Path()
+		for _, skipPath := range config.SkipPaths {
+			if strings.HasPrefix(path, skipPath) {
+				return c.Next()
+			}
+		}
+
+		// Extract key
+		var key string
+		for _, lookup := range lookups {
+			if key = extractTokenFromRequest(c, strings.TrimSpace(lookup), config.AuthScheme); key != "" {
+				break
+			}
+		}
+		if key == "" {
+			return config.ErrorHandler(c, fiber.NewError(fiber.StatusUnauthorized, "No API key provided"))
+		}
+
+		info, err := config.validate(c.UserContext(), config.Hasher(key))
+		if err != nil {
+			return config.ErrorHandler(c, err)
+		}
+
+		// Check required scopes
+		for _, required := range config.RequiredScopes {
+			if !common.HasPermission(info.Scopes, required) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Insufficient scopes",
+				})
+			}
+		}
+
+		// Store client info in context
+		c.Locals(config.ContextKey, info)
+		c.Locals("clientId", info.ClientID)
+		c.Locals("serviceName", info.ServiceName)
+		c.Locals("tenantId", info.TenantID)
+		c.Locals("scopes", info.Scopes)
+
+		return c.Next()
+	}
+}
+
+// validate resolves a key hash through the cache and the validator
+func (config *APIKeyConfig) validate(ctx context.Context, keyHash string) (*APIKeyInfo, error) {
+	cacheKey := config.CachePrefix + keyHash
+	if config.Cache != nil {
+		var cached APIKeyInfo
+		if err := config.Cache.GetObject(ctx, cacheKey, &cached); err == nil {
+			if cached.Expired() {
+				return nil, fiber.NewError(fiber.StatusUnauthorized, "API key expired")
+			}
+			return &cached, nil
+		}
+	}
+
+	info, err := config.Validator.ValidateAPIKey(ctx, keyHash)
+	if errors.Is(err, ErrInvalidAPIKey) || (err == nil && info == nil) {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid API key")
+	}
+	if err != nil {
+		if config.Logger != nil {
+			config.Logger.Error(logging.General, logging.Api, "API key validation failed", map[logging.ExtraKey]interface{}{
+				"error": err.Error(),
+			})
+		}
+		return nil, fiber.NewError(fiber.StatusServiceUnavailable, "API key validation unavailable")
+	}
+	if info.Expired() {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "API key expired")
+	}
+
+	if config.Cache != nil {
+		ttl := config.CacheTTL
+		if !info.ExpiresAt.IsZero() {
+			ttl = min(ttl, time.Until(info.ExpiresAt))
+		}
+		if err := config.Cache.SetObject(ctx, cacheKey, info, ttl); err != nil && config.Logger != nil {
+			config.Logger.Warn(logging.General, logging.Api, "Failed to cache API key", map[logging.ExtraKey]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+	return info, nil
+}
+
+// GetAPIKeyInfo extracts the API key info stored under the default ContextKey
+func GetAPIKeyInfo(c *fiber.Ctx) *APIKeyInfo {
+	info, ok := c.Locals("apiKey").(*APIKeyInfo)
+	if !ok {
+		return nil
+	}
+	return info
+}
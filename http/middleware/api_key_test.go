@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func apiKeyStatus(t *testing.T, handler fiber.Handler, key string) int {
+	app := fiber.New()
+	app.Use(handler)
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString(GetAPIKeyInfo(c).ClientID) })
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-API-Key", key)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestAPIKeyCacheHit(t *testing.T) {
+	calls := 0
+	validator := APIKeyValidatorFunc(func(ctx context.Context, keyHash string) (*APIKeyInfo, error) {
+		calls++
+		return &APIKeyInfo{ClientID: "billing"}, nil
+	})
+	config := DefaultAPIKeyConfig(validator)
+	config.Cache = cache.NewMemoryCache()
+	handler := APIKeyMiddleware(config)
+
+	assert.Equal(t, fiber.StatusOK, apiKeyStatus(t, handler, "key-1"))
+	assert.Equal(t, fiber.StatusOK, apiKeyStatus(t, handler, "key-1"))
+	assert.Equal(t, 1, calls)
+
+	// A cached key that has expired since is refused without a lookup
+	expired := APIKeyInfo{ClientID: "billing", ExpiresAt: time.Now().Add(-time.Minute)}
+	require.NoError(t, config.Cache.SetObject(context.Background(), "apikey:"+HashAPIKey("key-2"), expired, time.Hour))
+	assert.Equal(t, fiber.StatusUnauthorized, apiKeyStatus(t, handler, "key-2"))
+	assert.Equal(t, 1, calls)
+}
+
+func TestAPIKeyValidatorErrors(t *testing.T) {
+	tests := map[string]struct {
+		info *APIKeyInfo
+		err  error
+		want int
+	}{
+		"invalid":     {err: ErrInvalidAPIKey, want: fiber.StatusUnauthorized},
+		"not found":   {want: fiber.StatusUnauthorized},
+		"expired":     {info: &APIKeyInfo{ExpiresAt: time.Now().Add(-time.Second)}, want: fiber.StatusUnauthorized},
+		"store error": {err: errors.New("connection refused"), want: fiber.StatusServiceUnavailable},
+		"valid":       {info: &APIKeyInfo{ClientID: "billing"}, want: fiber.StatusOK},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			validator := APIKeyValidatorFunc(func(ctx context.Context, keyHash string) (*APIKeyInfo, error) {
+				return tt.info, tt.err
+			})
+			assert.Equal(t, tt.want, apiKeyStatus(t, APIKeyMiddleware(DefaultAPIKeyConfig(validator)), "key"))
+		})
+	}
+}
+
+func TestAPIKeyRequiredScopes(t *testing.T) {
+	validator := StaticAPIKeyValidator{
+		HashAPIKey("reader"): {ClientID: "reader", Scopes: []string{"orders:read"}},
+		HashAPIKey("writer"): {ClientID: "writer", Scopes: []string{"orders:read", "orders:write"}},
+	}
+	config := DefaultAPIKeyConfig(validator)
+	config.RequiredScopes = []string{"orders:read", "orders:write"}
+	handler := APIKeyMiddleware(config)
+
+	assert.Equal(t, fiber.StatusForbidden, apiKeyStatus(t, handler, "reader"))
+	assert.Equal(t, fiber.StatusOK, apiKeyStatus(t, handler, "writer"))
+	assert.Equal(t, fiber.StatusUnauthorized, apiKeyStatus(t, handler, "unknown"))
+}
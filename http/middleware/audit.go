@@ -1,6 +1,10 @@
 package middleware
 
 import (
+	"encoding/json"
+	"errors"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/minisource/go-common/audit"
@@ -155,3 +159,180 @@ func getEntityTypeFromPath(path string) string {
 	}
 	return "UNKNOWN"
 }
+
+// ========================================
+// Automatic Route Auditing
+// ========================================
+
+// AuditRoute configures automatic auditing for a route pattern
+type AuditRoute struct {
+	// Pattern is the Fiber route template, e.g. "/api/v1/users/:id"
+	Pattern string
+	// Methods limits auditing to these methods (default: POST, PUT, PATCH, DELETE)
+	Methods []string
+	// Action overrides the action derived from the HTTP method
+	Action string
+	// EntityType overrides the entity type derived from the route
+	EntityType string
+	// EntityIDParam is the route parameter holding the entity ID (default: "id")
+	EntityIDParam string
+	// CaptureFields are request body fields stored as new values ("*" = all)
+	CaptureFields []string
+	// RedactFields are body fields replaced with a placeholder when captured,
+	// in addition to audit.DefaultExcludedFields. They are matched at any
+	// depth, ignoring case, "_" and "-"
+	RedactFields []string
+}
+
+// AutoAuditConfig holds configuration for automatic route auditing
+type AutoAuditConfig struct {
+	Logger audit.Logger
+	Routes []AuditRoute
+	// AuditFailures records requests that ended with status >= 400 as well
+	AuditFailures bool
+}
+
+// defaultAuditMethods are audited when a route doesn't list methods
+var defaultAuditMethods = []string{fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete}
+
+// AutoAudit creates middleware that records an audit entry for every request
+// matching a configured route pattern. Matching uses the route template
+// resolved by Fiber, so it must be registered with app.Use before the routes.
+func AutoAudit(config AutoAuditConfig) fiber.Handler {
+	if config.Logger == nil {
+		panic("audit logger cannot be nil")
+	}
+
+	routes := make(map[string][]AuditRoute, len(config.Routes))
+	for _, r := range config.Routes {
+		if len(r.Methods) == 0 {
+			r.Methods = defaultAuditMethods
+		}
+		if r.EntityIDParam == "" {
+			r.EntityIDParam = "id"
+		}
+		routes[r.Pattern] = append(routes[r.Pattern], r)
+	}
+
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			var fe *fiber.Error
+			if errors.As(err, &fe) {
+				status = fe.Code
+			} else {
+				status = fiber.StatusInternalServerError
+			}
+		}
+		if status >= 400 && !config.AuditFailures {
+			return err
+		}
+
+		route, ok := matchAuditRoute(routes[c.Route().Path], c.Method())
+		if !ok {
+			return err
+		}
+
+		tenantID := localUUID(c, "tenantId", "tenantID")
+		if tenantID == uuid.Nil {
+			return err
+		}
+
+		entry := &audit.AuditLog{
+			TenantID:   tenantID,
+			Action:     route.Action,
+			EntityType: route.EntityType,
+			IPAddress:  c.IP(),
+			UserAgent:  c.Get(fiber.HeaderUserAgent),
+			Metadata: map[string]interface{}{
+				"method":      c.Method(),
+				"path":        c.Path(),
+				"route":       route.Pattern,
+				"status_code": status,
+			},
+		}
+
+		if entry.Action == "" {
+			entry.Action = getActionFromMethod(c.Method())
+		}
+		if entry.EntityType == "" {
+			entry.EntityType = getEntityTypeFromPath(route.Pattern)
+		}
+		if userID := localUUID(c, "userId", "userID"); userID != uuid.Nil {
+			entry.UserID = &userID
+		}
+		if id, parseErr := uuid.Parse(c.Params(route.EntityIDParam)); parseErr == nil {
+			entry.EntityID = &id
+		}
+		if requestID := GetRequestID(c); requestID != "" {
+			entry.Metadata["request_id"] = requestID
+		}
+		if len(route.CaptureFields) > 0 {
+			entry.NewValues = captureBodyFields(c.Body(), route.CaptureFields, route.RedactFields)
+		}
+
+		_ = config.Logger.Log(c.UserContext(), entry)
+
+		return err
+	}
+}
+
+// matchAuditRoute returns the first route configured for the method
+func matchAuditRoute(candidates []AuditRoute, method string) (AuditRoute, bool) {
+	for _, r := range candidates {
+		for _, m := range r.Methods {
+			if strings.EqualFold(m, method) {
+				return r, true
+			}
+		}
+	}
+	return AuditRoute{}, false
+}
+
+// localUUID reads a UUID stored as uuid.UUID or string under the first
+// matching locals key
+func localUUID(c *fiber.Ctx, keys ...string) uuid.UUID {
+	for _, key := range keys {
+		switch v := c.Locals(key).(type) {
+		case uuid.UUID:
+			if v != uuid.Nil {
+				return v
+			}
+		case string:
+			if id, err := uuid.Parse(v); err == nil {
+				return id
+			}
+		}
+	}
+	return uuid.Nil
+}
+
+// captureBodyFields extracts the configured fields from a JSON body and masks
+// secrets in them with audit.Mask
+func captureBodyFields(body []byte, fields, redact []string) map[string]interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil
+	}
+
+	captured := make(map[string]interface{})
+	if len(fields) == 1 && fields[0] == "*" {
+		for k, v := range payload {
+			captured[k] = v
+		}
+	} else {
+		for _, f := range fields {
+			if v, ok := payload[f]; ok {
+				captured[f] = v
+			}
+		}
+	}
+
+	return audit.Mask(captured, redact...)
+}
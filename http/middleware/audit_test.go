@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/minisource/go-common/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAuditLogger keeps logged entries in memory
+type memoryAuditLogger struct {
+	mu      sync.Mutex
+	entries []*audit.AuditLog
+}
+
+func (l *memoryAuditLogger) Log(ctx context.Context, entry *audit.AuditLog) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+func (l *memoryAuditLogger) LogAction(ctx context.Context, tenantID, userID uuid.UUID, action, entityType string, entityID *uuid.UUID, changes map[string]interface{}) error {
+	return l.Log(ctx, &audit.AuditLog{TenantID: tenantID, UserID: &userID, Action: action, EntityType: entityType, EntityID: entityID, NewValues: changes})
+}
+
+func (l *memoryAuditLogger) Query(ctx context.Context, filter *audit.Filter) ([]*audit.AuditLog, error) {
+	return nil, nil
+}
+
+func TestAutoAuditRedactsCapturedFields(t *testing.T) {
+	logger := &memoryAuditLogger{}
+	// Spare capacity must not be shared between concurrent requests
+	redact := make([]string, 1, 8)
+	redact[0] = "pin"
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("tenantId", uuid.NewString())
+		return c.Next()
+	})
+	app.Use(AutoAudit(AutoAuditConfig{
+		Logger: logger,
+		Routes: []AuditRoute{{Pattern: "/users", CaptureFields: []string{"*"}, RedactFields: redact}},
+	}))
+	app.Post("/users", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusCreated) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"ali","pin":"1234","password":"secret"}`))
+			req.Header.Set("Content-Type", "application/json")
+			_, err := app.Test(req)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, logger.entries, 32)
+	for _, entry := range logger.entries {
+		assert.Equal(t, map[string]interface{}{"name": "ali", "pin": "[REDACTED]", "password": "[REDACTED]"}, entry.NewValues)
+	}
+	assert.Equal(t, []string{"pin"}, redact)
+}
+
+func TestCaptureBodyFieldsMasksNestedAndCamelCaseSecrets(t *testing.T) {
+	body := `{"user":{"name":"ali","password":"p"},"refreshToken":"r","passwordHash":"h","devices":[{"id":1,"apiKey":"k"}]}`
+
+	captured := captureBodyFields([]byte(body), []string{"*"}, nil)
+
+	assert.Equal(t, map[string]interface{}{
+		"user":         map[string]interface{}{"name": "ali", "password": "[REDACTED]"},
+		"refreshToken": "[REDACTED]",
+		"passwordHash": "[REDACTED]",
+		"devices":      []interface{}{map[string]interface{}{"id": float64(1), "apiKey": "[REDACTED]"}},
+	}, captured)
+}
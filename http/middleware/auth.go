@@ -10,14 +10,28 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/minisource/go-common/common"
 )
 
 // AuthConfig holds configuration for auth middleware
 type AuthConfig struct {
 	// Enabled determines if auth is required
 	Enabled bool
-	// Secret is the JWT signing key
+	// Secret is the HMAC key of tokens without a kid header
 	Secret string
+	// Keys maps kid headers to verification keys: []byte or string for
+	// HMAC, *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey. Keeping
+	// the previous key next to the new one rotates keys without downtime
+	Keys map[string]interface{}
+	// JWKS validates tokens with the asymmetric keys published by an IdP;
+	// symmetric keys in the set are ignored. Create it once with NewJWKS,
+	// share it between middlewares and Stop it on shutdown
+	JWKS *JWKS
+	// Issuer, if set, must match the iss claim
+	Issuer string
+	// Audience, if set, must be listed in the aud claim. Set it when
+	// accepting tokens of an IdP that also issues them to other clients
+	Audience string
 	// SkipPaths are paths that don't require authentication
 	SkipPaths []string
 	// TokenLookup defines how to extract token
@@ -120,7 +134,7 @@ func AuthMiddleware(config AuthConfig) fiber.Handler {
 		if config.Validator != nil {
 			claims, err = config.Validator(token)
 		} else {
-			claims, err = validateToken(token, config.Secret)
+			claims, err = validateToken(token, config.keyFunc(), config.parserOptions()...)
 		}
 
 		if err != nil {
@@ -182,7 +196,7 @@ func ServiceAuthMiddleware(config AuthConfig) fiber.Handler {
 			return config.ErrorHandler(c, fiber.NewError(fiber.StatusUnauthorized, "No token provided"))
 		}
 
-		claims, err := validateServiceToken(token, config.Secret)
+		claims, err := validateServiceToken(token, config.keyFunc(), config.parserOptions()...)
 		if err != nil {
 			return config.ErrorHandler(c, err)
 		}
@@ -221,7 +235,8 @@ func RequireRoles(roles ...string) fiber.Handler {
 	}
 }
 
-// RequirePermissions creates middleware that requires specific permissions
+// RequirePermissions creates middleware that requires all of the given
+// permissions; granted permissions match as in common.MatchPermission
 func RequirePermissions(permissions ...string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userPerms, ok := c.Locals("permissions").([]string)
@@ -232,14 +247,7 @@ func RequirePermissions(permissions ...string) fiber.Handler {
 		}
 
 		for _, required := range permissions {
-			found := false
-			for _, userPerm := range userPerms {
-				if userPerm == required {
-					found = true
-					break
-				}
-			}
-			if !found {
+			if !common.HasPermission(userPerms, required) {
 				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 					"error": "Insufficient permissions",
 				})
@@ -250,6 +258,29 @@ func RequirePermissions(permissions ...string) fiber.Handler {
 	}
 }
 
+// RequireAnyPermission creates middleware that requires at least one of the
+// given permissions
+func RequireAnyPermission(permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userPerms, ok := c.Locals("permissions").([]string)
+		if !ok {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Access denied",
+			})
+		}
+
+		for _, required := range permissions {
+			if common.HasPermission(userPerms, required) {
+				return c.Next()
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Insufficient permissions",
+		})
+	}
+}
+
 // RequireScopes creates middleware that requires specific scopes (for service auth)
 func RequireScopes(scopes ...string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -282,6 +313,7 @@ func RequireScopes(scopes ...string) fiber.Handler {
 // OptionalAuth creates middleware that sets user context if token is present
 // but doesn't require authentication
 func OptionalAuth(config AuthConfig) fiber.Handler {
+
 	return func(c *fiber.Ctx) error {
 		token := extractTokenFromRequest(c, config.TokenLookup, config.AuthScheme)
 		if token == "" {
@@ -294,7 +326,7 @@ func OptionalAuth(config AuthConfig) fiber.Handler {
 		if config.Validator != nil {
 			claims, err = config.Validator(token)
 		} else {
-			claims, err = validateToken(token, config.Secret)
+			claims, err = validateToken(token, config.keyFunc(), config.parserOptions()...)
 		}
 
 		if err == nil && claims != nil {
@@ -347,13 +379,8 @@ func extractFromHeader(c *fiber.Ctx, header, scheme string) string {
 	return auth
 }
 
-func validateToken(tokenString, secret string) (*TokenClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid token signing method")
-		}
-		return []byte(secret), nil
-	})
+func validateToken(tokenString string, keyFunc jwt.Keyfunc, opts ...jwt.ParserOption) (*TokenClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, keyFunc, opts...)
 
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
@@ -364,6 +391,11 @@ func validateToken(tokenString, secret string) (*TokenClaims, error) {
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid token claims")
 	}
 
+	// Refresh tokens only renew token pairs
+	if claims.TokenType == TokenTypeRefresh {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid token type")
+	}
+
 	if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(time.Now()) {
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "Token expired")
 	}
@@ -371,13 +403,8 @@ func validateToken(tokenString, secret string) (*TokenClaims, error) {
 	return claims, nil
 }
 
-func validateServiceToken(tokenString, secret string) (*ServiceTokenClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &ServiceTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid token signing method")
-		}
-		return []byte(secret), nil
-	})
+func validateServiceToken(tokenString string, keyFunc jwt.Keyfunc, opts ...jwt.ParserOption) (*ServiceTokenClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ServiceTokenClaims{}, keyFunc, opts...)
 
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
@@ -458,15 +485,9 @@ func HasRole(c *fiber.Ctx, role string) bool {
 	return false
 }
 
-// HasPermission checks if user has specific permission
+// HasPermission checks if user has specific permission, honoring wildcards
 func HasPermission(c *fiber.Ctx, permission string) bool {
-	perms := GetPermissionsFromContext(c)
-	for _, p := range perms {
-		if p == permission {
-			return true
-		}
-	}
-	return false
+	return common.HasPermission(GetPermissionsFromContext(c), permission)
 }
 
 // HasScope checks if service has specific scope
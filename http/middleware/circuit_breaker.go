@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/breaker"
+	"github.com/minisource/go-common/response"
+)
+
+// CircuitBreakerConfig configures the circuit breaker middleware
+type CircuitBreakerConfig struct {
+	// Breakers holds the breakers (default: a group named "http" with
+	// breaker.DefaultConfig)
+	Breakers *breaker.Group
+	// KeyFunc picks the breaker of a request (default: one breaker for
+	// every request passing the middleware). Keep the set of keys bounded
+	KeyFunc func(c *fiber.Ctx) string
+	// Skip bypasses the breaker for a request
+	Skip func(c *fiber.Ctx) bool
+	// IsFailure reports whether a handled request counts as a failure
+	// (default: a 5xx status, or an error that maps to one)
+	IsFailure func(c *fiber.Ctx, err error) bool
+}
+
+// CircuitBreaker fails requests fast with 503 and Retry-After while the
+// handlers behind it keep failing, e.g. because their database is down,
+// instead of letting every request wait for a timeout
+func CircuitBreaker(config CircuitBreakerConfig) fiber.Handler {
+	if config.Breakers == nil {
+		config.Breakers = breaker.NewGroup("http", breaker.DefaultConfig())
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = isServerFailure
+	}
+
+	return func(c *fiber.Ctx) error {
+		if config.Skip != nil && config.Skip(c) {
+			return c.Next()
+		}
+
+		key := ""
+		if config.KeyFunc != nil {
+			key = config.KeyFunc(c)
+		}
+		cb := config.Breakers.Get(key)
+
+		done, err := cb.Allow()
+		if err != nil {
+			if retryAfter := cb.RetryAfter(); retryAfter > 0 {
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			}
+			return response.ServiceUnavailable(c, "service temporarily unavailable")
+		}
+
+		err = c.Next()
+		done(!config.IsFailure(c, err))
+		return err
+	}
+}
+
+// isServerFailure reports whether the request ended with a 5xx status
+func isServerFailure(c *fiber.Ctx, err error) bool {
+	if err == nil {
+		return c.Response().StatusCode() >= fiber.StatusInternalServerError
+	}
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return fe.Code >= fiber.StatusInternalServerError
+	}
+	return true
+}
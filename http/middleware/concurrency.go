@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	helper "github.com/minisource/go-common/http/helper"
+	"github.com/minisource/go-common/limiter"
+)
+
+// ConcurrencyLimit sheds requests with 503 when the gate (a
+// limiter.ConcurrencyLimiter or limiter.AdaptiveLimiter) is saturated
+func ConcurrencyLimit(gate limiter.Gate) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		release, err := gate.Acquire(c.UserContext())
+		if err != nil {
+			c.Set(fiber.HeaderRetryAfter, "1")
+			httpResponse := helper.GenerateBaseResponseWithError(nil, false, helper.LimiterError, err)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(httpResponse)
+		}
+		defer release()
+		return c.Next()
+	}
+}
@@ -0,0 +1,354 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ========================================
+// JWKS
+// ========================================
+
+// JWKSConfig configures a JWKS key set
+type JWKSConfig struct {
+	// URL of the JSON Web Key Set, e.g. https://idp/.well-known/jwks.json
+	URL string
+	// RefreshInterval between background refreshes (default: 1h)
+	RefreshInterval time.Duration
+	// MinRefreshInterval limits refreshes triggered by unknown key IDs,
+	// so forged kids cannot flood the IdP (default: 1m)
+	MinRefreshInterval time.Duration
+	// FailureBackoff limits retries after a failed fetch; it is shorter than
+	// MinRefreshInterval so an IdP outage is recovered from quickly
+	// (default: 5s, at most MinRefreshInterval)
+	FailureBackoff time.Duration
+	// HTTPTimeout is the timeout for fetching the key set (default: 5s)
+	HTTPTimeout time.Duration
+}
+
+// maxJWKSSize bounds the key set body read from the IdP
+const maxJWKSSize = 1 << 20
+
+// JWKS caches the keys of a JSON Web Key Set and refreshes them in the
+// background, so keys rotated by the IdP are picked up without a restart
+type JWKS struct {
+	cfg    JWKSConfig
+	client *http.Client
+
+	mu          sync.RWMutex
+	keys      map[string]interface{}
+	nextFetch time.Time
+	refreshMu sync.Mutex
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewJWKS fetches the key set and starts refreshing it; call Stop to end
+// the background refresh. The initial fetch error is returned with a
+// usable key set that retries on the next lookup
+func NewJWKS(cfg JWKSConfig) (*JWKS, error) {
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = time.Hour
+	}
+	if cfg.MinRefreshInterval <= 0 {
+		cfg.MinRefreshInterval = time.Minute
+	}
+	if cfg.FailureBackoff <= 0 {
+		cfg.FailureBackoff = 5 * time.Second
+	}
+	if cfg.FailureBackoff > cfg.MinRefreshInterval {
+		cfg.FailureBackoff = cfg.MinRefreshInterval
+	}
+	if cfg.HTTPTimeout <= 0 {
+		cfg.HTTPTimeout = 5 * time.Second
+	}
+
+	j := &JWKS{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.HTTPTimeout},
+		keys:   make(map[string]interface{}),
+		stop:   make(chan struct{}),
+	}
+	err := j.Refresh(context.Background())
+
+	go func() {
+		ticker := time.NewTicker(cfg.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_ = j.Refresh(context.Background())
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+
+	return j, err
+}
+
+// Stop ends the background refresh
+func (j *JWKS) Stop() {
+	j.once.Do(func() { close(j.stop) })
+}
+
+// Key returns the key with the given ID, refreshing the set once per
+// MinRefreshInterval when the ID is unknown, or once per FailureBackoff
+// while fetches fail
+func (j *JWKS) Key(kid string) (interface{}, error) {
+	if key, ok := j.lookup(kid); ok {
+		return key, nil
+	}
+	if j.refreshedRecently() {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	j.refreshMu.Lock()
+	defer j.refreshMu.Unlock()
+
+	// Callers waiting for the same refresh don't fetch the set again
+	if !j.refreshedRecently() {
+		if err := j.refresh(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	if key, ok := j.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func (j *JWKS) lookup(kid string) (interface{}, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+func (j *JWKS) refreshedRecently() bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return time.Now().Before(j.nextFetch)
+}
+
+// Refresh fetches the key set now
+func (j *JWKS) Refresh(ctx context.Context) error {
+	j.refreshMu.Lock()
+	defer j.refreshMu.Unlock()
+	return j.refresh(ctx)
+}
+
+// refresh fetches the key set; the caller holds refreshMu
+func (j *JWKS) refresh(ctx context.Context) error {
+	keys, err := j.fetch(ctx)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err != nil {
+		j.nextFetch = time.Now().Add(j.cfg.FailureBackoff)
+		return err
+	}
+	j.keys = keys
+	j.nextFetch = time.Now().Add(j.cfg.MinRefreshInterval)
+	return nil
+}
+
+// fetch downloads and parses the key set
+func (j *JWKS) fetch(ctx context.Context) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.cfg.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSSize)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// Skip keys of unsupported types instead of failing the set
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+// jsonWebKey is a JWK as defined by RFC 7517
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC and OKP
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey decodes an asymmetric key. Symmetric ("oct") keys are rejected:
+// anyone able to read the set could sign tokens with them, so HMAC keys are
+// only accepted from AuthConfig.Keys and AuthConfig.Secret
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBase64URL(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBase64URL(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBase64URL(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBase64URL(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return key, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBase64URL(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+// ========================================
+// Key Resolution
+// ========================================
+
+// keyFunc resolves the verification key of a token from its kid header:
+// Keys first, then the JWKS. Tokens without a kid are verified with Secret
+func (config AuthConfig) keyFunc() jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		var key interface{}
+		switch {
+		case kid != "" && config.Keys[kid] != nil:
+			key = config.Keys[kid]
+		case kid != "" && config.JWKS != nil:
+			found, err := config.JWKS.Key(kid)
+			if err != nil {
+				return nil, fiber.NewError(fiber.StatusUnauthorized, "Unknown signing key")
+			}
+			key = found
+		case config.Secret != "":
+			key = []byte(config.Secret)
+		default:
+			return nil, fiber.NewError(fiber.StatusUnauthorized, "Unknown signing key")
+		}
+
+		if secret, ok := key.(string); ok {
+			key = []byte(secret)
+		}
+		if !methodMatchesKey(token.Method, key) {
+			return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid token signing method")
+		}
+		return key, nil
+	}
+}
+
+// parserOptions requires the configured issuer and audience
+func (config AuthConfig) parserOptions() []jwt.ParserOption {
+	var opts []jwt.ParserOption
+	if config.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		opts = append(opts, jwt.WithAudience(config.Audience))
+	}
+	return opts
+}
+
+// methodMatchesKey prevents algorithm confusion, e.g. an HS256 token signed
+// with a public RSA key
+func methodMatchesKey(method jwt.SigningMethod, key interface{}) bool {
+	switch key.(type) {
+	case []byte:
+		_, ok := method.(*jwt.SigningMethodHMAC)
+		return ok
+	case *rsa.PublicKey:
+		switch method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return true
+		}
+	case *ecdsa.PublicKey:
+		_, ok := method.(*jwt.SigningMethodECDSA)
+		return ok
+	case ed25519.PublicKey:
+		_, ok := method.(*jwt.SigningMethodEd25519)
+		return ok
+	}
+	return false
+}
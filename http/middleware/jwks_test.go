@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jwksServer publishes RSA keys by kid and counts fetches
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    map[string]*rsa.PrivateKey
+	fetches atomic.Int32
+	down    atomic.Bool
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	s := &jwksServer{keys: make(map[string]*rsa.PrivateKey)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		if s.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		set := struct {
+			Keys []jsonWebKey `json:"keys"`
+		}{}
+		for kid, key := range s.keys {
+			set.Keys = append(set.Keys, jsonWebKey{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// rotate publishes a new key under kid, replacing all others
+func (s *jwksServer) rotate(t *testing.T, kid string) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	s.mu.Lock()
+	s.keys = map[string]*rsa.PrivateKey{kid: key}
+	s.mu.Unlock()
+	return key
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}) string {
+	return signClaims(t, method, kid, key, jwt.RegisteredClaims{})
+}
+
+func signClaims(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, registered jwt.RegisteredClaims) string {
+	registered.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Hour))
+	token := jwt.NewWithClaims(method, TokenClaims{UserID: "user-1", RegisteredClaims: registered})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func authStatus(t *testing.T, config AuthConfig, token string) int {
+	app := fiber.New()
+	app.Use(AuthMiddleware(config))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString(GetUserIDFromContext(c)) })
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestJWKSPicksUpRotatedKey(t *testing.T) {
+	server := newJWKSServer(t)
+	old := server.rotate(t, "k1")
+	jwks, err := NewJWKS(JWKSConfig{URL: server.URL, MinRefreshInterval: time.Millisecond})
+	require.NoError(t, err)
+	defer jwks.Stop()
+
+	config := AuthConfig{Enabled: true, JWKS: jwks}
+	assert.Equal(t, fiber.StatusOK, authStatus(t, config, signToken(t, jwt.SigningMethodRS256, "k1", old)))
+
+	next := server.rotate(t, "k2")
+	time.Sleep(2 * time.Millisecond)
+	assert.Equal(t, fiber.StatusOK, authStatus(t, config, signToken(t, jwt.SigningMethodRS256, "k2", next)))
+	assert.Equal(t, fiber.StatusUnauthorized, authStatus(t, config, signToken(t, jwt.SigningMethodRS256, "k1", old)),
+		"keys removed from the set stop working")
+}
+
+func TestJWKSLimitsRefreshesForUnknownKids(t *testing.T) {
+	server := newJWKSServer(t)
+	server.rotate(t, "k1")
+	jwks, err := NewJWKS(JWKSConfig{URL: server.URL})
+	require.NoError(t, err)
+	defer jwks.Stop()
+
+	_, err = jwks.Key("forged")
+	assert.ErrorContains(t, err, "unknown key id")
+	assert.Equal(t, int32(1), server.fetches.Load(), "refreshed within MinRefreshInterval")
+
+	// Concurrent lookups past the interval share one refresh
+	jwks.mu.Lock()
+	jwks.nextFetch = time.Time{}
+	jwks.mu.Unlock()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = jwks.Key("forged")
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), server.fetches.Load())
+}
+
+func TestJWKSRetriesAfterFailedFetch(t *testing.T) {
+	server := newJWKSServer(t)
+	key := server.rotate(t, "k1")
+	server.down.Store(true)
+	jwks, err := NewJWKS(JWKSConfig{URL: server.URL, MinRefreshInterval: time.Hour, FailureBackoff: time.Millisecond})
+	require.Error(t, err)
+	defer jwks.Stop()
+
+	_, err = jwks.Key("k1")
+	assert.ErrorContains(t, err, "unknown key id", "failed fetches back off")
+	assert.Equal(t, int32(1), server.fetches.Load())
+
+	server.down.Store(false)
+	time.Sleep(2 * time.Millisecond)
+	got, err := jwks.Key("k1")
+	require.NoError(t, err)
+	assert.True(t, key.PublicKey.Equal(got))
+}
+
+func TestJWKSRejectsOversizedSet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"keys":[`))
+		_, _ = w.Write(bytes.Repeat([]byte(" "), maxJWKSSize))
+		_, _ = w.Write([]byte(`]}`))
+	}))
+	defer server.Close()
+
+	jwks, err := NewJWKS(JWKSConfig{URL: server.URL})
+	defer jwks.Stop()
+	assert.ErrorContains(t, err, "failed to parse JWKS")
+}
+
+func TestJWKSIgnoresSymmetricKeys(t *testing.T) {
+	secret := []byte("published-in-the-key-set")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"keys":[{"kty":"oct","kid":"hs","use":"sig","k":"` +
+			base64.RawURLEncoding.EncodeToString(secret) + `"}]}`))
+	}))
+	defer server.Close()
+	jwks, err := NewJWKS(JWKSConfig{URL: server.URL})
+	require.NoError(t, err)
+	defer jwks.Stop()
+
+	_, err = jwks.Key("hs")
+	assert.ErrorContains(t, err, "unknown key id")
+	config := AuthConfig{Enabled: true, JWKS: jwks}
+	assert.Equal(t, fiber.StatusUnauthorized, authStatus(t, config, signToken(t, jwt.SigningMethodHS256, "hs", secret)))
+}
+
+func TestJWKSChecksIssuerAndAudience(t *testing.T) {
+	server := newJWKSServer(t)
+	key := server.rotate(t, "k1")
+	jwks, err := NewJWKS(JWKSConfig{URL: server.URL})
+	require.NoError(t, err)
+	defer jwks.Stop()
+
+	config := AuthConfig{Enabled: true, JWKS: jwks, Issuer: "https://idp", Audience: "orders"}
+	sign := func(iss string, aud ...string) string {
+		return signClaims(t, jwt.SigningMethodRS256, "k1", key, jwt.RegisteredClaims{Issuer: iss, Audience: aud})
+	}
+
+	assert.Equal(t, fiber.StatusOK, authStatus(t, config, sign("https://idp", "billing", "orders")))
+	assert.Equal(t, fiber.StatusUnauthorized, authStatus(t, config, sign("https://idp", "billing")),
+		"tokens the IdP issued to other clients are rejected")
+	assert.Equal(t, fiber.StatusUnauthorized, authStatus(t, config, sign("https://idp")))
+	assert.Equal(t, fiber.StatusUnauthorized, authStatus(t, config, sign("https://other", "orders")))
+}
+
+func TestAuthRejectsUnknownKid(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	config := AuthConfig{Enabled: true, Keys: map[string]interface{}{"k1": &key.PublicKey}}
+
+	assert.Equal(t, fiber.StatusOK, authStatus(t, config, signToken(t, jwt.SigningMethodRS256, "k1", key)))
+	assert.Equal(t, fiber.StatusUnauthorized, authStatus(t, config, signToken(t, jwt.SigningMethodRS256, "k2", key)))
+}
+
+func TestAuthRejectsHMACTokenForRSAKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	config := AuthConfig{Enabled: true, Keys: map[string]interface{}{"k1": &key.PublicKey}}
+
+	// The public key is known to everyone; signing an HS256 token with it
+	// must not pass as an RS256 one
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, authStatus(t, config, signToken(t, jwt.SigningMethodHS256, "k1", public)))
+	assert.False(t, methodMatchesKey(jwt.SigningMethodHS256, &key.PublicKey))
+	assert.True(t, methodMatchesKey(jwt.SigningMethodPS256, &key.PublicKey))
+}
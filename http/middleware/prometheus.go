@@ -1,14 +1,98 @@
 package middleware
 
 import (
+	"errors"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	helper "github.com/minisource/go-common/http/helper"
 	"github.com/minisource/go-common/metrics"
 )
 
+// Route labels that are not route templates
+const (
+	// UnmatchedRoute labels requests no route handled, e.g. 404 scans
+	UnmatchedRoute = "unmatched"
+	// OverflowRoute labels routes seen after MaxRoutes was reached
+	OverflowRoute = "other"
+)
+
+// PrometheusConfig configures the HTTP metrics middleware
+type PrometheusConfig struct {
+	// Registry receives the http_server_* collectors (default:
+	// metrics.DefaultRegistry)
+	Registry *metrics.Registry
+	// Metrics overrides the collectors built from Registry
+	Metrics *metrics.HTTPServerMetrics
+	// SkipPaths are not recorded, e.g. /metrics and /health
+	SkipPaths []string
+	// Skip bypasses recording for a request
+	Skip func(c *fiber.Ctx) bool
+	// MaxRoutes caps the distinct route labels; later routes are recorded
+	// as "other" (default: 500)
+	MaxRoutes int
+}
+
+// DefaultPrometheusConfig records on the global registry and skips the
+// metrics and health endpoints
+func DefaultPrometheusConfig() PrometheusConfig {
+	return PrometheusConfig{
+		SkipPaths: []string{metrics.DefaultPath, "/health", "/ready", "/live"},
+		MaxRoutes: 500,
+	}
+}
+
+// PrometheusMiddleware records RED metrics for every request: count,
+// duration and response size by method, route template and status class,
+// and the number of requests in flight. Raw paths never become labels
+func PrometheusMiddleware(config PrometheusConfig) fiber.Handler {
+	if config.Metrics == nil {
+		if config.Registry == nil {
+			config.Registry = metrics.DefaultRegistry()
+		}
+		config.Metrics = metrics.NewHTTPServerMetrics(config.Registry)
+	}
+	if config.MaxRoutes <= 0 {
+		config.MaxRoutes = 500
+	}
+	skip := make(map[string]bool, len(config.SkipPaths))
+	for _, p := range config.SkipPaths {
+		skip[p] = true
+	}
+	routes := &routeLabels{max: config.MaxRoutes, seen: make(map[string]struct{})}
+	m := config.Metrics
+
+	return func(c *fiber.Ctx) error {
+		if skip[c.Path()] || (config.Skip != nil && config.Skip(c)) {
+			return c.Next()
+		}
+
+		start := time.Now()
+		method := c.Method()
+		inFlight := m.InFlight.WithLabelValues(method)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		// c.Route() only changes when a route below this middleware matches
+		self := c.Route()
+		err := c.Next()
+
+		route := UnmatchedRoute
+		if r := c.Route(); r != self {
+			route = routes.label(r.Path)
+		}
+		m.Observe(method, route, responseStatus(c, err), responseSize(c), time.Since(start))
+		return err
+	}
+}
 
+// Prometheus records request durations on metrics.HttpDuration by raw
+// route and status code
+//
+// Deprecated: use PrometheusMiddleware, which records the standard
+// http_server_* metrics with bounded labels
 func Prometheus() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		start := time.Now()
@@ -21,3 +105,50 @@ func Prometheus() fiber.Handler {
 		return err
 	}
 }
+
+// responseStatus returns the status the error handler will send for err
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return fe.Code
+	}
+	return helper.TranslateErrorToStatusCode(err)
+}
+
+// responseSize prefers the declared length so streamed bodies are counted
+func responseSize(c *fiber.Ctx) int {
+	if n := c.Response().Header.ContentLength(); n > 0 {
+		return n
+	}
+	return len(c.Response().Body())
+}
+
+// routeLabels bounds the number of distinct route labels
+type routeLabels struct {
+	mu   sync.RWMutex
+	max  int
+	seen map[string]struct{}
+}
+
+func (r *routeLabels) label(route string) string {
+	r.mu.RLock()
+	_, ok := r.seen[route]
+	r.mu.RUnlock()
+	if ok {
+		return route
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.seen[route]; ok {
+		return route
+	}
+	if len(r.seen) >= r.max {
+		return OverflowRoute
+	}
+	r.seen[route] = struct{}{}
+	return route
+}
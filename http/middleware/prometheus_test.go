@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func prometheusApp(t *testing.T, maxRoutes int) (*fiber.App, *metrics.HTTPServerMetrics) {
+	m := metrics.NewHTTPServerMetrics(metrics.NewRegistry(metrics.RegistryConfig{}))
+	app := fiber.New()
+	app.Use(PrometheusMiddleware(PrometheusConfig{Metrics: m, MaxRoutes: maxRoutes, SkipPaths: []string{"/health"}}))
+	app.Get("/users/:id", func(c *fiber.Ctx) error { return c.SendString(c.Params("id")) })
+	app.Get("/orders/:id", func(c *fiber.Ctx) error { return fiber.ErrConflict })
+	app.Get("/health", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	return app, m
+}
+
+func doGet(t *testing.T, app *fiber.App, path string) int {
+	resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestPrometheusMiddlewareLabelsRouteTemplate(t *testing.T) {
+	app, m := prometheusApp(t, 0)
+
+	doGet(t, app, "/users/1")
+	doGet(t, app, "/users/2")
+	assert.Equal(t, fiber.StatusConflict, doGet(t, app, "/orders/7"))
+	doGet(t, app, "/health")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.Requests.WithLabelValues("GET", "/users/:id", "2xx")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Requests.WithLabelValues("GET", "/orders/:id", "4xx")), "returned errors use the handler status")
+	assert.Equal(t, 2, testutil.CollectAndCount(m.Requests), "raw paths and skipped paths are not labels")
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.InFlight.WithLabelValues("GET")))
+}
+
+func TestPrometheusMiddlewareUnmatchedRoute(t *testing.T) {
+	app, m := prometheusApp(t, 0)
+
+	assert.Equal(t, fiber.StatusNotFound, doGet(t, app, "/wp-login.php"))
+	doGet(t, app, "/.env")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.Requests.WithLabelValues("GET", UnmatchedRoute, "4xx")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.Requests))
+}
+
+func TestPrometheusMiddlewareOverflowRoute(t *testing.T) {
+	app, m := prometheusApp(t, 1)
+
+	doGet(t, app, "/users/1")
+	doGet(t, app, "/orders/1")
+	doGet(t, app, "/users/2")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.Requests.WithLabelValues("GET", "/users/:id", "2xx")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Requests.WithLabelValues("GET", OverflowRoute, "4xx")))
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/limiter"
+)
+
+// RateLimitConfig configures the keyed rate limit middleware
+type RateLimitConfig struct {
+	Limiter limiter.Limiter
+	// KeyFunc extracts the key (default: client IP)
+	KeyFunc limiter.KeyFunc
+	// Skip bypasses limiting for a request
+	Skip func(c *fiber.Ctx) bool
+	// DisableHeaders stops RateLimit-* headers on allowed responses
+	DisableHeaders bool
+}
+
+// RateLimit rejects requests over the limit of their key with 429 and
+// reports the limit state in RateLimit-* headers
+func RateLimit(config RateLimitConfig) fiber.Handler {
+	if config.KeyFunc == nil {
+		config.KeyFunc = limiter.KeyByIP()
+	}
+
+	return func(c *fiber.Ctx) error {
+		if config.Skip != nil && config.Skip(c) {
+			return c.Next()
+		}
+
+		decision, err := config.Limiter.Allow(config.KeyFunc(c))
+		if err != nil {
+			// Fail open: a broken limiter must not take the service down
+			return c.Next()
+		}
+		if !decision.Allowed {
+			return limiter.Reject(c, decision)
+		}
+		if !config.DisableHeaders {
+			limiter.SetHeaders(c, decision)
+		}
+		return c.Next()
+	}
+}
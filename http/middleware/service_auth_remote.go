@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/common"
 	"github.com/minisource/go-common/logging"
 )
 
@@ -79,7 +80,7 @@ func RemoteServiceAuthMiddleware(cfg RemoteServiceAuthConfig) fiber.Handler {
 		// Check cache first
 		if cached := remoteTokenCache.get(token); cached != nil {
 			// Check scope if required
-			if cfg.RequiredScope != "" && !hasScopeInList(cached.Scopes, cfg.RequiredScope) {
+			if cfg.RequiredScope != "" && !common.HasPermission(cached.Scopes, cfg.RequiredScope) {
 				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 					"error": "Insufficient permissions",
 				})
@@ -121,7 +122,7 @@ func RemoteServiceAuthMiddleware(cfg RemoteServiceAuthConfig) fiber.Handler {
 		remoteTokenCache.set(token, validation, ttl)
 
 		// Check scope if required
-		if cfg.RequiredScope != "" && !hasScopeInList(validation.Scopes, cfg.RequiredScope) {
+		if cfg.RequiredScope != "" && !common.HasPermission(validation.Scopes, cfg.RequiredScope) {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"error":    "Insufficient permissions",
 				"required": cfg.RequiredScope,
@@ -148,7 +149,7 @@ func RequireScope(scope string) fiber.Handler {
 			})
 		}
 
-		if !hasScopeInList(scopes, scope) {
+		if !common.HasPermission(scopes, scope) {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"error":    "Insufficient permissions",
 				"required": scope,
@@ -159,28 +160,6 @@ func RequireScope(scope string) fiber.Handler {
 	}
 }
 
-// hasScopeInList checks if the given scopes contain the required scope
-func hasScopeInList(scopes []string, required string) bool {
-	for _, scope := range scopes {
-		// Check for wildcard scope
-		if scope == "*" {
-			return true
-		}
-		// Check exact match
-		if scope == required {
-			return true
-		}
-		// Check resource wildcard (e.g., "notifications:*" matches "notifications:send")
-		parts := strings.Split(required, ":")
-		if len(parts) == 2 {
-			if scope == parts[0]+":*" {
-				return true
-			}
-		}
-	}
-	return false
-}
-
 func (c *TokenValidationCache) get(token string) *TokenValidationResult {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
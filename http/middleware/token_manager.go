@@ -0,0 +1,254 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Token types set on the tokenType claim of issued tokens
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// TokenManagerConfig configures token issuance
+type TokenManagerConfig struct {
+	// SigningMethod of issued tokens (default: HS256)
+	SigningMethod jwt.SigningMethod
+	// SigningKey is a []byte or string secret for HMAC methods, otherwise
+	// an *rsa.PrivateKey, *ecdsa.PrivateKey or ed25519.PrivateKey
+	SigningKey interface{}
+	// KeyID is set as the kid header so AuthConfig.Keys or a JWKS can
+	// pick the verification key
+	KeyID string
+	// Issuer and Audience are set on issued tokens and required on
+	// refresh tokens
+	Issuer   string
+	Audience []string
+	// AccessTTL is the lifetime of access tokens (default: 15m)
+	AccessTTL time.Duration
+	// RefreshTTL is the lifetime of refresh tokens (default: 7d)
+	RefreshTTL time.Duration
+	// OnRefresh returns the claims of the new token pair for a verified
+	// refresh token, e.g. to reload roles or reject revoked sessions by
+	// returning an error. By default the refresh token claims are reused
+	OnRefresh func(ctx context.Context, claims *TokenClaims) (*TokenClaims, error)
+}
+
+// DefaultTokenManagerConfig returns default token manager configuration
+func DefaultTokenManagerConfig(secret string) TokenManagerConfig {
+	return TokenManagerConfig{
+		SigningMethod: jwt.SigningMethodHS256,
+		SigningKey:    []byte(secret),
+		AccessTTL:     15 * time.Minute,
+		RefreshTTL:    7 * 24 * time.Hour,
+	}
+}
+
+// TokenPair is an access token with the refresh token that renews it
+type TokenPair struct {
+	AccessToken      string    `json:"accessToken"`
+	RefreshToken     string    `json:"refreshToken"`
+	TokenType        string    `json:"tokenType"`
+	ExpiresIn        int64     `json:"expiresIn"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+}
+
+// RefreshRequest is the body of the refresh endpoint
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// TokenManager issues access/refresh token pairs from TokenClaims and
+// renews them from refresh tokens
+type TokenManager struct {
+	config    TokenManagerConfig
+	verifyKey interface{}
+}
+
+// NewTokenManager creates a token manager; it fails when the signing key
+// does not suit the signing method
+func NewTokenManager(config TokenManagerConfig) (*TokenManager, error) {
+	if config.SigningMethod == nil {
+		config.SigningMethod = jwt.SigningMethodHS256
+	}
+	if config.AccessTTL <= 0 {
+		config.AccessTTL = 15 * time.Minute
+	}
+	if config.RefreshTTL <= 0 {
+		config.RefreshTTL = 7 * 24 * time.Hour
+	}
+
+	var verifyKey interface{}
+	switch key := config.SigningKey.(type) {
+	case string:
+		config.SigningKey = []byte(key)
+		verifyKey = []byte(key)
+	case []byte:
+		verifyKey = key
+	case crypto.Signer:
+		verifyKey = key.Public()
+	default:
+		return nil, errors.New("token manager: unsupported signing key")
+	}
+	if secret, ok := verifyKey.([]byte); ok && len(secret) == 0 {
+		return nil, errors.New("token manager: empty signing key")
+	}
+	if !methodMatchesKey(config.SigningMethod, verifyKey) {
+		return nil, errors.New("token manager: signing key does not match signing method")
+	}
+
+	return &TokenManager{config: config, verifyKey: verifyKey}, nil
+}
+
+// AuthConfig returns a DefaultAuthConfig that validates the access tokens
+// of this manager, requiring its issuer and first audience
+func (m *TokenManager) AuthConfig() AuthConfig {
+	config := DefaultAuthConfig()
+	if m.config.KeyID != "" {
+		config.Keys = map[string]interface{}{m.config.KeyID: m.verifyKey}
+	} else if secret, ok := m.verifyKey.([]byte); ok {
+		config.Secret = string(secret)
+	}
+	config.Issuer = m.config.Issuer
+	if len(m.config.Audience) > 0 {
+		config.Audience = m.config.Audience[0]
+	}
+	return config
+}
+
+// Issue signs an access token and a refresh token for claims. UserID
+// becomes the subject unless one is set
+func (m *TokenManager) Issue(claims TokenClaims) (*TokenPair, error) {
+	now := time.Now()
+
+	access, accessExp, err := m.sign(claims, TokenTypeAccess, now, m.config.AccessTTL)
+	if err != nil {
+		return nil, err
+	}
+	refresh, refreshExp, err := m.sign(claims, TokenTypeRefresh, now, m.config.RefreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(m.config.AccessTTL.Seconds()),
+		ExpiresAt:        accessExp,
+		RefreshExpiresAt: refreshExp,
+	}, nil
+}
+
+func (m *TokenManager) sign(claims TokenClaims, tokenType string, now time.Time, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := now.Add(ttl)
+
+	claims.TokenType = tokenType
+	claims.ID = uuid.NewString()
+	claims.Issuer = m.config.Issuer
+	claims.Audience = m.config.Audience
+	if claims.Subject == "" {
+		claims.Subject = claims.UserID
+	}
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.NotBefore = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+
+	token := jwt.NewWithClaims(m.config.SigningMethod, &claims)
+	if m.config.KeyID != "" {
+		token.Header["kid"] = m.config.KeyID
+	}
+	signed, err := token.SignedString(m.config.SigningKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// VerifyRefreshToken validates a refresh token and returns its claims
+func (m *TokenManager) VerifyRefreshToken(tokenString string) (*TokenClaims, error) {
+	opts := []jwt.ParserOption{jwt.WithExpirationRequired()}
+	if m.config.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.config.Issuer))
+	}
+	if len(m.config.Audience) > 0 {
+		opts = append(opts, jwt.WithAudience(m.config.Audience...))
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, m.keyFunc, opts...)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid refresh token")
+	}
+
+	claims, ok := token.Claims.(*TokenClaims)
+	if !ok || !token.Valid {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid token claims")
+	}
+
+	if claims.TokenType != TokenTypeRefresh {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid token type")
+	}
+
+	return claims, nil
+}
+
+func (m *TokenManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	if kid, _ := token.Header["kid"].(string); kid != m.config.KeyID {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Unknown signing key")
+	}
+	if !methodMatchesKey(token.Method, m.verifyKey) {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid token signing method")
+	}
+	return m.verifyKey, nil
+}
+
+// Refresh verifies a refresh token and issues a new token pair. The
+// refresh token is rotated; rejecting reused ones needs OnRefresh to track
+// the token IDs
+func (m *TokenManager) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	claims, err := m.VerifyRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.config.OnRefresh != nil {
+		claims, err = m.config.OnRefresh(ctx, claims)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	next := *claims
+	next.RegisteredClaims = jwt.RegisteredClaims{Subject: claims.Subject}
+	return m.Issue(next)
+}
+
+// RefreshHandler is the Fiber handler of the refresh endpoint. It reads
+// a RefreshRequest body and responds with a TokenPair
+func (m *TokenManager) RefreshHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req RefreshRequest
+		if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Refresh token is required",
+			})
+		}
+
+		pair, err := m.Refresh(c.UserContext(), req.RefreshToken)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid refresh token",
+			})
+		}
+
+		return c.JSON(pair)
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTokenManager(t *testing.T) *TokenManager {
+	config := DefaultTokenManagerConfig("test-secret")
+	config.Issuer = "auth-service"
+	config.Audience = []string{"api"}
+	m, err := NewTokenManager(config)
+	require.NoError(t, err)
+	return m
+}
+
+func tokenID(t *testing.T, token string) string {
+	claims := &TokenClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(token, claims)
+	require.NoError(t, err)
+	return claims.ID
+}
+
+func TestAuthMiddlewareRejectsRefreshToken(t *testing.T) {
+	m := newTestTokenManager(t)
+	pair, err := m.Issue(TokenClaims{UserID: "user-1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, authStatus(t, m.AuthConfig(), pair.AccessToken))
+	assert.Equal(t, fiber.StatusUnauthorized, authStatus(t, m.AuthConfig(), pair.RefreshToken))
+}
+
+func TestVerifyRefreshTokenRejectsAccessToken(t *testing.T) {
+	m := newTestTokenManager(t)
+	pair, err := m.Issue(TokenClaims{UserID: "user-1"})
+	require.NoError(t, err)
+
+	_, err = m.VerifyRefreshToken(pair.AccessToken)
+	assert.Error(t, err)
+
+	claims, err := m.VerifyRefreshToken(pair.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+}
+
+func TestRefreshRotatesTokenID(t *testing.T) {
+	m := newTestTokenManager(t)
+	pair, err := m.Issue(TokenClaims{UserID: "user-1", Roles: []string{"admin"}})
+	require.NoError(t, err)
+
+	next, err := m.Refresh(context.Background(), pair.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, tokenID(t, pair.RefreshToken), tokenID(t, next.RefreshToken))
+	assert.NotEqual(t, tokenID(t, pair.AccessToken), tokenID(t, next.AccessToken))
+
+	claims, err := m.VerifyRefreshToken(next.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, []string{"admin"}, claims.Roles)
+}
+
+func TestVerifyRefreshTokenChecksIssuerAndAudience(t *testing.T) {
+	m := newTestTokenManager(t)
+	for name, mutate := range map[string]func(*TokenManagerConfig){
+		"issuer":   func(c *TokenManagerConfig) { c.Issuer = "other-service" },
+		"audience": func(c *TokenManagerConfig) { c.Audience = []string{"admin"} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			config := m.config
+			mutate(&config)
+			other, err := NewTokenManager(config)
+			require.NoError(t, err)
+			pair, err := other.Issue(TokenClaims{UserID: "user-1"})
+			require.NoError(t, err)
+
+			_, err = m.VerifyRefreshToken(pair.RefreshToken)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	validation "github.com/minisource/go-common/validations"
 )
 
 // Validator wraps the validator instance
@@ -41,6 +42,9 @@ func NewValidator() *Validator {
 		return name
 	})
 
+	// Register shared custom validations
+	validation.RegisterAll(v)
+
 	return &Validator{validate: v}
 }
 
@@ -188,6 +192,14 @@ func getErrorMessage(fe validator.FieldError) string {
 		return "Value must be numeric"
 	case "mobile":
 		return "Invalid mobile number format"
+	case "national_code":
+		return "Invalid national code"
+	case "sheba":
+		return "Invalid Sheba number"
+	case "iban":
+		return "Invalid IBAN"
+	case "bank_card":
+		return "Invalid bank card number"
 	case "password":
 		return "Password must contain at least one uppercase, one lowercase, one number, and one special character"
 	default:
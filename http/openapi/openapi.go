@@ -0,0 +1,354 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ============================================
+// OpenAPI Document
+// ============================================
+
+// Version is the OpenAPI version of generated documents
+const Version = "3.0.3"
+
+// DefaultPath is where Mount serves the document
+const DefaultPath = "/openapi.json"
+
+// Document is an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is a base URL of the API
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem maps lowercase HTTP methods to operations
+type PathItem map[string]*Operation
+
+// Operation documents one route
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is a JSON request body
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a documented response
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the shared schemas
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is an authentication scheme
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Schema is a JSON schema object
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// ============================================
+// Spec Builder
+// ============================================
+
+// Config describes the API
+type Config struct {
+	Title       string
+	Version     string
+	Description string
+	Servers     []string
+	// BearerAuth documents JWT bearer authentication on every route not
+	// marked Public
+	BearerAuth bool
+}
+
+// DefaultConfig returns a config titled "API" at version 1.0.0 with bearer
+// authentication
+func DefaultConfig() Config {
+	return Config{
+		Title:      "API",
+		Version:    "1.0.0",
+		BearerAuth: true,
+	}
+}
+
+// Route documents one endpoint
+type Route struct {
+	Method string
+	// Path in Fiber syntax, e.g. /users/:id
+	Path        string
+	Summary     string
+	Tags        []string
+	OperationID string
+	// PathParams overrides the string schema of path parameters
+	PathParams map[string]*Schema
+	// Parameters lists query parameters
+	Parameters []Parameter
+	// Request is a value of the request body type, e.g. CreateUser{}; nil
+	// for routes without a body
+	Request interface{}
+	// Response is a value of the result type; nil for routes without one
+	Response interface{}
+	// Status is the success status, 200 by default
+	Status int
+	// Raw documents Response as is instead of inside the standard
+	// {result, success, resultCode} envelope
+	Raw bool
+	// Public routes need no authentication
+	Public bool
+}
+
+// Spec collects routes and builds the OpenAPI document. It is safe for
+// concurrent use
+type Spec struct {
+	mu     sync.RWMutex
+	config Config
+	routes []Route
+}
+
+// New creates an empty spec
+func New(config Config) *Spec {
+	return &Spec{config: config}
+}
+
+// Add documents a route
+func (s *Spec) Add(route Route) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes = append(s.routes, route)
+}
+
+// Routes returns the documented routes
+func (s *Spec) Routes() []Route {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Route(nil), s.routes...)
+}
+
+// Document builds the OpenAPI document of the routes added so far
+func (s *Spec) Document() *Document {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reg := newSchemaRegistry()
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       s.config.Title,
+			Version:     s.config.Version,
+			Description: s.config.Description,
+		},
+		Paths:      make(map[string]PathItem),
+		Components: Components{Schemas: reg.schemas},
+	}
+	for _, url := range s.config.Servers {
+		doc.Servers = append(doc.Servers, Server{URL: url})
+	}
+	if s.config.BearerAuth {
+		doc.Components.SecuritySchemes = map[string]SecurityScheme{
+			"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		}
+	}
+
+	for _, route := range s.routes {
+		path, params := convertPath(route.Path, route.PathParams)
+		item := doc.Paths[path]
+		if item == nil {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = s.operation(reg, route, params)
+	}
+	return doc
+}
+
+func (s *Spec) operation(reg *schemaRegistry, route Route, params []Parameter) *Operation {
+	op := &Operation{
+		Tags:        route.Tags,
+		Summary:     route.Summary,
+		OperationID: route.OperationID,
+		Parameters:  append(params, route.Parameters...),
+		Responses:   make(map[string]Response),
+	}
+	if s.config.BearerAuth && !route.Public {
+		op.Security = []map[string][]string{{"bearerAuth": {}}}
+	}
+
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonContent(reg.schemaOf(reflect.TypeOf(route.Request))),
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	var result *Schema
+	if route.Response != nil {
+		result = reg.schemaOf(reflect.TypeOf(route.Response))
+	}
+	if route.Raw {
+		resp := Response{Description: http.StatusText(status)}
+		if result != nil {
+			resp.Content = jsonContent(result)
+		}
+		op.Responses[strconv.Itoa(status)] = resp
+	} else {
+		op.Responses[strconv.Itoa(status)] = Response{
+			Description: http.StatusText(status),
+			Content:     jsonContent(envelope(result)),
+		}
+		op.Responses["default"] = Response{
+			Description: "Error",
+			Content:     jsonContent(envelope(nil)),
+		}
+	}
+	return op
+}
+
+// envelope wraps result in the {result, success, resultCode} response body
+// of the http/helper handlers
+func envelope(result *Schema) *Schema {
+	if result == nil {
+		result = &Schema{Nullable: true}
+	}
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"result":     result,
+			"success":    {Type: "boolean"},
+			"resultCode": {Type: "integer", Format: "int32"},
+			"message":    {Type: "string"},
+			"error":      {},
+			"validationErrors": {Type: "array", Items: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"property": {Type: "string"},
+					"tag":      {Type: "string"},
+					"value":    {Type: "string"},
+					"message":  {Type: "string"},
+				},
+			}},
+		},
+		Required: []string{"success", "resultCode"},
+	}
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{fiber.MIMEApplicationJSON: {Schema: schema}}
+}
+
+// convertPath turns Fiber params (:id, :id?) into OpenAPI {id} segments and
+// returns their parameters
+func convertPath(path string, schemas map[string]*Schema) (string, []Parameter) {
+	var params []Parameter
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, ":") {
+			continue
+		}
+		name := strings.TrimSuffix(seg[1:], "?")
+		schema := schemas[name]
+		if schema == nil {
+			schema = &Schema{Type: "string"}
+		}
+		segments[i] = "{" + name + "}"
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	path = strings.Join(segments, "/")
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path, params
+}
+
+// JSON returns the document encoded as JSON
+func (s *Spec) JSON() ([]byte, error) {
+	return json.Marshal(s.Document())
+}
+
+// Handler serves the document as JSON
+func (s *Spec) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(s.Document())
+	}
+}
+
+// Mount serves the document at /openapi.json on router
+func (s *Spec) Mount(router fiber.Router) {
+	router.Get(DefaultPath, s.Handler())
+}
@@ -0,0 +1,125 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAudit struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type testUser struct {
+	testAudit
+	ID       uuid.UUID         `json:"id"`
+	Email    string            `json:"email" validate:"required,email"`
+	Name     *string           `json:"name,omitempty" validate:"omitempty,min=2,max=50"`
+	Age      int               `json:"age" validate:"gte=18,lt=130"`
+	Role     string            `json:"role" validate:"oneof=admin user"`
+	Tags     []string          `json:"tags" validate:"max=5,dive,min=1"`
+	Manager  *testUser         `json:"manager"`
+	Labels   map[string]string `json:"labels"`
+	Password string            `json:"-"`
+	internal string
+}
+
+func TestSchemaFromTags(t *testing.T) {
+	reg := newSchemaRegistry()
+	ref := reg.schemaOf(reflectTypeOf[testUser]())
+	assert.Equal(t, "#/components/schemas/testUser", ref.Ref)
+
+	s := reg.schemas["testUser"]
+	require.NotNil(t, s)
+	assert.ElementsMatch(t, []string{"email"}, s.Required)
+	assert.NotContains(t, s.Properties, "Password")
+	assert.NotContains(t, s.Properties, "internal")
+
+	assert.Equal(t, "date-time", s.Properties["createdAt"].Format)
+	assert.Equal(t, "uuid", s.Properties["id"].Format)
+	assert.Equal(t, "email", s.Properties["email"].Format)
+
+	name := s.Properties["name"]
+	assert.True(t, name.Nullable)
+	assert.Equal(t, 2, *name.MinLength)
+	assert.Equal(t, 50, *name.MaxLength)
+
+	age := s.Properties["age"]
+	assert.Equal(t, 18.0, *age.Minimum)
+	assert.Equal(t, 130.0, *age.Maximum)
+	assert.True(t, age.ExclusiveMaximum)
+
+	assert.Equal(t, []interface{}{"admin", "user"}, s.Properties["role"].Enum)
+	assert.Equal(t, 5, *s.Properties["tags"].MaxItems)
+	assert.Nil(t, s.Properties["tags"].Items.MinLength)
+
+	manager := s.Properties["manager"]
+	assert.True(t, manager.Nullable)
+	assert.Equal(t, "#/components/schemas/testUser", manager.AllOf[0].Ref)
+	assert.Equal(t, "string", s.Properties["labels"].AdditionalProperties.Type)
+}
+
+type testPage[T any] struct {
+	Items []T `json:"items"`
+}
+
+func TestSchemaNameOfGenericType(t *testing.T) {
+	assert.Equal(t, "testPage_testUser", schemaName(reflectTypeOf[testPage[testUser]]()))
+	assert.Equal(t, "testUser", schemaName(reflectTypeOf[testUser]()))
+}
+
+func TestDocumentPathsAndResponses(t *testing.T) {
+	spec := New(DefaultConfig())
+	spec.Add(Route{Method: "GET", Path: "/users/:id", Response: testUser{}})
+	spec.Add(Route{Method: "POST", Path: "/users/", Request: &testUser{}, Response: testUser{}, Status: 201})
+	spec.Add(Route{Method: "GET", Path: "/health", Raw: true, Public: true})
+
+	doc := spec.Document()
+	require.Contains(t, doc.Paths, "/users/{id}")
+	require.Contains(t, doc.Paths, "/users")
+
+	get := doc.Paths["/users/{id}"]["get"]
+	require.Len(t, get.Parameters, 1)
+	assert.Equal(t, "id", get.Parameters[0].Name)
+	assert.Equal(t, "path", get.Parameters[0].In)
+	assert.Equal(t, []map[string][]string{{"bearerAuth": {}}}, get.Security)
+
+	result := get.Responses["200"].Content[fiber.MIMEApplicationJSON].Schema.Properties["result"]
+	assert.Equal(t, "#/components/schemas/testUser", result.Ref)
+
+	post := doc.Paths["/users"]["post"]
+	assert.Contains(t, post.Responses, "201")
+	assert.True(t, post.RequestBody.Required)
+
+	health := doc.Paths["/health"]["get"]
+	assert.Empty(t, health.Security)
+	assert.NotContains(t, health.Responses, "default")
+}
+
+func TestMountServesDocument(t *testing.T) {
+	app := fiber.New()
+	spec := New(Config{Title: "Users", Version: "2.0.0"})
+	spec.Add(Route{Method: "GET", Path: "/users", Response: []testUser{}})
+	spec.Mount(app)
+
+	resp, err := app.Test(httptest.NewRequest("GET", DefaultPath, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var doc Document
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+	assert.Equal(t, Version, doc.OpenAPI)
+	assert.Equal(t, "Users", doc.Info.Title)
+	assert.Contains(t, doc.Components.Schemas, "testUser")
+}
+
+func reflectTypeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
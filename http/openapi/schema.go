@@ -0,0 +1,308 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ============================================
+// Schema Reflection
+// ============================================
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	uuidType          = reflect.TypeOf(uuid.UUID{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaRegistry turns Go types into schemas, registering named structs as
+// components referenced by $ref
+type schemaRegistry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// schemaOf returns the schema of t; named structs become references
+func (r *schemaRegistry) schemaOf(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	s := r.baseSchema(t)
+	if nullable {
+		if s.Ref != "" {
+			// $ref siblings are ignored in OpenAPI 3.0, so wrap the reference
+			return &Schema{AllOf: []*Schema{s}, Nullable: true}
+		}
+		s.Nullable = true
+	}
+	return s
+}
+
+func (r *schemaRegistry) baseSchema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Interface:
+		return &Schema{}
+	}
+
+	// Custom encodings are opaque to reflection
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		return &Schema{}
+	}
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		return r.ref(t)
+	}
+	return &Schema{}
+}
+
+// ref registers the named struct t and returns a reference to it
+func (r *schemaRegistry) ref(t reflect.Type) *Schema {
+	name, ok := r.names[t]
+	if !ok {
+		name = schemaName(t)
+		for i := 2; r.schemas[name] != nil; i++ {
+			name = schemaName(t) + strconv.Itoa(i)
+		}
+		r.names[t] = name
+		// Reserve the name first so recursive types terminate
+		r.schemas[name] = &Schema{}
+		*r.schemas[name] = *r.structSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (r *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	r.addFields(s, t)
+	return s
+}
+
+// addFields adds the json fields of t to s, flattening embedded structs the
+// way encoding/json does
+func (r *schemaRegistry) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				r.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := r.schemaOf(f.Type)
+		if hasJSONOption(opts, "string") && prop.Ref == "" {
+			prop = &Schema{Type: "string", Nullable: prop.Nullable}
+		}
+		if applyValidateTag(prop, f.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+		if doc := f.Tag.Get("doc"); doc != "" {
+			prop.Description = doc
+		}
+		s.Properties[name] = prop
+	}
+}
+
+func hasJSONOption(opts, option string) bool {
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// applyValidateTag maps validator tags onto schema constraints and reports
+// whether the field is required. Rules after "dive" apply to elements and
+// are skipped
+func applyValidateTag(s *Schema, tag string) bool {
+	if tag == "" || tag == "-" {
+		return false
+	}
+	// Constraints on a wrapped reference cannot be expressed, keep the ref
+	target := s
+	if s.Ref != "" || len(s.AllOf) > 0 {
+		target = nil
+	}
+
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if name == "dive" {
+			break
+		}
+		if name == "required" {
+			required = true
+			continue
+		}
+		if target == nil {
+			continue
+		}
+		switch name {
+		case "email":
+			target.Format = "email"
+		case "url", "uri", "http_url":
+			target.Format = "uri"
+		case "uuid", "uuid4", "uuid_rfc4122":
+			target.Format = "uuid"
+		case "datetime":
+			target.Format = "date-time"
+		case "e164":
+			target.Pattern = `^\+[1-9]\d{1,14}$`
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				target.Enum = append(target.Enum, enumValue(target.Type, v))
+			}
+		case "len":
+			setBound(target, param, true, false)
+			setBound(target, param, false, false)
+		case "min", "gte":
+			setBound(target, param, true, false)
+		case "max", "lte":
+			setBound(target, param, false, false)
+		case "gt":
+			setBound(target, param, true, true)
+		case "lt":
+			setBound(target, param, false, true)
+		}
+	}
+	return required
+}
+
+// setBound sets a length, item count or value bound depending on the type
+func setBound(s *Schema, param string, lower, exclusive bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch s.Type {
+	case "string", "array":
+		count := int(n)
+		if exclusive {
+			if lower {
+				count++
+			} else {
+				count--
+			}
+		}
+		switch {
+		case s.Type == "string" && lower:
+			s.MinLength = &count
+		case s.Type == "string":
+			s.MaxLength = &count
+		case lower:
+			s.MinItems = &count
+		default:
+			s.MaxItems = &count
+		}
+	case "integer", "number":
+		if lower {
+			s.Minimum = &n
+			s.ExclusiveMinimum = exclusive
+		} else {
+			s.Maximum = &n
+			s.ExclusiveMaximum = exclusive
+		}
+	}
+}
+
+func enumValue(typ, v string) interface{} {
+	switch typ {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return v
+}
+
+// typeArgPackage matches the package path qualifying a generic type argument
+var typeArgPackage = regexp.MustCompile(`[\w./-]*\.`)
+
+// schemaName names the component of t: PagedList[pkg.User] becomes
+// PagedList_User
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	base, args, found := strings.Cut(name, "[")
+	if !found {
+		return name
+	}
+	args = typeArgPackage.ReplaceAllString(strings.TrimSuffix(args, "]"), "")
+	args = strings.NewReplacer(",", "_", "[", "_", "]", "", "*", "", " ", "").Replace(args)
+	return base + "_" + args
+}
@@ -1,262 +1,496 @@
 package services
 
-// import (
-// 	"context"
-// 	"database/sql"
-// 	"fmt"
-// 	"math"
-// 	"reflect"
-// 	"strings"
-// 	"time"
-
-// 	"github.com/minisource/go-common/common"
-// 	"github.com/minisource/go-common/constants"
-// 	"github.com/minisource/go-common/dto"
-// 	"github.com/minisource/go-common/logging"
-// 	"github.com/minisource/go-common/metrics"
-// 	"github.com/minisource/go-common/service_errors"
-// )
-
-// type BaseService[T any, Tc any, Tu any, Tr any] struct {
-// 	DB     *sqlc.Queries // SQLC-generated queries
-// 	Logger logging.Logger
-// }
-
-// func NewBaseService[T any, Tc any, Tu any, Tr any](cfg *logging.LoggerConfig, db *sql.DB) *BaseService[T, Tc, Tu, Tr] {
-// 	return &BaseService[T, Tc, Tu, Tr]{
-// 		DB:     sqlc.New(db), // Initialize SQLC client
-// 		Logger: logging.NewLogger(cfg),
-// 	}
-// }
-
-// func (s *BaseService[T, Tc, Tu, Tr]) Create(ctx context.Context, req *Tc) (*Tr, error) {
-// 	model, err := common.TypeConverter[T](req)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	// Convert the model to SQLC's input type
-// 	input, err := common.TypeConverter[sqlc.CreateModelParams](model)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	// Execute the SQL query using SQLC
-// 	createdModel, err := s.DB.CreateModel(ctx, *input)
-// 	if err != nil {
-// 		s.Logger.Error(logging.Postgres, logging.Insert, err.Error(), nil)
-// 		metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "Create", "Failed").Inc()
-// 		return nil, err
-// 	}
-
-// 	metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "Create", "Success").Inc()
-// 	return common.TypeConverter[Tr](&createdModel)
-// }
-
-// func (s *BaseService[T, Tc, Tu, Tr]) Update(ctx context.Context, id int, req *Tu) (*Tr, error) {
-// 	updateMap, err := common.TypeConverter[map[string]interface{}](req)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	// Convert the update map to SQLC's input type
-// 	input := sqlc.UpdateModelParams{
-// 		ID:         int32(id),
-// 		ModifiedBy: sql.NullInt64{Int64: int64(ctx.Value(constants.UserIdKey).(float64)), Valid: true},
-// 		ModifiedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
-// 	}
-
-// 	// Apply updates from the map
-// 	for k, v := range *updateMap {
-// 		switch k {
-// 		case "field1":
-// 			input.Field1 = v.(string)
-// 		case "field2":
-// 			input.Field2 = v.(int32)
-// 		// Add more fields as needed
-// 		}
-// 	}
-
-// 	// Execute the SQL query using SQLC
-// 	updatedModel, err := s.DB.UpdateModel(ctx, input)
-// 	if err != nil {
-// 		s.Logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
-// 		metrics.DbCall.WithLabelValues(reflect.TypeOf(*new(T)).String(), "Update", "Failed").Inc()
-// 		return nil, err
-// 	}
-
-// 	metrics.DbCall.WithLabelValues(reflect.TypeOf(*new(T)).String(), "Update", "Success").Inc()
-// 	return common.TypeConverter[Tr](&updatedModel)
-// }
-
-// func (s *BaseService[T, Tc, Tu, Tr]) Delete(ctx context.Context, id int) error {
-// 	if ctx.Value(constants.UserIdKey) == nil {
-// 		return &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
-// 	}
-
-// 	// Execute the SQL query using SQLC
-// 	err := s.DB.DeleteModel(ctx, sqlc.DeleteModelParams{
-// 		ID:         int32(id),
-// 		DeletedBy:  sql.NullInt64{Int64: int64(ctx.Value(constants.UserIdKey).(float64)), Valid: true},
-// 		DeletedAt:  sql.NullTime{Time: time.Now().UTC(), Valid: true},
-// 	})
-// 	if err != nil {
-// 		s.Logger.Error(logging.Postgres, logging.Update, service_errors.RecordNotFound, nil)
-// 		metrics.DbCall.WithLabelValues(reflect.TypeOf(*new(T)).String(), "Delete", "Failed").Inc()
-// 		return err
-// 	}
-
-// 	metrics.DbCall.WithLabelValues(reflect.TypeOf(*new(T)).String(), "Delete", "Success").Inc()
-// 	return nil
-// }
-
-// func (s *BaseService[T, Tc, Tu, Tr]) GetById(ctx context.Context, id int) (*Tr, error) {
-// 	model, err := s.DB.GetModel(ctx, int32(id))
-// 	if err != nil {
-// 		metrics.DbCall.WithLabelValues(reflect.TypeOf(*new(T)).String(), "GetById", "Failed").Inc()
-// 		return nil, err
-// 	}
-
-// 	metrics.DbCall.WithLabelValues(reflect.TypeOf(*new(T)).String(), "GetById", "Success").Inc()
-// 	return common.TypeConverter[Tr](&model)
-// }
-
-// func (s *BaseService[T, Tc, Tu, Tr]) GetByFilter(ctx context.Context, req *dto.PaginationInputWithFilter) (*dto.PagedList[Tr], error) {
-// 	query := getQuery[T](&req.DynamicFilter)
-// 	sort := getSort[T](&req.DynamicFilter)
-
-// 	// Execute the SQL query using SQLC
-// 	models, err := s.DB.ListModels(ctx, sqlc.ListModelsParams{
-// 		Query:  query,
-// 		Sort:   sort,
-// 		Offset: int32(req.GetOffset()),
-// 		Limit:  int32(req.GetPageSize()),
-// 	})
-// 	if err != nil {
-// 		metrics.DbCall.WithLabelValues(reflect.TypeOf(*new(T)).String(), "GetByFilter", "Failed").Inc()
-// 		return nil, err
-// 	}
-
-// 	// Convert the result to the response type
-// 	rItems, err := common.TypeConverter[[]Tr](&models)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	// Get the total count of rows
-// 	totalRows, err := s.DB.CountModels(ctx, query)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	metrics.DbCall.WithLabelValues(reflect.TypeOf(*new(T)).String(), "GetByFilter", "Success").Inc()
-// 	return NewPagedList(rItems, totalRows, req.PageNumber, int64(req.PageSize)), nil
-// }
-
-// func NewPagedList[T any](items *[]T, count int64, pageNumber int, pageSize int64) *dto.PagedList[T] {
-// 	pl := &dto.PagedList[T]{
-// 		PageNumber: pageNumber,
-// 		TotalRows:  count,
-// 		Items:      items,
-// 	}
-// 	pl.TotalPages = int(math.Ceil(float64(count) / float64(pageSize)))
-// 	pl.HasNextPage = pl.PageNumber < pl.TotalPages
-// 	pl.HasPreviousPage = pl.PageNumber > 1
-
-// 	return pl
-// }
-
-// // Paginate
-// func Paginate[T any, Tr any](pagination *dto.PaginationInputWithFilter, db *sqlc.Queries) (*dto.PagedList[Tr], error) {
-// 	query := getQuery[T](&pagination.DynamicFilter)
-// 	sort := getSort[T](&pagination.DynamicFilter)
-
-// 	// Execute the SQL query using SQLC
-// 	items, err := db.ListModels(context.Background(), sqlc.ListModelsParams{
-// 		Query:  query,
-// 		Sort:   sort,
-// 		Offset: int32(pagination.GetOffset()),
-// 		Limit:  int32(pagination.GetPageSize()),
-// 	})
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	// Convert the result to the response type
-// 	rItems, err := common.TypeConverter[[]Tr](&items)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	// Get the total count of rows
-// 	totalRows, err := db.CountModels(context.Background(), query)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	return NewPagedList(rItems, totalRows, pagination.PageNumber, int64(pagination.PageSize)), nil
-// }
-
-// // getQuery
-// func getQuery[T any](filter *dto.DynamicFilter) string {
-// 	t := new(T)
-// 	typeT := reflect.TypeOf(*t)
-// 	query := make([]string, 0)
-// 	query = append(query, "deleted_by is null")
-// 	if filter.Filter != nil {
-// 		for name, filter := range filter.Filter {
-// 			fld, ok := typeT.FieldByName(name)
-// 			if ok {
-// 				fld.Name = common.ToSnakeCase(fld.Name)
-// 				switch filter.Type {
-// 				case "contains":
-// 					query = append(query, fmt.Sprintf("%s ILike '%%%s%%'", fld.Name, filter.From))
-// 				case "notContains":
-// 					query = append(query, fmt.Sprintf("%s not ILike '%%%s%%'", fld.Name, filter.From))
-// 				case "startsWith":
-// 					query = append(query, fmt.Sprintf("%s ILike '%s%%'", fld.Name, filter.From))
-// 				case "endsWith":
-// 					query = append(query, fmt.Sprintf("%s ILike '%%%s'", fld.Name, filter.From))
-// 				case "equals":
-// 					query = append(query, fmt.Sprintf("%s = '%s'", fld.Name, filter.From))
-// 				case "notEqual":
-// 					query = append(query, fmt.Sprintf("%s != '%s'", fld.Name, filter.From))
-// 				case "lessThan":
-// 					query = append(query, fmt.Sprintf("%s < %s", fld.Name, filter.From))
-// 				case "lessThanOrEqual":
-// 					query = append(query, fmt.Sprintf("%s <= %s", fld.Name, filter.From))
-// 				case "greaterThan":
-// 					query = append(query, fmt.Sprintf("%s > %s", fld.Name, filter.From))
-// 				case "greaterThanOrEqual":
-// 					query = append(query, fmt.Sprintf("%s >= %s", fld.Name, filter.From))
-// 				case "inRange":
-// 					if fld.Type.Kind() == reflect.String {
-// 						query = append(query, fmt.Sprintf("%s >= '%s'", fld.Name, filter.From))
-// 						query = append(query, fmt.Sprintf("%s <= '%s'", fld.Name, filter.To))
-// 					} else {
-// 						query = append(query, fmt.Sprintf("%s >= %s", fld.Name, filter.From))
-// 						query = append(query, fmt.Sprintf("%s <= %s", fld.Name, filter.To))
-// 					}
-// 				}
-// 			}
-// 		}
-// 	}
-// 	return strings.Join(query, " AND ")
-// }
-
-// // getSort
-// func getSort[T any](filter *dto.DynamicFilter) string {
-// 	t := new(T)
-// 	typeT := reflect.TypeOf(*t)
-// 	sort := make([]string, 0)
-// 	if filter.Sort != nil {
-// 		for _, tp := range *filter.Sort {
-// 			fld, ok := typeT.FieldByName(tp.ColId)
-// 			if ok && (tp.Sort == "asc" || tp.Sort == "desc") {
-// 				fld.Name = common.ToSnakeCase(fld.Name)
-// 				sort = append(sort, fmt.Sprintf("%s %s", fld.Name, tp.Sort))
-// 			}
-// 		}
-// 	}
-// 	return strings.Join(sort, ", ")
-// }
\ No newline at end of file
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/minisource/go-common/audit"
+	"github.com/minisource/go-common/common"
+	"github.com/minisource/go-common/dto"
+	apperrors "github.com/minisource/go-common/errors"
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/metrics"
+	"github.com/minisource/go-common/repository"
+	"github.com/minisource/go-common/service_errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Hooks run inside the transaction of a write; an error aborts and rolls
+// back the operation
+type Hooks[T any] struct {
+	BeforeCreate func(ctx context.Context, model *T) error
+	AfterCreate  func(ctx context.Context, model *T) error
+	BeforeUpdate func(ctx context.Context, old, updated *T) error
+	AfterUpdate  func(ctx context.Context, model *T) error
+	BeforeDelete func(ctx context.Context, model *T) error
+	AfterDelete  func(ctx context.Context, model *T) error
+}
+
+// BaseService is a generic CRUD service for the model T with create
+// request Tc, update request Tu and response Tr. Requests and responses are
+// mapped with common.TypeConverter, by json field names
+type BaseService[T any, Tc any, Tu any, Tr any] struct {
+	DB         *gorm.DB
+	Repository *repository.GormRepository[T]
+	Logger     logging.Logger
+	// EntityType labels metrics and audit entries, defaults to the type name of T
+	EntityType string
+	// Audit records creates, updates and deletes through audit.FromContext
+	Audit bool
+	// FilterFields whitelists the fields GetByFilter may filter and sort on.
+	// When nil, the mapped fields of T are allowed except those tagged
+	// json:"-" and those excluded from audit records (see audit.IsExcludedField)
+	FilterFields dto.FilterFields
+	Hooks        Hooks[T]
+}
+
+// NewBaseService creates a service for T backed by db
+func NewBaseService[T any, Tc any, Tu any, Tr any](db *gorm.DB, logger logging.Logger) *BaseService[T, Tc, Tu, Tr] {
+	return &BaseService[T, Tc, Tu, Tr]{
+		DB:         db,
+		Repository: repository.NewGormRepository[T](db),
+		Logger:     logger,
+		EntityType: repository.GetEntityType[T](),
+	}
+}
+
+// Create maps req to T, inserts it and returns it as Tr
+func (s *BaseService[T, Tc, Tu, Tr]) Create(ctx context.Context, req *Tc) (*Tr, error) {
+	model, err := common.TypeConverter[T](req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if s.Hooks.BeforeCreate != nil {
+			if err := s.Hooks.BeforeCreate(ctx, &model); err != nil {
+				return err
+			}
+		}
+		if err := repository.NewGormRepository[T](tx).Create(ctx, &model); err != nil {
+			return err
+		}
+		if s.Hooks.AfterCreate != nil {
+			return s.Hooks.AfterCreate(ctx, &model)
+		}
+		return nil
+	})
+	if err != nil {
+		s.fail(logging.Insert, "Create", err)
+		return nil, translateError(err)
+	}
+
+	s.succeed("Create")
+	s.audit(ctx, audit.ActionCreate, &model, nil, &model)
+	return s.response(&model)
+}
+
+// Update writes the fields of req to the entity with id. Nil pointer, slice
+// and map fields of req, and zero fields tagged omitempty, leave the entity
+// unchanged; every other field is written, zero values included, so Tu
+// should use pointer fields for values a client may omit or clear. Use
+// Patch for field-mask partial updates
+func (s *BaseService[T, Tc, Tu, Tr]) Update(ctx context.Context, id uuid.UUID, req *Tu) (*Tr, error) {
+	fields, err := common.StructToMap(req)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range fields {
+		if v == nil {
+			delete(fields, k)
+		}
+	}
+
+	var old, model T
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := repository.NewGormRepository[T](tx)
+		current, err := repo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		old, model = *current, *current
+		if err := common.NewMapper().Map(fields, &model); err != nil {
+			return err
+		}
+
+		if s.Hooks.BeforeUpdate != nil {
+			if err := s.Hooks.BeforeUpdate(ctx, &old, &model); err != nil {
+				return err
+			}
+		}
+		if err := repo.Update(ctx, &model); err != nil {
+			return err
+		}
+		if s.Hooks.AfterUpdate != nil {
+			return s.Hooks.AfterUpdate(ctx, &model)
+		}
+		return nil
+	})
+	if err != nil {
+		s.fail(logging.Update, "Update", err)
+		return nil, translateError(err)
+	}
+
+	s.succeed("Update")
+	s.audit(ctx, audit.ActionUpdate, &model, &old, &model)
+	return s.response(&model)
+}
+
+// Patch writes a field-mask update to the entity with id. fields are keyed
+// by column or Go field name of T, as helper.ParsePatch returns them; absent
+// fields are left unchanged and a nil value resets the field to its zero
+// value, NULL for nullable columns
+func (s *BaseService[T, Tc, Tu, Tr]) Patch(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error {
+	var old, model T
+	sch, err := schema.Parse(&model, &schemaCache, s.DB.NamingStrategy)
+	if err != nil {
+		return err
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := repository.NewGormRepository[T](tx).FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		old, model = *current, *current
+
+		rv := reflect.ValueOf(&model).Elem()
+		columns := make([]string, 0, len(fields)+1)
+		for key, value := range fields {
+			f := sch.LookUpField(key)
+			if f == nil || f.DBName == "" || f.PrimaryKey {
+				return &service_errors.ServiceError{EndUserMessage: service_errors.ValidationError, Err: fmt.Errorf("unknown field %q", key)}
+			}
+			if err := f.Set(ctx, rv, value); err != nil {
+				return &service_errors.ServiceError{EndUserMessage: service_errors.ValidationError, Err: fmt.Errorf("field %q: %w", key, err)}
+			}
+			columns = append(columns, f.DBName)
+		}
+		for _, f := range sch.Fields {
+			if f.AutoUpdateTime > 0 {
+				columns = append(columns, f.DBName)
+			}
+		}
+
+		if s.Hooks.BeforeUpdate != nil {
+			if err := s.Hooks.BeforeUpdate(ctx, &old, &model); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&model).Select(columns).Updates(&model).Error; err != nil {
+			return err
+		}
+		if s.Hooks.AfterUpdate != nil {
+			return s.Hooks.AfterUpdate(ctx, &model)
+		}
+		return nil
+	})
+	if err != nil {
+		s.fail(logging.Update, "Patch", err)
+		return translateError(err)
+	}
+
+	s.succeed("Patch")
+	s.audit(ctx, audit.ActionUpdate, &model, &old, &model)
+	return nil
+}
+
+// Delete soft deletes the entity with id, or hard deletes it if T has no
+// gorm.DeletedAt field
+func (s *BaseService[T, Tc, Tu, Tr]) Delete(ctx context.Context, id uuid.UUID) error {
+	var model T
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := repository.NewGormRepository[T](tx)
+		current, err := repo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		model = *current
+
+		if s.Hooks.BeforeDelete != nil {
+			if err := s.Hooks.BeforeDelete(ctx, &model); err != nil {
+				return err
+			}
+		}
+		if err := repo.SoftDelete(ctx, id); err != nil {
+			return err
+		}
+		if s.Hooks.AfterDelete != nil {
+			return s.Hooks.AfterDelete(ctx, &model)
+		}
+		return nil
+	})
+	if err != nil {
+		s.fail(logging.Delete, "Delete", err)
+		return translateError(err)
+	}
+
+	s.succeed("Delete")
+	s.audit(ctx, audit.ActionDelete, &model, &model, nil)
+	return nil
+}
+
+// CreateBatch creates reqs with a single batch insert. Items failing to map
+// or rejected by BeforeCreate are reported in an *errors.MultiError and the
+// others are still inserted; a failing insert fails the whole batch. The
+// results line up with reqs, nil for failed items
+func (s *BaseService[T, Tc, Tu, Tr]) CreateBatch(ctx context.Context, reqs []*Tc) ([]*Tr, error) {
+	multi := &apperrors.MultiError{}
+	models := make([]*T, len(reqs))
+	for i, req := range reqs {
+		model, err := common.TypeConverter[T](req)
+		if err != nil {
+			multi.Add(i, err)
+			continue
+		}
+		models[i] = &model
+	}
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var batch []*T
+		for i, model := range models {
+			if model == nil {
+				continue
+			}
+			if s.Hooks.BeforeCreate != nil {
+				if err := s.Hooks.BeforeCreate(ctx, model); err != nil {
+					multi.Add(i, err)
+					models[i] = nil
+					continue
+				}
+			}
+			batch = append(batch, model)
+		}
+		if err := repository.NewGormRepository[T](tx).CreateBatch(ctx, batch); err != nil {
+			return err
+		}
+		if s.Hooks.AfterCreate != nil {
+			for _, model := range batch {
+				if err := s.Hooks.AfterCreate(ctx, model); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.fail(logging.Insert, "CreateBatch", err)
+		return nil, err
+	}
+
+	s.succeed("CreateBatch")
+	results := make([]*Tr, len(reqs))
+	for i, model := range models {
+		if model == nil {
+			continue
+		}
+		s.audit(ctx, audit.ActionCreate, model, nil, model)
+		if results[i], err = s.response(model); err != nil {
+			multi.Add(i, err)
+		}
+	}
+	return results, multi.ErrorOrNil()
+}
+
+// UpdateBatch updates each item in its own transaction; failed items are
+// reported in an *errors.MultiError. The results line up with items
+func (s *BaseService[T, Tc, Tu, Tr]) UpdateBatch(ctx context.Context, items []dto.BulkUpdateItem[Tu]) ([]*Tr, error) {
+	multi := &apperrors.MultiError{}
+	results := make([]*Tr, len(items))
+	for i := range items {
+		res, err := s.Update(ctx, items[i].ID, &items[i].Data)
+		if err != nil {
+			multi.Add(i, err)
+			continue
+		}
+		results[i] = res
+	}
+	return results, multi.ErrorOrNil()
+}
+
+// DeleteBatch deletes each id in its own transaction; failed ids are
+// reported in an *errors.MultiError indexed like ids
+func (s *BaseService[T, Tc, Tu, Tr]) DeleteBatch(ctx context.Context, ids []uuid.UUID) error {
+	multi := &apperrors.MultiError{}
+	for i, id := range ids {
+		multi.Add(i, s.Delete(ctx, id))
+	}
+	return multi.ErrorOrNil()
+}
+
+// GetByID returns the entity with id
+func (s *BaseService[T, Tc, Tu, Tr]) GetByID(ctx context.Context, id uuid.UUID) (*Tr, error) {
+	model, err := s.Repository.FindByID(ctx, id)
+	if err != nil {
+		s.fail(logging.Select, "GetByID", err)
+		return nil, translateError(err)
+	}
+
+	s.succeed("GetByID")
+	return s.response(model)
+}
+
+// GetByFilter returns a page of entities matching the dynamic filter.
+// Filters and sorts may only name fields of T, by Go, json or column name
+func (s *BaseService[T, Tc, Tu, Tr]) GetByFilter(ctx context.Context, req *dto.PaginationInputWithFilter) (*dto.PagedList[Tr], error) {
+	var model T
+	query := s.DB.WithContext(ctx).Model(&model)
+
+	fields, err := s.filterFields()
+	if err != nil {
+		return nil, err
+	}
+	fq, err := req.DynamicFilter.Build(fields)
+	if err != nil {
+		return nil, &service_errors.ServiceError{EndUserMessage: service_errors.ValidationError, Err: err}
+	}
+	query = query.Scopes(repository.FilterScope(fq))
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		s.fail(logging.Select, "GetByFilter", err)
+		return nil, err
+	}
+
+	var items []T
+	err = query.
+		Offset(req.GetOffset()).
+		Limit(req.GetPageSize()).
+		Find(&items).Error
+	if err != nil {
+		s.fail(logging.Select, "GetByFilter", err)
+		return nil, err
+	}
+
+	rItems, err := common.TypeConverter[[]Tr](items)
+	if err != nil {
+		return nil, err
+	}
+
+	s.succeed("GetByFilter")
+	return NewPagedList(&rItems, total, req.GetPageNumber(), int64(req.GetPageSize())), nil
+}
+
+// NewPagedList wraps a page of items with its paging metadata
+func NewPagedList[T any](items *[]T, count int64, pageNumber int, pageSize int64) *dto.PagedList[T] {
+	pl := &dto.PagedList[T]{
+		PageNumber: pageNumber,
+		TotalRows:  count,
+		Items:      items,
+	}
+	if pageSize > 0 {
+		pl.TotalPages = int(math.Ceil(float64(count) / float64(pageSize)))
+	}
+	pl.HasNextPage = pl.PageNumber < pl.TotalPages
+	pl.HasPreviousPage = pl.PageNumber > 1
+
+	return pl
+}
+
+func (s *BaseService[T, Tc, Tu, Tr]) response(model *T) (*Tr, error) {
+	res, err := common.TypeConverter[Tr](model)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (s *BaseService[T, Tc, Tu, Tr]) succeed(operation string) {
+	metrics.DbCall.WithLabelValues(s.EntityType, operation, "Success").Inc()
+}
+
+func (s *BaseService[T, Tc, Tu, Tr]) fail(sub logging.SubCategory, operation string, err error) {
+	metrics.DbCall.WithLabelValues(s.EntityType, operation, "Failed").Inc()
+	if s.Logger != nil && !errors.Is(err, repository.ErrNotFound) {
+		s.Logger.Error(logging.Postgres, sub, err.Error(), map[logging.ExtraKey]interface{}{
+			logging.Name: s.EntityType,
+		})
+	}
+}
+
+func (s *BaseService[T, Tc, Tu, Tr]) audit(ctx context.Context, action string, model, old, updated *T) {
+	if !s.Audit {
+		return
+	}
+
+	var entityID *uuid.UUID
+	if e, ok := any(model).(interface{ GetID() uuid.UUID }); ok {
+		id := e.GetID()
+		entityID = &id
+	}
+
+	var oldValues, newValues map[string]interface{}
+	if old != nil && updated != nil {
+		changes, err := audit.Diff(old, updated)
+		if err == nil {
+			oldValues, newValues = audit.SplitChanges(changes)
+		}
+	} else if updated != nil {
+		newValues = audit.Snapshot(updated)
+	} else if old != nil {
+		oldValues = audit.Snapshot(old)
+	}
+
+	err := audit.FromContext(ctx).LogChange(action, s.EntityType, entityID, oldValues, newValues)
+	if err != nil && s.Logger != nil {
+		s.Logger.Warn(logging.General, logging.Api, "Audit log failed", map[logging.ExtraKey]interface{}{
+			logging.Name:         s.EntityType,
+			logging.ErrorMessage: err.Error(),
+		})
+	}
+}
+
+var schemaCache sync.Map
+
+// filterFields returns FilterFields, or whitelists the Go, json and column
+// names of T's fields that are neither hidden from json nor secret
+func (s *BaseService[T, Tc, Tu, Tr]) filterFields() (dto.FilterFields, error) {
+	if s.FilterFields != nil {
+		return s.FilterFields, nil
+	}
+
+	var model T
+	sch, err := schema.Parse(&model, &schemaCache, s.DB.NamingStrategy)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(dto.FilterFields, len(sch.Fields)*3)
+	for _, f := range sch.Fields {
+		jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.DBName == "" || jsonName == "-" ||
+			audit.IsExcludedField(f.Name) || audit.IsExcludedField(f.DBName) || audit.IsExcludedField(jsonName) {
+			continue
+		}
+		var column strings.Builder
+		s.DB.Dialector.QuoteTo(&column, f.DBName)
+		field := dto.FilterField{Column: column.String()}
+		fields[f.Name] = field
+		fields[f.DBName] = field
+		if jsonName != "" {
+			fields[jsonName] = field
+		}
+	}
+	return fields, nil
+}
+
+// translateError maps repository and constraint errors to service errors.
+// Constraint violations are only recognized when the DB is opened with
+// gorm.Config.TranslateError
+func translateError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound, Err: err}
+	case errors.Is(err, gorm.ErrDuplicatedKey), errors.Is(err, repository.ErrAlreadyExists):
+		return &service_errors.ServiceError{EndUserMessage: service_errors.Conflict, Err: err}
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return &service_errors.ServiceError{EndUserMessage: service_errors.BadRequest, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/minisource/go-common/audit"
+	appctx "github.com/minisource/go-common/context"
+	"github.com/minisource/go-common/dto"
+	"github.com/minisource/go-common/http/helper"
+	"github.com/minisource/go-common/service_errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type member struct {
+	ID           uuid.UUID `gorm:"primaryKey" json:"id"`
+	Name         string    `json:"name"`
+	Age          int       `json:"age"`
+	PasswordHash string    `json:"passwordHash"`
+	Internal     string    `json:"-"`
+}
+
+func (m member) GetID() uuid.UUID { return m.ID }
+
+type createMember struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Age          int       `json:"age"`
+	PasswordHash string    `json:"passwordHash"`
+}
+
+type updateMember struct {
+	Name *string `json:"name"`
+	Age  *int    `json:"age"`
+}
+
+type memberResponse struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	Age  int       `json:"age"`
+}
+
+type memoryAuditLogger struct {
+	audit.NoopLogger
+	entries []*audit.AuditLog
+}
+
+func (l *memoryAuditLogger) Log(ctx context.Context, entry *audit.AuditLog) error {
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+func newMemberService(t *testing.T) *BaseService[member, createMember, updateMember, memberResponse] {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard, TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&member{}))
+	return NewBaseService[member, createMember, updateMember, memberResponse](db, nil)
+}
+
+func TestGetByFilterRejectsHiddenAndSecretFields(t *testing.T) {
+	svc := newMemberService(t)
+	ctx := context.Background()
+	_, err := svc.Create(ctx, &createMember{ID: uuid.New(), Name: "ali", PasswordHash: "abc"})
+	require.NoError(t, err)
+
+	for _, field := range []string{"passwordHash", "password_hash", "PasswordHash", "internal", "Internal"} {
+		req := &dto.PaginationInputWithFilter{DynamicFilter: dto.DynamicFilter{
+			Filter: map[string]dto.Filter{field: {Type: dto.OpStartsWith, From: "a", FilterType: "text"}},
+		}}
+		_, err := svc.GetByFilter(ctx, req)
+		assertInvalidFilter(t, err, field)
+	}
+
+	req := &dto.PaginationInputWithFilter{DynamicFilter: dto.DynamicFilter{
+		Filter: map[string]dto.Filter{"name": {Type: dto.OpEquals, From: "ali", FilterType: "text"}},
+	}}
+	page, err := svc.GetByFilter(ctx, req)
+	require.NoError(t, err)
+	assert.Len(t, *page.Items, 1)
+}
+
+func TestGetByFilterUsesExplicitWhitelist(t *testing.T) {
+	svc := newMemberService(t)
+	svc.FilterFields = dto.AllowFields("age")
+
+	req := &dto.PaginationInputWithFilter{DynamicFilter: dto.DynamicFilter{
+		Filter: map[string]dto.Filter{"name": {Type: dto.OpEquals, From: "ali", FilterType: "text"}},
+	}}
+	_, err := svc.GetByFilter(context.Background(), req)
+
+	assertInvalidFilter(t, err, "name")
+}
+
+func assertInvalidFilter(t *testing.T, err error, field string) {
+	t.Helper()
+	var se *service_errors.ServiceError
+	if assert.True(t, errors.As(err, &se), field) {
+		assert.ErrorIs(t, se.Err, dto.ErrInvalidFilter, field)
+	}
+}
+
+func TestUpdateLeavesNilFieldsUnchanged(t *testing.T) {
+	svc := newMemberService(t)
+	ctx := context.Background()
+	created, err := svc.Create(ctx, &createMember{ID: uuid.New(), Name: "ali", Age: 30})
+	require.NoError(t, err)
+
+	name := "sara"
+	updated, err := svc.Update(ctx, created.ID, &updateMember{Name: &name})
+	require.NoError(t, err)
+	assert.Equal(t, "sara", updated.Name)
+	assert.Equal(t, 30, updated.Age)
+
+	age := 0
+	updated, err = svc.Update(ctx, created.ID, &updateMember{Age: &age})
+	require.NoError(t, err)
+	assert.Equal(t, "sara", updated.Name)
+	assert.Equal(t, 0, updated.Age)
+}
+
+func TestUpdateSkipsZeroOmitemptyFields(t *testing.T) {
+	type renameMember struct {
+		Name string `json:"name,omitempty"`
+		Age  int    `json:"age"`
+	}
+	svc := newMemberService(t)
+	ctx := context.Background()
+	created, err := svc.Create(ctx, &createMember{ID: uuid.New(), Name: "ali", Age: 30})
+	require.NoError(t, err)
+
+	renames := NewBaseService[member, createMember, renameMember, memberResponse](svc.DB, nil)
+	updated, err := renames.Update(ctx, created.ID, &renameMember{Age: 0})
+	require.NoError(t, err)
+	assert.Equal(t, "ali", updated.Name, "zero omitempty field is skipped")
+	assert.Equal(t, 0, updated.Age, "zero field without omitempty is written")
+}
+
+func TestCreateTranslatesDuplicateKey(t *testing.T) {
+	svc := newMemberService(t)
+	ctx := context.Background()
+	req := &createMember{ID: uuid.New(), Name: "ali"}
+	_, err := svc.Create(ctx, req)
+	require.NoError(t, err)
+
+	_, err = svc.Create(ctx, req)
+	assert.Equal(t, fiber.StatusConflict, helper.TranslateErrorToStatusCode(err))
+}
+
+func TestRegisterCRUDPatchUpdatesPresentFieldsOnly(t *testing.T) {
+	svc := newMemberService(t)
+	created, err := svc.Create(context.Background(), &createMember{ID: uuid.New(), Name: "ali", Age: 30})
+	require.NoError(t, err)
+
+	app := fiber.New()
+	helper.RegisterCRUD[createMember, updateMember, memberResponse](app, "/members", svc)
+	patch := func(body string) int {
+		req := httptest.NewRequest(fiber.MethodPatch, "/members/"+created.ID.String(), strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, patch(`{"age":0}`))
+	got, err := svc.GetByID(context.Background(), created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "ali", got.Name)
+	assert.Equal(t, 0, got.Age)
+
+	assert.Equal(t, fiber.StatusBadRequest, patch(`{"passwordHash":"x"}`))
+}
+
+func TestAuditSnapshotsAreRedacted(t *testing.T) {
+	svc := newMemberService(t)
+	svc.Audit = true
+	rec := &memoryAuditLogger{}
+	ctx := appctx.WithTenantID(audit.NewContext(context.Background(), rec), uuid.New())
+
+	created, err := svc.Create(ctx, &createMember{ID: uuid.New(), Name: "ali", PasswordHash: "abc"})
+	require.NoError(t, err)
+	require.NoError(t, svc.Delete(ctx, created.ID))
+
+	require.Len(t, rec.entries, 2)
+	assert.Equal(t, "ali", rec.entries[0].NewValues["name"])
+	assert.NotContains(t, rec.entries[0].NewValues, "passwordHash")
+	assert.Equal(t, "ali", rec.entries[1].OldValues["name"])
+	assert.NotContains(t, rec.entries[1].OldValues, "passwordHash")
+}
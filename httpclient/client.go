@@ -4,14 +4,30 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/minisource/go-common/breaker"
 	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/metrics"
 )
 
+// Doer executes HTTP requests. Depend on it instead of *Client so HTTP
+// integrations can be unit tested with testing.MockHTTPClient
+type Doer interface {
+	Do(ctx context.Context, req Request) (*Response, error)
+	Get(ctx context.Context, path string, headers map[string]string) (*Response, error)
+	Post(ctx context.Context, path string, body interface{}, headers map[string]string) (*Response, error)
+	Put(ctx context.Context, path string, body interface{}, headers map[string]string) (*Response, error)
+	Delete(ctx context.Context, path string, headers map[string]string) (*Response, error)
+}
+
+var _ Doer = (*Client)(nil)
+
 // Client is a reusable HTTP client with retry, logging, and error handling
 type Client struct {
 	httpClient   *http.Client
@@ -20,6 +36,11 @@ type Client struct {
 	baseURL      string
 	serviceName  string
 	interceptors []Interceptor
+	breakers     *breaker.Group
+	breakerKey   func(req Request) string
+	metrics      *metrics.HTTPClientMetrics
+	metricsPath  func(req Request) string
+	paths        *pathLabels
 }
 
 // Config holds HTTP client configuration
@@ -30,6 +51,25 @@ type Config struct {
 	RetryConfig  RetryConfig
 	Logger       logging.Logger
 	Interceptors []Interceptor
+	// CircuitBreaker, if set, rejects requests without calling the service
+	// after repeated failures, so retries don't pile onto a service that
+	// is down. Transport errors and 5xx responses count as failures
+	CircuitBreaker *breaker.Config
+	// BreakerKey picks the breaker of a request, e.g. per endpoint
+	// (default: one breaker for the client). Keep the set of keys bounded
+	BreakerKey func(req Request) string
+	// Tracing records an OpenTelemetry client span for every attempt and
+	// propagates the trace context to the service
+	Tracing bool
+	// Metrics, if set, receives the http_client_* collectors: calls,
+	// duration, retries and circuit breaker state. Use
+	// metrics.DefaultRegistry() or metrics.NewRegistry with your own
+	// prometheus.Registerer
+	Metrics *metrics.Registry
+	// MetricsPath labels the metrics of a request (default: its Path).
+	// Return templates such as "/users/:id"; past 200 distinct paths the
+	// rest are labeled "other"
+	MetricsPath func(req Request) string
 }
 
 // RetryConfig holds retry configuration
@@ -72,16 +112,36 @@ func NewClient(cfg Config) *Client {
 		cfg.RetryConfig = DefaultRetryConfig()
 	}
 
-	return &Client{
+	var transport http.RoundTripper
+	if cfg.Tracing {
+		transport = NewTracingTransport(nil, cfg.ServiceName)
+	}
+
+	client := &Client{
 		httpClient: &http.Client{
-			Timeout: cfg.Timeout,
+			Timeout:   cfg.Timeout,
+			Transport: transport,
 		},
 		logger:       cfg.Logger,
 		retryConfig:  cfg.RetryConfig,
 		baseURL:      cfg.BaseURL,
 		serviceName:  cfg.ServiceName,
 		interceptors: cfg.Interceptors,
+		breakerKey:   cfg.BreakerKey,
 	}
+	if cfg.Metrics != nil {
+		client.metrics = metrics.NewHTTPClientMetrics(cfg.Metrics)
+		client.metricsPath = cfg.MetricsPath
+		client.paths = &pathLabels{seen: make(map[string]struct{})}
+	}
+	if cfg.CircuitBreaker != nil {
+		breakerConfig := *cfg.CircuitBreaker
+		if breakerConfig.Logger == nil {
+			breakerConfig.Logger = cfg.Logger
+		}
+		client.breakers = breaker.NewGroup(cfg.ServiceName, breakerConfig)
+	}
+	return client
 }
 
 // Request represents an HTTP request
@@ -111,6 +171,7 @@ func (c *Client) Do(ctx context.Context, req Request) (*Response, error) {
 	})
 
 	var lastErr error
+	lastStatus := "error"
 	for attempt := 0; attempt <= c.retryConfig.MaxRetries; attempt++ {
 		if attempt > 0 {
 			delay := c.calculateBackoff(attempt)
@@ -122,12 +183,26 @@ func (c *Client) Do(ctx context.Context, req Request) (*Response, error) {
 
 			select {
 			case <-ctx.Done():
+				c.observe(req, lastStatus, attempt-1, startTime)
 				return nil, ctx.Err()
 			case <-time.After(delay):
 			}
 		}
 
+		done, err := c.allow(req)
+		if err != nil {
+			c.logger.Warn(logging.General, logging.ExternalService, "HTTP request rejected by circuit breaker", map[logging.ExtraKey]interface{}{
+				"service": c.serviceName,
+				"method":  req.Method,
+				"path":    req.Path,
+				"attempt": attempt + 1,
+			})
+			c.observe(req, "rejected", attempt, startTime)
+			return nil, NewServiceUnavailableError(c.serviceName, err)
+		}
+
 		resp, err := c.doRequest(ctx, req, attempt)
+		done(breakerSuccess(ctx, resp, err))
 		if err == nil && !c.shouldRetry(resp.StatusCode) {
 			duration := time.Since(startTime)
 			c.logger.Info(logging.General, logging.ExternalService, "HTTP request completed", map[logging.ExtraKey]interface{}{
@@ -138,11 +213,13 @@ func (c *Client) Do(ctx context.Context, req Request) (*Response, error) {
 				"duration":   duration.String(),
 				"attempt":    attempt + 1,
 			})
+			c.observe(req, metrics.StatusClass(resp.StatusCode), attempt, startTime)
 			return resp, nil
 		}
 
 		if err != nil {
 			lastErr = err
+			lastStatus = "error"
 			c.logger.Warn(logging.General, logging.ExternalService, "HTTP request failed", map[logging.ExtraKey]interface{}{
 				"service": c.serviceName,
 				"method":  req.Method,
@@ -152,6 +229,7 @@ func (c *Client) Do(ctx context.Context, req Request) (*Response, error) {
 			})
 		} else if c.shouldRetry(resp.StatusCode) {
 			lastErr = fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(resp.Body))
+			lastStatus = metrics.StatusClass(resp.StatusCode)
 			c.logger.Warn(logging.General, logging.ExternalService, "HTTP request returned retryable error", map[logging.ExtraKey]interface{}{
 				"service":    c.serviceName,
 				"method":     req.Method,
@@ -171,10 +249,82 @@ func (c *Client) Do(ctx context.Context, req Request) (*Response, error) {
 		"duration": duration.String(),
 		"error":    lastErr.Error(),
 	})
+	c.observe(req, lastStatus, c.retryConfig.MaxRetries, startTime)
 
 	return nil, NewServiceUnavailableError(c.serviceName, lastErr)
 }
 
+// observe records a finished call when metrics are enabled
+func (c *Client) observe(req Request, status string, retries int, start time.Time) {
+	if c.metrics == nil {
+		return
+	}
+	path := req.Path
+	if c.metricsPath != nil {
+		path = c.metricsPath(req)
+	}
+	c.metrics.Observe(c.serviceName, req.Method, c.paths.label(path), status, retries, time.Since(start))
+}
+
+// pathLabels caps the distinct path labels, so raw paths with IDs can't
+// grow the metrics without bound
+type pathLabels struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+const maxPathLabels = 200
+
+func (p *pathLabels) label(path string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.seen[path]; ok {
+		return path
+	}
+	if len(p.seen) >= maxPathLabels {
+		return "other"
+	}
+	p.seen[path] = struct{}{}
+	return path
+}
+
+// allow asks the request's circuit breaker for permission to call the
+// service; without a breaker every request is allowed
+func (c *Client) allow(req Request) (func(success bool), error) {
+	if c.breakers == nil {
+		return func(bool) {}, nil
+	}
+	key := ""
+	if c.breakerKey != nil {
+		key = c.breakerKey(req)
+	}
+	b := c.breakers.Get(key)
+	done, err := b.Allow()
+	if c.metrics == nil {
+		return done, err
+	}
+
+	// Record the state after each call, when it may have changed
+	state := c.metrics.BreakerState.WithLabelValues(c.serviceName, key)
+	state.Set(float64(b.State()))
+	if err != nil {
+		return done, err
+	}
+	return func(success bool) {
+		done(success)
+		state.Set(float64(b.State()))
+	}, nil
+}
+
+// breakerSuccess reports whether an attempt shows the service healthy;
+// requests canceled by the caller and 4xx responses don't count against it
+func breakerSuccess(ctx context.Context, resp *Response, err error) bool {
+	if err != nil {
+		return errors.Is(ctx.Err(), context.Canceled)
+	}
+	return resp.StatusCode < http.StatusInternalServerError
+}
+
 func (c *Client) doRequest(ctx context.Context, req Request, attempt int) (*Response, error) {
 	url := c.baseURL + req.Path
 	if len(req.Query) > 0 {
@@ -0,0 +1,138 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minisource/go-common/breaker"
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nopLogger discards everything
+type nopLogger struct{}
+
+func (nopLogger) Init() {}
+func (nopLogger) Debug(logging.Category, logging.SubCategory, string, map[logging.ExtraKey]interface{}) {
+}
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Info(logging.Category, logging.SubCategory, string, map[logging.ExtraKey]interface{}) {
+}
+func (nopLogger) Infof(string, ...interface{}) {}
+func (nopLogger) Warn(logging.Category, logging.SubCategory, string, map[logging.ExtraKey]interface{}) {
+}
+func (nopLogger) Warnf(string, ...interface{}) {}
+func (nopLogger) Error(logging.Category, logging.SubCategory, string, map[logging.ExtraKey]interface{}) {
+}
+func (nopLogger) Errorf(string, ...interface{}) {}
+func (nopLogger) Fatal(logging.Category, logging.SubCategory, string, map[logging.ExtraKey]interface{}) {
+}
+func (nopLogger) Fatalf(string, ...interface{}) {}
+
+// flakyServer fails the first failures requests with 503
+func flakyServer(t *testing.T, failures int64) (*httptest.Server, *atomic.Int64) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id":"42"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func newTestClient(server *httptest.Server, reg *metrics.Registry, cb *breaker.Config) *Client {
+	return NewClient(Config{
+		BaseURL:     server.URL,
+		ServiceName: "users",
+		Logger:      nopLogger{},
+		RetryConfig: RetryConfig{
+			MaxRetries:      2,
+			InitialDelay:    time.Millisecond,
+			MaxDelay:        time.Millisecond,
+			BackoffFactor:   1,
+			RetryableErrors: []int{http.StatusServiceUnavailable},
+		},
+		CircuitBreaker: cb,
+		Metrics:        reg,
+		MetricsPath: func(req Request) string {
+			if strings.HasPrefix(req.Path, "/users/") {
+				return "/users/:id"
+			}
+			return req.Path
+		},
+	})
+}
+
+func TestClientMetricsRecordRetries(t *testing.T) {
+	server, calls := flakyServer(t, 1)
+	reg := metrics.NewRegistry(metrics.RegistryConfig{})
+	client := newTestClient(server, reg, nil)
+
+	resp, err := client.Get(context.Background(), "/users/42", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err = client.Get(context.Background(), "/users/43", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), calls.Load())
+
+	err = testutil.GatherAndCompare(reg.Gatherer(), strings.NewReader(`
+# HELP http_client_requests_total Total number of HTTP client calls
+# TYPE http_client_requests_total counter
+http_client_requests_total{method="GET",path="/users/:id",service="users",status="2xx"} 2
+# HELP http_client_retries_total Total number of HTTP client retry attempts
+# TYPE http_client_retries_total counter
+http_client_retries_total{method="GET",path="/users/:id",service="users"} 1
+`), "http_client_requests_total", "http_client_retries_total")
+	assert.NoError(t, err)
+	count, err := testutil.GatherAndCount(reg.Gatherer(), "http_client_request_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "one duration series per path template")
+}
+
+func TestClientMetricsRecordFailure(t *testing.T) {
+	server, calls := flakyServer(t, 100)
+	reg := metrics.NewRegistry(metrics.RegistryConfig{})
+	client := newTestClient(server, reg, nil)
+
+	_, err := client.Post(context.Background(), "/orders", map[string]string{"sku": "a"}, nil)
+	var unavailable *ServiceUnavailableError
+	require.True(t, errors.As(err, &unavailable))
+	assert.Equal(t, int64(3), calls.Load())
+
+	m := metrics.NewHTTPClientMetrics(reg)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Requests.WithLabelValues("users", "POST", "/orders", "5xx")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.Retries.WithLabelValues("users", "POST", "/orders")))
+}
+
+func TestClientMetricsRecordBreakerState(t *testing.T) {
+	server, calls := flakyServer(t, 100)
+	reg := metrics.NewRegistry(metrics.RegistryConfig{})
+	cb := breaker.DefaultConfig()
+	cb.FailureThreshold = 3
+	cb.OpenTimeout = time.Minute
+	client := newTestClient(server, reg, &cb)
+	m := metrics.NewHTTPClientMetrics(reg)
+	state := m.BreakerState.WithLabelValues("users", "")
+
+	_, err := client.Get(context.Background(), "/users/1", nil)
+	require.Error(t, err)
+	assert.Equal(t, float64(breaker.StateOpen), testutil.ToFloat64(state), "three failed attempts open the breaker")
+
+	_, err = client.Get(context.Background(), "/users/1", nil)
+	require.Error(t, err)
+	assert.Equal(t, int64(3), calls.Load(), "an open breaker rejects without calling the service")
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Requests.WithLabelValues("users", "GET", "/users/:id", "rejected")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Requests.WithLabelValues("users", "GET", "/users/:id", "5xx")))
+}
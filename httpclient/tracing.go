@@ -0,0 +1,86 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/minisource/go-common/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the tracer of client spans
+const tracerName = "httpclient"
+
+// tracingTransport records a client span for every request
+type tracingTransport struct {
+	base        http.RoundTripper
+	tracer      trace.Tracer
+	serviceName string
+}
+
+// NewTracingTransport wraps base (default: http.DefaultTransport) so every
+// request records a client span and sends the W3C trace context, with the
+// tenant, user and request IDs as baggage. It uses the global tracer
+// provider and propagator set up by tracing.Init
+func NewTracingTransport(base http.RoundTripper, serviceName string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &tracingTransport{base: base, tracer: otel.Tracer(tracerName), serviceName: serviceName}
+}
+
+// RoundTrip implements http.RoundTripper; the span ends when the response
+// body is closed
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The query may hold secrets, so only the rest of the URL is recorded
+	url := *req.URL
+	url.RawQuery, url.User = "", nil
+
+	ctx, span := t.tracer.Start(req.Context(), req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethod(req.Method),
+			semconv.HTTPURL(url.String()),
+			semconv.NetPeerName(req.URL.Hostname()),
+			semconv.PeerService(t.serviceName),
+		),
+	)
+
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(tracing.WithIdentityBaggage(ctx), propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	} else {
+		span.SetStatus(codes.Ok, "")
+	}
+	resp.Body = &spanBody{ReadCloser: resp.Body, span: span}
+	return resp, nil
+}
+
+// spanBody ends its span when the response body is closed
+type spanBody struct {
+	io.ReadCloser
+	span trace.Span
+	once sync.Once
+}
+
+func (b *spanBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.span.End() })
+	return err
+}
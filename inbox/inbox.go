@@ -0,0 +1,120 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/messaging"
+)
+
+// ErrInProgress is returned for a message another consumer is handling
+// right now; the broker redelivers it later
+var ErrInProgress = errors.New("inbox: message is being processed")
+
+// Claim is the outcome of claiming a message
+type Claim int
+
+const (
+	// Acquired means the caller processes the message
+	Acquired Claim = iota
+	// Processed means the message was handled before
+	Processed
+	// Busy means another consumer holds the claim
+	Busy
+)
+
+// Store remembers which messages a handler processed. Entries are keyed by
+// handler name and message ID, so several handlers can consume the same
+// message independently
+type Store interface {
+	// Claim reserves the message for lease, the longest time handling may
+	// take; an expired claim can be acquired again
+	Claim(ctx context.Context, handler, messageID string, lease time.Duration) (Claim, error)
+	// Complete marks the message processed for ttl
+	Complete(ctx context.Context, handler, messageID string, ttl time.Duration) error
+	// Release drops the claim of a failed message so a redelivery is
+	// processed
+	Release(ctx context.Context, handler, messageID string) error
+}
+
+// Config configures the deduplication middleware
+type Config struct {
+	Store Store
+	// Handler names the consumer; required
+	Handler string
+	// TTL is how long processed messages are remembered; it must outlast
+	// the redelivery window of the broker (default: 7 days)
+	TTL time.Duration
+	// Lease is how long a claim blocks duplicates while the message is
+	// handled (default: 5m)
+	Lease time.Duration
+	// MessageID extracts the deduplication key (default: Message.ID)
+	MessageID func(msg *messaging.Message) string
+	Logger    logging.Logger
+}
+
+// DefaultConfig returns default configuration for handler
+func DefaultConfig(store Store, handler string) Config {
+	return Config{
+		Store:   store,
+		Handler: handler,
+		TTL:     7 * 24 * time.Hour,
+		Lease:   5 * time.Minute,
+	}
+}
+
+// Deduplicate skips messages the handler already processed, making an
+// at-least-once pipeline effectively exactly-once per handler. A message
+// another consumer is handling fails with ErrInProgress so it is
+// redelivered; messages without an ID pass through
+func Deduplicate(cfg Config) messaging.Middleware {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 7 * 24 * time.Hour
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = 5 * time.Minute
+	}
+	if cfg.MessageID == nil {
+		cfg.MessageID = func(msg *messaging.Message) string { return msg.ID }
+	}
+
+	return func(next messaging.Handler) messaging.Handler {
+		return func(ctx context.Context, msg *messaging.Message) error {
+			id := cfg.MessageID(msg)
+			if id == "" {
+				return next(ctx, msg)
+			}
+
+			claim, err := cfg.Store.Claim(ctx, cfg.Handler, id, cfg.Lease)
+			if err != nil {
+				return err
+			}
+			switch claim {
+			case Processed:
+				if cfg.Logger != nil {
+					cfg.Logger.Debug(logging.Messaging, logging.Consume, "Skipped duplicate message", map[logging.ExtraKey]interface{}{
+						logging.Topic:     msg.Topic,
+						logging.MessageID: id,
+						logging.Name:      cfg.Handler,
+					})
+				}
+				return nil
+			case Busy:
+				return ErrInProgress
+			}
+
+			if err := next(ctx, msg); err != nil {
+				// A permanent failure is not redelivered, so remember it
+				if messaging.IsPermanent(err) {
+					_ = cfg.Store.Complete(ctx, cfg.Handler, id, cfg.TTL)
+				} else {
+					_ = cfg.Store.Release(ctx, cfg.Handler, id)
+				}
+				return err
+			}
+			return cfg.Store.Complete(ctx, cfg.Handler, id, cfg.TTL)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/minisource/go-common/cache"
+	"github.com/minisource/go-common/messaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *CacheStore {
+	c := cache.NewMemoryCache()
+	t.Cleanup(func() { c.Close() })
+	return NewCacheStore(c)
+}
+
+func TestDeduplicateSkipsProcessedMessages(t *testing.T) {
+	store := newTestStore(t)
+	calls := 0
+	h := Deduplicate(DefaultConfig(store, "billing"))(func(ctx context.Context, msg *messaging.Message) error {
+		calls++
+		return nil
+	})
+	msg := messaging.NewMessage("orders", nil)
+
+	require.NoError(t, h(context.Background(), msg))
+	require.NoError(t, h(context.Background(), msg))
+
+	assert.Equal(t, 1, calls)
+}
+
+func TestDeduplicateIsPerHandler(t *testing.T) {
+	store := newTestStore(t)
+	calls := 0
+	handler := func(ctx context.Context, msg *messaging.Message) error {
+		calls++
+		return nil
+	}
+	msg := messaging.NewMessage("orders", nil)
+
+	require.NoError(t, Deduplicate(DefaultConfig(store, "billing"))(handler)(context.Background(), msg))
+	require.NoError(t, Deduplicate(DefaultConfig(store, "shipping"))(handler)(context.Background(), msg))
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestDeduplicateReleasesFailedMessages(t *testing.T) {
+	store := newTestStore(t)
+	fail := true
+	calls := 0
+	h := Deduplicate(DefaultConfig(store, "billing"))(func(ctx context.Context, msg *messaging.Message) error {
+		calls++
+		if fail {
+			return errors.New("db down")
+		}
+		return nil
+	})
+	msg := messaging.NewMessage("orders", nil)
+
+	assert.Error(t, h(context.Background(), msg))
+	fail = false
+	assert.NoError(t, h(context.Background(), msg))
+	assert.NoError(t, h(context.Background(), msg))
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestDeduplicateRemembersPermanentFailures(t *testing.T) {
+	store := newTestStore(t)
+	calls := 0
+	h := Deduplicate(DefaultConfig(store, "billing"))(func(ctx context.Context, msg *messaging.Message) error {
+		calls++
+		return messaging.Permanent(errors.New("invalid"))
+	})
+	msg := messaging.NewMessage("orders", nil)
+
+	assert.Error(t, h(context.Background(), msg))
+	assert.NoError(t, h(context.Background(), msg))
+
+	assert.Equal(t, 1, calls)
+}
+
+func TestDeduplicateBusyWhileClaimed(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	msg := messaging.NewMessage("orders", nil)
+	claim, err := store.Claim(ctx, "billing", msg.ID, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, Acquired, claim)
+
+	h := Deduplicate(DefaultConfig(store, "billing"))(func(ctx context.Context, msg *messaging.Message) error {
+		t.Fatal("handler must not run while another consumer holds the claim")
+		return nil
+	})
+
+	assert.ErrorIs(t, h(ctx, msg), ErrInProgress)
+}
+
+func TestCacheStoreClaimExpires(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	claim, _ := store.Claim(ctx, "billing", "1", 10*time.Millisecond)
+	require.Equal(t, Acquired, claim)
+	time.Sleep(20 * time.Millisecond)
+	claim, err := store.Claim(ctx, "billing", "1", time.Minute)
+
+	require.NoError(t, err)
+	assert.Equal(t, Acquired, claim)
+}
@@ -0,0 +1,66 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/minisource/go-common/cache"
+)
+
+const (
+	cacheProcessing = "processing"
+	cacheProcessed  = "processed"
+)
+
+// CacheStore keeps the inbox in a cache.Cache, e.g. Redis shared by all
+// instances of a service
+type CacheStore struct {
+	cache  cache.Cache
+	prefix string
+}
+
+// NewCacheStore creates a cache backed store; keys are prefixed with
+// "inbox:"
+func NewCacheStore(c cache.Cache) *CacheStore {
+	return &CacheStore{cache: c, prefix: "inbox:"}
+}
+
+func (s *CacheStore) key(handler, messageID string) string {
+	return s.prefix + handler + ":" + messageID
+}
+
+// Claim implements Store
+func (s *CacheStore) Claim(ctx context.Context, handler, messageID string, lease time.Duration) (Claim, error) {
+	key := s.key(handler, messageID)
+	ok, err := s.cache.SetNX(ctx, key, []byte(cacheProcessing), lease)
+	if err != nil {
+		return Busy, err
+	}
+	if ok {
+		return Acquired, nil
+	}
+
+	value, err := s.cache.Get(ctx, key)
+	if err != nil {
+		// Expired between the two calls; let the broker redeliver
+		if errors.Is(err, cache.ErrKeyNotFound) || errors.Is(err, cache.ErrKeyExpired) {
+			return Busy, nil
+		}
+		return Busy, err
+	}
+	if string(value) == cacheProcessed {
+		return Processed, nil
+	}
+	return Busy, nil
+}
+
+// Complete implements Store
+func (s *CacheStore) Complete(ctx context.Context, handler, messageID string, ttl time.Duration) error {
+	return s.cache.Set(ctx, s.key(handler, messageID), []byte(cacheProcessed), ttl)
+}
+
+// Release implements Store
+func (s *CacheStore) Release(ctx context.Context, handler, messageID string) error {
+	return s.cache.Delete(ctx, s.key(handler, messageID))
+}
@@ -0,0 +1,115 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	statusProcessing = "processing"
+	statusProcessed  = "processed"
+)
+
+// Entry is a row of the inbox table
+type Entry struct {
+	Handler   string    `json:"handler" gorm:"size:255;primaryKey"`
+	MessageID string    `json:"message_id" gorm:"size:255;primaryKey"`
+	Status    string    `json:"status" gorm:"size:20;not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+}
+
+// TableName overrides the table name
+func (Entry) TableName() string {
+	return "inbox_messages"
+}
+
+// GormStore keeps the inbox in a database table; run Prune periodically to
+// drop expired entries
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore creates a database backed store
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// AutoMigrate creates or updates the inbox table
+func (s *GormStore) AutoMigrate() error {
+	return s.db.AutoMigrate(&Entry{})
+}
+
+// Claim implements Store
+func (s *GormStore) Claim(ctx context.Context, handler, messageID string, lease time.Duration) (Claim, error) {
+	db := s.db.WithContext(ctx)
+	now := time.Now()
+
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&Entry{
+		Handler:   handler,
+		MessageID: messageID,
+		Status:    statusProcessing,
+		ExpiresAt: now.Add(lease),
+		CreatedAt: now,
+	})
+	if res.Error != nil {
+		return Busy, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return Acquired, nil
+	}
+
+	// Take over an expired claim or a forgotten entry
+	res = db.Model(&Entry{}).
+		Where("handler = ? AND message_id = ? AND expires_at < ?", handler, messageID, now).
+		Updates(map[string]interface{}{
+			"status":     statusProcessing,
+			"expires_at": now.Add(lease),
+		})
+	if res.Error != nil {
+		return Busy, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return Acquired, nil
+	}
+
+	var entry Entry
+	err := db.Where("handler = ? AND message_id = ?", handler, messageID).Take(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Busy, nil
+	}
+	if err != nil {
+		return Busy, err
+	}
+	if entry.Status == statusProcessed {
+		return Processed, nil
+	}
+	return Busy, nil
+}
+
+// Complete implements Store
+func (s *GormStore) Complete(ctx context.Context, handler, messageID string, ttl time.Duration) error {
+	return s.db.WithContext(ctx).Model(&Entry{}).
+		Where("handler = ? AND message_id = ?", handler, messageID).
+		Updates(map[string]interface{}{
+			"status":     statusProcessed,
+			"expires_at": time.Now().Add(ttl),
+		}).Error
+}
+
+// Release implements Store
+func (s *GormStore) Release(ctx context.Context, handler, messageID string) error {
+	return s.db.WithContext(ctx).
+		Where("handler = ? AND message_id = ?", handler, messageID).
+		Delete(&Entry{}).Error
+}
+
+// Prune deletes expired entries and returns their number
+func (s *GormStore) Prune(ctx context.Context) (int64, error) {
+	res := s.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&Entry{})
+	return res.RowsAffected, res.Error
+}
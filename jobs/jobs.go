@@ -0,0 +1,189 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultQueue is used when no queue is given
+const DefaultQueue = "default"
+
+var (
+	// ErrDuplicate is returned when a job with the same ID is queued
+	ErrDuplicate = errors.New("jobs: job already exists")
+	// ErrNotFound is returned for unknown jobs
+	ErrNotFound = errors.New("jobs: job not found")
+)
+
+// Job is a unit of background work
+type Job struct {
+	ID      string `json:"id"`
+	Queue   string `json:"queue"`
+	Type    string `json:"type"`
+	Payload []byte `json:"payload"`
+	// Attempts counts the executions started so far, including the
+	// current one
+	Attempts    int `json:"attempts"`
+	MaxAttempts int `json:"max_attempts"`
+	// RunAt is when the job was scheduled to run first
+	RunAt     time.Time `json:"run_at"`
+	CreatedAt time.Time `json:"created_at"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Decode unmarshals the JSON payload into v
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// Handler executes a job. Returning an error retries it with backoff until
+// MaxAttempts is reached, then moves it to the dead-letter queue; errors
+// wrapped with Permanent go there right away
+type Handler func(ctx context.Context, job *Job) error
+
+// ============================================
+// Store
+// ============================================
+
+// Store persists queued jobs. A dequeued job is leased: it becomes due
+// again when the lease expires without Complete, Retry or Kill, so jobs of
+// crashed workers are picked up again (at-least-once)
+type Store interface {
+	// Enqueue adds a job; ErrDuplicate when its ID exists
+	Enqueue(ctx context.Context, job *Job) error
+	// Dequeue leases the next due job of queue and increments its
+	// attempts; nil when no job is due
+	Dequeue(ctx context.Context, queue string, lease time.Duration) (*Job, error)
+	// Complete removes a finished job
+	Complete(ctx context.Context, job *Job) error
+	// Retry schedules a failed job at runAt, recording job.LastError
+	Retry(ctx context.Context, job *Job, runAt time.Time) error
+	// Kill moves a job to the dead-letter queue, recording job.LastError
+	Kill(ctx context.Context, job *Job) error
+	// Dead lists up to limit dead jobs of queue, newest first
+	Dead(ctx context.Context, queue string, limit int) ([]*Job, error)
+	// Requeue moves a dead job back to its queue with its attempts reset
+	Requeue(ctx context.Context, queue, id string) error
+}
+
+// ============================================
+// Client
+// ============================================
+
+// Client enqueues jobs
+type Client struct {
+	store Store
+}
+
+// NewClient creates a client for store
+func NewClient(store Store) *Client {
+	return &Client{store: store}
+}
+
+// EnqueueOption configures an enqueued job
+type EnqueueOption func(*Job)
+
+// WithQueue puts the job on a queue other than DefaultQueue
+func WithQueue(queue string) EnqueueOption {
+	return func(j *Job) {
+		j.Queue = queue
+	}
+}
+
+// WithDelay runs the job after d
+func WithDelay(d time.Duration) EnqueueOption {
+	return func(j *Job) {
+		j.RunAt = time.Now().Add(d)
+	}
+}
+
+// WithRunAt runs the job at t
+func WithRunAt(t time.Time) EnqueueOption {
+	return func(j *Job) {
+		j.RunAt = t
+	}
+}
+
+// WithMaxAttempts sets the number of executions before the job is dead
+// (default: 5)
+func WithMaxAttempts(n int) EnqueueOption {
+	return func(j *Job) {
+		j.MaxAttempts = n
+	}
+}
+
+// WithID sets the job ID; enqueueing an ID that is queued or dead fails with
+// ErrDuplicate, which makes jobs unique
+func WithID(id string) EnqueueOption {
+	return func(j *Job) {
+		j.ID = id
+	}
+}
+
+// Enqueue queues a job of jobType. payload is stored as is when it is a
+// []byte and as JSON otherwise
+func (c *Client) Enqueue(ctx context.Context, jobType string, payload interface{}, opts ...EnqueueOption) (*Job, error) {
+	var data []byte
+	switch p := payload.(type) {
+	case nil:
+	case []byte:
+		data = p
+	default:
+		var err error
+		if data, err = json.Marshal(p); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:          uuid.NewString(),
+		Queue:       DefaultQueue,
+		Type:        jobType,
+		Payload:     data,
+		MaxAttempts: 5,
+		RunAt:       now,
+		CreatedAt:   now,
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+	if job.MaxAttempts < 1 {
+		job.MaxAttempts = 1
+	}
+
+	if err := c.store.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ============================================
+// Permanent Errors
+// ============================================
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error as not retryable; the job goes to the
+// dead-letter queue
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
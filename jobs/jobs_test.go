@@ -0,0 +1,281 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWorker(store Store) *Worker {
+	return NewWorker(store, WorkerConfig{
+		Concurrency:    1,
+		PollInterval:   5 * time.Millisecond,
+		InitialBackoff: time.Nanosecond,
+	})
+}
+
+func TestEnqueueAndRun(t *testing.T) {
+	store := NewMemoryStore()
+	client := NewClient(store)
+	worker := newTestWorker(store)
+
+	type payload struct {
+		Email string `json:"email"`
+	}
+	var got payload
+	worker.Handle("email.send", func(ctx context.Context, job *Job) error {
+		return job.Decode(&got)
+	})
+
+	job, err := client.Enqueue(context.Background(), "email.send", payload{Email: "a@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultQueue, job.Queue)
+	assert.Equal(t, 5, job.MaxAttempts)
+
+	ran, err := worker.RunNext(context.Background())
+	require.NoError(t, err)
+	assert.True(t, ran)
+	assert.Equal(t, "a@example.com", got.Email)
+
+	ran, err = worker.RunNext(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ran)
+}
+
+func TestRetryThenDead(t *testing.T) {
+	store := NewMemoryStore()
+	worker := newTestWorker(store)
+
+	var calls int
+	worker.Handle("flaky", func(ctx context.Context, job *Job) error {
+		calls++
+		assert.Equal(t, calls, job.Attempts)
+		return errors.New("boom")
+	})
+
+	_, err := NewClient(store).Enqueue(context.Background(), "flaky", nil, WithMaxAttempts(3))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond)
+		ran, err := worker.RunNext(context.Background())
+		require.NoError(t, err)
+		assert.True(t, ran)
+	}
+	ran, _ := worker.RunNext(context.Background())
+	assert.False(t, ran)
+	assert.Equal(t, 3, calls)
+
+	dead, err := store.Dead(context.Background(), DefaultQueue, 10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "boom", dead[0].LastError)
+	assert.Equal(t, 3, dead[0].Attempts)
+}
+
+func TestPermanentAndUnknownTypeGoDead(t *testing.T) {
+	store := NewMemoryStore()
+	client := NewClient(store)
+	worker := newTestWorker(store)
+
+	worker.Handle("invalid", func(ctx context.Context, job *Job) error {
+		return Permanent(errors.New("bad payload"))
+	})
+
+	_, err := client.Enqueue(context.Background(), "invalid", nil)
+	require.NoError(t, err)
+	_, err = client.Enqueue(context.Background(), "unknown", nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err := worker.RunNext(context.Background())
+		require.NoError(t, err)
+	}
+
+	dead, err := store.Dead(context.Background(), DefaultQueue, 0)
+	require.NoError(t, err)
+	assert.Len(t, dead, 2)
+	for _, job := range dead {
+		assert.Equal(t, 1, job.Attempts)
+	}
+}
+
+func TestPanicIsRecovered(t *testing.T) {
+	store := NewMemoryStore()
+	worker := newTestWorker(store)
+	worker.Handle("panics", func(ctx context.Context, job *Job) error {
+		panic("oops")
+	})
+
+	_, err := NewClient(store).Enqueue(context.Background(), "panics", nil, WithMaxAttempts(1))
+	require.NoError(t, err)
+
+	_, err = worker.RunNext(context.Background())
+	require.NoError(t, err)
+
+	dead, _ := store.Dead(context.Background(), DefaultQueue, 0)
+	require.Len(t, dead, 1)
+	assert.Contains(t, dead[0].LastError, "oops")
+}
+
+func TestDelayedJob(t *testing.T) {
+	store := NewMemoryStore()
+	worker := newTestWorker(store)
+	worker.Handle("later", func(ctx context.Context, job *Job) error { return nil })
+
+	_, err := NewClient(store).Enqueue(context.Background(), "later", nil, WithDelay(30*time.Millisecond))
+	require.NoError(t, err)
+
+	ran, _ := worker.RunNext(context.Background())
+	assert.False(t, ran)
+
+	time.Sleep(40 * time.Millisecond)
+	ran, _ = worker.RunNext(context.Background())
+	assert.True(t, ran)
+}
+
+func TestQueuePriority(t *testing.T) {
+	store := NewMemoryStore()
+	client := NewClient(store)
+	worker := NewWorker(store, WorkerConfig{Queues: []string{"critical", DefaultQueue}})
+
+	var order []string
+	handler := func(ctx context.Context, job *Job) error {
+		order = append(order, job.Queue)
+		return nil
+	}
+	worker.Handle("task", handler)
+
+	_, _ = client.Enqueue(context.Background(), "task", nil)
+	_, _ = client.Enqueue(context.Background(), "task", nil, WithQueue("critical"))
+
+	for i := 0; i < 2; i++ {
+		_, err := worker.RunNext(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"critical", DefaultQueue}, order)
+}
+
+func TestUniqueID(t *testing.T) {
+	client := NewClient(NewMemoryStore())
+
+	_, err := client.Enqueue(context.Background(), "report", nil, WithID("report-2024-01"))
+	require.NoError(t, err)
+
+	_, err = client.Enqueue(context.Background(), "report", nil, WithID("report-2024-01"))
+	assert.ErrorIs(t, err, ErrDuplicate)
+}
+
+func TestRequeueDeadJob(t *testing.T) {
+	store := NewMemoryStore()
+	worker := newTestWorker(store)
+
+	var fail atomic.Bool
+	fail.Store(true)
+	worker.Handle("task", func(ctx context.Context, job *Job) error {
+		if fail.Load() {
+			return Permanent(errors.New("down"))
+		}
+		return nil
+	})
+
+	job, err := NewClient(store).Enqueue(context.Background(), "task", nil)
+	require.NoError(t, err)
+	_, err = worker.RunNext(context.Background())
+	require.NoError(t, err)
+
+	fail.Store(false)
+	require.NoError(t, store.Requeue(context.Background(), DefaultQueue, job.ID))
+	assert.ErrorIs(t, store.Requeue(context.Background(), DefaultQueue, job.ID), ErrNotFound)
+
+	ran, err := worker.RunNext(context.Background())
+	require.NoError(t, err)
+	assert.True(t, ran)
+
+	dead, _ := store.Dead(context.Background(), DefaultQueue, 0)
+	assert.Empty(t, dead)
+}
+
+func TestLeaseExpiryRedelivers(t *testing.T) {
+	store := NewMemoryStore()
+	_, err := NewClient(store).Enqueue(context.Background(), "task", nil)
+	require.NoError(t, err)
+
+	first, err := store.Dequeue(context.Background(), DefaultQueue, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+
+	again, _ := store.Dequeue(context.Background(), DefaultQueue, 10*time.Millisecond)
+	assert.Nil(t, again)
+
+	time.Sleep(15 * time.Millisecond)
+	again, err = store.Dequeue(context.Background(), DefaultQueue, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, again)
+	assert.Equal(t, first.ID, again.ID)
+	assert.Equal(t, 2, again.Attempts)
+}
+
+func TestWorkerStartStop(t *testing.T) {
+	store := NewMemoryStore()
+	worker := newTestWorker(store)
+
+	done := make(chan struct{})
+	worker.Handle("task", func(ctx context.Context, job *Job) error {
+		close(done)
+		return nil
+	})
+	worker.Start()
+
+	_, err := NewClient(store).Enqueue(context.Background(), "task", nil)
+	require.NoError(t, err)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job was not run")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, worker.Stop(ctx))
+}
+
+func TestStopCancelsRunningJobs(t *testing.T) {
+	store := NewMemoryStore()
+	worker := newTestWorker(store)
+
+	started := make(chan struct{})
+	worker.Handle("slow", func(ctx context.Context, job *Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	worker.Start()
+
+	_, err := NewClient(store).Enqueue(context.Background(), "slow", nil)
+	require.NoError(t, err)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, worker.Stop(ctx), context.DeadlineExceeded)
+}
+
+func TestWorkerBackoff(t *testing.T) {
+	w := NewWorker(nil, WorkerConfig{
+		InitialBackoff: time.Second,
+		MaxBackoff:     10 * time.Second,
+		BackoffFactor:  2,
+	})
+
+	assert.Equal(t, time.Second, w.backoff(1))
+	assert.Equal(t, 2*time.Second, w.backoff(2))
+	assert.Equal(t, 8*time.Second, w.backoff(4))
+	assert.Equal(t, 10*time.Second, w.backoff(5))
+}
@@ -0,0 +1,209 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Record states
+const (
+	StatusPending = "pending"
+	StatusDead    = "dead"
+)
+
+// Record is a row of the jobs table
+type Record struct {
+	ID          string    `json:"id" gorm:"size:255;primaryKey"`
+	Queue       string    `json:"queue" gorm:"size:100;not null;index:idx_jobs_due,priority:1"`
+	Status      string    `json:"status" gorm:"size:20;not null;index:idx_jobs_due,priority:2"`
+	RunAt       time.Time `json:"run_at" gorm:"not null;index:idx_jobs_due,priority:3"`
+	Type        string    `json:"type" gorm:"size:255;not null"`
+	Payload     []byte    `json:"payload"`
+	Attempts    int       `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int       `json:"max_attempts" gorm:"not null"`
+	LastError   string    `json:"last_error,omitempty" gorm:"type:text"`
+	ScheduledAt time.Time `json:"scheduled_at" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (Record) TableName() string {
+	return "jobs"
+}
+
+func (r *Record) job() *Job {
+	return &Job{
+		ID:          r.ID,
+		Queue:       r.Queue,
+		Type:        r.Type,
+		Payload:     r.Payload,
+		Attempts:    r.Attempts,
+		MaxAttempts: r.MaxAttempts,
+		RunAt:       r.ScheduledAt,
+		CreatedAt:   r.CreatedAt,
+		LastError:   r.LastError,
+	}
+}
+
+// GormStore keeps jobs in a database table. On Postgres and MySQL workers
+// skip rows locked by each other, so dequeueing does not contend
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore creates a database backed store
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// AutoMigrate creates or updates the jobs table
+func (s *GormStore) AutoMigrate() error {
+	return s.db.AutoMigrate(&Record{})
+}
+
+// Enqueue implements Store. Passing a transaction with WithTx enqueues the
+// job only if the transaction commits
+func (s *GormStore) Enqueue(ctx context.Context, job *Job) error {
+	res := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&Record{
+		ID:          job.ID,
+		Queue:       job.Queue,
+		Status:      StatusPending,
+		RunAt:       job.RunAt,
+		Type:        job.Type,
+		Payload:     job.Payload,
+		MaxAttempts: job.MaxAttempts,
+		ScheduledAt: job.RunAt,
+		CreatedAt:   job.CreatedAt,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+// WithTx returns a store using tx, e.g. to enqueue jobs together with the
+// writes that cause them
+func (s *GormStore) WithTx(tx *gorm.DB) *GormStore {
+	return &GormStore{db: tx}
+}
+
+// Dequeue implements Store
+func (s *GormStore) Dequeue(ctx context.Context, queue string, lease time.Duration) (*Job, error) {
+	var job *Job
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		query := tx.Where("queue = ? AND status = ? AND run_at <= ?", queue, StatusPending, now).
+			Order("run_at ASC")
+		switch tx.Dialector.Name() {
+		case "postgres", "mysql":
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+
+		var record Record
+		err := query.Take(&record).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Matching the old run_at lets only one of two racing workers
+		// lease the job where rows cannot be locked
+		res := tx.Model(&Record{}).
+			Where("id = ? AND run_at = ?", record.ID, record.RunAt).
+			Updates(map[string]interface{}{
+				"run_at":   now.Add(lease),
+				"attempts": gorm.Expr("attempts + 1"),
+			})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		record.Attempts++
+		job = record.job()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Complete implements Store
+func (s *GormStore) Complete(ctx context.Context, job *Job) error {
+	return s.db.WithContext(ctx).Where("id = ?", job.ID).Delete(&Record{}).Error
+}
+
+// Retry implements Store
+func (s *GormStore) Retry(ctx context.Context, job *Job, runAt time.Time) error {
+	return s.update(ctx, job.ID, map[string]interface{}{
+		"run_at":     runAt,
+		"last_error": job.LastError,
+	})
+}
+
+// Kill implements Store
+func (s *GormStore) Kill(ctx context.Context, job *Job) error {
+	return s.update(ctx, job.ID, map[string]interface{}{
+		"status":     StatusDead,
+		"last_error": job.LastError,
+	})
+}
+
+func (s *GormStore) update(ctx context.Context, id string, updates map[string]interface{}) error {
+	res := s.db.WithContext(ctx).Model(&Record{}).Where("id = ?", id).Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Dead implements Store
+func (s *GormStore) Dead(ctx context.Context, queue string, limit int) ([]*Job, error) {
+	query := s.db.WithContext(ctx).
+		Where("queue = ? AND status = ?", queue, StatusDead).
+		Order("updated_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var records []Record
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	jobs := make([]*Job, len(records))
+	for i := range records {
+		jobs[i] = records[i].job()
+	}
+	return jobs, nil
+}
+
+// Requeue implements Store
+func (s *GormStore) Requeue(ctx context.Context, queue, id string) error {
+	res := s.db.WithContext(ctx).Model(&Record{}).
+		Where("id = ? AND queue = ? AND status = ?", id, queue, StatusDead).
+		Updates(map[string]interface{}{
+			"status":   StatusPending,
+			"attempts": 0,
+			"run_at":   time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
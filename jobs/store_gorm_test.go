@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestGormStore(t *testing.T) *GormStore {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	store := NewGormStore(db)
+	require.NoError(t, store.AutoMigrate())
+	return store
+}
+
+func TestGormStoreRoundTrip(t *testing.T) {
+	store := newTestGormStore(t)
+	client := NewClient(store)
+	ctx := context.Background()
+
+	job, err := client.Enqueue(ctx, "email.send", map[string]string{"email": "a@example.com"}, WithID("job-1"))
+	require.NoError(t, err)
+	_, err = client.Enqueue(ctx, "email.send", nil, WithID("job-1"))
+	assert.ErrorIs(t, err, ErrDuplicate)
+
+	leased, err := store.Dequeue(ctx, DefaultQueue, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, leased)
+	assert.Equal(t, job.ID, leased.ID)
+	assert.Equal(t, 1, leased.Attempts)
+	assert.JSONEq(t, `{"email":"a@example.com"}`, string(leased.Payload))
+
+	none, err := store.Dequeue(ctx, DefaultQueue, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, none, "leased jobs are not handed out twice")
+
+	leased.LastError = "smtp down"
+	require.NoError(t, store.Retry(ctx, leased, time.Now().Add(-time.Second)))
+	leased, err = store.Dequeue(ctx, DefaultQueue, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, leased)
+	assert.Equal(t, 2, leased.Attempts)
+	assert.Equal(t, "smtp down", leased.LastError)
+
+	require.NoError(t, store.Kill(ctx, leased))
+	dead, err := store.Dead(ctx, DefaultQueue, 10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, job.ID, dead[0].ID)
+
+	require.NoError(t, store.Requeue(ctx, DefaultQueue, job.ID))
+	assert.ErrorIs(t, store.Requeue(ctx, DefaultQueue, job.ID), ErrNotFound)
+	leased, err = store.Dequeue(ctx, DefaultQueue, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, leased)
+	assert.Equal(t, 1, leased.Attempts, "requeue resets attempts")
+
+	require.NoError(t, store.Complete(ctx, leased))
+	assert.ErrorIs(t, store.Retry(ctx, leased, time.Now()), ErrNotFound)
+}
@@ -0,0 +1,145 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps jobs in process memory, for tests and local
+// development; jobs are lost on restart
+type MemoryStore struct {
+	mu     sync.Mutex
+	queues map[string]map[string]*memoryEntry
+}
+
+type memoryEntry struct {
+	job    Job
+	due    time.Time
+	dead   bool
+	diedAt time.Time
+}
+
+// NewMemoryStore creates an in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{queues: make(map[string]map[string]*memoryEntry)}
+}
+
+// Enqueue implements Store
+func (s *MemoryStore) Enqueue(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := s.queues[job.Queue]
+	if q == nil {
+		q = make(map[string]*memoryEntry)
+		s.queues[job.Queue] = q
+	}
+	if _, ok := q[job.ID]; ok {
+		return ErrDuplicate
+	}
+	q[job.ID] = &memoryEntry{job: *job, due: job.RunAt}
+	return nil
+}
+
+// Dequeue implements Store
+func (s *MemoryStore) Dequeue(ctx context.Context, queue string, lease time.Duration) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var next *memoryEntry
+	for _, e := range s.queues[queue] {
+		if e.dead || e.due.After(now) {
+			continue
+		}
+		if next == nil || e.due.Before(next.due) {
+			next = e
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	next.due = now.Add(lease)
+	next.job.Attempts++
+	job := next.job
+	return &job, nil
+}
+
+// Complete implements Store
+func (s *MemoryStore) Complete(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.queues[job.Queue], job.ID)
+	return nil
+}
+
+// Retry implements Store
+func (s *MemoryStore) Retry(ctx context.Context, job *Job, runAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.queues[job.Queue][job.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	e.due = runAt
+	e.job.LastError = job.LastError
+	return nil
+}
+
+// Kill implements Store
+func (s *MemoryStore) Kill(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.queues[job.Queue][job.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	e.dead = true
+	e.diedAt = time.Now()
+	e.job.LastError = job.LastError
+	return nil
+}
+
+// Dead implements Store
+func (s *MemoryStore) Dead(ctx context.Context, queue string, limit int) ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var dead []*memoryEntry
+	for _, e := range s.queues[queue] {
+		if e.dead {
+			dead = append(dead, e)
+		}
+	}
+	sort.Slice(dead, func(i, j int) bool { return dead[i].diedAt.After(dead[j].diedAt) })
+	if limit > 0 && len(dead) > limit {
+		dead = dead[:limit]
+	}
+
+	jobs := make([]*Job, len(dead))
+	for i, e := range dead {
+		job := e.job
+		jobs[i] = &job
+	}
+	return jobs, nil
+}
+
+// Requeue implements Store
+func (s *MemoryStore) Requeue(ctx context.Context, queue, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.queues[queue][id]
+	if !ok || !e.dead {
+		return ErrNotFound
+	}
+	e.dead = false
+	e.due = time.Now()
+	e.job.Attempts = 0
+	return nil
+}
@@ -0,0 +1,226 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps jobs in Redis. Each queue uses a sorted set of job IDs
+// scored by due time, a hash per job and a sorted set of dead jobs; the
+// keys of a queue share a hash tag so they live on one cluster slot
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a Redis backed store; keys are prefixed with
+// "jobs"
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client, prefix: "jobs"}
+}
+
+func (s *RedisStore) pendingKey(queue string) string {
+	return s.prefix + ":{" + queue + "}:pending"
+}
+
+func (s *RedisStore) deadKey(queue string) string {
+	return s.prefix + ":{" + queue + "}:dead"
+}
+
+func (s *RedisStore) jobPrefix(queue string) string {
+	return s.prefix + ":{" + queue + "}:job:"
+}
+
+// redisJob holds the fields of a job that do not change while it is queued
+type redisJob struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	Payload     []byte    `json:"payload"`
+	MaxAttempts int       `json:"max_attempts"`
+	RunAt       time.Time `json:"run_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+var enqueueScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	return 0
+end
+redis.call('HSET', KEYS[2], 'data', ARGV[1], 'attempts', 0, 'last_error', '')
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[3])
+return 1
+`)
+
+// Enqueue implements Store
+func (s *RedisStore) Enqueue(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(redisJob{
+		ID:          job.ID,
+		Type:        job.Type,
+		Payload:     job.Payload,
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       job.RunAt,
+		CreatedAt:   job.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	added, err := enqueueScript.Run(ctx, s.client,
+		[]string{s.pendingKey(job.Queue), s.jobPrefix(job.Queue) + job.ID},
+		data, job.RunAt.UnixMilli(), job.ID,
+	).Int()
+	if err != nil {
+		return err
+	}
+	if added == 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+// dequeueScript leases the earliest due job by moving its score past the
+// lease and returns its data, attempts and last error
+var dequeueScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 1)
+if #ids == 0 then
+	return false
+end
+local id = ids[1]
+local key = ARGV[3] .. id
+if redis.call('EXISTS', key) == 0 then
+	redis.call('ZREM', KEYS[1], id)
+	return false
+end
+redis.call('ZADD', KEYS[1], tonumber(ARGV[1]) + tonumber(ARGV[2]), id)
+local attempts = redis.call('HINCRBY', key, 'attempts', 1)
+return {redis.call('HGET', key, 'data'), tostring(attempts), redis.call('HGET', key, 'last_error')}
+`)
+
+// Dequeue implements Store
+func (s *RedisStore) Dequeue(ctx context.Context, queue string, lease time.Duration) (*Job, error) {
+	res, err := dequeueScript.Run(ctx, s.client,
+		[]string{s.pendingKey(queue)},
+		time.Now().UnixMilli(), lease.Milliseconds(), s.jobPrefix(queue),
+	).Slice()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(res) != 3 {
+		return nil, errors.New("jobs: unexpected dequeue result")
+	}
+
+	data, _ := res[0].(string)
+	attempts, _ := res[1].(string)
+	lastError, _ := res[2].(string)
+	return decodeRedisJob(queue, data, attempts, lastError)
+}
+
+func decodeRedisJob(queue, data, attempts, lastError string) (*Job, error) {
+	var stored redisJob
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(attempts)
+	return &Job{
+		ID:          stored.ID,
+		Queue:       queue,
+		Type:        stored.Type,
+		Payload:     stored.Payload,
+		Attempts:    n,
+		MaxAttempts: stored.MaxAttempts,
+		RunAt:       stored.RunAt,
+		CreatedAt:   stored.CreatedAt,
+		LastError:   lastError,
+	}, nil
+}
+
+// Complete implements Store
+func (s *RedisStore) Complete(ctx context.Context, job *Job) error {
+	_, err := s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.ZRem(ctx, s.pendingKey(job.Queue), job.ID)
+		p.Del(ctx, s.jobPrefix(job.Queue)+job.ID)
+		return nil
+	})
+	return err
+}
+
+// Retry implements Store
+func (s *RedisStore) Retry(ctx context.Context, job *Job, runAt time.Time) error {
+	_, err := s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, s.jobPrefix(job.Queue)+job.ID, "last_error", job.LastError)
+		p.ZAddXX(ctx, s.pendingKey(job.Queue), redis.Z{Score: float64(runAt.UnixMilli()), Member: job.ID})
+		return nil
+	})
+	return err
+}
+
+// Kill implements Store
+func (s *RedisStore) Kill(ctx context.Context, job *Job) error {
+	_, err := s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, s.jobPrefix(job.Queue)+job.ID, "last_error", job.LastError)
+		p.ZRem(ctx, s.pendingKey(job.Queue), job.ID)
+		p.ZAdd(ctx, s.deadKey(job.Queue), redis.Z{Score: float64(time.Now().UnixMilli()), Member: job.ID})
+		return nil
+	})
+	return err
+}
+
+// Dead implements Store
+func (s *RedisStore) Dead(ctx context.Context, queue string, limit int) ([]*Job, error) {
+	stop := int64(-1)
+	if limit > 0 {
+		stop = int64(limit - 1)
+	}
+	ids, err := s.client.ZRevRange(ctx, s.deadKey(queue), 0, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*Job, 0, len(ids))
+	for _, id := range ids {
+		fields, err := s.client.HGetAll(ctx, s.jobPrefix(queue)+id).Result()
+		if err != nil {
+			return nil, err
+		}
+		if fields["data"] == "" {
+			continue
+		}
+		job, err := decodeRedisJob(queue, fields["data"], fields["attempts"], fields["last_error"])
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+var requeueScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[3], 'attempts', 0)
+redis.call('ZADD', KEYS[2], ARGV[2], ARGV[1])
+return 1
+`)
+
+// Requeue implements Store
+func (s *RedisStore) Requeue(ctx context.Context, queue, id string) error {
+	moved, err := requeueScript.Run(ctx, s.client,
+		[]string{s.deadKey(queue), s.pendingKey(queue), s.jobPrefix(queue) + id},
+		id, time.Now().UnixMilli(),
+	).Int()
+	if err != nil {
+		return err
+	}
+	if moved == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minisource/go-common/logging"
+)
+
+// WorkerConfig configures a worker pool
+type WorkerConfig struct {
+	// Queues are polled in order, so earlier queues have priority
+	// (default: DefaultQueue)
+	Queues []string
+	// Concurrency is the number of jobs run in parallel (default: 10)
+	Concurrency int
+	// PollInterval is the wait after finding no due job (default: 1s)
+	PollInterval time.Duration
+	// Lease is how long a job may run before it is cancelled and becomes
+	// due for another worker (default: 5m)
+	Lease time.Duration
+	// InitialBackoff, MaxBackoff and BackoffFactor space the attempts of a
+	// failing job
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	BackoffFactor  float64
+	Logger         logging.Logger
+}
+
+// DefaultWorkerConfig returns default worker configuration
+func DefaultWorkerConfig() WorkerConfig {
+	return WorkerConfig{
+		Queues:         []string{DefaultQueue},
+		Concurrency:    10,
+		PollInterval:   time.Second,
+		Lease:          5 * time.Minute,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Hour,
+		BackoffFactor:  2.0,
+	}
+}
+
+// Worker runs queued jobs with their registered handlers
+type Worker struct {
+	store Store
+	cfg   WorkerConfig
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	// ctx is cancelled when Stop gives up waiting for running jobs
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+	started atomic.Bool
+}
+
+// NewWorker creates a worker pool for store
+func NewWorker(store Store, cfg WorkerConfig) *Worker {
+	if len(cfg.Queues) == 0 {
+		cfg.Queues = []string{DefaultQueue}
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 10
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = 5 * time.Minute
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = time.Second
+	}
+	if cfg.BackoffFactor < 1 {
+		cfg.BackoffFactor = 2.0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Worker{
+		store:    store,
+		cfg:      cfg,
+		handlers: make(map[string]Handler),
+		ctx:      ctx,
+		cancel:   cancel,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Handle registers the handler of jobType
+func (w *Worker) Handle(jobType string, handler Handler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[jobType] = handler
+}
+
+// Start runs the worker pool until Stop is called
+func (w *Worker) Start() {
+	if !w.started.CompareAndSwap(false, true) {
+		return
+	}
+
+	for i := 0; i < w.cfg.Concurrency; i++ {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			for {
+				ran, err := w.RunNext(w.ctx)
+				if ran && err == nil {
+					if w.stopped() {
+						return
+					}
+					continue
+				}
+				select {
+				case <-w.stop:
+					return
+				case <-time.After(w.cfg.PollInterval):
+				}
+			}
+		}()
+	}
+}
+
+func (w *Worker) stopped() bool {
+	select {
+	case <-w.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop stops taking jobs and waits for running ones to finish; when ctx
+// ends first they are cancelled. It matches shutdown.Hook.
+func (w *Worker) Stop(ctx context.Context) error {
+	w.once.Do(func() { close(w.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		w.cancel()
+		return nil
+	case <-ctx.Done():
+		w.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// RunNext runs the next due job of the queues and reports whether there
+// was one
+func (w *Worker) RunNext(ctx context.Context) (bool, error) {
+	for _, queue := range w.cfg.Queues {
+		job, err := w.store.Dequeue(ctx, queue, w.cfg.Lease)
+		if err != nil {
+			w.log("Dequeue failed", nil, err)
+			return false, err
+		}
+		if job != nil {
+			return true, w.run(ctx, job)
+		}
+	}
+	return false, nil
+}
+
+// run executes job and records the outcome
+func (w *Worker) run(ctx context.Context, job *Job) error {
+	w.mu.RLock()
+	handler := w.handlers[job.Type]
+	w.mu.RUnlock()
+
+	var err error
+	if handler == nil {
+		err = Permanent(fmt.Errorf("no handler for job type %q", job.Type))
+	} else {
+		err = w.execute(ctx, handler, job)
+	}
+
+	if err == nil {
+		return w.store.Complete(context.Background(), job)
+	}
+
+	job.LastError = err.Error()
+	if IsPermanent(err) || job.Attempts >= job.MaxAttempts {
+		w.log("Job failed permanently", job, err)
+		return w.store.Kill(context.Background(), job)
+	}
+	w.log("Job failed", job, err)
+	return w.store.Retry(context.Background(), job, time.Now().Add(w.backoff(job.Attempts)))
+}
+
+// execute calls handler within the lease, turning panics into errors
+func (w *Worker) execute(ctx context.Context, handler Handler, job *Job) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, w.cfg.Lease)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// backoff returns the delay after the given number of failed attempts
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := float64(w.cfg.InitialBackoff)
+	for i := 1; i < attempts; i++ {
+		delay *= w.cfg.BackoffFactor
+		if w.cfg.MaxBackoff > 0 && delay >= float64(w.cfg.MaxBackoff) {
+			return w.cfg.MaxBackoff
+		}
+	}
+	return time.Duration(delay)
+}
+
+func (w *Worker) log(msg string, job *Job, err error) {
+	if w.cfg.Logger == nil {
+		return
+	}
+	extra := map[logging.ExtraKey]interface{}{
+		logging.ErrorMessage: err.Error(),
+	}
+	if job != nil {
+		extra[logging.ID] = job.ID
+		extra[logging.Name] = job.Type
+		extra["attempts"] = job.Attempts
+	}
+	w.cfg.Logger.Error(logging.Internal, logging.Job, msg, extra)
+}
@@ -0,0 +1,144 @@
+package limiter
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// AdaptiveConfig configures an adaptive concurrency limiter
+type AdaptiveConfig struct {
+	MinLimit     int
+	MaxLimit     int
+	InitialLimit int
+	// TargetLatency is the request latency above which the limit shrinks
+	TargetLatency time.Duration
+	// Backoff multiplies the limit when latency is over target (e.g. 0.9)
+	Backoff float64
+	// Smoothing is the weight of the newest sample in the latency average
+	Smoothing float64
+	// CPUFunc reports CPU utilisation (0.0 to 1.0); nil disables the signal
+	CPUFunc func() float64
+	// CPUThreshold sheds new requests while CPUFunc is above it
+	CPUThreshold float64
+}
+
+// DefaultAdaptiveConfig returns default adaptive limiter configuration
+func DefaultAdaptiveConfig() AdaptiveConfig {
+	return AdaptiveConfig{
+		MinLimit:      10,
+		MaxLimit:      1000,
+		InitialLimit:  100,
+		TargetLatency: 250 * time.Millisecond,
+		Backoff:       0.9,
+		Smoothing:     0.2,
+		CPUThreshold:  0.9,
+	}
+}
+
+// AdaptiveLimiter adjusts its in-flight limit from observed latency: the
+// limit grows by one for every limit's worth of requests completed under
+// target (additive increase) and shrinks by Backoff when the smoothed
+// latency exceeds it (multiplicative decrease). It shrinks at most once
+// per round trip: requests already in flight at a decrease don't shrink
+// the limit again. Requests over the limit are shed immediately.
+type AdaptiveLimiter struct {
+	mu           sync.Mutex
+	cfg          AdaptiveConfig
+	limit        float64
+	inFlight     int
+	latency      float64 // smoothed, in seconds
+	lastDecrease time.Time
+	now          func() time.Time
+}
+
+// NewAdaptiveLimiter creates an adaptive concurrency limiter
+func NewAdaptiveLimiter(cfg AdaptiveConfig) *AdaptiveLimiter {
+	defaults := DefaultAdaptiveConfig()
+	if cfg.MinLimit <= 0 {
+		cfg.MinLimit = defaults.MinLimit
+	}
+	if cfg.MaxLimit < cfg.MinLimit {
+		cfg.MaxLimit = max(defaults.MaxLimit, cfg.MinLimit)
+	}
+	if cfg.InitialLimit <= 0 {
+		cfg.InitialLimit = defaults.InitialLimit
+	}
+	cfg.InitialLimit = min(max(cfg.InitialLimit, cfg.MinLimit), cfg.MaxLimit)
+	if cfg.TargetLatency <= 0 {
+		cfg.TargetLatency = defaults.TargetLatency
+	}
+	if cfg.Backoff <= 0 || cfg.Backoff >= 1 {
+		cfg.Backoff = defaults.Backoff
+	}
+	if cfg.Smoothing <= 0 || cfg.Smoothing > 1 {
+		cfg.Smoothing = defaults.Smoothing
+	}
+	if cfg.CPUThreshold <= 0 {
+		cfg.CPUThreshold = defaults.CPUThreshold
+	}
+	return &AdaptiveLimiter{cfg: cfg, limit: float64(cfg.InitialLimit), now: time.Now}
+}
+
+// Acquire implements Gate. The returned release records the latency.
+func (l *AdaptiveLimiter) Acquire(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if l.cfg.CPUFunc != nil && l.cfg.CPUFunc() > l.cfg.CPUThreshold {
+		return nil, ErrOverloaded
+	}
+
+	l.mu.Lock()
+	if l.inFlight >= int(l.limit) {
+		l.mu.Unlock()
+		return nil, ErrOverloaded
+	}
+	l.inFlight++
+	l.mu.Unlock()
+
+	start := l.now()
+	var once sync.Once
+	return func() {
+		once.Do(func() { l.release(start) })
+	}, nil
+}
+
+func (l *AdaptiveLimiter) release(start time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+
+	now := l.now()
+	sample := now.Sub(start).Seconds()
+	if l.latency == 0 {
+		l.latency = sample
+	} else {
+		l.latency = l.cfg.Smoothing*sample + (1-l.cfg.Smoothing)*l.latency
+	}
+
+	if l.latency > l.cfg.TargetLatency.Seconds() {
+		if !start.Before(l.lastDecrease) {
+			l.limit = math.Max(float64(l.cfg.MinLimit), l.limit*l.cfg.Backoff)
+			l.lastDecrease = now
+		}
+	} else {
+		l.limit = math.Min(float64(l.cfg.MaxLimit), l.limit+1/l.limit)
+	}
+}
+
+// Limit returns the current in-flight limit
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// InFlight returns the number of requests being processed
+func (l *AdaptiveLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
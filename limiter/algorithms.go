@@ -0,0 +1,269 @@
+package limiter
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// algoBase holds the configuration shared by the algorithm limiters
+type algoBase struct {
+	mu  sync.Mutex
+	cfg RateConfig
+	now func() time.Time
+}
+
+func newAlgoBase(cfg RateConfig) algoBase {
+	return algoBase{cfg: cfg, now: time.Now}
+}
+
+// SetRate implements Reconfigurable. Per-key state is kept, so clients
+// don't get a fresh allowance when the limit changes.
+func (b *algoBase) SetRate(cfg RateConfig) error {
+	if cfg.Limit <= 0 || cfg.Window <= 0 {
+		return fmt.Errorf("%w: limit and window must be positive", ErrInvalidConfig)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.cfg.Limit = cfg.Limit
+	b.cfg.Window = cfg.Window
+	b.cfg.Burst = cfg.Burst
+	if b.cfg.Burst <= 0 {
+		b.cfg.Burst = cfg.Limit
+	}
+	return nil
+}
+
+// Rate returns the current configuration
+func (b *algoBase) Rate() RateConfig {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cfg
+}
+
+// ============================================
+// Fixed window
+// ============================================
+
+type fixedWindowState struct {
+	start time.Time
+	count int
+}
+
+type fixedWindow struct {
+	algoBase
+	store *keyStore[fixedWindowState]
+}
+
+func newFixedWindow(cfg RateConfig) *fixedWindow {
+	return &fixedWindow{algoBase: newAlgoBase(cfg), store: newKeyStore[fixedWindowState](cfg.TTL)}
+}
+
+// Stats implements StatsProvider
+func (l *fixedWindow) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.store.stats()
+}
+
+func (l *fixedWindow) Allow(key string) (Decision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	st := l.store.get(key, now)
+	if now.Sub(st.start) >= l.cfg.Window {
+		st.start = now.Truncate(l.cfg.Window)
+		st.count = 0
+	}
+
+	d := Decision{Limit: l.cfg.Limit, Reset: st.start.Add(l.cfg.Window).Sub(now)}
+	if st.count < l.cfg.Limit {
+		st.count++
+		d.Allowed = true
+	} else {
+		d.RetryAfter = d.Reset
+	}
+	d.Remaining = l.cfg.Limit - st.count
+	return d, nil
+}
+
+// ============================================
+// Sliding log
+// ============================================
+
+type slidingLog struct {
+	algoBase
+	store *keyStore[[]time.Time]
+}
+
+func newSlidingLog(cfg RateConfig) *slidingLog {
+	return &slidingLog{algoBase: newAlgoBase(cfg), store: newKeyStore[[]time.Time](cfg.TTL)}
+}
+
+// Stats implements StatsProvider
+func (l *slidingLog) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.store.stats()
+}
+
+func (l *slidingLog) Allow(key string) (Decision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	log := l.store.get(key, now)
+
+	// Drop requests that left the window
+	cutoff := now.Add(-l.cfg.Window)
+	i := 0
+	for i < len(*log) && !(*log)[i].After(cutoff) {
+		i++
+	}
+	*log = (*log)[i:]
+
+	d := Decision{Limit: l.cfg.Limit}
+	if len(*log) < l.cfg.Limit {
+		*log = append(*log, now)
+		d.Allowed = true
+	}
+	d.Remaining = l.cfg.Limit - len(*log)
+	if len(*log) > 0 {
+		d.Reset = (*log)[len(*log)-1].Add(l.cfg.Window).Sub(now)
+	}
+	if !d.Allowed {
+		// The oldest request leaving the window frees a slot
+		d.RetryAfter = (*log)[0].Add(l.cfg.Window).Sub(now)
+	}
+	return d, nil
+}
+
+// ============================================
+// Sliding window counter
+// ============================================
+
+type slidingWindowState struct {
+	start time.Time
+	curr  int
+	prev  int
+}
+
+type slidingWindow struct {
+	algoBase
+	store *keyStore[slidingWindowState]
+}
+
+func newSlidingWindow(cfg RateConfig) *slidingWindow {
+	return &slidingWindow{algoBase: newAlgoBase(cfg), store: newKeyStore[slidingWindowState](cfg.TTL)}
+}
+
+// Stats implements StatsProvider
+func (l *slidingWindow) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.store.stats()
+}
+
+func (l *slidingWindow) Allow(key string) (Decision, error) {
+	return l.allowWithin(key, 0), nil
+}
+
+// allowWithin admits the request if the key's usage is below limit, which
+// may be lower than the configured limit (see PriorityLimiter); a limit of
+// zero uses the configured one
+func (l *slidingWindow) allowWithin(key string, limit int) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if limit <= 0 {
+		limit = l.cfg.Limit
+	}
+	now := l.now()
+	window := l.cfg.Window
+	st := l.store.get(key, now)
+
+	start := now.Truncate(window)
+	switch {
+	case st.start.Equal(start):
+	case st.start.Add(window).Equal(start):
+		st.prev, st.curr = st.curr, 0
+		st.start = start
+	default:
+		st.prev, st.curr = 0, 0
+		st.start = start
+	}
+
+	// Weight the previous window by how much of it still overlaps
+	overlap := 1 - float64(now.Sub(start))/float64(window)
+	estimate := func() int {
+		return int(math.Ceil(float64(st.prev)*overlap)) + st.curr
+	}
+
+	d := Decision{Limit: limit, Reset: start.Add(window).Sub(now)}
+	if estimate() < limit {
+		st.curr++
+		d.Allowed = true
+	} else {
+		d.RetryAfter = d.Reset
+		if free := limit - st.curr - 1; free >= 0 && st.prev > 0 {
+			// Wait until the previous window's weight drops enough
+			elapsed := time.Duration(float64(window) * (1 - float64(free)/float64(st.prev)))
+			d.RetryAfter = max(0, start.Add(elapsed).Sub(now))
+		}
+	}
+	d.Remaining = max(0, limit-estimate())
+	return d
+}
+
+// ============================================
+// GCRA
+// ============================================
+
+type gcra struct {
+	algoBase
+	store *keyStore[time.Time] // theoretical arrival time per key
+}
+
+func newGCRA(cfg RateConfig) *gcra {
+	return &gcra{algoBase: newAlgoBase(cfg), store: newKeyStore[time.Time](cfg.TTL)}
+}
+
+// Stats implements StatsProvider
+func (l *gcra) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.store.stats()
+}
+
+func (l *gcra) Allow(key string) (Decision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	interval := l.cfg.Window / time.Duration(l.cfg.Limit)
+	tolerance := interval * time.Duration(l.cfg.Burst)
+	tat := l.store.get(key, now)
+
+	base := *tat
+	if base.Before(now) {
+		base = now
+	}
+	next := base.Add(interval)
+
+	d := Decision{Limit: l.cfg.Burst}
+	if allowAt := next.Add(-tolerance); now.Before(allowAt) {
+		d.Reset = base.Sub(now)
+		d.Remaining = 0
+		d.RetryAfter = allowAt.Sub(now)
+		return d, nil
+	}
+
+	*tat = next
+	d.Allowed = true
+	d.Reset = next.Sub(now)
+	d.Remaining = int((tolerance - next.Sub(now)) / interval)
+	return d, nil
+}
@@ -0,0 +1,99 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrOverloaded is returned when a request is shed by a concurrency limiter
+var ErrOverloaded = errors.New("too many concurrent requests")
+
+// Gate admits requests based on work in flight rather than request rate
+type Gate interface {
+	// Acquire admits a request; call release when it completes
+	Acquire(ctx context.Context) (release func(), err error)
+}
+
+// ConcurrencyConfig configures a max-in-flight limiter
+type ConcurrencyConfig struct {
+	// MaxInFlight is the number of requests processed at once
+	MaxInFlight int
+	// MaxQueue is the number of requests waiting for a slot (0: no queue)
+	MaxQueue int
+	// QueueTimeout is the longest a request waits for a slot
+	QueueTimeout time.Duration
+}
+
+// DefaultConcurrencyConfig returns default concurrency limiter configuration
+func DefaultConcurrencyConfig() ConcurrencyConfig {
+	return ConcurrencyConfig{
+		MaxInFlight:  100,
+		MaxQueue:     100,
+		QueueTimeout: 100 * time.Millisecond,
+	}
+}
+
+// ConcurrencyLimiter is a semaphore with a bounded, time limited queue
+type ConcurrencyLimiter struct {
+	cfg     ConcurrencyConfig
+	sem     chan struct{}
+	waiting atomic.Int64
+}
+
+// NewConcurrencyLimiter creates a max-in-flight limiter
+func NewConcurrencyLimiter(cfg ConcurrencyConfig) *ConcurrencyLimiter {
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = DefaultConcurrencyConfig().MaxInFlight
+	}
+	return &ConcurrencyLimiter{cfg: cfg, sem: make(chan struct{}, cfg.MaxInFlight)}
+}
+
+// Acquire implements Gate
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) (func(), error) {
+	select {
+	case l.sem <- struct{}{}:
+		return l.releaseFunc(), nil
+	default:
+	}
+
+	if l.cfg.MaxQueue <= 0 || l.cfg.QueueTimeout <= 0 {
+		return nil, ErrOverloaded
+	}
+	if l.waiting.Add(1) > int64(l.cfg.MaxQueue) {
+		l.waiting.Add(-1)
+		return nil, ErrOverloaded
+	}
+	defer l.waiting.Add(-1)
+
+	timer := time.NewTimer(l.cfg.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case l.sem <- struct{}{}:
+		return l.releaseFunc(), nil
+	case <-timer.C:
+		return nil, ErrOverloaded
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *ConcurrencyLimiter) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-l.sem })
+	}
+}
+
+// InFlight returns the number of requests being processed
+func (l *ConcurrencyLimiter) InFlight() int {
+	return len(l.sem)
+}
+
+// Waiting returns the number of queued requests
+func (l *ConcurrencyLimiter) Waiting() int {
+	return int(l.waiting.Load())
+}
@@ -0,0 +1,38 @@
+package limiter
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/response"
+)
+
+// Rate limit response headers (IETF RateLimit header fields draft)
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+)
+
+// SetHeaders writes the RateLimit-* headers of a decision, plus Retry-After
+// when the request was denied. Durations are rounded up to whole seconds.
+func SetHeaders(c *fiber.Ctx, d Decision) {
+	c.Set(HeaderRateLimitLimit, strconv.Itoa(d.Limit))
+	c.Set(HeaderRateLimitRemaining, strconv.Itoa(max(0, d.Remaining)))
+	c.Set(HeaderRateLimitReset, strconv.Itoa(seconds(d.Reset)))
+	if !d.Allowed {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(max(1, seconds(d.RetryAfter))))
+	}
+}
+
+// Reject writes the headers of a denied decision and a 429 response
+func Reject(c *fiber.Ctx, d Decision) error {
+	SetHeaders(c, d)
+	return response.TooManyRequests(c, "rate limit exceeded")
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,161 @@
+package limiter
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/metrics"
+)
+
+// statsInterval is how often tracked key gauges are refreshed
+const statsInterval = time.Second
+
+// KeyCount is a key with the number of times it was denied
+type KeyCount struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+// InstrumentedLimiter records Prometheus metrics for a limiter and keeps
+// an approximate list of the most denied keys
+type InstrumentedLimiter struct {
+	name      string
+	next      Limiter
+	maxKeys   int
+	mu        sync.Mutex
+	denied    map[string]int64
+	lastStats atomic.Int64
+	evicted   uint64
+}
+
+// Instrument wraps a limiter so its decisions are exported as metrics
+// labelled by name and key class (the key prefix before ':'). maxKeys
+// bounds the denied-key table (default 1000).
+func Instrument(name string, l Limiter, maxKeys int) *InstrumentedLimiter {
+	if maxKeys <= 0 {
+		maxKeys = 1000
+	}
+	return &InstrumentedLimiter{name: name, next: l, maxKeys: maxKeys, denied: make(map[string]int64)}
+}
+
+// Allow implements Limiter
+func (l *InstrumentedLimiter) Allow(key string) (Decision, error) {
+	d, err := l.next.Allow(key)
+	if err != nil {
+		metrics.RateLimitDecisionsTotal.WithLabelValues(l.name, keyClass(key), "error").Inc()
+		return d, err
+	}
+
+	result := "allowed"
+	if !d.Allowed {
+		result = "denied"
+		l.recordDenied(key)
+	}
+	metrics.RateLimitDecisionsTotal.WithLabelValues(l.name, keyClass(key), result).Inc()
+	l.refreshStats()
+	return d, nil
+}
+
+// recordDenied counts a denied key. When the table is full the least
+// denied key is replaced (space-saving), so heavy hitters stay listed.
+func (l *InstrumentedLimiter) recordDenied(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.denied[key]; ok || len(l.denied) < l.maxKeys {
+		l.denied[key]++
+		return
+	}
+
+	minKey, minCount := "", int64(-1)
+	for k, c := range l.denied {
+		if minCount < 0 || c < minCount {
+			minKey, minCount = k, c
+		}
+	}
+	delete(l.denied, minKey)
+	l.denied[key] = minCount + 1
+}
+
+// refreshStats updates the tracked key gauge and eviction counter at most
+// once per statsInterval
+func (l *InstrumentedLimiter) refreshStats() {
+	sp, ok := l.next.(StatsProvider)
+	if !ok {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := l.lastStats.Load()
+	if now-last < int64(statsInterval) || !l.lastStats.CompareAndSwap(last, now) {
+		return
+	}
+
+	stats := sp.Stats()
+	metrics.RateLimitTrackedKeys.WithLabelValues(l.name).Set(float64(stats.TrackedKeys))
+
+	l.mu.Lock()
+	delta := stats.Evicted - l.evicted
+	l.evicted = stats.Evicted
+	l.mu.Unlock()
+	if delta > 0 {
+		metrics.RateLimitKeysEvictedTotal.WithLabelValues(l.name).Add(float64(delta))
+	}
+}
+
+// TopDenied returns the n most denied keys
+func (l *InstrumentedLimiter) TopDenied(n int) []KeyCount {
+	l.mu.Lock()
+	top := make([]KeyCount, 0, len(l.denied))
+	for k, c := range l.denied {
+		top = append(top, KeyCount{Key: k, Count: c})
+	}
+	l.mu.Unlock()
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Key < top[j].Key
+	})
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// ResetDenied clears the denied-key table
+func (l *InstrumentedLimiter) ResetDenied() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.denied = make(map[string]int64)
+}
+
+// DebugHandler returns a Fiber handler listing the most limited keys.
+// Mount it on an internal or admin-only route; keys may identify users.
+// The "limit" query parameter caps the list (default 50).
+func (l *InstrumentedLimiter) DebugHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		body := fiber.Map{
+			"limiter":    l.name,
+			"top_denied": l.TopDenied(c.QueryInt("limit", 50)),
+		}
+		if sp, ok := l.next.(StatsProvider); ok {
+			stats := sp.Stats()
+			body["tracked_keys"] = stats.TrackedKeys
+			body["evicted_keys"] = stats.Evicted
+		}
+		return c.JSON(body)
+	}
+}
+
+// keyClass returns the class prefix of a key (ip, user, tenant...)
+func keyClass(key string) string {
+	if i := strings.Index(key, ":"); i > 0 {
+		return key[:i]
+	}
+	return "other"
+}
@@ -0,0 +1,305 @@
+package limiter
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// limiterEntry wraps a rate limiter with last access time
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Override replaces the default rate for a key or key prefix
+type Override struct {
+	Rate  rate.Limit
+	Burst int
+}
+
+// KeyedLimiter manages token bucket rate limiters per key (IP, user, tenant,
+// API key, route...) with automatic cleanup
+type KeyedLimiter struct {
+	keys      map[string]*limiterEntry
+	overrides map[string]Override
+	mu        *sync.RWMutex
+	r         rate.Limit
+	b         int
+	ttl       time.Duration
+	stopClean chan struct{}
+	stopped   bool
+	evicted   uint64
+}
+
+// IPRateLimiter is the former name of KeyedLimiter
+//
+// Deprecated: use KeyedLimiter
+type IPRateLimiter = KeyedLimiter
+
+// Config holds configuration for KeyedLimiter
+type Config struct {
+	Rate       rate.Limit    // Requests per second
+	Burst      int           // Maximum burst size
+	TTL        time.Duration // Time to keep inactive keys (default: 1 hour)
+	CleanupInt time.Duration // Cleanup interval (default: 5 minutes)
+	// Overrides maps exact keys, or prefixes ending in ':' or '*', to
+	// their own rate (e.g. "tenant:" for every tenant key)
+	Overrides map[string]Override
+}
+
+// DefaultConfig returns default rate limiter configuration
+func DefaultConfig() Config {
+	return Config{
+		Rate:       10,              // 10 requests per second
+		Burst:      20,              // burst of 20
+		TTL:        time.Hour,       // keep for 1 hour
+		CleanupInt: 5 * time.Minute, // clean every 5 minutes
+	}
+}
+
+// NewKeyedLimiter creates a keyed limiter from config with automatic cleanup
+func NewKeyedLimiter(cfg Config) *KeyedLimiter {
+	k := NewIPRateLimiterWithTTL(cfg.Rate, cfg.Burst, cfg.TTL, cfg.CleanupInt)
+	for key, o := range cfg.Overrides {
+		k.overrides[key] = o
+	}
+	return k
+}
+
+// NewIPRateLimiter creates a new IP rate limiter with automatic cleanup
+func NewIPRateLimiter(r rate.Limit, b int) *IPRateLimiter {
+	return NewIPRateLimiterWithTTL(r, b, time.Hour, 5*time.Minute)
+}
+
+// NewIPRateLimiterWithTTL creates a new IP rate limiter with custom TTL
+func NewIPRateLimiterWithTTL(r rate.Limit, b int, ttl, cleanupInterval time.Duration) *IPRateLimiter {
+	if ttl == 0 {
+		ttl = time.Hour
+	}
+	if cleanupInterval == 0 {
+		cleanupInterval = 5 * time.Minute
+	}
+
+	i := &KeyedLimiter{
+		keys:      make(map[string]*limiterEntry),
+		overrides: make(map[string]Override),
+		mu:        &sync.RWMutex{},
+		r:         r,
+		b:         b,
+		ttl:       ttl,
+		stopClean: make(chan struct{}),
+	}
+
+	// Start background cleanup goroutine
+	go i.cleanupLoop(cleanupInterval)
+
+	return i
+}
+
+// NewIPRateLimiterFromConfig creates a new IP rate limiter from config
+func NewIPRateLimiterFromConfig(cfg Config) *IPRateLimiter {
+	return NewKeyedLimiter(cfg)
+}
+
+// cleanupLoop periodically removes expired entries
+func (i *KeyedLimiter) cleanupLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			i.cleanup()
+		case <-i.stopClean:
+			return
+		}
+	}
+}
+
+// cleanup removes entries that haven't been accessed within TTL
+func (i *KeyedLimiter) cleanup() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	cutoff := time.Now().Add(-i.ttl)
+	for key, entry := range i.keys {
+		if entry.lastSeen.Before(cutoff) {
+			delete(i.keys, key)
+			i.evicted++
+		}
+	}
+}
+
+// Stop stops the cleanup goroutine
+func (i *KeyedLimiter) Stop() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.stopped {
+		close(i.stopClean)
+		i.stopped = true
+	}
+}
+
+// SetRate implements Reconfigurable by changing the default rate to
+// Limit per Window with the given Burst (default Limit). Existing buckets
+// keep their tokens.
+func (i *KeyedLimiter) SetRate(cfg RateConfig) error {
+	if cfg.Limit <= 0 || cfg.Window <= 0 {
+		return fmt.Errorf("%w: limit and window must be positive", ErrInvalidConfig)
+	}
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = cfg.Limit
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.r = rate.Limit(float64(cfg.Limit) / cfg.Window.Seconds())
+	i.b = burst
+	i.applyRates()
+	return nil
+}
+
+// SetOverride sets the rate for an exact key or a key prefix ending in
+// ':' or '*'. Existing limiters of matching keys are updated in place.
+func (i *KeyedLimiter) SetOverride(key string, o Override) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.overrides[key] = o
+	i.applyRates()
+}
+
+// RemoveOverride restores the default rate for a key or prefix
+func (i *KeyedLimiter) RemoveOverride(key string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delete(i.overrides, key)
+	i.applyRates()
+}
+
+// applyRates updates existing buckets after a rate change. Callers hold
+// the lock.
+func (i *KeyedLimiter) applyRates() {
+	for k, entry := range i.keys {
+		r, b := i.rateFor(k)
+		entry.limiter.SetLimit(r)
+		entry.limiter.SetBurst(b)
+	}
+}
+
+// rateFor returns the rate of a key: an exact override, then the longest
+// matching prefix override, then the default. Callers hold the lock.
+func (i *KeyedLimiter) rateFor(key string) (rate.Limit, int) {
+	if o, ok := i.overrides[key]; ok {
+		return o.Rate, o.Burst
+	}
+
+	best, found := "", false
+	for prefix := range i.overrides {
+		p := strings.TrimSuffix(prefix, "*")
+		if p == prefix && !strings.HasSuffix(prefix, ":") {
+			continue
+		}
+		if strings.HasPrefix(key, p) && len(p) >= len(best) {
+			best, found = prefix, true
+		}
+	}
+	if found {
+		o := i.overrides[best]
+		return o.Rate, o.Burst
+	}
+	return i.r, i.b
+}
+
+// Add creates a new rate limiter for key
+func (i *KeyedLimiter) Add(key string) *rate.Limiter {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	r, b := i.rateFor(key)
+	limiter := rate.NewLimiter(r, b)
+	i.keys[key] = &limiterEntry{
+		limiter:  limiter,
+		lastSeen: time.Now(),
+	}
+
+	return limiter
+}
+
+// AddIP creates a new rate limiter and adds it to the map,
+// using the IP address as the key
+//
+// Deprecated: use Add
+func (i *KeyedLimiter) AddIP(ip string) *rate.Limiter {
+	return i.Add(ip)
+}
+
+// GetLimiter returns the rate limiter for the provided key if it exists.
+// Otherwise calls Add to create one
+func (i *KeyedLimiter) GetLimiter(key string) *rate.Limiter {
+	i.mu.Lock()
+	entry, exists := i.keys[key]
+
+	if !exists {
+		i.mu.Unlock()
+		return i.Add(key)
+	}
+
+	// Update last seen time
+	entry.lastSeen = time.Now()
+	i.mu.Unlock()
+
+	return entry.limiter
+}
+
+// Allow implements Limiter using the key's token bucket
+func (i *KeyedLimiter) Allow(key string) (Decision, error) {
+	lim := i.GetLimiter(key)
+	now := time.Now()
+	allowed := lim.AllowN(now, 1)
+
+	tokens := lim.TokensAt(now)
+	burst := lim.Burst()
+	d := Decision{
+		Allowed:   allowed,
+		Limit:     burst,
+		Remaining: max(0, int(math.Floor(tokens))),
+	}
+	if r := float64(lim.Limit()); r > 0 && tokens < float64(burst) {
+		d.Reset = time.Duration((float64(burst) - tokens) / r * float64(time.Second))
+		if !allowed {
+			d.RetryAfter = time.Duration((1 - tokens) / r * float64(time.Second))
+		}
+	}
+	return d, nil
+}
+
+// Len returns the number of tracked keys
+func (i *KeyedLimiter) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.keys)
+}
+
+// Stats implements StatsProvider
+func (i *KeyedLimiter) Stats() Stats {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return Stats{TrackedKeys: len(i.keys), Evicted: i.evicted}
+}
+
+// Clear removes all entries
+func (i *KeyedLimiter) Clear() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.keys = make(map[string]*limiterEntry)
+}
@@ -0,0 +1,100 @@
+package limiter
+
+import (
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// KeyFunc extracts the limiter key of a request. Keys are prefixed by their
+// class (ip:, user:, tenant:, apikey:, route:) so overrides can target a
+// whole class.
+type KeyFunc func(c *fiber.Ctx) string
+
+// KeyByIP keys requests by client IP
+func KeyByIP() KeyFunc {
+	return func(c *fiber.Ctx) string {
+		return "ip:" + c.IP()
+	}
+}
+
+// KeyByUser keys requests by authenticated user, falling back to the IP
+func KeyByUser() KeyFunc {
+	return func(c *fiber.Ctx) string {
+		if id := localString(c, "userId"); id != "" {
+			return "user:" + id
+		}
+		return "ip:" + c.IP()
+	}
+}
+
+// KeyByTenant keys requests by tenant, falling back to the IP
+func KeyByTenant() KeyFunc {
+	return func(c *fiber.Ctx) string {
+		if id := localString(c, "tenantId"); id != "" {
+			return "tenant:" + id
+		}
+		return "ip:" + c.IP()
+	}
+}
+
+// KeyByAPIKey keys requests by the client of a validated API key, falling
+// back to the IP. It reads the clientId local set by APIKeyMiddleware (or
+// ServiceAuthMiddleware), so it must run after it; keying on the raw header
+// would let clients skip the limit by sending a new value per request
+func KeyByAPIKey() KeyFunc {
+	return func(c *fiber.Ctx) string {
+		if id := localString(c, "clientId"); id != "" {
+			return "apikey:" + id
+		}
+		return "ip:" + c.IP()
+	}
+}
+
+// KeyByRoute keys requests by method and route pattern. The limiter must be
+// registered on the routes it limits, e.g. app.Get(path, limit, handler):
+// under app.Use or a group the matched route is the middleware itself, so
+// the key falls back to the request path and a warning is logged once
+func KeyByRoute() KeyFunc {
+	var warn sync.Once
+	return func(c *fiber.Ctx) string {
+		route := c.Route()
+		if len(route.Params) == 0 && !samePath(route.Path, c.Path()) {
+			warn.Do(func() {
+				log.Printf("limiter: KeyByRoute is registered on %q with Use, keying by request path", route.Path)
+			})
+			return "route:" + c.Method() + " " + c.Path()
+		}
+		return "route:" + c.Method() + " " + route.Path
+	}
+}
+
+// samePath reports whether a route without parameters matched the whole
+// path rather than a prefix of it, as middleware does
+func samePath(pattern, path string) bool {
+	return strings.EqualFold(strings.TrimSuffix(pattern, "/"), strings.TrimSuffix(path, "/"))
+}
+
+// ComposeKeys joins several keys, e.g. a per-tenant limit per route
+func ComposeKeys(funcs ...KeyFunc) KeyFunc {
+	return func(c *fiber.Ctx) string {
+		parts := make([]string, len(funcs))
+		for i, f := range funcs {
+			parts[i] = f(c)
+		}
+		return strings.Join(parts, "|")
+	}
+}
+
+// localString reads a Fiber local stored as a string or a Stringer
+func localString(c *fiber.Ctx, key string) string {
+	switch v := c.Locals(key).(type) {
+	case string:
+		return v
+	case interface{ String() string }:
+		return v.String()
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package limiter
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidConfig is returned for a rate configuration that can't be used
+var ErrInvalidConfig = errors.New("invalid limiter config")
+
+// Algorithm selects how requests are counted
+type Algorithm string
+
+const (
+	// FixedWindow counts requests per aligned window; cheap but allows up to
+	// twice the limit across a window boundary
+	FixedWindow Algorithm = "fixed_window"
+	// SlidingLog keeps a timestamp per request; exact but memory grows with
+	// the limit
+	SlidingLog Algorithm = "sliding_log"
+	// SlidingWindow weights the previous window's count; a close, constant
+	// memory approximation of SlidingLog
+	SlidingWindow Algorithm = "sliding_window"
+	// GCRA is the generic cell rate algorithm (a leaky bucket); it spaces
+	// requests evenly and allows Burst requests at once
+	GCRA Algorithm = "gcra"
+)
+
+// Decision is the outcome of a limiter check
+type Decision struct {
+	Allowed bool
+	// Limit is the number of requests allowed per window
+	Limit int
+	// Remaining is the number of requests left in the current window
+	Remaining int
+	// Reset is the time until the limit is fully restored
+	Reset time.Duration
+	// RetryAfter is the time until the next request would be allowed;
+	// zero when the request was allowed
+	RetryAfter time.Duration
+}
+
+// Limiter decides whether a request for a key may proceed
+type Limiter interface {
+	Allow(key string) (Decision, error)
+}
+
+// Stats describes the keys held by a limiter
+type Stats struct {
+	TrackedKeys int
+	// Evicted is the total number of idle keys removed by cleanup
+	Evicted uint64
+}
+
+// StatsProvider is implemented by limiters that can report Stats
+type StatsProvider interface {
+	Stats() Stats
+}
+
+// RateConfig configures an algorithm based limiter
+type RateConfig struct {
+	Algorithm Algorithm
+	// Limit is the number of requests allowed per Window
+	Limit  int
+	Window time.Duration
+	// Burst is the number of requests GCRA allows at once (default Limit)
+	Burst int
+	// TTL is how long idle keys are kept (default: 1 hour, at least Window)
+	TTL time.Duration
+}
+
+// New creates a limiter for the configured algorithm
+func New(cfg RateConfig) (Limiter, error) {
+	if cfg.Limit <= 0 || cfg.Window <= 0 {
+		return nil, fmt.Errorf("%w: limit and window must be positive", ErrInvalidConfig)
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = cfg.Limit
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = time.Hour
+	}
+	if cfg.TTL < cfg.Window {
+		cfg.TTL = cfg.Window
+	}
+
+	switch cfg.Algorithm {
+	case FixedWindow:
+		return newFixedWindow(cfg), nil
+	case SlidingLog:
+		return newSlidingLog(cfg), nil
+	case "", SlidingWindow:
+		return newSlidingWindow(cfg), nil
+	case GCRA:
+		return newGCRA(cfg), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown algorithm %q", ErrInvalidConfig, cfg.Algorithm)
+	}
+}
+
+// keyStore holds per-key algorithm state and drops keys idle for longer
+// than ttl. Sweeps happen inline, so no background goroutine is needed.
+type keyStore[S any] struct {
+	entries   map[string]*storeEntry[S]
+	ttl       time.Duration
+	lastSweep time.Time
+	evicted   uint64
+}
+
+type storeEntry[S any] struct {
+	state    S
+	lastSeen time.Time
+}
+
+func newKeyStore[S any](ttl time.Duration) *keyStore[S] {
+	return &keyStore[S]{entries: make(map[string]*storeEntry[S]), ttl: ttl, lastSweep: time.Now()}
+}
+
+// get returns the state of key, creating it if needed. Callers hold the
+// limiter's lock.
+func (s *keyStore[S]) get(key string, now time.Time) *S {
+	if now.Sub(s.lastSweep) > s.ttl {
+		s.sweep(now)
+	}
+	e, ok := s.entries[key]
+	if !ok {
+		e = &storeEntry[S]{}
+		s.entries[key] = e
+	}
+	e.lastSeen = now
+	return &e.state
+}
+
+func (s *keyStore[S]) sweep(now time.Time) {
+	cutoff := now.Add(-s.ttl)
+	for key, e := range s.entries {
+		if e.lastSeen.Before(cutoff) {
+			delete(s.entries, key)
+			s.evicted++
+		}
+	}
+	s.lastSweep = now
+}
+
+func (s *keyStore[S]) stats() Stats {
+	return Stats{TrackedKeys: len(s.entries), Evicted: s.evicted}
+}
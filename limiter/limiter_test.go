@@ -0,0 +1,348 @@
+package limiter
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced clock for algorithm tests
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter(t *testing.T, algo Algorithm, limit int, window time.Duration) (Limiter, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l, err := New(RateConfig{Algorithm: algo, Limit: limit, Window: window})
+	require.NoError(t, err)
+
+	switch v := l.(type) {
+	case *fixedWindow:
+		v.now = clock.now
+	case *slidingLog:
+		v.now = clock.now
+	case *slidingWindow:
+		v.now = clock.now
+	case *gcra:
+		v.now = clock.now
+	}
+	return l, clock
+}
+
+func allowN(t *testing.T, l Limiter, key string, n int) int {
+	allowed := 0
+	for i := 0; i < n; i++ {
+		d, err := l.Allow(key)
+		require.NoError(t, err)
+		if d.Allowed {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestAlgorithmsEnforceLimit(t *testing.T) {
+	for _, algo := range []Algorithm{FixedWindow, SlidingLog, SlidingWindow, GCRA} {
+		t.Run(string(algo), func(t *testing.T) {
+			l, clock := newTestLimiter(t, algo, 5, time.Second)
+
+			assert.Equal(t, 5, allowN(t, l, "a", 10))
+			assert.Equal(t, 5, allowN(t, l, "b", 5), "keys are independent")
+
+			d, _ := l.Allow("a")
+			assert.False(t, d.Allowed)
+			assert.Equal(t, 0, d.Remaining)
+			assert.Greater(t, d.Reset, time.Duration(0))
+
+			clock.advance(2 * time.Second)
+			assert.Equal(t, 5, allowN(t, l, "a", 10))
+		})
+	}
+}
+
+func TestSlidingWindowWeightsPreviousWindow(t *testing.T) {
+	l, clock := newTestLimiter(t, SlidingWindow, 10, time.Second)
+
+	assert.Equal(t, 10, allowN(t, l, "k", 10))
+	// Half of the previous window still counts
+	clock.advance(1500 * time.Millisecond)
+	assert.Equal(t, 5, allowN(t, l, "k", 10))
+}
+
+func TestGCRASpacesRequests(t *testing.T) {
+	l, clock := newTestLimiter(t, GCRA, 10, time.Second)
+
+	assert.Equal(t, 10, allowN(t, l, "k", 20))
+	clock.advance(100 * time.Millisecond)
+	assert.Equal(t, 1, allowN(t, l, "k", 5))
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	_, err := New(RateConfig{Limit: 0, Window: time.Second})
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = New(RateConfig{Algorithm: "nope", Limit: 1, Window: time.Second})
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestKeyedLimiterOverrides(t *testing.T) {
+	l := NewKeyedLimiter(Config{
+		Rate:  1,
+		Burst: 1,
+		Overrides: map[string]Override{
+			"tenant:":     {Rate: 1, Burst: 3},
+			"tenant:gold": {Rate: 1, Burst: 5},
+		},
+	})
+	defer l.Stop()
+
+	assert.Equal(t, 1, allowN(t, l, "ip:1.2.3.4", 5))
+	assert.Equal(t, 3, allowN(t, l, "tenant:basic", 5))
+	assert.Equal(t, 5, allowN(t, l, "tenant:gold", 10))
+
+	l.SetOverride("ip:*", Override{Rate: 1, Burst: 2})
+	assert.Equal(t, 2, allowN(t, l, "ip:5.6.7.8", 5))
+}
+
+func TestConcurrencyLimiterQueue(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyConfig{MaxInFlight: 1, MaxQueue: 1, QueueTimeout: 20 * time.Millisecond})
+
+	release, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+
+	// Queued request times out while the slot is held
+	_, err = l.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrOverloaded)
+
+	// Queued request gets the slot once it is released
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		release()
+	}()
+	release2, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+	release2()
+	assert.Equal(t, 0, l.InFlight())
+}
+
+func TestAdaptiveLimiterBacksOff(t *testing.T) {
+	l := NewAdaptiveLimiter(AdaptiveConfig{MinLimit: 2, MaxLimit: 10, InitialLimit: 10, TargetLatency: time.Millisecond})
+
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l.now = clock.now
+
+	// Slow requests in flight together shrink the limit once
+	l.inFlight = 20
+	start := clock.now()
+	clock.advance(10 * time.Millisecond)
+	for i := 0; i < 20; i++ {
+		l.release(start)
+	}
+	assert.Equal(t, 9, l.Limit())
+
+	// Each later round trip shrinks it again, down to MinLimit
+	for i := 0; i < 20; i++ {
+		l.inFlight++
+		start := clock.now()
+		clock.advance(10 * time.Millisecond)
+		l.release(start)
+	}
+	assert.Equal(t, 2, l.Limit())
+
+	cpu := NewAdaptiveLimiter(AdaptiveConfig{CPUFunc: func() float64 { return 0.99 }})
+	_, err := cpu.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrOverloaded)
+}
+
+func TestQuotaConsume(t *testing.T) {
+	store := cache.NewMemoryCache()
+	defer store.Close()
+
+	events := make(chan ThresholdEvent, 2)
+	q, err := NewQuota(QuotaConfig{
+		Cache:       store,
+		Period:      PeriodDay,
+		Limits:      map[string]int64{"default": 10},
+		Thresholds:  []float64{0.8},
+		OnThreshold: func(_ context.Context, e ThresholdEvent) { events <- e },
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	st, err := q.Consume(ctx, "tenant:1", 7)
+	require.NoError(t, err)
+	assert.True(t, st.Allowed)
+	assert.Equal(t, int64(3), st.Remaining)
+
+	st, err = q.Consume(ctx, "tenant:1", 5)
+	require.NoError(t, err)
+	assert.False(t, st.Allowed, "over limit is rejected without recording")
+
+	st, err = q.Consume(ctx, "tenant:1", 2)
+	require.NoError(t, err)
+	assert.True(t, st.Allowed)
+	assert.Equal(t, 0.8, (<-events).Threshold)
+
+	remaining, err := q.Remaining(ctx, "tenant:1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), remaining)
+}
+
+func TestRetryAfter(t *testing.T) {
+	l, clock := newTestLimiter(t, GCRA, 10, time.Second)
+	allowN(t, l, "k", 10)
+
+	d, _ := l.Allow("k")
+	assert.False(t, d.Allowed)
+	assert.Equal(t, 100*time.Millisecond, d.RetryAfter)
+
+	clock.advance(d.RetryAfter)
+	d, _ = l.Allow("k")
+	assert.True(t, d.Allowed)
+	assert.Zero(t, d.RetryAfter)
+}
+
+func TestInstrumentedTopDenied(t *testing.T) {
+	base, _ := newTestLimiter(t, FixedWindow, 1, time.Minute)
+	l := Instrument("test", base, 2)
+
+	allowN(t, l, "ip:a", 4) // 3 denied
+	allowN(t, l, "ip:b", 3) // 2 denied
+	allowN(t, l, "ip:c", 2) // 1 denied, replaces the least denied key
+
+	top := l.TopDenied(10)
+	require.Len(t, top, 2)
+	assert.Equal(t, KeyCount{Key: "ip:a", Count: 3}, top[0])
+	assert.Equal(t, "ip:c", top[1].Key)
+	assert.Equal(t, 3, base.(StatsProvider).Stats().TrackedKeys)
+}
+
+func TestPriorityLimiterShedsLowerClassesFirst(t *testing.T) {
+	l, err := NewPriorityLimiter(PriorityConfig{
+		Limit:   10,
+		Window:  time.Minute,
+		Classes: map[string]Priority{"svc:": PriorityInternal, "tenant:paid:": PriorityPaid},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, PriorityPaid, l.Classify("tenant:paid:1"))
+	assert.Equal(t, PriorityFree, l.Classify("tenant:2"))
+
+	assert.Equal(t, 5, allowN(t, l, "tenant:2", 10), "free stops at half capacity")
+	assert.Equal(t, 3, allowN(t, l, "tenant:paid:1", 10), "paid stops at 80%")
+	assert.Equal(t, 2, allowN(t, l, "svc:billing", 10), "internal uses the rest")
+}
+
+func TestSetRateKeepsState(t *testing.T) {
+	l, _ := newTestLimiter(t, FixedWindow, 5, time.Minute)
+	assert.Equal(t, 3, allowN(t, l, "k", 3))
+
+	require.NoError(t, l.(Reconfigurable).SetRate(RateConfig{Limit: 4, Window: time.Minute}))
+	assert.Equal(t, 1, allowN(t, l, "k", 5), "usage survives the change")
+}
+
+func TestSetRateWhileAllowing(t *testing.T) {
+	for _, algo := range []Algorithm{FixedWindow, SlidingLog, SlidingWindow, GCRA} {
+		l, err := New(RateConfig{Algorithm: algo, Limit: 10, Window: time.Second})
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				_, _ = l.Allow("k")
+			}
+		}()
+		for i := 1; i <= 100; i++ {
+			require.NoError(t, l.(Reconfigurable).SetRate(RateConfig{Limit: i, Window: time.Second}))
+		}
+		wg.Wait()
+	}
+}
+
+func TestWarmUpRampsToTarget(t *testing.T) {
+	l, err := New(RateConfig{Algorithm: GCRA, Limit: 100, Window: time.Second})
+	require.NoError(t, err)
+	target := RateConfig{Limit: 100, Window: time.Second}
+
+	require.NoError(t, WarmUp(context.Background(), l.(Reconfigurable), target, WarmUpConfig{
+		Duration:      20 * time.Millisecond,
+		StartFraction: 0.1,
+		Steps:         2,
+	}))
+	assert.Equal(t, 10, l.(*gcra).Rate().Limit)
+
+	assert.Eventually(t, func() bool {
+		return l.(*gcra).Rate().Limit == 100
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestKeyByAPIKeyUsesValidatedClient(t *testing.T) {
+	app := fiber.New()
+	var keys []string
+	app.Use(func(c *fiber.Ctx) error {
+		// Stands in for APIKeyMiddleware, which sets clientId for valid keys
+		if c.Get("X-API-Key") == "valid" {
+			c.Locals("clientId", "reports")
+		}
+		return c.Next()
+	})
+	app.Get("/", func(c *fiber.Ctx) error {
+		keys = append(keys, KeyByAPIKey()(c))
+		return nil
+	})
+
+	for _, header := range []string{"valid", "random-1", "random-2"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", header)
+		_, err := app.Test(req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"apikey:reports", "ip:0.0.0.0", "ip:0.0.0.0"}, keys,
+		"unvalidated keys share the IP bucket")
+}
+
+func TestKeyByRouteSeparatesRoutes(t *testing.T) {
+	app := fiber.New()
+	keyFunc := KeyByRoute()
+	var keys []string
+	record := func(c *fiber.Ctx) error {
+		keys = append(keys, keyFunc(c))
+		return c.Next()
+	}
+	ok := func(c *fiber.Ctx) error { return nil }
+	app.Get("/orders/:id", record, ok)
+	app.Get("/users", record, ok)
+
+	for _, path := range []string{"/orders/1", "/orders/2", "/users"} {
+		_, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"route:GET /orders/:id", "route:GET /orders/:id", "route:GET /users"}, keys)
+}
+
+func TestKeyByRouteFallsBackToPathUnderUse(t *testing.T) {
+	app := fiber.New()
+	keyFunc := KeyByRoute()
+	var keys []string
+	app.Use(func(c *fiber.Ctx) error {
+		keys = append(keys, keyFunc(c))
+		return c.Next()
+	})
+	app.Get("/orders/:id", func(c *fiber.Ctx) error { return nil })
+
+	for _, path := range []string{"/orders/1", "/orders/2"} {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, []string{"route:GET /orders/1", "route:GET /orders/2"}, keys)
+}
@@ -0,0 +1,152 @@
+package limiter
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Priority is a traffic class; higher classes are shed last
+type Priority int
+
+const (
+	PriorityFree Priority = iota
+	PriorityPaid
+	PriorityInternal
+)
+
+// String returns the class name
+func (p Priority) String() string {
+	switch p {
+	case PriorityFree:
+		return "free"
+	case PriorityPaid:
+		return "paid"
+	case PriorityInternal:
+		return "internal"
+	default:
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+}
+
+// sharedKey is the single key holding the shared capacity
+const sharedKey = "shared"
+
+// PriorityConfig configures a shared limit split across priority classes
+type PriorityConfig struct {
+	// Limit and Window define the shared capacity
+	Limit  int
+	Window time.Duration
+	// Shares is the fraction of the shared capacity each class may fill.
+	// Once usage passes a class's share, that class is rejected while
+	// higher classes still get through.
+	Shares map[Priority]float64
+	// Classes maps key prefixes to classes (longest prefix wins), e.g.
+	// "svc:" internal, "tenant:paid:" paid
+	Classes map[string]Priority
+	// Default is the class of keys matching no prefix
+	Default Priority
+}
+
+// DefaultPriorityShares keeps the last half of the capacity for paid and
+// internal traffic and the last fifth for internal traffic only
+func DefaultPriorityShares() map[Priority]float64 {
+	return map[Priority]float64{
+		PriorityFree:     0.5,
+		PriorityPaid:     0.8,
+		PriorityInternal: 1.0,
+	}
+}
+
+// PriorityLimiter enforces one shared limit where lower priority classes
+// are shed first as usage grows
+type PriorityLimiter struct {
+	mu      sync.RWMutex
+	cfg     PriorityConfig
+	window  *slidingWindow
+	classes []classPrefix // sorted longest first
+}
+
+type classPrefix struct {
+	prefix   string
+	priority Priority
+}
+
+// NewPriorityLimiter creates a priority limiter
+func NewPriorityLimiter(cfg PriorityConfig) (*PriorityLimiter, error) {
+	if cfg.Limit <= 0 || cfg.Window <= 0 {
+		return nil, fmt.Errorf("%w: limit and window must be positive", ErrInvalidConfig)
+	}
+	if cfg.Shares == nil {
+		cfg.Shares = DefaultPriorityShares()
+	}
+
+	l := &PriorityLimiter{
+		cfg:    cfg,
+		window: newSlidingWindow(RateConfig{Limit: cfg.Limit, Window: cfg.Window, TTL: cfg.Window * 2}),
+	}
+	for prefix, p := range cfg.Classes {
+		l.classes = append(l.classes, classPrefix{prefix: prefix, priority: p})
+	}
+	sortClasses(l.classes)
+	return l, nil
+}
+
+// Classify returns the priority class of a key
+func (l *PriorityLimiter) Classify(key string) Priority {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, c := range l.classes {
+		if strings.HasPrefix(key, c.prefix) {
+			return c.priority
+		}
+	}
+	return l.cfg.Default
+}
+
+// Allow implements Limiter. The decision's Limit is the capacity available
+// to the key's class.
+func (l *PriorityLimiter) Allow(key string) (Decision, error) {
+	p := l.Classify(key)
+
+	l.mu.RLock()
+	share, ok := l.cfg.Shares[p]
+	capacity := l.cfg.Limit
+	l.mu.RUnlock()
+	if !ok {
+		share = 1.0
+	}
+
+	limit := int(math.Ceil(share * float64(capacity)))
+	return l.window.allowWithin(sharedKey, min(limit, capacity)), nil
+}
+
+// SetRate implements Reconfigurable by changing the shared capacity;
+// current usage is kept
+func (l *PriorityLimiter) SetRate(cfg RateConfig) error {
+	if err := l.window.SetRate(cfg); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg.Limit = cfg.Limit
+	l.cfg.Window = cfg.Window
+	return nil
+}
+
+// SetShares replaces the capacity share of each class
+func (l *PriorityLimiter) SetShares(shares map[Priority]float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg.Shares = shares
+}
+
+// sortClasses orders prefixes longest first so the most specific matches
+func sortClasses(classes []classPrefix) {
+	sort.Slice(classes, func(i, j int) bool {
+		return len(classes[i].prefix) > len(classes[j].prefix)
+	})
+}
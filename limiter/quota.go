@@ -0,0 +1,247 @@
+package limiter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minisource/go-common/cache"
+)
+
+// Period is the horizon over which quota usage accumulates
+type Period string
+
+const (
+	PeriodDay   Period = "day"
+	PeriodMonth Period = "month"
+)
+
+// QuotaStatus describes the usage of a key in the current period
+type QuotaStatus struct {
+	Allowed   bool      `json:"allowed"`
+	Used      int64     `json:"used"`
+	Limit     int64     `json:"limit"`
+	Remaining int64     `json:"remaining"`
+	Period    Period    `json:"period"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// ThresholdEvent is emitted when usage crosses a configured threshold
+type ThresholdEvent struct {
+	Key       string    `json:"key"`
+	Plan      string    `json:"plan"`
+	Threshold float64   `json:"threshold"`
+	Used      int64     `json:"used"`
+	Limit     int64     `json:"limit"`
+	Period    Period    `json:"period"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// QuotaConfig configures a quota manager
+type QuotaConfig struct {
+	// Cache stores the counters; use a Redis cache when running replicas
+	Cache  cache.Cache
+	Prefix string
+	Period Period
+	// Limits maps plan names to their limit per period
+	Limits map[string]int64
+	// PlanFunc resolves the plan of a key (default: "default")
+	PlanFunc func(ctx context.Context, key string) (string, error)
+	// Thresholds are usage fractions that trigger OnThreshold (e.g. 0.8, 1)
+	Thresholds []float64
+	// OnThreshold is called in the background when a threshold is crossed
+	OnThreshold func(ctx context.Context, event ThresholdEvent)
+	// Location defines period boundaries (default UTC)
+	Location *time.Location
+}
+
+// Quota tracks long-horizon usage (daily or monthly) per key, typically a
+// tenant, against the limit of its plan
+type Quota struct {
+	cfg QuotaConfig
+	now func() time.Time
+}
+
+// NewQuota creates a quota manager
+func NewQuota(cfg QuotaConfig) (*Quota, error) {
+	if cfg.Cache == nil {
+		return nil, fmt.Errorf("%w: quota cache is required", ErrInvalidConfig)
+	}
+	switch cfg.Period {
+	case PeriodDay, PeriodMonth:
+	case "":
+		cfg.Period = PeriodMonth
+	default:
+		return nil, fmt.Errorf("%w: unknown period %q", ErrInvalidConfig, cfg.Period)
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "quota"
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+	if cfg.PlanFunc == nil {
+		cfg.PlanFunc = func(context.Context, string) (string, error) { return "default", nil }
+	}
+	return &Quota{cfg: cfg, now: time.Now}, nil
+}
+
+// Consume records n units of usage. When the key would exceed its limit
+// nothing is recorded and the status is not Allowed.
+func (q *Quota) Consume(ctx context.Context, key string, n int64) (QuotaStatus, error) {
+	plan, limit, err := q.limit(ctx, key)
+	if err != nil {
+		return QuotaStatus{}, err
+	}
+
+	now := q.now()
+	start, end := q.bounds(now)
+	counter := q.counterKey(key, start)
+
+	// Create the counter with its expiry; increments keep the TTL
+	if _, err := q.cfg.Cache.SetNX(ctx, counter, []byte("0"), end.Sub(now)+24*time.Hour); err != nil {
+		return QuotaStatus{}, err
+	}
+	used, err := q.cfg.Cache.Increment(ctx, counter, n)
+	if err != nil {
+		return QuotaStatus{}, err
+	}
+
+	if used > limit {
+		used, err = q.cfg.Cache.Decrement(ctx, counter, n)
+		if err != nil {
+			return QuotaStatus{}, err
+		}
+		return q.status(false, used, limit, end), nil
+	}
+
+	q.notify(ctx, key, plan, used-n, used, limit, end)
+	return q.status(true, used, limit, end), nil
+}
+
+// Status returns the usage of key in the current period
+func (q *Quota) Status(ctx context.Context, key string) (QuotaStatus, error) {
+	_, limit, err := q.limit(ctx, key)
+	if err != nil {
+		return QuotaStatus{}, err
+	}
+
+	start, end := q.bounds(q.now())
+	used, err := q.used(ctx, q.counterKey(key, start))
+	if err != nil {
+		return QuotaStatus{}, err
+	}
+	return q.status(used < limit, used, limit, end), nil
+}
+
+// Remaining returns the units left for key in the current period
+func (q *Quota) Remaining(ctx context.Context, key string) (int64, error) {
+	st, err := q.Status(ctx, key)
+	return st.Remaining, err
+}
+
+// Reset clears the usage of key in the current period
+func (q *Quota) Reset(ctx context.Context, key string) error {
+	start, _ := q.bounds(q.now())
+	return q.cfg.Cache.Delete(ctx, q.counterKey(key, start))
+}
+
+func (q *Quota) limit(ctx context.Context, key string) (string, int64, error) {
+	plan, err := q.cfg.PlanFunc(ctx, key)
+	if err != nil {
+		return "", 0, err
+	}
+	limit, ok := q.cfg.Limits[plan]
+	if !ok {
+		return plan, 0, fmt.Errorf("%w: no quota for plan %q", ErrInvalidConfig, plan)
+	}
+	return plan, limit, nil
+}
+
+func (q *Quota) used(ctx context.Context, counter string) (int64, error) {
+	data, err := q.cfg.Cache.Get(ctx, counter)
+	if errors.Is(err, cache.ErrKeyNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+}
+
+func (q *Quota) status(allowed bool, used, limit int64, end time.Time) QuotaStatus {
+	return QuotaStatus{
+		Allowed:   allowed,
+		Used:      used,
+		Limit:     limit,
+		Remaining: max(0, limit-used),
+		Period:    q.cfg.Period,
+		ResetAt:   end,
+	}
+}
+
+// bounds returns the start and end of the period containing t
+func (q *Quota) bounds(t time.Time) (time.Time, time.Time) {
+	t = t.In(q.cfg.Location)
+	if q.cfg.Period == PeriodDay {
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, q.cfg.Location)
+		return start, start.AddDate(0, 0, 1)
+	}
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, q.cfg.Location)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// counterKey names the counter of key for the period starting at start,
+// e.g. quota:month:2024-05:tenant:42
+func (q *Quota) counterKey(key string, start time.Time) string {
+	layout := "2006-01"
+	if q.cfg.Period == PeriodDay {
+		layout = "2006-01-02"
+	}
+	return fmt.Sprintf("%s:%s:%s:%s", q.cfg.Prefix, q.cfg.Period, start.Format(layout), key)
+}
+
+// notify fires OnThreshold for every threshold crossed by this consumption
+func (q *Quota) notify(ctx context.Context, key, plan string, before, after, limit int64, end time.Time) {
+	if q.cfg.OnThreshold == nil || limit <= 0 {
+		return
+	}
+	for _, t := range q.cfg.Thresholds {
+		mark := int64(t * float64(limit))
+		if before < mark && after >= mark {
+			event := ThresholdEvent{
+				Key: key, Plan: plan, Threshold: t, Used: after, Limit: limit,
+				Period: q.cfg.Period, ResetAt: end,
+			}
+			go q.cfg.OnThreshold(context.WithoutCancel(ctx), event)
+		}
+	}
+}
+
+// WebhookNotifier returns an OnThreshold callback posting the event as JSON
+func WebhookNotifier(url string, timeout time.Duration) func(ctx context.Context, event ThresholdEvent) {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+	return func(ctx context.Context, event ThresholdEvent) {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}
+}
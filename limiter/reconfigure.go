@@ -0,0 +1,98 @@
+package limiter
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/response"
+)
+
+// Reconfigurable is implemented by limiters whose rate can change at
+// runtime without losing per-key state
+type Reconfigurable interface {
+	SetRate(cfg RateConfig) error
+}
+
+// WarmUpConfig configures a post-deploy ramp to the target rate
+type WarmUpConfig struct {
+	// Duration of the ramp
+	Duration time.Duration
+	// StartFraction of the target limit applied at the beginning (e.g. 0.2)
+	StartFraction float64
+	// Steps is the number of increases during the ramp (default 10)
+	Steps int
+}
+
+// WarmUp starts l at a fraction of target and raises it step by step until
+// target is reached, so a freshly deployed instance with cold caches isn't
+// stampeded. It returns immediately; the ramp stops early when ctx is done.
+func WarmUp(ctx context.Context, l Reconfigurable, target RateConfig, cfg WarmUpConfig) error {
+	if cfg.Steps <= 0 {
+		cfg.Steps = 10
+	}
+	if cfg.StartFraction <= 0 || cfg.StartFraction >= 1 || cfg.Duration <= 0 {
+		return l.SetRate(target)
+	}
+
+	if err := l.SetRate(scaleRate(target, cfg.StartFraction)); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(cfg.Duration / time.Duration(cfg.Steps))
+		defer ticker.Stop()
+
+		for step := 1; step <= cfg.Steps; step++ {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			fraction := cfg.StartFraction + (1-cfg.StartFraction)*float64(step)/float64(cfg.Steps)
+			_ = l.SetRate(scaleRate(target, fraction))
+		}
+	}()
+	return nil
+}
+
+// scaleRate returns target with its limit and burst scaled by fraction
+func scaleRate(target RateConfig, fraction float64) RateConfig {
+	scaled := target
+	scaled.Limit = max(1, int(math.Round(float64(target.Limit)*fraction)))
+	if target.Burst > 0 {
+		scaled.Burst = max(1, int(math.Round(float64(target.Burst)*fraction)))
+	}
+	return scaled
+}
+
+// rateRequest is the body accepted by ReconfigureHandler
+type rateRequest struct {
+	Limit  int    `json:"limit"`
+	Window string `json:"window"` // e.g. "1s", "1m"
+	Burst  int    `json:"burst"`
+}
+
+// ReconfigureHandler returns an admin Fiber handler that changes the rate
+// of l from a JSON body such as {"limit": 100, "window": "1m", "burst": 20}.
+// Protect the route; for config hot-reload call SetRate from a reload hook
+// instead.
+func ReconfigureHandler(l Reconfigurable) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req rateRequest
+		if err := c.BodyParser(&req); err != nil {
+			return response.BadRequest(c, response.ErrCodeBadRequest, "invalid body")
+		}
+		window, err := time.ParseDuration(req.Window)
+		if err != nil {
+			return response.BadRequest(c, response.ErrCodeBadRequest, "invalid window")
+		}
+
+		cfg := RateConfig{Limit: req.Limit, Window: window, Burst: req.Burst}
+		if err := l.SetRate(cfg); err != nil {
+			return response.BadRequest(c, response.ErrCodeBadRequest, err.Error())
+		}
+		return response.OK(c, req)
+	}
+}
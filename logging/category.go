@@ -14,6 +14,7 @@ const (
 	RequestResponse Category = "RequestResponse"
 	Prometheus      Category = "Prometheus"
 	Casdoor         Category = "Casdoor"
+	Messaging       Category = "Messaging"
 )
 
 const (
@@ -21,6 +22,8 @@ const (
 	Startup         SubCategory = "Startup"
 	ExternalService SubCategory = "ExternalService"
 	Create          SubCategory = "Create"
+	Shutdown        SubCategory = "Shutdown"
+	Reload          SubCategory = "Reload"
 
 	// Postgres
 	Migration SubCategory = "Migration"
@@ -34,6 +37,12 @@ const (
 	Api                 SubCategory = "Api"
 	HashPassword        SubCategory = "HashPassword"
 	DefaultRoleNotFound SubCategory = "DefaultRoleNotFound"
+	Job                 SubCategory = "Job"
+	Scheduler           SubCategory = "Scheduler"
+	Notification        SubCategory = "Notification"
+	LeaderElection      SubCategory = "LeaderElection"
+	WebSocket           SubCategory = "WebSocket"
+	ServerSentEvents    SubCategory = "ServerSentEvents"
 
 	// Validation
 	MobileValidation   SubCategory = "MobileValidation"
@@ -41,6 +50,10 @@ const (
 
 	// IO
 	RemoveFile SubCategory = "RemoveFile"
+
+	// Messaging
+	Publish SubCategory = "Publish"
+	Consume SubCategory = "Consume"
 )
 
 const (
@@ -65,4 +78,14 @@ const (
 	TenantID  ExtraKey = "TenantID"
 	Email     ExtraKey = "Email"
 	SessionID ExtraKey = "SessionID"
+	Version   ExtraKey = "Version"
+
+	// Messaging keys
+	Topic     ExtraKey = "Topic"
+	MessageID ExtraKey = "MessageID"
+
+	// Trace correlation keys
+	TraceID   ExtraKey = "trace_id"
+	SpanID    ExtraKey = "span_id"
+	RequestID ExtraKey = "request_id"
 )
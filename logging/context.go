@@ -0,0 +1,144 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	appctx "github.com/minisource/go-common/context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type loggerKey struct{}
+
+var defaultLogger atomic.Value // holds Logger
+
+// SetDefault sets the logger returned by FromContext when the context
+// carries none
+func SetDefault(logger Logger) {
+	defaultLogger.Store(&logger)
+}
+
+// NewContext returns a context carrying the logger
+func NewContext(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns a logger that adds trace_id, span_id and request_id
+// from ctx to every entry, and records Error and Fatal entries as events on
+// the active span. The underlying logger comes from NewContext, then
+// SetDefault, then a console logger.
+func FromContext(ctx context.Context) Logger {
+	logger, _ := ctx.Value(loggerKey{}).(Logger)
+	if logger == nil {
+		if l, ok := defaultLogger.Load().(*Logger); ok {
+			logger = *l
+		}
+	}
+	if logger == nil {
+		logger = newFiberLogger(&LoggerConfig{Level: "info"})
+	}
+	return &contextLogger{next: logger, ctx: ctx}
+}
+
+// contextLogger decorates a Logger with trace correlation
+type contextLogger struct {
+	next Logger
+	ctx  context.Context
+}
+
+func (l *contextLogger) Init() {
+	l.next.Init()
+}
+
+// enrich copies extra and adds the correlation IDs
+func (l *contextLogger) enrich(extra map[ExtraKey]interface{}) map[ExtraKey]interface{} {
+	out := make(map[ExtraKey]interface{}, len(extra)+3)
+	for k, v := range extra {
+		out[k] = v
+	}
+
+	if sc := trace.SpanContextFromContext(l.ctx); sc.IsValid() {
+		out[TraceID] = sc.TraceID().String()
+		out[SpanID] = sc.SpanID().String()
+	} else if traceID, ok := appctx.GetTraceID(l.ctx); ok && traceID != "" {
+		out[TraceID] = traceID
+	}
+	if requestID, ok := appctx.GetRequestID(l.ctx); ok && requestID != "" {
+		out[RequestID] = requestID
+	}
+	return out
+}
+
+// correlation returns the IDs as key/value pairs for formatted entries
+func (l *contextLogger) correlation() string {
+	sc := trace.SpanContextFromContext(l.ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf(" %s=%s %s=%s", TraceID, sc.TraceID(), SpanID, sc.SpanID())
+}
+
+// spanEvent records an error-level entry on the active span
+func (l *contextLogger) spanEvent(level, msg string, attrs ...attribute.KeyValue) {
+	span := trace.SpanFromContext(l.ctx)
+	if !span.IsRecording() {
+		return
+	}
+	attrs = append(attrs,
+		attribute.String("log.severity", level),
+		attribute.String("log.message", msg),
+	)
+	span.AddEvent("log", trace.WithAttributes(attrs...))
+}
+
+func (l *contextLogger) Debug(cat Category, sub SubCategory, msg string, extra map[ExtraKey]interface{}) {
+	l.next.Debug(cat, sub, msg, l.enrich(extra))
+}
+
+func (l *contextLogger) Debugf(template string, args ...interface{}) {
+	l.next.Debugf(template+l.correlation(), args...)
+}
+
+func (l *contextLogger) Info(cat Category, sub SubCategory, msg string, extra map[ExtraKey]interface{}) {
+	l.next.Info(cat, sub, msg, l.enrich(extra))
+}
+
+func (l *contextLogger) Infof(template string, args ...interface{}) {
+	l.next.Infof(template+l.correlation(), args...)
+}
+
+func (l *contextLogger) Warn(cat Category, sub SubCategory, msg string, extra map[ExtraKey]interface{}) {
+	l.next.Warn(cat, sub, msg, l.enrich(extra))
+}
+
+func (l *contextLogger) Warnf(template string, args ...interface{}) {
+	l.next.Warnf(template+l.correlation(), args...)
+}
+
+func (l *contextLogger) Error(cat Category, sub SubCategory, msg string, extra map[ExtraKey]interface{}) {
+	l.spanEvent("error", msg,
+		attribute.String("log.category", string(cat)),
+		attribute.String("log.sub_category", string(sub)),
+	)
+	l.next.Error(cat, sub, msg, l.enrich(extra))
+}
+
+func (l *contextLogger) Errorf(template string, args ...interface{}) {
+	l.spanEvent("error", fmt.Sprintf(template, args...))
+	l.next.Errorf(template+l.correlation(), args...)
+}
+
+func (l *contextLogger) Fatal(cat Category, sub SubCategory, msg string, extra map[ExtraKey]interface{}) {
+	l.spanEvent("fatal", msg,
+		attribute.String("log.category", string(cat)),
+		attribute.String("log.sub_category", string(sub)),
+	)
+	l.next.Fatal(cat, sub, msg, l.enrich(extra))
+}
+
+func (l *contextLogger) Fatalf(template string, args ...interface{}) {
+	l.spanEvent("fatal", fmt.Sprintf(template, args...))
+	l.next.Fatalf(template+l.correlation(), args...)
+}
@@ -1,11 +1,13 @@
 package logging
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestNewLoggerZap(t *testing.T) {
@@ -119,3 +121,17 @@ func TestSubCategories(t *testing.T) {
 	assert.NotEqual(t, "", string(Api))
 	assert.NotEqual(t, "", string(ExternalService))
 }
+
+func TestFromContextAddsTraceIDs(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("0102030405060708090a0b0c0d0e0f10")
+	spanID, _ := trace.SpanIDFromHex("0102030405060708")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	logger := FromContext(NewContext(ctx, NewLogger(&LoggerConfig{Level: "debug"}))).(*contextLogger)
+	extra := logger.enrich(map[ExtraKey]interface{}{UserID: "1"})
+
+	assert.Equal(t, traceID.String(), extra[TraceID])
+	assert.Equal(t, spanID.String(), extra[SpanID])
+	assert.Equal(t, "1", extra[UserID])
+}
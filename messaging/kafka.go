@@ -0,0 +1,250 @@
+package messaging
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ============================================
+// Kafka
+// ============================================
+
+// KafkaConfig configures the Kafka publisher and subscriber
+type KafkaConfig struct {
+	Brokers []string
+	// TLS enables TLS towards the brokers
+	TLS *tls.Config
+	// Group is the default consumer group of subscriptions
+	Group string
+	// StartFromOldest makes new consumer groups start at the oldest offset
+	// instead of the newest
+	StartFromOldest bool
+	// DeadLetterTopic receives messages failed with a permanent error;
+	// empty drops them
+	DeadLetterTopic string
+}
+
+// KafkaPublisher publishes messages with kafka-go; messages with the same
+// Key land on the same partition
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher creates a Kafka publisher
+func NewKafkaPublisher(cfg KafkaConfig) *KafkaPublisher {
+	return &KafkaPublisher{writer: newKafkaWriter(cfg)}
+}
+
+func newKafkaWriter(cfg KafkaConfig) *kafka.Writer {
+	w := &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}
+	if cfg.TLS != nil {
+		w.Transport = &kafka.Transport{TLS: cfg.TLS}
+	}
+	return w
+}
+
+// Publish writes the messages synchronously
+func (p *KafkaPublisher) Publish(ctx context.Context, topic string, msgs ...*Message) error {
+	records := make([]kafka.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		prepare(topic, msg)
+		if msg.Topic == "" {
+			return ErrNoTopic
+		}
+		records = append(records, toKafka(msg))
+	}
+	return p.writer.WriteMessages(ctx, records...)
+}
+
+// Close flushes and closes the writer
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
+
+// KafkaSubscriber consumes topics in consumer groups. Messages of a
+// partition are handled in order, so a failed message is retried after the
+// retry delay until it succeeds or fails permanently; the concurrency
+// option does not apply
+type KafkaSubscriber struct {
+	cfg KafkaConfig
+
+	mu      sync.Mutex
+	readers map[*kafka.Reader]struct{}
+	dlq     *kafka.Writer
+	closed  bool
+}
+
+// NewKafkaSubscriber creates a Kafka subscriber
+func NewKafkaSubscriber(cfg KafkaConfig) *KafkaSubscriber {
+	s := &KafkaSubscriber{cfg: cfg, readers: make(map[*kafka.Reader]struct{})}
+	if cfg.DeadLetterTopic != "" {
+		s.dlq = newKafkaWriter(cfg)
+	}
+	return s
+}
+
+// Subscribe consumes topic in the group of the options or the config and
+// commits each message once handled
+func (s *KafkaSubscriber) Subscribe(ctx context.Context, topic string, handler Handler, opts ...SubscribeOption) error {
+	if topic == "" {
+		return ErrNoTopic
+	}
+	cfg := newSubscribeConfig(opts)
+	if cfg.Group == "" {
+		cfg.Group = s.cfg.Group
+	}
+	if cfg.Group == "" {
+		return errors.New("messaging: kafka subscriptions need a consumer group")
+	}
+
+	readerCfg := kafka.ReaderConfig{
+		Brokers:     s.cfg.Brokers,
+		GroupID:     cfg.Group,
+		Topic:       topic,
+		StartOffset: kafka.LastOffset,
+	}
+	if s.cfg.StartFromOldest {
+		readerCfg.StartOffset = kafka.FirstOffset
+	}
+	if s.cfg.TLS != nil {
+		readerCfg.Dialer = &kafka.Dialer{Timeout: 10 * time.Second, DualStack: true, TLS: s.cfg.TLS}
+	}
+	reader := kafka.NewReader(readerCfg)
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		reader.Close()
+		return ErrClosed
+	}
+	s.readers[reader] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.readers, reader)
+		s.mu.Unlock()
+		reader.Close()
+	}()
+
+	for {
+		record, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil || s.isClosed() {
+				return nil
+			}
+			return fmt.Errorf("messaging: kafka fetch: %w", err)
+		}
+
+		if err := s.handle(ctx, handler, fromKafka(record), cfg.RetryDelay); err != nil {
+			if ctx.Err() != nil || s.isClosed() {
+				return nil
+			}
+			return err
+		}
+		if err := reader.CommitMessages(ctx, record); err != nil {
+			if ctx.Err() != nil || s.isClosed() {
+				return nil
+			}
+			return fmt.Errorf("messaging: kafka commit: %w", err)
+		}
+	}
+}
+
+// handle calls handler until the message succeeds or fails permanently
+func (s *KafkaSubscriber) handle(ctx context.Context, handler Handler, msg *Message, retryDelay time.Duration) error {
+	for {
+		msg.Attempt++
+		err := handler(ctx, msg)
+		if err == nil {
+			return nil
+		}
+		if IsPermanent(err) {
+			return s.deadLetter(ctx, msg, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+func (s *KafkaSubscriber) deadLetter(ctx context.Context, msg *Message, cause error) error {
+	if s.dlq == nil {
+		return nil
+	}
+	dead := copyMessage(msg)
+	dead.Topic = s.cfg.DeadLetterTopic
+	dead.SetHeader(HeaderOriginalTopic, msg.Topic)
+	dead.SetHeader(HeaderError, cause.Error())
+	if err := s.dlq.WriteMessages(ctx, toKafka(dead)); err != nil {
+		return fmt.Errorf("messaging: kafka dead letter: %w", err)
+	}
+	return nil
+}
+
+func (s *KafkaSubscriber) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// Close stops all subscriptions
+func (s *KafkaSubscriber) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	readers := make([]*kafka.Reader, 0, len(s.readers))
+	for r := range s.readers {
+		readers = append(readers, r)
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for _, r := range readers {
+		errs = append(errs, r.Close())
+	}
+	if s.dlq != nil {
+		errs = append(errs, s.dlq.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func toKafka(msg *Message) kafka.Message {
+	headers := make([]kafka.Header, 0, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	return kafka.Message{
+		Topic:   msg.Topic,
+		Key:     msg.Key,
+		Value:   msg.Body,
+		Headers: headers,
+		Time:    msg.Timestamp,
+	}
+}
+
+func fromKafka(record kafka.Message) *Message {
+	msg := &Message{
+		Topic:     record.Topic,
+		Key:       record.Key,
+		Body:      record.Value,
+		Headers:   make(map[string]string, len(record.Headers)),
+		Timestamp: record.Time,
+	}
+	for _, h := range record.Headers {
+		msg.Headers[h.Key] = string(h.Value)
+	}
+	msg.ID = msg.Headers[HeaderMessageID]
+	return msg
+}
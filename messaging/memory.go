@@ -0,0 +1,169 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryBroker is an in-process Publisher and Subscriber for tests and
+// local development. Subscriptions of the same group share the messages;
+// each subscription without a group receives all of them. Messages
+// published before a subscription exists are not kept
+type MemoryBroker struct {
+	mu      sync.Mutex
+	topics  map[string]map[string]*memoryGroup
+	buffer  int
+	closed  bool
+	closing chan struct{}
+}
+
+type memoryGroup struct {
+	messages chan *Message
+	members  int
+}
+
+// NewMemoryBroker creates an in-memory broker; buffer is the number of
+// undelivered messages per group before Publish blocks (default 100)
+func NewMemoryBroker(buffer int) *MemoryBroker {
+	if buffer <= 0 {
+		buffer = 100
+	}
+	return &MemoryBroker{
+		topics:  make(map[string]map[string]*memoryGroup),
+		buffer:  buffer,
+		closing: make(chan struct{}),
+	}
+}
+
+// Publish delivers the messages to every group subscribed to their topic
+func (b *MemoryBroker) Publish(ctx context.Context, topic string, msgs ...*Message) error {
+	for _, msg := range msgs {
+		prepare(topic, msg)
+		if msg.Topic == "" {
+			return ErrNoTopic
+		}
+
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return ErrClosed
+		}
+		groups := make([]*memoryGroup, 0, len(b.topics[msg.Topic]))
+		for _, g := range b.topics[msg.Topic] {
+			groups = append(groups, g)
+		}
+		b.mu.Unlock()
+
+		for _, g := range groups {
+			if err := b.enqueue(ctx, g, copyMessage(msg)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) enqueue(ctx context.Context, g *memoryGroup, msg *Message) error {
+	select {
+	case g.messages <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.closing:
+		return ErrClosed
+	}
+}
+
+// Subscribe consumes topic until ctx is cancelled or the broker is closed.
+// Failed messages are redelivered after the retry delay
+func (b *MemoryBroker) Subscribe(ctx context.Context, topic string, handler Handler, opts ...SubscribeOption) error {
+	if topic == "" {
+		return ErrNoTopic
+	}
+	cfg := newSubscribeConfig(opts)
+	group := cfg.Group
+	if group == "" {
+		group = "anonymous-" + uuid.NewString()
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	if b.topics[topic] == nil {
+		b.topics[topic] = make(map[string]*memoryGroup)
+	}
+	g := b.topics[topic][group]
+	if g == nil {
+		g = &memoryGroup{messages: make(chan *Message, b.buffer)}
+		b.topics[topic][group] = g
+	}
+	g.members++
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		g.members--
+		if g.members == 0 && cfg.Group == "" {
+			delete(b.topics[topic], group)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-b.closing:
+					return
+				case msg := <-g.messages:
+					msg.Attempt++
+					err := handler(ctx, msg)
+					if err != nil && !IsPermanent(err) {
+						b.redeliver(g, msg, cfg.RetryDelay)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (b *MemoryBroker) redeliver(g *memoryGroup, msg *Message, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		select {
+		case g.messages <- msg:
+		case <-b.closing:
+		}
+	})
+}
+
+// Close stops all subscriptions
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.closing)
+	}
+	return nil
+}
+
+func copyMessage(msg *Message) *Message {
+	c := *msg
+	c.Headers = make(map[string]string, len(msg.Headers))
+	for k, v := range msg.Headers {
+		c.Headers[k] = v
+	}
+	return &c
+}
@@ -0,0 +1,187 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrClosed      = errors.New("messaging: closed")
+	ErrNoTopic     = errors.New("messaging: topic is required")
+	ErrUnsupported = errors.New("messaging: not supported by this driver")
+)
+
+// Message is a broker independent message
+type Message struct {
+	// ID identifies the message for deduplication (default: random UUID)
+	ID string
+	// Topic is the Kafka topic, NATS subject or RabbitMQ routing key
+	Topic string
+	// Key routes messages with the same key to the same partition (Kafka)
+	Key []byte
+	// Headers carry metadata such as the trace context
+	Headers map[string]string
+	Body    []byte
+	// Timestamp is set on publish when zero
+	Timestamp time.Time
+	// Attempt is the delivery attempt, starting at 1, when the broker
+	// reports it
+	Attempt int
+}
+
+// NewMessage creates a message with a new ID
+func NewMessage(topic string, body []byte) *Message {
+	return &Message{
+		ID:        uuid.NewString(),
+		Topic:     topic,
+		Headers:   make(map[string]string),
+		Body:      body,
+		Timestamp: time.Now(),
+	}
+}
+
+// Header returns a header value
+func (m *Message) Header(key string) string {
+	return m.Headers[key]
+}
+
+// SetHeader sets a header value
+func (m *Message) SetHeader(key, value string) {
+	if m.Headers == nil {
+		m.Headers = make(map[string]string)
+	}
+	m.Headers[key] = value
+}
+
+// Header keys set by the drivers
+const (
+	HeaderMessageID = "message-id"
+	// HeaderOriginalTopic and HeaderError describe dead-lettered messages
+	HeaderOriginalTopic = "original-topic"
+	HeaderError         = "error"
+)
+
+// prepare fills the defaults of a message before publishing
+func prepare(topic string, msg *Message) {
+	if msg.ID == "" {
+		msg.ID = uuid.NewString()
+	}
+	if msg.Topic == "" {
+		msg.Topic = topic
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	msg.SetHeader(HeaderMessageID, msg.ID)
+}
+
+// ============================================
+// Publisher / Subscriber
+// ============================================
+
+// Publisher sends messages to a topic
+type Publisher interface {
+	// Publish sends the messages to topic; messages with their own Topic
+	// override it
+	Publish(ctx context.Context, topic string, msgs ...*Message) error
+
+	// Close flushes pending messages and releases the connection
+	Close() error
+}
+
+// Handler processes one message. Returning nil acknowledges it; an error
+// negatively acknowledges it so the broker redelivers it, unless the error
+// is wrapped with Permanent, which acknowledges (or dead-letters) it
+// without redelivery
+type Handler func(ctx context.Context, msg *Message) error
+
+// Subscriber receives messages from a topic
+type Subscriber interface {
+	// Subscribe consumes topic until ctx is cancelled or the subscriber is
+	// closed, calling handler for every message. It blocks and returns nil
+	// once stopped, or the error that ended consumption
+	Subscribe(ctx context.Context, topic string, handler Handler, opts ...SubscribeOption) error
+
+	// Close stops all subscriptions and releases the connection
+	Close() error
+}
+
+// SubscribeOption configures a subscription
+type SubscribeOption func(*SubscribeConfig)
+
+// SubscribeConfig holds the subscription settings
+type SubscribeConfig struct {
+	// Group is the consumer group (Kafka), durable consumer (NATS) or queue
+	// (RabbitMQ) sharing the messages between instances
+	Group string
+	// Concurrency is the number of messages handled in parallel
+	Concurrency int
+	// RetryDelay is how long a negatively acknowledged message waits
+	// before redelivery, where the broker supports it
+	RetryDelay time.Duration
+}
+
+// WithGroup sets the consumer group
+func WithGroup(group string) SubscribeOption {
+	return func(c *SubscribeConfig) {
+		c.Group = group
+	}
+}
+
+// WithConcurrency handles up to n messages in parallel
+func WithConcurrency(n int) SubscribeOption {
+	return func(c *SubscribeConfig) {
+		c.Concurrency = n
+	}
+}
+
+// WithRetryDelay sets the redelivery delay of failed messages
+func WithRetryDelay(d time.Duration) SubscribeOption {
+	return func(c *SubscribeConfig) {
+		c.RetryDelay = d
+	}
+}
+
+func newSubscribeConfig(opts []SubscribeOption) SubscribeConfig {
+	cfg := SubscribeConfig{
+		Concurrency: 1,
+		RetryDelay:  time.Second,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	return cfg
+}
+
+// ============================================
+// Permanent Errors
+// ============================================
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error as not retryable: the message is
+// acknowledged, or dead-lettered where the broker supports it, instead of
+// redelivered
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
@@ -0,0 +1,227 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minisource/go-common/breaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func subscribe(t *testing.T, b *MemoryBroker, ctx context.Context, topic string, handler Handler, opts ...SubscribeOption) {
+	t.Helper()
+	members := func() int {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		n := 0
+		for _, g := range b.topics[topic] {
+			n += g.members
+		}
+		return n
+	}
+	before := members()
+	go func() {
+		_ = b.Subscribe(ctx, topic, handler, opts...)
+	}()
+	// Wait for the subscription to be registered
+	require.Eventually(t, func() bool { return members() > before }, time.Second, time.Millisecond)
+}
+
+func TestMemoryBrokerGroupsShareMessages(t *testing.T) {
+	b := NewMemoryBroker(10)
+	defer b.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	got := map[string]int{}
+	record := func(name string) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			mu.Lock()
+			got[name]++
+			mu.Unlock()
+			return nil
+		}
+	}
+	subscribe(t, b, ctx, "orders", record("a"), WithGroup("billing"))
+	subscribe(t, b, ctx, "orders", record("b"), WithGroup("billing"))
+	subscribe(t, b, ctx, "orders", record("c"))
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, b.Publish(ctx, "orders", NewMessage("", []byte("x"))))
+	}
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return got["a"]+got["b"] == 4 && got["c"] == 4
+	}, time.Second, time.Millisecond)
+}
+
+func TestMemoryBrokerRedeliversFailedMessages(t *testing.T) {
+	b := NewMemoryBroker(10)
+	defer b.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	attempts := make(chan int, 5)
+	subscribe(t, b, ctx, "orders", func(ctx context.Context, msg *Message) error {
+		attempts <- msg.Attempt
+		if msg.Attempt < 3 {
+			return errors.New("boom")
+		}
+		return nil
+	}, WithRetryDelay(time.Millisecond))
+
+	require.NoError(t, b.Publish(ctx, "orders", NewMessage("", nil)))
+
+	for want := 1; want <= 3; want++ {
+		select {
+		case got := <-attempts:
+			assert.Equal(t, want, got)
+		case <-time.After(time.Second):
+			t.Fatalf("attempt %d not delivered", want)
+		}
+	}
+}
+
+func TestMemoryBrokerPermanentErrorIsNotRedelivered(t *testing.T) {
+	b := NewMemoryBroker(10)
+	defer b.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	attempts := make(chan int, 5)
+	subscribe(t, b, ctx, "orders", func(ctx context.Context, msg *Message) error {
+		attempts <- msg.Attempt
+		return Permanent(errors.New("bad payload"))
+	}, WithRetryDelay(time.Millisecond))
+
+	require.NoError(t, b.Publish(ctx, "orders", NewMessage("", nil)))
+
+	assert.Equal(t, 1, <-attempts)
+	select {
+	case <-attempts:
+		t.Fatal("permanent failure was redelivered")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMemoryBrokerClosed(t *testing.T) {
+	b := NewMemoryBroker(0)
+	require.NoError(t, b.Close())
+
+	assert.ErrorIs(t, b.Publish(context.Background(), "orders", NewMessage("", nil)), ErrClosed)
+	assert.ErrorIs(t, b.Subscribe(context.Background(), "orders", nil), ErrClosed)
+}
+
+func TestPublishRequiresTopic(t *testing.T) {
+	b := NewMemoryBroker(0)
+	defer b.Close()
+
+	assert.ErrorIs(t, b.Publish(context.Background(), "", &Message{}), ErrNoTopic)
+}
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, msg *Message) error {
+				order = append(order, name)
+				return next(ctx, msg)
+			}
+		}
+	}
+	h := Chain(func(ctx context.Context, msg *Message) error {
+		order = append(order, "handler")
+		return nil
+	}, mw("outer"), mw("inner"))
+
+	require.NoError(t, h(context.Background(), &Message{}))
+	assert.Equal(t, []string{"outer", "inner", "handler"}, order)
+}
+
+func TestRetry(t *testing.T) {
+	calls := 0
+	h := Retry(RetryConfig{MaxRetries: 2, InitialDelay: time.Millisecond, GiveUp: true})(
+		func(ctx context.Context, msg *Message) error {
+			calls++
+			return errors.New("unavailable")
+		})
+
+	err := h(context.Background(), &Message{})
+
+	assert.Equal(t, 3, calls)
+	assert.True(t, IsPermanent(err))
+	assert.EqualError(t, err, "unavailable")
+}
+
+func TestRetrySkipsPermanentErrors(t *testing.T) {
+	calls := 0
+	h := Retry(DefaultRetryConfig())(func(ctx context.Context, msg *Message) error {
+		calls++
+		return Permanent(errors.New("invalid"))
+	})
+
+	assert.Error(t, h(context.Background(), &Message{}))
+	assert.Equal(t, 1, calls)
+}
+
+func TestRecoverer(t *testing.T) {
+	h := Recoverer()(func(ctx context.Context, msg *Message) error {
+		panic("nil map")
+	})
+
+	err := h(context.Background(), &Message{})
+
+	assert.True(t, IsPermanent(err))
+	assert.Contains(t, err.Error(), "nil map")
+}
+
+func TestCircuitBreakerPausesWhileOpen(t *testing.T) {
+	cb := breaker.New("consumer", breaker.Config{FailureThreshold: 2, OpenTimeout: time.Hour})
+	calls := 0
+	h := CircuitBreaker(cb)(func(ctx context.Context, msg *Message) error {
+		calls++
+		if msg.ID == "bad" {
+			return Permanent(errors.New("invalid"))
+		}
+		return errors.New("database down")
+	})
+
+	// Permanent errors don't count against the dependency
+	for i := 0; i < 3; i++ {
+		assert.Error(t, h(context.Background(), &Message{ID: "bad"}))
+	}
+	assert.Equal(t, breaker.StateClosed, cb.State())
+
+	assert.Error(t, h(context.Background(), &Message{}))
+	assert.Error(t, h(context.Background(), &Message{}))
+	assert.Equal(t, breaker.StateOpen, cb.State())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := h(ctx, &Message{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 5, calls)
+}
+
+func TestTracePublisherPublishes(t *testing.T) {
+	b := NewMemoryBroker(0)
+	defer b.Close()
+	p := TracePublisher(b, "memory")
+
+	msg := NewMessage("orders", nil)
+	require.NoError(t, p.Publish(context.Background(), "orders", msg))
+
+	assert.Equal(t, msg.ID, msg.Header(HeaderMessageID))
+}
+
+func TestDurableName(t *testing.T) {
+	assert.Equal(t, "billing-orders_any_created", durableName("billing", "orders.*.created"))
+	assert.Equal(t, "audit-events_all", durableName("audit", "events.>"))
+}
@@ -0,0 +1,226 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/minisource/go-common/breaker"
+	"github.com/minisource/go-common/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ============================================
+// Middleware
+// ============================================
+
+// Middleware decorates a Handler
+type Middleware func(Handler) Handler
+
+// Chain wraps handler with middlewares; the first one runs outermost
+func Chain(handler Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Recoverer turns a panicking handler into a permanent error, so a poison
+// message cannot crash the consumer or be redelivered forever
+func Recoverer() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = Permanent(fmt.Errorf("messaging: handler panic: %v", r))
+				}
+			}()
+			return next(ctx, msg)
+		}
+	}
+}
+
+// Logging logs failed messages as errors and handled ones at debug level
+func Logging(logger logging.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			start := time.Now()
+			err := next(ctx, msg)
+			extra := map[logging.ExtraKey]interface{}{
+				logging.Topic:     msg.Topic,
+				logging.MessageID: msg.ID,
+				logging.Latency:   time.Since(start).String(),
+			}
+			if err != nil {
+				extra[logging.ErrorMessage] = err.Error()
+				logger.Error(logging.Messaging, logging.Consume, "Message handling failed", extra)
+			} else {
+				logger.Debug(logging.Messaging, logging.Consume, "Message handled", extra)
+			}
+			return err
+		}
+	}
+}
+
+// RetryConfig configures in-process retries
+type RetryConfig struct {
+	MaxRetries    int
+	InitialDelay  time.Duration
+	MaxDelay      time.Duration
+	BackoffFactor float64
+	// GiveUp marks the error permanent once the retries are exhausted, so
+	// the message is dead-lettered instead of redelivered
+	GiveUp bool
+}
+
+// DefaultRetryConfig returns default retry configuration
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries:    3,
+		InitialDelay:  200 * time.Millisecond,
+		MaxDelay:      5 * time.Second,
+		BackoffFactor: 2.0,
+	}
+}
+
+// Retry calls the handler again with exponential backoff when it fails;
+// permanent errors are not retried
+func Retry(cfg RetryConfig) Middleware {
+	if cfg.BackoffFactor < 1 {
+		cfg.BackoffFactor = 2.0
+	}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			delay := cfg.InitialDelay
+			var err error
+			for attempt := 0; ; attempt++ {
+				err = next(ctx, msg)
+				if err == nil || IsPermanent(err) || attempt >= cfg.MaxRetries {
+					break
+				}
+				select {
+				case <-ctx.Done():
+					return err
+				case <-time.After(delay):
+				}
+				delay = time.Duration(float64(delay) * cfg.BackoffFactor)
+				if cfg.MaxDelay > 0 && delay > cfg.MaxDelay {
+					delay = cfg.MaxDelay
+				}
+			}
+			if err != nil && cfg.GiveUp {
+				return Permanent(err)
+			}
+			return err
+		}
+	}
+}
+
+// CircuitBreaker stops consuming while b is open: handlers wait for the
+// breaker instead of failing, and redelivering, every message while a
+// dependency of theirs is down. Permanent errors are the message's fault
+// and don't count as failures
+func CircuitBreaker(b *breaker.Breaker) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			done, err := b.Wait(ctx)
+			if err != nil {
+				return err
+			}
+			err = next(ctx, msg)
+			done(err == nil || IsPermanent(err) || errors.Is(err, context.Canceled))
+			return err
+		}
+	}
+}
+
+// ============================================
+// Tracing
+// ============================================
+
+// Tracing continues the trace of the publisher from the message headers
+// and records a consumer span per message
+func Tracing(system string) Middleware {
+	tracer := otel.Tracer("messaging")
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(msg.Headers))
+			ctx, span := tracer.Start(ctx, msg.Topic+" process",
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(messageAttributes(system, msg)...),
+			)
+			defer span.End()
+
+			err := next(ctx, msg)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
+
+// tracedPublisher injects the trace context into the message headers
+type tracedPublisher struct {
+	next   Publisher
+	tracer trace.Tracer
+	system string
+}
+
+// TracePublisher decorates p so every message carries the trace context of
+// the publishing request. system is reported as messaging.system (e.g.
+// "kafka")
+func TracePublisher(p Publisher, system string) Publisher {
+	return &tracedPublisher{next: p, tracer: otel.Tracer("messaging"), system: system}
+}
+
+func (t *tracedPublisher) Publish(ctx context.Context, topic string, msgs ...*Message) error {
+	ctx, span := t.tracer.Start(ctx, topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystem(t.system),
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingBatchMessageCount(len(msgs)),
+		),
+	)
+	defer span.End()
+
+	propagator := otel.GetTextMapPropagator()
+	for _, msg := range msgs {
+		if msg.Headers == nil {
+			msg.Headers = make(map[string]string)
+		}
+		propagator.Inject(ctx, propagation.MapCarrier(msg.Headers))
+	}
+
+	err := t.next.Publish(ctx, topic, msgs...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+func (t *tracedPublisher) Close() error {
+	return t.next.Close()
+}
+
+func messageAttributes(system string, msg *Message) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.MessagingSystem(system),
+		semconv.MessagingDestinationName(msg.Topic),
+		semconv.MessagingMessageID(msg.ID),
+		semconv.MessagingMessagePayloadSizeBytes(len(msg.Body)),
+	}
+	if msg.Attempt > 0 {
+		attrs = append(attrs, attribute.Int("messaging.delivery_attempt", msg.Attempt))
+	}
+	return attrs
+}
@@ -0,0 +1,218 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// ============================================
+// NATS JetStream
+// ============================================
+
+// NATSConfig configures the JetStream broker
+type NATSConfig struct {
+	URL string
+	// Stream holding the subjects; it must exist
+	Stream string
+	// Options are passed to nats.Connect (credentials, TLS, reconnects)
+	Options []nats.Option
+	// AckWait is how long the server waits for an ack before redelivering
+	// (default: 30s)
+	AckWait time.Duration
+	// MaxDeliver caps deliveries of a message, after which the server gives
+	// up on it (default: unlimited)
+	MaxDeliver int
+}
+
+// NATSBroker publishes to and consumes from a JetStream stream. Topics are
+// subjects; groups are durable consumers
+type NATSBroker struct {
+	cfg  NATSConfig
+	conn *nats.Conn
+	js   jetstream.JetStream
+
+	mu       sync.Mutex
+	consumes map[jetstream.ConsumeContext]struct{}
+	closed   bool
+}
+
+// NewNATSBroker connects to NATS
+func NewNATSBroker(cfg NATSConfig) (*NATSBroker, error) {
+	if cfg.Stream == "" {
+		return nil, errors.New("messaging: nats stream is required")
+	}
+	if cfg.AckWait <= 0 {
+		cfg.AckWait = 30 * time.Second
+	}
+	conn, err := nats.Connect(cfg.URL, cfg.Options...)
+	if err != nil {
+		return nil, fmt.Errorf("messaging: nats connect: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("messaging: jetstream: %w", err)
+	}
+	return &NATSBroker{
+		cfg:      cfg,
+		conn:     conn,
+		js:       js,
+		consumes: make(map[jetstream.ConsumeContext]struct{}),
+	}, nil
+}
+
+// Publish publishes the messages and waits for the stream to store them.
+// The message ID deduplicates retried publishes
+func (b *NATSBroker) Publish(ctx context.Context, topic string, msgs ...*Message) error {
+	for _, msg := range msgs {
+		prepare(topic, msg)
+		if msg.Topic == "" {
+			return ErrNoTopic
+		}
+		m := &nats.Msg{Subject: msg.Topic, Data: msg.Body, Header: nats.Header{}}
+		for k, v := range msg.Headers {
+			m.Header.Set(k, v)
+		}
+		if _, err := b.js.PublishMsg(ctx, m, jetstream.WithMsgID(msg.ID)); err != nil {
+			return fmt.Errorf("messaging: nats publish: %w", err)
+		}
+	}
+	return nil
+}
+
+// Subscribe consumes the subject with a durable consumer named after the
+// group and subject, or an ephemeral one without a group
+func (b *NATSBroker) Subscribe(ctx context.Context, topic string, handler Handler, opts ...SubscribeOption) error {
+	if topic == "" {
+		return ErrNoTopic
+	}
+	cfg := newSubscribeConfig(opts)
+
+	consumerCfg := jetstream.ConsumerConfig{
+		FilterSubject: topic,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       b.cfg.AckWait,
+		MaxDeliver:    b.cfg.MaxDeliver,
+		MaxAckPending: cfg.Concurrency * 2,
+	}
+	if cfg.Group != "" {
+		consumerCfg.Durable = durableName(cfg.Group, topic)
+	} else {
+		consumerCfg.InactiveThreshold = time.Minute
+	}
+	consumer, err := b.js.CreateOrUpdateConsumer(ctx, b.cfg.Stream, consumerCfg)
+	if err != nil {
+		return fmt.Errorf("messaging: nats consumer: %w", err)
+	}
+
+	work := make(chan jetstream.Msg)
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for m := range work {
+				b.handle(ctx, handler, m, cfg.RetryDelay)
+			}
+		}()
+	}
+
+	consumeErr := make(chan error, 1)
+	cc, err := consumer.Consume(func(m jetstream.Msg) {
+		select {
+		case work <- m:
+		case <-ctx.Done():
+			// Redelivered after AckWait
+		}
+	}, jetstream.PullMaxMessages(cfg.Concurrency), jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+		if errors.Is(err, jetstream.ErrConsumerDeleted) {
+			select {
+			case consumeErr <- err:
+			default:
+			}
+		}
+	}))
+	if err != nil {
+		close(work)
+		return fmt.Errorf("messaging: nats consume: %w", err)
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		cc.Stop()
+		close(work)
+		return ErrClosed
+	}
+	b.consumes[cc] = struct{}{}
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.consumes, cc)
+		b.mu.Unlock()
+		cc.Stop()
+		<-cc.Closed()
+		close(work)
+		wg.Wait()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-cc.Closed():
+		return nil
+	case err := <-consumeErr:
+		return fmt.Errorf("messaging: nats consume: %w", err)
+	}
+}
+
+func (b *NATSBroker) handle(ctx context.Context, handler Handler, m jetstream.Msg, retryDelay time.Duration) {
+	msg := &Message{
+		Topic:   m.Subject(),
+		Body:    m.Data(),
+		Headers: make(map[string]string, len(m.Headers())),
+	}
+	for k := range m.Headers() {
+		msg.Headers[k] = m.Headers().Get(k)
+	}
+	msg.ID = msg.Headers[HeaderMessageID]
+	if meta, err := m.Metadata(); err == nil {
+		msg.Attempt = int(meta.NumDelivered)
+		msg.Timestamp = meta.Timestamp
+	}
+
+	err := handler(ctx, msg)
+	switch {
+	case err == nil:
+		_ = m.Ack()
+	case IsPermanent(err):
+		_ = m.TermWithReason(err.Error())
+	default:
+		_ = m.NakWithDelay(retryDelay)
+	}
+}
+
+// Close stops all subscriptions and drains the connection
+func (b *NATSBroker) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	for cc := range b.consumes {
+		cc.Stop()
+	}
+	b.mu.Unlock()
+	return b.conn.Drain()
+}
+
+// durableName builds a consumer name from the group and subject; names may
+// not contain dots, wildcards or whitespace
+func durableName(group, subject string) string {
+	return strings.NewReplacer(".", "_", "*", "any", ">", "all", " ", "_").Replace(group + "-" + subject)
+}
@@ -0,0 +1,224 @@
+package messaging
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ============================================
+// RabbitMQ
+// ============================================
+
+// RabbitMQConfig configures the RabbitMQ broker
+type RabbitMQConfig struct {
+	URL string
+	// TLS enables TLS for amqps:// URLs
+	TLS *tls.Config
+	// Exchange is the durable topic exchange messages are published to
+	// (default: "events")
+	Exchange string
+	// DeadLetterExchange receives messages failed with a permanent error
+	// from queues declared by Subscribe; empty drops them
+	DeadLetterExchange string
+}
+
+// RabbitMQBroker publishes to a topic exchange with the topic as routing
+// key. Groups are durable queues bound to the topic; subscriptions without
+// a group get an exclusive queue that is deleted when they end
+type RabbitMQBroker struct {
+	cfg  RabbitMQConfig
+	conn *amqp.Connection
+
+	pubMu sync.Mutex
+	pub   *amqp.Channel
+}
+
+// NewRabbitMQBroker connects to RabbitMQ and declares the exchange
+func NewRabbitMQBroker(cfg RabbitMQConfig) (*RabbitMQBroker, error) {
+	if cfg.Exchange == "" {
+		cfg.Exchange = "events"
+	}
+
+	var (
+		conn *amqp.Connection
+		err  error
+	)
+	if cfg.TLS != nil {
+		conn, err = amqp.DialTLS(cfg.URL, cfg.TLS)
+	} else {
+		conn, err = amqp.Dial(cfg.URL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("messaging: rabbitmq connect: %w", err)
+	}
+
+	b := &RabbitMQBroker{cfg: cfg, conn: conn}
+	if b.pub, err = b.channel(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := b.pub.Confirm(false); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("messaging: rabbitmq confirm mode: %w", err)
+	}
+	return b, nil
+}
+
+// channel opens a channel and declares the exchange on it
+func (b *RabbitMQBroker) channel() (*amqp.Channel, error) {
+	ch, err := b.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("messaging: rabbitmq channel: %w", err)
+	}
+	if err := ch.ExchangeDeclare(b.cfg.Exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("messaging: rabbitmq exchange: %w", err)
+	}
+	return ch, nil
+}
+
+// Publish publishes persistent messages and waits for the broker to
+// confirm them
+func (b *RabbitMQBroker) Publish(ctx context.Context, topic string, msgs ...*Message) error {
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+
+	for _, msg := range msgs {
+		prepare(topic, msg)
+		if msg.Topic == "" {
+			return ErrNoTopic
+		}
+		headers := make(amqp.Table, len(msg.Headers))
+		for k, v := range msg.Headers {
+			headers[k] = v
+		}
+		confirm, err := b.pub.PublishWithDeferredConfirmWithContext(ctx, b.cfg.Exchange, msg.Topic, false, false, amqp.Publishing{
+			MessageId:    msg.ID,
+			Headers:      headers,
+			Body:         msg.Body,
+			Timestamp:    msg.Timestamp,
+			DeliveryMode: amqp.Persistent,
+		})
+		if err != nil {
+			return fmt.Errorf("messaging: rabbitmq publish: %w", err)
+		}
+		acked, err := confirm.WaitContext(ctx)
+		if err != nil {
+			return fmt.Errorf("messaging: rabbitmq publish: %w", err)
+		}
+		if !acked {
+			return fmt.Errorf("messaging: rabbitmq publish: message %s was rejected", msg.ID)
+		}
+	}
+	return nil
+}
+
+// Subscribe consumes the topic from the group's queue. RabbitMQ has no
+// delayed redelivery, so a failed message holds its worker for the retry
+// delay before it is requeued
+func (b *RabbitMQBroker) Subscribe(ctx context.Context, topic string, handler Handler, opts ...SubscribeOption) error {
+	if topic == "" {
+		return ErrNoTopic
+	}
+	cfg := newSubscribeConfig(opts)
+
+	ch, err := b.channel()
+	if err != nil {
+		if b.conn.IsClosed() {
+			return ErrClosed
+		}
+		return err
+	}
+	defer ch.Close()
+
+	var args amqp.Table
+	if b.cfg.DeadLetterExchange != "" {
+		args = amqp.Table{"x-dead-letter-exchange": b.cfg.DeadLetterExchange}
+	}
+	durable := cfg.Group != ""
+	queue, err := ch.QueueDeclare(cfg.Group, durable, false, !durable, false, args)
+	if err != nil {
+		return fmt.Errorf("messaging: rabbitmq queue: %w", err)
+	}
+	if err := ch.QueueBind(queue.Name, topic, b.cfg.Exchange, false, nil); err != nil {
+		return fmt.Errorf("messaging: rabbitmq bind: %w", err)
+	}
+	if err := ch.Qos(cfg.Concurrency, 0, false); err != nil {
+		return fmt.Errorf("messaging: rabbitmq qos: %w", err)
+	}
+	deliveries, err := ch.Consume(queue.Name, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("messaging: rabbitmq consume: %w", err)
+	}
+
+	// Closing the channel ends the deliveries once ctx is done
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			ch.Close()
+		case <-stop:
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range deliveries {
+				b.handle(ctx, handler, d, cfg.RetryDelay)
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (b *RabbitMQBroker) handle(ctx context.Context, handler Handler, d amqp.Delivery, retryDelay time.Duration) {
+	msg := &Message{
+		ID:        d.MessageId,
+		Topic:     d.RoutingKey,
+		Body:      d.Body,
+		Headers:   make(map[string]string, len(d.Headers)),
+		Timestamp: d.Timestamp,
+		Attempt:   1,
+	}
+	for k, v := range d.Headers {
+		msg.Headers[k] = fmt.Sprint(v)
+	}
+	// Quorum queues count deliveries; classic queues only flag redeliveries
+	if count, ok := d.Headers["x-delivery-count"].(int64); ok {
+		msg.Attempt = int(count) + 1
+	} else if d.Redelivered {
+		msg.Attempt = 2
+	}
+
+	err := handler(ctx, msg)
+	switch {
+	case err == nil:
+		_ = d.Ack(false)
+	case IsPermanent(err):
+		_ = d.Nack(false, false)
+	default:
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryDelay):
+		}
+		_ = d.Nack(false, true)
+	}
+}
+
+// Close closes the connection, which ends all subscriptions
+func (b *RabbitMQBroker) Close() error {
+	if b.conn.IsClosed() {
+		return nil
+	}
+	return b.conn.Close()
+}
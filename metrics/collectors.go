@@ -0,0 +1,288 @@
+package metrics
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ============================================
+// Buckets
+// ============================================
+
+// Latency buckets in seconds
+var (
+	// HTTPDurationBuckets covers typical API latencies, 5ms to 10s
+	HTTPDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	// DBDurationBuckets covers query latencies, 1ms to 5s
+	DBDurationBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+	// SizeBuckets covers response sizes, 100B to 10MB
+	SizeBuckets = prometheus.ExponentialBuckets(100, 10, 6)
+)
+
+// StatusClass returns the class of an HTTP status code, e.g. "2xx"
+func StatusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// ============================================
+// HTTP Server
+// ============================================
+
+// HTTPServerMetrics are the RED metrics of an HTTP server. Routes are
+// templates (/users/:id), never raw paths
+type HTTPServerMetrics struct {
+	Requests     *prometheus.CounterVec
+	Duration     *prometheus.HistogramVec
+	InFlight     *prometheus.GaugeVec
+	ResponseSize *prometheus.HistogramVec
+}
+
+// NewHTTPServerMetrics registers the HTTP server collectors on r
+func NewHTTPServerMetrics(r *Registry) *HTTPServerMetrics {
+	return &HTTPServerMetrics{
+		Requests: r.Counter("http_server_requests_total",
+			"Total number of HTTP requests handled", "method", "route", "status_class"),
+		Duration: r.Histogram("http_server_request_duration_seconds",
+			"Duration of HTTP requests in seconds", HTTPDurationBuckets, "method", "route", "status_class"),
+		InFlight: r.Gauge("http_server_requests_in_flight",
+			"Number of HTTP requests being handled", "method"),
+		ResponseSize: r.Histogram("http_server_response_size_bytes",
+			"Size of HTTP responses in bytes", SizeBuckets, "method", "route", "status_class"),
+	}
+}
+
+// Observe records a finished request
+func (m *HTTPServerMetrics) Observe(method, route string, status, size int, duration time.Duration) {
+	class := StatusClass(status)
+	m.Requests.WithLabelValues(method, route, class).Inc()
+	m.Duration.WithLabelValues(method, route, class).Observe(duration.Seconds())
+	m.ResponseSize.WithLabelValues(method, route, class).Observe(float64(size))
+}
+
+// ============================================
+// Database
+// ============================================
+
+// DBMetrics track database queries by operation and table
+type DBMetrics struct {
+	Queries      *prometheus.CounterVec
+	Duration     *prometheus.HistogramVec
+	RowsAffected *prometheus.CounterVec
+	Errors       *prometheus.CounterVec
+}
+
+// NewDBMetrics registers the database collectors on r
+func NewDBMetrics(r *Registry) *DBMetrics {
+	return &DBMetrics{
+		Queries: r.Counter("db_queries_total",
+			"Total number of database queries", "operation", "table", "status"),
+		Duration: r.Histogram("db_query_duration_seconds",
+			"Duration of database queries in seconds", DBDurationBuckets, "operation", "table"),
+		RowsAffected: r.Counter("db_rows_affected_total",
+			"Total number of rows affected by database queries", "operation", "table"),
+		Errors: r.Counter("db_errors_total",
+			"Total number of failed database queries", "operation", "table"),
+	}
+}
+
+// Observe records a finished query; a nil err counts as success
+func (m *DBMetrics) Observe(operation, table string, rows int64, duration time.Duration, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+		m.Errors.WithLabelValues(operation, table).Inc()
+	}
+	m.Queries.WithLabelValues(operation, table, status).Inc()
+	m.Duration.WithLabelValues(operation, table).Observe(duration.Seconds())
+	if rows > 0 {
+		m.RowsAffected.WithLabelValues(operation, table).Add(float64(rows))
+	}
+}
+
+// ============================================
+// Cache
+// ============================================
+
+// CacheMetrics track cache lookups by cache name
+type CacheMetrics struct {
+	Hits     *prometheus.CounterVec
+	Misses   *prometheus.CounterVec
+	Errors   *prometheus.CounterVec
+	Duration *prometheus.HistogramVec
+}
+
+// NewCacheMetrics registers the cache collectors on r
+func NewCacheMetrics(r *Registry) *CacheMetrics {
+	return &CacheMetrics{
+		Hits: r.Counter("cache_lookup_hits_total",
+			"Total number of cache hits", "cache"),
+		Misses: r.Counter("cache_lookup_misses_total",
+			"Total number of cache misses", "cache"),
+		Errors: r.Counter("cache_errors_total",
+			"Total number of failed cache operations", "cache", "operation"),
+		Duration: r.Histogram("cache_operation_duration_seconds",
+			"Duration of cache operations in seconds", DBDurationBuckets, "cache", "operation"),
+	}
+}
+
+// Hit records a cache hit
+func (m *CacheMetrics) Hit(cache string) {
+	m.Hits.WithLabelValues(cache).Inc()
+}
+
+// Miss records a cache miss
+func (m *CacheMetrics) Miss(cache string) {
+	m.Misses.WithLabelValues(cache).Inc()
+}
+
+// Observe records a finished cache operation
+func (m *CacheMetrics) Observe(cache, operation string, duration time.Duration, err error) {
+	if err != nil {
+		m.Errors.WithLabelValues(cache, operation).Inc()
+	}
+	m.Duration.WithLabelValues(cache, operation).Observe(duration.Seconds())
+}
+
+// ============================================
+// External Calls
+// ============================================
+
+// ExternalMetrics track calls to other services by target and operation
+type ExternalMetrics struct {
+	Requests *prometheus.CounterVec
+	Duration *prometheus.HistogramVec
+	InFlight *prometheus.GaugeVec
+}
+
+// NewExternalMetrics registers the external call collectors on r
+func NewExternalMetrics(r *Registry) *ExternalMetrics {
+	return &ExternalMetrics{
+		Requests: r.Counter("external_requests_total",
+			"Total number of calls to external services", "target", "operation", "status"),
+		Duration: r.Histogram("external_request_duration_seconds",
+			"Duration of calls to external services in seconds", HTTPDurationBuckets, "target", "operation"),
+		InFlight: r.Gauge("external_requests_in_flight",
+			"Number of calls to external services in progress", "target"),
+	}
+}
+
+// Start marks a call in flight and returns a function recording its
+// outcome, where status is e.g. "2xx", "OK" or "error"
+func (m *ExternalMetrics) Start(target, operation string) func(status string) {
+	start := time.Now()
+	inFlight := m.InFlight.WithLabelValues(target)
+	inFlight.Inc()
+	return func(status string) {
+		inFlight.Dec()
+		m.Requests.WithLabelValues(target, operation, status).Inc()
+		m.Duration.WithLabelValues(target, operation).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ============================================
+// HTTP and gRPC Clients
+// ============================================
+
+// HTTPClientMetrics track calls made with httpclient by service, method
+// and path. A call counts once with its final status, however many
+// attempts it took; Retries counts the extra attempts
+type HTTPClientMetrics struct {
+	Requests     *prometheus.CounterVec
+	Duration     *prometheus.HistogramVec
+	Retries      *prometheus.CounterVec
+	BreakerState *prometheus.GaugeVec
+}
+
+// NewHTTPClientMetrics registers the HTTP client collectors on r
+func NewHTTPClientMetrics(r *Registry) *HTTPClientMetrics {
+	return &HTTPClientMetrics{
+		Requests: r.Counter("http_client_requests_total",
+			"Total number of HTTP client calls", "service", "method", "path", "status"),
+		Duration: r.Histogram("http_client_request_duration_seconds",
+			"Duration of HTTP client calls in seconds, including retries", HTTPDurationBuckets, "service", "method", "path"),
+		Retries: r.Counter("http_client_retries_total",
+			"Total number of HTTP client retry attempts", "service", "method", "path"),
+		BreakerState: r.Gauge("http_client_circuit_breaker_state",
+			"Circuit breaker state of HTTP clients (0 = closed, 1 = half-open, 2 = open)", "service", "key"),
+	}
+}
+
+// Observe records a finished call, where status is a status class such as
+// "2xx", "error" or "rejected"
+func (m *HTTPClientMetrics) Observe(service, method, path, status string, retries int, duration time.Duration) {
+	m.Requests.WithLabelValues(service, method, path, status).Inc()
+	m.Duration.WithLabelValues(service, method, path).Observe(duration.Seconds())
+	if retries > 0 {
+		m.Retries.WithLabelValues(service, method, path).Add(float64(retries))
+	}
+}
+
+// GRPCClientMetrics track calls made with grpcclient by service and full
+// method name. A call counts once with its final code; Retries counts the
+// extra attempts
+type GRPCClientMetrics struct {
+	Requests     *prometheus.CounterVec
+	Duration     *prometheus.HistogramVec
+	Retries      *prometheus.CounterVec
+	BreakerState *prometheus.GaugeVec
+}
+
+// NewGRPCClientMetrics registers the gRPC client collectors on r
+func NewGRPCClientMetrics(r *Registry) *GRPCClientMetrics {
+	return &GRPCClientMetrics{
+		Requests: r.Counter("grpc_client_requests_total",
+			"Total number of gRPC client calls", "service", "method", "code"),
+		Duration: r.Histogram("grpc_client_request_duration_seconds",
+			"Duration of gRPC client calls in seconds, including retries", HTTPDurationBuckets, "service", "method"),
+		Retries: r.Counter("grpc_client_retries_total",
+			"Total number of gRPC client retry attempts", "service", "method"),
+		BreakerState: r.Gauge("grpc_client_circuit_breaker_state",
+			"Circuit breaker state of gRPC clients (0 = closed, 1 = half-open, 2 = open)", "service", "key"),
+	}
+}
+
+// Observe records a finished call, where code is a gRPC status code name
+// or "rejected"
+func (m *GRPCClientMetrics) Observe(service, method, code string, retries int, duration time.Duration) {
+	m.Requests.WithLabelValues(service, method, code).Inc()
+	m.Duration.WithLabelValues(service, method).Observe(duration.Seconds())
+	if retries > 0 {
+		m.Retries.WithLabelValues(service, method).Add(float64(retries))
+	}
+}
+
+// ============================================
+// Build Info
+// ============================================
+
+// BuildInfo describes the running binary
+type BuildInfo struct {
+	Version   string
+	Commit    string
+	BuildDate string
+}
+
+// RegisterBuildInfo sets the build_info gauge to 1 with the build labels;
+// an empty Version or Commit is read from the embedded module build info
+func RegisterBuildInfo(r *Registry, info BuildInfo) {
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" {
+			info.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			if info.Commit == "" && s.Key == "vcs.revision" {
+				info.Commit = s.Value
+			}
+		}
+	}
+	r.Gauge("build_info",
+		"Build information of the running binary, always 1", "version", "commit", "build_date", "go_version").
+		WithLabelValues(info.Version, info.Commit, info.BuildDate, runtime.Version()).Set(1)
+}
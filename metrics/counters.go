@@ -2,6 +2,8 @@ package metrics
 
 import "github.com/prometheus/client_golang/prometheus"
 
+// DbCall counts service level database operations by entity; query level
+// metrics come from GormPlugin
 var DbCall = prometheus.NewCounterVec(
 	prometheus.CounterOpts{
 		Name: "db_calls_total",
@@ -29,3 +31,94 @@ var CacheMissesTotal = prometheus.NewCounterVec(
 		Help: "Total number of cache misses",
 	}, []string{"cache_type"},
 )
+
+var AuditLogsPrunedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "audit_logs_pruned_total",
+		Help: "Total number of audit logs removed by retention",
+	}, []string{"mode"},
+)
+
+var AuditPruneErrorsTotal = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "audit_prune_errors_total",
+		Help: "Total number of failed audit retention runs",
+	},
+)
+
+var SpanRequestsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "span_requests_total",
+		Help: "Total number of server requests derived from spans",
+	}, []string{"route", "method", "status_code"},
+)
+
+var SpanErrorsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "span_errors_total",
+		Help: "Total number of failed server requests derived from spans",
+	}, []string{"route", "method", "status_code"},
+)
+
+var RateLimitDecisionsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "rate_limit_decisions_total",
+		Help: "Total number of rate limit decisions",
+	}, []string{"limiter", "key_class", "result"},
+)
+
+var RateLimitKeysEvictedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "rate_limit_keys_evicted_total",
+		Help: "Total number of idle limiter keys removed by cleanup",
+	}, []string{"limiter"},
+)
+
+var SchedulerJobRunsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "scheduler_job_runs_total",
+		Help: "Total number of scheduled job ticks by result",
+	}, []string{"job", "result"},
+)
+
+var NotificationsSentTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "notifications_sent_total",
+		Help: "Total number of notifications sent by channel and result",
+	}, []string{"channel", "result"},
+)
+
+var CircuitBreakerTransitionsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "circuit_breaker_transitions_total",
+		Help: "Total number of circuit breaker state changes by new state",
+	}, []string{"breaker", "key", "state"},
+)
+
+var CircuitBreakerRejectedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "circuit_breaker_rejected_total",
+		Help: "Total number of calls rejected by an open circuit breaker",
+	}, []string{"breaker", "key"},
+)
+
+var LeaderElectionChangesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "leader_election_changes_total",
+		Help: "Total number of leadership changes of this replica by event",
+	}, []string{"key", "event"},
+)
+
+var WebSocketMessagesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "websocket_messages_total",
+		Help: "Total number of WebSocket messages by direction (in, out, dropped)",
+	}, []string{"hub", "direction"},
+)
+
+var SSEEventsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "sse_events_total",
+		Help: "Total number of server-sent events by result (published, sent, dropped)",
+	}, []string{"broker", "result"},
+)
@@ -0,0 +1,52 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var HealthCheckStatus = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "health_check_status",
+		Help: "Result of the last health check (1 = healthy, 0.5 = degraded, 0 = unhealthy)",
+	}, []string{"checker"},
+)
+
+var HealthCheckConsecutiveFailures = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "health_check_consecutive_failures",
+		Help: "Number of consecutive failed health checks",
+	}, []string{"checker"},
+)
+
+var RateLimitTrackedKeys = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "rate_limit_tracked_keys",
+		Help: "Number of keys currently tracked by a limiter",
+	}, []string{"limiter"},
+)
+
+var CircuitBreakerState = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "circuit_breaker_state",
+		Help: "Current circuit breaker state (0 = closed, 1 = half-open, 2 = open)",
+	}, []string{"breaker", "key"},
+)
+
+var LeaderElectionIsLeader = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "leader_election_is_leader",
+		Help: "Whether this replica leads the election (1 = leader, 0 = follower)",
+	}, []string{"key"},
+)
+
+var WebSocketConnections = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "websocket_connections",
+		Help: "Number of open WebSocket connections",
+	}, []string{"hub"},
+)
+
+var SSEConnections = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "sse_connections",
+		Help: "Number of open server-sent event streams",
+	}, []string{"broker"},
+)
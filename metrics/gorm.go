@@ -0,0 +1,200 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const gormStartKey = "metrics:start"
+
+// GormPluginConfig configures database metrics
+type GormPluginConfig struct {
+	// Registry receives the db_* collectors (default: DefaultRegistry)
+	Registry *Registry
+	// Metrics overrides the collectors built from Registry
+	Metrics *DBMetrics
+	// CountNotFound records gorm.ErrRecordNotFound as an error
+	CountNotFound bool
+}
+
+// DefaultGormPluginConfig returns default database metrics configuration
+func DefaultGormPluginConfig() GormPluginConfig {
+	return GormPluginConfig{}
+}
+
+// GormPlugin records duration, rows affected and errors of every query by
+// operation and table, so repositories need no manual DbCall bookkeeping
+type GormPlugin struct {
+	cfg     GormPluginConfig
+	metrics *DBMetrics
+}
+
+// NewGormPlugin creates the plugin; register it with db.Use(plugin)
+func NewGormPlugin(cfg GormPluginConfig) *GormPlugin {
+	m := cfg.Metrics
+	if m == nil {
+		if cfg.Registry == nil {
+			cfg.Registry = DefaultRegistry()
+		}
+		m = NewDBMetrics(cfg.Registry)
+	}
+	return &GormPlugin{cfg: cfg, metrics: m}
+}
+
+// Name implements gorm.Plugin
+func (p *GormPlugin) Name() string {
+	return "metrics"
+}
+
+// Initialize implements gorm.Plugin by registering the callbacks
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	registrations := []error{
+		cb.Create().Before("gorm:create").Register("metrics:before_create", p.before),
+		cb.Create().After("gorm:create").Register("metrics:after_create", p.after),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", p.before),
+		cb.Query().After("gorm:query").Register("metrics:after_query", p.after),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", p.before),
+		cb.Update().After("gorm:update").Register("metrics:after_update", p.after),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", p.before),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", p.after),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", p.before),
+		cb.Row().After("gorm:row").Register("metrics:after_row", p.after),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", p.before),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", p.after),
+	}
+	for _, err := range registrations {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *GormPlugin) before(db *gorm.DB) {
+	db.InstanceSet(gormStartKey, time.Now())
+}
+
+func (p *GormPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormStartKey)
+	if !ok {
+		return
+	}
+	start, ok := v.(time.Time)
+	if !ok {
+		return
+	}
+
+	operation, table := NormalizeStatement(db.Statement.SQL.String())
+	if db.Statement.Table != "" {
+		table = db.Statement.Table
+	}
+
+	err := db.Error
+	if !p.cfg.CountNotFound && errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	p.metrics.Observe(operation, table, db.RowsAffected, time.Since(start), err)
+}
+
+// NormalizeStatement returns the lowercase operation and the table of a
+// SQL statement, e.g. ("select", "users") for SELECT * FROM "users" WHERE
+// ...; values never reach the labels. Leading comments are skipped and
+// unrecognized parts are "unknown"
+func NormalizeStatement(sql string) (operation, table string) {
+	fields := strings.Fields(stripLeadingComments(sql))
+	if len(fields) == 0 {
+		return "unknown", "unknown"
+	}
+	operation = strings.ToLower(strings.TrimLeft(fields[0], "("))
+	if !isWord(operation) {
+		return "unknown", "unknown"
+	}
+
+	var after string
+	switch operation {
+	case "select", "with":
+		after = "from"
+	case "insert":
+		after = "into"
+	case "update":
+		return operation, orUnknown(tableName(fields, 1))
+	case "delete":
+		after = "from"
+	default:
+		return operation, "unknown"
+	}
+	for i := 1; i < len(fields); i++ {
+		if !strings.EqualFold(fields[i], after) {
+			continue
+		}
+		// A subquery has no name of its own; use the next table read
+		if name := tableName(fields, i+1); name != "" {
+			return operation, name
+		}
+	}
+	return operation, "unknown"
+}
+
+// stripLeadingComments removes the /* */ and -- comments before a
+// statement, e.g. the annotations added by query tagging
+func stripLeadingComments(sql string) string {
+	for {
+		sql = strings.TrimSpace(sql)
+		switch {
+		case strings.HasPrefix(sql, "/*"):
+			end := strings.Index(sql, "*/")
+			if end < 0 {
+				return ""
+			}
+			sql = sql[end+2:]
+		case strings.HasPrefix(sql, "--"):
+			end := strings.IndexByte(sql, '\n')
+			if end < 0 {
+				return ""
+			}
+			sql = sql[end+1:]
+		default:
+			return sql
+		}
+	}
+}
+
+// tableName reads the table at fields[i], dropping quotes and ONLY; it is
+// empty for a subquery or a placeholder
+func tableName(fields []string, i int) string {
+	if i < len(fields) && strings.EqualFold(fields[i], "only") {
+		i++
+	}
+	if i >= len(fields) || strings.HasPrefix(fields[i], "(") {
+		return ""
+	}
+	name := strings.Trim(fields[i], ");,")
+	name = strings.NewReplacer(`"`, "", "`", "", "[", "", "]", "").Replace(name)
+	if name == "" || strings.HasPrefix(name, "$") || strings.HasPrefix(name, "?") {
+		return ""
+	}
+	return strings.ToLower(name)
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// isWord reports whether s is a plain keyword, so arbitrary text never
+// becomes an operation label
+func isWord(s string) bool {
+	for _, r := range s {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return s != ""
+}
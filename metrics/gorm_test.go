@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeStatement(t *testing.T) {
+	tests := []struct {
+		sql       string
+		operation string
+		table     string
+	}{
+		{`SELECT * FROM "users" WHERE "users"."id" = $1`, "select", "users"},
+		{"SELECT count(*) FROM `orders` WHERE status = ?", "select", "orders"},
+		{`SELECT * FROM "public"."users" LIMIT 1`, "select", "public.users"},
+		{`SELECT * FROM [dbo].[Users]`, "select", "dbo.users"},
+		{`SELECT * FROM ONLY "accounts"`, "select", "accounts"},
+		{`select * from users;`, "select", "users"},
+		{`SELECT * FROM (SELECT id FROM "events") AS e`, "select", "events"},
+		{`WITH recent AS (SELECT * FROM "orders" WHERE created_at > $1) SELECT * FROM recent`, "with", "orders"},
+		{`INSERT INTO "audit_logs" ("id","action") VALUES ($1,$2)`, "insert", "audit_logs"},
+		{`UPDATE "users" SET "name"=$1 WHERE "id" = $2`, "update", "users"},
+		{`UPDATE ONLY users SET name = 'x'`, "update", "users"},
+		{`DELETE FROM "sessions" WHERE expires_at < $1`, "delete", "sessions"},
+		{`/* app:orders */ SELECT * FROM "orders"`, "select", "orders"},
+		{"-- list users\nSELECT * FROM users", "select", "users"},
+		{"/* a */ -- b\n /* c */ DELETE FROM carts", "delete", "carts"},
+		{`TRUNCATE TABLE users`, "truncate", "unknown"},
+		{`VACUUM`, "vacuum", "unknown"},
+		{`SELECT 1`, "select", "unknown"},
+		{`SELECT * FROM $1`, "select", "unknown"},
+		{`(SELECT * FROM users) UNION (SELECT * FROM admins)`, "select", "users"},
+		{`'; DROP TABLE users; --`, "unknown", "unknown"},
+		{`/* unterminated SELECT * FROM users`, "unknown", "unknown"},
+		{``, "unknown", "unknown"},
+		{"   \n\t", "unknown", "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			operation, table := NormalizeStatement(tt.sql)
+			assert.Equal(t, tt.operation, operation)
+			assert.Equal(t, tt.table, table)
+		})
+	}
+}
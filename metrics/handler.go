@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ============================================
+// Handler
+// ============================================
+
+// DefaultPath is where Mount serves metrics
+const DefaultPath = "/metrics"
+
+// HandlerOption configures Handler
+type HandlerOption func(*handlerOptions)
+
+type handlerOptions struct {
+	username string
+	password string
+}
+
+// WithBasicAuth requires HTTP basic auth with the given credentials
+func WithBasicAuth(username, password string) HandlerOption {
+	return func(o *handlerOptions) {
+		o.username = username
+		o.password = password
+	}
+}
+
+// Handler serves the metrics of gatherer in the Prometheus exposition
+// format; a nil gatherer serves the global registry
+func Handler(gatherer prometheus.Gatherer, opts ...HandlerOption) fiber.Handler {
+	o := &handlerOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if gatherer == nil {
+		gatherer = prometheus.DefaultGatherer
+	}
+
+	serve := adaptor.HTTPHandler(promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+	if o.username == "" && o.password == "" {
+		return serve
+	}
+	return func(c *fiber.Ctx) error {
+		if !o.authorized(c) {
+			c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="metrics"`)
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+		return serve(c)
+	}
+}
+
+func (o *handlerOptions) authorized(c *fiber.Ctx) bool {
+	username, password, ok := basicAuth(c)
+	if !ok {
+		return false
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(o.username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(password), []byte(o.password)) == 1
+	return userOK && passOK
+}
+
+// basicAuth parses the basic credentials of the Authorization header
+func basicAuth(c *fiber.Ctx) (string, string, bool) {
+	auth := c.Get(fiber.HeaderAuthorization)
+	const prefix = "Basic "
+	if len(auth) < len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(auth[len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Cut(string(decoded), ":")
+}
+
+// Mount serves the metrics of r at /metrics on router
+func Mount(router fiber.Router, r *Registry, opts ...HandlerOption) {
+	router.Get(DefaultPath, Handler(r.Gatherer(), opts...))
+}
@@ -15,3 +15,24 @@ var DbQueryDuration = prometheus.NewHistogramVec(
 		Help:    "Duration of database queries in milliseconds",
 		Buckets: prometheus.DefBuckets,
 	}, []string{"operation", "table"})
+
+var HealthCheckDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "health_check_duration_seconds",
+		Help:    "Duration of health checks in seconds",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2, 5},
+	}, []string{"checker"})
+
+var SpanDurationSeconds = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "span_duration_seconds",
+		Help:    "Duration of server requests derived from spans in seconds",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"route", "method", "status_code"})
+
+var SchedulerJobDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "scheduler_job_duration_seconds",
+		Help:    "Duration of scheduled job runs in seconds",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900},
+	}, []string{"job"})
@@ -17,4 +17,47 @@ func InitMetrics() {
 	// Register cache metrics
 	prometheus.MustRegister(CacheHitsTotal)
 	prometheus.MustRegister(CacheMissesTotal)
+
+	// Register health check metrics
+	prometheus.MustRegister(HealthCheckStatus)
+	prometheus.MustRegister(HealthCheckDuration)
+	prometheus.MustRegister(HealthCheckConsecutiveFailures)
+
+	// Register audit metrics
+	prometheus.MustRegister(AuditLogsPrunedTotal)
+	prometheus.MustRegister(AuditPruneErrorsTotal)
+
+	// Register span-derived RED metrics
+	prometheus.MustRegister(SpanRequestsTotal)
+	prometheus.MustRegister(SpanErrorsTotal)
+	prometheus.MustRegister(SpanDurationSeconds)
+
+	// Register rate limiter metrics
+	prometheus.MustRegister(RateLimitDecisionsTotal)
+	prometheus.MustRegister(RateLimitKeysEvictedTotal)
+	prometheus.MustRegister(RateLimitTrackedKeys)
+
+	// Register scheduler metrics
+	prometheus.MustRegister(SchedulerJobRunsTotal)
+	prometheus.MustRegister(SchedulerJobDuration)
+
+	// Register notification metrics
+	prometheus.MustRegister(NotificationsSentTotal)
+
+	// Register circuit breaker metrics
+	prometheus.MustRegister(CircuitBreakerState)
+	prometheus.MustRegister(CircuitBreakerTransitionsTotal)
+	prometheus.MustRegister(CircuitBreakerRejectedTotal)
+
+	// Register leader election metrics
+	prometheus.MustRegister(LeaderElectionIsLeader)
+	prometheus.MustRegister(LeaderElectionChangesTotal)
+
+	// Register WebSocket metrics
+	prometheus.MustRegister(WebSocketConnections)
+	prometheus.MustRegister(WebSocketMessagesTotal)
+
+	// Register server-sent event metrics
+	prometheus.MustRegister(SSEConnections)
+	prometheus.MustRegister(SSEEventsTotal)
 }
@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promcollector "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// ============================================
+// OTLP Export
+// ============================================
+
+// OTLP transport protocols
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+)
+
+// ShutdownFunc flushes pending metrics and releases the exporter. Its
+// signature matches shutdown.Hook so it can be registered directly
+type ShutdownFunc func(ctx context.Context) error
+
+// OTLPConfig configures OTLP metric export. tracing.Config.MetricsConfig
+// derives it from the shared telemetry config
+type OTLPConfig struct {
+	Enabled bool
+	// Endpoint is the collector address, e.g. "localhost:4317"
+	Endpoint string
+	// Protocol is "grpc" (default) or "http"
+	Protocol string
+	// Insecure disables TLS towards the collector
+	Insecure bool
+	// Headers are sent with every export (e.g. collector auth)
+	Headers map[string]string
+	// Interval between exports
+	Interval time.Duration
+	// Timeout bounds a single export call
+	Timeout time.Duration
+
+	ServiceName        string
+	ServiceVersion     string
+	Environment        string
+	ResourceAttributes map[string]string
+
+	// Gatherer is the Prometheus registry whose collectors are exported
+	// (default: the global registry)
+	Gatherer prometheus.Gatherer
+}
+
+// DefaultOTLPConfig returns default OTLP export configuration
+func DefaultOTLPConfig() OTLPConfig {
+	return OTLPConfig{
+		Endpoint: "localhost:4317",
+		Protocol: ProtocolGRPC,
+		Insecure: true,
+		Interval: 15 * time.Second,
+		Timeout:  10 * time.Second,
+	}
+}
+
+// InitOTLP periodically exports every Prometheus collector of the gatherer
+// to an OTLP collector, alongside or instead of the /metrics scrape, and
+// installs the global MeterProvider so OpenTelemetry instruments are
+// exported too. When export is disabled it does nothing
+//
+//	shutdownMetrics, err := metrics.InitOTLP(ctx, tracingCfg.MetricsConfig())
+//	manager.AddHook("metrics", shutdown.Hook(shutdownMetrics), shutdown.InStage(shutdown.StageResources))
+func InitOTLP(ctx context.Context, cfg OTLPConfig) (ShutdownFunc, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+	provider, err := newMeterProvider(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetMeterProvider(provider)
+	return provider.Shutdown, nil
+}
+
+func newMeterProvider(ctx context.Context, cfg OTLPConfig) (*sdkmetric.MeterProvider, error) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 15 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Gatherer == nil {
+		cfg.Gatherer = prometheus.DefaultGatherer
+	}
+
+	exporter, err := newMetricExporter(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+	}
+
+	reader := sdkmetric.NewPeriodicReader(exporter,
+		sdkmetric.WithInterval(cfg.Interval),
+		sdkmetric.WithTimeout(cfg.Timeout),
+		sdkmetric.WithProducer(promcollector.NewMetricProducer(promcollector.WithGatherer(cfg.Gatherer))),
+	)
+
+	return sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithResource(newResource(cfg)),
+	), nil
+}
+
+// newMetricExporter creates an OTLP metric exporter for the configured
+// protocol. Exporters connect lazily, so an unreachable collector doesn't
+// block startup
+func newMetricExporter(ctx context.Context, cfg OTLPConfig) (sdkmetric.Exporter, error) {
+	switch cfg.Protocol {
+	case "", ProtocolGRPC:
+		opts := []otlpmetricgrpc.Option{
+			otlpmetricgrpc.WithEndpoint(cfg.Endpoint),
+			otlpmetricgrpc.WithTimeout(cfg.Timeout),
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetricgrpc.WithHeaders(cfg.Headers))
+		}
+		return otlpmetricgrpc.New(ctx, opts...)
+	case ProtocolHTTP:
+		opts := []otlpmetrichttp.Option{
+			otlpmetrichttp.WithEndpoint(cfg.Endpoint),
+			otlpmetrichttp.WithTimeout(cfg.Timeout),
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(cfg.Headers))
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol: %s", cfg.Protocol)
+	}
+}
+
+// newResource describes the service emitting metrics
+func newResource(cfg OTLPConfig) *resource.Resource {
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+		semconv.DeploymentEnvironment(cfg.Environment),
+	}
+	for k, v := range cfg.ResourceAttributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	// Schemaless so the merge doesn't conflict with the SDK's semconv version
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attrs...))
+	if err != nil {
+		return resource.NewSchemaless(attrs...)
+	}
+	return res
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// otlpCollector records the OTLP/HTTP export requests it receives
+type otlpCollector struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []string
+}
+
+func newOTLPCollector(t *testing.T) *otlpCollector {
+	c := &otlpCollector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		c.mu.Lock()
+		c.requests = append(c.requests, r)
+		c.bodies = append(c.bodies, string(body))
+		c.mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *otlpCollector) received() ([]*http.Request, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requests, c.bodies
+}
+
+func (c *otlpCollector) config() OTLPConfig {
+	cfg := DefaultOTLPConfig()
+	cfg.Enabled = true
+	cfg.Protocol = ProtocolHTTP
+	cfg.Endpoint = strings.TrimPrefix(c.URL, "http://")
+	cfg.Headers = map[string]string{"Authorization": "Bearer collector-token"}
+	cfg.ServiceName = "invoicing"
+	return cfg
+}
+
+func TestInitOTLPDisabled(t *testing.T) {
+	shutdown, err := InitOTLP(context.Background(), OTLPConfig{})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestInitOTLPUnsupportedProtocol(t *testing.T) {
+	_, err := InitOTLP(context.Background(), OTLPConfig{Enabled: true, Protocol: "thrift"})
+	assert.ErrorContains(t, err, "unsupported OTLP protocol: thrift")
+}
+
+func TestOTLPExportsGathererOnShutdown(t *testing.T) {
+	collector := newOTLPCollector(t)
+	r := NewRegistry(RegistryConfig{})
+	r.Counter("orders_created_total", "Orders created").WithLabelValues().Add(3)
+
+	cfg := collector.config()
+	cfg.Gatherer = r.Gatherer()
+	cfg.ResourceAttributes = map[string]string{"region": "eu-west-1"}
+	provider, err := newMeterProvider(context.Background(), cfg)
+	require.NoError(t, err)
+	require.NoError(t, provider.Shutdown(context.Background()))
+
+	requests, bodies := collector.received()
+	require.Len(t, requests, 1, "shutdown flushes one export")
+	assert.Equal(t, "/v1/metrics", requests[0].URL.Path)
+	assert.Equal(t, "Bearer collector-token", requests[0].Header.Get("Authorization"))
+	assert.Contains(t, bodies[0], "orders_created_total")
+	assert.Contains(t, bodies[0], "invoicing", "service.name resource attribute")
+	assert.Contains(t, bodies[0], "eu-west-1")
+}
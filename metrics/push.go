@@ -0,0 +1,219 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// ============================================
+// Push (batch jobs)
+// ============================================
+
+// PushConfig configures pushing the metrics of a short-lived job, which
+// exits before Prometheus could scrape it
+type PushConfig struct {
+	// URL of the Pushgateway, e.g. http://pushgateway:9091; empty skips it
+	URL string
+	// Job is the job grouping label
+	Job string
+	// Instance is the instance grouping label (default: hostname)
+	Instance string
+	// Grouping adds grouping labels, e.g. {"tenant": "acme"}
+	Grouping map[string]string
+	// Username and Password enable basic auth towards the Pushgateway
+	Username string
+	Password string
+	// Timeout bounds one push (default: 10s)
+	Timeout time.Duration
+	// OTLP also exports the metrics once to an OTel collector
+	OTLP *OTLPConfig
+	// Gatherer is the registry to push (default: the global registry)
+	Gatherer prometheus.Gatherer
+	// OnError reports failed pushes from PushOnExit (default: log.Printf)
+	OnError func(err error)
+}
+
+// Pusher pushes the collected metrics of a job
+type Pusher struct {
+	cfg PushConfig
+}
+
+// NewPusher creates a pusher
+func NewPusher(cfg PushConfig) *Pusher {
+	if cfg.Instance == "" {
+		cfg.Instance, _ = os.Hostname()
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Gatherer == nil {
+		cfg.Gatherer = prometheus.DefaultGatherer
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(err error) {
+			log.Printf("metrics: push failed: %v", err)
+		}
+	}
+	return &Pusher{cfg: cfg}
+}
+
+var defaultPusher atomic.Pointer[Pusher]
+
+// InitPush creates a pusher and makes it the one PushOnExit uses
+//
+//	metrics.InitPush(metrics.PushConfig{URL: cfg.PushgatewayURL, Job: "invoice-export"})
+//	defer metrics.PushOnExit()
+func InitPush(cfg PushConfig) *Pusher {
+	p := NewPusher(cfg)
+	defaultPusher.Store(p)
+	return p
+}
+
+// PushOnExit pushes with the pusher of InitPush, for use with defer at the
+// top of main. Failures go to PushConfig.OnError
+func PushOnExit() {
+	p := defaultPusher.Load()
+	if p == nil {
+		return
+	}
+	if err := p.Push(context.Background()); err != nil {
+		p.cfg.OnError(err)
+	}
+}
+
+// Push replaces the job's metrics on the Pushgateway and exports them over
+// OTLP when configured
+func (p *Pusher) Push(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	var errs []error
+	if p.cfg.URL != "" {
+		if err := p.gateway().PushContext(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("pushgateway: %w", err))
+		}
+	}
+	if p.cfg.OTLP != nil && p.cfg.OTLP.Enabled {
+		if err := p.pushOTLP(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("otlp: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Delete removes the job's metrics from the Pushgateway
+func (p *Pusher) Delete() error {
+	if p.cfg.URL == "" {
+		return nil
+	}
+	return p.gateway().Delete()
+}
+
+func (p *Pusher) gateway() *push.Pusher {
+	pusher := push.New(p.cfg.URL, p.cfg.Job).
+		Gatherer(p.cfg.Gatherer).
+		Client(&http.Client{Timeout: p.cfg.Timeout})
+	if p.cfg.Instance != "" {
+		pusher = pusher.Grouping("instance", p.cfg.Instance)
+	}
+	for name, value := range p.cfg.Grouping {
+		pusher = pusher.Grouping(name, value)
+	}
+	if p.cfg.Username != "" {
+		pusher = pusher.BasicAuth(p.cfg.Username, p.cfg.Password)
+	}
+	return pusher
+}
+
+// pushOTLP exports once through a temporary meter provider; shutting it
+// down flushes the reader
+func (p *Pusher) pushOTLP(ctx context.Context) error {
+	cfg := *p.cfg.OTLP
+	cfg.Gatherer = p.cfg.Gatherer
+	attrs := map[string]string{"job": p.cfg.Job, "instance": p.cfg.Instance}
+	for k, v := range cfg.ResourceAttributes {
+		attrs[k] = v
+	}
+	for k, v := range p.cfg.Grouping {
+		attrs[k] = v
+	}
+	cfg.ResourceAttributes = attrs
+
+	provider, err := newMeterProvider(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	return provider.Shutdown(ctx)
+}
+
+// ============================================
+// Job Metrics
+// ============================================
+
+// JobMetrics describe the runs of batch jobs by job_name; "job" is left
+// to the Pushgateway grouping key
+type JobMetrics struct {
+	Runs          *prometheus.CounterVec
+	Duration      *prometheus.GaugeVec
+	LastRun       *prometheus.GaugeVec
+	LastSuccess   *prometheus.GaugeVec
+	LastSucceeded *prometheus.GaugeVec
+}
+
+// NewJobMetrics registers the job collectors on r
+func NewJobMetrics(r *Registry) *JobMetrics {
+	return &JobMetrics{
+		Runs: r.Counter("job_runs_total",
+			"Total number of job runs", "job_name", "status"),
+		Duration: r.Gauge("job_duration_seconds",
+			"Duration of the last job run in seconds", "job_name"),
+		LastRun: r.Gauge("job_last_run_timestamp_seconds",
+			"Unix time the last job run finished", "job_name"),
+		LastSuccess: r.Gauge("job_last_success_timestamp_seconds",
+			"Unix time the last successful job run finished", "job_name"),
+		LastSucceeded: r.Gauge("job_last_run_success",
+			"Whether the last job run succeeded (1) or failed (0)", "job_name"),
+	}
+}
+
+// Job times one run of a batch job
+type Job struct {
+	name    string
+	start   time.Time
+	metrics *JobMetrics
+}
+
+// StartJob starts timing a run of the named job
+//
+//	job := metrics.StartJob(reg, "invoice-export")
+//	err := export(ctx)
+//	job.Finish(err)
+func StartJob(r *Registry, name string) *Job {
+	return &Job{name: name, start: time.Now(), metrics: NewJobMetrics(r)}
+}
+
+// Finish records the outcome of the run; a nil err is a success
+func (j *Job) Finish(err error) {
+	now := time.Now()
+	status := "success"
+	succeeded := 1.0
+	if err != nil {
+		status = "error"
+		succeeded = 0
+	} else {
+		j.metrics.LastSuccess.WithLabelValues(j.name).Set(float64(now.Unix()))
+	}
+	j.metrics.Runs.WithLabelValues(j.name, status).Inc()
+	j.metrics.Duration.WithLabelValues(j.name).Set(now.Sub(j.start).Seconds())
+	j.metrics.LastRun.WithLabelValues(j.name).Set(float64(now.Unix()))
+	j.metrics.LastSucceeded.WithLabelValues(j.name).Set(succeeded)
+}
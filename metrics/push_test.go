@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pushedRequest struct {
+	method string
+	path   string
+	user   string
+	pass   string
+	body   string
+}
+
+// pushgateway records the pushes it receives and answers with status
+type pushgateway struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   int
+	requests []pushedRequest
+}
+
+func newPushgateway(t *testing.T) *pushgateway {
+	g := &pushgateway{status: http.StatusOK}
+	g.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		user, pass, _ := r.BasicAuth()
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.requests = append(g.requests, pushedRequest{r.Method, r.URL.Path, user, pass, string(body)})
+		if r.Method == http.MethodDelete && g.status == http.StatusOK {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.WriteHeader(g.status)
+	}))
+	t.Cleanup(g.Close)
+	return g
+}
+
+func (g *pushgateway) received() []pushedRequest {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]pushedRequest(nil), g.requests...)
+}
+
+func TestPusherPush(t *testing.T) {
+	gateway := newPushgateway(t)
+	r := NewRegistry(RegistryConfig{})
+	job := StartJob(r, "invoice-export")
+	job.Finish(nil)
+
+	p := NewPusher(PushConfig{
+		URL:      gateway.URL,
+		Job:      "invoice-export",
+		Instance: "worker-1",
+		Grouping: map[string]string{"tenant": "acme"},
+		Username: "push",
+		Password: "secret",
+		Gatherer: r.Gatherer(),
+	})
+	require.NoError(t, p.Push(context.Background()))
+
+	requests := gateway.received()
+	require.Len(t, requests, 1)
+	assert.Equal(t, http.MethodPut, requests[0].method, "a push replaces the job's metrics")
+	assert.True(t, strings.HasPrefix(requests[0].path, "/metrics/job/invoice-export/"), requests[0].path)
+	assert.Contains(t, requests[0].path, "/instance/worker-1")
+	assert.Contains(t, requests[0].path, "/tenant/acme")
+	assert.Equal(t, "push", requests[0].user)
+	assert.Equal(t, "secret", requests[0].pass)
+	assert.Contains(t, requests[0].body, "job_runs_total")
+
+	require.NoError(t, p.Delete())
+	requests = gateway.received()
+	require.Len(t, requests, 2)
+	assert.Equal(t, http.MethodDelete, requests[1].method)
+	assert.Contains(t, requests[1].path, "/instance/worker-1")
+}
+
+func TestPusherPushErrors(t *testing.T) {
+	gateway := newPushgateway(t)
+	gateway.status = http.StatusInternalServerError
+	collector := newOTLPCollector(t)
+	otlp := collector.config()
+	otlp.Protocol = "thrift"
+
+	p := NewPusher(PushConfig{URL: gateway.URL, Job: "cleanup", Gatherer: NewRegistry(RegistryConfig{}).Gatherer(), OTLP: &otlp})
+	err := p.Push(context.Background())
+	assert.ErrorContains(t, err, "pushgateway:")
+	assert.ErrorContains(t, err, "otlp: failed to create metric exporter")
+}
+
+func TestPusherPushOTLP(t *testing.T) {
+	collector := newOTLPCollector(t)
+	r := NewRegistry(RegistryConfig{})
+	StartJob(r, "reindex").Finish(errors.New("index locked"))
+	otlp := collector.config()
+
+	p := NewPusher(PushConfig{Job: "reindex", Instance: "worker-2", Gatherer: r.Gatherer(), OTLP: &otlp})
+	require.NoError(t, p.Push(context.Background()), "no URL skips the Pushgateway")
+
+	_, bodies := collector.received()
+	require.Len(t, bodies, 1)
+	assert.Contains(t, bodies[0], "job_runs_total")
+	assert.Contains(t, bodies[0], "worker-2", "grouping labels become resource attributes")
+}
+
+func TestPushOnExit(t *testing.T) {
+	t.Cleanup(func() { defaultPusher.Store(nil) })
+	PushOnExit() // no pusher configured
+
+	gateway := newPushgateway(t)
+	r := NewRegistry(RegistryConfig{})
+	StartJob(r, "nightly").Finish(nil)
+	var errs []error
+	cfg := PushConfig{
+		URL:      gateway.URL,
+		Job:      "nightly",
+		Gatherer: r.Gatherer(),
+		OnError:  func(err error) { errs = append(errs, err) },
+	}
+
+	InitPush(cfg)
+	PushOnExit()
+	assert.Len(t, gateway.received(), 1)
+	assert.Empty(t, errs)
+
+	gateway.mu.Lock()
+	gateway.status = http.StatusBadGateway
+	gateway.mu.Unlock()
+	PushOnExit()
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "pushgateway:")
+}
+
+func TestJobFinish(t *testing.T) {
+	r := NewRegistry(RegistryConfig{})
+	m := NewJobMetrics(r)
+
+	StartJob(r, "sync").Finish(nil)
+	StartJob(r, "sync").Finish(errors.New("timeout"))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Runs.WithLabelValues("sync", "success")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Runs.WithLabelValues("sync", "error")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.LastSucceeded.WithLabelValues("sync")))
+	assert.Positive(t, testutil.ToFloat64(m.LastSuccess.WithLabelValues("sync")))
+}
@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ============================================
+// Registry
+// ============================================
+
+// RegistryConfig configures a Registry
+type RegistryConfig struct {
+	// Namespace prefixes every metric name, e.g. "orders" gives
+	// orders_http_requests_total
+	Namespace string
+	// ConstLabels are added to every metric, e.g. {"service": "orders"}
+	ConstLabels prometheus.Labels
+	// Registerer and Gatherer default to a new prometheus.Registry
+	Registerer prometheus.Registerer
+	Gatherer   prometheus.Gatherer
+}
+
+// Registry creates namespaced collectors and registers them once. Asking
+// for a collector that is already registered returns the existing one, so
+// packages can share metrics without coordinating registration
+type Registry struct {
+	namespace   string
+	constLabels prometheus.Labels
+	registerer  prometheus.Registerer
+	gatherer    prometheus.Gatherer
+
+	mu         sync.Mutex
+	collectors map[string]prometheus.Collector
+
+	runtimeOnce sync.Once
+}
+
+// NewRegistry creates a registry; with a zero config it owns a new, empty
+// prometheus.Registry
+func NewRegistry(cfg RegistryConfig) *Registry {
+	if cfg.Registerer == nil {
+		reg := prometheus.NewRegistry()
+		cfg.Registerer = reg
+		if cfg.Gatherer == nil {
+			cfg.Gatherer = reg
+		}
+	}
+	if cfg.Gatherer == nil {
+		if g, ok := cfg.Registerer.(prometheus.Gatherer); ok {
+			cfg.Gatherer = g
+		} else {
+			cfg.Gatherer = prometheus.DefaultGatherer
+		}
+	}
+	return &Registry{
+		namespace:   cfg.Namespace,
+		constLabels: cfg.ConstLabels,
+		registerer:  cfg.Registerer,
+		gatherer:    cfg.Gatherer,
+		collectors:  make(map[string]prometheus.Collector),
+	}
+}
+
+var (
+	defaultRegistry     *Registry
+	defaultRegistryOnce sync.Once
+)
+
+// DefaultRegistry wraps the global Prometheus registry without a namespace,
+// where InitMetrics registers the package level collectors
+func DefaultRegistry() *Registry {
+	defaultRegistryOnce.Do(func() {
+		defaultRegistry = NewRegistry(RegistryConfig{
+			Registerer: prometheus.DefaultRegisterer,
+			Gatherer:   prometheus.DefaultGatherer,
+		})
+	})
+	return defaultRegistry
+}
+
+// Registerer returns the underlying registerer
+func (r *Registry) Registerer() prometheus.Registerer {
+	return r.registerer
+}
+
+// Gatherer returns the underlying gatherer, for Handler
+func (r *Registry) Gatherer() prometheus.Gatherer {
+	return r.gatherer
+}
+
+// Namespace returns the metric name prefix
+func (r *Registry) Namespace() string {
+	return r.namespace
+}
+
+// MustRegister registers collectors built outside the registry, ignoring
+// ones that are already registered
+func (r *Registry) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		r.register(c)
+	}
+}
+
+// Counter returns the counter vector name with the given labels
+func (r *Registry) Counter(name, help string, labels ...string) *prometheus.CounterVec {
+	return r.getOrRegister(name, func() prometheus.Collector {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   r.namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: r.constLabels,
+		}, labels)
+	}).(*prometheus.CounterVec)
+}
+
+// Gauge returns the gauge vector name with the given labels
+func (r *Registry) Gauge(name, help string, labels ...string) *prometheus.GaugeVec {
+	return r.getOrRegister(name, func() prometheus.Collector {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   r.namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: r.constLabels,
+		}, labels)
+	}).(*prometheus.GaugeVec)
+}
+
+// Histogram returns the histogram vector name with the given labels;
+// nil buckets use prometheus.DefBuckets
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+	return r.getOrRegister(name, func() prometheus.Collector {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   r.namespace,
+			Name:        name,
+			Help:        help,
+			Buckets:     buckets,
+			ConstLabels: r.constLabels,
+		}, labels)
+	}).(*prometheus.HistogramVec)
+}
+
+func (r *Registry) getOrRegister(name string, create func() prometheus.Collector) prometheus.Collector {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.collectors[name]; ok {
+		return c
+	}
+	c := r.register(create())
+	r.collectors[name] = c
+	return c
+}
+
+// register registers c, returning the existing collector if an equal one
+// is already registered
+func (r *Registry) register(c prometheus.Collector) prometheus.Collector {
+	if err := r.registerer.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return c
+}
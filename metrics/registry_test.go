@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryNamespacesAndReusesCollectors(t *testing.T) {
+	r := NewRegistry(RegistryConfig{Namespace: "orders", ConstLabels: prometheus.Labels{"service": "orders"}})
+
+	jobs := r.Counter("jobs_total", "Jobs processed", "queue")
+	assert.Same(t, jobs, r.Counter("jobs_total", "Jobs processed", "queue"), "collectors are registered once")
+	jobs.WithLabelValues("email").Add(2)
+	r.Gauge("queue_depth", "Queued jobs", "queue").WithLabelValues("email").Set(5)
+
+	// Collectors built elsewhere that are already registered are ignored
+	r.MustRegister(jobs)
+
+	err := testutil.GatherAndCompare(r.Gatherer(), strings.NewReader(`
+# HELP orders_jobs_total Jobs processed
+# TYPE orders_jobs_total counter
+orders_jobs_total{queue="email",service="orders"} 2
+# HELP orders_queue_depth Queued jobs
+# TYPE orders_queue_depth gauge
+orders_queue_depth{queue="email",service="orders"} 5
+`), "orders_jobs_total", "orders_queue_depth")
+	assert.NoError(t, err)
+}
+
+func TestRegistrySharesExistingCollectors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	a := NewRegistry(RegistryConfig{Registerer: reg})
+	b := NewRegistry(RegistryConfig{Registerer: reg})
+
+	a.Counter("events_total", "Events", "type").WithLabelValues("x").Inc()
+	b.Counter("events_total", "Events", "type").WithLabelValues("x").Inc()
+	assert.Equal(t, 2.0, testutil.ToFloat64(a.Counter("events_total", "Events", "type").WithLabelValues("x")))
+	assert.Same(t, reg, b.Gatherer(), "the registerer doubles as gatherer")
+}
+
+func TestStatusClass(t *testing.T) {
+	for status, class := range map[int]string{200: "2xx", 204: "2xx", 301: "3xx", 404: "4xx", 503: "5xx", 0: "unknown", 999: "unknown"} {
+		assert.Equal(t, class, StatusClass(status), "status %d", status)
+	}
+}
+
+func TestStandardCollectors(t *testing.T) {
+	r := NewRegistry(RegistryConfig{})
+
+	server := NewHTTPServerMetrics(r)
+	server.Observe("GET", "/users/:id", 404, 120, 30*time.Millisecond)
+	server.Observe("GET", "/users/:id", 200, 80, 10*time.Millisecond)
+	assert.Equal(t, 1.0, testutil.ToFloat64(server.Requests.WithLabelValues("GET", "/users/:id", "4xx")))
+
+	db := NewDBMetrics(r)
+	db.Observe("select", "users", 3, time.Millisecond, nil)
+	db.Observe("update", "users", 0, time.Millisecond, errors.New("deadlock"))
+
+	err := testutil.GatherAndCompare(r.Gatherer(), strings.NewReader(`
+# HELP db_errors_total Total number of failed database queries
+# TYPE db_errors_total counter
+db_errors_total{operation="update",table="users"} 1
+# HELP db_queries_total Total number of database queries
+# TYPE db_queries_total counter
+db_queries_total{operation="select",status="success",table="users"} 1
+db_queries_total{operation="update",status="error",table="users"} 1
+# HELP db_rows_affected_total Total number of rows affected by database queries
+# TYPE db_rows_affected_total counter
+db_rows_affected_total{operation="select",table="users"} 3
+`), "db_errors_total", "db_queries_total", "db_rows_affected_total")
+	assert.NoError(t, err)
+
+	cache := NewCacheMetrics(r)
+	cache.Hit("sessions")
+	cache.Miss("sessions")
+	cache.Observe("sessions", "get", time.Millisecond, errors.New("timeout"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(cache.Errors.WithLabelValues("sessions", "get")))
+
+	external := NewExternalMetrics(r)
+	done := external.Start("payments", "charge")
+	assert.Equal(t, 1.0, testutil.ToFloat64(external.InFlight.WithLabelValues("payments")))
+	done("2xx")
+	assert.Equal(t, 0.0, testutil.ToFloat64(external.InFlight.WithLabelValues("payments")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(external.Requests.WithLabelValues("payments", "charge", "2xx")))
+
+	RegisterBuildInfo(r, BuildInfo{Version: "1.2.3", Commit: "abc", BuildDate: "2025-01-01"})
+	count, err := testutil.GatherAndCount(r.Gatherer(), "build_info")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestHandlerBasicAuth(t *testing.T) {
+	r := NewRegistry(RegistryConfig{})
+	r.Counter("logins_total", "Logins").WithLabelValues().Inc()
+	app := fiber.New()
+	Mount(app, r, WithBasicAuth("prometheus", "secret"))
+
+	get := func(user, pass string) (int, string, string) {
+		req := httptest.NewRequest("GET", DefaultPath, nil)
+		if user != "" {
+			req.SetBasicAuth(user, pass)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get("WWW-Authenticate"), string(body)
+	}
+
+	status, challenge, _ := get("", "")
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+	assert.Equal(t, `Basic realm="metrics"`, challenge)
+
+	status, _, _ = get("prometheus", "wrong")
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+
+	status, _, body := get("prometheus", "secret")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Contains(t, body, "logins_total 1")
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// ============================================
+// Runtime Metrics
+// ============================================
+
+// EnableRuntime registers Go runtime and process metrics on the default
+// registry
+func EnableRuntime() {
+	DefaultRegistry().EnableRuntime()
+}
+
+// EnableRuntime registers the Go runtime metrics (goroutines, GC, heap and
+// scheduler latencies from runtime/metrics) and the process metrics (CPU,
+// memory, open and max file descriptors). The plain Go and process
+// collectors of the global registry are replaced. Calling it again is a
+// no-op
+func (r *Registry) EnableRuntime() {
+	r.runtimeOnce.Do(func() {
+		r.registerer.Unregister(collectors.NewGoCollector())
+		r.registerer.Unregister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+		reg := r.registerer
+		if len(r.constLabels) > 0 {
+			reg = prometheus.WrapRegistererWith(r.constLabels, reg)
+		}
+		for _, c := range []prometheus.Collector{
+			collectors.NewGoCollector(collectors.WithGoCollectorRuntimeMetrics(
+				collectors.MetricsGC,
+				collectors.MetricsMemory,
+				collectors.MetricsScheduler,
+			)),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		} {
+			if err := reg.Register(c); err != nil {
+				var are prometheus.AlreadyRegisteredError
+				if !errors.As(err, &are) {
+					panic(err)
+				}
+			}
+		}
+	})
+}
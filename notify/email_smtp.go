@@ -0,0 +1,274 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig configures an SMTP email sender
+type SMTPConfig struct {
+	Host string
+	// Port of the server (default: 587, or 465 with ImplicitTLS)
+	Port     int
+	Username string
+	Password string
+	// From is the default sender, e.g. "Acme <no-reply@acme.com>"
+	From string
+	// ImplicitTLS connects over TLS, e.g. on port 465. Otherwise the
+	// connection is upgraded with STARTTLS when the server offers it;
+	// credentials are never sent unencrypted except to localhost
+	ImplicitTLS bool
+	// Timeout bounds a whole delivery (default: 30s)
+	Timeout time.Duration
+}
+
+// SMTPSender sends emails over SMTP
+type SMTPSender struct {
+	cfg  SMTPConfig
+	name string
+}
+
+// NewSMTPSender creates an SMTP email sender
+func NewSMTPSender(cfg SMTPConfig) *SMTPSender {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+		if cfg.ImplicitTLS {
+			cfg.Port = 465
+		}
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &SMTPSender{cfg: cfg, name: "smtp"}
+}
+
+// SESConfig configures an Amazon SES email sender
+type SESConfig struct {
+	// Region of the SES endpoint, e.g. eu-west-1
+	Region string
+	// Username and Password are SES SMTP credentials, not IAM access keys
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+// NewSESSender creates an email sender for the Amazon SES SMTP interface
+func NewSESSender(cfg SESConfig) *SMTPSender {
+	sender := NewSMTPSender(SMTPConfig{
+		Host:     "email-smtp." + cfg.Region + ".amazonaws.com",
+		Port:     587,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		From:     cfg.From,
+		Timeout:  cfg.Timeout,
+	})
+	sender.name = "ses"
+	return sender
+}
+
+// Name returns the provider name
+func (s *SMTPSender) Name() string {
+	return s.name
+}
+
+// Send implements EmailSender
+func (s *SMTPSender) Send(ctx context.Context, msg *Email) error {
+	from := msg.From
+	if from == "" {
+		from = s.cfg.From
+	}
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return Permanent(fmt.Errorf("notify: invalid from address %q: %w", from, err))
+	}
+
+	var recipients []string
+	for _, list := range [][]string{msg.To, msg.Cc, msg.Bcc} {
+		for _, rcpt := range list {
+			addr, err := mail.ParseAddress(rcpt)
+			if err != nil {
+				return Permanent(fmt.Errorf("notify: invalid recipient %q: %w", rcpt, err))
+			}
+			recipients = append(recipients, addr.Address)
+		}
+	}
+	if len(recipients) == 0 {
+		return ErrNoRecipient
+	}
+
+	body, err := buildMessage(fromAddr, msg)
+	if err != nil {
+		return Permanent(err)
+	}
+	return s.deliver(ctx, fromAddr.Address, recipients, body)
+}
+
+func (s *SMTPSender) deliver(ctx context.Context, from string, recipients []string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: s.cfg.Host}
+
+	var conn net.Conn
+	var err error
+	if s.cfg.ImplicitTLS {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("notify: smtp dial: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("notify: smtp: %w", err)
+	}
+	defer client.Close()
+
+	if !s.cfg.ImplicitTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("notify: smtp starttls: %w", err)
+			}
+		}
+	}
+	if s.cfg.Username != "" {
+		// PlainAuth refuses to send credentials without TLS, except to localhost
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return Permanent(fmt.Errorf("notify: smtp auth: %w", err))
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return smtpError(err)
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return smtpError(err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return smtpError(err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("notify: smtp: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpError(err)
+	}
+	return client.Quit()
+}
+
+// smtpError marks 5xx replies, e.g. unknown mailboxes, as permanent
+func smtpError(err error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return Permanent(fmt.Errorf("notify: smtp: %w", err))
+	}
+	return fmt.Errorf("notify: smtp: %w", err)
+}
+
+// buildMessage renders the headers and MIME body of msg
+func buildMessage(from *mail.Address, msg *Email) ([]byte, error) {
+	var buf bytes.Buffer
+	writeHeader := func(key, value string) {
+		buf.WriteString(key + ": " + value + "\r\n")
+	}
+
+	writeHeader("From", from.String())
+	// Addresses are written parsed, so they can't inject headers either
+	for _, header := range []struct {
+		key   string
+		addrs []string
+	}{{"To", msg.To}, {"Cc", msg.Cc}} {
+		if len(header.addrs) == 0 {
+			continue
+		}
+		formatted := make([]string, 0, len(header.addrs))
+		for _, addr := range header.addrs {
+			parsed, err := mail.ParseAddress(addr)
+			if err != nil {
+				return nil, fmt.Errorf("notify: invalid recipient %q: %w", addr, err)
+			}
+			formatted = append(formatted, parsed.String())
+		}
+		writeHeader(header.key, strings.Join(formatted, ", "))
+	}
+	if msg.ReplyTo != "" {
+		replyTo, err := mail.ParseAddress(msg.ReplyTo)
+		if err != nil {
+			return nil, fmt.Errorf("notify: invalid reply-to address %q: %w", msg.ReplyTo, err)
+		}
+		writeHeader("Reply-To", replyTo.String())
+	}
+	// Fields drops CR and LF, so subjects can't inject headers
+	subject := strings.Join(strings.Fields(msg.Subject), " ")
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", subject))
+	writeHeader("Date", time.Now().Format(time.RFC1123Z))
+	writeHeader("Message-ID", messageID(from.Address))
+	writeHeader("MIME-Version", "1.0")
+
+	switch {
+	case msg.HTML != "" && msg.Text != "":
+		boundary := randomHex(16)
+		writeHeader("Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+		buf.WriteString("\r\n")
+		for _, part := range []struct{ contentType, body string }{
+			{"text/plain", msg.Text},
+			{"text/html", msg.HTML},
+		} {
+			buf.WriteString("--" + boundary + "\r\n")
+			writePart(&buf, part.contentType, part.body)
+		}
+		buf.WriteString("--" + boundary + "--\r\n")
+	case msg.HTML != "":
+		writePart(&buf, "text/html", msg.HTML)
+	default:
+		writePart(&buf, "text/plain", msg.Text)
+	}
+	return buf.Bytes(), nil
+}
+
+func writePart(buf *bytes.Buffer, contentType, body string) {
+	buf.WriteString("Content-Type: " + contentType + "; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	w := quotedprintable.NewWriter(buf)
+	w.Write([]byte(body))
+	w.Close()
+	buf.WriteString("\r\n")
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if _, d, ok := strings.Cut(from, "@"); ok {
+		domain = d
+	}
+	return "<" + randomHex(16) + "@" + domain + ">"
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
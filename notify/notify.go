@@ -0,0 +1,303 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	appctx "github.com/minisource/go-common/context"
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/metrics"
+)
+
+// Channel identifies a notification channel
+type Channel string
+
+const (
+	ChannelEmail Channel = "email"
+	ChannelSMS   Channel = "sms"
+	ChannelPush  Channel = "push"
+)
+
+var (
+	// ErrNoProvider is returned when no sender is configured for a channel
+	ErrNoProvider = errors.New("notify: no provider configured")
+	// ErrNoRecipient is returned for messages without a recipient
+	ErrNoRecipient = errors.New("notify: no recipient")
+)
+
+// ============================================
+// Messages
+// ============================================
+
+// Email is an email message. With Template set, Subject, Text and HTML are
+// rendered from the template
+type Email struct {
+	To  []string
+	Cc  []string
+	Bcc []string
+	// From overrides the sender's default address
+	From    string
+	ReplyTo string
+	Subject string
+	Text    string
+	HTML    string
+
+	Template string
+	// Params are the template data
+	Params map[string]interface{}
+	// Lang selects the template translation (default: language of ctx)
+	Lang string
+}
+
+// SMS is a text message. With Template set, Text is rendered from the
+// template's Text
+type SMS struct {
+	// To is the phone number in E.164 format, e.g. +989121234567
+	To string
+	// From overrides the sender's default number or sender ID
+	From string
+	Text string
+
+	Template string
+	Params   map[string]interface{}
+	Lang     string
+}
+
+// Push is a push notification for a device token or a topic. With Template
+// set, Title and Body are rendered from the template's Subject and Text
+type Push struct {
+	Token string
+	Topic string
+	Title string
+	Body  string
+	// Data is delivered to the app with the notification
+	Data map[string]string
+
+	Template string
+	Params   map[string]interface{}
+	Lang     string
+}
+
+// ============================================
+// Senders
+// ============================================
+
+// Sender delivers messages of one channel. Errors wrapped with Permanent,
+// e.g. invalid recipients, are not retried
+type Sender[M any] interface {
+	Send(ctx context.Context, msg M) error
+}
+
+// SenderFunc adapts a function to Sender
+type SenderFunc[M any] func(ctx context.Context, msg M) error
+
+// Send implements Sender
+func (f SenderFunc[M]) Send(ctx context.Context, msg M) error {
+	return f(ctx, msg)
+}
+
+type (
+	// EmailSender delivers emails, e.g. SMTPSender
+	EmailSender = Sender[*Email]
+	// SMSSender delivers text messages, e.g. TwilioSender or KavenegarSender
+	SMSSender = Sender[*SMS]
+	// PushSender delivers push notifications, e.g. FCMSender
+	PushSender = Sender[*Push]
+)
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a send error as not retryable
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// ============================================
+// Tenants
+// ============================================
+
+// Providers are the senders of each channel; nil channels fall back to the
+// notifier defaults
+type Providers struct {
+	Email EmailSender
+	SMS   SMSSender
+	Push  PushSender
+}
+
+// TenantProviders resolves the providers of a tenant, e.g. built with
+// NewProviders from settings stored per tenant. Nil means the defaults
+type TenantProviders interface {
+	Providers(ctx context.Context, tenantID string) (*Providers, error)
+}
+
+// StaticTenantProviders maps tenant IDs to their providers
+type StaticTenantProviders map[string]*Providers
+
+// Providers implements TenantProviders
+func (p StaticTenantProviders) Providers(ctx context.Context, tenantID string) (*Providers, error) {
+	return p[tenantID], nil
+}
+
+// ============================================
+// Notifier
+// ============================================
+
+// Config configures a Notifier
+type Config struct {
+	// Providers are the default senders
+	Providers
+	// Tenants overrides providers for the tenant of the context
+	Tenants TenantProviders
+	// Templates renders messages with a Template (default: none)
+	Templates *Templates
+	Logger    logging.Logger
+}
+
+// Notifier renders messages and sends them through the providers of the
+// tenant in context, falling back to the defaults
+type Notifier struct {
+	cfg Config
+}
+
+// New creates a notifier
+func New(cfg Config) *Notifier {
+	return &Notifier{cfg: cfg}
+}
+
+// SendEmail renders and sends an email
+func (n *Notifier) SendEmail(ctx context.Context, msg *Email) error {
+	if len(msg.To)+len(msg.Cc)+len(msg.Bcc) == 0 {
+		return ErrNoRecipient
+	}
+	if msg.Template != "" {
+		rendered, err := n.render(ctx, msg.Template, msg.Lang, msg.Params)
+		if err != nil {
+			return err
+		}
+		msg.Subject, msg.Text, msg.HTML = rendered.Subject, rendered.Text, rendered.HTML
+	}
+
+	providers, err := n.providers(ctx)
+	if err != nil {
+		return err
+	}
+	sender := n.cfg.Email
+	if providers != nil && providers.Email != nil {
+		sender = providers.Email
+	}
+	if sender == nil {
+		return n.record(ChannelEmail, ErrNoProvider)
+	}
+	return n.record(ChannelEmail, sender.Send(ctx, msg))
+}
+
+// SendSMS renders and sends a text message
+func (n *Notifier) SendSMS(ctx context.Context, msg *SMS) error {
+	if msg.To == "" {
+		return ErrNoRecipient
+	}
+	if msg.Template != "" {
+		rendered, err := n.render(ctx, msg.Template, msg.Lang, msg.Params)
+		if err != nil {
+			return err
+		}
+		msg.Text = rendered.Text
+	}
+
+	providers, err := n.providers(ctx)
+	if err != nil {
+		return err
+	}
+	sender := n.cfg.SMS
+	if providers != nil && providers.SMS != nil {
+		sender = providers.SMS
+	}
+	if sender == nil {
+		return n.record(ChannelSMS, ErrNoProvider)
+	}
+	return n.record(ChannelSMS, sender.Send(ctx, msg))
+}
+
+// SendPush renders and sends a push notification
+func (n *Notifier) SendPush(ctx context.Context, msg *Push) error {
+	if msg.Token == "" && msg.Topic == "" {
+		return ErrNoRecipient
+	}
+	if msg.Template != "" {
+		rendered, err := n.render(ctx, msg.Template, msg.Lang, msg.Params)
+		if err != nil {
+			return err
+		}
+		msg.Title, msg.Body = rendered.Subject, rendered.Text
+	}
+
+	providers, err := n.providers(ctx)
+	if err != nil {
+		return err
+	}
+	sender := n.cfg.Push
+	if providers != nil && providers.Push != nil {
+		sender = providers.Push
+	}
+	if sender == nil {
+		return n.record(ChannelPush, ErrNoProvider)
+	}
+	return n.record(ChannelPush, sender.Send(ctx, msg))
+}
+
+func (n *Notifier) render(ctx context.Context, name, lang string, params map[string]interface{}) (*Rendered, error) {
+	if n.cfg.Templates == nil {
+		return nil, ErrTemplateNotFound
+	}
+	if lang == "" {
+		lang = appctx.GetLanguage(ctx)
+	}
+	return n.cfg.Templates.Render(name, lang, params)
+}
+
+func (n *Notifier) providers(ctx context.Context) (*Providers, error) {
+	if n.cfg.Tenants == nil {
+		return nil, nil
+	}
+	tenantID, ok := appctx.GetTenantID(ctx)
+	if !ok {
+		return nil, nil
+	}
+	return n.cfg.Tenants.Providers(ctx, tenantID.String())
+}
+
+// record logs and counts the result of a send
+func (n *Notifier) record(channel Channel, err error) error {
+	result := "success"
+	if err != nil {
+		result = "error"
+		if n.cfg.Logger != nil {
+			n.cfg.Logger.Error(logging.General, logging.Notification, "Failed to send notification", map[logging.ExtraKey]interface{}{
+				"channel":            string(channel),
+				logging.ErrorMessage: err.Error(),
+			})
+		}
+	}
+	metrics.NotificationsSentTotal.WithLabelValues(string(channel), result).Inc()
+	return err
+}
+
+// normalizeLang lower-cases lang, e.g. "fa-IR" to "fa-ir"
+func normalizeLang(lang string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+}
@@ -0,0 +1,271 @@
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	appctx "github.com/minisource/go-common/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder[M any] struct {
+	sent []M
+	errs []error
+}
+
+func (r *recorder[M]) Send(ctx context.Context, msg M) error {
+	r.sent = append(r.sent, msg)
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		return err
+	}
+	return nil
+}
+
+func TestTemplates(t *testing.T) {
+	templates := NewTemplates("en")
+	templates.MustRegister("welcome", "en", Template{
+		Subject: "Welcome {{.Name}}",
+		Text:    "Hi {{.Name}}, {{t \"notifications.notification_sent\"}}",
+		HTML:    "<p>Hi {{.Name}}</p>",
+	})
+	templates.MustRegister("welcome", "fa", Template{
+		Subject: "خوش آمدید {{.Name}}",
+		Text:    "{{t \"notifications.notification_sent\"}}",
+	})
+
+	rendered, err := templates.Render("welcome", "en", map[string]interface{}{"Name": "<Ada>"})
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome <Ada>", rendered.Subject)
+	assert.Equal(t, "Hi <Ada>, Notification sent successfully", rendered.Text)
+	assert.Equal(t, "<p>Hi &lt;Ada&gt;</p>", rendered.HTML)
+
+	rendered, err = templates.Render("welcome", "fa-IR", map[string]interface{}{"Name": "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "خوش آمدید Ada", rendered.Subject)
+	assert.Equal(t, "اعلان با موفقیت ارسال شد", rendered.Text)
+
+	rendered, err = templates.Render("welcome", "de", map[string]interface{}{"Name": "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome Ada", rendered.Subject)
+
+	_, err = templates.Render("missing", "en", nil)
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+	assert.Error(t, templates.Register("broken", "en", Template{Subject: "{{.Name"}))
+}
+
+func TestNotifierTenantProviders(t *testing.T) {
+	defaults := &recorder[*SMS]{}
+	tenantSMS := &recorder[*SMS]{}
+	tenantID := uuid.New()
+
+	templates := NewTemplates("en")
+	templates.MustRegister("otp", "en", Template{Text: "Your code is {{.Code}}"})
+	templates.MustRegister("otp", "fa", Template{Text: "کد شما {{.Code}}"})
+
+	notifier := New(Config{
+		Providers: Providers{SMS: defaults},
+		Tenants:   StaticTenantProviders{tenantID.String(): {SMS: tenantSMS}},
+		Templates: templates,
+	})
+
+	ctx := context.Background()
+	require.NoError(t, notifier.SendSMS(ctx, &SMS{To: "+15550001", Template: "otp", Params: map[string]interface{}{"Code": 1234}}))
+	require.Len(t, defaults.sent, 1)
+	assert.Equal(t, "Your code is 1234", defaults.sent[0].Text)
+
+	ctx = appctx.WithLanguage(appctx.WithTenantID(ctx, tenantID), "fa")
+	require.NoError(t, notifier.SendSMS(ctx, &SMS{To: "+989120000000", Template: "otp", Params: map[string]interface{}{"Code": 42}}))
+	require.Len(t, tenantSMS.sent, 1)
+	assert.Equal(t, "کد شما 42", tenantSMS.sent[0].Text)
+
+	// The tenant has no email provider and there is no default
+	assert.ErrorIs(t, notifier.SendEmail(ctx, &Email{To: []string{"a@example.com"}}), ErrNoProvider)
+	assert.ErrorIs(t, notifier.SendSMS(ctx, &SMS{}), ErrNoRecipient)
+}
+
+func TestRetryAndFallback(t *testing.T) {
+	cfg := RetryConfig{MaxRetries: 2, InitialDelay: time.Millisecond}
+
+	flaky := &recorder[*SMS]{errs: []error{errors.New("timeout"), errors.New("timeout")}}
+	require.NoError(t, Retry[*SMS](flaky, cfg).Send(context.Background(), &SMS{To: "1"}))
+	assert.Len(t, flaky.sent, 3)
+
+	invalid := &recorder[*SMS]{errs: []error{Permanent(errors.New("invalid number"))}}
+	assert.True(t, IsPermanent(Retry[*SMS](invalid, cfg).Send(context.Background(), &SMS{To: "1"})))
+	assert.Len(t, invalid.sent, 1)
+
+	down := &recorder[*SMS]{errs: []error{errors.New("down"), errors.New("down"), errors.New("down")}}
+	backup := &recorder[*SMS]{}
+	require.NoError(t, Fallback(Retry[*SMS](down, cfg), backup).Send(context.Background(), &SMS{To: "1"}))
+	assert.Len(t, down.sent, 3)
+	assert.Len(t, backup.sent, 1)
+
+	first := &recorder[*SMS]{errs: []error{Permanent(errors.New("rejected"))}}
+	second := &recorder[*SMS]{errs: []error{Permanent(errors.New("rejected"))}}
+	err := Fallback[*SMS](first, second).Send(context.Background(), &SMS{To: "1"})
+	assert.True(t, IsPermanent(err))
+	assert.Contains(t, err.Error(), "sender 1: rejected")
+}
+
+func TestBuildMessage(t *testing.T) {
+	from := &mail.Address{Name: "Acme", Address: "no-reply@acme.com"}
+	body, err := buildMessage(from, &Email{
+		To:      []string{"Ada <ada@example.com>"},
+		Subject: "سلام\r\nBcc: victim@example.com",
+		Text:    "plain",
+		HTML:    "<b>rich</b>",
+	})
+	require.NoError(t, err)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(body)))
+	require.NoError(t, err)
+	assert.Equal(t, `"Ada" <ada@example.com>`, parsed.Header.Get("To"))
+	assert.Empty(t, parsed.Header.Get("Bcc"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "سلام Bcc: victim@example.com", subject)
+	assert.Contains(t, parsed.Header.Get("Content-Type"), "multipart/alternative")
+	assert.Contains(t, string(body), "<b>rich</b>")
+
+	_, err = buildMessage(from, &Email{To: []string{"ada@example.com\r\nBcc: x@example.com"}})
+	assert.Error(t, err)
+}
+
+func TestTwilioSender(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2010-04-01/Accounts/AC1/Messages.json", r.URL.Path)
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "AC1:secret", user+":"+pass)
+		require.NoError(t, r.ParseForm())
+		if r.Form.Get("To") == "+1invalid" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"code":21211,"message":"Invalid 'To' Phone Number"}`)
+			return
+		}
+		assert.Equal(t, "+15550000", r.Form.Get("From"))
+		assert.Equal(t, "hello", r.Form.Get("Body"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	sender := NewTwilioSender(TwilioConfig{AccountSID: "AC1", AuthToken: "secret", From: "+15550000", BaseURL: server.URL})
+	require.NoError(t, sender.Send(context.Background(), &SMS{To: "+15551111", Text: "hello"}))
+	assert.True(t, IsPermanent(sender.Send(context.Background(), &SMS{To: "+1invalid", Text: "hello"})))
+}
+
+func TestKavenegarSender(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/key/sms/send.json", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		switch r.Form.Get("receptor") {
+		case "09120000000":
+			assert.Equal(t, "10004346", r.Form.Get("sender"))
+			_, _ = io.WriteString(w, `{"return":{"status":200,"message":"تایید شد"},"entries":[]}`)
+		case "bad":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"return":{"status":411,"message":"receptor invalid"},"entries":null}`)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	sender := NewKavenegarSender(KavenegarConfig{APIKey: "key", Sender: "10004346", BaseURL: server.URL})
+	require.NoError(t, sender.Send(context.Background(), &SMS{To: "09120000000", Text: "سلام"}))
+
+	err := sender.Send(context.Background(), &SMS{To: "bad", Text: "x"})
+	assert.True(t, IsPermanent(err))
+	assert.Contains(t, err.Error(), "411")
+
+	err = sender.Send(context.Background(), &SMS{To: "down", Text: "x"})
+	assert.Error(t, err)
+	assert.False(t, IsPermanent(err))
+}
+
+func TestFCMSender(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	tokenRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokenRequests++
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+			_, _ = io.WriteString(w, `{"access_token":"at","expires_in":3600}`)
+		case "/v1/projects/demo/messages:send":
+			assert.Equal(t, "Bearer at", r.Header.Get("Authorization"))
+			var body struct {
+				Message fcmMessage `json:"message"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			if body.Message.Token == "stale" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = io.WriteString(w, `{"error":{"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`)
+				return
+			}
+			assert.Equal(t, "Hello", body.Message.Notification.Title)
+			assert.Equal(t, "42", body.Message.Data["orderId"])
+			_, _ = io.WriteString(w, `{"name":"projects/demo/messages/1"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	credentials, _ := json.Marshal(map[string]string{
+		"project_id":   "demo",
+		"client_email": "push@demo.iam.gserviceaccount.com",
+		"private_key":  string(keyPEM),
+		"token_uri":    server.URL + "/token",
+	})
+	sender, err := NewFCMSender(FCMConfig{CredentialsJSON: credentials, BaseURL: server.URL})
+	require.NoError(t, err)
+
+	push := &Push{Token: "device", Title: "Hello", Data: map[string]string{"orderId": "42"}}
+	require.NoError(t, sender.Send(context.Background(), push))
+	require.NoError(t, sender.Send(context.Background(), push))
+	assert.Equal(t, 1, tokenRequests)
+
+	err = sender.Send(context.Background(), &Push{Token: "stale", Title: "Hello"})
+	assert.ErrorIs(t, err, ErrUnregistered)
+	assert.True(t, IsPermanent(err))
+
+	_, err = NewFCMSender(FCMConfig{CredentialsJSON: []byte(`{}`)})
+	assert.Error(t, err)
+}
+
+func TestNewProviders(t *testing.T) {
+	providers, err := NewProviders(ProvidersConfig{
+		SMS: []SMSProviderConfig{
+			{Kavenegar: &KavenegarConfig{APIKey: "key"}},
+			{Twilio: &TwilioConfig{AccountSID: "AC1"}},
+		},
+		Retry: &RetryConfig{MaxRetries: 1},
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, providers.SMS)
+	assert.Nil(t, providers.Email)
+
+	_, err = NewProviders(ProvidersConfig{Email: []EmailProviderConfig{{}}})
+	assert.Error(t, err)
+}
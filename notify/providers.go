@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+)
+
+// EmailProviderConfig configures one email provider; set one field
+type EmailProviderConfig struct {
+	SMTP *SMTPConfig
+	SES  *SESConfig
+}
+
+// SMSProviderConfig configures one SMS provider; set one field
+type SMSProviderConfig struct {
+	Kavenegar *KavenegarConfig
+	Twilio    *TwilioConfig
+}
+
+// PushProviderConfig configures one push provider
+type PushProviderConfig struct {
+	FCM *FCMConfig
+}
+
+// ProvidersConfig lists the providers of each channel in fallback order,
+// e.g. decoded from settings stored per tenant
+type ProvidersConfig struct {
+	Email []EmailProviderConfig
+	SMS   []SMSProviderConfig
+	Push  []PushProviderConfig
+	// Retry, if set, retries each provider before falling back to the next
+	Retry *RetryConfig
+}
+
+// NewProviders builds the senders of cfg. Channels with several providers
+// fall back from one to the next
+func NewProviders(cfg ProvidersConfig) (*Providers, error) {
+	var providers Providers
+
+	emails := make([]EmailSender, 0, len(cfg.Email))
+	for _, c := range cfg.Email {
+		switch {
+		case c.SMTP != nil:
+			emails = append(emails, NewSMTPSender(*c.SMTP))
+		case c.SES != nil:
+			emails = append(emails, NewSESSender(*c.SES))
+		default:
+			return nil, errors.New("notify: email provider without configuration")
+		}
+	}
+	providers.Email = chain(emails, cfg.Retry)
+
+	sms := make([]SMSSender, 0, len(cfg.SMS))
+	for _, c := range cfg.SMS {
+		switch {
+		case c.Kavenegar != nil:
+			sms = append(sms, NewKavenegarSender(*c.Kavenegar))
+		case c.Twilio != nil:
+			sms = append(sms, NewTwilioSender(*c.Twilio))
+		default:
+			return nil, errors.New("notify: SMS provider without configuration")
+		}
+	}
+	providers.SMS = chain(sms, cfg.Retry)
+
+	pushes := make([]PushSender, 0, len(cfg.Push))
+	for _, c := range cfg.Push {
+		if c.FCM == nil {
+			return nil, errors.New("notify: push provider without configuration")
+		}
+		sender, err := NewFCMSender(*c.FCM)
+		if err != nil {
+			return nil, fmt.Errorf("notify: push provider: %w", err)
+		}
+		pushes = append(pushes, sender)
+	}
+	providers.Push = chain(pushes, cfg.Retry)
+
+	return &providers, nil
+}
+
+// chain wraps senders with retries and fallback; nil without senders
+func chain[M any](senders []Sender[M], retry *RetryConfig) Sender[M] {
+	if retry != nil {
+		for i, sender := range senders {
+			senders[i] = Retry(sender, *retry)
+		}
+	}
+	switch len(senders) {
+	case 0:
+		return nil
+	case 1:
+		return senders[0]
+	}
+	return Fallback(senders...)
+}
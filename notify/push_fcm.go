@@ -0,0 +1,199 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrUnregistered is returned for device tokens FCM no longer accepts,
+// e.g. after the app was uninstalled; delete such tokens
+var ErrUnregistered = errors.New("notify: device token is unregistered")
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// FCMConfig configures the Firebase Cloud Messaging sender
+type FCMConfig struct {
+	// CredentialsJSON is the service account key file
+	CredentialsJSON json.RawMessage
+	// ProjectID overrides the project of the service account
+	ProjectID string
+	// BaseURL of the API (default: https://fcm.googleapis.com)
+	BaseURL     string
+	HTTPTimeout time.Duration
+}
+
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEmail string `json:"client_email"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCMSender sends push notifications through the FCM HTTP v1 API
+type FCMSender struct {
+	cfg     FCMConfig
+	account serviceAccount
+	key     *rsa.PrivateKey
+	client  *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewFCMSender creates an FCM push sender from a service account key
+func NewFCMSender(cfg FCMConfig) (*FCMSender, error) {
+	var account serviceAccount
+	if err := json.Unmarshal(cfg.CredentialsJSON, &account); err != nil {
+		return nil, fmt.Errorf("notify: invalid FCM credentials: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, errors.New("notify: FCM credentials need client_email and private_key")
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("notify: invalid FCM private key: %w", err)
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	if cfg.ProjectID == "" {
+		cfg.ProjectID = account.ProjectID
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://fcm.googleapis.com"
+	}
+	if cfg.HTTPTimeout <= 0 {
+		cfg.HTTPTimeout = 10 * time.Second
+	}
+	return &FCMSender{
+		cfg:     cfg,
+		account: account,
+		key:     key,
+		client:  &http.Client{Timeout: cfg.HTTPTimeout},
+	}, nil
+}
+
+// Name returns the provider name
+func (s *FCMSender) Name() string {
+	return "fcm"
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token,omitempty"`
+	Topic        string            `json:"topic,omitempty"`
+	Notification *fcmNotification  `json:"notification,omitempty"`
+	Data         map[string]string `json:"data,omitempty"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+// Send implements PushSender
+func (s *FCMSender) Send(ctx context.Context, msg *Push) error {
+	message := fcmMessage{Token: msg.Token, Topic: msg.Topic, Data: msg.Data}
+	if msg.Title != "" || msg.Body != "" {
+		message.Notification = &fcmNotification{Title: msg.Title, Body: msg.Body}
+	}
+	payload, err := json.Marshal(map[string]interface{}{"message": message})
+	if err != nil {
+		return Permanent(err)
+	}
+
+	token, err := s.token(ctx)
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimSuffix(s.cfg.BaseURL, "/") + "/v1/projects/" + url.PathEscape(s.cfg.ProjectID) + "/messages:send"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify: fcm: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		// Retry with a fresh access token
+		s.mu.Lock()
+		s.accessToken = ""
+		s.mu.Unlock()
+		return fmt.Errorf("notify: fcm: status %d", resp.StatusCode)
+	case resp.StatusCode == http.StatusNotFound && msg.Token != "":
+		return Permanent(fmt.Errorf("%w: %s", ErrUnregistered, msg.Token))
+	}
+	return checkResponse("fcm", resp)
+}
+
+// token returns a cached OAuth access token, exchanging a signed service
+// account assertion when it is about to expire
+func (s *FCMSender) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.accessToken != "" && time.Until(s.expiresAt) > time.Minute {
+		return s.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   s.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   s.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(s.key)
+	if err != nil {
+		return "", Permanent(err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("notify: fcm token: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse("fcm token", resp); err != nil {
+		return "", err
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err := json.Unmarshal(data, &body); err != nil || body.AccessToken == "" {
+		return "", errors.New("notify: fcm token: invalid response")
+	}
+	s.accessToken = body.AccessToken
+	s.expiresAt = now.Add(time.Duration(body.ExpiresIn) * time.Second)
+	return s.accessToken, nil
+}
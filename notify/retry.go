@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetryConfig configures in-process retries of a sender
+type RetryConfig struct {
+	MaxRetries    int
+	InitialDelay  time.Duration
+	MaxDelay      time.Duration
+	BackoffFactor float64
+}
+
+// DefaultRetryConfig returns default retry configuration
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries:    3,
+		InitialDelay:  500 * time.Millisecond,
+		MaxDelay:      10 * time.Second,
+		BackoffFactor: 2.0,
+	}
+}
+
+// Retry sends again with exponential backoff when sender fails; permanent
+// errors are not retried
+func Retry[M any](sender Sender[M], cfg RetryConfig) Sender[M] {
+	if cfg.BackoffFactor < 1 {
+		cfg.BackoffFactor = 2.0
+	}
+	return &retrySender[M]{sender: sender, cfg: cfg}
+}
+
+type retrySender[M any] struct {
+	sender Sender[M]
+	cfg    RetryConfig
+}
+
+// Name returns the name of the wrapped sender
+func (s *retrySender[M]) Name() string {
+	return senderName(s.sender, 0)
+}
+
+func (s *retrySender[M]) Send(ctx context.Context, msg M) error {
+	delay := s.cfg.InitialDelay
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = s.sender.Send(ctx, msg)
+		if err == nil || IsPermanent(err) || attempt >= s.cfg.MaxRetries {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay = time.Duration(float64(delay) * s.cfg.BackoffFactor)
+		if s.cfg.MaxDelay > 0 && delay > s.cfg.MaxDelay {
+			delay = s.cfg.MaxDelay
+		}
+	}
+	return err
+}
+
+// Fallback tries senders in order until one succeeds, e.g. a secondary SMS
+// provider when the primary is down. Wrap each sender with Retry to retry
+// it before falling back. Permanent errors of every sender make the
+// combined error permanent
+func Fallback[M any](senders ...Sender[M]) Sender[M] {
+	return SenderFunc[M](func(ctx context.Context, msg M) error {
+		errs := make([]error, 0, len(senders))
+		permanent := true
+		for i, sender := range senders {
+			err := sender.Send(ctx, msg)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", senderName(sender, i), err))
+			permanent = permanent && IsPermanent(err)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		if len(errs) == 0 {
+			return ErrNoProvider
+		}
+		err := errors.Join(errs...)
+		if permanent {
+			return Permanent(err)
+		}
+		return err
+	})
+}
+
+// senderName returns the Name of providers like TwilioSender, or the
+// position of other senders in a chain
+func senderName(sender interface{}, i int) string {
+	if named, ok := sender.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("sender %d", i)
+}
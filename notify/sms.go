@@ -0,0 +1,187 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ============================================
+// Twilio
+// ============================================
+
+// TwilioConfig configures the Twilio SMS sender
+type TwilioConfig struct {
+	AccountSID string
+	AuthToken  string
+	// From is the default sender number; MessagingServiceSID can be set
+	// instead to let Twilio pick it
+	From                string
+	MessagingServiceSID string
+	// BaseURL of the API (default: https://api.twilio.com)
+	BaseURL     string
+	HTTPTimeout time.Duration
+}
+
+// TwilioSender sends text messages through Twilio
+type TwilioSender struct {
+	cfg    TwilioConfig
+	client *http.Client
+}
+
+// NewTwilioSender creates a Twilio SMS sender
+func NewTwilioSender(cfg TwilioConfig) *TwilioSender {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.twilio.com"
+	}
+	if cfg.HTTPTimeout <= 0 {
+		cfg.HTTPTimeout = 10 * time.Second
+	}
+	return &TwilioSender{cfg: cfg, client: &http.Client{Timeout: cfg.HTTPTimeout}}
+}
+
+// Name returns the provider name
+func (s *TwilioSender) Name() string {
+	return "twilio"
+}
+
+// Send implements SMSSender
+func (s *TwilioSender) Send(ctx context.Context, msg *SMS) error {
+	form := url.Values{"To": {msg.To}, "Body": {msg.Text}}
+	switch {
+	case msg.From != "":
+		form.Set("From", msg.From)
+	case s.cfg.MessagingServiceSID != "":
+		form.Set("MessagingServiceSid", s.cfg.MessagingServiceSID)
+	default:
+		form.Set("From", s.cfg.From)
+	}
+
+	endpoint := strings.TrimSuffix(s.cfg.BaseURL, "/") + "/2010-04-01/Accounts/" + url.PathEscape(s.cfg.AccountSID) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(s.cfg.AccountSID, s.cfg.AuthToken)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify: twilio: %w", err)
+	}
+	defer resp.Body.Close()
+	return checkResponse("twilio", resp)
+}
+
+// ============================================
+// Kavenegar
+// ============================================
+
+// KavenegarConfig configures the Kavenegar SMS sender
+type KavenegarConfig struct {
+	APIKey string
+	// Sender is the default line number (default: the account's default)
+	Sender string
+	// BaseURL of the API (default: https://api.kavenegar.com)
+	BaseURL     string
+	HTTPTimeout time.Duration
+}
+
+// KavenegarSender sends text messages through Kavenegar
+type KavenegarSender struct {
+	cfg    KavenegarConfig
+	client *http.Client
+}
+
+// NewKavenegarSender creates a Kavenegar SMS sender
+func NewKavenegarSender(cfg KavenegarConfig) *KavenegarSender {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.kavenegar.com"
+	}
+	if cfg.HTTPTimeout <= 0 {
+		cfg.HTTPTimeout = 10 * time.Second
+	}
+	return &KavenegarSender{cfg: cfg, client: &http.Client{Timeout: cfg.HTTPTimeout}}
+}
+
+// Name returns the provider name
+func (s *KavenegarSender) Name() string {
+	return "kavenegar"
+}
+
+type kavenegarResponse struct {
+	Return struct {
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+	} `json:"return"`
+}
+
+// Send implements SMSSender
+func (s *KavenegarSender) Send(ctx context.Context, msg *SMS) error {
+	form := url.Values{"receptor": {msg.To}, "message": {msg.Text}}
+	if sender := msg.From; sender != "" {
+		form.Set("sender", sender)
+	} else if s.cfg.Sender != "" {
+		form.Set("sender", s.cfg.Sender)
+	}
+
+	endpoint := strings.TrimSuffix(s.cfg.BaseURL, "/") + "/v1/" + url.PathEscape(s.cfg.APIKey) + "/sms/send.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		// The API key is part of the URL; don't leak it into logs
+		return fmt.Errorf("notify: kavenegar: request failed: %w", redactURL(err))
+	}
+	defer resp.Body.Close()
+
+	var body kavenegarResponse
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err := json.Unmarshal(data, &body); err != nil || body.Return.Status == 0 {
+		body.Return.Status = resp.StatusCode
+	}
+	if body.Return.Status == http.StatusOK {
+		return nil
+	}
+	return statusError("kavenegar", body.Return.Status, body.Return.Message)
+}
+
+// ============================================
+// HTTP helpers
+// ============================================
+
+// checkResponse turns an unsuccessful provider response into an error
+func checkResponse(provider string, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return statusError(provider, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// statusError marks client errors other than timeouts and rate limits as
+// permanent, e.g. invalid numbers or credentials
+func statusError(provider string, status int, message string) error {
+	err := fmt.Errorf("notify: %s: status %d: %s", provider, status, message)
+	if status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
+
+// redactURL drops the URL from *url.Error, keeping the operation and cause
+func redactURL(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
@@ -0,0 +1,154 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+
+	"github.com/minisource/go-common/i18n"
+)
+
+// ErrTemplateNotFound is returned when no translation of a template exists
+var ErrTemplateNotFound = errors.New("notify: template not found")
+
+// Template holds the Go templates of a message in one language. Subject is
+// also the push title and Text the SMS and push body. Besides the params,
+// templates can call {{t "notifications.welcome"}} to translate i18n keys
+// in the template's language, with optional params: {{t "key" .}}
+type Template struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Rendered is the output of a template
+type Rendered struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type parsedTemplate struct {
+	lang    string
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// Templates holds templates by name and language
+type Templates struct {
+	mu          sync.RWMutex
+	defaultLang string
+	templates   map[string]*parsedTemplate
+}
+
+// NewTemplates creates a template set. Renders in languages without a
+// translation use defaultLang (default: en)
+func NewTemplates(defaultLang string) *Templates {
+	if defaultLang == "" {
+		defaultLang = "en"
+	}
+	return &Templates{
+		defaultLang: normalizeLang(defaultLang),
+		templates:   make(map[string]*parsedTemplate),
+	}
+}
+
+// Register parses and adds the translation of template name in lang
+func (t *Templates) Register(name, lang string, tpl Template) error {
+	lang = normalizeLang(lang)
+	parsed := &parsedTemplate{lang: lang}
+	// t is rebound to the render language; this stub only satisfies parsing
+	funcs := map[string]interface{}{"t": func(string, ...map[string]interface{}) string { return "" }}
+
+	var err error
+	if parsed.subject, err = texttemplate.New(name).Funcs(funcs).Parse(tpl.Subject); err != nil {
+		return fmt.Errorf("notify: template %s.%s subject: %w", name, lang, err)
+	}
+	if parsed.text, err = texttemplate.New(name).Funcs(funcs).Parse(tpl.Text); err != nil {
+		return fmt.Errorf("notify: template %s.%s text: %w", name, lang, err)
+	}
+	if tpl.HTML != "" {
+		if parsed.html, err = htmltemplate.New(name).Funcs(funcs).Parse(tpl.HTML); err != nil {
+			return fmt.Errorf("notify: template %s.%s html: %w", name, lang, err)
+		}
+	}
+
+	t.mu.Lock()
+	t.templates[name+"."+lang] = parsed
+	t.mu.Unlock()
+	return nil
+}
+
+// MustRegister is like Register but panics on parse errors, for templates
+// defined in code
+func (t *Templates) MustRegister(name, lang string, tpl Template) {
+	if err := t.Register(name, lang, tpl); err != nil {
+		panic(err)
+	}
+}
+
+// Render executes template name in lang, falling back from a regional
+// variant like fa-IR to fa and then to the default language
+func (t *Templates) Render(name, lang string, params map[string]interface{}) (*Rendered, error) {
+	parsed := t.lookup(name, normalizeLang(lang))
+	if parsed == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+
+	funcs := map[string]interface{}{
+		"t": func(key string, params ...map[string]interface{}) string {
+			return i18n.TLang(parsed.lang, key, params...)
+		},
+	}
+	var rendered Rendered
+	var buf bytes.Buffer
+
+	subject, _ := parsed.subject.Clone()
+	if err := subject.Funcs(funcs).Execute(&buf, params); err != nil {
+		return nil, fmt.Errorf("notify: template %s subject: %w", name, err)
+	}
+	// Subjects end up in headers, so they must stay on one line
+	rendered.Subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	text, _ := parsed.text.Clone()
+	if err := text.Funcs(funcs).Execute(&buf, params); err != nil {
+		return nil, fmt.Errorf("notify: template %s text: %w", name, err)
+	}
+	rendered.Text = strings.TrimSpace(buf.String())
+
+	if parsed.html != nil {
+		buf.Reset()
+		html, err := parsed.html.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if err := html.Funcs(funcs).Execute(&buf, params); err != nil {
+			return nil, fmt.Errorf("notify: template %s html: %w", name, err)
+		}
+		rendered.HTML = buf.String()
+	}
+	return &rendered, nil
+}
+
+func (t *Templates) lookup(name, lang string) *parsedTemplate {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	candidates := []string{lang}
+	if base, _, ok := strings.Cut(lang, "-"); ok {
+		candidates = append(candidates, base)
+	}
+	candidates = append(candidates, t.defaultLang)
+	for _, candidate := range candidates {
+		if parsed, ok := t.templates[name+"."+candidate]; ok {
+			return parsed
+		}
+	}
+	return nil
+}
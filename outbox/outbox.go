@@ -0,0 +1,191 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minisource/go-common/messaging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Message states
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	// StatusFailed messages exhausted their attempts and are no longer
+	// relayed; reset them to pending to retry
+	StatusFailed = "failed"
+)
+
+// Message is an event waiting in the outbox table to be published
+type Message struct {
+	ID            uuid.UUID         `json:"id" gorm:"size:36;primary_key"`
+	Topic         string            `json:"topic" gorm:"size:255;not null"`
+	Key           string            `json:"key,omitempty" gorm:"size:255"`
+	Headers       map[string]string `json:"headers,omitempty" gorm:"serializer:json"`
+	Payload       []byte            `json:"payload"`
+	Status        string            `json:"status" gorm:"size:20;not null;index:idx_outbox_due,priority:1"`
+	Attempts      int               `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time         `json:"next_attempt_at" gorm:"not null;index:idx_outbox_due,priority:2"`
+	LastError     string            `json:"last_error,omitempty" gorm:"type:text"`
+	CreatedAt     time.Time         `json:"created_at" gorm:"not null"`
+	DeliveredAt   *time.Time        `json:"delivered_at,omitempty" gorm:"index"`
+}
+
+// TableName overrides the table name
+func (Message) TableName() string {
+	return "outbox_messages"
+}
+
+// toMessaging converts the row to the message published by the relay; the
+// row ID becomes the message ID so consumers can deduplicate redeliveries
+func (m *Message) toMessaging() *messaging.Message {
+	msg := &messaging.Message{
+		ID:        m.ID.String(),
+		Topic:     m.Topic,
+		Headers:   make(map[string]string, len(m.Headers)),
+		Body:      m.Payload,
+		Timestamp: m.CreatedAt,
+	}
+	if m.Key != "" {
+		msg.Key = []byte(m.Key)
+	}
+	for k, v := range m.Headers {
+		msg.Headers[k] = v
+	}
+	return msg
+}
+
+// Store reads and writes the outbox table
+type Store struct {
+	db *gorm.DB
+}
+
+// NewStore creates an outbox store
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// AutoMigrate creates or updates the outbox table
+func (s *Store) AutoMigrate() error {
+	return s.db.AutoMigrate(&Message{})
+}
+
+// Add stores messages for topic in tx, the transaction of the domain write,
+// so they are published if and only if it commits:
+//
+//	err := db.Transaction(func(tx *gorm.DB) error {
+//		if err := tx.Create(&order).Error; err != nil {
+//			return err
+//		}
+//		return store.Add(ctx, tx, "orders.created", messaging.NewMessage("", body))
+//	})
+//
+// Messages with their own Topic override topic
+func (s *Store) Add(ctx context.Context, tx *gorm.DB, topic string, msgs ...*messaging.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([]*Message, 0, len(msgs))
+	for _, msg := range msgs {
+		row := &Message{
+			ID:            uuid.New(),
+			Topic:         msg.Topic,
+			Key:           string(msg.Key),
+			Headers:       msg.Headers,
+			Payload:       msg.Body,
+			Status:        StatusPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}
+		if row.Topic == "" {
+			row.Topic = topic
+		}
+		if row.Topic == "" {
+			return messaging.ErrNoTopic
+		}
+		if id, err := uuid.Parse(msg.ID); err == nil {
+			row.ID = id
+		}
+		if !msg.Timestamp.IsZero() {
+			row.CreatedAt = msg.Timestamp
+		}
+		rows = append(rows, row)
+	}
+	return tx.WithContext(ctx).Create(&rows).Error
+}
+
+// due locks up to limit pending messages whose next attempt is due. On
+// Postgres and MySQL rows locked by another relay are skipped, so several
+// instances can relay concurrently
+func (s *Store) due(ctx context.Context, tx *gorm.DB, limit int) ([]*Message, error) {
+	query := tx.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", StatusPending, time.Now()).
+		Order("next_attempt_at ASC").
+		Limit(limit)
+	switch tx.Dialector.Name() {
+	case "postgres", "mysql":
+		query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+	}
+
+	var rows []*Message
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Prune deletes delivered messages older than retention in batches and
+// returns the number of deleted rows
+func (s *Store) Prune(ctx context.Context, retention time.Duration, batchSize int) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	cutoff := time.Now().Add(-retention)
+
+	var deleted int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+
+		var ids []uuid.UUID
+		err := s.db.WithContext(ctx).Model(&Message{}).
+			Where("status = ? AND delivered_at < ?", StatusDelivered, cutoff).
+			Limit(batchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return deleted, err
+		}
+		if len(ids) == 0 {
+			return deleted, nil
+		}
+
+		res := s.db.WithContext(ctx).Where("id IN ?", ids).Delete(&Message{})
+		if res.Error != nil {
+			return deleted, res.Error
+		}
+		deleted += res.RowsAffected
+		if len(ids) < batchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// Retry resets failed messages to pending so the relay publishes them again
+func (s *Store) Retry(ctx context.Context, ids ...uuid.UUID) (int64, error) {
+	query := s.db.WithContext(ctx).Model(&Message{}).Where("status = ?", StatusFailed)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	res := query.Updates(map[string]interface{}{
+		"status":          StatusPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	})
+	return res.RowsAffected, res.Error
+}
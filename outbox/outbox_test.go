@@ -0,0 +1,94 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/minisource/go-common/messaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	store := NewStore(db)
+	require.NoError(t, store.AutoMigrate())
+	return store
+}
+
+func TestStoreAddFollowsTransaction(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	msg := messaging.NewMessage("", []byte(`{"id":1}`))
+	msg.Key = []byte("order-1")
+	msg.Headers = map[string]string{"traceparent": "00-abc"}
+	require.NoError(t, store.db.Transaction(func(tx *gorm.DB) error {
+		return store.Add(ctx, tx, "orders.created", msg)
+	}))
+
+	rollback := errors.New("rollback")
+	err := store.db.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, store.Add(ctx, tx, "orders.cancelled", messaging.NewMessage("", nil)))
+		return rollback
+	})
+	require.ErrorIs(t, err, rollback)
+
+	rows, err := store.due(ctx, store.db, 10)
+	require.NoError(t, err)
+	require.Len(t, rows, 1, "messages of a rolled back transaction are discarded")
+	assert.Equal(t, msg.ID, rows[0].ID.String())
+	assert.Equal(t, "orders.created", rows[0].Topic)
+	assert.Equal(t, "order-1", rows[0].Key)
+	assert.Equal(t, map[string]string{"traceparent": "00-abc"}, rows[0].Headers)
+	assert.Equal(t, []byte(`{"id":1}`), rows[0].Payload)
+	assert.Equal(t, StatusPending, rows[0].Status)
+
+	assert.ErrorIs(t, store.Add(ctx, store.db, "", messaging.NewMessage("", nil)), messaging.ErrNoTopic)
+}
+
+func TestRelayBackoff(t *testing.T) {
+	r := NewRelay(nil, nil, RelayConfig{
+		InitialBackoff: time.Second,
+		MaxBackoff:     10 * time.Second,
+		BackoffFactor:  2,
+	})
+
+	assert.Equal(t, time.Second, r.backoff(1))
+	assert.Equal(t, 2*time.Second, r.backoff(2))
+	assert.Equal(t, 8*time.Second, r.backoff(4))
+	assert.Equal(t, 10*time.Second, r.backoff(5))
+	assert.Equal(t, 10*time.Second, r.backoff(50))
+}
+
+func TestMessageToMessagingKeepsID(t *testing.T) {
+	row := &Message{
+		ID:        uuid.New(),
+		Topic:     "orders.created",
+		Key:       "order-1",
+		Headers:   map[string]string{"traceparent": "00-abc"},
+		Payload:   []byte(`{"id":1}`),
+		CreatedAt: time.Now(),
+	}
+
+	msg := row.toMessaging()
+
+	assert.Equal(t, row.ID.String(), msg.ID)
+	assert.Equal(t, "orders.created", msg.Topic)
+	assert.Equal(t, []byte("order-1"), msg.Key)
+	assert.Equal(t, "00-abc", msg.Header("traceparent"))
+	assert.Equal(t, row.Payload, msg.Body)
+}
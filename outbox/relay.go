@@ -0,0 +1,243 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/messaging"
+	"gorm.io/gorm"
+)
+
+// RelayConfig configures the relay worker
+type RelayConfig struct {
+	// Interval between polls when started with Start (default: 1s)
+	Interval time.Duration
+	// BatchSize is the number of messages relayed per transaction
+	// (default: 100)
+	BatchSize int
+	// MaxAttempts marks a message failed after that many failed publishes
+	// (0 = retry forever)
+	MaxAttempts int
+	// InitialBackoff, MaxBackoff and BackoffFactor space the attempts of a
+	// failing message
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	BackoffFactor  float64
+	// Retention keeps delivered messages that long before pruning them
+	// (0 = keep forever)
+	Retention time.Duration
+	// PruneInterval is the minimum time between prunes (default: 1h)
+	PruneInterval time.Duration
+	Logger        logging.Logger
+}
+
+// DefaultRelayConfig returns default relay configuration
+func DefaultRelayConfig() RelayConfig {
+	return RelayConfig{
+		Interval:       time.Second,
+		BatchSize:      100,
+		MaxAttempts:    20,
+		InitialBackoff: time.Second,
+		MaxBackoff:     10 * time.Minute,
+		BackoffFactor:  2.0,
+		Retention:      7 * 24 * time.Hour,
+		PruneInterval:  time.Hour,
+	}
+}
+
+// RelayStats summarizes a relay pass
+type RelayStats struct {
+	Delivered int
+	Failed    int
+	Pruned    int64
+}
+
+// Relay publishes outbox messages with at-least-once semantics: a message
+// is marked delivered only after the publisher accepted it, so a crash in
+// between publishes it again
+type Relay struct {
+	store     *Store
+	publisher messaging.Publisher
+	cfg       RelayConfig
+
+	lastPrune time.Time
+	stop      chan struct{}
+	done      chan struct{}
+	once      sync.Once
+	started   atomic.Bool
+}
+
+// NewRelay creates a relay publishing the messages of store
+func NewRelay(store *Store, publisher messaging.Publisher, cfg RelayConfig) *Relay {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = time.Second
+	}
+	if cfg.BackoffFactor < 1 {
+		cfg.BackoffFactor = 2.0
+	}
+	if cfg.PruneInterval <= 0 {
+		cfg.PruneInterval = time.Hour
+	}
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		cfg:       cfg,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start relays on the configured interval until Stop is called. A full
+// batch is followed by the next one without waiting
+func (r *Relay) Start() {
+	if !r.started.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				for {
+					stats, err := r.RunOnce(context.Background())
+					if err != nil || stats.Delivered+stats.Failed < r.cfg.BatchSize || r.stopped() {
+						break
+					}
+				}
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (r *Relay) stopped() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop stops the relay and waits for a running pass to finish. It matches
+// shutdown.Hook.
+func (r *Relay) Stop(ctx context.Context) error {
+	r.once.Do(func() { close(r.stop) })
+	if !r.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunOnce relays one batch of due messages and prunes delivered ones when
+// the prune interval has passed
+func (r *Relay) RunOnce(ctx context.Context) (RelayStats, error) {
+	var stats RelayStats
+
+	err := r.store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		rows, err := r.store.due(ctx, tx, r.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if err := r.relay(ctx, tx, row); err != nil {
+				return err
+			}
+			if row.Status == StatusDelivered {
+				stats.Delivered++
+			} else {
+				stats.Failed++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.logError("Outbox relay failed", err)
+		return stats, err
+	}
+
+	if r.cfg.Retention > 0 && time.Since(r.lastPrune) >= r.cfg.PruneInterval {
+		r.lastPrune = time.Now()
+		stats.Pruned, err = r.store.Prune(ctx, r.cfg.Retention, r.cfg.BatchSize*10)
+		if err != nil {
+			r.logError("Outbox prune failed", err)
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// relay publishes one message and records the outcome
+func (r *Relay) relay(ctx context.Context, tx *gorm.DB, row *Message) error {
+	now := time.Now()
+	updates := map[string]interface{}{}
+
+	if err := r.publisher.Publish(ctx, row.Topic, row.toMessaging()); err != nil {
+		row.Attempts++
+		row.LastError = err.Error()
+		updates["attempts"] = row.Attempts
+		updates["last_error"] = row.LastError
+		if r.cfg.MaxAttempts > 0 && row.Attempts >= r.cfg.MaxAttempts {
+			row.Status = StatusFailed
+			updates["status"] = row.Status
+			r.logError("Outbox message failed permanently", err, row)
+		} else {
+			updates["next_attempt_at"] = now.Add(r.backoff(row.Attempts))
+		}
+	} else {
+		row.Status = StatusDelivered
+		row.DeliveredAt = &now
+		updates["status"] = row.Status
+		updates["delivered_at"] = now
+	}
+
+	return tx.WithContext(ctx).Model(&Message{}).Where("id = ?", row.ID).Updates(updates).Error
+}
+
+// backoff returns the delay after the given number of failed attempts
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := float64(r.cfg.InitialBackoff)
+	for i := 1; i < attempts; i++ {
+		delay *= r.cfg.BackoffFactor
+		if r.cfg.MaxBackoff > 0 && delay >= float64(r.cfg.MaxBackoff) {
+			return r.cfg.MaxBackoff
+		}
+	}
+	return time.Duration(delay)
+}
+
+func (r *Relay) logError(msg string, err error, rows ...*Message) {
+	if r.cfg.Logger == nil {
+		return
+	}
+	extra := map[logging.ExtraKey]interface{}{
+		logging.ErrorMessage: err.Error(),
+	}
+	for _, row := range rows {
+		extra[logging.MessageID] = row.ID.String()
+		extra[logging.Topic] = row.Topic
+	}
+	r.cfg.Logger.Error(logging.Messaging, logging.Publish, msg, extra)
+}
@@ -0,0 +1,246 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minisource/go-common/messaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// flakyPublisher fails while failing is set and otherwise publishes to the
+// memory broker
+type flakyPublisher struct {
+	*messaging.MemoryBroker
+	failing atomic.Bool
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, topic string, msgs ...*messaging.Message) error {
+	if p.failing.Load() {
+		return errors.New("broker down")
+	}
+	return p.MemoryBroker.Publish(ctx, topic, msgs...)
+}
+
+// subscribe returns the messages published to topic from now on
+func subscribe(t *testing.T, broker *messaging.MemoryBroker, topic string) <-chan *messaging.Message {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	received := make(chan *messaging.Message, 100)
+	ready := make(chan struct{})
+	var once atomic.Bool
+	go func() {
+		_ = broker.Subscribe(ctx, topic, func(ctx context.Context, msg *messaging.Message) error {
+			if msg.Header("probe") != "" {
+				if once.CompareAndSwap(false, true) {
+					close(ready)
+				}
+				return nil
+			}
+			received <- msg
+			return nil
+		})
+	}()
+
+	// Messages published before the subscription exists are dropped, so
+	// probe until it receives
+	require.Eventually(t, func() bool {
+		probe := messaging.NewMessage(topic, nil)
+		probe.Headers["probe"] = "1"
+		require.NoError(t, broker.Publish(ctx, topic, probe))
+		select {
+		case <-ready:
+			return true
+		case <-time.After(5 * time.Millisecond):
+			return false
+		}
+	}, time.Second, time.Millisecond)
+	return received
+}
+
+func receiveIDs(t *testing.T, received <-chan *messaging.Message, n int) []string {
+	t.Helper()
+	ids := make([]string, 0, n)
+	for len(ids) < n {
+		select {
+		case msg := <-received:
+			ids = append(ids, msg.ID)
+		case <-time.After(time.Second):
+			t.Fatalf("received %d of %d messages", len(ids), n)
+		}
+	}
+	return ids
+}
+
+func addMessages(t *testing.T, store *Store, topic string, n int) []string {
+	t.Helper()
+	var ids []string
+	for i := 0; i < n; i++ {
+		msg := messaging.NewMessage("", []byte(`{}`))
+		ids = append(ids, msg.ID)
+		require.NoError(t, store.Add(context.Background(), store.db, topic, msg))
+	}
+	return ids
+}
+
+func loadMessage(t *testing.T, store *Store, id string) Message {
+	t.Helper()
+	var row Message
+	require.NoError(t, store.db.First(&row, "id = ?", id).Error)
+	return row
+}
+
+func newTestRelay(store *Store, publisher messaging.Publisher, cfg RelayConfig) *Relay {
+	if cfg.InitialBackoff == 0 {
+		cfg.InitialBackoff = time.Hour
+	}
+	return NewRelay(store, publisher, cfg)
+}
+
+func TestRelayDeliversAtLeastOnce(t *testing.T) {
+	store := newTestStore(t)
+	broker := messaging.NewMemoryBroker(0)
+	defer broker.Close()
+	received := subscribe(t, broker, "orders.created")
+	relay := newTestRelay(store, broker, RelayConfig{})
+	ctx := context.Background()
+
+	ids := addMessages(t, store, "orders.created", 3)
+	stats, err := relay.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Delivered)
+	assert.ElementsMatch(t, ids, receiveIDs(t, received, 3), "messages keep their outbox ID")
+	row := loadMessage(t, store, ids[0])
+	assert.Equal(t, StatusDelivered, row.Status)
+	assert.NotNil(t, row.DeliveredAt)
+
+	stats, err = relay.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Delivered, "delivered messages are not relayed again")
+
+	// A message published but not marked delivered is published again
+	id := addMessages(t, store, "orders.created", 1)[0]
+	var failMark atomic.Bool
+	failMark.Store(true)
+	require.NoError(t, store.db.Callback().Update().Before("gorm:update").Register("test:fail_mark", func(db *gorm.DB) {
+		if failMark.CompareAndSwap(true, false) {
+			_ = db.AddError(errors.New("connection lost"))
+		}
+	}))
+	_, err = relay.RunOnce(ctx)
+	assert.Error(t, err)
+	_, err = relay.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{id, id}, receiveIDs(t, received, 2))
+	assert.Equal(t, StatusDelivered, loadMessage(t, store, id).Status)
+}
+
+func TestRelayReschedulesFailedPublish(t *testing.T) {
+	store := newTestStore(t)
+	publisher := &flakyPublisher{MemoryBroker: messaging.NewMemoryBroker(0)}
+	defer publisher.Close()
+	received := subscribe(t, publisher.MemoryBroker, "orders.created")
+	relay := newTestRelay(store, publisher, RelayConfig{InitialBackoff: time.Minute})
+	ctx := context.Background()
+
+	id := addMessages(t, store, "orders.created", 1)[0]
+	publisher.failing.Store(true)
+	stats, err := relay.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Failed)
+
+	row := loadMessage(t, store, id)
+	assert.Equal(t, StatusPending, row.Status)
+	assert.Equal(t, 1, row.Attempts)
+	assert.Equal(t, "broker down", row.LastError)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), row.NextAttemptAt, 5*time.Second)
+
+	publisher.failing.Store(false)
+	stats, err = relay.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Delivered, "not due before the backoff elapses")
+
+	require.NoError(t, store.db.Model(&Message{}).Where("id = ?", id).Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+	stats, err = relay.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Delivered)
+	assert.Equal(t, []string{id}, receiveIDs(t, received, 1))
+}
+
+func TestRelayFailsMessageAfterMaxAttempts(t *testing.T) {
+	store := newTestStore(t)
+	publisher := &flakyPublisher{MemoryBroker: messaging.NewMemoryBroker(0)}
+	defer publisher.Close()
+	received := subscribe(t, publisher.MemoryBroker, "orders.created")
+	relay := newTestRelay(store, publisher, RelayConfig{MaxAttempts: 2, InitialBackoff: time.Nanosecond})
+	ctx := context.Background()
+
+	id := addMessages(t, store, "orders.created", 1)[0]
+	other := addMessages(t, store, "orders.created", 1)[0]
+	require.NoError(t, store.db.Model(&Message{}).Where("id = ?", other).Update("status", StatusDelivered).Error)
+
+	publisher.failing.Store(true)
+	for i := 0; i < 2; i++ {
+		time.Sleep(time.Millisecond)
+		_, err := relay.RunOnce(ctx)
+		require.NoError(t, err)
+	}
+	row := loadMessage(t, store, id)
+	assert.Equal(t, StatusFailed, row.Status)
+	assert.Equal(t, 2, row.Attempts)
+
+	publisher.failing.Store(false)
+	stats, err := relay.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Delivered+stats.Failed, "failed messages are not relayed")
+
+	n, err := store.Retry(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "only failed messages are reset")
+	row = loadMessage(t, store, id)
+	assert.Equal(t, StatusPending, row.Status)
+	assert.Zero(t, row.Attempts)
+
+	stats, err = relay.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Delivered)
+	assert.Equal(t, []string{id}, receiveIDs(t, received, 1))
+}
+
+func TestStorePruneDeletesOldDeliveredMessages(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	ids := addMessages(t, store, "orders.created", 6)
+	deliver := func(id string, at time.Time) {
+		require.NoError(t, store.db.Model(&Message{}).Where("id = ?", id).
+			Updates(map[string]interface{}{"status": StatusDelivered, "delivered_at": at}).Error)
+	}
+	for _, id := range ids[:3] {
+		deliver(id, time.Now().Add(-48*time.Hour))
+	}
+	deliver(ids[3], time.Now())
+
+	n, err := store.Prune(ctx, 24*time.Hour, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	var left []uuid.UUID
+	require.NoError(t, store.db.Model(&Message{}).Order("created_at").Pluck("id", &left).Error)
+	assert.Len(t, left, 3, "recent and pending messages are kept")
+
+	// The relay prunes on its own once Retention is set
+	deliver(ids[3], time.Now().Add(-48*time.Hour))
+	relay := newTestRelay(store, messaging.NewMemoryBroker(0), RelayConfig{Retention: 24 * time.Hour})
+	stats, err := relay.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Pruned)
+}
@@ -6,6 +6,7 @@ import (
 	"reflect"
 
 	"github.com/google/uuid"
+	"github.com/minisource/go-common/dto"
 	"gorm.io/gorm"
 )
 
@@ -13,6 +14,9 @@ var (
 	ErrNotFound      = errors.New("entity not found")
 	ErrAlreadyExists = errors.New("entity already exists")
 	ErrInvalidID     = errors.New("invalid entity ID")
+	// ErrPreloadInBatches is returned by Query.FindInBatches when the query
+	// has preloads
+	ErrPreloadInBatches = errors.New("preload is not supported in batches")
 )
 
 // BaseEntity defines the interface for entities with ID
@@ -237,6 +241,17 @@ func (q *Query[T]) Select(query interface{}, args ...interface{}) *Query[T] {
 	return q
 }
 
+// Filter applies a client supplied dynamic filter restricted to fields;
+// see dto.DynamicFilter.Build
+func (q *Query[T]) Filter(f *dto.DynamicFilter, fields dto.FilterFields) (*Query[T], error) {
+	fq, err := f.Build(fields)
+	if err != nil {
+		return q, err
+	}
+	q.db = q.db.Scopes(FilterScope(fq))
+	return q, nil
+}
+
 // Find executes the query and returns results
 func (q *Query[T]) Find() ([]T, error) {
 	var entities []T
@@ -244,6 +259,46 @@ func (q *Query[T]) Find() ([]T, error) {
 	return entities, err
 }
 
+// FindInBatches calls fn with the results batchSize at a time, in the
+// query's order; the batch slice is reused between calls. Rows are streamed
+// from a single query, so fn must not wait on other queries when the pool
+// has one connection. Preloads are not supported.
+func (q *Query[T]) FindInBatches(batchSize int, fn func(batch []T) error) error {
+	if len(q.db.Statement.Preloads) > 0 {
+		return ErrPreloadInBatches
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	rows, err := q.db.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	batch := make([]T, 0, batchSize)
+	for rows.Next() {
+		var entity T
+		if err := q.db.ScanRows(rows, &entity); err != nil {
+			return err
+		}
+		batch = append(batch, entity)
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
 // First returns the first result
 func (q *Query[T]) First() (*T, error) {
 	var entity T
@@ -277,6 +332,19 @@ func (q *Query[T]) Paginate(page, pageSize int) ([]T, int64, error) {
 	return entities, total, err
 }
 
+// FilterScope adds the conditions and ordering of fq to a GORM query
+func FilterScope(fq *dto.FilterQuery) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, c := range fq.Conditions {
+			db = db.Where(c.SQL, c.Args...)
+		}
+		for _, order := range fq.Order {
+			db = db.Order(order)
+		}
+		return db
+	}
+}
+
 // ============================================
 // Tenant-Scoped Repository
 // ============================================
@@ -1,5 +1,7 @@
 package response
 
+import "github.com/minisource/go-common/service_errors"
+
 // Common error codes for use across all microservices
 const (
 	// Authentication errors
@@ -91,7 +93,9 @@ const (
 	ErrCodeOTPMaxAttempts = "OTP_MAX_ATTEMPTS"
 )
 
-// ErrorCodeToStatus maps error codes to HTTP status codes
+// ErrorCodeToStatus maps error codes to HTTP status codes. It is the one
+// table helper.TranslateErrorToStatusCode consults for codes, so services
+// register their own codes here
 var ErrorCodeToStatus = map[string]int{
 	// 400 Bad Request
 	ErrCodeBadRequest:       400,
@@ -173,6 +177,30 @@ var ErrorCodeToStatus = map[string]int{
 
 	// 504 Gateway Timeout
 	ErrCodeTimeout: 504,
+
+	// service_errors codes
+	service_errors.BadRequest:         400,
+	service_errors.ValidationError:    400,
+	service_errors.OtpNotValid:        400,
+	service_errors.ClaimsNotFound:     401,
+	service_errors.TokenRequired:      401,
+	service_errors.TokenExpired:       401,
+	service_errors.TokenInvalid:       401,
+	service_errors.Unauthorized:       401,
+	service_errors.UserDisabled:       403,
+	service_errors.PermissionDenied:   403,
+	service_errors.Forbidden:          403,
+	service_errors.RecordNotFound:     404,
+	service_errors.NotFound:           404,
+	service_errors.OptExists:          409,
+	service_errors.OtpUsed:            409,
+	service_errors.EmailExists:        409,
+	service_errors.UsernameExists:     409,
+	service_errors.Conflict:           409,
+	service_errors.TooManyRequests:    429,
+	service_errors.UnExpectedError:    500,
+	service_errors.InternalError:      500,
+	service_errors.ServiceUnavailable: 503,
 }
 
 // GetStatusForCode returns the HTTP status code for an error code
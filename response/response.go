@@ -205,6 +205,11 @@ func UnprocessableEntity(c *fiber.Ctx, errors []ValidationError) error {
 	return New().Status(http.StatusUnprocessableEntity).ValidationErrors(errors).Send(c)
 }
 
+// TooManyRequests sends a 429 error
+func TooManyRequests(c *fiber.Ctx, message string) error {
+	return New().Status(http.StatusTooManyRequests).Error(ErrCodeRateLimited, message).Send(c)
+}
+
 // InternalError sends a 500 error
 func InternalError(c *fiber.Ctx, message string) error {
 	return New().Status(http.StatusInternalServerError).Error("INTERNAL_ERROR", message).Send(c)
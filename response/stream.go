@@ -0,0 +1,30 @@
+package response
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StreamFunc writes a response body incrementally. It runs after the handler
+// returns, so it must not use the fiber.Ctx.
+type StreamFunc func(w *bufio.Writer) error
+
+// Stream sends a chunked response with the given content type
+func Stream(c *fiber.Ctx, contentType string, fn StreamFunc) error {
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// Headers are already sent; a failed stream just ends early
+		_ = fn(w)
+		_ = w.Flush()
+	})
+	return nil
+}
+
+// StreamAttachment sends a chunked response as a file download
+func StreamAttachment(c *fiber.Ctx, filename, contentType string, fn StreamFunc) error {
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	return Stream(c, contentType, fn)
+}
@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Locker grants a key to a single caller until it expires. Keys are never
+// released early, so a replica whose clock lags cannot run a tick again
+type Locker interface {
+	// Acquire reports whether key was free and is now held for ttl
+	Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// MemoryLocker is a Locker for a single process, e.g. in tests
+type MemoryLocker struct {
+	mu   sync.Mutex
+	keys map[string]time.Time
+}
+
+// NewMemoryLocker creates an in-memory locker
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{keys: make(map[string]time.Time)}
+}
+
+// Acquire implements Locker
+func (l *MemoryLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for k, expires := range l.keys {
+		if !now.Before(expires) {
+			delete(l.keys, k)
+		}
+	}
+	if _, held := l.keys[key]; held {
+		return false, nil
+	}
+	l.keys[key] = now.Add(ttl)
+	return true, nil
+}
@@ -0,0 +1,34 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisLocker shares locks between replicas through Redis; the value of a
+// key names the holder for debugging
+type RedisLocker struct {
+	client redis.UniversalClient
+	prefix string
+	owner  string
+}
+
+// NewRedisLocker creates a Redis backed locker; keys are prefixed with
+// "scheduler:"
+func NewRedisLocker(client redis.UniversalClient) *RedisLocker {
+	host, _ := os.Hostname()
+	return &RedisLocker{
+		client: client,
+		prefix: "scheduler:",
+		owner:  fmt.Sprintf("%s-%d", host, os.Getpid()),
+	}
+}
+
+// Acquire implements Locker
+func (l *RedisLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return l.client.SetNX(ctx, l.prefix+key, l.owner, ttl).Result()
+}
@@ -0,0 +1,326 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/metrics"
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	// ErrDuplicate is returned when a job name is registered twice
+	ErrDuplicate = errors.New("scheduler: job already registered")
+	// ErrNotFound is returned for unknown job names
+	ErrNotFound = errors.New("scheduler: job not found")
+)
+
+// parser accepts five-field cron expressions with an optional leading
+// seconds field and descriptors such as @hourly or @every 10m
+var parser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// Func is the work of a scheduled job
+type Func func(ctx context.Context) error
+
+// Config configures a scheduler
+type Config struct {
+	// Locker makes each tick of a job run on one replica only; without it
+	// every replica runs every tick
+	Locker Locker
+	// Location cron expressions are evaluated in, unless they set CRON_TZ
+	// (default: time.Local)
+	Location *time.Location
+	// Timeout bounds every run of jobs without their own (0 = none)
+	Timeout time.Duration
+	Logger  logging.Logger
+}
+
+// DefaultConfig returns default scheduler configuration
+func DefaultConfig() Config {
+	return Config{
+		Location: time.Local,
+	}
+}
+
+// JobOption configures a registered job
+type JobOption func(*entry)
+
+// WithTimeout cancels the context of a run after d
+func WithTimeout(d time.Duration) JobOption {
+	return func(e *entry) {
+		e.timeout = d
+	}
+}
+
+// WithoutLock runs the job on every replica, e.g. to refresh local caches
+func WithoutLock() JobOption {
+	return func(e *entry) {
+		e.local = true
+	}
+}
+
+// alignedSchedule ticks on multiples of every since the zero time, so all
+// replicas share the same ticks
+type alignedSchedule struct {
+	every time.Duration
+}
+
+func (a alignedSchedule) Next(t time.Time) time.Time {
+	return t.Truncate(a.every).Add(a.every)
+}
+
+type entry struct {
+	name     string
+	schedule cron.Schedule
+	fn       Func
+	timeout  time.Duration
+	local    bool
+	running  atomic.Bool
+}
+
+// Scheduler runs jobs on cron schedules. A tick is skipped while the
+// previous run of the same job is still going on this replica
+type Scheduler struct {
+	cfg Config
+
+	mu      sync.Mutex
+	entries map[string]*entry
+
+	// ctx is cancelled when Stop gives up waiting for running jobs
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stop    chan struct{}
+	loops   sync.WaitGroup
+	runs    sync.WaitGroup
+	once    sync.Once
+	started atomic.Bool
+}
+
+// New creates a scheduler
+func New(cfg Config) *Scheduler {
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		cfg:     cfg,
+		entries: make(map[string]*entry),
+		ctx:     ctx,
+		cancel:  cancel,
+		stop:    make(chan struct{}),
+	}
+}
+
+// Add registers fn under name to run on spec, e.g. "*/5 * * * *",
+// "0 30 2 * * *" or "@every 10m"; @every runs on multiples of the interval.
+// Jobs added after Start are scheduled right away
+func (s *Scheduler) Add(name, spec string, fn Func, opts ...JobOption) error {
+	schedule, err := parser.Parse(spec)
+	if err != nil {
+		return fmt.Errorf("scheduler: job %s: %w", name, err)
+	}
+
+	// @every ticks would otherwise depend on the start time of each replica
+	if every, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		schedule = alignedSchedule{every: every.Delay}
+	}
+
+	e := &entry{name: name, schedule: schedule, fn: fn, timeout: s.cfg.Timeout}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[name]; ok {
+		return ErrDuplicate
+	}
+	s.entries[name] = e
+
+	if s.started.Load() && !s.stopped() {
+		s.loops.Add(1)
+		go s.loop(e)
+	}
+	return nil
+}
+
+// Start runs the registered jobs until Stop is called
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.started.CompareAndSwap(false, true) {
+		return
+	}
+	for _, e := range s.entries {
+		s.loops.Add(1)
+		go s.loop(e)
+	}
+}
+
+func (s *Scheduler) stopped() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop stops scheduling and waits for running jobs to finish; when ctx ends
+// first they are cancelled. It matches shutdown.Hook.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.once.Do(func() { close(s.stop) })
+	s.mu.Unlock()
+	s.loops.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Run runs the job name once now, without taking its lock
+func (s *Scheduler) Run(ctx context.Context, name string) error {
+	s.mu.Lock()
+	e, ok := s.entries[name]
+	s.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+	return s.run(ctx, e)
+}
+
+// loop fires the ticks of e; ticks missed while the process was suspended
+// are not caught up
+func (s *Scheduler) loop(e *entry) {
+	defer s.loops.Done()
+
+	next := e.schedule.Next(time.Now().In(s.cfg.Location))
+	for !next.IsZero() {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		tick := next
+		next = e.schedule.Next(tick)
+		if now := time.Now().In(s.cfg.Location); next.Before(now) {
+			next = e.schedule.Next(now)
+		}
+
+		s.runs.Add(1)
+		go func(until time.Time) {
+			defer s.runs.Done()
+			s.tick(e, tick, until)
+		}(next)
+	}
+}
+
+// tick runs e for the tick at tick if no other replica claimed it; the
+// claim lasts until the next tick
+func (s *Scheduler) tick(e *entry, tick, next time.Time) {
+	if !e.running.CompareAndSwap(false, true) {
+		s.observe(e.name, "overlap", 0)
+		s.log(e.name, "Previous run still in progress, tick skipped", nil)
+		return
+	}
+	defer e.running.Store(false)
+
+	if s.cfg.Locker != nil && !e.local {
+		ttl := next.Sub(tick)
+		if next.IsZero() || ttl < time.Second {
+			ttl = time.Second
+		}
+		acquired, err := s.cfg.Locker.Acquire(s.ctx, e.name+":"+strconv.FormatInt(tick.Unix(), 10), ttl)
+		if err != nil {
+			s.observe(e.name, "lock_error", 0)
+			s.log(e.name, "Lock failed", err)
+			return
+		}
+		if !acquired {
+			s.observe(e.name, "locked", 0)
+			return
+		}
+	}
+
+	_ = s.run(s.ctx, e)
+}
+
+// run executes e within its timeout, turning panics into errors
+func (s *Scheduler) run(ctx context.Context, e *entry) (err error) {
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	result := "success"
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panic: %v", r)
+			result = "panic"
+		}
+		s.observe(e.name, result, time.Since(start))
+		if err != nil {
+			s.log(e.name, "Job failed", err)
+		}
+	}()
+
+	err = e.fn(ctx)
+	switch {
+	case err == nil:
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result = "timeout"
+	default:
+		result = "error"
+	}
+	return err
+}
+
+func (s *Scheduler) observe(name, result string, duration time.Duration) {
+	metrics.SchedulerJobRunsTotal.WithLabelValues(name, result).Inc()
+	if duration > 0 {
+		metrics.SchedulerJobDuration.WithLabelValues(name).Observe(duration.Seconds())
+	}
+}
+
+func (s *Scheduler) log(name, msg string, err error) {
+	if s.cfg.Logger == nil {
+		return
+	}
+	extra := map[logging.ExtraKey]interface{}{
+		logging.Name: name,
+	}
+	if err != nil {
+		extra[logging.ErrorMessage] = err.Error()
+		s.cfg.Logger.Error(logging.Internal, logging.Scheduler, msg, extra)
+		return
+	}
+	s.cfg.Logger.Warn(logging.Internal, logging.Scheduler, msg, extra)
+}
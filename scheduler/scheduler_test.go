@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddValidatesSpec(t *testing.T) {
+	s := New(DefaultConfig())
+	noop := func(ctx context.Context) error { return nil }
+
+	assert.Error(t, s.Add("bad", "not a cron", noop))
+	assert.NoError(t, s.Add("minutely", "*/5 * * * *", noop))
+	assert.NoError(t, s.Add("seconds", "0 30 2 * * *", noop))
+	assert.NoError(t, s.Add("every", "@every 10m", noop))
+	assert.ErrorIs(t, s.Add("every", "@hourly", noop), ErrDuplicate)
+}
+
+func TestRun(t *testing.T) {
+	s := New(DefaultConfig())
+
+	var calls int
+	require.NoError(t, s.Add("count", "@hourly", func(ctx context.Context) error {
+		calls++
+		return nil
+	}))
+	require.NoError(t, s.Add("panics", "@hourly", func(ctx context.Context) error {
+		panic("boom")
+	}))
+	require.NoError(t, s.Add("slow", "@hourly", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithTimeout(10*time.Millisecond)))
+
+	assert.NoError(t, s.Run(context.Background(), "count"))
+	assert.Equal(t, 1, calls)
+	assert.ErrorContains(t, s.Run(context.Background(), "panics"), "boom")
+	assert.ErrorIs(t, s.Run(context.Background(), "slow"), context.DeadlineExceeded)
+	assert.ErrorIs(t, s.Run(context.Background(), "missing"), ErrNotFound)
+}
+
+func TestLockRunsEachTickOnce(t *testing.T) {
+	locker := NewMemoryLocker()
+
+	var locked, local atomic.Int32
+	replicas := make([]*Scheduler, 2)
+	for i := range replicas {
+		s := New(Config{Locker: locker})
+		require.NoError(t, s.Add("locked", "@every 1s", func(ctx context.Context) error {
+			locked.Add(1)
+			return nil
+		}))
+		require.NoError(t, s.Add("local", "@every 1s", func(ctx context.Context) error {
+			local.Add(1)
+			return nil
+		}, WithoutLock()))
+		s.Start()
+		replicas[i] = s
+	}
+
+	// The window holds two or three whole-second ticks
+	time.Sleep(2200 * time.Millisecond)
+	for _, s := range replicas {
+		require.NoError(t, s.Stop(context.Background()))
+	}
+
+	ticks := locked.Load()
+	assert.GreaterOrEqual(t, ticks, int32(2))
+	assert.LessOrEqual(t, ticks, int32(3))
+	assert.Equal(t, 2*ticks, local.Load())
+}
+
+func TestStopCancelsRunningJobs(t *testing.T) {
+	s := New(DefaultConfig())
+
+	started := make(chan struct{})
+	var once atomic.Bool
+	require.NoError(t, s.Add("slow", "@every 1s", func(ctx context.Context) error {
+		if once.CompareAndSwap(false, true) {
+			close(started)
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	s.Start()
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("job was not run")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Stop(ctx), context.DeadlineExceeded)
+}
+
+func TestAlignedSchedule(t *testing.T) {
+	schedule := alignedSchedule{every: 10 * time.Minute}
+	at := time.Date(2024, 1, 1, 10, 3, 20, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2024, 1, 1, 10, 10, 0, 0, time.UTC), schedule.Next(at))
+	assert.Equal(t, time.Date(2024, 1, 1, 10, 20, 0, 0, time.UTC), schedule.Next(schedule.Next(at)))
+}
+
+func TestMemoryLocker(t *testing.T) {
+	locker := NewMemoryLocker()
+
+	ok, err := locker.Acquire(context.Background(), "job:1", 20*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, _ = locker.Acquire(context.Background(), "job:1", 20*time.Millisecond)
+	assert.False(t, ok)
+
+	time.Sleep(25 * time.Millisecond)
+	ok, _ = locker.Acquire(context.Background(), "job:1", 20*time.Millisecond)
+	assert.True(t, ok)
+}
@@ -0,0 +1,93 @@
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Drainer tracks in-flight HTTP requests so shutdown can wait for them to
+// finish before servers and resources are torn down
+type Drainer struct {
+	inFlight     atomic.Int64
+	draining     atomic.Bool
+	pollInterval time.Duration
+}
+
+// NewDrainer creates a new request drainer
+func NewDrainer() *Drainer {
+	return &Drainer{pollInterval: 50 * time.Millisecond}
+}
+
+// Middleware counts in-flight requests. Once draining has started, new
+// requests are rejected with 503 and the connection is closed so clients
+// retry against another instance.
+func (d *Drainer) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if d.draining.Load() {
+			c.Set(fiber.HeaderConnection, "close")
+			return c.SendStatus(fiber.StatusServiceUnavailable)
+		}
+
+		d.inFlight.Add(1)
+		defer d.inFlight.Add(-1)
+
+		return c.Next()
+	}
+}
+
+// InFlight returns the number of requests currently being served
+func (d *Drainer) InFlight() int64 {
+	return d.inFlight.Load()
+}
+
+// IsDraining reports whether the drainer has stopped accepting requests
+func (d *Drainer) IsDraining() bool {
+	return d.draining.Load()
+}
+
+// Drain stops accepting new requests and waits until all in-flight requests
+// have completed or ctx is done
+func (d *Drainer) Drain(ctx context.Context) error {
+	d.draining.Store(true)
+
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if d.inFlight.Load() <= 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d requests still in flight: %w", d.inFlight.Load(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// WithDrainer runs a drain phase before any hook: new requests are refused
+// and in-flight requests get up to maxWait to complete. The drain wait does
+// not consume the hook timeout budget.
+func WithDrainer(d *Drainer, maxWait time.Duration) Option {
+	return func(m *Manager) {
+		m.drainer = d
+		m.drainWait = maxWait
+	}
+}
+
+// drain runs the drain phase and reports it as a hook result
+func (m *Manager) drain() HookResult {
+	wait := m.drainWait
+	if wait <= 0 {
+		wait = m.timeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), wait)
+	defer cancel()
+
+	return m.runHook(ctx, namedHook{name: "drain", fn: m.drainer.Drain})
+}
@@ -0,0 +1,109 @@
+package shutdown
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDependencyCycle is returned when hook dependencies form a cycle
+var ErrDependencyCycle = errors.New("shutdown hook dependency cycle")
+
+// After declares that the hook must only run once the named hooks have
+// finished. Dependencies on hooks of an earlier stage are always satisfied;
+// dependencies on hooks of a later stage are invalid and reported by Validate.
+func After(names ...string) HookOption {
+	return func(h *namedHook) {
+		h.after = append(h.after, names...)
+	}
+}
+
+// Validate checks that every dependency refers to a registered hook of the
+// same or an earlier stage and that dependencies contain no cycles
+func (m *Manager) Validate() error {
+	m.mu.RLock()
+	hooks := make([]namedHook, len(m.hooks))
+	copy(hooks, m.hooks)
+	m.mu.RUnlock()
+
+	stages := make(map[string]Stage, len(hooks))
+	for _, h := range hooks {
+		stages[h.name] = h.stage
+	}
+
+	var errs []error
+	for _, h := range hooks {
+		for _, dep := range h.after {
+			stage, ok := stages[dep]
+			if !ok {
+				errs = append(errs, fmt.Errorf("hook %q depends on unknown hook %q", h.name, dep))
+			} else if stage > h.stage {
+				errs = append(errs, fmt.Errorf("hook %q depends on hook %q of a later stage", h.name, dep))
+			}
+		}
+	}
+
+	for _, group := range groupByStage(hooks) {
+		if _, err := orderHooks(group.hooks); err != nil {
+			errs = append(errs, fmt.Errorf("stage %d: %w", group.stage, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// orderHooks returns the hooks of a stage in dependency order. Among hooks
+// whose dependencies are satisfied the most recently registered one runs
+// first, so hooks without dependencies keep LIFO order.
+func orderHooks(hooks []namedHook) ([]namedHook, error) {
+	deps := stageDependencies(hooks)
+
+	done := make([]bool, len(hooks))
+	ordered := make([]namedHook, 0, len(hooks))
+
+	for len(ordered) < len(hooks) {
+		next := -1
+		for i := len(hooks) - 1; i >= 0; i-- {
+			if !done[i] && allDone(deps[i], done) {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			return nil, ErrDependencyCycle
+		}
+		done[next] = true
+		ordered = append(ordered, hooks[next])
+	}
+
+	return ordered, nil
+}
+
+// stageDependencies resolves the After names of each hook to the indexes of
+// hooks in the same stage; names outside the stage are ignored
+func stageDependencies(hooks []namedHook) [][]int {
+	byName := make(map[string][]int, len(hooks))
+	for i, h := range hooks {
+		byName[h.name] = append(byName[h.name], i)
+	}
+
+	deps := make([][]int, len(hooks))
+	for i, h := range hooks {
+		for _, name := range h.after {
+			for _, j := range byName[name] {
+				if j != i {
+					deps[i] = append(deps[i], j)
+				}
+			}
+		}
+	}
+	return deps
+}
+
+func allDone(indexes []int, done []bool) bool {
+	for _, i := range indexes {
+		if !done[i] {
+			return false
+		}
+	}
+	return true
+}
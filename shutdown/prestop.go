@@ -0,0 +1,147 @@
+package shutdown
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// State is the externally observable shutdown state
+type State string
+
+const (
+	StateRunning      State = "running"
+	StatePreStop      State = "prestop"
+	StateShuttingDown State = "shutting_down"
+	StateStopped      State = "stopped"
+)
+
+// PreStopConfig configures the Kubernetes preStop coordinator
+type PreStopConfig struct {
+	// EndpointRemovalDelay is how long to wait after the preStop hook fired
+	// for endpoint controllers and load balancers to drop the pod
+	EndpointRemovalDelay time.Duration
+	// StateFile, if set, receives the current state on every transition so
+	// it can be inspected with an exec probe or a sidecar
+	StateFile string
+}
+
+// PreStop coordinates the Kubernetes preStop hook with shutdown. The preStop
+// hook calls Handler (httpGet) or Fire; shutdown hooks are then delayed by
+// WaitForPreStop until endpoints have had time to be removed.
+type PreStop struct {
+	cfg     PreStopConfig
+	mu      sync.RWMutex
+	state   State
+	firedAt time.Time
+	fired   chan struct{}
+	once    sync.Once
+}
+
+// NewPreStop creates a preStop coordinator
+func NewPreStop(cfg PreStopConfig) *PreStop {
+	if cfg.EndpointRemovalDelay == 0 {
+		cfg.EndpointRemovalDelay = 5 * time.Second
+	}
+	p := &PreStop{
+		cfg:   cfg,
+		fired: make(chan struct{}),
+	}
+	p.setState(StateRunning)
+	return p
+}
+
+// Fire records that the preStop hook was invoked
+func (p *PreStop) Fire() {
+	p.once.Do(func() {
+		p.mu.Lock()
+		p.firedAt = time.Now()
+		p.mu.Unlock()
+		p.setState(StatePreStop)
+		close(p.fired)
+	})
+}
+
+// State returns the current shutdown state
+func (p *PreStop) State() State {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.state
+}
+
+// WaitForPreStop blocks until the preStop hook has fired and the endpoint
+// removal delay has elapsed since, or until ctx is done
+func (p *PreStop) WaitForPreStop(ctx context.Context) error {
+	select {
+	case <-p.fired:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	p.mu.RLock()
+	remaining := p.cfg.EndpointRemovalDelay - time.Since(p.firedAt)
+	p.mu.RUnlock()
+	if remaining <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Handler is the target of a preStop httpGet hook. It fires the coordinator
+// and responds once endpoints have had time to be removed, which keeps
+// Kubernetes from sending SIGTERM too early.
+func (p *PreStop) Handler(c *fiber.Ctx) error {
+	p.Fire()
+	if err := p.WaitForPreStop(c.UserContext()); err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"state": p.State(), "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"state": p.State()})
+}
+
+// StateHandler exposes the current shutdown state
+func (p *PreStop) StateHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"state": p.State()})
+}
+
+// RegisterRoutes registers the preStop and state endpoints
+func (p *PreStop) RegisterRoutes(app *fiber.App) {
+	app.Get("/prestop", p.Handler)
+	app.Get("/shutdown/state", p.StateHandler)
+}
+
+// setState updates the state and mirrors it to the state file
+func (p *PreStop) setState(state State) {
+	p.mu.Lock()
+	p.state = state
+	p.mu.Unlock()
+
+	if p.cfg.StateFile != "" {
+		_ = os.WriteFile(p.cfg.StateFile, []byte(state), 0o644)
+	}
+}
+
+// WithPreStop replaces the fixed pre-shutdown delay with preStop coordination:
+// shutdown waits (at most the pre-shutdown delay) for the preStop hook and
+// endpoint removal, and the coordinator state follows the shutdown progress
+func (m *HealthAwareManager) WithPreStop(p *PreStop) *HealthAwareManager {
+	m.preStop = p
+
+	go func() {
+		<-m.Done()
+		p.setState(StateStopped)
+	}()
+
+	return m
+}
@@ -0,0 +1,99 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/minisource/go-common/logging"
+)
+
+// WithReloadSignals sets the signals that trigger reload hooks (default SIGHUP)
+func WithReloadSignals(signals ...os.Signal) Option {
+	return func(m *Manager) {
+		m.reloadSignals = signals
+	}
+}
+
+// OnReload registers a hook run on reload signals (config reload, log level
+// refresh, TLS certificate reload) without terminating the process
+func (m *Manager) OnReload(name string, fn Hook) {
+	m.mu.Lock()
+	m.reloadHooks = append(m.reloadHooks, namedHook{name: name, fn: fn})
+	listen := m.started && !m.reloadListening
+	if listen {
+		m.reloadListening = true
+	}
+	m.mu.Unlock()
+
+	if listen {
+		m.listenReload()
+	}
+}
+
+// Reload runs all reload hooks in registration order and returns the joined
+// errors of the hooks that failed
+func (m *Manager) Reload() error {
+	m.mu.RLock()
+	hooks := make([]namedHook, len(m.reloadHooks))
+	copy(hooks, m.reloadHooks)
+	m.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	var errs []error
+	for _, hook := range hooks {
+		if res := m.runReloadHook(ctx, hook); res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Name, res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// listenReload starts the reload signal loop; it stops once shutdown completes
+func (m *Manager) listenReload() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, m.reloadSignals...)
+
+	go func() {
+		defer signal.Stop(sigChan)
+		for {
+			select {
+			case <-sigChan:
+				_ = m.Reload()
+			case <-m.done:
+				return
+			}
+		}
+	}()
+}
+
+// runReloadHook executes a reload hook and logs its outcome
+func (m *Manager) runReloadHook(ctx context.Context, hook namedHook) HookResult {
+	err := callHook(ctx, hook)
+	res := newHookResult(hook.name, 0, err)
+
+	if m.logger != nil {
+		if err != nil {
+			m.logger.Error(logging.General, logging.Reload, "Reload hook failed", map[logging.ExtraKey]interface{}{
+				"hook":  hook.name,
+				"error": err.Error(),
+			})
+		} else {
+			m.logger.Info(logging.General, logging.Reload, "Reload hook completed", map[logging.ExtraKey]interface{}{
+				"hook": hook.name,
+			})
+		}
+	}
+
+	return res
+}
+
+// defaultReloadSignals returns the signals used when none are configured
+func defaultReloadSignals() []os.Signal {
+	return []os.Signal{syscall.SIGHUP}
+}
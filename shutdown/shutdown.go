@@ -2,6 +2,8 @@ package shutdown
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
 	"sync"
@@ -9,6 +11,8 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/health"
+	"github.com/minisource/go-common/logging"
 )
 
 // Hook represents a shutdown hook function
@@ -22,19 +26,80 @@ type Manager struct {
 	signals []os.Signal
 	done    chan struct{}
 	started bool
+
+	shutdownOnce sync.Once
+	onStart      []func()
+	logger       logging.Logger
+	result       *Result
+
+	drainer   *Drainer
+	drainWait time.Duration
+
+	reloadHooks     []namedHook
+	reloadSignals   []os.Signal
+	reloadListening bool
 }
 
 type namedHook struct {
-	name string
-	fn   Hook
+	name    string
+	fn      Hook
+	stage   Stage
+	timeout time.Duration
+	after   []string
+}
+
+// HookResult describes the outcome of a single shutdown hook
+type HookResult struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	Err      error         `json:"-"`
+	// Error is the message of Err, kept for the serialized report
+	Error string `json:"error,omitempty"`
+}
+
+func newHookResult(name string, duration time.Duration, err error) HookResult {
+	res := HookResult{Name: name, Duration: duration, Err: err}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// Result is the aggregated report of a shutdown run
+type Result struct {
+	Hooks    []HookResult  `json:"hooks"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Failed returns the hooks that returned an error
+func (r *Result) Failed() []HookResult {
+	failed := make([]HookResult, 0)
+	for _, h := range r.Hooks {
+		if h.Err != nil {
+			failed = append(failed, h)
+		}
+	}
+	return failed
+}
+
+// Err joins the errors of all failed hooks, or returns nil
+func (r *Result) Err() error {
+	var errs []error
+	for _, h := range r.Hooks {
+		if h.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.Name, h.Err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // NewManager creates a new shutdown manager
 func NewManager(opts ...Option) *Manager {
 	m := &Manager{
-		timeout: 30 * time.Second,
-		signals: []os.Signal{syscall.SIGINT, syscall.SIGTERM},
-		done:    make(chan struct{}),
+		timeout:       30 * time.Second,
+		signals:       []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		reloadSignals: defaultReloadSignals(),
+		done:          make(chan struct{}),
 	}
 
 	for _, opt := range opts {
@@ -54,6 +119,13 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithLogger sets the logger used to report hook progress and failures
+func WithLogger(logger logging.Logger) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
+
 // WithSignals sets the signals to listen for
 func WithSignals(signals ...os.Signal) Option {
 	return func(m *Manager) {
@@ -62,25 +134,40 @@ func WithSignals(signals ...os.Signal) Option {
 }
 
 // AddHook adds a shutdown hook with a name
-func (m *Manager) AddHook(name string, hook Hook) {
+func (m *Manager) AddHook(name string, hook Hook, opts ...HookOption) {
+	h := namedHook{name: name, fn: hook, stage: StageDefault}
+	for _, opt := range opts {
+		opt(&h)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hooks = append(m.hooks, h)
+}
+
+// OnShutdownStart registers a callback that runs when shutdown begins,
+// before any hook is executed
+func (m *Manager) OnShutdownStart(fn func()) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.hooks = append(m.hooks, namedHook{name: name, fn: hook})
+	m.onStart = append(m.onStart, fn)
 }
 
-// AddFiberApp adds a Fiber app for graceful shutdown
+// AddFiberApp adds a Fiber app for graceful shutdown. Apps are stopped in
+// StageServers, before any other resource is released.
 func (m *Manager) AddFiberApp(name string, app *fiber.App) {
 	m.AddHook(name, func(ctx context.Context) error {
 		return app.ShutdownWithContext(ctx)
-	})
+	}, InStage(StageServers))
 }
 
 // AddCloseFunc adds a close function as a hook
-func (m *Manager) AddCloseFunc(name string, fn func() error) {
+func (m *Manager) AddCloseFunc(name string, fn func() error, opts ...HookOption) {
 	m.AddHook(name, func(ctx context.Context) error {
 		return fn()
-	})
+	}, opts...)
 }
 
 // Wait blocks until shutdown is triggered
@@ -93,52 +180,167 @@ func (m *Manager) Done() <-chan struct{} {
 	return m.done
 }
 
+// Result returns the report of the last shutdown, or nil if shutdown has
+// not completed yet
+func (m *Manager) Result() *Result {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.result
+}
+
 // Start begins listening for shutdown signals
 // Returns a function to trigger manual shutdown
 func (m *Manager) Start() func() {
+	return m.StartWithContext(context.Background())
+}
+
+// StartWithContext begins listening for shutdown signals; cancelling ctx
+// triggers shutdown as well. Returns a function to trigger manual shutdown.
+func (m *Manager) StartWithContext(ctx context.Context) func() {
 	m.mu.Lock()
 	if m.started {
 		m.mu.Unlock()
-		return func() {}
+		return m.shutdown
 	}
 	m.started = true
+	listenReload := len(m.reloadHooks) > 0
+	m.reloadListening = listenReload
 	m.mu.Unlock()
 
+	// Reload signals are only captured once a reload hook exists, so the
+	// default behavior of SIGHUP is kept for services that don't use it
+	if listenReload {
+		m.listenReload()
+	}
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, m.signals...)
 
 	go func() {
-		<-sigChan
+		defer signal.Stop(sigChan)
+		select {
+		case <-sigChan:
+		case <-ctx.Done():
+		case <-m.done:
+			return
+		}
 		m.shutdown()
 	}()
 
-	return func() {
-		m.shutdown()
+	return m.shutdown
+}
+
+// Shutdown triggers shutdown and blocks until all hooks have finished or
+// ctx is done. It is safe to call concurrently and more than once.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	go m.shutdown()
+
+	select {
+	case <-m.done:
+		return m.Result().Err()
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
-// shutdown executes all hooks in reverse order
+// shutdown runs the shutdown sequence exactly once; concurrent callers block
+// until it has completed
 func (m *Manager) shutdown() {
+	m.shutdownOnce.Do(m.runShutdown)
+}
+
+// runShutdown executes all hooks stage by stage in dependency order
+func (m *Manager) runShutdown() {
 	m.mu.RLock()
 	hooks := make([]namedHook, len(m.hooks))
 	copy(hooks, m.hooks)
+	onStart := make([]func(), len(m.onStart))
+	copy(onStart, m.onStart)
 	m.mu.RUnlock()
 
+	for _, fn := range onStart {
+		fn()
+	}
+
+	start := time.Now()
+	result := &Result{Hooks: make([]HookResult, 0, len(hooks)+1)}
+
+	// Let in-flight requests finish before servers and resources go away
+	if m.drainer != nil {
+		result.Hooks = append(result.Hooks, m.drain())
+	}
+
+	// The hook timeout starts after draining, which has its own budget
 	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
 	defer cancel()
 
-	// Execute hooks in reverse order (LIFO)
-	for i := len(hooks) - 1; i >= 0; i-- {
-		hook := hooks[i]
-		if err := hook.fn(ctx); err != nil {
-			// Log error but continue with other hooks
-			continue
-		}
+	// Execute stages in order; keep going on error so one failing resource
+	// doesn't leak the rest
+	for _, group := range groupByStage(hooks) {
+		result.Hooks = append(result.Hooks, m.runStage(ctx, group)...)
 	}
 
+	result.Duration = time.Since(start)
+	m.logResult(result)
+
+	m.mu.Lock()
+	m.result = result
+	m.mu.Unlock()
+
 	close(m.done)
 }
 
+// runHook executes a single hook and reports its outcome
+func (m *Manager) runHook(ctx context.Context, hook namedHook) HookResult {
+	if m.logger != nil {
+		m.logger.Info(logging.General, logging.Shutdown, "Running shutdown hook", map[logging.ExtraKey]interface{}{
+			"hook": hook.name,
+		})
+	}
+
+	start := time.Now()
+	err := callHook(ctx, hook)
+	res := newHookResult(hook.name, time.Since(start), err)
+
+	if m.logger != nil {
+		if err != nil {
+			m.logger.Error(logging.General, logging.Shutdown, "Shutdown hook failed", map[logging.ExtraKey]interface{}{
+				"hook":     hook.name,
+				"duration": res.Duration.String(),
+				"error":    err.Error(),
+			})
+		} else {
+			m.logger.Info(logging.General, logging.Shutdown, "Shutdown hook completed", map[logging.ExtraKey]interface{}{
+				"hook":     hook.name,
+				"duration": res.Duration.String(),
+			})
+		}
+	}
+
+	return res
+}
+
+// logResult logs the aggregated shutdown report
+func (m *Manager) logResult(result *Result) {
+	if m.logger == nil {
+		return
+	}
+
+	failed := result.Failed()
+	extra := map[logging.ExtraKey]interface{}{
+		"hooks":    len(result.Hooks),
+		"failed":   len(failed),
+		"duration": result.Duration.String(),
+	}
+
+	if len(failed) > 0 {
+		extra["error"] = result.Err().Error()
+		m.logger.Warn(logging.General, logging.Shutdown, "Shutdown completed with errors", extra)
+		return
+	}
+	m.logger.Info(logging.General, logging.Shutdown, "Shutdown completed", extra)
+}
+
 // ============================================
 // Convenience Functions
 // ============================================
@@ -147,13 +349,13 @@ func (m *Manager) shutdown() {
 var defaultManager = NewManager()
 
 // Add adds a hook to the default manager
-func Add(name string, hook Hook) {
-	defaultManager.AddHook(name, hook)
+func Add(name string, hook Hook, opts ...HookOption) {
+	defaultManager.AddHook(name, hook, opts...)
 }
 
 // AddCloseFunc adds a close function to the default manager
-func AddClose(name string, fn func() error) {
-	defaultManager.AddCloseFunc(name, fn)
+func AddClose(name string, fn func() error, opts ...HookOption) {
+	defaultManager.AddCloseFunc(name, fn, opts...)
 }
 
 // AddFiber adds a Fiber app to the default manager
@@ -181,17 +383,25 @@ type HealthAwareManager struct {
 	healthCheckInterval time.Duration
 	preShutdownDelay    time.Duration
 	isHealthy           bool
+	shuttingDown        bool
+	preStop             *PreStop
 	mu                  sync.RWMutex
 }
 
 // NewHealthAwareManager creates a health-aware shutdown manager
 func NewHealthAwareManager(opts ...Option) *HealthAwareManager {
-	return &HealthAwareManager{
+	m := &HealthAwareManager{
 		Manager:             NewManager(opts...),
 		healthCheckInterval: 5 * time.Second,
 		preShutdownDelay:    5 * time.Second,
 		isHealthy:           true,
 	}
+
+	// Flip readiness and give load balancers time to react, whether shutdown
+	// was triggered by a signal or by GracefulShutdown
+	m.OnShutdownStart(m.beginShutdown)
+
+	return m
 }
 
 // SetHealthy sets the health status
@@ -208,6 +418,13 @@ func (m *HealthAwareManager) IsHealthy() bool {
 	return m.isHealthy
 }
 
+// IsShuttingDown reports whether shutdown has begun
+func (m *HealthAwareManager) IsShuttingDown() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.shuttingDown
+}
+
 // WithPreShutdownDelay sets delay before starting shutdown hooks
 // This allows load balancers to stop sending traffic
 func (m *HealthAwareManager) WithPreShutdownDelay(d time.Duration) *HealthAwareManager {
@@ -215,14 +432,44 @@ func (m *HealthAwareManager) WithPreShutdownDelay(d time.Duration) *HealthAwareM
 	return m
 }
 
+// BindHealthService wires the manager into a health service: readiness fails
+// as soon as shutdown begins, and IsHealthy follows the readiness status
+func (m *HealthAwareManager) BindHealthService(hs *health.HealthService) {
+	hs.RegisterChecker(health.NewCustomChecker("shutdown", func(ctx context.Context) error {
+		if m.IsShuttingDown() {
+			return errors.New("shutdown in progress")
+		}
+		return nil
+	}))
+
+	hs.OnStatusChange(func(old, new health.Status) {
+		if m.IsShuttingDown() {
+			return
+		}
+		m.SetHealthy(new != health.StatusUnhealthy)
+	})
+}
+
 // GracefulShutdown performs health-aware graceful shutdown
 func (m *HealthAwareManager) GracefulShutdown() {
-	// Mark as unhealthy first
-	m.SetHealthy(false)
+	m.shutdown()
+}
 
-	// Wait for load balancers to stop traffic
-	time.Sleep(m.preShutdownDelay)
+// beginShutdown marks the manager as unhealthy and waits for load balancers
+// to stop sending traffic
+func (m *HealthAwareManager) beginShutdown() {
+	m.mu.Lock()
+	m.shuttingDown = true
+	m.isHealthy = false
+	m.mu.Unlock()
 
-	// Then proceed with normal shutdown
-	m.shutdown()
+	if m.preStop == nil {
+		time.Sleep(m.preShutdownDelay)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.preShutdownDelay)
+	defer cancel()
+	_ = m.preStop.WaitForPreStop(ctx)
+	m.preStop.setState(StateShuttingDown)
 }
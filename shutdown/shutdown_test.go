@@ -0,0 +1,279 @@
+package shutdown
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordingHook(mu *sync.Mutex, order *[]string, name string) Hook {
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		*order = append(*order, name)
+		return nil
+	}
+}
+
+func TestShutdownLIFOOrder(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+
+	m := NewManager()
+	m.AddHook("first", recordingHook(&mu, &order, "first"))
+	m.AddHook("second", recordingHook(&mu, &order, "second"))
+	m.AddHook("third", recordingHook(&mu, &order, "third"))
+
+	m.Start()()
+	m.Wait()
+
+	assert.Equal(t, []string{"third", "second", "first"}, order)
+}
+
+func TestShutdownDependencyOrder(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+
+	m := NewManager()
+	m.AddHook("db", recordingHook(&mu, &order, "db"), After("consumer"))
+	m.AddHook("cache", recordingHook(&mu, &order, "cache"))
+	m.AddHook("consumer", recordingHook(&mu, &order, "consumer"))
+	m.AddHook("http-server", recordingHook(&mu, &order, "http-server"), InStage(StageServers))
+
+	assert.NoError(t, m.Validate())
+
+	m.Start()()
+	m.Wait()
+
+	assert.Equal(t, []string{"http-server", "consumer", "cache", "db"}, order)
+}
+
+func TestValidateReportsInvalidDependencies(t *testing.T) {
+	m := NewManager()
+	m.AddHook("a", func(ctx context.Context) error { return nil }, After("b"))
+	m.AddHook("b", func(ctx context.Context) error { return nil }, After("a"))
+	m.AddHook("c", func(ctx context.Context) error { return nil }, After("missing"))
+
+	err := m.Validate()
+
+	assert.ErrorIs(t, err, ErrDependencyCycle)
+	assert.Contains(t, err.Error(), "missing")
+}
+
+func TestShutdownResultAndHookTimeout(t *testing.T) {
+	m := NewManager()
+	m.AddHook("failing", func(ctx context.Context) error { return errors.New("close failed") })
+	m.AddHookWithTimeout("hung", 20*time.Millisecond, func(ctx context.Context) error {
+		select {}
+	})
+	m.AddHook("ok", func(ctx context.Context) error { return nil })
+
+	assert.Nil(t, m.Result())
+
+	m.Start()()
+	m.Wait()
+
+	result := m.Result()
+	assert.Len(t, result.Hooks, 3)
+	assert.Len(t, result.Failed(), 2)
+	assert.Contains(t, result.Err().Error(), "close failed")
+	assert.Contains(t, result.Err().Error(), "hung")
+
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+	var report struct {
+		Hooks []map[string]interface{} `json:"hooks"`
+	}
+	require.NoError(t, json.Unmarshal(data, &report))
+	errs := map[string]interface{}{}
+	for _, h := range report.Hooks {
+		errs[h["name"].(string)] = h["error"]
+	}
+	assert.Equal(t, "close failed", errs["failing"])
+	assert.Contains(t, errs["hung"], "deadline exceeded")
+	assert.Nil(t, errs["ok"], "successful hooks omit the error")
+}
+
+func TestShutdownIsIdempotent(t *testing.T) {
+	calls := 0
+	m := NewManager()
+	m.AddHook("counter", func(ctx context.Context) error {
+		calls++
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	trigger := m.StartWithContext(ctx)
+	cancel()
+	trigger()
+
+	assert.NoError(t, m.Shutdown(context.Background()))
+	assert.Equal(t, 1, calls)
+}
+
+func TestDrainDoesNotConsumeHookTimeout(t *testing.T) {
+	d := NewDrainer()
+	d.pollInterval = 5 * time.Millisecond
+	d.inFlight.Add(1)
+	time.AfterFunc(80*time.Millisecond, func() { d.inFlight.Add(-1) })
+
+	m := NewManager(WithTimeout(100*time.Millisecond), WithDrainer(d, time.Second))
+	m.AddHook("slow", func(ctx context.Context) error {
+		select {
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	m.Start()()
+	m.Wait()
+
+	assert.NoError(t, m.Result().Err())
+	assert.Greater(t, m.Result().Duration, 100*time.Millisecond)
+}
+
+// messageLogger records the messages of Info, Warn and Error calls
+type messageLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *messageLogger) record(msg string, extra map[logging.ExtraKey]interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if hook, ok := extra["hook"]; ok {
+		msg += ": " + hook.(string)
+	}
+	l.messages = append(l.messages, msg)
+}
+
+func (l *messageLogger) Init() {}
+func (l *messageLogger) Debug(logging.Category, logging.SubCategory, string, map[logging.ExtraKey]interface{}) {
+}
+func (l *messageLogger) Debugf(string, ...interface{}) {}
+func (l *messageLogger) Info(_ logging.Category, _ logging.SubCategory, msg string, extra map[logging.ExtraKey]interface{}) {
+	l.record(msg, extra)
+}
+func (l *messageLogger) Infof(string, ...interface{}) {}
+func (l *messageLogger) Warn(_ logging.Category, _ logging.SubCategory, msg string, extra map[logging.ExtraKey]interface{}) {
+	l.record(msg, extra)
+}
+func (l *messageLogger) Warnf(string, ...interface{}) {}
+func (l *messageLogger) Error(_ logging.Category, _ logging.SubCategory, msg string, extra map[logging.ExtraKey]interface{}) {
+	l.record(msg, extra)
+}
+func (l *messageLogger) Errorf(string, ...interface{}) {}
+func (l *messageLogger) Fatal(logging.Category, logging.SubCategory, string, map[logging.ExtraKey]interface{}) {
+}
+func (l *messageLogger) Fatalf(string, ...interface{}) {}
+
+func TestShutdownLogsHooksAndReportsPanics(t *testing.T) {
+	logger := &messageLogger{}
+	m := NewManager(WithLogger(logger))
+	m.AddHook("cache", func(ctx context.Context) error { return nil })
+	m.AddHook("db", func(ctx context.Context) error { panic("boom") })
+
+	err := m.Shutdown(context.Background())
+
+	assert.ErrorContains(t, err, "db: panic: boom")
+	assert.Len(t, m.Result().Failed(), 1)
+	assert.Subset(t, logger.messages, []string{
+		"Running shutdown hook: db",
+		"Shutdown hook failed: db",
+		"Running shutdown hook: cache",
+		"Shutdown hook completed: cache",
+	})
+}
+
+func TestStagesRunInOrderWithParallelHooks(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	var running, peak int
+	server := func(name string) Hook {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+			time.Sleep(30 * time.Millisecond)
+			mu.Lock()
+			running--
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	m := NewManager()
+	m.AddHook("db", recordingHook(&mu, &order, "db"), InStage(StageResources))
+	m.AddHook("http", server("http"), InStage(StageServers))
+	m.AddHook("grpc", server("grpc"), InStage(StageServers))
+	m.AddHook("worker", recordingHook(&mu, &order, "worker"), InStage(StageConsumers))
+
+	assert.NoError(t, m.Shutdown(context.Background()))
+	assert.Equal(t, 2, peak, "hooks of a stage run in parallel")
+	assert.ElementsMatch(t, []string{"http", "grpc"}, order[:2])
+	assert.Equal(t, []string{"worker", "db"}, order[2:])
+}
+
+func TestReloadHooksRunOnSignal(t *testing.T) {
+	reloaded := make(chan struct{}, 1)
+	m := NewManager(WithReloadSignals(syscall.SIGUSR1))
+	m.OnReload("config", func(ctx context.Context) error {
+		reloaded <- struct{}{}
+		return nil
+	})
+	m.OnReload("certs", func(ctx context.Context) error { return errors.New("bad pem") })
+	trigger := m.Start()
+	defer func() {
+		trigger()
+		m.Wait()
+	}()
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	select {
+	case <-reloaded:
+	case <-time.After(2 * time.Second):
+		t.Fatal("reload hook did not run")
+	}
+	assert.Nil(t, m.Result(), "reloading doesn't shut down")
+
+	assert.ErrorContains(t, m.Reload(), "certs: bad pem")
+}
+
+func TestPreStopWaitsForEndpointRemoval(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state")
+	p := NewPreStop(PreStopConfig{EndpointRemovalDelay: 50 * time.Millisecond, StateFile: stateFile})
+	assert.Equal(t, StateRunning, p.State())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.WaitForPreStop(ctx), context.DeadlineExceeded, "waits for the hook to fire")
+
+	app := fiber.New()
+	p.RegisterRoutes(app)
+	start := time.Now()
+	resp, err := app.Test(httptest.NewRequest("GET", "/prestop", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	assert.Equal(t, StatePreStop, p.State())
+	data, err := os.ReadFile(stateFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(StatePreStop), string(data))
+}
@@ -0,0 +1,155 @@
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/minisource/go-common/logging"
+)
+
+// Stage groups hooks that are shut down together. Stages run in ascending
+// order; hooks within a stage run in parallel, except StageDefault which keeps
+// the sequential LIFO order of plain AddHook registrations.
+type Stage int
+
+const (
+	// StageServers stops accepting traffic (HTTP/gRPC servers)
+	StageServers Stage = 100
+	// StageConsumers stops background consumers and workers
+	StageConsumers Stage = 200
+	// StageDefault holds hooks registered without an explicit stage
+	StageDefault Stage = 300
+	// StageResources closes databases, caches and other shared resources
+	StageResources Stage = 400
+)
+
+// HookOption configures a shutdown hook
+type HookOption func(*namedHook)
+
+// InStage assigns the hook to a shutdown stage
+func InStage(stage Stage) HookOption {
+	return func(h *namedHook) {
+		h.stage = stage
+	}
+}
+
+// WithHookTimeout limits how long a single hook may run
+func WithHookTimeout(timeout time.Duration) HookOption {
+	return func(h *namedHook) {
+		h.timeout = timeout
+	}
+}
+
+// AddHookWithTimeout adds a shutdown hook that is abandoned after timeout
+func (m *Manager) AddHookWithTimeout(name string, timeout time.Duration, hook Hook, opts ...HookOption) {
+	m.AddHook(name, hook, append([]HookOption{WithHookTimeout(timeout)}, opts...)...)
+}
+
+// stageGroup is the set of hooks belonging to one stage
+type stageGroup struct {
+	stage Stage
+	hooks []namedHook
+}
+
+// groupByStage splits hooks into stages ordered for execution, keeping
+// registration order within each stage
+func groupByStage(hooks []namedHook) []stageGroup {
+	byStage := make(map[Stage][]namedHook)
+	for _, h := range hooks {
+		byStage[h.stage] = append(byStage[h.stage], h)
+	}
+
+	groups := make([]stageGroup, 0, len(byStage))
+	for stage, list := range byStage {
+		groups = append(groups, stageGroup{stage: stage, hooks: list})
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].stage < groups[j].stage
+	})
+	return groups
+}
+
+// runStage executes the hooks of a stage. StageDefault runs sequentially in
+// dependency order; other stages run in parallel, each hook waiting only for
+// the hooks it declared with After. Cyclic dependencies are logged and ignored.
+func (m *Manager) runStage(ctx context.Context, group stageGroup) []HookResult {
+	if _, err := orderHooks(group.hooks); err != nil {
+		m.logCycle(group)
+		for i := range group.hooks {
+			group.hooks[i].after = nil
+		}
+	}
+
+	if group.stage == StageDefault {
+		ordered, _ := orderHooks(group.hooks)
+		results := make([]HookResult, len(ordered))
+		for i, h := range ordered {
+			results[i] = m.runHook(ctx, h)
+		}
+		return results
+	}
+
+	results := make([]HookResult, len(group.hooks))
+	deps := stageDependencies(group.hooks)
+	finished := make([]chan struct{}, len(group.hooks))
+	for i := range finished {
+		finished[i] = make(chan struct{})
+	}
+
+	var wg sync.WaitGroup
+	for i, h := range group.hooks {
+		wg.Add(1)
+		go func(idx int, hook namedHook) {
+			defer wg.Done()
+			defer close(finished[idx])
+			for _, dep := range deps[idx] {
+				<-finished[dep]
+			}
+			results[idx] = m.runHook(ctx, hook)
+		}(i, h)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// logCycle reports a dependency cycle that forced a fallback ordering
+func (m *Manager) logCycle(group stageGroup) {
+	if m.logger == nil {
+		return
+	}
+	m.logger.Warn(logging.General, logging.Shutdown, "Shutdown hook dependency cycle, ignoring dependencies", map[logging.ExtraKey]interface{}{
+		"stage": int(group.stage),
+	})
+}
+
+// callHook runs the hook function honoring its own timeout. A hook that
+// ignores cancellation is abandoned so it cannot consume the whole budget.
+func callHook(ctx context.Context, hook namedHook) (err error) {
+	if hook.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hook.timeout)
+		defer cancel()
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errCh <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		errCh <- hook.fn(ctx)
+	}()
+
+	select {
+	case err = <-errCh:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("hook timed out: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,148 @@
+package sse
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend stores the recent events of each channel and delivers new ones
+type Backend interface {
+	// Publish appends ev to channel and returns the ID it was given
+	Publish(ctx context.Context, channel string, ev Event) (string, error)
+	// History returns the latest n events of channel, oldest first; with
+	// after set, only events that follow it
+	History(ctx context.Context, channel, after string, n int) ([]Event, error)
+	// Listen calls fn with every event appended to channel after the given
+	// ID, in order, until ctx is done or listening fails
+	Listen(ctx context.Context, channel, after string, fn func(Event)) error
+}
+
+// compareIDs orders event IDs of the form "<ms>-<seq>" used by Redis
+// streams; unparsable IDs sort first
+func compareIDs(a, b string) int {
+	ams, aseq := parseID(a)
+	bms, bseq := parseID(b)
+	if ams != bms {
+		return cmp.Compare(ams, bms)
+	}
+	return cmp.Compare(aseq, bseq)
+}
+
+func parseID(id string) (uint64, uint64) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ := strconv.ParseUint(msPart, 10, 64)
+	seq, _ := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq
+}
+
+// ============================================
+// Memory backend
+// ============================================
+
+// MemoryBackend keeps events in process, for single replica deployments
+// and tests
+type MemoryBackend struct {
+	historySize int
+
+	mu       sync.Mutex
+	channels map[string]*memoryChannel
+	lastMS   uint64
+	seq      uint64
+}
+
+type memoryChannel struct {
+	events []Event
+	notify chan struct{}
+}
+
+// NewMemoryBackend creates an in-memory backend keeping the last
+// historySize events per channel (default 1000)
+func NewMemoryBackend(historySize int) *MemoryBackend {
+	if historySize <= 0 {
+		historySize = 1000
+	}
+	return &MemoryBackend{historySize: historySize, channels: make(map[string]*memoryChannel)}
+}
+
+// Publish implements Backend
+func (b *MemoryBackend) Publish(ctx context.Context, channel string, ev Event) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ms := uint64(time.Now().UnixMilli())
+	if ms <= b.lastMS {
+		b.seq++
+	} else {
+		b.lastMS, b.seq = ms, 0
+	}
+	ev.ID = fmt.Sprintf("%d-%d", b.lastMS, b.seq)
+
+	ch := b.channel(channel)
+	ch.events = append(ch.events, ev)
+	if len(ch.events) > b.historySize {
+		ch.events = append([]Event(nil), ch.events[len(ch.events)-b.historySize:]...)
+	}
+	close(ch.notify)
+	ch.notify = make(chan struct{})
+	return ev.ID, nil
+}
+
+// History implements Backend
+func (b *MemoryBackend) History(ctx context.Context, channel, after string, n int) ([]Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	events := eventsAfter(b.channel(channel).events, after)
+	if len(events) > n {
+		events = events[len(events)-n:]
+	}
+	return append([]Event(nil), events...), nil
+}
+
+// Listen implements Backend
+func (b *MemoryBackend) Listen(ctx context.Context, channel, after string, fn func(Event)) error {
+	for {
+		b.mu.Lock()
+		ch := b.channel(channel)
+		events := append([]Event(nil), eventsAfter(ch.events, after)...)
+		notify := ch.notify
+		b.mu.Unlock()
+
+		for _, ev := range events {
+			fn(ev)
+			after = ev.ID
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+		}
+	}
+}
+
+func (b *MemoryBackend) channel(name string) *memoryChannel {
+	ch, ok := b.channels[name]
+	if !ok {
+		ch = &memoryChannel{notify: make(chan struct{})}
+		b.channels[name] = ch
+	}
+	return ch
+}
+
+// eventsAfter returns the events following the one with ID after
+func eventsAfter(events []Event, after string) []Event {
+	if after == "" {
+		return events
+	}
+	for i, ev := range events {
+		if compareIDs(ev.ID, after) > 0 {
+			return events[i:]
+		}
+	}
+	return nil
+}
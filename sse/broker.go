@@ -0,0 +1,391 @@
+package sse
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/metrics"
+	"github.com/minisource/go-common/response"
+)
+
+// ErrClosed is returned when subscribing to a closed broker
+var ErrClosed = errors.New("sse: broker closed")
+
+// Event is a server-sent event
+type Event struct {
+	// ID is assigned on publish; clients send the last one they saw as
+	// Last-Event-ID when they reconnect
+	ID string
+	// Type is the event name (default: "message")
+	Type string
+	Data []byte
+	// TenantID and UserID, when set, restrict the event to subscribers of
+	// that tenant or user; they are not sent to clients
+	TenantID string
+	UserID   string
+}
+
+// JSON returns an event of type eventType with v encoded as JSON data
+func JSON(eventType string, v interface{}) (Event, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Type: eventType, Data: data}, nil
+}
+
+// Subscriber describes a connected client, taken from the Fiber locals set
+// by the auth middleware
+type Subscriber struct {
+	Channel  string
+	UserID   string
+	TenantID string
+	Roles    []string
+}
+
+// Config configures a broker
+type Config struct {
+	// Name labels the broker's metrics and logs (default "default")
+	Name string
+	// Backend stores and delivers events (default: in-memory, for a single
+	// replica); use a RedisBackend to fan out across replicas
+	Backend Backend
+	// Replay is the number of recent events sent to new clients
+	Replay int
+	// MaxReplay caps the events resent to a client reconnecting with
+	// Last-Event-ID (default 1000)
+	MaxReplay int
+	// Heartbeat is how often a comment is sent to keep idle connections
+	// open through proxies (default 15s)
+	Heartbeat time.Duration
+	// Retry is the reconnection delay advertised to clients (default 3s)
+	Retry time.Duration
+	// Buffer is the number of events queued per client (default 64); a
+	// client that falls further behind is disconnected and replays the
+	// missed events when it reconnects
+	Buffer int
+	// AllowAnonymous accepts clients without the userId local set by the
+	// auth middleware
+	AllowAnonymous bool
+	// Filter, if set, further restricts which events a subscriber gets
+	Filter func(sub Subscriber, ev Event) bool
+	Logger logging.Logger
+}
+
+// DefaultConfig returns the default broker configuration
+func DefaultConfig() Config {
+	return Config{
+		Name:      "default",
+		MaxReplay: 1000,
+		Heartbeat: 15 * time.Second,
+		Retry:     3 * time.Second,
+		Buffer:    64,
+	}
+}
+
+// Broker streams the events published on a channel to the clients
+// subscribed to it, e.g. for live dashboards and progress feeds. Each
+// process listens to a channel once and fans events out to its clients
+type Broker struct {
+	cfg Config
+
+	mu       sync.Mutex
+	channels map[string]*channelState
+	closed   bool
+}
+
+type channelState struct {
+	subs   map[*subscriber]struct{}
+	cancel context.CancelFunc
+}
+
+type subscriber struct {
+	Subscriber
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (s *subscriber) close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// New creates a broker
+func New(cfg Config) *Broker {
+	defaults := DefaultConfig()
+	if cfg.Name == "" {
+		cfg.Name = defaults.Name
+	}
+	if cfg.Backend == nil {
+		cfg.Backend = NewMemoryBackend(0)
+	}
+	if cfg.MaxReplay <= 0 {
+		cfg.MaxReplay = defaults.MaxReplay
+	}
+	if cfg.Heartbeat <= 0 {
+		cfg.Heartbeat = defaults.Heartbeat
+	}
+	if cfg.Retry <= 0 {
+		cfg.Retry = defaults.Retry
+	}
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = defaults.Buffer
+	}
+	return &Broker{cfg: cfg, channels: make(map[string]*channelState)}
+}
+
+// Publish sends ev to the subscribers of channel on every replica and
+// returns its ID
+func (b *Broker) Publish(ctx context.Context, channel string, ev Event) (string, error) {
+	id, err := b.cfg.Backend.Publish(ctx, channel, ev)
+	if err != nil {
+		return "", fmt.Errorf("sse: failed to publish event: %w", err)
+	}
+	metrics.SSEEventsTotal.WithLabelValues(b.cfg.Name, "published").Inc()
+	return id, nil
+}
+
+// Stream subscribes the request to channel and streams its events until
+// the client disconnects or the broker closes. Call it from a Fiber
+// handler after the auth middleware:
+//
+//	app.Get("/orders/:id/events", func(c *fiber.Ctx) error {
+//	    return broker.Stream(c, "order:"+c.Params("id"))
+//	})
+func (b *Broker) Stream(c *fiber.Ctx, channel string) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" && !b.cfg.AllowAnonymous {
+		return response.Unauthorized(c, "Authentication required")
+	}
+	tenantID, _ := c.Locals("tenantId").(string)
+	roles, _ := c.Locals("roles").([]string)
+
+	sub, err := b.subscribe(c.UserContext(), Subscriber{
+		Channel:  channel,
+		UserID:   userID,
+		TenantID: tenantID,
+		Roles:    roles,
+	})
+	if err != nil {
+		return response.ServiceUnavailable(c, "Event stream unavailable")
+	}
+
+	// Registered before reading the history, so no event falls in between;
+	// events in both are sent once
+	lastID := c.Get("Last-Event-ID")
+	n := b.cfg.Replay
+	if lastID != "" {
+		n = b.cfg.MaxReplay
+	}
+	var history []Event
+	if n > 0 {
+		history, err = b.cfg.Backend.History(c.UserContext(), channel, lastID, n)
+		if err != nil {
+			b.log("Failed to read event history", channel, err)
+		}
+	}
+
+	c.Set("X-Accel-Buffering", "no")
+	return response.Stream(c, "text/event-stream", func(w *bufio.Writer) error {
+		defer b.unsubscribe(sub)
+		return b.write(w, sub, history)
+	})
+}
+
+// Close disconnects every client and stops listening
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for name, st := range b.channels {
+		st.cancel()
+		for sub := range st.subs {
+			sub.close()
+		}
+		delete(b.channels, name)
+	}
+	return nil
+}
+
+// write sends the history and then live events until the client goes away
+func (b *Broker) write(w *bufio.Writer, sub *subscriber, history []Event) error {
+	fmt.Fprintf(w, "retry: %d\n\n", b.cfg.Retry.Milliseconds())
+	last := ""
+	for _, ev := range history {
+		if b.allowed(sub.Subscriber, ev) {
+			writeEvent(w, ev)
+		}
+		last = ev.ID
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(b.cfg.Heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case ev := <-sub.events:
+			if last != "" && compareIDs(ev.ID, last) <= 0 {
+				continue
+			}
+			writeEvent(w, ev)
+			last = ev.ID
+			metrics.SSEEventsTotal.WithLabelValues(b.cfg.Name, "sent").Inc()
+		case <-ticker.C:
+			_, _ = w.WriteString(": ping\n\n")
+		case <-sub.done:
+			return nil
+		}
+		// A failed flush means the client is gone
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// writeEvent writes ev in the text/event-stream format
+func writeEvent(w *bufio.Writer, ev Event) {
+	_, _ = w.WriteString("id: " + ev.ID + "\n")
+	if ev.Type != "" {
+		_, _ = w.WriteString("event: " + ev.Type + "\n")
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(string(ev.Data), "\r\n", "\n"), "\n") {
+		_, _ = w.WriteString("data: " + line + "\n")
+	}
+	_, _ = w.WriteString("\n")
+}
+
+// allowed reports whether sub may receive ev
+func (b *Broker) allowed(sub Subscriber, ev Event) bool {
+	if ev.TenantID != "" && ev.TenantID != sub.TenantID {
+		return false
+	}
+	if ev.UserID != "" && ev.UserID != sub.UserID {
+		return false
+	}
+	return b.cfg.Filter == nil || b.cfg.Filter(sub, ev)
+}
+
+// subscribe registers a client, listening to its channel if this process
+// doesn't already
+func (b *Broker) subscribe(ctx context.Context, info Subscriber) (*subscriber, error) {
+	b.mu.Lock()
+	_, listening := b.channels[info.Channel]
+	b.mu.Unlock()
+
+	// Listen from the latest event, so events published from now on are
+	// delivered even before the listener is running
+	var after string
+	if !listening {
+		latest, err := b.cfg.Backend.History(ctx, info.Channel, "", 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(latest) > 0 {
+			after = latest[0].ID
+		}
+	}
+
+	sub := &subscriber{
+		Subscriber: info,
+		events:     make(chan Event, b.cfg.Buffer),
+		done:       make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	st, ok := b.channels[info.Channel]
+	if !ok {
+		listenCtx, cancel := context.WithCancel(context.Background())
+		st = &channelState{subs: make(map[*subscriber]struct{}), cancel: cancel}
+		b.channels[info.Channel] = st
+		go b.listen(listenCtx, info.Channel, after)
+	}
+	st.subs[sub] = struct{}{}
+	metrics.SSEConnections.WithLabelValues(b.cfg.Name).Inc()
+	return sub, nil
+}
+
+func (b *Broker) unsubscribe(sub *subscriber) {
+	sub.close()
+	metrics.SSEConnections.WithLabelValues(b.cfg.Name).Dec()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st, ok := b.channels[sub.Channel]
+	if !ok {
+		return
+	}
+	delete(st.subs, sub)
+	if len(st.subs) == 0 {
+		st.cancel()
+		delete(b.channels, sub.Channel)
+	}
+}
+
+// listen delivers the events of channel until nobody here subscribes to
+// it, resuming after backend errors
+func (b *Broker) listen(ctx context.Context, channel, after string) {
+	for {
+		err := b.cfg.Backend.Listen(ctx, channel, after, func(ev Event) {
+			after = ev.ID
+			b.deliver(channel, ev)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			b.log("Event stream listener failed", channel, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// deliver queues ev for the subscribers of channel allowed to see it
+func (b *Broker) deliver(channel string, ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st, ok := b.channels[channel]
+	if !ok {
+		return
+	}
+	for sub := range st.subs {
+		if !b.allowed(sub.Subscriber, ev) {
+			continue
+		}
+		select {
+		case sub.events <- ev:
+		default:
+			metrics.SSEEventsTotal.WithLabelValues(b.cfg.Name, "dropped").Inc()
+			sub.close()
+		}
+	}
+}
+
+func (b *Broker) log(msg, channel string, err error) {
+	if b.cfg.Logger == nil {
+		return
+	}
+	b.cfg.Logger.Error(logging.Internal, logging.ServerSentEvents, msg, map[logging.ExtraKey]interface{}{
+		logging.Name:         b.cfg.Name,
+		logging.Topic:        channel,
+		logging.ErrorMessage: err.Error(),
+	})
+}
@@ -0,0 +1,202 @@
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveBroker serves broker on a random port; the user and tenant are
+// taken from the query, standing in for the auth middleware
+func serveBroker(t *testing.T, broker *Broker) string {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(func(c *fiber.Ctx) error {
+		if user := c.Query("user"); user != "" {
+			c.Locals("userId", user)
+			c.Locals("tenantId", c.Query("tenant"))
+		}
+		return c.Next()
+	})
+	app.Get("/events/:channel", func(c *fiber.Ctx) error {
+		return broker.Stream(c, c.Params("channel"))
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() {
+		_ = broker.Close()
+		_ = app.Shutdown()
+	})
+	return "http://" + ln.Addr().String() + "/events/"
+}
+
+type stream struct {
+	t      *testing.T
+	resp   *http.Response
+	reader *bufio.Reader
+}
+
+func connect(t *testing.T, url, lastEventID string) *stream {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	return &stream{t: t, resp: resp, reader: bufio.NewReader(resp.Body)}
+}
+
+// next returns the next event, skipping the retry field and heartbeats
+func (s *stream) next() Event {
+	done := make(chan Event, 1)
+	go func() {
+		var ev Event
+		var data []string
+		for {
+			line, err := s.reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "" && ev.ID != "":
+				ev.Data = []byte(strings.Join(data, "\n"))
+				done <- ev
+				return
+			case strings.HasPrefix(line, "id: "):
+				ev.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				ev.Type = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				data = append(data, strings.TrimPrefix(line, "data: "))
+			}
+		}
+	}()
+	select {
+	case ev := <-done:
+		return ev
+	case <-time.After(2 * time.Second):
+		s.t.Fatal("timed out waiting for event")
+		return Event{}
+	}
+}
+
+func waitSubscribers(t *testing.T, broker *Broker, channel string, n int) {
+	require.Eventually(t, func() bool {
+		broker.mu.Lock()
+		defer broker.mu.Unlock()
+		st, ok := broker.channels[channel]
+		return ok && len(st.subs) == n
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestStreamRejectsAnonymous(t *testing.T) {
+	url := serveBroker(t, New(Config{}))
+
+	resp, err := http.Get(url + "orders")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestStreamDeliversAllowedEvents(t *testing.T) {
+	broker := New(Config{})
+	url := serveBroker(t, broker)
+	ctx := context.Background()
+
+	alice := connect(t, url+"orders?user=alice&tenant=acme", "")
+	bob := connect(t, url+"orders?user=bob&tenant=other", "")
+	waitSubscribers(t, broker, "orders", 2)
+
+	_, err := broker.Publish(ctx, "orders", Event{Data: []byte("acme only"), TenantID: "acme"})
+	require.NoError(t, err)
+	ev, err := JSON("order.updated", map[string]string{"status": "paid"})
+	require.NoError(t, err)
+	id, err := broker.Publish(ctx, "orders", ev)
+	require.NoError(t, err)
+
+	got := alice.next()
+	assert.Equal(t, "acme only", string(got.Data))
+	got = alice.next()
+	assert.Equal(t, id, got.ID)
+	assert.Equal(t, "order.updated", got.Type)
+	assert.JSONEq(t, `{"status":"paid"}`, string(got.Data))
+
+	// bob skips the acme event
+	assert.Equal(t, id, bob.next().ID)
+}
+
+func TestStreamReplaysHistory(t *testing.T) {
+	broker := New(Config{Replay: 2})
+	url := serveBroker(t, broker)
+	ctx := context.Background()
+
+	var ids []string
+	for _, data := range []string{"1", "2", "3", "4"} {
+		id, err := broker.Publish(ctx, "progress", Event{Data: []byte(data)})
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	// New clients get the last Replay events, then live ones
+	s := connect(t, url+"progress?user=alice", "")
+	assert.Equal(t, "3", string(s.next().Data))
+	assert.Equal(t, "4", string(s.next().Data))
+	waitSubscribers(t, broker, "progress", 1)
+	_, err := broker.Publish(ctx, "progress", Event{Data: []byte("5")})
+	require.NoError(t, err)
+	assert.Equal(t, "5", string(s.next().Data))
+
+	// Reconnecting clients get everything after Last-Event-ID
+	s = connect(t, url+"progress?user=alice", ids[0])
+	for _, want := range []string{"2", "3", "4", "5"} {
+		assert.Equal(t, want, string(s.next().Data))
+	}
+}
+
+func TestSlowSubscriberDisconnected(t *testing.T) {
+	broker := New(Config{Buffer: 1})
+	sub, err := broker.subscribe(context.Background(), Subscriber{Channel: "c"})
+	require.NoError(t, err)
+
+	broker.deliver("c", Event{ID: "1-0"})
+	broker.deliver("c", Event{ID: "2-0"})
+	select {
+	case <-sub.done:
+	default:
+		t.Fatal("slow subscriber not closed")
+	}
+
+	broker.unsubscribe(sub)
+	broker.mu.Lock()
+	assert.Empty(t, broker.channels)
+	broker.mu.Unlock()
+}
+
+func TestWriteEventSplitsLines(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	writeEvent(w, Event{ID: "1-0", Type: "log", Data: []byte("a\r\nb")})
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "id: 1-0\nevent: log\ndata: a\ndata: b\n\n", buf.String())
+}
+
+func TestCompareIDs(t *testing.T) {
+	assert.Equal(t, -1, compareIDs("9-5", "10-0"))
+	assert.Equal(t, 1, compareIDs("10-1", "10-0"))
+	assert.Equal(t, 0, compareIDs("10-1", "10-1"))
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned for missing objects
+	ErrNotFound = errors.New("storage: object not found")
+	// ErrInvalidKey is returned for empty keys or keys escaping their prefix
+	ErrInvalidKey = errors.New("storage: invalid object key")
+	// ErrTooLarge is returned when an upload exceeds Limits.MaxSize
+	ErrTooLarge = errors.New("storage: object too large")
+	// ErrContentType is returned when an upload's content type is not
+	// allowed by Limits.AllowedContentTypes
+	ErrContentType = errors.New("storage: content type not allowed")
+)
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	ContentType  string            `json:"contentType,omitempty"`
+	ETag         string            `json:"etag,omitempty"`
+	LastModified time.Time         `json:"lastModified"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// PutOptions describe an upload
+type PutOptions struct {
+	// Size of the content, -1 or 0 when unknown. Known sizes let backends
+	// skip buffering and reject oversized uploads before reading them
+	Size int64
+	// ContentType of the content (default: sniffed from the first 512
+	// bytes)
+	ContentType  string
+	CacheControl string
+	// Metadata is stored with the object as user metadata; keys are
+	// lower-cased, as S3 doesn't preserve their case
+	Metadata map[string]string
+}
+
+// ListOptions select objects to list
+type ListOptions struct {
+	// Prefix restricts the listing to keys starting with it
+	Prefix string
+	// StartAfter continues a listing after this key, e.g. the last key of
+	// the previous page
+	StartAfter string
+	// Limit caps the number of objects returned (default: no limit)
+	Limit int
+}
+
+// BlobStore stores objects by key. Keys use "/" separators, e.g.
+// "tenants/<id>/avatars/<file>"; implementations return ErrNotFound for
+// missing objects
+type BlobStore interface {
+	// Put streams r into the object at key, replacing it if it exists
+	Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (*ObjectInfo, error)
+	// Get streams the object at key; the caller closes the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Stat returns the object's info without its content
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Delete removes the object at key; missing objects are not an error
+	Delete(ctx context.Context, key string) error
+	// List returns objects in key order
+	List(ctx context.Context, opts ListOptions) ([]ObjectInfo, error)
+	// PresignGet returns a URL downloading the object until expiry
+	PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// PresignPut returns a URL uploading the object until expiry. Clients
+	// must send the returned headers, e.g. Content-Type, with the request
+	PresignPut(ctx context.Context, key string, expiry time.Duration, opts PutOptions) (string, http.Header, error)
+}
+
+// ValidateKey rejects empty keys, absolute keys and keys with "." or ".."
+// segments, so user-supplied names can't escape their prefix
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.ContainsRune(key, 0) {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// Join builds a key from parts, e.g. Join("tenants", id, "avatar.png").
+// Unlike path.Join it validates instead of cleaning each part, so a
+// user-supplied "../t2/avatar.png" fails rather than resolving elsewhere
+func Join(parts ...string) (string, error) {
+	for _, part := range parts {
+		if err := ValidateKey(part); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+func normalizeMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	normalized := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		normalized[strings.ToLower(k)] = v
+	}
+	return normalized
+}
+
+// ============================================
+// Limits
+// ============================================
+
+// Limits restrict uploads
+type Limits struct {
+	// MaxSize is the largest accepted object in bytes (default: no limit)
+	MaxSize int64
+	// AllowedContentTypes lists accepted media types; "image/*" accepts
+	// all images (default: all)
+	AllowedContentTypes []string
+	// SniffContentType checks the type sniffed from the content instead of
+	// trusting PutOptions.ContentType, e.g. for user uploads. The sniffed
+	// type is also stored
+	SniffContentType bool
+}
+
+// WithLimits wraps store so Put and PresignPut enforce limits. Uploads of
+// unknown size are counted while streaming and fail with ErrTooLarge once
+// they exceed MaxSize; presigned uploads can only be checked for their
+// declared content type, so validate their size after the upload with Stat
+func WithLimits(store BlobStore, limits Limits) BlobStore {
+	return &limitedStore{BlobStore: store, limits: limits}
+}
+
+type limitedStore struct {
+	BlobStore
+	limits Limits
+}
+
+func (s *limitedStore) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (*ObjectInfo, error) {
+	if s.limits.MaxSize > 0 {
+		if opts.Size > s.limits.MaxSize {
+			return nil, ErrTooLarge
+		}
+		r = &limitReader{r: r, remaining: s.limits.MaxSize}
+	}
+
+	if opts.ContentType == "" || s.limits.SniffContentType {
+		var sniffed string
+		r, sniffed = sniffContentType(r)
+		if opts.ContentType == "" || !sameMediaType(opts.ContentType, sniffed) {
+			opts.ContentType = sniffed
+		}
+	}
+	if !s.allowed(opts.ContentType) {
+		return nil, fmt.Errorf("%w: %s", ErrContentType, opts.ContentType)
+	}
+
+	return s.BlobStore.Put(ctx, key, r, opts)
+}
+
+func (s *limitedStore) PresignPut(ctx context.Context, key string, expiry time.Duration, opts PutOptions) (string, http.Header, error) {
+	if s.limits.MaxSize > 0 && opts.Size > s.limits.MaxSize {
+		return "", nil, ErrTooLarge
+	}
+	if !s.allowed(opts.ContentType) {
+		return "", nil, fmt.Errorf("%w: %s", ErrContentType, opts.ContentType)
+	}
+	return s.BlobStore.PresignPut(ctx, key, expiry, opts)
+}
+
+func (s *limitedStore) allowed(contentType string) bool {
+	if len(s.limits.AllowedContentTypes) == 0 {
+		return true
+	}
+	mediaType := baseMediaType(contentType)
+	for _, allowed := range s.limits.AllowedContentTypes {
+		if allowed == mediaType || allowed == "*/*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// limitReader fails with ErrTooLarge instead of truncating like
+// io.LimitReader, so oversized uploads are aborted rather than stored cut
+type limitReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
+
+// sniffContentType detects the content type of r without consuming it
+func sniffContentType(r io.Reader) (io.Reader, string) {
+	buffered := bufio.NewReaderSize(r, 512)
+	head, _ := buffered.Peek(512)
+	return buffered, http.DetectContentType(head)
+}
+
+func baseMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mediaType
+}
+
+// sameMediaType reports whether declared is compatible with the sniffed
+// type; sniffing can't tell text formats like JSON or CSV from plain text,
+// so text declarations of text content are kept
+func sameMediaType(declared, sniffed string) bool {
+	declared, sniffed = baseMediaType(declared), baseMediaType(sniffed)
+	if declared == sniffed {
+		return true
+	}
+	if sniffed == "text/plain" {
+		return strings.HasPrefix(declared, "text/") || declared == "application/json" ||
+			strings.HasSuffix(declared, "+json") || declared == "application/xml"
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"a", "tenants/t1/avatar.png", "a/b.c/d"} {
+		assert.NoError(t, ValidateKey(key), key)
+	}
+	for _, key := range []string{"", "/a", "a//b", "a/../b", "..", "./a", "a/", "a\x00b"} {
+		assert.ErrorIs(t, ValidateKey(key), ErrInvalidKey, key)
+	}
+
+	key, err := Join("tenants", "t1", "avatar.png")
+	require.NoError(t, err)
+	assert.Equal(t, "tenants/t1/avatar.png", key)
+	_, err = Join("tenants", "t1", "../../t2/avatar.png")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	info, err := store.Put(ctx, "docs/a.txt", strings.NewReader("hello"), PutOptions{Metadata: map[string]string{"Owner": "u1"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), info.Size)
+	assert.Equal(t, "text/plain; charset=utf-8", info.ContentType)
+	assert.Equal(t, map[string]string{"owner": "u1"}, info.Metadata)
+
+	_, err = store.Put(ctx, "docs/b.json", strings.NewReader("{}"), PutOptions{ContentType: "application/json"})
+	require.NoError(t, err)
+	_, err = store.Put(ctx, "images/c.png", strings.NewReader("png"), PutOptions{})
+	require.NoError(t, err)
+
+	r, got, err := store.Get(ctx, "docs/a.txt")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(r)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "hello", string(body))
+	assert.Equal(t, info.ETag, got.ETag)
+
+	_, _, err = store.Get(ctx, "docs/missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	list, err := store.List(ctx, ListOptions{Prefix: "docs/"})
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "docs/a.txt", list[0].Key)
+
+	list, err = store.List(ctx, ListOptions{StartAfter: "docs/a.txt", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "docs/b.json", list[0].Key)
+
+	url, err := store.PresignGet(ctx, "docs/a.txt", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(url, "memory:///docs/a.txt?expires="))
+
+	require.NoError(t, store.Delete(ctx, "docs/a.txt"))
+	require.NoError(t, store.Delete(ctx, "docs/a.txt"))
+	_, err = store.Stat(ctx, "docs/a.txt")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLimits(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemoryStore()
+	store := WithLimits(memory, Limits{
+		MaxSize:             64,
+		AllowedContentTypes: []string{"image/*", "application/json"},
+		SniffContentType:    true,
+	})
+	png := "\x89PNG\r\n\x1a\n"
+
+	info, err := store.Put(ctx, "a.png", strings.NewReader(png+"data"), PutOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", info.ContentType)
+
+	// Declared types are kept for text content the sniffer can't tell apart
+	info, err = store.Put(ctx, "a.json", strings.NewReader(`{"a":1}`), PutOptions{ContentType: "application/json"})
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", info.ContentType)
+
+	// Sniffing catches HTML declared as an image
+	_, err = store.Put(ctx, "b.png", strings.NewReader("<html><script>x</script></html>"), PutOptions{ContentType: "image/png"})
+	assert.ErrorIs(t, err, ErrContentType)
+
+	_, err = store.Put(ctx, "big.png", strings.NewReader(png), PutOptions{Size: 65, ContentType: "image/png"})
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	// Unknown sizes fail while streaming, without storing a truncated object
+	_, err = store.Put(ctx, "big.png", io.MultiReader(strings.NewReader(png), bytes.NewReader(make([]byte, 100))), PutOptions{})
+	assert.ErrorIs(t, err, ErrTooLarge)
+	_, err = memory.Stat(ctx, "big.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, _, err = store.PresignPut(ctx, "c.pdf", time.Minute, PutOptions{ContentType: "application/pdf"})
+	assert.ErrorIs(t, err, ErrContentType)
+	_, headers, err := store.PresignPut(ctx, "c.png", time.Minute, PutOptions{ContentType: "image/png"})
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", headers.Get("Content-Type"))
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps objects in memory, e.g. for tests. Presigned URLs use
+// the memory:// scheme and can't be fetched
+type MemoryStore struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	info ObjectInfo
+	data []byte
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: make(map[string]memoryObject)}
+}
+
+// Put implements BlobStore
+func (s *MemoryStore) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (*ObjectInfo, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ContentType == "" {
+		opts.ContentType = http.DetectContentType(data)
+	}
+	sum := md5.Sum(data)
+	info := ObjectInfo{
+		Key:          key,
+		Size:         int64(len(data)),
+		ContentType:  opts.ContentType,
+		ETag:         hex.EncodeToString(sum[:]),
+		LastModified: time.Now().UTC(),
+		Metadata:     normalizeMetadata(opts.Metadata),
+	}
+
+	s.mu.Lock()
+	s.objects[key] = memoryObject{info: info, data: data}
+	s.mu.Unlock()
+	return &info, nil
+}
+
+// Get implements BlobStore
+func (s *MemoryStore) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	s.mu.RLock()
+	obj, ok := s.objects[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	info := obj.info
+	return io.NopCloser(bytes.NewReader(obj.data)), &info, nil
+}
+
+// Stat implements BlobStore
+func (s *MemoryStore) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	s.mu.RLock()
+	obj, ok := s.objects[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	info := obj.info
+	return &info, nil
+}
+
+// Delete implements BlobStore
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	delete(s.objects, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// List implements BlobStore
+func (s *MemoryStore) List(ctx context.Context, opts ListOptions) ([]ObjectInfo, error) {
+	s.mu.RLock()
+	objects := make([]ObjectInfo, 0, len(s.objects))
+	for key, obj := range s.objects {
+		if strings.HasPrefix(key, opts.Prefix) && key > opts.StartAfter {
+			objects = append(objects, obj.info)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	if opts.Limit > 0 && len(objects) > opts.Limit {
+		objects = objects[:opts.Limit]
+	}
+	return objects, nil
+}
+
+// PresignGet implements BlobStore
+func (s *MemoryStore) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	return presignMemory(key, expiry), nil
+}
+
+// PresignPut implements BlobStore
+func (s *MemoryStore) PresignPut(ctx context.Context, key string, expiry time.Duration, opts PutOptions) (string, http.Header, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", nil, err
+	}
+	headers := http.Header{}
+	if opts.ContentType != "" {
+		headers.Set("Content-Type", opts.ContentType)
+	}
+	return presignMemory(key, expiry), headers, nil
+}
+
+func presignMemory(key string, expiry time.Duration) string {
+	u := url.URL{Scheme: "memory", Path: "/" + key}
+	u.RawQuery = url.Values{"expires": {time.Now().Add(expiry).UTC().Format(time.RFC3339)}}.Encode()
+	return u.String()
+}
@@ -0,0 +1,267 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config configures an S3 compatible store
+type S3Config struct {
+	// Endpoint is the host[:port] of the API (default: s3.amazonaws.com)
+	Endpoint string
+	Region   string
+	Bucket   string
+	// AccessKeyID and SecretAccessKey are static credentials. Without them
+	// credentials come from the AWS environment variables, the shared
+	// credentials file or the instance/pod IAM role
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Insecure uses plain HTTP, e.g. for a local MinIO
+	Insecure bool
+	// PathStyle addresses buckets as endpoint/bucket instead of
+	// bucket.endpoint, as MinIO expects
+	PathStyle bool
+}
+
+// S3Store stores objects in an S3 compatible bucket. It serves AWS S3,
+// MinIO and GCS through its S3 interoperability API
+type S3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Store creates a store for an AWS S3 bucket or any S3 compatible API
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("storage: bucket is required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "s3.amazonaws.com"
+	}
+
+	var creds *credentials.Credentials
+	if cfg.AccessKeyID != "" {
+		creds = credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken)
+	} else {
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{},
+		})
+	}
+
+	lookup := minio.BucketLookupAuto
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:        creds,
+		Secure:       !cfg.Insecure,
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage: %w", err)
+	}
+	return &S3Store{client: client, bucket: cfg.Bucket}, nil
+}
+
+// NewMinIOStore creates a store for a MinIO bucket, addressed path-style
+func NewMinIOStore(cfg S3Config) (*S3Store, error) {
+	cfg.PathStyle = true
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	return NewS3Store(cfg)
+}
+
+// NewGCSStore creates a store for a Google Cloud Storage bucket through its
+// S3 interoperability API. AccessKeyID and SecretAccessKey are an HMAC key
+// of a service account; Endpoint defaults to storage.googleapis.com
+func NewGCSStore(cfg S3Config) (*S3Store, error) {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "storage.googleapis.com"
+	}
+	if cfg.Region == "" {
+		cfg.Region = "auto"
+	}
+	cfg.PathStyle = true
+	return NewS3Store(cfg)
+}
+
+// EnsureBucket creates the bucket if it doesn't exist, e.g. on startup
+// against a local MinIO
+func (s *S3Store) EnsureBucket(ctx context.Context) error {
+	exists, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil || exists {
+		return err
+	}
+	return s.client.MakeBucket(ctx, s.bucket, minio.MakeBucketOptions{})
+}
+
+// Put implements BlobStore
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (*ObjectInfo, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	if opts.ContentType == "" {
+		r, opts.ContentType = sniffContentType(r)
+	}
+	size := opts.Size
+	if size <= 0 {
+		size = -1
+	}
+
+	upload, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:  opts.ContentType,
+		CacheControl: opts.CacheControl,
+		UserMetadata: opts.Metadata,
+	})
+	if err != nil {
+		return nil, s.wrapError(err)
+	}
+
+	lastModified := upload.LastModified
+	if lastModified.IsZero() {
+		lastModified = time.Now().UTC()
+	}
+	return &ObjectInfo{
+		Key:          key,
+		Size:         upload.Size,
+		ContentType:  opts.ContentType,
+		ETag:         upload.ETag,
+		LastModified: lastModified,
+		Metadata:     normalizeMetadata(opts.Metadata),
+	}, nil
+}
+
+// Get implements BlobStore
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, nil, err
+	}
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, s.wrapError(err)
+	}
+	// GetObject is lazy; Stat issues the request and surfaces errors
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, nil, s.wrapError(err)
+	}
+	return obj, toObjectInfo(stat), nil
+}
+
+// Stat implements BlobStore
+func (s *S3Store) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	stat, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s.wrapError(err)
+	}
+	return toObjectInfo(stat), nil
+}
+
+// Delete implements BlobStore
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	err := s.wrapError(s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}))
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// List implements BlobStore
+func (s *S3Store) List(ctx context.Context, opts ListOptions) ([]ObjectInfo, error) {
+	// Cancelling stops the listing goroutine when Limit is reached
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var objects []ObjectInfo
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:     opts.Prefix,
+		StartAfter: opts.StartAfter,
+		Recursive:  true,
+	}) {
+		if obj.Err != nil {
+			return nil, s.wrapError(obj.Err)
+		}
+		objects = append(objects, *toObjectInfo(obj))
+		if opts.Limit > 0 && len(objects) == opts.Limit {
+			break
+		}
+	}
+	return objects, nil
+}
+
+// PresignGet implements BlobStore
+func (s *S3Store) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, expiry, nil)
+	if err != nil {
+		return "", s.wrapError(err)
+	}
+	return u.String(), nil
+}
+
+// PresignPut implements BlobStore. The content type is signed, so uploads
+// with another Content-Type header are rejected
+func (s *S3Store) PresignPut(ctx context.Context, key string, expiry time.Duration, opts PutOptions) (string, http.Header, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", nil, err
+	}
+	headers := http.Header{}
+	if opts.ContentType != "" {
+		headers.Set("Content-Type", opts.ContentType)
+	}
+	if opts.CacheControl != "" {
+		headers.Set("Cache-Control", opts.CacheControl)
+	}
+	for k, v := range opts.Metadata {
+		headers.Set("X-Amz-Meta-"+k, v)
+	}
+
+	u, err := s.client.PresignHeader(ctx, http.MethodPut, s.bucket, key, expiry, nil, headers)
+	if err != nil {
+		return "", nil, s.wrapError(err)
+	}
+	return u.String(), headers, nil
+}
+
+func (s *S3Store) wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		return ErrNotFound
+	}
+	return fmt.Errorf("storage: %w", err)
+}
+
+func toObjectInfo(obj minio.ObjectInfo) *ObjectInfo {
+	return &ObjectInfo{
+		Key:          obj.Key,
+		Size:         obj.Size,
+		ContentType:  obj.ContentType,
+		ETag:         obj.ETag,
+		LastModified: obj.LastModified,
+		Metadata:     normalizeMetadata(obj.UserMetadata),
+	}
+}
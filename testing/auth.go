@@ -0,0 +1,260 @@
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minisource/go-common/http/middleware"
+)
+
+// ============================================
+// Fake Token Validator
+// ============================================
+
+// FakeTokenValidator is a programmable middleware.TokenValidator. Results
+// are looked up by token, falling back to Default; unknown tokens are invalid
+//
+// RemoteServiceAuthMiddleware caches validations globally, Reset clears
+// that cache too
+type FakeTokenValidator struct {
+	mu      sync.Mutex
+	results map[string]*middleware.TokenValidationResult
+	errors  map[string]error
+	calls   []string
+
+	// Default is returned for tokens without a programmed result
+	Default *middleware.TokenValidationResult
+	// Err is returned for every token without a programmed error
+	Err error
+	// Delay simulates a slow auth service
+	Delay time.Duration
+}
+
+// NewFakeTokenValidator creates a validator with no programmed tokens
+func NewFakeTokenValidator() *FakeTokenValidator {
+	middleware.ClearTokenCache()
+	return &FakeTokenValidator{
+		results: make(map[string]*middleware.TokenValidationResult),
+		errors:  make(map[string]error),
+	}
+}
+
+// ValidServiceResult returns a valid result for the test client with the given scopes
+func ValidServiceResult(scopes ...string) *middleware.TokenValidationResult {
+	return &middleware.TokenValidationResult{
+		Valid:       true,
+		ClientID:    TestClientID,
+		ServiceName: "test-service",
+		TenantID:    TestTenantID,
+		Scopes:      scopes,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+}
+
+// SetResult programs the result for a token
+func (v *FakeTokenValidator) SetResult(token string, result *middleware.TokenValidationResult) *FakeTokenValidator {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.results[token] = result
+	return v
+}
+
+// SetValid programs a valid service result with scopes for a token
+func (v *FakeTokenValidator) SetValid(token string, scopes ...string) *FakeTokenValidator {
+	return v.SetResult(token, ValidServiceResult(scopes...))
+}
+
+// SetError programs an error for a token
+func (v *FakeTokenValidator) SetError(token string, err error) *FakeTokenValidator {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.errors[token] = err
+	return v
+}
+
+// ValidateToken implements middleware.TokenValidator
+func (v *FakeTokenValidator) ValidateToken(ctx context.Context, token string) (*middleware.TokenValidationResult, error) {
+	v.mu.Lock()
+	v.calls = append(v.calls, token)
+	delay := v.Delay
+	err, hasErr := v.errors[token]
+	result, hasResult := v.results[token]
+	if !hasErr {
+		err = v.Err
+	}
+	if !hasResult {
+		result = v.Default
+	}
+	v.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return &middleware.TokenValidationResult{Valid: false}, nil
+	}
+	copied := *result
+	return &copied, nil
+}
+
+// Calls returns the validated tokens in call order
+func (v *FakeTokenValidator) Calls() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return append([]string(nil), v.calls...)
+}
+
+// CallCount returns how many times ValidateToken was called
+func (v *FakeTokenValidator) CallCount() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.calls)
+}
+
+// Reset clears programmed results, calls and the middleware token cache
+func (v *FakeTokenValidator) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.results = make(map[string]*middleware.TokenValidationResult)
+	v.errors = make(map[string]error)
+	v.calls = nil
+	v.Default = nil
+	v.Err = nil
+	v.Delay = 0
+	middleware.ClearTokenCache()
+}
+
+// ============================================
+// Fake OAuth Introspection Server
+// ============================================
+
+// IntrospectionRequest is a request captured by IntrospectionServer
+type IntrospectionRequest struct {
+	Token        string `json:"token"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// IntrospectionServer is an httptest server speaking the introspection
+// protocol used by OAuthIntrospectionMiddleware. Unknown tokens are
+// reported as inactive
+type IntrospectionServer struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	tokens       map[string]middleware.IntrospectionResponse
+	requests     []IntrospectionRequest
+	status       int
+	clientID     string
+	clientSecret string
+}
+
+// NewIntrospectionServer starts a fake introspection endpoint that is closed
+// when the test ends
+func NewIntrospectionServer(t testing.TB) *IntrospectionServer {
+	t.Helper()
+	s := &IntrospectionServer{
+		tokens: make(map[string]middleware.IntrospectionResponse),
+		status: http.StatusOK,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// SetToken programs the introspection response for a token
+func (s *IntrospectionServer) SetToken(token string, resp middleware.IntrospectionResponse) *IntrospectionServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token] = resp
+	return s
+}
+
+// SetActive programs an active token for the test client with the given scopes
+func (s *IntrospectionServer) SetActive(token string, scopes ...string) *IntrospectionServer {
+	now := time.Now()
+	return s.SetToken(token, middleware.IntrospectionResponse{
+		Active:    true,
+		ClientID:  TestClientID,
+		TokenType: "access_token",
+		Scopes:    scopes,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Hour).Unix(),
+		Subject:   TestUserID,
+		TenantID:  TestTenantID,
+	})
+}
+
+// SetStatus makes every request fail with the given HTTP status; use
+// http.StatusOK to restore normal behavior
+func (s *IntrospectionServer) SetStatus(status int) *IntrospectionServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+	return s
+}
+
+// RequireClient rejects requests that don't carry these client credentials
+func (s *IntrospectionServer) RequireClient(clientID, clientSecret string) *IntrospectionServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clientID, s.clientSecret = clientID, clientSecret
+	return s
+}
+
+// Requests returns the captured requests
+func (s *IntrospectionServer) Requests() []IntrospectionRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]IntrospectionRequest(nil), s.requests...)
+}
+
+// Config returns an OAuthIntrospectionConfig pointed at this server
+func (s *IntrospectionServer) Config() middleware.OAuthIntrospectionConfig {
+	cfg := middleware.DefaultOAuthIntrospectionConfig()
+	cfg.IntrospectionURL = s.URL
+	cfg.ClientID = s.clientID
+	cfg.ClientSecret = s.clientSecret
+	return cfg
+}
+
+func (s *IntrospectionServer) handle(w http.ResponseWriter, r *http.Request) {
+	var req IntrospectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.Method != http.MethodPost {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	status := s.status
+	clientOK := s.clientID == "" || (req.ClientID == s.clientID && req.ClientSecret == s.clientSecret)
+	resp, ok := s.tokens[req.Token]
+	s.mu.Unlock()
+
+	switch {
+	case status != http.StatusOK:
+		http.Error(w, http.StatusText(status), status)
+		return
+	case !clientOK:
+		http.Error(w, "invalid client", http.StatusUnauthorized)
+		return
+	case !ok:
+		resp = middleware.IntrospectionResponse{Active: false}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,130 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	stdtesting "testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/http/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeTokenValidator(t *stdtesting.T) {
+	v := NewFakeTokenValidator()
+	ctx := context.Background()
+	revoked := errors.New("revoked")
+	v.SetValid("good", "users:read").SetError("bad", revoked)
+
+	result, err := v.ValidateToken(ctx, "good")
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, TestClientID, result.ClientID)
+	assert.Equal(t, []string{"users:read"}, result.Scopes)
+	result.ClientID = "changed"
+	again, _ := v.ValidateToken(ctx, "good")
+	assert.Equal(t, TestClientID, again.ClientID, "results are copies")
+
+	_, err = v.ValidateToken(ctx, "bad")
+	assert.ErrorIs(t, err, revoked)
+	result, err = v.ValidateToken(ctx, "unknown")
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+
+	v.Default = ValidServiceResult("*")
+	result, _ = v.ValidateToken(ctx, "unknown")
+	assert.True(t, result.Valid, "unknown tokens fall back to Default")
+	v.Err = revoked
+	_, err = v.ValidateToken(ctx, "unknown")
+	assert.ErrorIs(t, err, revoked)
+
+	assert.Equal(t, []string{"good", "good", "bad", "unknown", "unknown", "unknown"}, v.Calls())
+	v.Reset()
+	assert.Zero(t, v.CallCount())
+	result, err = v.ValidateToken(ctx, "good")
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+}
+
+func TestFakeTokenValidatorDelay(t *stdtesting.T) {
+	v := NewFakeTokenValidator()
+	v.Delay = time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	_, err := v.ValidateToken(ctx, "any")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFakeTokenValidatorWithRemoteServiceAuth(t *stdtesting.T) {
+	v := NewFakeTokenValidator().SetValid("reader", "users:read").SetValid("writer", "users:write")
+	t.Cleanup(middleware.ClearTokenCache)
+	app := fiber.New()
+	app.Use(middleware.RemoteServiceAuthMiddleware(middleware.RemoteServiceAuthConfig{
+		TokenValidator: v,
+		RequiredScope:  "users:read",
+		Enabled:        true,
+	}))
+	app.Get("/users", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+
+	status := func(token string) int {
+		req := httptest.NewRequest("GET", "/users", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+	assert.Equal(t, fiber.StatusNoContent, status("reader"))
+	assert.Equal(t, fiber.StatusNoContent, status("reader"))
+	assert.Equal(t, fiber.StatusForbidden, status("writer"))
+	assert.Equal(t, fiber.StatusUnauthorized, status("stolen"))
+	assert.Equal(t, 3, v.CallCount(), "validations are cached by the middleware")
+}
+
+func TestIntrospectionServer(t *stdtesting.T) {
+	s := NewIntrospectionServer(t).SetActive("good", "users:read").RequireClient("orders", "secret")
+	cfg := s.Config()
+	cfg.RequiredScopes = []string{"users:read"}
+	app := fiber.New()
+	app.Use(middleware.OAuthIntrospectionMiddleware(cfg))
+	app.Get("/users", func(c *fiber.Ctx) error { return c.SendString(middleware.GetClientIDFromContext(c)) })
+
+	status := func(token string) int {
+		req := httptest.NewRequest("GET", "/users", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, status("good"))
+	assert.Equal(t, fiber.StatusUnauthorized, status("unknown"), "unknown tokens are inactive")
+	s.SetToken("narrow", middleware.IntrospectionResponse{Active: true, Scopes: []string{"orders:read"}})
+	assert.Equal(t, fiber.StatusForbidden, status("narrow"))
+
+	s.SetStatus(fiber.StatusServiceUnavailable)
+	assert.Equal(t, fiber.StatusUnauthorized, status("good"))
+	s.SetStatus(fiber.StatusOK)
+
+	requests := s.Requests()
+	require.Len(t, requests, 4)
+	assert.Equal(t, IntrospectionRequest{Token: "good", ClientID: "orders", ClientSecret: "secret"}, requests[0])
+}
+
+func TestIntrospectionServerRejectsWrongClient(t *stdtesting.T) {
+	s := NewIntrospectionServer(t).SetActive("good").RequireClient("orders", "secret")
+	cfg := s.Config()
+	cfg.ClientSecret = "wrong"
+	app := fiber.New()
+	app.Use(middleware.OAuthIntrospectionMiddleware(cfg))
+	app.Get("/users", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("Authorization", "Bearer good")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
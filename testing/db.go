@@ -0,0 +1,119 @@
+package testing
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// ============================================
+// Database Test Helpers
+// ============================================
+
+// DefaultPreservedTables are never truncated by TruncateAll
+var DefaultPreservedTables = []string{"schema_migrations", "goose_db_version", "gorp_migrations"}
+
+// WithTx runs fn inside a transaction that is rolled back when the test
+// ends, so rows never leak between tests. Nested db.Transaction calls in
+// the code under test become savepoints
+//
+//	testing.WithTx(t, db, func(tx *gorm.DB) {
+//		repo := NewRepo(tx)
+//		...
+//	})
+func WithTx(t testing.TB, db *gorm.DB, fn func(tx *gorm.DB)) {
+	t.Helper()
+	fn(BeginTx(t, db))
+}
+
+// BeginTx starts a transaction that is rolled back when the test ends
+func BeginTx(t testing.TB, db *gorm.DB) *gorm.DB {
+	t.Helper()
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("begin test transaction: %v", tx.Error)
+	}
+	t.Cleanup(func() {
+		if err := tx.Rollback().Error; err != nil && !errors.Is(err, sql.ErrTxDone) {
+			t.Errorf("rollback test transaction: %v", err)
+		}
+	})
+	return tx
+}
+
+// TruncateOnCleanup truncates every table except the preserved ones when the
+// test ends. Use it for tests whose code commits its own transactions, where
+// WithTx can't isolate them
+func TruncateOnCleanup(t testing.TB, db *gorm.DB, preserve ...string) {
+	t.Helper()
+	t.Cleanup(func() {
+		if err := TruncateAll(db, preserve...); err != nil {
+			t.Errorf("truncate tables: %v", err)
+		}
+	})
+}
+
+// TruncateAll removes all rows from every table except DefaultPreservedTables
+// and preserve, resetting identity sequences where the database supports it
+func TruncateAll(db *gorm.DB, preserve ...string) error {
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		return fmt.Errorf("list tables: %w", err)
+	}
+
+	skip := make(map[string]bool)
+	for _, name := range append(append([]string(nil), DefaultPreservedTables...), preserve...) {
+		skip[name] = true
+	}
+	var targets []string
+	for _, table := range tables {
+		if !skip[table] {
+			targets = append(targets, table)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	quoted := make([]string, len(targets))
+	for i, table := range targets {
+		quoted[i] = db.Statement.Quote(table)
+	}
+
+	switch db.Dialector.Name() {
+	case "postgres":
+		return db.Exec("TRUNCATE TABLE " + strings.Join(quoted, ", ") + " RESTART IDENTITY CASCADE").Error
+	case "mysql":
+		return db.Connection(func(conn *gorm.DB) error {
+			if err := conn.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+				return err
+			}
+			defer conn.Exec("SET FOREIGN_KEY_CHECKS = 1")
+			for _, table := range quoted {
+				if err := conn.Exec("TRUNCATE TABLE " + table).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	default:
+		return db.Connection(func(conn *gorm.DB) error {
+			if db.Dialector.Name() == "sqlite" {
+				if err := conn.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+					return err
+				}
+				defer conn.Exec("PRAGMA foreign_keys = ON")
+			}
+			for _, table := range quoted {
+				if err := conn.Exec("DELETE FROM " + table).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package testing
+
+import (
+	"errors"
+	stdtesting "testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type dbTestUser struct {
+	ID   uint
+	Name string
+}
+
+type dbTestSetting struct {
+	Key   string `gorm:"primaryKey"`
+	Value string
+}
+
+type dbTestMigration struct {
+	Version int `gorm:"primaryKey"`
+}
+
+func (dbTestMigration) TableName() string { return "schema_migrations" }
+
+func newTestDB(t *stdtesting.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	require.NoError(t, db.AutoMigrate(&dbTestUser{}, &dbTestSetting{}, &dbTestMigration{}))
+	return db
+}
+
+func countRows(t *stdtesting.T, db *gorm.DB, model interface{}) int64 {
+	var n int64
+	require.NoError(t, db.Model(model).Count(&n).Error)
+	return n
+}
+
+func TestWithTxRollsBackWhenTestEnds(t *stdtesting.T) {
+	db := newTestDB(t)
+
+	t.Run("writes", func(t *stdtesting.T) {
+		WithTx(t, db, func(tx *gorm.DB) {
+			require.NoError(t, tx.Create(&dbTestUser{Name: "ali"}).Error)
+
+			// Nested transactions are savepoints inside the test transaction
+			err := tx.Transaction(func(inner *gorm.DB) error {
+				require.NoError(t, inner.Create(&dbTestUser{Name: "sara"}).Error)
+				return errors.New("rollback inner")
+			})
+			require.Error(t, err)
+			assert.Equal(t, int64(1), countRows(t, tx, &dbTestUser{}))
+		})
+	})
+
+	assert.Zero(t, countRows(t, db, &dbTestUser{}))
+}
+
+func TestBeginTxToleratesCommittedTransaction(t *stdtesting.T) {
+	db := newTestDB(t)
+
+	t.Run("commits", func(t *stdtesting.T) {
+		tx := BeginTx(t, db)
+		require.NoError(t, tx.Create(&dbTestUser{Name: "ali"}).Error)
+		require.NoError(t, tx.Commit().Error)
+	})
+
+	assert.Equal(t, int64(1), countRows(t, db, &dbTestUser{}), "the cleanup rollback is a no-op")
+}
+
+func TestTruncateAll(t *stdtesting.T) {
+	db := newTestDB(t)
+	require.NoError(t, db.Create(&[]dbTestUser{{Name: "ali"}, {Name: "sara"}}).Error)
+	require.NoError(t, db.Create(&dbTestSetting{Key: "locale", Value: "fa"}).Error)
+	require.NoError(t, db.Create(&dbTestMigration{Version: 1}).Error)
+
+	require.NoError(t, TruncateAll(db, "db_test_settings"))
+
+	assert.Zero(t, countRows(t, db, &dbTestUser{}))
+	assert.Equal(t, int64(1), countRows(t, db, &dbTestSetting{}), "preserved by argument")
+	assert.Equal(t, int64(1), countRows(t, db, &dbTestMigration{}), "preserved by default")
+}
+
+func TestTruncateOnCleanup(t *stdtesting.T) {
+	db := newTestDB(t)
+
+	t.Run("commits", func(t *stdtesting.T) {
+		TruncateOnCleanup(t, db)
+		require.NoError(t, db.Create(&dbTestUser{Name: "ali"}).Error)
+		require.NoError(t, db.Create(&dbTestSetting{Key: "locale"}).Error)
+	})
+
+	assert.Zero(t, countRows(t, db, &dbTestUser{}))
+	assert.Zero(t, countRows(t, db, &dbTestSetting{}))
+}
@@ -0,0 +1,217 @@
+package testing
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ============================================
+// Deterministic Fake Data
+// ============================================
+
+// DefaultSeed is the seed used by NewFaker(0)
+const DefaultSeed uint64 = 42
+
+// Faker generates realistic fake data from a seed. The same seed always
+// yields the same sequence, so generated values can be used in golden files
+// and snapshot tests. Generated emails are unique per Faker. Not safe for
+// concurrent use
+type Faker struct {
+	rng *rand.Rand
+	seq int
+}
+
+// FakeAddress is a generated postal address
+type FakeAddress struct {
+	Street     string
+	City       string
+	State      string
+	PostalCode string
+	Country    string
+}
+
+// String formats the address on one line
+func (a FakeAddress) String() string {
+	return fmt.Sprintf("%s, %s, %s %s, %s", a.Street, a.City, a.State, a.PostalCode, a.Country)
+}
+
+var (
+	fakeFirstNames = []string{
+		"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda",
+		"William", "Elizabeth", "David", "Barbara", "Richard", "Susan", "Joseph", "Jessica",
+		"Thomas", "Sarah", "Daniel", "Karen", "Matthew", "Nancy", "Anthony", "Lisa",
+	}
+	fakeLastNames = []string{
+		"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis",
+		"Rodriguez", "Martinez", "Hernandez", "Lopez", "Wilson", "Anderson", "Thomas", "Taylor",
+		"Moore", "Jackson", "Martin", "Lee", "Thompson", "White", "Harris", "Clark",
+	}
+	fakePersianFirstNames = []string{
+		"علی", "محمد", "حسین", "رضا", "مهدی", "امیر", "سارا", "زهرا",
+		"فاطمه", "مریم", "نگار", "پریسا", "آرش", "کاوه", "نرگس", "شیرین",
+	}
+	fakePersianLastNames = []string{
+		"محمدی", "حسینی", "احمدی", "رضایی", "کریمی", "موسوی", "جعفری", "صادقی",
+		"رحیمی", "کاظمی", "نوری", "عباسی", "قاسمی", "طاهری", "شریفی", "اکبری",
+	}
+	fakeStreets = []string{
+		"Main St", "Oak Ave", "Maple Dr", "Cedar Ln", "Pine St", "Elm St",
+		"Washington Blvd", "Lake Rd", "Hill St", "Park Ave",
+	}
+	fakeCities = []struct{ city, state string }{
+		{"New York", "NY"}, {"Los Angeles", "CA"}, {"Chicago", "IL"}, {"Houston", "TX"},
+		{"Phoenix", "AZ"}, {"Seattle", "WA"}, {"Boston", "MA"}, {"Denver", "CO"},
+	}
+	fakeIranianCities = []struct{ city, province string }{
+		{"تهران", "تهران"}, {"مشهد", "خراسان رضوی"}, {"اصفهان", "اصفهان"}, {"شیراز", "فارس"},
+		{"تبریز", "آذربایجان شرقی"}, {"کرج", "البرز"}, {"رشت", "گیلان"}, {"یزد", "یزد"},
+	}
+	fakeIranianStreets = []string{
+		"خیابان ولیعصر", "خیابان آزادی", "خیابان انقلاب", "بلوار کشاورز", "خیابان شریعتی", "خیابان فردوسی",
+	}
+	// Prefixes of Iranian mobile operators (after +98)
+	fakeIranianMobilePrefixes = []string{"912", "919", "935", "936", "937", "938", "939", "901", "902", "990"}
+	// Real US area codes, so generated numbers pass phone validation
+	fakeUSAreaCodes  = []string{"202", "212", "305", "312", "415", "512", "617", "646", "702", "718"}
+	fakeEmailDomains = []string{"example.com", "example.org", "example.net"}
+)
+
+// NewFaker creates a Faker for seed; 0 means DefaultSeed
+func NewFaker(seed uint64) *Faker {
+	if seed == 0 {
+		seed = DefaultSeed
+	}
+	return &Faker{rng: rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))}
+}
+
+// Int returns an int in [lo, hi]
+func (f *Faker) Int(lo, hi int) int {
+	if hi <= lo {
+		return lo
+	}
+	return lo + f.rng.IntN(hi-lo+1)
+}
+
+// Digits returns n random decimal digits
+func (f *Faker) Digits(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + f.rng.IntN(10))
+	}
+	return string(b)
+}
+
+// Pick returns a random element of items
+func Pick[T any](f *Faker, items []T) T {
+	return items[f.rng.IntN(len(items))]
+}
+
+// Bool returns a random bool
+func (f *Faker) Bool() bool {
+	return f.rng.IntN(2) == 1
+}
+
+// UUID returns a version 4 UUID derived from the seed
+func (f *Faker) UUID() uuid.UUID {
+	var id uuid.UUID
+	for i := 0; i < len(id); i += 8 {
+		v := f.rng.Uint64()
+		for j := 0; j < 8; j++ {
+			id[i+j] = byte(v >> (8 * j))
+		}
+	}
+	id[6] = (id[6] & 0x0f) | 0x40 // version 4
+	id[8] = (id[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return id
+}
+
+// FirstName returns an English first name
+func (f *Faker) FirstName() string {
+	return Pick(f, fakeFirstNames)
+}
+
+// LastName returns an English last name
+func (f *Faker) LastName() string {
+	return Pick(f, fakeLastNames)
+}
+
+// Name returns an English full name
+func (f *Faker) Name() string {
+	return f.FirstName() + " " + f.LastName()
+}
+
+// PersianName returns a Persian full name
+func (f *Faker) PersianName() string {
+	return Pick(f, fakePersianFirstNames) + " " + Pick(f, fakePersianLastNames)
+}
+
+// Email returns a unique email such as "mary.smith3@example.org"
+func (f *Faker) Email() string {
+	f.seq++
+	return fmt.Sprintf("%s.%s%d@%s",
+		strings.ToLower(f.FirstName()), strings.ToLower(f.LastName()), f.seq, Pick(f, fakeEmailDomains))
+}
+
+// IranianMobile returns an Iranian mobile number in E.164, e.g. +989121234567
+func (f *Faker) IranianMobile() string {
+	return "+98" + Pick(f, fakeIranianMobilePrefixes) + f.Digits(7)
+}
+
+// USPhone returns a US phone number in E.164, e.g. +12125550143
+func (f *Faker) USPhone() string {
+	exchange := fmt.Sprintf("%d%s", f.Int(2, 9), f.Digits(2))
+	if exchange[1:] == "11" {
+		exchange = exchange[:2] + "2"
+	}
+	return "+1" + Pick(f, fakeUSAreaCodes) + exchange + f.Digits(4)
+}
+
+// Address returns a US address
+func (f *Faker) Address() FakeAddress {
+	city := Pick(f, fakeCities)
+	return FakeAddress{
+		Street:     fmt.Sprintf("%d %s", f.Int(1, 9999), Pick(f, fakeStreets)),
+		City:       city.city,
+		State:      city.state,
+		PostalCode: fmt.Sprintf("%05d", f.Int(1001, 99950)),
+		Country:    "US",
+	}
+}
+
+// IranianAddress returns an Iranian address with a 10 digit postal code
+func (f *Faker) IranianAddress() FakeAddress {
+	city := Pick(f, fakeIranianCities)
+	return FakeAddress{
+		Street:     fmt.Sprintf("%s، پلاک %d", Pick(f, fakeIranianStreets), f.Int(1, 300)),
+		City:       city.city,
+		State:      city.province,
+		PostalCode: fmt.Sprintf("%d%s", f.Int(1, 9), f.Digits(9)),
+		Country:    "IR",
+	}
+}
+
+// Time returns a time within d after FixedTime
+func (f *Faker) Time(d time.Duration) time.Time {
+	if d <= 0 {
+		return FixedTime()
+	}
+	return FixedTime().Add(time.Duration(f.rng.Int64N(int64(d))))
+}
+
+// Sentence returns n lowercase pseudo words
+func (f *Faker) Sentence(n int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	words := make([]string, n)
+	for i := range words {
+		b := make([]byte, f.Int(2, 9))
+		for j := range b {
+			b[j] = letters[f.rng.IntN(len(letters))]
+		}
+		words[i] = string(b)
+	}
+	return strings.Join(words, " ")
+}
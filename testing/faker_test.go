@@ -0,0 +1,38 @@
+package testing
+
+import (
+	stdtesting "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakerIsDeterministic(t *stdtesting.T) {
+	generate := func(seed uint64) []interface{} {
+		f := NewFaker(seed)
+		return []interface{}{f.UUID(), f.Name(), f.Email(), f.IranianMobile(), f.Address(), f.Int(1, 100), f.Sentence(5)}
+	}
+
+	assert.Equal(t, generate(7), generate(7))
+	assert.NotEqual(t, generate(7), generate(8))
+	assert.Equal(t, generate(0), generate(DefaultSeed), "0 means DefaultSeed")
+}
+
+func TestEmailsAreUnique(t *stdtesting.T) {
+	f := NewFaker(1)
+	builder := NewFixtureBuilder()
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		for _, email := range []string{f.Email(), builder.NextEmail()} {
+			assert.False(t, seen[email], "duplicate email %s", email)
+			seen[email] = true
+		}
+	}
+}
+
+func TestSeededFixtureBuilderRepeatsIDs(t *stdtesting.T) {
+	a, b := NewSeededFixtureBuilder(3), NewSeededFixtureBuilder(3)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, a.NextID(), b.NextID())
+	}
+	assert.NotEqual(t, NewFixtureBuilder().NextID(), NewFixtureBuilder().NextID())
+}
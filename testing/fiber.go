@@ -0,0 +1,229 @@
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// ============================================
+// Fiber Context Builder
+// ============================================
+
+// FiberCtxBuilder prepares a request with pre-populated locals, as set by
+// the auth and tracing middleware, for unit testing handlers and middleware
+//
+//	resp := testing.NewFiberCtx().
+//		Method("POST").Route("/orders/:id", "/orders/42").
+//		JSONBody(req).
+//		Authenticated().
+//		Run(t, handler.Update)
+type FiberCtxBuilder struct {
+	method      string
+	route       string
+	path        string
+	body        []byte
+	headers     map[string]string
+	query       url.Values
+	locals      map[string]interface{}
+	userContext context.Context
+}
+
+// NewFiberCtx starts a GET / request without locals
+func NewFiberCtx() *FiberCtxBuilder {
+	return &FiberCtxBuilder{
+		method:  fiber.MethodGet,
+		path:    "/",
+		headers: make(map[string]string),
+		query:   make(url.Values),
+		locals:  make(map[string]interface{}),
+	}
+}
+
+// Method sets the HTTP method
+func (b *FiberCtxBuilder) Method(method string) *FiberCtxBuilder {
+	b.method = strings.ToUpper(method)
+	return b
+}
+
+// Path sets the request path, which may include a query string
+func (b *FiberCtxBuilder) Path(path string) *FiberCtxBuilder {
+	b.path = path
+	return b
+}
+
+// Route sets the route pattern the handler is mounted on and the concrete
+// request path, so c.Params works, e.g. Route("/users/:id", "/users/42")
+func (b *FiberCtxBuilder) Route(pattern, path string) *FiberCtxBuilder {
+	b.route = pattern
+	b.path = path
+	return b
+}
+
+// Body sets a raw body with its content type
+func (b *FiberCtxBuilder) Body(body []byte, contentType string) *FiberCtxBuilder {
+	b.body = body
+	b.headers[fiber.HeaderContentType] = contentType
+	return b
+}
+
+// JSONBody sets v encoded as JSON as the body
+func (b *FiberCtxBuilder) JSONBody(v interface{}) *FiberCtxBuilder {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic("testing: marshal request body: " + err.Error())
+	}
+	return b.Body(body, fiber.MIMEApplicationJSON)
+}
+
+// Header sets a request header
+func (b *FiberCtxBuilder) Header(key, value string) *FiberCtxBuilder {
+	b.headers[key] = value
+	return b
+}
+
+// Query adds a query parameter
+func (b *FiberCtxBuilder) Query(key, value string) *FiberCtxBuilder {
+	b.query.Add(key, value)
+	return b
+}
+
+// Local sets a Fiber local
+func (b *FiberCtxBuilder) Local(key string, value interface{}) *FiberCtxBuilder {
+	b.locals[key] = value
+	return b
+}
+
+// UserID sets the "userId" local
+func (b *FiberCtxBuilder) UserID(id string) *FiberCtxBuilder {
+	return b.Local("userId", id)
+}
+
+// TenantID sets the "tenantId" local
+func (b *FiberCtxBuilder) TenantID(id string) *FiberCtxBuilder {
+	return b.Local("tenantId", id)
+}
+
+// Roles sets the "roles" local
+func (b *FiberCtxBuilder) Roles(roles ...string) *FiberCtxBuilder {
+	return b.Local("roles", roles)
+}
+
+// Permissions sets the "permissions" local
+func (b *FiberCtxBuilder) Permissions(permissions ...string) *FiberCtxBuilder {
+	return b.Local("permissions", permissions)
+}
+
+// TraceID sets the "traceId" local
+func (b *FiberCtxBuilder) TraceID(id string) *FiberCtxBuilder {
+	return b.Local("traceId", id)
+}
+
+// Authenticated sets the locals AuthMiddleware would set for a token from
+// NewToken with default options
+func (b *FiberCtxBuilder) Authenticated() *FiberCtxBuilder {
+	return b.UserID(TestUserID).
+		TenantID(TestTenantID).
+		Local("email", "test@example.com").
+		Roles("user").
+		Permissions()
+}
+
+// UserContext sets the context returned by c.UserContext
+func (b *FiberCtxBuilder) UserContext(ctx context.Context) *FiberCtxBuilder {
+	b.userContext = ctx
+	return b
+}
+
+func (b *FiberCtxBuilder) target() string {
+	target := b.path
+	if len(b.query) > 0 {
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		target += sep + b.query.Encode()
+	}
+	return target
+}
+
+func (b *FiberCtxBuilder) apply(c *fiber.Ctx) {
+	for key, value := range b.locals {
+		c.Locals(key, value)
+	}
+	if b.userContext != nil {
+		c.SetUserContext(b.userContext)
+	}
+}
+
+// Build returns a standalone *fiber.Ctx for calling a handler directly. It
+// is released when the test ends. Route params are not resolved, use Run
+// for handlers reading c.Params
+func (b *FiberCtxBuilder) Build(t testing.TB) *fiber.Ctx {
+	t.Helper()
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+
+	req := &fasthttp.RequestCtx{}
+	req.Request.Header.SetMethod(b.method)
+	req.Request.SetRequestURI(b.target())
+	for key, value := range b.headers {
+		req.Request.Header.Set(key, value)
+	}
+	if b.body != nil {
+		req.Request.SetBody(b.body)
+	}
+
+	c := app.AcquireCtx(req)
+	b.apply(c)
+	t.Cleanup(func() { app.ReleaseCtx(c) })
+	return c
+}
+
+// Run mounts handlers on a one-route app behind a middleware setting the
+// locals, performs the request and returns the response
+func (b *FiberCtxBuilder) Run(t testing.TB, handlers ...fiber.Handler) *HTTPResponse {
+	t.Helper()
+	app := TestApp()
+
+	route := b.route
+	if route == "" {
+		route, _, _ = strings.Cut(b.path, "?")
+	}
+	chain := append([]fiber.Handler{func(c *fiber.Ctx) error {
+		b.apply(c)
+		return c.Next()
+	}}, handlers...)
+	app.Add(b.method, route, chain...)
+
+	var body io.Reader
+	if b.body != nil {
+		body = strings.NewReader(string(b.body))
+	}
+	req := httptest.NewRequest(b.method, b.target(), body)
+	for key, value := range b.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("run fiber handler: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read fiber response: %v", err)
+	}
+	return &HTTPResponse{
+		StatusCode: resp.StatusCode,
+		Body:       respBody,
+		Headers:    resp.Header,
+	}
+}
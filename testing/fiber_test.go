@@ -0,0 +1,138 @@
+package testing
+
+import (
+	"context"
+	stdtesting "testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey struct{}
+
+func TestFiberCtxRun(t *stdtesting.T) {
+	type order struct {
+		Item string `json:"item"`
+	}
+
+	resp := NewFiberCtx().
+		Method("post").
+		Route("/orders/:id", "/orders/42").
+		JSONBody(order{Item: "book"}).
+		Query("dry", "1").
+		Header("X-Request-ID", "req-1").
+		Authenticated().
+		TraceID("trace-1").
+		Run(t, func(c *fiber.Ctx) error {
+			var body order
+			if err := c.BodyParser(&body); err != nil {
+				return err
+			}
+			return c.JSON(fiber.Map{
+				"method":   c.Method(),
+				"id":       c.Params("id"),
+				"dry":      c.Query("dry"),
+				"request":  c.Get("X-Request-ID"),
+				"item":     body.Item,
+				"userId":   c.Locals("userId"),
+				"tenantId": c.Locals("tenantId"),
+				"email":    c.Locals("email"),
+				"roles":    c.Locals("roles"),
+				"traceId":  c.Locals("traceId"),
+			})
+		})
+
+	AssertOK(t, resp)
+	AssertJSONPath(t, resp, "method", fiber.MethodPost)
+	AssertJSONPath(t, resp, "id", "42")
+	AssertJSONPath(t, resp, "dry", "1")
+	AssertJSONPath(t, resp, "request", "req-1")
+	AssertJSONPath(t, resp, "item", "book")
+	AssertJSONPath(t, resp, "userId", TestUserID)
+	AssertJSONPath(t, resp, "tenantId", TestTenantID)
+	AssertJSONPath(t, resp, "email", "test@example.com")
+	AssertJSONPath(t, resp, "traceId", "trace-1")
+	AssertBodyContains(t, resp, `"roles":["user"]`)
+}
+
+func TestFiberCtxRunWithoutRoute(t *stdtesting.T) {
+	resp := NewFiberCtx().
+		Path("/health?verbose=1").
+		Query("probe", "ready").
+		Run(t, func(c *fiber.Ctx) error {
+			return c.SendString(c.Query("verbose") + "," + c.Query("probe"))
+		})
+
+	AssertOK(t, resp)
+	assert.Equal(t, "1,ready", resp.BodyString())
+}
+
+func TestFiberCtxRunMiddlewareChain(t *stdtesting.T) {
+	resp := NewFiberCtx().
+		UserID("user-1").
+		Run(t,
+			func(c *fiber.Ctx) error {
+				if c.Locals("userId") == nil {
+					return c.SendStatus(fiber.StatusUnauthorized)
+				}
+				return c.Next()
+			},
+			func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusNoContent)
+			})
+	AssertStatus(t, resp, fiber.StatusNoContent)
+
+	resp = NewFiberCtx().Run(t, func(c *fiber.Ctx) error {
+		if c.Locals("userId") == nil {
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	AssertUnauthorized(t, resp)
+}
+
+func TestFiberCtxBuild(t *stdtesting.T) {
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	c := NewFiberCtx().
+		Method("PUT").
+		Path("/users/7").
+		Query("force", "true").
+		Body([]byte("name=ada"), fiber.MIMEApplicationForm).
+		Header("Authorization", "Bearer token").
+		TenantID("tenant-1").
+		Roles("admin", "user").
+		Permissions("users:write").
+		UserContext(ctx).
+		Build(t)
+
+	assert.Equal(t, fiber.MethodPut, c.Method())
+	assert.Equal(t, "/users/7", c.Path())
+	assert.Equal(t, "true", c.Query("force"))
+	assert.Equal(t, "Bearer token", c.Get(fiber.HeaderAuthorization))
+	assert.Equal(t, "ada", c.FormValue("name"))
+	assert.Equal(t, "tenant-1", c.Locals("tenantId"))
+	assert.Equal(t, []string{"admin", "user"}, c.Locals("roles"))
+	assert.Equal(t, []string{"users:write"}, c.Locals("permissions"))
+	assert.Nil(t, c.Locals("userId"))
+	require.NotNil(t, c.UserContext())
+	assert.Equal(t, "value", c.UserContext().Value(ctxKey{}))
+}
+
+func TestFiberCtxBuildDefaults(t *stdtesting.T) {
+	c := NewFiberCtx().Authenticated().Build(t)
+
+	assert.Equal(t, fiber.MethodGet, c.Method())
+	assert.Equal(t, "/", c.Path())
+	assert.Equal(t, TestUserID, c.Locals("userId"))
+	assert.IsType(t, []string(nil), c.Locals("permissions"))
+	assert.Empty(t, c.Locals("permissions"))
+	assert.Empty(t, c.Body())
+}
+
+func TestFiberCtxJSONBodyPanicsOnUnencodable(t *stdtesting.T) {
+	assert.Panics(t, func() {
+		NewFiberCtx().JSONBody(make(chan int))
+	})
+}
@@ -0,0 +1,300 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// ============================================
+// Fixture Loader
+// ============================================
+
+// fixtureNameKey names a row so other rows can reference it
+const fixtureNameKey = "_name"
+
+// fixtureNamespace seeds the name based UUIDs returned by {{ uuid "name" }}
+var fixtureNamespace = uuid.MustParse("6ba7b812-9dad-11d1-80b4-00c04fd430c8")
+
+// FixtureLoader inserts declarative YAML or JSON fixtures through GORM.
+// A fixture file maps table names to rows, tables are inserted in file order:
+//
+//	users:
+//	  - _name: alice
+//	    id: '{{ uuid "alice" }}'
+//	    email: alice@example.com
+//	    created_at: '{{ now }}'
+//	orders:
+//	  - id: '{{ uuid }}'
+//	    user_id: '{{ ref "users.alice.id" }}'
+//	    expires_at: '{{ nowAdd "24h" }}'
+//
+// String values are text/template templates with these functions:
+//
+//	uuid            random UUID, or a UUID stable for a name: uuid "alice"
+//	now             the loader time, RFC 3339
+//	nowAdd "-1h"    the loader time plus a duration
+//	date "2024-01-15"  a date at midnight UTC
+//	ref "table.name.column"  a column of an earlier row, by _name or index
+//
+// Nested maps and lists are stored as JSON
+type FixtureLoader struct {
+	db    *gorm.DB
+	fsys  fs.FS
+	now   time.Time
+	funcs template.FuncMap
+	rows  map[string]map[string]map[string]interface{}
+}
+
+// FixtureOption configures a FixtureLoader
+type FixtureOption func(*FixtureLoader)
+
+// WithFixtureFS reads fixtures from fsys instead of the working directory
+func WithFixtureFS(fsys fs.FS) FixtureOption {
+	return func(l *FixtureLoader) {
+		l.fsys = fsys
+	}
+}
+
+// WithFixtureTime sets the time returned by now and nowAdd
+func WithFixtureTime(t time.Time) FixtureOption {
+	return func(l *FixtureLoader) {
+		l.now = t
+	}
+}
+
+// WithFixtureFunc adds a template function
+func WithFixtureFunc(name string, fn interface{}) FixtureOption {
+	return func(l *FixtureLoader) {
+		l.funcs[name] = fn
+	}
+}
+
+// NewFixtureLoader creates a loader inserting into db
+func NewFixtureLoader(db *gorm.DB, opts ...FixtureOption) *FixtureLoader {
+	l := &FixtureLoader{
+		db:    db,
+		fsys:  os.DirFS("."),
+		now:   time.Now().UTC(),
+		funcs: make(template.FuncMap),
+		rows:  make(map[string]map[string]map[string]interface{}),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// LoadFixtures loads fixture files or directories into db, failing the test
+// on error. Paths are relative to the package under test
+//
+//	testing.WithTx(t, db, func(tx *gorm.DB) {
+//		testing.LoadFixtures(t, tx, "testdata/fixtures")
+//	})
+func LoadFixtures(t testing.TB, db *gorm.DB, paths ...string) *FixtureLoader {
+	t.Helper()
+	l := NewFixtureLoader(db)
+	if err := l.Load(paths...); err != nil {
+		t.Fatalf("load fixtures: %v", err)
+	}
+	return l
+}
+
+// Load inserts the fixtures in paths inside one transaction. Directories
+// load their .yml, .yaml and .json files in name order
+func (l *FixtureLoader) Load(paths ...string) error {
+	var files []string
+	for _, p := range paths {
+		p = path.Clean(strings.TrimPrefix(p, "./"))
+		info, err := fs.Stat(l.fsys, p)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		entries, err := fs.ReadDir(l.fsys, p)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			switch path.Ext(entry.Name()) {
+			case ".yml", ".yaml", ".json":
+				if !entry.IsDir() {
+					files = append(files, path.Join(p, entry.Name()))
+				}
+			}
+		}
+	}
+
+	return l.db.Transaction(func(tx *gorm.DB) error {
+		for _, file := range files {
+			if err := l.loadFile(tx, file); err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Ref returns a column of a loaded row, e.g. Ref("users.alice.id")
+func (l *FixtureLoader) Ref(ref string) (interface{}, error) {
+	parts := strings.Split(ref, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("fixture ref %q: want table.row.column", ref)
+	}
+	row, ok := l.rows[parts[0]][parts[1]]
+	if !ok {
+		return nil, fmt.Errorf("fixture ref %q: unknown row", ref)
+	}
+	value, ok := row[parts[2]]
+	if !ok {
+		return nil, fmt.Errorf("fixture ref %q: unknown column", ref)
+	}
+	return value, nil
+}
+
+func (l *FixtureLoader) loadFile(tx *gorm.DB, file string) error {
+	data, err := fs.ReadFile(l.fsys, file)
+	if err != nil {
+		return err
+	}
+
+	// yaml.v3 also parses JSON and, unlike maps, the node keeps table order
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return errors.New("fixture file must map table names to rows")
+	}
+
+	for i := 0; i < len(root.Content); i += 2 {
+		table := root.Content[i].Value
+		var rows []map[string]interface{}
+		if err := root.Content[i+1].Decode(&rows); err != nil {
+			return fmt.Errorf("table %s: %w", table, err)
+		}
+		for n, row := range rows {
+			if err := l.insert(tx, table, n, row); err != nil {
+				return fmt.Errorf("table %s row %d: %w", table, n, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (l *FixtureLoader) insert(tx *gorm.DB, table string, index int, row map[string]interface{}) error {
+	name := strconv.Itoa(index)
+	if v, ok := row[fixtureNameKey]; ok {
+		name = fmt.Sprint(v)
+		delete(row, fixtureNameKey)
+	}
+
+	for column, value := range row {
+		resolved, err := l.resolve(value)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", column, err)
+		}
+		row[column] = resolved
+	}
+	if err := tx.Table(table).Create(row).Error; err != nil {
+		return err
+	}
+
+	if l.rows[table] == nil {
+		l.rows[table] = make(map[string]map[string]interface{})
+	}
+	l.rows[table][name] = row
+	l.rows[table][strconv.Itoa(index)] = row
+	return nil
+}
+
+func (l *FixtureLoader) resolve(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, nil
+		}
+		tmpl, err := template.New("").Funcs(l.templateFuncs()).Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, nil); err != nil {
+			return nil, err
+		}
+		return buf.String(), nil
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	default:
+		return v, nil
+	}
+}
+
+func (l *FixtureLoader) templateFuncs() template.FuncMap {
+	funcs := template.FuncMap{
+		"uuid": func(name ...string) string {
+			if len(name) == 0 {
+				return uuid.NewString()
+			}
+			return uuid.NewSHA1(fixtureNamespace, []byte(strings.Join(name, "."))).String()
+		},
+		"now": func() string {
+			return l.now.Format(time.RFC3339Nano)
+		},
+		"nowAdd": func(d string) (string, error) {
+			dur, err := time.ParseDuration(d)
+			if err != nil {
+				return "", err
+			}
+			return l.now.Add(dur).Format(time.RFC3339Nano), nil
+		},
+		"date": func(s string) (string, error) {
+			t, err := time.Parse(time.DateOnly, s)
+			if err != nil {
+				return "", err
+			}
+			return t.Format(time.RFC3339), nil
+		},
+		"ref": func(ref string) (string, error) {
+			v, err := l.Ref(ref)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprint(v), nil
+		},
+	}
+	for name, fn := range l.funcs {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// FixtureUUID returns the UUID {{ uuid name }} produces in fixtures, for
+// asserting on fixture rows from Go
+func FixtureUUID(name string) uuid.UUID {
+	return uuid.NewSHA1(fixtureNamespace, []byte(name))
+}
@@ -0,0 +1,160 @@
+package testing
+
+import (
+	"strings"
+	stdtesting "testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var fixtureTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newFixtureDB(t *stdtesting.T) *gorm.DB {
+	db := newTestDB(t)
+	require.NoError(t, db.Exec(`CREATE TABLE accounts (id TEXT PRIMARY KEY, email TEXT, created_at TEXT, meta TEXT)`).Error)
+	require.NoError(t, db.Exec(`CREATE TABLE orders (id TEXT PRIMARY KEY, account_id TEXT, total INTEGER, expires_at TEXT, placed_on TEXT)`).Error)
+	return db
+}
+
+func countTableRows(t *stdtesting.T, db *gorm.DB, table string) int64 {
+	var n int64
+	require.NoError(t, db.Table(table).Count(&n).Error)
+	return n
+}
+
+func TestFixtureLoaderLoadsYAML(t *stdtesting.T) {
+	db := newFixtureDB(t)
+	fsys := fstest.MapFS{
+		"fixtures.yml": {Data: []byte(`
+accounts:
+  - _name: alice
+    id: '{{ uuid "alice" }}'
+    email: alice@example.com
+    created_at: '{{ now }}'
+    meta:
+      plan: pro
+      tags: [a, b]
+  - id: '{{ uuid }}'
+    email: '{{ upper "bob" }}@example.com'
+orders:
+  - id: order-1
+    account_id: '{{ ref "accounts.alice.id" }}'
+    total: 250
+    expires_at: '{{ nowAdd "24h" }}'
+    placed_on: '{{ date "2024-01-15" }}'
+`)},
+	}
+	l := NewFixtureLoader(db,
+		WithFixtureFS(fsys),
+		WithFixtureTime(fixtureTime),
+		WithFixtureFunc("upper", strings.ToUpper),
+	)
+	require.NoError(t, l.Load("./fixtures.yml"))
+
+	alice := FixtureUUID("alice").String()
+	var account struct {
+		ID        string
+		Email     string
+		CreatedAt string
+		Meta      string
+	}
+	require.NoError(t, db.Table("accounts").Where("email = ?", "alice@example.com").Take(&account).Error)
+	assert.Equal(t, alice, account.ID)
+	assert.Equal(t, "2024-03-01T12:00:00Z", account.CreatedAt)
+	assert.JSONEq(t, `{"plan":"pro","tags":["a","b"]}`, account.Meta)
+	assert.Equal(t, int64(1), countTableRows(t, db.Where("email = ?", "BOB@example.com"), "accounts"))
+
+	var order struct {
+		AccountID string
+		Total     int
+		ExpiresAt string
+		PlacedOn  string
+	}
+	require.NoError(t, db.Table("orders").Where("id = ?", "order-1").Take(&order).Error)
+	assert.Equal(t, alice, order.AccountID)
+	assert.Equal(t, 250, order.Total)
+	assert.Equal(t, "2024-03-02T12:00:00Z", order.ExpiresAt)
+	assert.Equal(t, "2024-01-15T00:00:00Z", order.PlacedOn)
+
+	ref, err := l.Ref("accounts.alice.email")
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", ref)
+	ref, err = l.Ref("accounts.0.id")
+	require.NoError(t, err)
+	assert.Equal(t, alice, ref, "rows are also named by index")
+	_, hasName := l.rows["accounts"]["alice"][fixtureNameKey]
+	assert.False(t, hasName, "_name is not inserted")
+}
+
+func TestFixtureLoaderLoadsDirectoryInNameOrder(t *stdtesting.T) {
+	db := newFixtureDB(t)
+	fsys := fstest.MapFS{
+		"fixtures/01_accounts.json": {Data: []byte(`{"accounts": [{"_name": "carol", "id": "acc-1", "email": "carol@example.com"}]}`)},
+		"fixtures/02_orders.yaml": {Data: []byte(`
+orders:
+  - id: order-1
+    account_id: '{{ ref "accounts.carol.id" }}'
+`)},
+		"fixtures/README.md":    {Data: []byte("not a fixture")},
+		"fixtures/nested/x.yml": {Data: []byte("accounts: [{id: ignored}]")},
+	}
+	l := NewFixtureLoader(db, WithFixtureFS(fsys))
+	require.NoError(t, l.Load("fixtures"))
+
+	assert.Equal(t, int64(1), countTableRows(t, db, "accounts"))
+	var accountID string
+	require.NoError(t, db.Table("orders").Select("account_id").Where("id = ?", "order-1").Scan(&accountID).Error)
+	assert.Equal(t, "acc-1", accountID)
+}
+
+func TestFixtureLoaderRollsBackOnError(t *stdtesting.T) {
+	db := newFixtureDB(t)
+	fsys := fstest.MapFS{
+		"bad.yml": {Data: []byte(`
+accounts:
+  - id: acc-1
+orders:
+  - id: order-1
+    account_id: '{{ ref "accounts.missing.id" }}'
+`)},
+	}
+	err := NewFixtureLoader(db, WithFixtureFS(fsys)).Load("bad.yml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad.yml: table orders row 0: column account_id")
+	assert.Contains(t, err.Error(), `fixture ref "accounts.missing.id": unknown row`)
+	assert.Zero(t, countTableRows(t, db, "accounts"), "earlier rows are rolled back")
+}
+
+func TestFixtureLoaderErrors(t *stdtesting.T) {
+	db := newFixtureDB(t)
+	fsys := fstest.MapFS{
+		"list.yml":     {Data: []byte("- id: 1")},
+		"empty.yml":    {Data: []byte("")},
+		"duration.yml": {Data: []byte(`orders: [{id: '{{ nowAdd "soon" }}'}]`)},
+	}
+	l := NewFixtureLoader(db, WithFixtureFS(fsys))
+
+	assert.Error(t, l.Load("missing.yml"))
+	assert.ErrorContains(t, l.Load("list.yml"), "must map table names to rows")
+	assert.ErrorContains(t, l.Load("duration.yml"), "invalid duration")
+	assert.NoError(t, l.Load("empty.yml"))
+
+	_, err := l.Ref("accounts.alice")
+	assert.ErrorContains(t, err, "want table.row.column")
+}
+
+func TestLoadFixturesFailsTest(t *stdtesting.T) {
+	rec := &failureRecorder{TB: t}
+	LoadFixtures(rec, newFixtureDB(t), "testdata/does-not-exist")
+	require.Len(t, rec.errors, 1)
+	assert.Contains(t, rec.errors[0], "load fixtures:")
+}
+
+func TestFixtureUUIDIsStable(t *stdtesting.T) {
+	assert.Equal(t, FixtureUUID("alice"), FixtureUUID("alice"))
+	assert.NotEqual(t, FixtureUUID("alice"), FixtureUUID("bob"))
+}
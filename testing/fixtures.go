@@ -1,6 +1,7 @@
 package testing
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,7 @@ import (
 // FixtureBuilder helps build test data
 type FixtureBuilder struct {
 	counter int
+	faker   *Faker
 }
 
 // NewFixtureBuilder creates a new fixture builder
@@ -20,8 +22,17 @@ func NewFixtureBuilder() *FixtureBuilder {
 	return &FixtureBuilder{}
 }
 
-// NextID returns a new UUID
+// NewSeededFixtureBuilder creates a fixture builder whose IDs are derived
+// from seed, for reproducible snapshots
+func NewSeededFixtureBuilder(seed uint64) *FixtureBuilder {
+	return &FixtureBuilder{faker: NewFaker(seed)}
+}
+
+// NextID returns a new UUID, deterministic for seeded builders
 func (f *FixtureBuilder) NextID() uuid.UUID {
+	if f.faker != nil {
+		return f.faker.UUID()
+	}
 	return uuid.New()
 }
 
@@ -34,7 +45,7 @@ func (f *FixtureBuilder) NextInt() int {
 // NextEmail generates a unique email
 func (f *FixtureBuilder) NextEmail() string {
 	f.counter++
-	return "test" + string(rune('0'+f.counter)) + "@example.com"
+	return "test" + strconv.Itoa(f.counter) + "@example.com"
 }
 
 // NextPhone generates a unique phone number
@@ -0,0 +1,262 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// ============================================
+// Golden Files
+// ============================================
+
+// updateGolden reports whether golden files are rewritten instead of
+// compared: UPDATE_GOLDEN=1, or a boolean -update flag defined by the test
+// package. The flag is looked up rather than registered here so that it
+// doesn't clash with packages declaring their own
+func updateGolden() bool {
+	if os.Getenv("UPDATE_GOLDEN") == "1" {
+		return true
+	}
+	f := flag.Lookup("update")
+	if f == nil {
+		return false
+	}
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return false
+	}
+	update, _ := getter.Get().(bool)
+	return update
+}
+
+// GoldenDir is the directory golden files are read from, relative to the
+// package under test
+var GoldenDir = "testdata"
+
+const ignoredGoldenValue = "<ignored>"
+
+type goldenOptions struct {
+	ignore    [][]string
+	unordered bool
+}
+
+// GoldenOption configures AssertGolden
+type GoldenOption func(*goldenOptions)
+
+// IgnorePaths skips JSON values at dot separated paths, where "*" matches
+// any key or array index, e.g. "meta.timestamp" or "data.items.*.id"
+func IgnorePaths(paths ...string) GoldenOption {
+	return func(o *goldenOptions) {
+		for _, p := range paths {
+			o.ignore = append(o.ignore, strings.Split(p, "."))
+		}
+	}
+}
+
+// UnorderedArrays compares JSON arrays as multisets
+func UnorderedArrays() GoldenOption {
+	return func(o *goldenOptions) {
+		o.unordered = true
+	}
+}
+
+// AssertGolden compares got with testdata/<name>.golden. Strings and byte
+// slices are stored as is (indented if they hold JSON), other values as
+// indented JSON. When both sides are JSON the comparison ignores object key
+// order and the ignored paths
+//
+//	testing.AssertGolden(t, "users/list", resp.Body, testing.IgnorePaths("meta.timestamp", "data.*.id"))
+func AssertGolden(t testing.TB, name string, got interface{}, opts ...GoldenOption) {
+	t.Helper()
+
+	o := &goldenOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	data, err := goldenBytes(got)
+	if err != nil {
+		t.Fatalf("golden %s: %v", name, err)
+	}
+	file := filepath.Join(GoldenDir, filepath.FromSlash(name)+".golden")
+
+	if updateGolden() {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatalf("golden %s: %v", name, err)
+		}
+		if err := os.WriteFile(file, data, 0o644); err != nil {
+			t.Fatalf("golden %s: %v", name, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("golden %s: %v (run with UPDATE_GOLDEN=1 to create it)", name, err)
+	}
+
+	var wantJSON, gotJSON interface{}
+	if json.Unmarshal(want, &wantJSON) == nil && json.Unmarshal(data, &gotJSON) == nil {
+		wantJSON = o.normalize(wantJSON, nil)
+		gotJSON = o.normalize(gotJSON, nil)
+		if diffs := jsonDiff("", wantJSON, gotJSON, nil); len(diffs) > 0 {
+			t.Errorf("golden %s mismatch (run with UPDATE_GOLDEN=1 to accept):\n%s", name, strings.Join(diffs, "\n"))
+		}
+		return
+	}
+
+	if !bytes.Equal(bytes.TrimSpace(want), bytes.TrimSpace(data)) {
+		t.Errorf("golden %s mismatch (run with UPDATE_GOLDEN=1 to accept):\n--- want\n%s\n--- got\n%s", name, want, data)
+	}
+}
+
+func goldenBytes(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return prettyJSON(v), nil
+	case string:
+		return prettyJSON([]byte(v)), nil
+	case json.RawMessage:
+		return prettyJSON(v), nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// prettyJSON indents JSON input so golden files diff well; other input is
+// returned unchanged
+func prettyJSON(data []byte) []byte {
+	var buf bytes.Buffer
+	if json.Indent(&buf, data, "", "  ") != nil {
+		return data
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// normalize replaces ignored values and sorts unordered arrays
+func (o *goldenOptions) normalize(v interface{}, path []string) interface{} {
+	for _, ignore := range o.ignore {
+		if matchGoldenPath(ignore, path) {
+			return ignoredGoldenValue
+		}
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = o.normalize(child, append(path, k))
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = o.normalize(child, append(path, strconv.Itoa(i)))
+		}
+		if o.unordered {
+			sort.Slice(v, func(i, j int) bool {
+				a, _ := json.Marshal(v[i])
+				b, _ := json.Marshal(v[j])
+				return string(a) < string(b)
+			})
+		}
+	}
+	return v
+}
+
+func matchGoldenPath(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonDiff lists the paths where want and got differ
+func jsonDiff(path string, want, got interface{}, diffs []string) []string {
+	const maxDiffs = 20
+	if len(diffs) >= maxDiffs {
+		return diffs
+	}
+	at := path
+	if at == "" {
+		at = "$"
+	}
+
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return append(diffs, fmt.Sprintf("%s: want object, got %s", at, compactJSON(got)))
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := joinGoldenPath(path, k)
+			wv, inWant := w[k]
+			gv, inGot := g[k]
+			switch {
+			case !inGot:
+				diffs = append(diffs, fmt.Sprintf("%s: missing, want %s", child, compactJSON(wv)))
+			case !inWant:
+				diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", child, compactJSON(gv)))
+			default:
+				diffs = jsonDiff(child, wv, gv, diffs)
+			}
+		}
+		return diffs
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			return append(diffs, fmt.Sprintf("%s: want array, got %s", at, compactJSON(got)))
+		}
+		if len(w) != len(g) {
+			return append(diffs, fmt.Sprintf("%s: want %d elements, got %d", at, len(w), len(g)))
+		}
+		for i := range w {
+			diffs = jsonDiff(joinGoldenPath(path, strconv.Itoa(i)), w[i], g[i], diffs)
+		}
+		return diffs
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		diffs = append(diffs, fmt.Sprintf("%s: want %s, got %s", at, compactJSON(want), compactJSON(got)))
+	}
+	return diffs
+}
+
+func joinGoldenPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func compactJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
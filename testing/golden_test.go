@@ -0,0 +1,81 @@
+package testing
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	stdtesting "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Packages using golden files commonly declare their own -update flag; the
+// helper must not register one that clashes with it
+var update = flag.Bool("update", false, "update golden files")
+
+// failureRecorder captures the failures reported by a helper
+type failureRecorder struct {
+	stdtesting.TB
+	errors []string
+}
+
+func (r *failureRecorder) Helper() {}
+
+func (r *failureRecorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *failureRecorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+}
+
+func useGoldenDir(t *stdtesting.T) string {
+	dir := t.TempDir()
+	old := GoldenDir
+	GoldenDir = dir
+	t.Cleanup(func() { GoldenDir = old })
+	return dir
+}
+
+func TestAssertGoldenUpdatesAndCompares(t *stdtesting.T) {
+	dir := useGoldenDir(t)
+
+	t.Setenv("UPDATE_GOLDEN", "1")
+	AssertGolden(t, "users/list", map[string]interface{}{"id": 1, "name": "ali", "at": "10:00"})
+	data, err := os.ReadFile(filepath.Join(dir, "users", "list.golden"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"name":"ali","at":"10:00"}`, string(data))
+
+	t.Setenv("UPDATE_GOLDEN", "")
+	AssertGolden(t, "users/list", `{"name":"ali","at":"11:00","id":1}`, IgnorePaths("at"))
+
+	rec := &failureRecorder{TB: t}
+	AssertGolden(rec, "users/list", `{"name":"sara","at":"10:00","id":1}`)
+	require.Len(t, rec.errors, 1)
+	assert.Contains(t, rec.errors[0], `name: want "ali", got "sara"`)
+}
+
+func TestAssertGoldenUsesUpdateFlag(t *stdtesting.T) {
+	dir := useGoldenDir(t)
+	*update = true
+	defer func() { *update = false }()
+
+	AssertGolden(t, "plain", "hello")
+	data, err := os.ReadFile(filepath.Join(dir, "plain.golden"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestAssertGoldenUnorderedArrays(t *stdtesting.T) {
+	useGoldenDir(t)
+	t.Setenv("UPDATE_GOLDEN", "1")
+	AssertGolden(t, "ids", []int{1, 2, 3})
+	t.Setenv("UPDATE_GOLDEN", "")
+
+	AssertGolden(t, "ids", []int{3, 1, 2}, UnorderedArrays())
+	rec := &failureRecorder{TB: t}
+	AssertGolden(rec, "ids", []int{3, 1, 2})
+	assert.NotEmpty(t, rec.errors)
+}
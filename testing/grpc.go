@@ -0,0 +1,199 @@
+package testing
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+
+	grpcauth "github.com/minisource/go-common/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// ============================================
+// In-Memory gRPC Server
+// ============================================
+
+const bufconnSize = 1024 * 1024
+
+// NewGRPCServer starts a gRPC server on an in-memory listener, registers
+// services with register and returns a client connection to it. Server and
+// connection are closed when the test ends
+//
+//	conn := testing.NewGRPCServer(t, func(s *grpc.Server) {
+//		pb.RegisterUserServiceServer(s, handler)
+//	}, testing.FakeGRPCAuth(testing.ValidGRPCResult("users:read")))
+//	client := pb.NewUserServiceClient(conn)
+func NewGRPCServer(t testing.TB, register func(*grpc.Server), interceptors ...grpc.UnaryServerInterceptor) *grpc.ClientConn {
+	t.Helper()
+	return NewGRPCServerWithOptions(t, register, []grpc.ServerOption{grpc.ChainUnaryInterceptor(interceptors...)})
+}
+
+// NewGRPCServerWithOptions is NewGRPCServer with explicit server options
+// (stream interceptors, limits) and extra dial options
+func NewGRPCServerWithOptions(t testing.TB, register func(*grpc.Server), serverOpts []grpc.ServerOption, dialOpts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(bufconnSize)
+	server := grpc.NewServer(serverOpts...)
+	register(server)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := server.Serve(lis); err != nil && err != grpc.ErrServerStopped {
+			t.Errorf("grpc test server: %v", err)
+		}
+	}()
+
+	dialOpts = append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, dialOpts...)
+	conn, err := grpc.NewClient("passthrough:///bufnet", dialOpts...)
+	if err != nil {
+		server.Stop()
+		t.Fatalf("dial grpc test server: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		server.Stop()
+		<-done
+	})
+	return conn
+}
+
+// ============================================
+// Metadata Helpers
+// ============================================
+
+// WithBearerToken returns an outgoing context carrying token in the
+// authorization metadata, as read by the grpc auth interceptors
+func WithBearerToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+// WithMetadata returns an outgoing context with the key/value pairs appended
+func WithMetadata(ctx context.Context, kv ...string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// MetadataRecorder captures the incoming metadata of every unary call
+type MetadataRecorder struct {
+	mu    sync.Mutex
+	calls map[string][]metadata.MD
+	last  metadata.MD
+}
+
+// NewMetadataRecorder creates an empty recorder; install Interceptor on the server
+func NewMetadataRecorder() *MetadataRecorder {
+	return &MetadataRecorder{calls: make(map[string][]metadata.MD)}
+}
+
+// Interceptor returns a unary interceptor recording incoming metadata
+func (r *MetadataRecorder) Interceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		md = md.Copy()
+		r.mu.Lock()
+		r.calls[info.FullMethod] = append(r.calls[info.FullMethod], md)
+		r.last = md
+		r.mu.Unlock()
+		return handler(ctx, req)
+	}
+}
+
+// Last returns the metadata of the most recent call
+func (r *MetadataRecorder) Last() metadata.MD {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// For returns the metadata of every call to a full method name,
+// e.g. "/users.v1.UserService/GetUser"
+func (r *MetadataRecorder) For(fullMethod string) []metadata.MD {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]metadata.MD(nil), r.calls[fullMethod]...)
+}
+
+// AssertMetadata fails the test unless md holds exactly the values for key
+func AssertMetadata(t testing.TB, md metadata.MD, key string, values ...string) {
+	t.Helper()
+	got := md.Get(key)
+	if len(got) == 0 && len(values) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, values) {
+		t.Errorf("expected metadata %q = %v, got %v", key, values, got)
+	}
+}
+
+// AssertNoMetadata fails the test if md holds key
+func AssertNoMetadata(t testing.TB, md metadata.MD, key string) {
+	t.Helper()
+	if got := md.Get(key); len(got) > 0 {
+		t.Errorf("expected no metadata %q, got %v", key, got)
+	}
+}
+
+// ============================================
+// Simulated Auth
+// ============================================
+
+// ValidGRPCResult returns a valid gRPC validation result for the test client
+// with the given scopes
+func ValidGRPCResult(scopes ...string) *grpcauth.TokenValidationResult {
+	result := grpcauth.TokenValidationResult(*ValidServiceResult(scopes...))
+	return &result
+}
+
+// FakeGRPCAuth returns an interceptor that authenticates every call as
+// result without looking at metadata, so handlers can read the caller
+// through grpc.GetServiceClientID and friends
+func FakeGRPCAuth(result *grpcauth.TokenValidationResult) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = context.WithValue(ctx, grpcauth.ServiceClientIDKey, result.ClientID)
+		ctx = context.WithValue(ctx, grpcauth.ServiceNameKey, result.ServiceName)
+		ctx = context.WithValue(ctx, grpcauth.ServiceScopesKey, result.Scopes)
+		ctx = context.WithValue(ctx, grpcauth.TenantIDKey, result.TenantID)
+		if result.UserID != "" {
+			ctx = context.WithValue(ctx, grpcauth.UserIDKey, result.UserID)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// GRPCAuthInterceptor returns the real grpc.UnaryAuthInterceptor backed by
+// the fake validator, for tests exercising token handling end to end. The
+// gRPC token cache is cleared first
+func GRPCAuthInterceptor(v *FakeTokenValidator, scopeMap map[string]string) grpc.UnaryServerInterceptor {
+	grpcauth.ClearGRPCTokenCache()
+	return grpcauth.UnaryAuthInterceptor(grpcauth.AuthInterceptorConfig{
+		TokenValidator: grpcValidator{v},
+		ScopeMap:       scopeMap,
+		Enabled:        true,
+	})
+}
+
+// grpcValidator adapts FakeTokenValidator to grpc.TokenValidator
+type grpcValidator struct {
+	v *FakeTokenValidator
+}
+
+func (a grpcValidator) ValidateToken(ctx context.Context, token string) (*grpcauth.TokenValidationResult, error) {
+	result, err := a.v.ValidateToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	converted := grpcauth.TokenValidationResult(*result)
+	return &converted, nil
+}
@@ -2,11 +2,22 @@ package testing
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/minisource/go-common/cache"
+	"github.com/minisource/go-common/httpclient"
 )
 
 // ============================================
@@ -141,129 +152,706 @@ func (r *MockRepository[T]) Reset() {
 // Mock Cache
 // ============================================
 
-// MockCache is an in-memory cache for testing
+// MockCacheCall is a call captured by MockCache
+type MockCacheCall struct {
+	Method string
+	Key    string
+}
+
+// MockCache is an in-memory cache.Cache, cache.HashCache and cache.ListCache
+// for testing. Errors and latency can be injected per method, and every call
+// is recorded for assertions. A zero TTL means the key never expires
 type MockCache struct {
-	mu    sync.RWMutex
-	items map[string]mockCacheItem
+	mu      sync.Mutex
+	items   map[string]*mockCacheItem
+	errs    map[string]error
+	delays  map[string]time.Duration
+	calls   []MockCacheCall
+	closed  bool
+	encoder cache.Serializer
 
-	// Error injection
-	ErrGet    error
-	ErrSet    error
-	ErrDelete error
+	// Latency is added to every call on top of per-method latency
+	Latency time.Duration
 }
 
 type mockCacheItem struct {
-	value     interface{}
+	value     []byte
+	hash      map[string][]byte
+	list      [][]byte
 	expiresAt time.Time
 }
 
+var (
+	_ cache.Cache     = (*MockCache)(nil)
+	_ cache.HashCache = (*MockCache)(nil)
+	_ cache.ListCache = (*MockCache)(nil)
+)
+
+var errMockCacheWrongType = errors.New("testing: operation against a key holding the wrong kind of value")
+
 // NewMockCache creates a new mock cache
 func NewMockCache() *MockCache {
 	return &MockCache{
-		items: make(map[string]mockCacheItem),
+		items:   make(map[string]*mockCacheItem),
+		errs:    make(map[string]error),
+		delays:  make(map[string]time.Duration),
+		encoder: &cache.JSONSerializer{},
 	}
 }
 
-// Get retrieves a value
-func (c *MockCache) Get(ctx context.Context, key string, dest interface{}) error {
-	if c.ErrGet != nil {
-		return c.ErrGet
+// SetError makes method (e.g. "Get", "HSet") return err until cleared with
+// a nil error. It panics for methods MockCache doesn't have
+func (c *MockCache) SetError(method string, err error) *MockCache {
+	mustHaveMethod(c, method)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		delete(c.errs, method)
+	} else {
+		c.errs[method] = err
 	}
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	return c
+}
 
-	item, exists := c.items[key]
-	if !exists {
-		return errors.New("key not found")
+// SetLatency delays every call to method by d
+func (c *MockCache) SetLatency(method string, d time.Duration) *MockCache {
+	mustHaveMethod(c, method)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.delays[method] = d
+	return c
+}
+
+// enter records a call, waits for the injected latency and returns the
+// injected error for method
+func (c *MockCache) enter(ctx context.Context, method, key string) error {
+	c.mu.Lock()
+	c.calls = append(c.calls, MockCacheCall{Method: method, Key: key})
+	delay := c.Latency + c.delays[method]
+	err := c.errs[method]
+	c.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// live returns the unexpired item for key; must be called with c.mu held
+func (c *MockCache) live(key string) *mockCacheItem {
+	item, ok := c.items[key]
+	if !ok {
+		return nil
 	}
 	if !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
-		return errors.New("key expired")
+		delete(c.items, key)
+		return nil
 	}
+	return item
+}
 
-	// Simple copy for basic types
-	switch d := dest.(type) {
-	case *string:
-		if v, ok := item.value.(string); ok {
-			*d = v
-		}
-	case *int:
-		if v, ok := item.value.(int); ok {
-			*d = v
-		}
+func mockExpiry(ttl time.Duration) time.Time {
+	if ttl > 0 {
+		return time.Now().Add(ttl)
 	}
-	return nil
+	return time.Time{}
+}
+
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
 }
 
-// Set stores a value
-func (c *MockCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
-	if c.ErrSet != nil {
-		return c.ErrSet
+// Get retrieves a value by key
+func (c *MockCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := c.enter(ctx, "Get", key); err != nil {
+		return nil, err
 	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	item := c.live(key)
+	if item == nil {
+		return nil, cache.ErrKeyNotFound
+	}
+	if item.hash != nil || item.list != nil {
+		return nil, errMockCacheWrongType
+	}
+	return cloneBytes(item.value), nil
+}
 
-	item := mockCacheItem{value: value}
-	if ttl > 0 {
-		item.expiresAt = time.Now().Add(ttl)
+// GetObject retrieves and unmarshals a value
+func (c *MockCache) GetObject(ctx context.Context, key string, dest interface{}) error {
+	if err := c.enter(ctx, "GetObject", key); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	item := c.live(key)
+	var data []byte
+	if item != nil {
+		data = cloneBytes(item.value)
+	}
+	c.mu.Unlock()
+	if item == nil {
+		return cache.ErrKeyNotFound
 	}
-	c.items[key] = item
+	return c.encoder.Unmarshal(data, dest)
+}
+
+// Set stores a value with optional TTL
+func (c *MockCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.enter(ctx, "Set", key); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = &mockCacheItem{value: cloneBytes(value), expiresAt: mockExpiry(ttl)}
 	return nil
 }
 
-// Delete removes a value
-func (c *MockCache) Delete(ctx context.Context, key string) error {
-	if c.ErrDelete != nil {
-		return c.ErrDelete
+// SetObject marshals and stores a value
+func (c *MockCache) SetObject(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if err := c.enter(ctx, "SetObject", key); err != nil {
+		return err
+	}
+	data, err := c.encoder.Marshal(value)
+	if err != nil {
+		return err
 	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.items[key] = &mockCacheItem{value: data, expiresAt: mockExpiry(ttl)}
+	return nil
+}
 
+// Delete removes a key
+func (c *MockCache) Delete(ctx context.Context, key string) error {
+	if err := c.enter(ctx, "Delete", key); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	delete(c.items, key)
 	return nil
 }
 
 // Exists checks if key exists
 func (c *MockCache) Exists(ctx context.Context, key string) (bool, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	if err := c.enter(ctx, "Exists", key); err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.live(key) != nil, nil
+}
 
-	item, exists := c.items[key]
-	if !exists {
-		return false, nil
+// TTL returns remaining TTL for key, -1 for keys without expiry
+func (c *MockCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if err := c.enter(ctx, "TTL", key); err != nil {
+		return 0, err
 	}
-	if !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item := c.live(key)
+	if item == nil {
+		return 0, cache.ErrKeyNotFound
+	}
+	if item.expiresAt.IsZero() {
+		return -1, nil
+	}
+	return time.Until(item.expiresAt), nil
+}
+
+// Increment increments a numeric value
+func (c *MockCache) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	if err := c.enter(ctx, "Increment", key); err != nil {
+		return 0, err
+	}
+	return c.incr(key, delta)
+}
+
+// Decrement decrements a numeric value
+func (c *MockCache) Decrement(ctx context.Context, key string, delta int64) (int64, error) {
+	if err := c.enter(ctx, "Decrement", key); err != nil {
+		return 0, err
+	}
+	return c.incr(key, -delta)
+}
+
+func (c *MockCache) incr(key string, delta int64) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item := c.live(key)
+	if item == nil {
+		item = &mockCacheItem{}
+		c.items[key] = item
+	}
+	if item.hash != nil || item.list != nil {
+		return 0, errMockCacheWrongType
+	}
+	var n int64
+	if len(item.value) > 0 {
+		var err error
+		if n, err = strconv.ParseInt(string(item.value), 10, 64); err != nil {
+			return 0, fmt.Errorf("testing: value of %q is not an integer", key)
+		}
+	}
+	n += delta
+	item.value = []byte(strconv.FormatInt(n, 10))
+	return n, nil
+}
+
+// SetNX sets value only if not exists (returns true if set)
+func (c *MockCache) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	if err := c.enter(ctx, "SetNX", key); err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.live(key) != nil {
 		return false, nil
 	}
+	c.items[key] = &mockCacheItem{value: cloneBytes(value), expiresAt: mockExpiry(ttl)}
 	return true, nil
 }
 
-// Reset clears all data
+// GetSet sets new value and returns old value
+func (c *MockCache) GetSet(ctx context.Context, key string, value []byte) ([]byte, error) {
+	if err := c.enter(ctx, "GetSet", key); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var old []byte
+	if item := c.live(key); item != nil {
+		old = item.value
+	}
+	c.items[key] = &mockCacheItem{value: cloneBytes(value)}
+	return old, nil
+}
+
+// Keys returns keys matching a glob pattern, e.g. "user:*"
+func (c *MockCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	if err := c.enter(ctx, "Keys", pattern); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var keys []string
+	for key := range c.items {
+		if ok, _ := path.Match(pattern, key); ok && c.live(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// DeleteMany deletes multiple keys
+func (c *MockCache) DeleteMany(ctx context.Context, keys ...string) error {
+	if err := c.enter(ctx, "DeleteMany", strings.Join(keys, ",")); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.items, key)
+	}
+	return nil
+}
+
+// Ping checks connection
+func (c *MockCache) Ping(ctx context.Context) error {
+	return c.enter(ctx, "Ping", "")
+}
+
+// Close marks the cache closed, see Closed
+func (c *MockCache) Close() error {
+	if err := c.enter(context.Background(), "Close", ""); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+// Closed reports whether Close was called
+func (c *MockCache) Closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// hashFor returns the hash at key, creating it when create is set; must be
+// called with c.mu held
+func (c *MockCache) hashFor(key string, create bool) (map[string][]byte, error) {
+	item := c.live(key)
+	if item == nil {
+		if !create {
+			return nil, nil
+		}
+		item = &mockCacheItem{hash: make(map[string][]byte)}
+		c.items[key] = item
+	}
+	if item.hash == nil {
+		return nil, errMockCacheWrongType
+	}
+	return item.hash, nil
+}
+
+// HSet sets a hash field
+func (c *MockCache) HSet(ctx context.Context, key, field string, value []byte) error {
+	if err := c.enter(ctx, "HSet", key); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hash, err := c.hashFor(key, true)
+	if err != nil {
+		return err
+	}
+	hash[field] = cloneBytes(value)
+	return nil
+}
+
+// HGet gets a hash field
+func (c *MockCache) HGet(ctx context.Context, key, field string) ([]byte, error) {
+	if err := c.enter(ctx, "HGet", key); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hash, err := c.hashFor(key, false)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := hash[field]
+	if !ok {
+		return nil, cache.ErrKeyNotFound
+	}
+	return cloneBytes(value), nil
+}
+
+// HGetAll gets all hash fields
+func (c *MockCache) HGetAll(ctx context.Context, key string) (map[string][]byte, error) {
+	if err := c.enter(ctx, "HGetAll", key); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hash, err := c.hashFor(key, false)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]byte, len(hash))
+	for field, value := range hash {
+		result[field] = cloneBytes(value)
+	}
+	return result, nil
+}
+
+// HDel deletes hash fields
+func (c *MockCache) HDel(ctx context.Context, key string, fields ...string) error {
+	if err := c.enter(ctx, "HDel", key); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hash, err := c.hashFor(key, false)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		delete(hash, field)
+	}
+	if hash != nil && len(hash) == 0 {
+		delete(c.items, key)
+	}
+	return nil
+}
+
+// HExists checks if hash field exists
+func (c *MockCache) HExists(ctx context.Context, key, field string) (bool, error) {
+	if err := c.enter(ctx, "HExists", key); err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hash, err := c.hashFor(key, false)
+	if err != nil {
+		return false, err
+	}
+	_, ok := hash[field]
+	return ok, nil
+}
+
+// listFor returns the list item at key, creating it when create is set;
+// must be called with c.mu held
+func (c *MockCache) listFor(key string, create bool) (*mockCacheItem, error) {
+	item := c.live(key)
+	if item == nil {
+		if !create {
+			return nil, nil
+		}
+		item = &mockCacheItem{list: [][]byte{}}
+		c.items[key] = item
+	}
+	if item.list == nil {
+		return nil, errMockCacheWrongType
+	}
+	return item, nil
+}
+
+// LPush prepends values to list
+func (c *MockCache) LPush(ctx context.Context, key string, values ...[]byte) error {
+	if err := c.enter(ctx, "LPush", key); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, err := c.listFor(key, true)
+	if err != nil {
+		return err
+	}
+	// like Redis, each value is pushed to the head in turn
+	for _, v := range values {
+		item.list = append([][]byte{cloneBytes(v)}, item.list...)
+	}
+	return nil
+}
+
+// RPush appends values to list
+func (c *MockCache) RPush(ctx context.Context, key string, values ...[]byte) error {
+	if err := c.enter(ctx, "RPush", key); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, err := c.listFor(key, true)
+	if err != nil {
+		return err
+	}
+	for _, v := range values {
+		item.list = append(item.list, cloneBytes(v))
+	}
+	return nil
+}
+
+// LPop removes and returns first element
+func (c *MockCache) LPop(ctx context.Context, key string) ([]byte, error) {
+	if err := c.enter(ctx, "LPop", key); err != nil {
+		return nil, err
+	}
+	return c.pop(key, true)
+}
+
+// RPop removes and returns last element
+func (c *MockCache) RPop(ctx context.Context, key string) ([]byte, error) {
+	if err := c.enter(ctx, "RPop", key); err != nil {
+		return nil, err
+	}
+	return c.pop(key, false)
+}
+
+func (c *MockCache) pop(key string, head bool) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, err := c.listFor(key, false)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil || len(item.list) == 0 {
+		return nil, cache.ErrKeyNotFound
+	}
+	var value []byte
+	if head {
+		value, item.list = item.list[0], item.list[1:]
+	} else {
+		value, item.list = item.list[len(item.list)-1], item.list[:len(item.list)-1]
+	}
+	if len(item.list) == 0 {
+		delete(c.items, key)
+	}
+	return value, nil
+}
+
+// LRange returns range of elements; negative indexes count from the end
+func (c *MockCache) LRange(ctx context.Context, key string, start, stop int64) ([][]byte, error) {
+	if err := c.enter(ctx, "LRange", key); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, err := c.listFor(key, false)
+	if err != nil || item == nil {
+		return nil, err
+	}
+	n := int64(len(item.list))
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	stop = min(stop, n-1)
+	if start > stop {
+		return [][]byte{}, nil
+	}
+	result := make([][]byte, 0, stop-start+1)
+	for _, v := range item.list[start : stop+1] {
+		result = append(result, cloneBytes(v))
+	}
+	return result, nil
+}
+
+// LLen returns list length
+func (c *MockCache) LLen(ctx context.Context, key string) (int64, error) {
+	if err := c.enter(ctx, "LLen", key); err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, err := c.listFor(key, false)
+	if err != nil || item == nil {
+		return 0, err
+	}
+	return int64(len(item.list)), nil
+}
+
+// Calls returns the recorded calls to method, or all calls if method is empty
+func (c *MockCache) Calls(method string) []MockCacheCall {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var calls []MockCacheCall
+	for _, call := range c.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// CallCount returns how many times method was called
+func (c *MockCache) CallCount(method string) int {
+	return len(c.Calls(method))
+}
+
+// AssertCalled fails the test unless method was called with key
+func (c *MockCache) AssertCalled(t testing.TB, method, key string) {
+	t.Helper()
+	for _, call := range c.Calls(method) {
+		if call.Key == key {
+			return
+		}
+	}
+	t.Errorf("expected %s(%q) to be called, calls: %v", method, key, c.Calls(""))
+}
+
+// AssertNotCalled fails the test if method was called with key
+func (c *MockCache) AssertNotCalled(t testing.TB, method, key string) {
+	t.Helper()
+	for _, call := range c.Calls(method) {
+		if call.Key == key {
+			t.Errorf("expected %s(%q) not to be called", method, key)
+			return
+		}
+	}
+}
+
+// AssertCallCount fails the test unless method was called exactly n times
+func (c *MockCache) AssertCallCount(t testing.TB, method string, n int) {
+	t.Helper()
+	if got := c.CallCount(method); got != n {
+		t.Errorf("expected %s to be called %d times, got %d", method, n, got)
+	}
+}
+
+// Len returns the number of live keys
+func (c *MockCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for key := range c.items {
+		if c.live(key) != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Reset clears all data, injected errors and latency, and recorded calls
 func (c *MockCache) Reset() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.items = make(map[string]mockCacheItem)
-	c.ErrGet = nil
-	c.ErrSet = nil
-	c.ErrDelete = nil
+	c.items = make(map[string]*mockCacheItem)
+	c.errs = make(map[string]error)
+	c.delays = make(map[string]time.Duration)
+	c.calls = nil
+	c.closed = false
+	c.Latency = 0
+}
+
+func mustHaveMethod(v interface{}, method string) {
+	if _, ok := reflect.TypeOf(v).MethodByName(method); !ok {
+		panic(fmt.Sprintf("testing: %T has no method %q", v, method))
+	}
 }
 
 // ============================================
 // Mock HTTP Client
 // ============================================
 
+// ErrNoMockResponse is returned for requests that match no mocked response
+var ErrNoMockResponse = errors.New("testing: no mock response")
+
 // MockHTTPResponse represents a mocked response
 type MockHTTPResponse struct {
 	StatusCode int
 	Body       []byte
 	Headers    map[string]string
 	Error      error
+	// Delay is waited before responding, honoring context cancellation
+	Delay time.Duration
+}
+
+// JSONResponse returns a mocked response with v encoded as JSON
+func JSONResponse(status int, v interface{}) *MockHTTPResponse {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic("testing: marshal mock response: " + err.Error())
+	}
+	return &MockHTTPResponse{
+		StatusCode: status,
+		Body:       body,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+	}
 }
 
-// MockHTTPClient is a mock HTTP client for testing
+// MockHTTPClient is an httpclient.Doer returning programmed responses.
+// Requests are matched by method ("*" matches any) and a path.Match
+// pattern on the path, e.g. "/users/*"; a pattern containing "?" is matched
+// against the path with its sorted query string. Later mocks take
+// precedence over earlier ones
 type MockHTTPClient struct {
 	mu        sync.Mutex
-	responses map[string]*MockHTTPResponse
+	responses []*mockHTTPRoute
 	requests  []MockHTTPRequest
+
+	// Latency is added to every response on top of its own Delay
+	Latency time.Duration
+}
+
+type mockHTTPRoute struct {
+	method    string
+	pattern   string
+	responses []*MockHTTPResponse
+	calls     int
 }
 
 // MockHTTPRequest represents a captured request
@@ -274,32 +862,178 @@ type MockHTTPRequest struct {
 	Body    []byte
 }
 
+var _ httpclient.Doer = (*MockHTTPClient)(nil)
+
 // NewMockHTTPClient creates a new mock HTTP client
 func NewMockHTTPClient() *MockHTTPClient {
-	return &MockHTTPClient{
-		responses: make(map[string]*MockHTTPResponse),
-	}
+	return &MockHTTPClient{}
+}
+
+// MockResponse sets up a mock response for requests matching method and pattern
+func (c *MockHTTPClient) MockResponse(method, pattern string, resp *MockHTTPResponse) {
+	c.MockSequence(method, pattern, resp)
 }
 
-// MockResponse sets up a mock response for a URL
-func (c *MockHTTPClient) MockResponse(method, url string, resp *MockHTTPResponse) {
+// MockSequence returns resps in order for successive matching requests,
+// repeating the last one once the sequence is exhausted
+func (c *MockHTTPClient) MockSequence(method, pattern string, resps ...*MockHTTPResponse) {
+	if len(resps) == 0 {
+		panic("testing: MockSequence needs at least one response")
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	key := method + " " + url
-	c.responses[key] = resp
+	c.responses = append(c.responses, &mockHTTPRoute{
+		method:    strings.ToUpper(method),
+		pattern:   pattern,
+		responses: resps,
+	})
+}
+
+// Do implements httpclient.Doer
+func (c *MockHTTPClient) Do(ctx context.Context, req httpclient.Request) (*httpclient.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = json.Marshal(req.Body); err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+	}
+	url := mockRequestURL(req)
+	headers := make(map[string]string, len(req.Headers))
+	for k, v := range req.Headers {
+		headers[k] = v
+	}
+
+	c.mu.Lock()
+	c.requests = append(c.requests, MockHTTPRequest{
+		Method:  req.Method,
+		URL:     url,
+		Headers: headers,
+		Body:    body,
+	})
+	resp := c.match(req.Method, req.Path, url)
+	latency := c.Latency
+	c.mu.Unlock()
+
+	if resp == nil {
+		return nil, fmt.Errorf("%w for %s %s", ErrNoMockResponse, req.Method, url)
+	}
+	if delay := latency + resp.Delay; delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	status := resp.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	header := make(http.Header, len(resp.Headers))
+	for k, v := range resp.Headers {
+		header.Set(k, v)
+	}
+	return &httpclient.Response{
+		StatusCode: status,
+		Body:       append([]byte(nil), resp.Body...),
+		Headers:    header,
+	}, nil
+}
+
+// match must be called with c.mu held
+func (c *MockHTTPClient) match(method, reqPath, url string) *MockHTTPResponse {
+	method = strings.ToUpper(method)
+	for i := len(c.responses) - 1; i >= 0; i-- {
+		route := c.responses[i]
+		if route.method != "*" && route.method != method {
+			continue
+		}
+		target := reqPath
+		if strings.Contains(route.pattern, "?") {
+			target = url
+		}
+		if ok, _ := path.Match(route.pattern, target); !ok && route.pattern != target {
+			continue
+		}
+		resp := route.responses[min(route.calls, len(route.responses)-1)]
+		route.calls++
+		return resp
+	}
+	return nil
+}
+
+func mockRequestURL(req httpclient.Request) string {
+	if len(req.Query) == 0 {
+		return req.Path
+	}
+	keys := make([]string, 0, len(req.Query))
+	for k := range req.Query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + req.Query[k]
+	}
+	return req.Path + "?" + strings.Join(parts, "&")
+}
+
+// Get implements httpclient.Doer
+func (c *MockHTTPClient) Get(ctx context.Context, path string, headers map[string]string) (*httpclient.Response, error) {
+	return c.Do(ctx, httpclient.Request{Method: http.MethodGet, Path: path, Headers: headers})
+}
+
+// Post implements httpclient.Doer
+func (c *MockHTTPClient) Post(ctx context.Context, path string, body interface{}, headers map[string]string) (*httpclient.Response, error) {
+	return c.Do(ctx, httpclient.Request{Method: http.MethodPost, Path: path, Body: body, Headers: headers})
+}
+
+// Put implements httpclient.Doer
+func (c *MockHTTPClient) Put(ctx context.Context, path string, body interface{}, headers map[string]string) (*httpclient.Response, error) {
+	return c.Do(ctx, httpclient.Request{Method: http.MethodPut, Path: path, Body: body, Headers: headers})
+}
+
+// Delete implements httpclient.Doer
+func (c *MockHTTPClient) Delete(ctx context.Context, path string, headers map[string]string) (*httpclient.Response, error) {
+	return c.Do(ctx, httpclient.Request{Method: http.MethodDelete, Path: path, Headers: headers})
 }
 
 // GetRequests returns captured requests
 func (c *MockHTTPClient) GetRequests() []MockHTTPRequest {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	return c.requests
+	return append([]MockHTTPRequest(nil), c.requests...)
+}
+
+// CallCount returns how many captured requests match method and pattern
+func (c *MockHTTPClient) CallCount(method, pattern string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := 0
+	for _, req := range c.requests {
+		if method != "*" && !strings.EqualFold(method, req.Method) {
+			continue
+		}
+		target, _, _ := strings.Cut(req.URL, "?")
+		if strings.Contains(pattern, "?") {
+			target = req.URL
+		}
+		if ok, _ := path.Match(pattern, target); ok || pattern == target {
+			count++
+		}
+	}
+	return count
 }
 
 // Reset clears all mocks
 func (c *MockHTTPClient) Reset() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.responses = make(map[string]*MockHTTPResponse)
+	c.responses = nil
 	c.requests = nil
+	c.Latency = 0
 }
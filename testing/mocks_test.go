@@ -0,0 +1,241 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	stdtesting "testing"
+	"time"
+
+	"github.com/minisource/go-common/cache"
+	"github.com/minisource/go-common/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockCacheValues(t *stdtesting.T) {
+	c := NewMockCache()
+	ctx := context.Background()
+
+	_, err := c.Get(ctx, "user:1")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+
+	require.NoError(t, c.Set(ctx, "user:1", []byte("ali"), 0))
+	require.NoError(t, c.SetObject(ctx, "user:2", map[string]string{"name": "sara"}, time.Minute))
+	value, err := c.Get(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("ali"), value)
+	var user map[string]string
+	require.NoError(t, c.GetObject(ctx, "user:2", &user))
+	assert.Equal(t, "sara", user["name"])
+
+	ttl, err := c.TTL(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(-1), ttl, "no expiry")
+	ttl, err = c.TTL(ctx, "user:2")
+	require.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	set, err := c.SetNX(ctx, "user:1", []byte("other"), 0)
+	require.NoError(t, err)
+	assert.False(t, set)
+	old, err := c.GetSet(ctx, "user:1", []byte("reza"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("ali"), old)
+
+	n, err := c.Increment(ctx, "visits", 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+	n, err = c.Decrement(ctx, "visits", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	_, err = c.Increment(ctx, "user:1", 1)
+	assert.Error(t, err, "not an integer")
+
+	keys, err := c.Keys(ctx, "user:*")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user:1", "user:2"}, keys)
+	require.NoError(t, c.DeleteMany(ctx, "user:1", "user:2"))
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestMockCacheExpiry(t *stdtesting.T) {
+	c := NewMockCache()
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "session", []byte("x"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	exists, err := c.Exists(ctx, "session")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	set, err := c.SetNX(ctx, "session", []byte("y"), 0)
+	require.NoError(t, err)
+	assert.True(t, set, "expired keys can be set again")
+}
+
+func TestMockCacheHashesAndLists(t *stdtesting.T) {
+	c := NewMockCache()
+	ctx := context.Background()
+
+	require.NoError(t, c.HSet(ctx, "user:1", "name", []byte("ali")))
+	require.NoError(t, c.HSet(ctx, "user:1", "city", []byte("tehran")))
+	name, err := c.HGet(ctx, "user:1", "name")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("ali"), name)
+	all, err := c.HGetAll(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+	require.NoError(t, c.HDel(ctx, "user:1", "name", "city"))
+	exists, err := c.Exists(ctx, "user:1")
+	require.NoError(t, err)
+	assert.False(t, exists, "an emptied hash is removed")
+
+	require.NoError(t, c.RPush(ctx, "queue", []byte("b"), []byte("c")))
+	require.NoError(t, c.LPush(ctx, "queue", []byte("a")))
+	items, err := c.LRange(ctx, "queue", 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("a"), []byte("b"), []byte("c")}, items)
+	last, err := c.RPop(ctx, "queue")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("c"), last)
+	length, err := c.LLen(ctx, "queue")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), length)
+
+	_, err = c.Get(ctx, "queue")
+	assert.Error(t, err, "lists are not plain values")
+	assert.Error(t, c.HSet(ctx, "queue", "f", nil))
+}
+
+func TestMockCacheInjectedErrorsAndLatency(t *stdtesting.T) {
+	c := NewMockCache()
+	ctx := context.Background()
+	down := errors.New("redis down")
+
+	c.SetError("Get", down)
+	_, err := c.Get(ctx, "k")
+	assert.ErrorIs(t, err, down)
+	assert.NoError(t, c.Set(ctx, "k", []byte("v"), 0), "other methods are unaffected")
+	c.SetError("Get", nil)
+	_, err = c.Get(ctx, "k")
+	assert.NoError(t, err)
+
+	assert.Panics(t, func() { c.SetError("Fetch", down) })
+
+	c.SetLatency("Get", time.Second)
+	timeout, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	_, err = c.Get(timeout, "k")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMockCacheRecordsCalls(t *stdtesting.T) {
+	c := NewMockCache()
+	ctx := context.Background()
+	c.Set(ctx, "a", nil, 0)
+	c.Get(ctx, "a")
+	c.Get(ctx, "b")
+	require.NoError(t, c.Close())
+
+	assert.Equal(t, []MockCacheCall{{"Get", "a"}, {"Get", "b"}}, c.Calls("Get"))
+	c.AssertCalled(t, "Get", "b")
+	c.AssertNotCalled(t, "Set", "b")
+	c.AssertCallCount(t, "Get", 2)
+	assert.True(t, c.Closed())
+
+	rec := &failureRecorder{TB: t}
+	c.AssertCalled(rec, "Delete", "a")
+	c.AssertNotCalled(rec, "Get", "a")
+	c.AssertCallCount(rec, "Set", 2)
+	assert.Len(t, rec.errors, 3)
+
+	c.Reset()
+	assert.Empty(t, c.Calls(""))
+	assert.Zero(t, c.Len())
+	assert.False(t, c.Closed())
+}
+
+func TestMockHTTPClientMatching(t *stdtesting.T) {
+	c := NewMockHTTPClient()
+	ctx := context.Background()
+	c.MockResponse("GET", "/users/*", JSONResponse(200, map[string]string{"name": "ali"}))
+	c.MockResponse("GET", "/users/me", &MockHTTPResponse{StatusCode: 401})
+	c.MockResponse("*", "/search?q=go", &MockHTTPResponse{Body: []byte("found")})
+
+	resp, err := c.Get(ctx, "/users/42", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Headers.Get("Content-Type"))
+	var user map[string]string
+	require.NoError(t, resp.DecodeJSON(&user))
+	assert.Equal(t, "ali", user["name"])
+
+	resp, err = c.Get(ctx, "/users/me", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode, "later mocks take precedence")
+
+	resp, err = c.Do(ctx, httpclient.Request{Method: "POST", Path: "/search", Query: map[string]string{"q": "go"}})
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode, "zero status means 200")
+	assert.Equal(t, "found", string(resp.Body))
+
+	_, err = c.Delete(ctx, "/users/42", nil)
+	assert.ErrorIs(t, err, ErrNoMockResponse)
+}
+
+func TestMockHTTPClientSequencesAndErrors(t *stdtesting.T) {
+	c := NewMockHTTPClient()
+	ctx := context.Background()
+	refused := errors.New("connection refused")
+	c.MockSequence("POST", "/orders",
+		&MockHTTPResponse{Error: refused},
+		&MockHTTPResponse{StatusCode: 503},
+		&MockHTTPResponse{StatusCode: 201},
+	)
+
+	_, err := c.Post(ctx, "/orders", map[string]int{"qty": 1}, nil)
+	assert.ErrorIs(t, err, refused)
+	resp, _ := c.Post(ctx, "/orders", nil, nil)
+	assert.Equal(t, 503, resp.StatusCode)
+	resp, _ = c.Post(ctx, "/orders", nil, nil)
+	assert.Equal(t, 201, resp.StatusCode)
+	resp, _ = c.Post(ctx, "/orders", nil, nil)
+	assert.Equal(t, 201, resp.StatusCode, "the last response repeats")
+
+	assert.Panics(t, func() { c.MockSequence("GET", "/empty") })
+
+	c.MockResponse("GET", "/slow", &MockHTTPResponse{Delay: time.Second})
+	timeout, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	_, err = c.Get(timeout, "/slow", nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMockHTTPClientRecordsRequests(t *stdtesting.T) {
+	c := NewMockHTTPClient()
+	ctx := context.Background()
+	c.MockResponse("*", "/*", &MockHTTPResponse{})
+
+	headers := map[string]string{"X-Request-ID": "r-1"}
+	c.Put(ctx, "/users", map[string]string{"name": "ali"}, headers)
+	headers["X-Request-ID"] = "changed"
+	c.Do(ctx, httpclient.Request{Method: "GET", Path: "/users", Query: map[string]string{"page": "2", "limit": "10"}})
+
+	requests := c.GetRequests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, MockHTTPRequest{
+		Method:  "PUT",
+		URL:     "/users",
+		Headers: map[string]string{"X-Request-ID": "r-1"},
+		Body:    []byte(`{"name":"ali"}`),
+	}, requests[0])
+	assert.Equal(t, "/users?limit=10&page=2", requests[1].URL, "query keys are sorted")
+
+	assert.Equal(t, 2, c.CallCount("*", "/users"))
+	assert.Equal(t, 1, c.CallCount("get", "/users"))
+	assert.Equal(t, 1, c.CallCount("GET", "/users?limit=10&page=2"))
+
+	c.Reset()
+	assert.Empty(t, c.GetRequests())
+	_, err := c.Get(ctx, "/users", nil)
+	assert.ErrorIs(t, err, ErrNoMockResponse)
+}
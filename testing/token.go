@@ -0,0 +1,307 @@
+package testing
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/minisource/go-common/http/middleware"
+)
+
+// ============================================
+// JWT Test Tokens
+// ============================================
+
+const (
+	// TestJWTSecret is the default HMAC secret used by NewToken
+	TestJWTSecret = "test-jwt-secret"
+	// TestUserID is the default user ID in test tokens
+	TestUserID = "00000000-0000-0000-0000-000000000001"
+	// TestTenantID is the default tenant ID in test tokens
+	TestTenantID = "00000000-0000-0000-0000-000000000002"
+	// TestClientID is the default client ID in service test tokens
+	TestClientID = "test-client"
+	// WrongAudience is the audience set by the WithWrongAudience option
+	WrongAudience = "wrong-audience"
+)
+
+type tokenOptions struct {
+	method jwt.SigningMethod
+	key    interface{}
+	kid    string
+
+	user    middleware.TokenClaims
+	service middleware.ServiceTokenClaims
+
+	issuedAt  time.Time
+	expiresIn time.Duration
+	noExpiry  bool
+	notBefore time.Duration
+	issuer    string
+	subject   string
+	audience  []string
+}
+
+// TokenOption configures a test token
+type TokenOption func(*tokenOptions)
+
+// WithSecret signs the token with an HMAC secret (HS256)
+func WithSecret(secret string) TokenOption {
+	return func(o *tokenOptions) {
+		o.method = jwt.SigningMethodHS256
+		o.key = []byte(secret)
+	}
+}
+
+// WithSigningKey signs the token with a custom method and key, e.g.
+// jwt.SigningMethodRS256 and an *rsa.PrivateKey
+func WithSigningKey(method jwt.SigningMethod, key interface{}) TokenOption {
+	return func(o *tokenOptions) {
+		o.method = method
+		o.key = key
+	}
+}
+
+// WithKeyID sets the "kid" header
+func WithKeyID(kid string) TokenOption {
+	return func(o *tokenOptions) {
+		o.kid = kid
+	}
+}
+
+// WithUserID sets the user ID (and subject)
+func WithUserID(id string) TokenOption {
+	return func(o *tokenOptions) {
+		o.user.UserID = id
+	}
+}
+
+// WithSessionID sets the session ID
+func WithSessionID(id string) TokenOption {
+	return func(o *tokenOptions) {
+		o.user.SessionID = id
+	}
+}
+
+// WithEmail sets the email claim
+func WithEmail(email string) TokenOption {
+	return func(o *tokenOptions) {
+		o.user.Email = email
+	}
+}
+
+// WithTenantID sets the tenant ID on user and service tokens
+func WithTenantID(id string) TokenOption {
+	return func(o *tokenOptions) {
+		o.user.TenantID = id
+		o.service.TenantID = id
+	}
+}
+
+// WithRoles sets the roles claim
+func WithRoles(roles ...string) TokenOption {
+	return func(o *tokenOptions) {
+		o.user.Roles = roles
+	}
+}
+
+// WithPermissions sets the permissions claim
+func WithPermissions(permissions ...string) TokenOption {
+	return func(o *tokenOptions) {
+		o.user.Permissions = permissions
+	}
+}
+
+// WithTokenType sets the tokenType claim on user and service tokens
+func WithTokenType(tokenType string) TokenOption {
+	return func(o *tokenOptions) {
+		o.user.TokenType = tokenType
+		o.service.TokenType = tokenType
+	}
+}
+
+// WithClientID sets the client ID of a service token
+func WithClientID(id string) TokenOption {
+	return func(o *tokenOptions) {
+		o.service.ClientID = id
+	}
+}
+
+// WithServiceName sets the service name of a service token
+func WithServiceName(name string) TokenOption {
+	return func(o *tokenOptions) {
+		o.service.ServiceName = name
+	}
+}
+
+// WithScopes sets the scopes of a service token
+func WithScopes(scopes ...string) TokenOption {
+	return func(o *tokenOptions) {
+		o.service.Scopes = scopes
+	}
+}
+
+// WithIssuer sets the "iss" claim
+func WithIssuer(issuer string) TokenOption {
+	return func(o *tokenOptions) {
+		o.issuer = issuer
+	}
+}
+
+// WithSubject sets the "sub" claim
+func WithSubject(subject string) TokenOption {
+	return func(o *tokenOptions) {
+		o.subject = subject
+	}
+}
+
+// WithAudience sets the "aud" claim
+func WithAudience(audience ...string) TokenOption {
+	return func(o *tokenOptions) {
+		o.audience = audience
+	}
+}
+
+// WithWrongAudience sets an audience no service accepts
+func WithWrongAudience() TokenOption {
+	return WithAudience(WrongAudience)
+}
+
+// WithIssuedAt sets "iat"; expiry and not-before are relative to it
+func WithIssuedAt(t time.Time) TokenOption {
+	return func(o *tokenOptions) {
+		o.issuedAt = t
+	}
+}
+
+// WithExpiresIn sets the token lifetime, default 1 hour
+func WithExpiresIn(d time.Duration) TokenOption {
+	return func(o *tokenOptions) {
+		o.expiresIn = d
+		o.noExpiry = false
+	}
+}
+
+// WithoutExpiry omits the "exp" claim
+func WithoutExpiry() TokenOption {
+	return func(o *tokenOptions) {
+		o.noExpiry = true
+	}
+}
+
+// Expired makes a token that expired a minute ago
+func Expired() TokenOption {
+	return func(o *tokenOptions) {
+		o.issuedAt = time.Now().Add(-time.Hour)
+		o.expiresIn = time.Hour - time.Minute
+		o.noExpiry = false
+	}
+}
+
+// NotYetValid sets "nbf" an hour after issuance
+func NotYetValid() TokenOption {
+	return func(o *tokenOptions) {
+		o.notBefore = time.Hour
+	}
+}
+
+func newTokenOptions(opts []TokenOption) *tokenOptions {
+	o := &tokenOptions{
+		method:    jwt.SigningMethodHS256,
+		key:       []byte(TestJWTSecret),
+		expiresIn: time.Hour,
+		user: middleware.TokenClaims{
+			UserID:    TestUserID,
+			Email:     "test@example.com",
+			TenantID:  TestTenantID,
+			Roles:     []string{"user"},
+			TokenType: "access",
+		},
+		service: middleware.ServiceTokenClaims{
+			ClientID:    TestClientID,
+			ServiceName: "test-service",
+			TenantID:    TestTenantID,
+			Scopes:      []string{"*"},
+			TokenType:   "service",
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.issuedAt.IsZero() {
+		o.issuedAt = time.Now()
+	}
+	return o
+}
+
+func (o *tokenOptions) registered(subject string) jwt.RegisteredClaims {
+	rc := jwt.RegisteredClaims{
+		Issuer:   o.issuer,
+		Subject:  subject,
+		Audience: o.audience,
+		IssuedAt: jwt.NewNumericDate(o.issuedAt),
+	}
+	if o.subject != "" {
+		rc.Subject = o.subject
+	}
+	if !o.noExpiry {
+		rc.ExpiresAt = jwt.NewNumericDate(o.issuedAt.Add(o.expiresIn))
+	}
+	if o.notBefore > 0 {
+		rc.NotBefore = jwt.NewNumericDate(o.issuedAt.Add(o.notBefore))
+	}
+	return rc
+}
+
+func (o *tokenOptions) sign(claims jwt.Claims) string {
+	token := jwt.NewWithClaims(o.method, claims)
+	if o.kid != "" {
+		token.Header["kid"] = o.kid
+	}
+	signed, err := token.SignedString(o.key)
+	if err != nil {
+		panic("testing: sign token: " + err.Error())
+	}
+	return signed
+}
+
+// NewToken returns a signed user token with middleware.TokenClaims, valid
+// for AuthMiddleware configured with TestJWTSecret unless options say otherwise.
+// It panics if the key does not match the signing method
+//
+//	token := testing.NewToken(testing.WithRoles("admin"), testing.Expired())
+func NewToken(opts ...TokenOption) string {
+	o := newTokenOptions(opts)
+	claims := o.user
+	claims.RegisteredClaims = o.registered(claims.UserID)
+	return o.sign(&claims)
+}
+
+// NewServiceToken returns a signed service token with middleware.ServiceTokenClaims
+func NewServiceToken(opts ...TokenOption) string {
+	o := newTokenOptions(opts)
+	claims := o.service
+	claims.RegisteredClaims = o.registered(claims.ClientID)
+	return o.sign(&claims)
+}
+
+// BearerHeaders returns headers carrying token as a bearer token
+func BearerHeaders(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+// WithToken returns a copy of the request carrying token in the
+// Authorization header
+func (r HTTPRequest) WithToken(token string) HTTPRequest {
+	headers := make(map[string]string, len(r.Headers)+1)
+	for k, v := range r.Headers {
+		headers[k] = v
+	}
+	headers["Authorization"] = "Bearer " + token
+	r.Headers = headers
+	return r
+}
+
+// WithNewToken is shorthand for r.WithToken(NewToken(opts...))
+func (r HTTPRequest) WithNewToken(opts ...TokenOption) HTTPRequest {
+	return r.WithToken(NewToken(opts...))
+}
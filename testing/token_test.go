@@ -0,0 +1,143 @@
+package testing
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http/httptest"
+	stdtesting "testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/minisource/go-common/http/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseUserToken(token string, key interface{}, opts ...jwt.ParserOption) (*middleware.TokenClaims, *jwt.Token, error) {
+	claims := &middleware.TokenClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) { return key, nil }, opts...)
+	return claims, parsed, err
+}
+
+func TestNewTokenDefaults(t *stdtesting.T) {
+	claims, _, err := parseUserToken(NewToken(), []byte(TestJWTSecret))
+	require.NoError(t, err)
+	assert.Equal(t, TestUserID, claims.UserID)
+	assert.Equal(t, TestUserID, claims.Subject)
+	assert.Equal(t, TestTenantID, claims.TenantID)
+	assert.Equal(t, []string{"user"}, claims.Roles)
+	assert.Equal(t, "access", claims.TokenType)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), claims.ExpiresAt.Time, 5*time.Second)
+}
+
+func TestNewTokenOptions(t *stdtesting.T) {
+	issuedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	token := NewToken(
+		WithUserID("u-1"), WithSessionID("s-1"), WithEmail("ali@example.com"), WithTenantID("t-1"),
+		WithRoles("admin"), WithPermissions("users:read"), WithTokenType("refresh"),
+		WithIssuer("auth"), WithSubject("sub-1"), WithAudience("orders", "billing"),
+		WithIssuedAt(issuedAt), WithExpiresIn(10*time.Minute), WithSecret("other-secret"),
+	)
+
+	_, _, err := parseUserToken(token, []byte(TestJWTSecret))
+	assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+	claims, _, err := parseUserToken(token, []byte("other-secret"), jwt.WithIssuer("auth"), jwt.WithAudience("billing"))
+	require.NoError(t, err)
+	assert.Equal(t, middleware.TokenClaims{
+		UserID:      "u-1",
+		SessionID:   "s-1",
+		Email:       "ali@example.com",
+		TenantID:    "t-1",
+		Roles:       []string{"admin"},
+		Permissions: []string{"users:read"},
+		TokenType:   "refresh",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "auth",
+			Subject:   "sub-1",
+			Audience:  jwt.ClaimStrings{"orders", "billing"},
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(10 * time.Minute)),
+		},
+	}, *claims)
+}
+
+func TestNewTokenValidity(t *stdtesting.T) {
+	key := []byte(TestJWTSecret)
+
+	_, _, err := parseUserToken(NewToken(Expired()), key)
+	assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+	_, _, err = parseUserToken(NewToken(NotYetValid()), key)
+	assert.ErrorIs(t, err, jwt.ErrTokenNotValidYet)
+	_, _, err = parseUserToken(NewToken(WithWrongAudience()), key, jwt.WithAudience("orders"))
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+
+	claims, _, err := parseUserToken(NewToken(WithoutExpiry()), key)
+	require.NoError(t, err)
+	assert.Nil(t, claims.ExpiresAt)
+}
+
+func TestNewTokenSigningKey(t *stdtesting.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	token := NewToken(WithSigningKey(jwt.SigningMethodRS256, key), WithKeyID("2025-01"))
+
+	_, parsed, err := parseUserToken(token, &key.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, "RS256", parsed.Method.Alg())
+	assert.Equal(t, "2025-01", parsed.Header["kid"])
+
+	assert.Panics(t, func() { NewToken(WithSigningKey(jwt.SigningMethodRS256, []byte("not an RSA key"))) })
+}
+
+func TestNewServiceToken(t *stdtesting.T) {
+	claims := &middleware.ServiceTokenClaims{}
+	_, err := jwt.ParseWithClaims(NewServiceToken(WithScopes("users:read"), WithServiceName("orders")), claims,
+		func(*jwt.Token) (interface{}, error) { return []byte(TestJWTSecret), nil })
+	require.NoError(t, err)
+	assert.Equal(t, TestClientID, claims.ClientID)
+	assert.Equal(t, TestClientID, claims.Subject)
+	assert.Equal(t, "orders", claims.ServiceName)
+	assert.Equal(t, []string{"users:read"}, claims.Scopes)
+	assert.Equal(t, "service", claims.TokenType)
+}
+
+func TestTokensAgainstAuthMiddleware(t *stdtesting.T) {
+	cfg := middleware.DefaultAuthConfig()
+	cfg.Secret = TestJWTSecret
+	cfg.Issuer = "auth"
+	cfg.Audience = "orders"
+	app := fiber.New()
+	app.Use(middleware.AuthMiddleware(cfg))
+	app.Get("/me", func(c *fiber.Ctx) error { return c.SendString(middleware.GetUserIDFromContext(c)) })
+
+	status := func(token string) int {
+		req := httptest.NewRequest("GET", "/me", nil)
+		for k, v := range BearerHeaders(token) {
+			req.Header.Set(k, v)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	valid := []TokenOption{WithIssuer("auth"), WithAudience("orders")}
+	assert.Equal(t, fiber.StatusOK, status(NewToken(valid...)))
+	assert.Equal(t, fiber.StatusUnauthorized, status(NewToken(append(valid, Expired())...)))
+	assert.Equal(t, fiber.StatusUnauthorized, status(NewToken(append(valid, WithWrongAudience())...)))
+	assert.Equal(t, fiber.StatusUnauthorized, status(NewToken(append(valid, WithIssuer("evil"))...)))
+	assert.Equal(t, fiber.StatusUnauthorized, status(NewToken(append(valid, WithSecret("other"))...)))
+}
+
+func TestHTTPRequestWithToken(t *stdtesting.T) {
+	req := HTTPRequest{Headers: map[string]string{"X-Request-ID": "r-1"}}
+	withToken := req.WithToken("abc")
+
+	assert.Equal(t, "Bearer abc", withToken.Headers["Authorization"])
+	assert.Equal(t, "r-1", withToken.Headers["X-Request-ID"])
+	assert.NotContains(t, req.Headers, "Authorization", "the original request is not modified")
+
+	claims, _, err := parseUserToken(req.WithNewToken(WithUserID("u-9")).Headers["Authorization"][len("Bearer "):], []byte(TestJWTSecret))
+	require.NoError(t, err)
+	assert.Equal(t, "u-9", claims.UserID)
+}
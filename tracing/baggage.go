@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	appctx "github.com/minisource/go-common/context"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// Baggage member keys carrying request identity across services
+const (
+	BaggageTenantID  = "tenant.id"
+	BaggageUserID    = "user.id"
+	BaggageRequestID = "request.id"
+)
+
+// WithIdentityBaggage adds the tenant, user and request IDs held by the
+// context package to the W3C baggage of ctx, so they are propagated with
+// the trace context on outgoing calls
+func WithIdentityBaggage(ctx context.Context) context.Context {
+	members := make(map[string]string, 3)
+	if tenantID, ok := appctx.GetTenantID(ctx); ok && tenantID != uuid.Nil {
+		members[BaggageTenantID] = tenantID.String()
+	}
+	if userID, ok := appctx.GetUserID(ctx); ok && userID != uuid.Nil {
+		members[BaggageUserID] = userID.String()
+	}
+	if requestID, ok := appctx.GetRequestID(ctx); ok && requestID != "" {
+		members[BaggageRequestID] = requestID
+	}
+	return withBaggageMembers(ctx, members)
+}
+
+// withFiberIdentityBaggage is WithIdentityBaggage for handlers where auth
+// middleware stored the identity in Fiber locals
+func withFiberIdentityBaggage(c *fiber.Ctx) context.Context {
+	ctx := WithIdentityBaggage(c.UserContext())
+	members := make(map[string]string, 3)
+	if tenantID, ok := c.Locals("tenantId").(string); ok && tenantID != "" {
+		members[BaggageTenantID] = tenantID
+	}
+	if userID, ok := c.Locals("userId").(string); ok && userID != "" {
+		members[BaggageUserID] = userID
+	}
+	if requestID, ok := c.Locals("request_id").(string); ok && requestID != "" {
+		members[BaggageRequestID] = requestID
+	}
+	return withBaggageMembers(ctx, members)
+}
+
+func withBaggageMembers(ctx context.Context, members map[string]string) context.Context {
+	if len(members) == 0 {
+		return ctx
+	}
+	bag := baggage.FromContext(ctx)
+	for key, value := range members {
+		member, err := baggage.NewMemberRaw(key, value)
+		if err != nil {
+			continue
+		}
+		if next, err := bag.SetMember(member); err == nil {
+			bag = next
+		}
+	}
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+// ContextFromBaggage copies tenant, user and request IDs received as
+// baggage into the context package. Values already present are kept.
+// Clients can send any baggage, so only call it on internal hops between
+// services, never on requests that come straight from clients.
+func ContextFromBaggage(ctx context.Context) context.Context {
+	bag := baggage.FromContext(ctx)
+
+	if _, ok := appctx.GetTenantID(ctx); !ok {
+		if id, err := uuid.Parse(bag.Member(BaggageTenantID).Value()); err == nil {
+			ctx = appctx.WithTenantID(ctx, id)
+		}
+	}
+	if _, ok := appctx.GetUserID(ctx); !ok {
+		if id, err := uuid.Parse(bag.Member(BaggageUserID).Value()); err == nil {
+			ctx = appctx.WithUserID(ctx, id)
+		}
+	}
+	if _, ok := appctx.GetRequestID(ctx); !ok {
+		if requestID := bag.Member(BaggageRequestID).Value(); requestID != "" {
+			ctx = appctx.WithRequestID(ctx, requestID)
+		}
+	}
+	return ctx
+}
@@ -0,0 +1,32 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	appctx "github.com/minisource/go-common/context"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestIdentityBaggageRoundTrip(t *testing.T) {
+	tenantID, userID := uuid.New(), uuid.New()
+	ctx := appctx.WithTenantID(context.Background(), tenantID)
+	ctx = appctx.WithUserID(ctx, userID)
+	ctx = appctx.WithRequestID(ctx, "req-1")
+
+	headers := make(map[string]string)
+	propagation.Baggage{}.Inject(WithIdentityBaggage(ctx), &mapCarrier{headers: headers})
+
+	received := propagation.Baggage{}.Extract(context.Background(), &mapCarrier{headers: headers})
+	received = ContextFromBaggage(received)
+
+	gotTenant, ok := appctx.GetTenantID(received)
+	assert.True(t, ok)
+	assert.Equal(t, tenantID, gotTenant)
+	gotUser, _ := appctx.GetUserID(received)
+	assert.Equal(t, userID, gotUser)
+	gotRequest, _ := appctx.GetRequestID(received)
+	assert.Equal(t, "req-1", gotRequest)
+}
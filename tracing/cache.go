@@ -0,0 +1,180 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/minisource/go-common/cache"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedCache wraps a cache.Cache and creates a child span per command.
+// Only key prefixes (the part before the first ':') are recorded, never
+// full keys or values.
+type tracedCache struct {
+	next   cache.Cache
+	tracer trace.Tracer
+	system string
+}
+
+// TraceCache decorates c so every command is traced. system is reported as
+// db.system (e.g. "redis").
+func TraceCache(c cache.Cache, system string) cache.Cache {
+	if system == "" {
+		system = "redis"
+	}
+	return &tracedCache{next: c, tracer: otel.Tracer("cache"), system: system}
+}
+
+func (t *tracedCache) start(ctx context.Context, op string, keys ...string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", t.system),
+		attribute.String("db.operation", op),
+	}
+	if len(keys) == 1 {
+		attrs = append(attrs, attribute.String("cache.key_prefix", keyPrefix(keys[0])))
+	} else if len(keys) > 1 {
+		attrs = append(attrs, attribute.Int("cache.key_count", len(keys)))
+	}
+	return t.tracer.Start(ctx, "cache."+strings.ToLower(op),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// end records the outcome; a missing key is a miss, not an error
+func (t *tracedCache) end(span trace.Span, err error) {
+	defer span.End()
+	if errors.Is(err, cache.ErrKeyNotFound) {
+		span.SetAttributes(attribute.Bool("cache.hit", false))
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+func (t *tracedCache) Get(ctx context.Context, key string) ([]byte, error) {
+	ctx, span := t.start(ctx, "GET", key)
+	v, err := t.next.Get(ctx, key)
+	if err == nil {
+		span.SetAttributes(attribute.Bool("cache.hit", true), attribute.Int("cache.value_size", len(v)))
+	}
+	t.end(span, err)
+	return v, err
+}
+
+func (t *tracedCache) GetObject(ctx context.Context, key string, dest interface{}) error {
+	ctx, span := t.start(ctx, "GET", key)
+	err := t.next.GetObject(ctx, key, dest)
+	if err == nil {
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+	}
+	t.end(span, err)
+	return err
+}
+
+func (t *tracedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	ctx, span := t.start(ctx, "SET", key)
+	span.SetAttributes(attribute.Int("cache.value_size", len(value)))
+	err := t.next.Set(ctx, key, value, ttl)
+	t.end(span, err)
+	return err
+}
+
+func (t *tracedCache) SetObject(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	ctx, span := t.start(ctx, "SET", key)
+	err := t.next.SetObject(ctx, key, value, ttl)
+	t.end(span, err)
+	return err
+}
+
+func (t *tracedCache) Delete(ctx context.Context, key string) error {
+	ctx, span := t.start(ctx, "DEL", key)
+	err := t.next.Delete(ctx, key)
+	t.end(span, err)
+	return err
+}
+
+func (t *tracedCache) Exists(ctx context.Context, key string) (bool, error) {
+	ctx, span := t.start(ctx, "EXISTS", key)
+	ok, err := t.next.Exists(ctx, key)
+	t.end(span, err)
+	return ok, err
+}
+
+func (t *tracedCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ctx, span := t.start(ctx, "TTL", key)
+	d, err := t.next.TTL(ctx, key)
+	t.end(span, err)
+	return d, err
+}
+
+func (t *tracedCache) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	ctx, span := t.start(ctx, "INCRBY", key)
+	v, err := t.next.Increment(ctx, key, delta)
+	t.end(span, err)
+	return v, err
+}
+
+func (t *tracedCache) Decrement(ctx context.Context, key string, delta int64) (int64, error) {
+	ctx, span := t.start(ctx, "DECRBY", key)
+	v, err := t.next.Decrement(ctx, key, delta)
+	t.end(span, err)
+	return v, err
+}
+
+func (t *tracedCache) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	ctx, span := t.start(ctx, "SETNX", key)
+	ok, err := t.next.SetNX(ctx, key, value, ttl)
+	span.SetAttributes(attribute.Bool("cache.set", ok))
+	t.end(span, err)
+	return ok, err
+}
+
+func (t *tracedCache) GetSet(ctx context.Context, key string, value []byte) ([]byte, error) {
+	ctx, span := t.start(ctx, "GETSET", key)
+	v, err := t.next.GetSet(ctx, key, value)
+	t.end(span, err)
+	return v, err
+}
+
+func (t *tracedCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	ctx, span := t.start(ctx, "KEYS", pattern)
+	keys, err := t.next.Keys(ctx, pattern)
+	span.SetAttributes(attribute.Int("cache.result_count", len(keys)))
+	t.end(span, err)
+	return keys, err
+}
+
+func (t *tracedCache) DeleteMany(ctx context.Context, keys ...string) error {
+	ctx, span := t.start(ctx, "DEL", keys...)
+	err := t.next.DeleteMany(ctx, keys...)
+	t.end(span, err)
+	return err
+}
+
+func (t *tracedCache) Ping(ctx context.Context) error {
+	ctx, span := t.start(ctx, "PING")
+	err := t.next.Ping(ctx)
+	t.end(span, err)
+	return err
+}
+
+func (t *tracedCache) Close() error {
+	return t.next.Close()
+}
+
+// keyPrefix returns the namespace of a key without its identifying suffix
+func keyPrefix(key string) string {
+	if i := strings.Index(key, ":"); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
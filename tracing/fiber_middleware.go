@@ -1,11 +1,17 @@
 package tracing
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	appctx "github.com/minisource/go-common/context"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -18,8 +24,26 @@ type MiddlewareConfig struct {
 	SpanNameFunc  func(*fiber.Ctx) string
 	RecordBody    bool
 	RecordHeaders bool
+	// RecoverPanics turns a panic into a 500 error after recording it. When
+	// false the panic is recorded and re-raised for the recovery middleware.
+	RecoverPanics bool
+	// RecordRouteParams adds route parameters as http.route.param.<name>
+	RecordRouteParams bool
+	// RedactParams lists route parameters whose values are replaced
+	RedactParams []string
+	// Propagators overrides the global propagator for this middleware
+	// (tracecontext, baggage, b3, b3multi, jaeger)
+	Propagators []string
+	// TrustIncomingBaggage restores the tenant, user and request IDs sent as
+	// baggage into the context package. Baggage is set by the caller, so
+	// only enable it for internal service-to-service traffic that never
+	// carries requests straight from clients
+	TrustIncomingBaggage bool
 }
 
+// redactedValue replaces sensitive attribute values
+const redactedValue = "[REDACTED]"
+
 // DefaultMiddlewareConfig returns default middleware configuration
 func DefaultMiddlewareConfig() MiddlewareConfig {
 	return MiddlewareConfig{
@@ -29,8 +53,10 @@ func DefaultMiddlewareConfig() MiddlewareConfig {
 		SpanNameFunc: func(c *fiber.Ctx) string {
 			return fmt.Sprintf("%s %s", c.Method(), c.Path())
 		},
-		RecordBody:    false,
-		RecordHeaders: false,
+		RecordBody:        false,
+		RecordHeaders:     false,
+		RecordRouteParams: true,
+		RedactParams:      []string{"token", "password", "secret", "key", "code"},
 	}
 }
 
@@ -38,8 +64,20 @@ func DefaultMiddlewareConfig() MiddlewareConfig {
 func Middleware(cfg MiddlewareConfig) fiber.Handler {
 	tracer := otel.Tracer(cfg.TracerName)
 	propagator := otel.GetTextMapPropagator()
+	if len(cfg.Propagators) > 0 {
+		p, err := NewPropagator(cfg.Propagators...)
+		if err != nil {
+			panic(fmt.Sprintf("tracing: %v", err))
+		}
+		propagator = p
+	}
 
-	return func(c *fiber.Ctx) error {
+	redact := make(map[string]bool, len(cfg.RedactParams))
+	for _, name := range cfg.RedactParams {
+		redact[strings.ToLower(name)] = true
+	}
+
+	return func(c *fiber.Ctx) (err error) {
 		// Check if path should be skipped
 		path := c.Path()
 		for _, skipPath := range cfg.SkipPaths {
@@ -51,6 +89,11 @@ func Middleware(cfg MiddlewareConfig) fiber.Handler {
 		// Extract context from incoming request headers
 		ctx := propagator.Extract(c.Context(), &headerCarrier{ctx: c})
 
+		// Restore tenant, user and request IDs sent by upstream services
+		if cfg.TrustIncomingBaggage {
+			ctx = ContextFromBaggage(ctx)
+		}
+
 		// Start span
 		spanName := cfg.SpanNameFunc(c)
 		ctx, span := tracer.Start(ctx, spanName,
@@ -67,12 +110,27 @@ func Middleware(cfg MiddlewareConfig) fiber.Handler {
 		)
 		defer span.End()
 
+		// Record panics before the recovery middleware handles them
+		defer func() {
+			if r := recover(); r != nil {
+				recordPanic(span, r)
+				recordRoute(c, span, cfg.RecordRouteParams, redact)
+				span.SetAttributes(semconv.HTTPStatusCode(fiber.StatusInternalServerError))
+				if !cfg.RecoverPanics {
+					panic(r)
+				}
+				err = fiber.NewError(fiber.StatusInternalServerError, "internal server error")
+			}
+		}()
+
 		// Add trace ID to response header
 		if span.SpanContext().HasTraceID() {
 			c.Set("X-Trace-ID", span.SpanContext().TraceID().String())
 		}
 
 		// Store context with span in Fiber context
+		// Expose the trace ID to the context package (and logging.FromContext)
+		ctx = appctx.WithTraceID(ctx, span.SpanContext().TraceID().String())
 		c.SetUserContext(ctx)
 		c.Locals("traceId", span.SpanContext().TraceID().String())
 		c.Locals("spanId", span.SpanContext().SpanID().String())
@@ -83,10 +141,21 @@ func Middleware(cfg MiddlewareConfig) fiber.Handler {
 		}
 
 		// Process request
-		err := c.Next()
+		err = c.Next()
 
-		// Record response status
+		// The matched route is only known after routing
+		recordRoute(c, span, cfg.RecordRouteParams, redact)
+
+		// Record response status; an error returned to Fiber's error handler
+		// hasn't set the status yet
 		statusCode := c.Response().StatusCode()
+		if err != nil {
+			statusCode = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				statusCode = fiberErr.Code
+			}
+		}
 		span.SetAttributes(semconv.HTTPStatusCode(statusCode))
 
 		// Record error if any
@@ -95,6 +164,17 @@ func Middleware(cfg MiddlewareConfig) fiber.Handler {
 			span.SetAttributes(attribute.String("error.message", err.Error()))
 		}
 
+		// Server spans fail on 5xx; 4xx are the client's fault
+		if statusCode >= 500 {
+			msg := http.StatusText(statusCode)
+			if err != nil {
+				msg = err.Error()
+			}
+			span.SetStatus(codes.Error, msg)
+		} else {
+			span.SetStatus(codes.Ok, "")
+		}
+
 		// Mark span as error if status code >= 400
 		if statusCode >= 400 {
 			span.SetAttributes(attribute.Bool("error", true))
@@ -104,6 +184,22 @@ func Middleware(cfg MiddlewareConfig) fiber.Handler {
 	}
 }
 
+// recordRoute adds the matched route and its parameters to the span
+func recordRoute(c *fiber.Ctx, span trace.Span, params bool, redact map[string]bool) {
+	route := c.Route()
+	span.SetAttributes(semconv.HTTPRoute(route.Path))
+	if !params {
+		return
+	}
+	for _, name := range route.Params {
+		value := c.Params(name)
+		if redact[strings.ToLower(name)] {
+			value = redactedValue
+		}
+		span.SetAttributes(attribute.String("http.route.param."+name, value))
+	}
+}
+
 // headerCarrier adapts Fiber context for OpenTelemetry propagation
 type headerCarrier struct {
 	ctx *fiber.Ctx
@@ -125,11 +221,20 @@ func (c *headerCarrier) Keys() []string {
 	return keys
 }
 
-// InjectHeaders injects trace context into outgoing request headers
+// InjectHeaders injects trace context into outgoing request headers,
+// including the tenant, user and request IDs as baggage
 func InjectHeaders(ctx *fiber.Ctx, headers map[string]string) {
 	propagator := otel.GetTextMapPropagator()
 	carrier := &mapCarrier{headers: headers}
-	propagator.Inject(ctx.UserContext(), carrier)
+	propagator.Inject(withFiberIdentityBaggage(ctx), carrier)
+}
+
+// InjectContext injects trace context and identity baggage from a plain
+// context into outgoing request headers
+func InjectContext(ctx context.Context, headers map[string]string) {
+	propagator := otel.GetTextMapPropagator()
+	carrier := &mapCarrier{headers: headers}
+	propagator.Inject(WithIdentityBaggage(ctx), carrier)
 }
 
 type mapCarrier struct {
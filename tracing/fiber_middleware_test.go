@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	appctx "github.com/minisource/go-common/context"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestProvider(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return exporter
+}
+
+func TestMiddlewareRecordsPanic(t *testing.T) {
+	exporter := newTestProvider(t)
+
+	cfg := DefaultMiddlewareConfig()
+	cfg.RecoverPanics = true
+	app := fiber.New()
+	app.Use(Middleware(cfg))
+	app.Get("/users/:id/tokens/:token", func(c *fiber.Ctx) error {
+		panic("boom")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/users/42/tokens/abc", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+	spans := exporter.GetSpans()
+	if assert.Len(t, spans, 1) {
+		span := spans[0]
+		assert.Equal(t, codes.Error, span.Status.Code)
+		assert.Contains(t, span.Attributes, attribute.String("http.route.param.id", "42"))
+		assert.Contains(t, span.Attributes, attribute.String("http.route.param.token", redactedValue))
+		assert.NotEmpty(t, span.Events)
+	}
+}
+
+func TestMiddlewareClientErrorIsNotSpanError(t *testing.T) {
+	exporter := newTestProvider(t)
+
+	app := fiber.New()
+	app.Use(Middleware(DefaultMiddlewareConfig()))
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/missing", nil))
+	assert.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	if assert.Len(t, spans, 1) {
+		assert.NotEqual(t, codes.Error, spans[0].Status.Code)
+		assert.Contains(t, spans[0].Attributes, attribute.Int("http.status_code", 404))
+	}
+}
+
+func TestMiddlewareTrustIncomingBaggage(t *testing.T) {
+	newTestProvider(t)
+	tenantID := uuid.New()
+
+	for _, trust := range []bool{false, true} {
+		cfg := DefaultMiddlewareConfig()
+		cfg.Propagators = []string{"tracecontext", "baggage"}
+		cfg.TrustIncomingBaggage = trust
+		app := fiber.New()
+		app.Use(Middleware(cfg))
+		var got uuid.UUID
+		app.Get("/", func(c *fiber.Ctx) error {
+			got, _ = appctx.GetTenantID(c.UserContext())
+			return nil
+		})
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("baggage", BaggageTenantID+"="+tenantID.String())
+		_, err := app.Test(req)
+		assert.NoError(t, err)
+		if trust {
+			assert.Equal(t, tenantID, got)
+		} else {
+			assert.Equal(t, uuid.Nil, got)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package tracing
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPluginConfig configures database tracing
+type GormPluginConfig struct {
+	TracerName string
+	// DBSystem is reported as db.system (e.g. "postgresql")
+	DBSystem string
+	// RecordStatement adds the SQL with placeholders (never the values)
+	RecordStatement bool
+}
+
+// DefaultGormPluginConfig returns default database tracing configuration
+func DefaultGormPluginConfig() GormPluginConfig {
+	return GormPluginConfig{
+		TracerName:      "gorm",
+		DBSystem:        "postgresql",
+		RecordStatement: true,
+	}
+}
+
+// GormPlugin creates a child span of the request span for every query.
+// Pass the request context with db.WithContext(ctx) to link them.
+type GormPlugin struct {
+	cfg    GormPluginConfig
+	tracer trace.Tracer
+}
+
+// NewGormPlugin creates the plugin; register it with db.Use(plugin)
+func NewGormPlugin(cfg GormPluginConfig) *GormPlugin {
+	if cfg.TracerName == "" {
+		cfg.TracerName = "gorm"
+	}
+	return &GormPlugin{cfg: cfg, tracer: otel.Tracer(cfg.TracerName)}
+}
+
+// Name implements gorm.Plugin
+func (p *GormPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize implements gorm.Plugin by registering the callbacks
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	registrations := []error{
+		cb.Create().Before("gorm:create").Register("tracing:before_create", p.beforeFunc("INSERT")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", p.after),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", p.beforeFunc("SELECT")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", p.after),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", p.beforeFunc("UPDATE")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", p.after),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", p.beforeFunc("DELETE")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", p.after),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", p.beforeFunc("")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", p.after),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", p.beforeFunc("")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", p.after),
+	}
+	for _, err := range registrations {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *GormPlugin) beforeFunc(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		p.before(db, operation)
+	}
+}
+
+func (p *GormPlugin) before(db *gorm.DB, operation string) {
+	if db.Statement == nil || db.Statement.Context == nil {
+		return
+	}
+
+	table := db.Statement.Table
+	name := "db"
+	if operation != "" {
+		name = "db." + strings.ToLower(operation)
+	}
+	if table != "" {
+		name += " " + table
+	}
+
+	ctx, span := p.tracer.Start(db.Statement.Context, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String(string(semconv.DBSystemKey), p.cfg.DBSystem),
+			semconv.DBSQLTable(table),
+		),
+	)
+	db.Statement.Context = ctx
+	db.InstanceSet(gormSpanKey, span)
+}
+
+func (p *GormPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	sql := db.Statement.SQL.String()
+	span.SetAttributes(
+		semconv.DBOperation(statementOperation(sql)),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+	if p.cfg.RecordStatement && sql != "" {
+		span.SetAttributes(semconv.DBStatement(sql))
+	}
+
+	if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
+
+// statementOperation summarizes a statement by its leading keyword
+func statementOperation(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if i := strings.IndexAny(sql, " \n\t("); i > 0 {
+		sql = sql[:i]
+	}
+	return strings.ToUpper(sql)
+}
@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// OTLP transport protocols
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+)
+
+// ShutdownFunc flushes pending spans and releases the exporter. Its
+// signature matches shutdown.Hook so it can be registered directly.
+type ShutdownFunc func(ctx context.Context) error
+
+// Init configures the global TracerProvider with an OTLP exporter, service
+// resource attributes and the configured sampler, and installs the
+// configured propagators (W3C trace context and baggage by default). When
+// tracing is disabled it leaves the no-op provider in place.
+//
+//	shutdownTracing, err := tracing.Init(ctx, cfg)
+//	manager.AddHook("tracing", shutdown.Hook(shutdownTracing), shutdown.InStage(shutdown.StageResources))
+func Init(ctx context.Context, cfg Config) (ShutdownFunc, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	propagator, err := NewPropagator(cfg.Propagators...)
+	if err != nil {
+		return nil, err
+	}
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exporter: %w", err)
+	}
+
+	res, err := newResource(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(newSpanProcessor(cfg, exporter)),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(newSampler(cfg)),
+	)
+	if cfg.SpanMetrics {
+		provider.RegisterSpanProcessor(NewSpanMetricsProcessor())
+	}
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+
+	return provider.Shutdown, nil
+}
+
+// newExporter creates an OTLP span exporter for the configured protocol.
+// Exporters connect lazily, so an unreachable collector doesn't block startup.
+func newExporter(ctx context.Context, cfg Config) (*otlptrace.Exporter, error) {
+	timeout := cfg.ExportTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	switch cfg.Protocol {
+	case "", ProtocolGRPC:
+		opts := []otlptracegrpc.Option{
+			otlptracegrpc.WithEndpoint(cfg.CollectorURL),
+			otlptracegrpc.WithTimeout(timeout),
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case ProtocolHTTP:
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(cfg.CollectorURL),
+			otlptracehttp.WithTimeout(timeout),
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol: %s", cfg.Protocol)
+	}
+}
+
+// newResource describes the service emitting spans
+func newResource(cfg Config) (*resource.Resource, error) {
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+		semconv.DeploymentEnvironment(cfg.Environment),
+		attribute.String("environment", cfg.Environment),
+	}
+	for k, v := range cfg.ResourceAttributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	// Schemaless so the merge doesn't conflict with the SDK's semconv version
+	return resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(attrs...),
+	)
+}
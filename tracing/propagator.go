@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Propagator names accepted in Config.Propagators and
+// MiddlewareConfig.Propagators
+const (
+	PropagatorTraceContext = "tracecontext"
+	PropagatorBaggage      = "baggage"
+	PropagatorB3           = "b3"      // single b3 header
+	PropagatorB3Multi      = "b3multi" // X-B3-* headers
+	PropagatorJaeger       = "jaeger"  // uber-trace-id header
+)
+
+// DefaultPropagators are used when none are configured
+var DefaultPropagators = []string{PropagatorTraceContext, PropagatorBaggage}
+
+// NewPropagator builds a composite propagator from names. Injection writes
+// every format, so traces continue across services that only understand B3
+// or Jaeger headers. Extraction also runs every format in order, and each
+// one found overrides the trace context of the previous ones, so list the
+// preferred format last.
+func NewPropagator(names ...string) (propagation.TextMapPropagator, error) {
+	if len(names) == 0 {
+		names = DefaultPropagators
+	}
+
+	propagators := make([]propagation.TextMapPropagator, 0, len(names))
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case PropagatorTraceContext:
+			propagators = append(propagators, propagation.TraceContext{})
+		case PropagatorBaggage:
+			propagators = append(propagators, propagation.Baggage{})
+		case PropagatorB3:
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)))
+		case PropagatorB3Multi:
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		case PropagatorJaeger:
+			propagators = append(propagators, jaeger.Jaeger{})
+		default:
+			return nil, fmt.Errorf("unknown propagator: %s", name)
+		}
+	}
+	return propagation.NewCompositeTextMapPropagator(propagators...), nil
+}
@@ -0,0 +1,217 @@
+package tracing
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Sampler types
+const (
+	SamplerAlways      = "always"
+	SamplerNever       = "never"
+	SamplerRatio       = "ratio"
+	SamplerRateLimited = "rate_limited"
+)
+
+// SamplerConfig selects and tunes the sampler used by Init
+type SamplerConfig struct {
+	// Type is one of always, never, ratio, rate_limited. Empty falls back
+	// to Config.SamplingRate.
+	Type string
+	// Ratio is the fraction of traces kept by the ratio sampler (0.0 to 1.0)
+	Ratio float64
+	// RatePerSecond is the number of traces kept per second by the
+	// rate-limited sampler
+	RatePerSecond float64
+	// ParentBased follows the sampling decision of the incoming parent span
+	ParentBased bool
+	// AlwaysSampleErrors exports spans ending with an error status even
+	// when their trace was not sampled
+	AlwaysSampleErrors bool
+	// SlowThreshold exports spans lasting longer than this even when their
+	// trace was not sampled (0 disables)
+	SlowThreshold time.Duration
+}
+
+// keepsOutliers reports whether unsampled spans must be recorded so errors
+// and slow spans can be exported after they end
+func (c SamplerConfig) keepsOutliers() bool {
+	return c.AlwaysSampleErrors || c.SlowThreshold > 0
+}
+
+// newSampler creates the configured sampler
+func newSampler(cfg Config) sdktrace.Sampler {
+	sc := cfg.Sampler
+
+	var sampler sdktrace.Sampler
+	switch sc.Type {
+	case SamplerAlways:
+		sampler = sdktrace.AlwaysSample()
+	case SamplerNever:
+		sampler = sdktrace.NeverSample()
+	case SamplerRatio:
+		sampler = ratioSampler(sc.Ratio)
+	case SamplerRateLimited:
+		sampler = NewRateLimitedSampler(sc.RatePerSecond)
+	default:
+		sampler = ratioSampler(cfg.SamplingRate)
+	}
+
+	if sc.ParentBased {
+		sampler = sdktrace.ParentBased(sampler)
+	}
+	// Record unsampled spans when they are still needed after they end
+	if sc.keepsOutliers() || cfg.SpanMetrics {
+		sampler = &recordingSampler{next: sampler}
+	}
+	return sampler
+}
+
+func ratioSampler(ratio float64) sdktrace.Sampler {
+	if ratio >= 1.0 {
+		return sdktrace.AlwaysSample()
+	} else if ratio <= 0.0 {
+		return sdktrace.NeverSample()
+	}
+	return sdktrace.TraceIDRatioBased(ratio)
+}
+
+// newSpanProcessor batches spans to the exporter, letting error and slow
+// outliers through when the sampler config asks for them
+func newSpanProcessor(cfg Config, exporter sdktrace.SpanExporter) sdktrace.SpanProcessor {
+	batcher := sdktrace.NewBatchSpanProcessor(exporter)
+	if !cfg.Sampler.keepsOutliers() {
+		return batcher
+	}
+	return &outlierProcessor{
+		SpanProcessor: batcher,
+		errors:        cfg.Sampler.AlwaysSampleErrors,
+		slow:          cfg.Sampler.SlowThreshold,
+	}
+}
+
+// ============================================
+// Rate-limited sampler
+// ============================================
+
+// RateLimitedSampler samples at most a fixed number of traces per second
+// using a token bucket
+type RateLimitedSampler struct {
+	mu       sync.Mutex
+	rate     float64
+	tokens   float64
+	last     time.Time
+	nowFunc  func() time.Time
+	describe string
+}
+
+// NewRateLimitedSampler creates a sampler keeping up to perSecond traces
+func NewRateLimitedSampler(perSecond float64) *RateLimitedSampler {
+	return &RateLimitedSampler{
+		rate:     perSecond,
+		tokens:   perSecond,
+		last:     time.Now(),
+		nowFunc:  time.Now,
+		describe: fmt.Sprintf("RateLimitedSampler{%g/s}", perSecond),
+	}
+}
+
+// ShouldSample implements sdktrace.Sampler
+func (s *RateLimitedSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := sdktrace.SamplingResult{
+		Decision:   sdktrace.Drop,
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+	if s.allow() {
+		result.Decision = sdktrace.RecordAndSample
+	}
+	return result
+}
+
+// Description implements sdktrace.Sampler
+func (s *RateLimitedSampler) Description() string {
+	return s.describe
+}
+
+func (s *RateLimitedSampler) allow() bool {
+	if s.rate <= 0 {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.nowFunc()
+	s.tokens += now.Sub(s.last).Seconds() * s.rate
+	// Fractional rates still need room for one whole token
+	if burst := math.Max(1, s.rate); s.tokens > burst {
+		s.tokens = burst
+	}
+	s.last = now
+
+	if s.tokens < 1 {
+		return false
+	}
+	s.tokens--
+	return true
+}
+
+// ============================================
+// Error and slow span outliers
+// ============================================
+
+// recordingSampler turns drop decisions into record-only ones so the
+// outlier and span-metrics processors can inspect unsampled spans when
+// they end
+type recordingSampler struct {
+	next sdktrace.Sampler
+}
+
+func (s *recordingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.next.ShouldSample(p)
+	if result.Decision == sdktrace.Drop {
+		result.Decision = sdktrace.RecordOnly
+	}
+	return result
+}
+
+func (s *recordingSampler) Description() string {
+	return "Outliers{" + s.next.Description() + "}"
+}
+
+// outlierProcessor exports unsampled spans that failed or were slow. Only
+// the outlier span itself is exported, not the rest of its trace.
+type outlierProcessor struct {
+	sdktrace.SpanProcessor
+	errors bool
+	slow   time.Duration
+}
+
+func (p *outlierProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.SpanContext().IsSampled() {
+		p.SpanProcessor.OnEnd(s)
+		return
+	}
+
+	isError := p.errors && s.Status().Code == codes.Error
+	isSlow := p.slow > 0 && s.EndTime().Sub(s.StartTime()) >= p.slow
+	if isError || isSlow {
+		p.SpanProcessor.OnEnd(sampledSpan{ReadOnlySpan: s})
+	}
+}
+
+// sampledSpan marks a record-only span as sampled so the batcher exports it
+type sampledSpan struct {
+	sdktrace.ReadOnlySpan
+}
+
+func (s sampledSpan) SpanContext() trace.SpanContext {
+	sc := s.ReadOnlySpan.SpanContext()
+	return sc.WithTraceFlags(sc.TraceFlags().WithSampled(true))
+}
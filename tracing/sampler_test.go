@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRateLimitedSampler(t *testing.T) {
+	now := time.Now()
+	s := NewRateLimitedSampler(2)
+	s.nowFunc = func() time.Time { return now }
+	s.last = now
+
+	assert.True(t, s.allow())
+	assert.True(t, s.allow())
+	assert.False(t, s.allow())
+
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, s.allow())
+	assert.False(t, s.allow())
+}
+
+func TestRateLimitedSamplerFractionalRate(t *testing.T) {
+	now := time.Now()
+	s := NewRateLimitedSampler(0.5)
+	s.nowFunc = func() time.Time { return now }
+	s.last = now
+
+	assert.False(t, s.allow())
+	now = now.Add(2 * time.Second)
+	assert.True(t, s.allow())
+	assert.False(t, s.allow())
+
+	// Idle time doesn't build up more than one trace
+	now = now.Add(time.Minute)
+	assert.True(t, s.allow())
+	assert.False(t, s.allow())
+}
+
+func TestOutlierSamplingExportsErrorsOnly(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	cfg := Config{Sampler: SamplerConfig{Type: SamplerNever, AlwaysSampleErrors: true}}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(newSampler(cfg)),
+		sdktrace.WithSpanProcessor(newSpanProcessor(cfg, exporter)),
+	)
+	tracer := provider.Tracer("test")
+
+	_, ok := tracer.Start(context.Background(), "ok")
+	ok.End()
+
+	_, failed := tracer.Start(context.Background(), "failed")
+	failed.SetStatus(codes.Error, "boom")
+	failed.End()
+
+	assert.NoError(t, provider.ForceFlush(context.Background()))
+	spans := exporter.GetSpans()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "failed", spans[0].Name)
+	}
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	appctx "github.com/minisource/go-common/context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the tracer name used by the span helpers
+const instrumentationName = "github.com/minisource/go-common/tracing"
+
+// Attribute keys shared by all services
+const (
+	AttrTenantID   = attribute.Key("tenant.id")
+	AttrUserID     = attribute.Key("user.id")
+	AttrEntityType = attribute.Key("entity.type")
+	AttrEntityID   = attribute.Key("entity.id")
+	AttrOperation  = attribute.Key("operation")
+)
+
+// Tenant returns the tenant attribute
+func Tenant(id uuid.UUID) attribute.KeyValue {
+	return AttrTenantID.String(id.String())
+}
+
+// User returns the user attribute
+func User(id uuid.UUID) attribute.KeyValue {
+	return AttrUserID.String(id.String())
+}
+
+// Entity returns the entity type and ID attributes
+func Entity(entityType string, id interface{}) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		AttrEntityType.String(entityType),
+		AttrEntityID.String(fmt.Sprint(id)),
+	}
+}
+
+// Operation returns the operation attribute (e.g. "create_order")
+func Operation(name string) attribute.KeyValue {
+	return AttrOperation.String(name)
+}
+
+// StartSpan starts an internal span. The tenant and user from the context
+// package are added automatically; end the span with span.End().
+//
+//	ctx, span := tracing.StartSpan(ctx, "orders.Create", tracing.Operation("create"))
+//	defer span.End()
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name,
+		trace.WithAttributes(append(contextAttributes(ctx), attrs...)...),
+	)
+}
+
+// WithSpan runs fn inside a span, recording a returned error or a panic
+// (which is re-raised) on it
+func WithSpan(ctx context.Context, name string, fn func(ctx context.Context) error, attrs ...attribute.KeyValue) (err error) {
+	ctx, span := StartSpan(ctx, name, attrs...)
+	defer func() {
+		if r := recover(); r != nil {
+			recordPanic(span, r)
+			span.End()
+			panic(r)
+		}
+		span.End()
+	}()
+
+	err = fn(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// contextAttributes reads the identity attributes held by the context package
+func contextAttributes(ctx context.Context) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if tenantID, ok := appctx.GetTenantID(ctx); ok && tenantID != uuid.Nil {
+		attrs = append(attrs, Tenant(tenantID))
+	}
+	if userID, ok := appctx.GetUserID(ctx); ok && userID != uuid.Nil {
+		attrs = append(attrs, User(userID))
+	}
+	return attrs
+}
+
+// recordPanic records a recovered panic as an error event on the span
+func recordPanic(span trace.Span, r interface{}) {
+	err, ok := r.(error)
+	if !ok {
+		err = fmt.Errorf("panic: %v", r)
+	}
+	span.RecordError(err, trace.WithStackTrace(true), trace.WithAttributes(attribute.Bool("panic", true)))
+	span.SetStatus(codes.Error, err.Error())
+}
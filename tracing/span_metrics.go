@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/minisource/go-common/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanMetricsProcessor derives RED metrics (requests, errors, duration)
+// from server spans. Duration observations of sampled spans carry the trace
+// ID as an exemplar; expose them with an OpenMetrics-enabled handler.
+type SpanMetricsProcessor struct{}
+
+// NewSpanMetricsProcessor creates the processor; register it with
+// sdktrace.WithSpanProcessor or enable Config.SpanMetrics
+func NewSpanMetricsProcessor() *SpanMetricsProcessor {
+	return &SpanMetricsProcessor{}
+}
+
+// OnStart implements sdktrace.SpanProcessor
+func (p *SpanMetricsProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+// OnEnd implements sdktrace.SpanProcessor
+func (p *SpanMetricsProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.SpanKind() != trace.SpanKindServer {
+		return
+	}
+
+	route, method, status := "", "", ""
+	for _, attr := range s.Attributes() {
+		switch attr.Key {
+		case semconv.HTTPRouteKey:
+			route = attr.Value.AsString()
+		case semconv.HTTPMethodKey:
+			method = attr.Value.AsString()
+		case semconv.HTTPStatusCodeKey:
+			status = strconv.FormatInt(attr.Value.AsInt64(), 10)
+		}
+	}
+	if route == "" {
+		route = s.Name()
+	}
+
+	labels := prometheus.Labels{"route": route, "method": method, "status_code": status}
+	metrics.SpanRequestsTotal.With(labels).Inc()
+	if s.Status().Code == codes.Error {
+		metrics.SpanErrorsTotal.With(labels).Inc()
+	}
+
+	seconds := s.EndTime().Sub(s.StartTime()).Seconds()
+	observer := metrics.SpanDurationSeconds.With(labels)
+	if sc := s.SpanContext(); sc.IsSampled() {
+		if eo, ok := observer.(prometheus.ExemplarObserver); ok {
+			eo.ObserveWithExemplar(seconds, prometheus.Labels{"trace_id": sc.TraceID().String()})
+			return
+		}
+	}
+	observer.Observe(seconds)
+}
+
+// Shutdown implements sdktrace.SpanProcessor
+func (p *SpanMetricsProcessor) Shutdown(context.Context) error { return nil }
+
+// ForceFlush implements sdktrace.SpanProcessor
+func (p *SpanMetricsProcessor) ForceFlush(context.Context) error { return nil }
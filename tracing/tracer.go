@@ -3,14 +3,15 @@ package tracing
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/minisource/go-common/metrics"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -24,6 +25,26 @@ type Config struct {
 	CollectorURL   string  // OTLP collector URL (e.g., "localhost:4317")
 	SamplingRate   float64 // 0.0 to 1.0
 	Enabled        bool
+
+	// Protocol is the OTLP transport used by Init: "grpc" (default) or "http"
+	Protocol string
+	// Insecure disables TLS towards the collector
+	Insecure bool
+	// Headers are sent with every export (e.g. collector auth)
+	Headers map[string]string
+	// ExportTimeout bounds a single export call
+	ExportTimeout time.Duration
+	// ResourceAttributes are added to the service resource
+	ResourceAttributes map[string]string
+	// Sampler overrides SamplingRate with a configurable sampler
+	Sampler SamplerConfig
+	// Propagators lists the header formats to accept and emit
+	// (tracecontext, baggage, b3, b3multi, jaeger); when a request carries
+	// several, the last one listed wins
+	Propagators []string
+	// SpanMetrics derives RED metrics from every server span, including
+	// unsampled ones (see SpanMetricsProcessor)
+	SpanMetrics bool
 }
 
 // DefaultConfig returns default tracing configuration
@@ -35,7 +56,32 @@ func DefaultConfig() Config {
 		CollectorURL:   "localhost:4317",
 		SamplingRate:   1.0,
 		Enabled:        false,
+		Protocol:       ProtocolGRPC,
+		Insecure:       true,
+		ExportTimeout:  10 * time.Second,
+	}
+}
+
+// MetricsConfig derives OTLP metric export from the tracing settings, so
+// traces and metrics share one collector configuration. Export is enabled
+// with tracing
+func (c Config) MetricsConfig() metrics.OTLPConfig {
+	cfg := metrics.DefaultOTLPConfig()
+	cfg.Enabled = c.Enabled
+	cfg.Endpoint = c.CollectorURL
+	if c.Protocol != "" {
+		cfg.Protocol = c.Protocol
+	}
+	cfg.Insecure = c.Insecure
+	cfg.Headers = c.Headers
+	if c.ExportTimeout > 0 {
+		cfg.Timeout = c.ExportTimeout
 	}
+	cfg.ServiceName = c.ServiceName
+	cfg.ServiceVersion = c.ServiceVersion
+	cfg.Environment = c.Environment
+	cfg.ResourceAttributes = c.ResourceAttributes
+	return cfg
 }
 
 // Tracer wraps OpenTelemetry tracer
@@ -70,35 +116,23 @@ func InitTracer(ctx context.Context, cfg Config) (*Tracer, error) {
 	}
 
 	// Create resource with service information
-	res, err := resource.Merge(
-		resource.Default(),
-		resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceName(cfg.ServiceName),
-			semconv.ServiceVersion(cfg.ServiceVersion),
-			attribute.String("environment", cfg.Environment),
-		),
-	)
+	res, err := newResource(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create resource: %w", err)
 	}
 
 	// Create sampler
-	var sampler sdktrace.Sampler
-	if cfg.SamplingRate >= 1.0 {
-		sampler = sdktrace.AlwaysSample()
-	} else if cfg.SamplingRate <= 0.0 {
-		sampler = sdktrace.NeverSample()
-	} else {
-		sampler = sdktrace.TraceIDRatioBased(cfg.SamplingRate)
-	}
+	sampler := newSampler(cfg)
 
 	// Create trace provider
 	provider := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSpanProcessor(newSpanProcessor(cfg, exporter)),
 		sdktrace.WithResource(res),
 		sdktrace.WithSampler(sampler),
 	)
+	if cfg.SpanMetrics {
+		provider.RegisterSpanProcessor(NewSpanMetricsProcessor())
+	}
 
 	// Set global provider and propagator
 	otel.SetTracerProvider(provider)
@@ -144,10 +178,14 @@ func SetAttributes(ctx context.Context, attrs ...attribute.KeyValue) {
 	span.SetAttributes(attrs...)
 }
 
-// RecordError records an error on the current span
-func RecordError(ctx context.Context, err error) {
+// RecordError records an error on the current span and marks it failed
+func RecordError(ctx context.Context, err error, attrs ...attribute.KeyValue) {
+	if err == nil {
+		return
+	}
 	span := trace.SpanFromContext(ctx)
-	span.RecordError(err)
+	span.RecordError(err, trace.WithAttributes(attrs...))
+	span.SetStatus(codes.Error, err.Error())
 }
 
 // GetTraceID returns the trace ID from context
@@ -0,0 +1,55 @@
+package validation
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/minisource/go-common/common"
+)
+
+// NationalCodeValidator validates an Iranian national code check digit
+func NationalCodeValidator(fld validator.FieldLevel) bool {
+	value, ok := fld.Field().Interface().(string)
+	if !ok {
+		return false
+	}
+
+	return common.ValidateIranianNationalCode(value)
+}
+
+// ShebaValidator validates an Iranian IBAN (Sheba)
+func ShebaValidator(fld validator.FieldLevel) bool {
+	value, ok := fld.Field().Interface().(string)
+	if !ok {
+		return false
+	}
+
+	return common.ValidateSheba(value)
+}
+
+// IBANValidator validates an IBAN from any country
+func IBANValidator(fld validator.FieldLevel) bool {
+	value, ok := fld.Field().Interface().(string)
+	if !ok {
+		return false
+	}
+
+	return common.ValidateIBAN(value)
+}
+
+// BankCardValidator validates a payment card number with the Luhn checksum
+func BankCardValidator(fld validator.FieldLevel) bool {
+	value, ok := fld.Field().Interface().(string)
+	if !ok {
+		return false
+	}
+
+	return common.ValidateBankCard(value)
+}
+
+// RegisterAll registers the shared custom validations (national_code,
+// sheba, iban, bank_card) on v
+func RegisterAll(v *validator.Validate) {
+	_ = v.RegisterValidation("national_code", NationalCodeValidator)
+	_ = v.RegisterValidation("sheba", ShebaValidator)
+	_ = v.RegisterValidation("iban", IBANValidator)
+	_ = v.RegisterValidation("bank_card", BankCardValidator)
+}
@@ -0,0 +1,28 @@
+package validation
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/minisource/go-common/common"
+)
+
+// PhoneNumberValidator validates a phone number in any supported region.
+// The tag param is the default region for national format, e.g. `validate:"phone=IR"`
+func PhoneNumberValidator(fld validator.FieldLevel) bool {
+	value, ok := fld.Field().Interface().(string)
+	if !ok {
+		return false
+	}
+
+	return common.ValidatePhone(value, common.PhoneParseOptions{DefaultRegion: fld.Param()})
+}
+
+// MobileNumberValidator validates a mobile number in any supported region.
+// The tag param is the default region for national format, e.g. `validate:"intl_mobile=IR"`
+func MobileNumberValidator(fld validator.FieldLevel) bool {
+	value, ok := fld.Field().Interface().(string)
+	if !ok {
+		return false
+	}
+
+	return common.ValidateMobile(value, fld.Param())
+}
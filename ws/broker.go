@@ -0,0 +1,121 @@
+package ws
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Broker carries hub messages between replicas, so a message sent on any
+// replica reaches the connections of all of them
+type Broker interface {
+	// Publish sends payload to every subscriber, including other replicas
+	Publish(ctx context.Context, payload []byte) error
+	// Subscribe calls fn with every published payload until ctx is done
+	// or the subscription fails
+	Subscribe(ctx context.Context, fn func(payload []byte)) error
+}
+
+// Message targets
+const (
+	kindAll    = "all"
+	kindUser   = "user"
+	kindTenant = "tenant"
+	kindRoom   = "room"
+)
+
+// envelope is a message published to the broker
+type envelope struct {
+	Origin string `json:"origin"`
+	Kind   string `json:"kind"`
+	Target string `json:"target,omitempty"`
+	Data   []byte `json:"data"`
+}
+
+// ============================================
+// Memory broker
+// ============================================
+
+// MemoryBroker connects hubs of a single process, e.g. in tests
+type MemoryBroker struct {
+	mu   sync.RWMutex
+	subs map[int]func([]byte)
+	next int
+}
+
+// NewMemoryBroker creates an in-memory broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subs: make(map[int]func([]byte))}
+}
+
+// Publish implements Broker
+func (b *MemoryBroker) Publish(ctx context.Context, payload []byte) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, fn := range b.subs {
+		fn(payload)
+	}
+	return nil
+}
+
+// Subscribe implements Broker
+func (b *MemoryBroker) Subscribe(ctx context.Context, fn func(payload []byte)) error {
+	b.mu.Lock()
+	id := b.next
+	b.next++
+	b.subs[id] = fn
+	b.mu.Unlock()
+
+	<-ctx.Done()
+	b.mu.Lock()
+	delete(b.subs, id)
+	b.mu.Unlock()
+	return ctx.Err()
+}
+
+// ============================================
+// Redis broker
+// ============================================
+
+// RedisBroker fans hub messages out over a Redis pub/sub channel
+type RedisBroker struct {
+	client  redis.UniversalClient
+	channel string
+}
+
+// NewRedisBroker creates a Redis pub/sub broker on channel
+// (default "ws:messages"); use one channel per hub
+func NewRedisBroker(client redis.UniversalClient, channel string) *RedisBroker {
+	if channel == "" {
+		channel = "ws:messages"
+	}
+	return &RedisBroker{client: client, channel: channel}
+}
+
+// Publish implements Broker
+func (b *RedisBroker) Publish(ctx context.Context, payload []byte) error {
+	return b.client.Publish(ctx, b.channel, payload).Err()
+}
+
+// Subscribe implements Broker
+func (b *RedisBroker) Subscribe(ctx context.Context, fn func(payload []byte)) error {
+	sub := b.client.Subscribe(ctx, b.channel)
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		return err
+	}
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-messages:
+			if !ok {
+				return redis.ErrClosed
+			}
+			fn([]byte(msg.Payload))
+		}
+	}
+}
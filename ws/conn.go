@@ -0,0 +1,195 @@
+package ws
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	fiberws "github.com/gofiber/websocket/v2"
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/metrics"
+)
+
+// Conn is a WebSocket connection of a hub. Messages are queued and written
+// by one goroutine per connection, so Send never blocks on a slow client
+type Conn struct {
+	hub      *Hub
+	ws       *fiberws.Conn
+	id       string
+	userID   string
+	tenantID string
+	rooms    map[string]struct{} // guarded by hub.mu
+
+	send       chan []byte
+	done       chan struct{}
+	writerDone chan struct{}
+	closeOnce  sync.Once
+	closeCode  int
+	closeText  string
+}
+
+func newConn(h *Hub, ws *fiberws.Conn) *Conn {
+	userID, _ := ws.Locals("userId").(string)
+	tenantID, _ := ws.Locals("tenantId").(string)
+	return &Conn{
+		hub:        h,
+		ws:         ws,
+		id:         randomID(),
+		userID:     userID,
+		tenantID:   tenantID,
+		rooms:      make(map[string]struct{}),
+		send:       make(chan []byte, h.cfg.SendBuffer),
+		done:       make(chan struct{}),
+		writerDone: make(chan struct{}),
+	}
+}
+
+// ID returns the connection ID
+func (c *Conn) ID() string {
+	return c.id
+}
+
+// UserID returns the authenticated user, empty for anonymous connections
+func (c *Conn) UserID() string {
+	return c.userID
+}
+
+// TenantID returns the tenant of the user, if any
+func (c *Conn) TenantID() string {
+	return c.tenantID
+}
+
+// Locals returns a Fiber local of the upgrade request; only valid while
+// the connection is open
+func (c *Conn) Locals(key string) interface{} {
+	return c.ws.Locals(key)
+}
+
+// Join adds the connection to room
+func (c *Conn) Join(room string) {
+	c.hub.join(c, room)
+}
+
+// Leave removes the connection from room
+func (c *Conn) Leave(room string) {
+	c.hub.leave(c, room)
+}
+
+// Send queues data as a text message. When the send buffer is full the
+// message is dropped and, unless the hub drops messages, the connection is
+// closed as a slow consumer
+func (c *Conn) Send(data []byte) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	select {
+	case c.send <- data:
+		return nil
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	metrics.WebSocketMessagesTotal.WithLabelValues(c.hub.cfg.Name, "dropped").Inc()
+	if !c.hub.cfg.DropWhenFull {
+		c.logWarn("Closing slow WebSocket consumer")
+		c.closeWith(websocket.CloseTryAgainLater, "slow consumer")
+	}
+	return ErrSlowConsumer
+}
+
+// SendJSON queues v encoded as JSON
+func (c *Conn) SendJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Send(data)
+}
+
+// Close closes the connection
+func (c *Conn) Close() {
+	c.closeWith(websocket.CloseNormalClosure, "")
+}
+
+// closeWith makes the writer send a close frame with code and end the
+// connection; only the first call has an effect
+func (c *Conn) closeWith(code int, text string) {
+	c.closeOnce.Do(func() {
+		c.closeCode, c.closeText = code, text
+		close(c.done)
+	})
+}
+
+// readLoop reads client messages until the connection fails or goes quiet
+// for longer than the pong timeout
+func (c *Conn) readLoop() {
+	cfg := c.hub.cfg
+	c.ws.SetReadLimit(cfg.MaxMessageSize)
+	_ = c.ws.SetReadDeadline(time.Now().Add(cfg.PongTimeout))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(cfg.PongTimeout))
+	})
+
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = c.ws.SetReadDeadline(time.Now().Add(cfg.PongTimeout))
+		metrics.WebSocketMessagesTotal.WithLabelValues(cfg.Name, "in").Inc()
+		if cfg.OnMessage != nil {
+			cfg.OnMessage(c, data)
+		}
+	}
+}
+
+// writeLoop writes queued messages and pings until the connection closes
+func (c *Conn) writeLoop() {
+	cfg := c.hub.cfg
+	ticker := time.NewTicker(cfg.PingInterval)
+	defer func() {
+		ticker.Stop()
+		// Unblocks the reader
+		_ = c.ws.Close()
+		close(c.writerDone)
+	}()
+
+	for {
+		select {
+		case data := <-c.send:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(cfg.WriteTimeout))
+			if err := c.ws.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.closeWith(websocket.CloseAbnormalClosure, "")
+				return
+			}
+			metrics.WebSocketMessagesTotal.WithLabelValues(cfg.Name, "out").Inc()
+		case <-ticker.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(cfg.WriteTimeout)); err != nil {
+				c.closeWith(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-c.done:
+			_ = c.ws.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(c.closeCode, c.closeText),
+				time.Now().Add(cfg.WriteTimeout))
+			return
+		}
+	}
+}
+
+func (c *Conn) logWarn(msg string) {
+	if c.hub.cfg.Logger == nil {
+		return
+	}
+	c.hub.cfg.Logger.Warn(logging.Internal, logging.WebSocket, msg, map[logging.ExtraKey]interface{}{
+		logging.Name:     c.hub.cfg.Name,
+		logging.ID:       c.id,
+		logging.UserID:   c.userID,
+		logging.TenantID: c.tenantID,
+	})
+}
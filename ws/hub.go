@@ -0,0 +1,384 @@
+package ws
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	fiberws "github.com/gofiber/websocket/v2"
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/metrics"
+	"github.com/minisource/go-common/response"
+)
+
+var (
+	// ErrClosed is returned when sending to a closed connection
+	ErrClosed = errors.New("ws: connection closed")
+	// ErrSlowConsumer is returned when a connection's send buffer is full
+	ErrSlowConsumer = errors.New("ws: send buffer full")
+)
+
+// Config configures a hub
+type Config struct {
+	// Name labels the hub's metrics and logs (default "default")
+	Name string
+	// SendBuffer is the number of messages queued per connection
+	// (default 64)
+	SendBuffer int
+	// DropWhenFull drops messages for a connection whose buffer is full;
+	// by default such a slow consumer is disconnected so it can reconnect
+	// and resync
+	DropWhenFull bool
+	// PingInterval is how often connections are pinged (default 30s)
+	PingInterval time.Duration
+	// PongTimeout closes a connection that sent nothing, not even a pong,
+	// for this long (default 60s); keep it above PingInterval
+	PongTimeout time.Duration
+	// WriteTimeout bounds every write (default 10s)
+	WriteTimeout time.Duration
+	// MaxMessageSize is the largest message accepted from clients
+	// (default 64KB)
+	MaxMessageSize int64
+	// AllowAnonymous accepts connections without the userId local set by
+	// the auth middleware
+	AllowAnonymous bool
+	// Origins lists the allowed values of the Origin header; "*" allows
+	// any origin. By default only the server's own origin is accepted, as
+	// browsers send cookies with cross-site WebSocket requests
+	Origins []string
+	// Broker fans messages out to the hubs of other replicas; without it
+	// messages only reach connections of this process
+	Broker Broker
+
+	OnConnect    func(c *Conn)
+	OnMessage    func(c *Conn, data []byte)
+	OnDisconnect func(c *Conn)
+	Logger       logging.Logger
+}
+
+// DefaultConfig returns the default hub configuration
+func DefaultConfig() Config {
+	return Config{
+		Name:           "default",
+		SendBuffer:     64,
+		PingInterval:   30 * time.Second,
+		PongTimeout:    60 * time.Second,
+		WriteTimeout:   10 * time.Second,
+		MaxMessageSize: 64 << 10,
+	}
+}
+
+// Hub keeps the WebSocket connections of a process, indexed by user, tenant
+// and room, and delivers messages to them
+type Hub struct {
+	cfg Config
+	id  string
+
+	mu      sync.RWMutex
+	conns   map[*Conn]struct{}
+	users   map[string]map[*Conn]struct{}
+	tenants map[string]map[*Conn]struct{}
+	rooms   map[string]map[*Conn]struct{}
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewHub creates a hub and subscribes it to the broker, if any
+func NewHub(cfg Config) *Hub {
+	defaults := DefaultConfig()
+	if cfg.Name == "" {
+		cfg.Name = defaults.Name
+	}
+	if cfg.SendBuffer <= 0 {
+		cfg.SendBuffer = defaults.SendBuffer
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = defaults.PingInterval
+	}
+	if cfg.PongTimeout <= 0 {
+		cfg.PongTimeout = defaults.PongTimeout
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = defaults.WriteTimeout
+	}
+	if cfg.MaxMessageSize <= 0 {
+		cfg.MaxMessageSize = defaults.MaxMessageSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &Hub{
+		cfg:     cfg,
+		id:      randomID(),
+		conns:   make(map[*Conn]struct{}),
+		users:   make(map[string]map[*Conn]struct{}),
+		tenants: make(map[string]map[*Conn]struct{}),
+		rooms:   make(map[string]map[*Conn]struct{}),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	if cfg.Broker != nil {
+		go h.subscribe(ctx)
+	} else {
+		close(h.done)
+	}
+	return h
+}
+
+// Handler upgrades requests to WebSocket connections of the hub. Mount it
+// after the auth middleware; browsers can't set headers on WebSocket
+// requests, so accept the token from a query parameter or cookie there
+func (h *Hub) Handler() fiber.Handler {
+	upgrade := fiberws.New(h.serve, fiberws.Config{Origins: h.cfg.Origins})
+	return func(c *fiber.Ctx) error {
+		if !fiberws.IsWebSocketUpgrade(c) {
+			return fiber.ErrUpgradeRequired
+		}
+		if len(h.cfg.Origins) == 0 && !sameOrigin(c) {
+			return response.Forbidden(c, "Origin not allowed")
+		}
+		if userID, _ := c.Locals("userId").(string); userID == "" && !h.cfg.AllowAnonymous {
+			return response.Unauthorized(c, "Authentication required")
+		}
+		return upgrade(c)
+	}
+}
+
+// sameOrigin reports whether the Origin header, if any, names the host the
+// request was sent to. Clients other than browsers usually send none
+func sameOrigin(c *fiber.Ctx) bool {
+	origin := c.Get(fiber.HeaderOrigin)
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, c.Hostname())
+}
+
+// Broadcast sends data to every connection
+func (h *Hub) Broadcast(ctx context.Context, data []byte) error {
+	return h.send(ctx, envelope{Kind: kindAll, Data: data})
+}
+
+// SendToUser sends data to every connection of a user
+func (h *Hub) SendToUser(ctx context.Context, userID string, data []byte) error {
+	return h.send(ctx, envelope{Kind: kindUser, Target: userID, Data: data})
+}
+
+// SendToTenant sends data to every connection of a tenant
+func (h *Hub) SendToTenant(ctx context.Context, tenantID string, data []byte) error {
+	return h.send(ctx, envelope{Kind: kindTenant, Target: tenantID, Data: data})
+}
+
+// SendToRoom sends data to every connection that joined room
+func (h *Hub) SendToRoom(ctx context.Context, room string, data []byte) error {
+	return h.send(ctx, envelope{Kind: kindRoom, Target: room, Data: data})
+}
+
+// Count returns the number of connections of this process
+func (h *Hub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.conns)
+}
+
+// Close stops the broker subscription and closes every connection
+func (h *Hub) Close() error {
+	h.cancel()
+	<-h.done
+
+	h.mu.RLock()
+	conns := make([]*Conn, 0, len(h.conns))
+	for c := range h.conns {
+		conns = append(conns, c)
+	}
+	h.mu.RUnlock()
+	for _, c := range conns {
+		c.closeWith(websocket.CloseGoingAway, "server shutting down")
+	}
+	return nil
+}
+
+// send delivers to local connections and publishes to the other replicas
+func (h *Hub) send(ctx context.Context, env envelope) error {
+	h.deliver(env)
+	if h.cfg.Broker == nil {
+		return nil
+	}
+
+	env.Origin = h.id
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("ws: failed to encode message: %w", err)
+	}
+	if err := h.cfg.Broker.Publish(ctx, payload); err != nil {
+		return fmt.Errorf("ws: failed to publish message: %w", err)
+	}
+	return nil
+}
+
+// deliver queues a message on the local connections it targets
+func (h *Hub) deliver(env envelope) {
+	h.mu.RLock()
+	var targets []*Conn
+	switch env.Kind {
+	case kindAll:
+		targets = make([]*Conn, 0, len(h.conns))
+		for c := range h.conns {
+			targets = append(targets, c)
+		}
+	case kindUser:
+		targets = members(h.users[env.Target])
+	case kindTenant:
+		targets = members(h.tenants[env.Target])
+	case kindRoom:
+		targets = members(h.rooms[env.Target])
+	}
+	h.mu.RUnlock()
+
+	for _, c := range targets {
+		_ = c.Send(env.Data)
+	}
+}
+
+// subscribe delivers messages published by other replicas until Close,
+// resubscribing after broker errors
+func (h *Hub) subscribe(ctx context.Context) {
+	defer close(h.done)
+	for {
+		err := h.cfg.Broker.Subscribe(ctx, func(payload []byte) {
+			var env envelope
+			if err := json.Unmarshal(payload, &env); err != nil || env.Origin == h.id {
+				return
+			}
+			h.deliver(env)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			h.log("WebSocket broker subscription failed", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// serve runs a connection until it closes
+func (h *Hub) serve(ws *fiberws.Conn) {
+	c := newConn(h, ws)
+	h.register(c)
+	defer h.unregister(c)
+
+	go c.writeLoop()
+	if h.cfg.OnConnect != nil {
+		h.cfg.OnConnect(c)
+	}
+	c.readLoop()
+	c.closeWith(websocket.CloseNormalClosure, "")
+	<-c.writerDone
+
+	if h.cfg.OnDisconnect != nil {
+		h.cfg.OnDisconnect(c)
+	}
+}
+
+func (h *Hub) register(c *Conn) {
+	h.mu.Lock()
+	h.conns[c] = struct{}{}
+	add(h.users, c.userID, c)
+	add(h.tenants, c.tenantID, c)
+	h.mu.Unlock()
+	metrics.WebSocketConnections.WithLabelValues(h.cfg.Name).Inc()
+}
+
+func (h *Hub) unregister(c *Conn) {
+	h.mu.Lock()
+	delete(h.conns, c)
+	remove(h.users, c.userID, c)
+	remove(h.tenants, c.tenantID, c)
+	for room := range c.rooms {
+		remove(h.rooms, room, c)
+	}
+	h.mu.Unlock()
+	metrics.WebSocketConnections.WithLabelValues(h.cfg.Name).Dec()
+}
+
+func (h *Hub) join(c *Conn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.conns[c]; !ok {
+		return
+	}
+	c.rooms[room] = struct{}{}
+	add(h.rooms, room, c)
+}
+
+func (h *Hub) leave(c *Conn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(c.rooms, room)
+	remove(h.rooms, room, c)
+}
+
+func (h *Hub) log(msg string, err error) {
+	if h.cfg.Logger == nil {
+		return
+	}
+	h.cfg.Logger.Error(logging.Internal, logging.WebSocket, msg, map[logging.ExtraKey]interface{}{
+		logging.Name:         h.cfg.Name,
+		logging.ErrorMessage: err.Error(),
+	})
+}
+
+// add indexes c under key; empty keys aren't indexed
+func add(index map[string]map[*Conn]struct{}, key string, c *Conn) {
+	if key == "" {
+		return
+	}
+	set, ok := index[key]
+	if !ok {
+		set = make(map[*Conn]struct{})
+		index[key] = set
+	}
+	set[c] = struct{}{}
+}
+
+func remove(index map[string]map[*Conn]struct{}, key string, c *Conn) {
+	set, ok := index[key]
+	if !ok {
+		return
+	}
+	delete(set, c)
+	if len(set) == 0 {
+		delete(index, key)
+	}
+}
+
+func members(set map[*Conn]struct{}) []*Conn {
+	conns := make([]*Conn, 0, len(set))
+	for c := range set {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// randomID returns a random hex string
+func randomID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
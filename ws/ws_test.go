@@ -0,0 +1,185 @@
+package ws
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveHub serves hub on a random port; the user and tenant are taken from
+// the query, standing in for the auth middleware
+func serveHub(t *testing.T, hub *Hub) string {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(func(c *fiber.Ctx) error {
+		if user := c.Query("user"); user != "" {
+			c.Locals("userId", user)
+			c.Locals("tenantId", c.Query("tenant"))
+		}
+		return c.Next()
+	})
+	app.Get("/ws", hub.Handler())
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() {
+		_ = hub.Close()
+		_ = app.Shutdown()
+	})
+	return "ws://" + ln.Addr().String() + "/ws"
+}
+
+func dial(t *testing.T, url string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func read(t *testing.T, conn *websocket.Conn) string {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	_, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	return string(data)
+}
+
+func waitCount(t *testing.T, hub *Hub, n int) {
+	require.Eventually(t, func() bool { return hub.Count() == n }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestHandlerRejectsAnonymous(t *testing.T) {
+	url := serveHub(t, NewHub(Config{}))
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestHandlerChecksOrigin(t *testing.T) {
+	url := serveHub(t, NewHub(Config{}))
+	host := strings.TrimPrefix(strings.TrimSuffix(url, "/ws"), "ws://")
+
+	_, resp, err := websocket.DefaultDialer.Dial(url+"?user=alice", http.Header{"Origin": {"https://evil.example"}})
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?user=alice", http.Header{"Origin": {"http://" + host}})
+	require.NoError(t, err)
+	_ = conn.Close()
+
+	// "*" allows any origin
+	url = serveHub(t, NewHub(Config{Origins: []string{"*"}}))
+	conn, _, err = websocket.DefaultDialer.Dial(url+"?user=alice", http.Header{"Origin": {"https://other.example"}})
+	require.NoError(t, err)
+	_ = conn.Close()
+}
+
+func TestTargetedSends(t *testing.T) {
+	hub := NewHub(Config{
+		OnMessage: func(c *Conn, data []byte) {
+			c.Join(string(data))
+			_ = c.Send([]byte("joined"))
+		},
+	})
+	url := serveHub(t, hub)
+
+	alice := dial(t, url+"?user=alice&tenant=acme")
+	bob := dial(t, url+"?user=bob&tenant=acme")
+	carol := dial(t, url+"?user=carol&tenant=other")
+	waitCount(t, hub, 3)
+	ctx := context.Background()
+
+	require.NoError(t, hub.SendToUser(ctx, "bob", []byte("to bob")))
+	assert.Equal(t, "to bob", read(t, bob))
+
+	require.NoError(t, hub.SendToTenant(ctx, "acme", []byte("to acme")))
+	assert.Equal(t, "to acme", read(t, alice))
+	assert.Equal(t, "to acme", read(t, bob))
+
+	require.NoError(t, carol.WriteMessage(websocket.TextMessage, []byte("orders")))
+	assert.Equal(t, "joined", read(t, carol))
+	require.NoError(t, hub.SendToRoom(ctx, "orders", []byte("to orders")))
+	assert.Equal(t, "to orders", read(t, carol))
+
+	require.NoError(t, hub.Broadcast(ctx, []byte("to all")))
+	for _, conn := range []*websocket.Conn{alice, bob, carol} {
+		assert.Equal(t, "to all", read(t, conn))
+	}
+
+	require.NoError(t, carol.Close())
+	waitCount(t, hub, 2)
+	hub.mu.RLock()
+	assert.Empty(t, hub.rooms)
+	hub.mu.RUnlock()
+}
+
+func TestBrokerFansOutAcrossHubs(t *testing.T) {
+	broker := NewMemoryBroker()
+	a := NewHub(Config{Broker: broker})
+	b := NewHub(Config{Broker: broker})
+	urlA, urlB := serveHub(t, a), serveHub(t, b)
+
+	onA := dial(t, urlA+"?user=alice")
+	onB := dial(t, urlB+"?user=alice")
+	waitCount(t, a, 1)
+	waitCount(t, b, 1)
+	require.Eventually(t, func() bool {
+		broker.mu.RLock()
+		defer broker.mu.RUnlock()
+		return len(broker.subs) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, a.SendToUser(context.Background(), "alice", []byte("hi")))
+	assert.Equal(t, "hi", read(t, onA))
+	assert.Equal(t, "hi", read(t, onB))
+
+	// The sending hub delivers once, not again from the broker
+	require.NoError(t, onA.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+	_, _, err := onA.ReadMessage()
+	assert.Error(t, err)
+}
+
+func TestHeartbeatKeepsConnectionOpen(t *testing.T) {
+	hub := NewHub(Config{PingInterval: 20 * time.Millisecond, PongTimeout: 60 * time.Millisecond})
+	url := serveHub(t, hub)
+
+	conn := dial(t, url+"?user=alice")
+	waitCount(t, hub, 1)
+
+	// Reading answers pings with pongs
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = hub.Broadcast(context.Background(), []byte("still here"))
+	}()
+	assert.Equal(t, "still here", read(t, conn))
+	assert.Equal(t, 1, hub.Count())
+}
+
+func TestSlowConsumer(t *testing.T) {
+	newTestConn := func(hub *Hub) *Conn {
+		return &Conn{hub: hub, send: make(chan []byte, 1), done: make(chan struct{})}
+	}
+
+	hub := NewHub(Config{})
+	c := newTestConn(hub)
+	require.NoError(t, c.Send([]byte("1")))
+	assert.ErrorIs(t, c.Send([]byte("2")), ErrSlowConsumer)
+	assert.ErrorIs(t, c.Send([]byte("3")), ErrClosed)
+	assert.Equal(t, websocket.CloseTryAgainLater, c.closeCode)
+
+	hub = NewHub(Config{DropWhenFull: true})
+	c = newTestConn(hub)
+	require.NoError(t, c.Send([]byte("1")))
+	assert.ErrorIs(t, c.Send([]byte("2")), ErrSlowConsumer)
+	assert.ErrorIs(t, c.Send([]byte("3")), ErrSlowConsumer)
+}